@@ -10,14 +10,24 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/checkfox/go_lead/internal/alerting"
 	"github.com/checkfox/go_lead/internal/config"
 	"github.com/checkfox/go_lead/internal/database"
+	"github.com/checkfox/go_lead/internal/encryption"
 	"github.com/checkfox/go_lead/internal/handlers"
 	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/models"
 	"github.com/checkfox/go_lead/internal/queue"
 	"github.com/checkfox/go_lead/internal/repository"
+	"github.com/checkfox/go_lead/internal/selftest"
+	"github.com/checkfox/go_lead/internal/services"
+	"github.com/checkfox/go_lead/internal/tracing"
+	"github.com/checkfox/go_lead/internal/worker"
 )
 
+// version is the application version, set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
 func main() {
 	// Initialize structured logger
 	logger.Init()
@@ -29,10 +39,38 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Decode lead and job payloads with exact numbers if configured
+	models.SetJSONUseNumber(cfg.JSON.UseNumber)
+
+	// Enforce the lead status transitions, including any configured extra ones
+	statusMachine, err := models.DefaultStatusMachine().WithTransitions(cfg.LeadStatus.ExtraTransitions)
+	if err != nil {
+		log.Fatalf("Invalid lead status transitions: %v", err)
+	}
+	models.SetStatusMachine(statusMachine)
+
+	// Switch to the configured log format and level
+	if err := logger.Configure(cfg.Logging.Format, cfg.Logging.Level); err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	logger.SetTraceIDFunc(tracing.TraceID)
+
 	logger.Info(ctx, "API Server starting",
+		"version", version,
 		"host", cfg.API.Host,
 		"port", cfg.API.Port,
 		"auth_enabled", cfg.Auth.Enabled)
+	cfg.Dump(ctx)
+
+	// Initialize tracing (no-op unless OTEL_EXPORTER_OTLP_ENDPOINT is set)
+	shutdownTracing := tracing.Init(cfg.Tracing, "go_lead-api")
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			logger.Error(ctx, "Failed to flush traces", "error", err.Error())
+		}
+	}()
 
 	// Initialize database connection
 	dbWrapper, err := database.InitFromConfig(cfg)
@@ -56,44 +94,207 @@ func main() {
 		log.Fatalf("Failed to initialize queue: %v", err)
 	}
 	defer jobQueue.Close()
+	jobQueue.SetDefaultMaxAttempts(cfg.Queue.JobMaxAttempts)
 
-	logger.Info(ctx, "Queue initialized")
+	logger.Info(ctx, "Queue initialized", "job_max_attempts", cfg.Queue.JobMaxAttempts)
+
+	// Verify the service wiring before accepting work
+	if cfg.SelfTest.Enabled {
+		if _, err := selftest.Run(ctx, selftest.Deps{
+			DB:             dbWrapper,
+			MigrationsPath: "./migrations",
+			Config:         cfg,
+		}); err != nil {
+			log.Fatalf("Startup self-test failed: %v", err)
+		}
+	}
 
 	// Initialize repositories
-	leadRepo := repository.NewLeadRepository(dbWrapper.DB)
-	deliveryAttemptRepo := repository.NewDeliveryAttemptRepository(dbWrapper.DB)
+	writeRetryPolicy := repository.WriteRetryPolicy{
+		MaxAttempts: cfg.Database.WriteRetryAttempts,
+		Backoff:     cfg.Database.WriteRetryBackoff,
+	}
+	leadRepo := repository.NewLeadRepositoryWithRetry(dbWrapper.DB, writeRetryPolicy)
+	if cfg.Tenant.Enabled {
+		leadRepo = repository.NewTenantScopedLeadRepository(dbWrapper.DB, writeRetryPolicy)
+	}
+	deliveryAttemptRepo := repository.NewDeliveryAttemptRepositoryWithRetry(dbWrapper.DB, writeRetryPolicy)
+	// Admin and Customer API callers act across tenants, so they use an unscoped repository
+	unscopedLeadRepo := repository.NewLeadRepositoryWithRetry(dbWrapper.DB, writeRetryPolicy)
+	normalizationAuditRepo := repository.NewNormalizationAuditRepository(dbWrapper.DB)
+
+	// Store PII fields of lead payloads encrypted when an encryption key is configured
+	fieldEncryptor, err := encryption.NewFieldEncryptorFromConfig(cfg.Privacy)
+	if err != nil {
+		log.Fatalf("Failed to initialize field encryption: %v", err)
+	}
+	if fieldEncryptor != nil {
+		leadRepo = repository.NewEncryptedLeadRepository(leadRepo, fieldEncryptor)
+		unscopedLeadRepo = repository.NewEncryptedLeadRepository(unscopedLeadRepo, fieldEncryptor)
+		normalizationAuditRepo = repository.NewEncryptedNormalizationAuditRepository(normalizationAuditRepo, fieldEncryptor)
+	}
 
 	// Initialize handlers
-	webhookHandler := handlers.NewWebhookHandler(leadRepo, jobQueue)
+	normalizer := services.NewNormalizerFromConfig(cfg.Normalizer)
+	webhookHandler := handlers.NewWebhookHandlerWithConfig(leadRepo, jobQueue, cfg.Webhook)
+	var submissionThrottle repository.SubmissionThrottleRepository
+	throttleWindow := time.Duration(cfg.Deduplication.TimeWindowSeconds) * time.Second
+	if throttleWindow > 0 {
+		submissionThrottle = repository.NewSubmissionThrottleRepository(dbWrapper.DB)
+		webhookHandler.SetDuplicateThrottle(submissionThrottle, throttleWindow, normalizer)
+	}
+	if len(cfg.SourceQuota.Quotas) > 0 {
+		webhookHandler.SetSourceQuotas(cfg.SourceQuota)
+	}
+	if cfg.Deduplication.GracePeriodHours > 0 {
+		webhookHandler.SetDeduplicationGracePeriod(time.Duration(cfg.Deduplication.GracePeriodHours) * time.Hour)
+		webhookHandler.SetDeduplicationSampleRate(cfg.Performance.DeduplicationSampleRate)
+	}
 	statsHandler := handlers.NewStatsHandler(leadRepo, deliveryAttemptRepo)
+	statsHandler.SetNormalizer(normalizer)
+	statsHandler.SetLogObfuscator(logger.NewLogObfuscator(cfg.Privacy.ObfuscatedFields))
+	statsHandler.SetHistoryMaskedFields(cfg.Privacy.HistoryMaskedFields)
+	stuckLeadDetector := alerting.NewStuckLeadDetector(repository.NewStuckLeadRepository(dbWrapper.DB), cfg.Alerting)
+	statsHandler.SetStuckLeadSource(stuckLeadDetector)
+	statsHandler.SetNormalizationAuditRepository(normalizationAuditRepo)
+	adminHandler := handlers.NewAdminHandler(jobQueue, unscopedLeadRepo)
+	mappingWatcher := config.NewMappingWatcher(cfg, cfg.Worker.MappingReloadInterval)
+	webhookHandler.SetAttachmentMapping(mappingWatcher)
+	mappingHandler := handlers.NewMappingHandler(mappingWatcher, cfg.CustomerAPI.ProductName)
+	exportRepo := repository.NewLeadExportRepository(dbWrapper.DB)
+	if cfg.Tenant.Enabled {
+		exportRepo = repository.NewTenantScopedLeadExportRepository(dbWrapper.DB)
+	}
+	exportHandler := handlers.NewExportHandler(exportRepo, cfg.Export.MaxRows)
+	migrationRunner := database.NewMigrationRunner(dbWrapper, "./migrations")
+	readinessHandler := handlers.NewReadinessHandler(handlers.ReadinessConfig{
+		Migrations:        migrationRunner,
+		Queue:             jobQueue,
+		MaxPendingJobs:    cfg.Health.MaxPendingJobs,
+		CustomerAPIURL:    cfg.CustomerAPI.URL,
+		CustomerAPIMethod: cfg.Health.CustomerAPIMethod,
+		CheckTimeout:      cfg.Health.CheckTimeout,
+
+		SchemaBehindDegraded: cfg.Health.SchemaBehindDegraded,
+	})
+	versionHandler := handlers.NewVersionHandler(version, migrationRunner)
+	callbackHandler := handlers.NewCallbackHandler(unscopedLeadRepo, deliveryAttemptRepo, jobQueue, cfg.Retry)
+	// Senders are notified of confirmed outcomes like the worker notifies them of synchronous ones
+	if cfg.Auth.SharedSecret != "" {
+		notifier, err := worker.NewNotificationWorker(worker.NotificationWorkerConfig{
+			Queue:               jobQueue,
+			LeadRepo:            unscopedLeadRepo,
+			CallbackAttemptRepo: repository.NewCallbackAttemptRepositoryWithRetry(dbWrapper.DB, writeRetryPolicy),
+			SharedSecret:        cfg.Auth.SharedSecret,
+			DefaultCallbackURL:  cfg.Callback.URL,
+			SourceCallbackURLs:  cfg.Callback.SourceURLs,
+			AllowedHosts:        cfg.Callback.AllowedHosts,
+		})
+		if err != nil {
+			log.Fatalf("Failed to create notification worker: %v", err)
+		}
+		callbackHandler.SetOutcomeNotifier(notifier)
+	}
 
 	// Initialize middleware
 	authMiddleware := handlers.NewAuthMiddleware(cfg)
 	recoveryMiddleware := handlers.NewRecoveryMiddleware()
+	tenantMiddleware := handlers.NewTenantMiddleware(cfg)
+	checksumMiddleware := handlers.NewChecksumMiddleware(cfg.Webhook.MaxBodyBytes)
+	timeoutMiddleware := handlers.NewTimeoutMiddleware(cfg.API.HandlerTimeout)
+	ipAllowlistMiddleware := handlers.NewIPAllowlistMiddleware(cfg)
+	concurrencyLimitMiddleware := handlers.NewConcurrencyLimitMiddleware(cfg.API.MaxInflight)
 
 	// Set up HTTP routes
 	mux := http.NewServeMux()
 
-	// Webhook endpoint with authentication and recovery middleware
+	// Webhook endpoint with IP allowlist, authentication and recovery middleware. The
+	// concurrency limit sits inside the timeout so a timed-out handler keeps its slot until it returns.
 	mux.HandleFunc("/webhooks/leads",
 		recoveryMiddleware.Recover(
-			authMiddleware.Authenticate(
-				webhookHandler.HandleLeadWebhook)))
+			timeoutMiddleware.Timeout(
+				concurrencyLimitMiddleware.Limit(
+					ipAllowlistMiddleware.Allow(
+						authMiddleware.AuthenticateTenant(
+							tenantMiddleware.RequireTenant(
+								checksumMiddleware.VerifyChecksum(
+									webhookHandler.HandleLeadWebhook))))))))
 
-	// Stats endpoints
+	// Async delivery confirmation callback from the Customer API
+	mux.HandleFunc("/callbacks/delivery-confirmation",
+		recoveryMiddleware.Recover(
+			timeoutMiddleware.Timeout(
+				authMiddleware.Authenticate(
+					callbackHandler.HandleDeliveryConfirmation))))
+
+	// Stats endpoints. The tenant of these routes is the one bound to the caller's secret,
+	// so each is authenticated before its tenant is resolved.
 	mux.HandleFunc("/stats/leads/counts",
-		recoveryMiddleware.Recover(statsHandler.HandleLeadCountsByStatus))
+		recoveryMiddleware.Recover(timeoutMiddleware.Timeout(authMiddleware.AuthenticateTenant(tenantMiddleware.RequireTenant(statsHandler.HandleLeadCountsByStatus)))))
 	mux.HandleFunc("/stats/leads/recent",
-		recoveryMiddleware.Recover(statsHandler.HandleRecentLeads))
+		recoveryMiddleware.Recover(timeoutMiddleware.Timeout(authMiddleware.AuthenticateTenant(tenantMiddleware.RequireTenant(statsHandler.HandleRecentLeads)))))
+	// The contact search reveals personal data, so it requires authentication like the admin endpoints
+	mux.HandleFunc("/stats/leads/search",
+		recoveryMiddleware.Recover(timeoutMiddleware.Timeout(authMiddleware.AuthenticateTenant(tenantMiddleware.RequireTenant(statsHandler.HandleSearchLeads)))))
 	mux.HandleFunc("/stats/leads/", // Handles /stats/leads/{id}/history
-		recoveryMiddleware.Recover(statsHandler.HandleLeadHistory))
+		recoveryMiddleware.Recover(timeoutMiddleware.Timeout(authMiddleware.AuthenticateTenant(tenantMiddleware.RequireTenant(statsHandler.HandleLeadHistory)))))
+
+	// Stuck leads across all tenants, an operational view like the admin endpoints
+	mux.HandleFunc("/stats/stuck-leads",
+		recoveryMiddleware.Recover(timeoutMiddleware.Timeout(authMiddleware.Authenticate(statsHandler.HandleStuckLeads))))
 
-	// Health check endpoint
+	// Admin endpoints (queue inspection and delivery re-trigger)
+	mux.HandleFunc("/admin/queue/pending",
+		recoveryMiddleware.Recover(timeoutMiddleware.Timeout(authMiddleware.Authenticate(adminHandler.HandlePendingJobs))))
+	mux.HandleFunc("/admin/leads/{id}/deliver",
+		recoveryMiddleware.Recover(timeoutMiddleware.Timeout(authMiddleware.Authenticate(adminHandler.HandleRedeliver))))
+	mux.HandleFunc("/admin/mapping",
+		recoveryMiddleware.Recover(timeoutMiddleware.Timeout(authMiddleware.Authenticate(mappingHandler.HandleMapping))))
+
+	// Lead export for offline analysis, restricted to the caller's tenant. It streams the
+	// export page by page, so it is not wrapped in the timeout middleware, which buffers the
+	// whole response.
+	mux.HandleFunc("/export/leads",
+		recoveryMiddleware.Recover(authMiddleware.AuthenticateTenant(tenantMiddleware.RequireTenant(exportHandler.HandleExportLeads))))
+
+	// Health check endpoint (liveness)
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
 
+	// Readiness endpoint checking schema, queue backlog and Customer API reachability
+	mux.HandleFunc("/health/ready", recoveryMiddleware.Recover(readinessHandler.HandleReady))
+
+	// Application and schema version, e.g. to follow a rolling deploy
+	mux.HandleFunc("/health/version", recoveryMiddleware.Recover(versionHandler.HandleVersion))
+
+	// Follow changes of the attribute mapping file like the worker, so /admin/mapping
+	// shows the mapping the worker applies
+	if cfg.Worker.MappingReloadInterval > 0 {
+		watcherCtx, stopWatcher := context.WithCancel(ctx)
+		defer stopWatcher()
+		go mappingWatcher.Run(watcherCtx)
+	}
+
+	// Delete submissions that left the throttle window, so the table does not keep every contact
+	if submissionThrottle != nil {
+		cleanupCtx, stopCleanup := context.WithCancel(ctx)
+		defer stopCleanup()
+		go runSubmissionThrottleCleanup(cleanupCtx, submissionThrottle, throttleWindow)
+	}
+
+	// Check for leads no worker retries in the background and report them as metric
+	if cfg.Alerting.StuckLeadCheckInterval > 0 {
+		detectorCtx, stopDetector := context.WithCancel(ctx)
+		defer stopDetector()
+		go stuckLeadDetector.Run(detectorCtx)
+
+		metricsHandler := handlers.NewMetricsHandler(nil)
+		metricsHandler.SetStuckLeadCount(stuckLeadDetector)
+		mux.HandleFunc("/metrics", recoveryMiddleware.Recover(metricsHandler.HandleMetrics))
+	}
+
 	// Create HTTP server
 	addr := fmt.Sprintf("%s:%s", cfg.API.Host, cfg.API.Port)
 	server := &http.Server{
@@ -137,3 +338,24 @@ func main() {
 		logger.Info(ctx, "Server shutdown complete")
 	}
 }
+
+// runSubmissionThrottleCleanup deletes expired submissions once per throttle window until ctx is cancelled
+func runSubmissionThrottleCleanup(ctx context.Context, throttle repository.SubmissionThrottleRepository, window time.Duration) {
+	ticker := time.NewTicker(window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := throttle.DeleteExpiredSubmissions(ctx, time.Now().Add(-window))
+		if err != nil {
+			logger.LogError(ctx, "Failed to delete expired submissions", err)
+			continue
+		}
+		logger.Debug(ctx, "Deleted expired submissions", "deleted", deleted)
+	}
+}
@@ -0,0 +1,77 @@
+// Command validate-mapping checks an attribute mapping file and lists every problem found.
+//
+// Usage:
+//
+//	validate-mapping [-format table|json] <mapping.json>
+//
+// It exits with 1 if the mapping is invalid and with 2 if the file cannot be checked.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/checkfox/go_lead/internal/config"
+)
+
+// Output formats
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// validationReport is the output of the json format
+type validationReport struct {
+	File   string                `json:"file"`
+	Valid  bool                  `json:"valid"`
+	Errors []config.MappingError `json:"errors"`
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run validates the mapping file named in args and returns the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("validate-mapping", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", formatTable, "output format: table or json")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || (*format != formatTable && *format != formatJSON) {
+		fmt.Fprintln(stderr, "usage: validate-mapping [-format table|json] <mapping.json>")
+		return 2
+	}
+
+	path := flags.Arg(0)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to read attribute mapping file: %v\n", err)
+		return 2
+	}
+	errs := config.ValidateAttributeMapping(data)
+
+	if *format == formatJSON {
+		report := validationReport{File: path, Valid: len(errs) == 0, Errors: errs}
+		if report.Errors == nil {
+			report.Errors = []config.MappingError{}
+		}
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else if len(errs) == 0 {
+		fmt.Fprintf(stdout, "%s: OK\n", path)
+	} else {
+		fmt.Fprintf(stdout, "%s: %d error(s)\n\n", path, len(errs))
+		config.FormatMappingErrors(stdout, errs)
+	}
+
+	if len(errs) > 0 {
+		return 1
+	}
+	return 0
+}
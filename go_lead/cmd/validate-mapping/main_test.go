@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeMapping(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test mapping file: %v", err)
+	}
+	return path
+}
+
+const invalidMapping = `{
+  "roof_area": {"type": "range", "min": 200, "max": 10},
+  "comment": {"type": "text", "max_length": -1}
+}`
+
+func TestRun_TableOutput(t *testing.T) {
+	path := writeMapping(t, invalidMapping)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{path}, &stdout, &stderr)
+
+	if code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	output := stdout.String()
+	if !strings.Contains(output, "2 error(s)") || !strings.Contains(output, "roof_area") || !strings.Contains(output, "comment") {
+		t.Errorf("Expected both errors in the table, got:\n%s", output)
+	}
+}
+
+func TestRun_JSONOutput(t *testing.T) {
+	path := writeMapping(t, invalidMapping)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-format", "json", path}, &stdout, &stderr)
+
+	if code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	var report validationReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode report: %v\n%s", err, stdout.String())
+	}
+	if report.Valid || len(report.Errors) != 2 || report.Errors[0].Key != "roof_area" || report.Errors[1].Field != "max_length" {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
+
+func TestRun_ValidMapping(t *testing.T) {
+	path := writeMapping(t, `{"phone": {"type": "text", "required": true}}`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{path}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected exit code 0, got %d: %s", code, stdout.String())
+	}
+	if !strings.Contains(stdout.String(), "OK") {
+		t.Errorf("Expected OK, got %q", stdout.String())
+	}
+}
+
+func TestRun_UsageErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing file argument", nil},
+		{"unknown format", []string{"-format", "yaml", "mapping.json"}},
+		{"unreadable file", []string{"/nonexistent/mapping.json"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != 2 {
+				t.Errorf("Expected exit code 2, got %d", code)
+			}
+		})
+	}
+}
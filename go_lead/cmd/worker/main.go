@@ -2,23 +2,36 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/checkfox/go_lead/internal/alerting"
 	"github.com/checkfox/go_lead/internal/client"
 	"github.com/checkfox/go_lead/internal/config"
 	"github.com/checkfox/go_lead/internal/database"
+	"github.com/checkfox/go_lead/internal/encryption"
+	"github.com/checkfox/go_lead/internal/handlers"
 	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/metrics"
+	"github.com/checkfox/go_lead/internal/models"
 	"github.com/checkfox/go_lead/internal/queue"
 	"github.com/checkfox/go_lead/internal/repository"
+	"github.com/checkfox/go_lead/internal/selftest"
 	"github.com/checkfox/go_lead/internal/services"
+	"github.com/checkfox/go_lead/internal/tracing"
 	"github.com/checkfox/go_lead/internal/worker"
 )
 
 func main() {
+	once := flag.Bool("once", false, "process available jobs, then exit instead of polling forever")
+	flag.Parse()
+
 	// Initialize structured logger
 	logger.Init()
 	ctx := context.Background()
@@ -29,10 +42,37 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Decode lead and job payloads with exact numbers if configured
+	models.SetJSONUseNumber(cfg.JSON.UseNumber)
+
+	// Enforce the lead status transitions, including any configured extra ones
+	statusMachine, err := models.DefaultStatusMachine().WithTransitions(cfg.LeadStatus.ExtraTransitions)
+	if err != nil {
+		log.Fatalf("Invalid lead status transitions: %v", err)
+	}
+	models.SetStatusMachine(statusMachine)
+
+	// Switch to the configured log format and level
+	if err := logger.Configure(cfg.Logging.Format, cfg.Logging.Level); err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	logger.SetTraceIDFunc(tracing.TraceID)
+
 	logger.Info(ctx, "Worker starting",
 		"poll_interval", cfg.Worker.PollInterval,
 		"concurrency", cfg.Worker.Concurrency,
 		"max_retry_attempts", cfg.Retry.MaxAttempts)
+	cfg.Dump(ctx)
+
+	// Initialize tracing (no-op unless OTEL_EXPORTER_OTLP_ENDPOINT is set)
+	shutdownTracing := tracing.Init(cfg.Tracing, "go_lead-worker")
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			logger.Error(ctx, "Failed to flush traces", "error", err.Error())
+		}
+	}()
 
 	// Initialize database connection
 	dbWrapper, err := database.InitFromConfig(cfg)
@@ -49,50 +89,113 @@ func main() {
 		log.Fatalf("Failed to initialize queue: %v", err)
 	}
 	defer jobQueue.Close()
+	jobQueue.SetDefaultMaxAttempts(cfg.Queue.JobMaxAttempts)
 
-	logger.Info(ctx, "Queue initialized")
+	logger.Info(ctx, "Queue initialized", "job_max_attempts", cfg.Queue.JobMaxAttempts)
+
+	// Verify the service wiring before accepting work
+	if cfg.SelfTest.Enabled {
+		if _, err := selftest.Run(ctx, selftest.Deps{
+			DB:             dbWrapper,
+			MigrationsPath: "./migrations",
+			Config:         cfg,
+		}); err != nil {
+			log.Fatalf("Startup self-test failed: %v", err)
+		}
+	}
 
 	// Initialize repositories
-	leadRepo := repository.NewLeadRepository(dbWrapper.DB)
-	deliveryAttemptRepo := repository.NewDeliveryAttemptRepository(dbWrapper.DB)
+	writeRetryPolicy := repository.WriteRetryPolicy{
+		MaxAttempts: cfg.Database.WriteRetryAttempts,
+		Backoff:     cfg.Database.WriteRetryBackoff,
+	}
+	// The worker loads leads of every tenant by ID, so it uses the unscoped repository
+	deps := workerDeps{
+		jobQueue:            jobQueue,
+		leadRepo:            repository.NewLeadRepositoryWithRetry(dbWrapper.DB, writeRetryPolicy),
+		deliveryAttemptRepo: repository.NewDeliveryAttemptRepositoryWithRetry(dbWrapper.DB, writeRetryPolicy),
+		callbackAttemptRepo: repository.NewCallbackAttemptRepositoryWithRetry(dbWrapper.DB, writeRetryPolicy),
+		processingLockRepo:  repository.NewProcessingLockRepositoryWithRetry(dbWrapper.DB, writeRetryPolicy),
+		chainAttemptRepo:    repository.NewDeliveryChainAttemptRepositoryWithRetry(dbWrapper.DB, writeRetryPolicy),
+		normalizationAudit:  repository.NewNormalizationAuditRepository(dbWrapper.DB),
+		customerAPILatency:  client.NewLatencyHistogram(cfg.Worker.LatencyBuckets),
+		deliveryPool:        client.NewDeliveryConnectionPool(cfg.CustomerAPI.MaxConnections),
+		rejectionNotifier:   alerting.NewRejectionNotifierFromConfig(cfg.Notification),
+	}
 
-	// Initialize services
-	validator := services.NewValidator()
-	normalizer := services.NewNormalizer()
-	mapper := services.NewMapper(cfg)
+	// Store PII fields of lead payloads encrypted when an encryption key is configured
+	fieldEncryptor, err := encryption.NewFieldEncryptorFromConfig(cfg.Privacy)
+	if err != nil {
+		log.Fatalf("Failed to initialize field encryption: %v", err)
+	}
+	if fieldEncryptor != nil {
+		deps.leadRepo = repository.NewEncryptedLeadRepository(deps.leadRepo, fieldEncryptor)
+		deps.normalizationAudit = repository.NewEncryptedNormalizationAuditRepository(deps.normalizationAudit, fieldEncryptor)
+	}
 
-	// Initialize Customer API client
-	customerAPIClient := client.NewCustomerAPIClient(
-		cfg.CustomerAPI.URL,
-		cfg.CustomerAPI.Token,
-		cfg.CustomerAPI.Timeout,
-	)
+	processor, err := buildProcessor(ctx, cfg, deps)
+	if err != nil {
+		log.Fatalf("Failed to initialize processor: %v", err)
+	}
 
-	// Calculate exponential backoff delays based on configuration
-	exponentialBackoffDelays := make([]time.Duration, cfg.Retry.MaxAttempts)
-	for i := 0; i < cfg.Retry.MaxAttempts; i++ {
-		// Exponential backoff: base * 2^i
-		exponentialBackoffDelays[i] = cfg.Retry.BackoffBase * time.Duration(1<<uint(i))
+	// Restart the processor with freshly loaded configuration when the attribute mapping file changes
+	var restarter *GracefulRestarter
+	if !*once && cfg.Worker.MaxJobs == 0 && cfg.Worker.MappingReloadInterval > 0 {
+		restarter = NewGracefulRestarter(cfg.AttributeMapping.FilePath, cfg.Worker.MappingReloadInterval, config.Load,
+			func(cfg *config.Config) (processorRunner, error) {
+				next, err := buildProcessor(ctx, cfg, deps)
+				if err != nil {
+					return nil, err
+				}
+				return next, nil
+			})
 	}
 
-	logger.Info(ctx, "Retry configuration",
-		"max_attempts", cfg.Retry.MaxAttempts,
-		"backoff_base", cfg.Retry.BackoffBase,
-		"backoff_delays", exponentialBackoffDelays)
+	// Serve in-process metrics if a port is configured
+	if cfg.Worker.MetricsPort != "" {
+		var omissionStats handlers.OmissionStatsSource = processor
+		if restarter != nil {
+			omissionStats = restarter
+		}
+		metricsHandler := handlers.NewMetricsHandler(omissionStats)
+		if restarter != nil {
+			metricsHandler.SetRestartCount(restarter)
+		}
+		metricsHandler.SetLatencyHistogram(deps.customerAPILatency)
+		latencyHandler := handlers.NewCustomerAPILatencyHandler(deps.customerAPILatency)
+		metricsMux := http.NewServeMux()
+		metricsMux.HandleFunc("/metrics", metricsHandler.HandleMetrics)
+		metricsMux.HandleFunc("/stats/customer-api/latency", latencyHandler.HandleLatency)
+		metricsServer := &http.Server{
+			Addr:    ":" + cfg.Worker.MetricsPort,
+			Handler: metricsMux,
+		}
+		defer metricsServer.Close()
+
+		go func() {
+			logger.Info(ctx, "Metrics server listening", "port", cfg.Worker.MetricsPort)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error(ctx, "Metrics server error", "error", err.Error())
+			}
+		}()
+	}
 
-	// Create worker processor
-	processor := worker.NewProcessor(worker.ProcessorConfig{
-		Queue:                    jobQueue,
-		LeadRepo:                 leadRepo,
-		DeliveryAttemptRepo:      deliveryAttemptRepo,
-		Validator:                validator,
-		Normalizer:               normalizer,
-		Mapper:                   mapper,
-		CustomerAPIClient:        customerAPIClient,
-		PollInterval:             cfg.Worker.PollInterval,
-		MaxDeliveryAttempts:      cfg.Retry.MaxAttempts,
-		ExponentialBackoffDelays: exponentialBackoffDelays,
-	})
+	// In one-shot mode, drain up to WORKER_MAX_JOBS jobs (or the whole queue with --once) and exit
+	if *once || cfg.Worker.MaxJobs > 0 {
+		runCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		summary, err := processor.RunOnce(runCtx, cfg.Worker.MaxJobs)
+		if err != nil && err != context.Canceled {
+			logger.Error(ctx, "Worker run failed", "error", err.Error())
+		}
+		waitForRejectionNotifications(ctx, deps.rejectionNotifier)
+		logger.Info(ctx, "Worker shutdown complete",
+			"processed", summary.Processed,
+			"succeeded", summary.Succeeded,
+			"failed", summary.Failed)
+		return
+	}
 
 	// Set up signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -105,9 +208,22 @@ func main() {
 	// Start worker in a goroutine
 	workerErrors := make(chan error, 1)
 	go func() {
+		if restarter != nil {
+			workerErrors <- restarter.Run(workerCtx, processor)
+			return
+		}
 		workerErrors <- processor.Start(workerCtx)
 	}()
 
+	// Confirm that delivered leads exist at the Customer API if it has a read endpoint
+	if cfg.Reconciliation.ReadURL != "" {
+		reconciler := worker.NewReconciler(repository.NewReconciliationRepository(dbWrapper.DB),
+			client.NewCustomerAPIClientFromConfig(cfg.CustomerAPI), cfg.Reconciliation)
+		go reconciler.Run(workerCtx)
+		logger.Info(ctx, "Reconciliation of delivered leads enabled",
+			"interval", cfg.Reconciliation.Interval, "window", cfg.Reconciliation.Window)
+	}
+
 	logger.Info(ctx, "Worker started successfully")
 
 	// Wait for shutdown signal or worker error
@@ -135,5 +251,151 @@ func main() {
 		}
 	}
 
+	waitForRejectionNotifications(ctx, deps.rejectionNotifier)
 	logger.Info(ctx, "Worker shutdown complete")
 }
+
+// waitForRejectionNotifications lets notifications of rejected leads that are still
+// being sent finish before the worker exits; each is bounded by REJECTION_NOTIFY_TIMEOUT
+func waitForRejectionNotifications(ctx context.Context, notifier *alerting.AsyncRejectionNotifier) {
+	if notifier == nil {
+		return
+	}
+	logger.Info(ctx, "Waiting for pending rejection notifications")
+	notifier.Wait()
+}
+
+// workerDeps holds the dependencies shared by every processor of the worker process
+type workerDeps struct {
+	jobQueue            queue.Queue
+	leadRepo            repository.LeadRepository
+	deliveryAttemptRepo repository.DeliveryAttemptRepository
+	callbackAttemptRepo repository.CallbackAttemptRepository
+	processingLockRepo  repository.ProcessingLockRepository
+	chainAttemptRepo    repository.DeliveryChainAttemptRepository
+	normalizationAudit  repository.NormalizationAuditRepository
+	customerAPILatency  *metrics.Histogram // kept across processor restarts
+	// deliveryPool is shared across processor restarts, so that a draining processor and
+	// its successor together stay within CUSTOMER_API_MAX_CONNECTIONS
+	deliveryPool *client.DeliveryConnectionPool
+	// rejectionNotifier informs operators of rejected leads via Slack or email (nil if not
+	// configured); it is shared so that shutdown can wait for its pending notifications
+	rejectionNotifier *alerting.AsyncRejectionNotifier
+}
+
+// buildProcessor creates a processor and its services from cfg
+func buildProcessor(ctx context.Context, cfg *config.Config, deps workerDeps) (*worker.Processor, error) {
+	// Initialize services
+	validator, err := services.NewValidatorFromConfig(cfg.Validation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize validator: %w", err)
+	}
+	normalizer := services.NewNormalizerFromConfig(cfg.Normalizer)
+	mapper := services.NewMapper(cfg)
+	enricher, err := services.NewEnrichmentChainFromConfig(cfg.Enrichment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize enrichment chain: %w", err)
+	}
+
+	// Initialize Customer API client
+	customerAPIClient := client.NewCustomerAPIClientFromConfig(cfg.CustomerAPI)
+	logObfuscator := logger.NewLogObfuscator(cfg.Privacy.ObfuscatedFields)
+	customerAPIClient.SetLogObfuscator(logObfuscator)
+	customerAPIClient.SetLatencyHistogram(deps.customerAPILatency)
+	customerAPIClient.SetMaxResponseBytes(cfg.Storage.MaxAttemptResponseBytes)
+
+	// Secondary endpoints of the forwarding chain, in delivery order
+	forwardingChain := make([]worker.LeadSender, 0, len(cfg.CustomerAPI.ForwardingChain))
+	for _, endpoint := range cfg.CustomerAPI.ForwardingChain {
+		chainClient := client.NewCustomerAPIClientFromConfig(endpoint)
+		chainClient.SetLogObfuscator(logObfuscator)
+		chainClient.SetMaxResponseBytes(cfg.Storage.MaxAttemptResponseBytes)
+		forwardingChain = append(forwardingChain, chainClient)
+	}
+
+	// Fallback endpoint for leads the primary Customer API failed to accept repeatedly
+	var fallbackClient worker.LeadSender
+	if fallback := cfg.CustomerAPI.Fallback(); fallback != nil {
+		fallbackAPIClient := client.NewCustomerAPIClientFromConfig(*fallback)
+		fallbackAPIClient.SetLogObfuscator(logObfuscator)
+		fallbackAPIClient.SetLatencyHistogram(deps.customerAPILatency)
+		fallbackAPIClient.SetMaxResponseBytes(cfg.Storage.MaxAttemptResponseBytes)
+		fallbackClient = fallbackAPIClient
+	}
+
+	// Record the fields normalization changes only if the audit is enabled
+	var normalizationAuditRepo repository.NormalizationAuditRepository
+	if cfg.Privacy.NormalizationAuditEnabled {
+		normalizationAuditRepo = deps.normalizationAudit
+	}
+
+	// Exponential backoff delays (base * 2^i), bounded when the config is validated
+	exponentialBackoffDelays := cfg.Retry.Delays()
+
+	logger.Info(ctx, "Retry configuration",
+		"max_attempts", cfg.Retry.MaxAttempts,
+		"backoff_base", cfg.Retry.BackoffBase,
+		"backoff_delays", exponentialBackoffDelays)
+
+	// Notifies webhook senders of the final lead outcome via their X-Callback-URL or the configured callback URLs.
+	// Callbacks are signed with the shared secret and disabled without it.
+	var notifier *worker.NotificationWorker
+	if cfg.Auth.SharedSecret == "" {
+		logger.Warn(ctx, "SHARED_SECRET is not set, sender callbacks are disabled")
+	} else {
+		n, err := worker.NewNotificationWorker(worker.NotificationWorkerConfig{
+			Queue:               deps.jobQueue,
+			LeadRepo:            deps.leadRepo,
+			CallbackAttemptRepo: deps.callbackAttemptRepo,
+			SharedSecret:        cfg.Auth.SharedSecret,
+			DefaultCallbackURL:  cfg.Callback.URL,
+			SourceCallbackURLs:  cfg.Callback.SourceURLs,
+			AllowedHosts:        cfg.Callback.AllowedHosts,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create notification worker: %w", err)
+		}
+		notifier = n
+	}
+
+	// Informs operators of rejected leads via Slack or email, if configured
+	var rejectionNotifier alerting.RejectionNotifier
+	if deps.rejectionNotifier != nil {
+		rejectionNotifier = deps.rejectionNotifier
+	}
+
+	return worker.NewProcessor(worker.ProcessorConfig{
+		Queue:                     deps.jobQueue,
+		LeadRepo:                  deps.leadRepo,
+		DeliveryAttemptRepo:       deps.deliveryAttemptRepo,
+		Validator:                 validator,
+		Normalizer:                normalizer,
+		NormalizationAuditRepo:    normalizationAuditRepo,
+		Mapper:                    mapper,
+		KeyCase:                   services.NewCustomerKeyCase(cfg.CustomerAPI, cfg.Normalizer.AcronymPreservation),
+		Enricher:                  enricher,
+		LogObfuscator:             logObfuscator,
+		ProcessingLockRepo:        deps.processingLockRepo,
+		LockTimeout:               cfg.Worker.LockTimeout,
+		CustomerAPIClient:         customerAPIClient,
+		DeliveryPool:              deps.deliveryPool,
+		ForwardHeaders:            cfg.CustomerAPI.ForwardHeaders,
+		SuppressionListEnabled:    cfg.Validation.SuppressionListEnabled,
+		UnexpectedResponseOutcome: cfg.CustomerAPI.UnexpectedResponseOutcome,
+		PollInterval:              cfg.Worker.PollInterval,
+		MaxPollInterval:           cfg.Worker.MaxPollInterval,
+		MaxDeliveryAttempts:       cfg.Retry.MaxAttempts,
+		ExponentialBackoffDelays:  exponentialBackoffDelays,
+		PriorityPenalty:           cfg.Retry.PriorityPenalty,
+		AttemptRetention:          cfg.Retry.AttemptRetention,
+		AsyncDeliveryMode:         cfg.CustomerAPI.AsyncMode,
+		ConfirmationTimeout:       cfg.CustomerAPI.ConfirmationTimeout,
+		Notifier:                  notifier,
+		RejectionNotifier:         rejectionNotifier,
+		DeliverySchedule:          cfg.CustomerAPI.DeliverySchedule,
+		ForwardingChain:           forwardingChain,
+		ChainAttemptRepo:          deps.chainAttemptRepo,
+		FallbackClient:            fallbackClient,
+		FallbackAfterAttempts:     cfg.CustomerAPI.FallbackAfterAttempts,
+	}), nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/logger"
+)
+
+// processorRunner is the part of worker.Processor driven by the GracefulRestarter
+type processorRunner interface {
+	Start(ctx context.Context) error
+	Shutdown()
+	OmissionStats() map[string]int64
+	UnknownOmissions() int64
+}
+
+// GracefulRestarter runs the worker processor and replaces it with one built from freshly
+// loaded configuration whenever the attribute mapping file changes. The file is polled
+// for a changed modification time or size. The old processor finishes its in-flight job
+// before the new one starts, so no job is interrupted or processed twice.
+type GracefulRestarter struct {
+	mappingFile  string
+	interval     time.Duration
+	loadConfig   func() (*config.Config, error)
+	newProcessor func(cfg *config.Config) (processorRunner, error)
+
+	mu       sync.Mutex
+	current  processorRunner
+	stopped  chan struct{}  // closed when the restarter shuts the current processor down
+	running  sync.WaitGroup // the Start call of the current processor
+	exited   chan error     // receives the result of a processor stopping on its own
+	restarts atomic.Int64
+}
+
+// NewGracefulRestarter creates a GracefulRestarter for the mapping file, checked every interval
+func NewGracefulRestarter(mappingFile string, interval time.Duration,
+	loadConfig func() (*config.Config, error),
+	newProcessor func(cfg *config.Config) (processorRunner, error)) *GracefulRestarter {
+	return &GracefulRestarter{
+		mappingFile:  mappingFile,
+		interval:     interval,
+		loadConfig:   loadConfig,
+		newProcessor: newProcessor,
+		exited:       make(chan error, 1),
+	}
+}
+
+// RestartCount returns how often the processor was replaced
+func (r *GracefulRestarter) RestartCount() int64 {
+	return r.restarts.Load()
+}
+
+// OmissionStats returns the attribute omission counts of the current processor
+func (r *GracefulRestarter) OmissionStats() map[string]int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current.OmissionStats()
+}
+
+// UnknownOmissions returns the omissions of unmapped attributes by the current processor
+func (r *GracefulRestarter) UnknownOmissions() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current.UnknownOmissions()
+}
+
+// Run starts processor and restarts it on mapping file changes until ctx is cancelled
+// or the processor stops on its own. The current processor is drained before returning.
+func (r *GracefulRestarter) Run(ctx context.Context, processor processorRunner) error {
+	lastState, _ := r.fileState()
+	r.start(ctx, processor)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.drain()
+			return ctx.Err()
+
+		case err := <-r.exited:
+			return err
+
+		case <-ticker.C:
+			state, err := r.fileState()
+			if err != nil {
+				// The file may be replaced right now; check again on the next tick
+				logger.Warn(ctx, "Failed to check attribute mapping file", "error", err.Error())
+				continue
+			}
+			if state.modTime.Equal(lastState.modTime) && state.size == lastState.size {
+				continue
+			}
+			lastState = state
+			r.restart(ctx)
+		}
+	}
+}
+
+// restart replaces the current processor with one built from freshly loaded configuration.
+// The running processor is kept if the configuration cannot be loaded.
+func (r *GracefulRestarter) restart(ctx context.Context) {
+	logger.Info(ctx, "Attribute mapping file changed, restarting processor", "file", r.mappingFile)
+
+	cfg, err := r.loadConfig()
+	if err != nil {
+		logger.Error(ctx, "Failed to reload configuration, keeping the running processor", "error", err.Error())
+		return
+	}
+	next, err := r.newProcessor(cfg)
+	if err != nil {
+		logger.Error(ctx, "Failed to create processor, keeping the running processor", "error", err.Error())
+		return
+	}
+
+	r.drain()
+	r.start(ctx, next)
+	r.restarts.Add(1)
+	logger.Info(ctx, "Processor restarted", "restart_count", r.restarts.Load())
+}
+
+// start runs processor in a new goroutine
+func (r *GracefulRestarter) start(ctx context.Context, processor processorRunner) {
+	stopped := make(chan struct{})
+	r.mu.Lock()
+	r.current = processor
+	r.stopped = stopped
+	r.mu.Unlock()
+
+	r.running.Add(1)
+	go func() {
+		defer r.running.Done()
+		err := processor.Start(ctx)
+		select {
+		case <-stopped:
+			// Shut down by the restarter
+		default:
+			r.exited <- err
+		}
+	}()
+}
+
+// drain asks the current processor to stop and waits until its in-flight job is done
+func (r *GracefulRestarter) drain() {
+	r.mu.Lock()
+	close(r.stopped)
+	r.current.Shutdown()
+	r.mu.Unlock()
+
+	r.running.Wait()
+}
+
+// fileState identifies the current version of the mapping file
+func (r *GracefulRestarter) fileState() (fileState, error) {
+	info, err := os.Stat(r.mappingFile)
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// fileState is the modification time and size of a file
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/services"
+)
+
+func init() {
+	logger.Init()
+}
+
+// fakeProcessor runs one long job until it is shut down, then finishes the job
+type fakeProcessor struct {
+	mapper   *services.Mapper
+	started  chan struct{}
+	shutdown chan struct{}
+	jobTime  time.Duration
+
+	mu       sync.Mutex
+	jobDone  bool
+	stopOnce sync.Once
+}
+
+func newFakeProcessor(cfg *config.Config) *fakeProcessor {
+	return &fakeProcessor{
+		mapper:   services.NewMapper(cfg),
+		started:  make(chan struct{}),
+		shutdown: make(chan struct{}),
+		jobTime:  50 * time.Millisecond,
+	}
+}
+
+func (p *fakeProcessor) Start(ctx context.Context) error {
+	close(p.started)
+	select {
+	case <-p.shutdown:
+	case <-ctx.Done():
+	}
+	// The in-flight job completes before Start returns
+	time.Sleep(p.jobTime)
+	p.mu.Lock()
+	p.jobDone = true
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *fakeProcessor) Shutdown() {
+	p.stopOnce.Do(func() { close(p.shutdown) })
+}
+
+func (p *fakeProcessor) OmissionStats() map[string]int64 {
+	return p.mapper.OmissionStats()
+}
+
+func (p *fakeProcessor) UnknownOmissions() int64 {
+	return p.mapper.UnknownOmissions()
+}
+
+func (p *fakeProcessor) finishedJob() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.jobDone
+}
+
+// writeMapping writes a mapping file limiting comment to maxLength characters
+func writeMapping(t *testing.T, path string, maxLength int, modTime time.Time) {
+	t.Helper()
+	content := fmt.Sprintf(`{"phone": {"type": "text", "required": true}, "comment": {"type": "text", "max_length": %d}}`, maxLength)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write mapping file: %v", err)
+	}
+	// Make the change visible regardless of the file system's timestamp resolution
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set mapping file time: %v", err)
+	}
+}
+
+func loadMapping(path string) func() (*config.Config, error) {
+	return func() (*config.Config, error) {
+		cfg := &config.Config{
+			CustomerAPI:      config.CustomerAPIConfig{ProductName: "test_product"},
+			AttributeMapping: config.AttributeMappingConfig{FilePath: path},
+		}
+		if err := cfg.LoadAttributeMapping(); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}
+}
+
+func TestGracefulRestarter_RestartsOnMappingChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	modTime := time.Now().Add(-time.Hour)
+	writeMapping(t, path, 100, modTime)
+
+	cfg, err := loadMapping(path)()
+	if err != nil {
+		t.Fatalf("Failed to load mapping: %v", err)
+	}
+	first := newFakeProcessor(cfg)
+
+	created := make(chan *fakeProcessor, 1)
+	restarter := NewGracefulRestarter(path, 10*time.Millisecond, loadMapping(path),
+		func(cfg *config.Config) (processorRunner, error) {
+			next := newFakeProcessor(cfg)
+			created <- next
+			return next, nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- restarter.Run(ctx, first) }()
+	<-first.started
+
+	// Shorten the allowed comment length
+	writeMapping(t, path, 5, modTime.Add(time.Minute))
+
+	var second *fakeProcessor
+	select {
+	case second = <-created:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a mapping change to create a new processor")
+	}
+	select {
+	case <-second.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the new processor to be started")
+	}
+
+	// The old processor finished its in-flight job before the new one started
+	if !first.finishedJob() {
+		t.Error("Expected the in-flight job of the old processor to finish before the restart")
+	}
+	if got := restarter.RestartCount(); got != 1 {
+		t.Errorf("Expected restart count 1, got %d", got)
+	}
+
+	// The new processor maps with the updated rules
+	result := second.mapper.MapToCustomerFormat(models.JSONB{"phone": "1234567890", "comment": "longer than five"})
+	if _, present := result.CustomerPayload["comment"]; present {
+		t.Error("Expected the new processor to omit a comment beyond the updated max_length")
+	}
+	result = first.mapper.MapToCustomerFormat(models.JSONB{"phone": "1234567890", "comment": "longer than five"})
+	if _, present := result.CustomerPayload["comment"]; !present {
+		t.Error("Expected the old processor to keep the original rules")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected Run to return context.Canceled, got %v", err)
+	}
+	if !second.finishedJob() {
+		t.Error("Expected the current processor to be drained on shutdown")
+	}
+}
+
+func TestGracefulRestarter_KeepsProcessorOnInvalidMapping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	modTime := time.Now().Add(-time.Hour)
+	writeMapping(t, path, 100, modTime)
+
+	cfg, err := loadMapping(path)()
+	if err != nil {
+		t.Fatalf("Failed to load mapping: %v", err)
+	}
+	first := newFakeProcessor(cfg)
+	restarter := NewGracefulRestarter(path, 10*time.Millisecond, loadMapping(path),
+		func(cfg *config.Config) (processorRunner, error) {
+			t.Error("Expected no processor to be created for an invalid mapping")
+			return newFakeProcessor(cfg), nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- restarter.Run(ctx, first) }()
+	<-first.started
+
+	if err := os.WriteFile(path, []byte(`{"comment": {"type": "text", "max_length": "long"}}`), 0644); err != nil {
+		t.Fatalf("Failed to write mapping file: %v", err)
+	}
+	os.Chtimes(path, modTime.Add(time.Minute), modTime.Add(time.Minute))
+	time.Sleep(100 * time.Millisecond)
+
+	if first.finishedJob() {
+		t.Error("Expected the running processor to be kept")
+	}
+	if got := restarter.RestartCount(); got != 0 {
+		t.Errorf("Expected restart count 0, got %d", got)
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,233 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// RejectionNotifier informs operators that a lead was rejected during validation
+type RejectionNotifier interface {
+	Notify(ctx context.Context, lead *models.InboundLead, reason models.RejectionReason) error
+}
+
+// rejectionSummary describes a rejected lead without its payload, so notifications
+// sent to chat or email never contain contact data
+func rejectionSummary(lead *models.InboundLead, reason models.RejectionReason) string {
+	summary := fmt.Sprintf("Lead %d rejected: %s", lead.ID, reason)
+	if lead.SourceID != nil && *lead.SourceID != "" {
+		summary += fmt.Sprintf(" (source %s)", *lead.SourceID)
+	}
+	if !lead.ReceivedAt.IsZero() {
+		summary += fmt.Sprintf(", received %s", lead.ReceivedAt.UTC().Format(time.RFC3339))
+	}
+	return summary
+}
+
+// SlackRejectionNotifier posts rejected leads to a Slack incoming webhook
+type SlackRejectionNotifier struct {
+	webhookURL string
+	httpClient *http.Client
+}
+
+// NewSlackRejectionNotifier creates a SlackRejectionNotifier for the webhook URL
+func NewSlackRejectionNotifier(webhookURL string) *SlackRejectionNotifier {
+	return &SlackRejectionNotifier{
+		webhookURL: webhookURL,
+		httpClient: &http.Client{},
+	}
+}
+
+// Notify posts the rejection as a Slack message
+func (n *SlackRejectionNotifier) Notify(ctx context.Context, lead *models.InboundLead, reason models.RejectionReason) error {
+	body, err := json.Marshal(map[string]string{"text": rejectionSummary(lead, reason)})
+	if err != nil {
+		return fmt.Errorf("failed to encode Slack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		// Drop the webhook URL from the error, its path is the credential
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to post Slack message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("slack webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailRejectionNotifier emails rejected leads via SMTP. STARTTLS is used when the
+// server offers it, and PLAIN authentication when a username is configured.
+type EmailRejectionNotifier struct {
+	smtp config.SMTPConfig
+}
+
+// NewEmailRejectionNotifier creates an EmailRejectionNotifier for the mail server
+func NewEmailRejectionNotifier(cfg config.SMTPConfig) *EmailRejectionNotifier {
+	return &EmailRejectionNotifier{smtp: cfg}
+}
+
+// Notify sends the rejection as an email to every recipient. The deadline of ctx
+// bounds the whole SMTP conversation.
+func (n *EmailRejectionNotifier) Notify(ctx context.Context, lead *models.InboundLead, reason models.RejectionReason) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(n.smtp.Host, n.smtp.Port))
+	if err != nil {
+		return fmt.Errorf("failed to connect to mail server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.smtp.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.smtp.Host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if n.smtp.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.smtp.Username, n.smtp.Password, n.smtp.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate with mail server: %w", err)
+		}
+	}
+
+	if err := client.Mail(n.smtp.From); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+	for _, to := range n.smtp.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("failed to add recipient %s: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message: %w", err)
+	}
+	if _, err := w.Write(n.message(lead, reason)); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// message builds the email for a rejected lead
+func (n *EmailRejectionNotifier) message(lead *models.InboundLead, reason models.RejectionReason) []byte {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.smtp.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.smtp.To, ", "))
+	fmt.Fprintf(&msg, "Subject: Lead %d rejected: %s\r\n", lead.ID, reason)
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(rejectionSummary(lead, reason))
+	msg.WriteString("\r\n")
+	return []byte(msg.String())
+}
+
+// AsyncRejectionNotifier passes rejections of the configured reasons on to its notifiers
+// in the background, so a slow or failing notification never delays lead processing.
+// Each notification is bounded by the timeout; failures are logged.
+type AsyncRejectionNotifier struct {
+	notifiers []RejectionNotifier
+	reasons   map[string]bool
+	timeout   time.Duration
+	pending   sync.WaitGroup
+}
+
+// NewAsyncRejectionNotifier creates an AsyncRejectionNotifier for the rejection reasons.
+// A timeout of 0 defaults to 10 seconds.
+func NewAsyncRejectionNotifier(reasons []string, timeout time.Duration, notifiers ...RejectionNotifier) *AsyncRejectionNotifier {
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	n := &AsyncRejectionNotifier{
+		notifiers: notifiers,
+		reasons:   make(map[string]bool, len(reasons)),
+		timeout:   timeout,
+	}
+	for _, reason := range reasons {
+		n.reasons[reason] = true
+	}
+	return n
+}
+
+// NewRejectionNotifierFromConfig creates an AsyncRejectionNotifier for the Slack webhook
+// and mail server of the notification config. Returns nil if neither is configured.
+func NewRejectionNotifierFromConfig(cfg config.NotificationConfig) *AsyncRejectionNotifier {
+	var notifiers []RejectionNotifier
+	if cfg.SlackWebhookURL != "" {
+		notifiers = append(notifiers, NewSlackRejectionNotifier(cfg.SlackWebhookURL))
+	}
+	if cfg.EmailSMTP.Host != "" {
+		notifiers = append(notifiers, NewEmailRejectionNotifier(cfg.EmailSMTP))
+	}
+	if len(notifiers) == 0 {
+		return nil
+	}
+	return NewAsyncRejectionNotifier(cfg.Reasons, cfg.Timeout, notifiers...)
+}
+
+// Notify starts the notifications for the lead if its reason is configured and returns
+// immediately. The notifications keep the values of ctx but not its cancellation, so
+// they complete after the job that rejected the lead.
+func (n *AsyncRejectionNotifier) Notify(ctx context.Context, lead *models.InboundLead, reason models.RejectionReason) error {
+	if !n.reasons[string(reason)] {
+		return nil
+	}
+
+	// The processor keeps working with the lead, so the notifiers get their own copy
+	snapshot := *lead
+	for _, notifier := range n.notifiers {
+		n.pending.Add(1)
+		go func(notifier RejectionNotifier) {
+			defer n.pending.Done()
+
+			notifyCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), n.timeout)
+			defer cancel()
+			if err := notifier.Notify(notifyCtx, &snapshot, reason); err != nil {
+				logger.LogError(notifyCtx, "Failed to send rejection notification", err,
+					"lead_id", snapshot.ID, "rejection_reason", string(reason))
+			}
+		}(notifier)
+	}
+	return nil
+}
+
+// Wait blocks until all started notifications are done, e.g. before the worker exits
+func (n *AsyncRejectionNotifier) Wait() {
+	n.pending.Wait()
+}
@@ -0,0 +1,195 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+func init() {
+	// Initialize logger for tests
+	logger.Init()
+}
+
+// notification is a rejection received by a mockRejectionNotifier
+type notification struct {
+	lead        models.InboundLead
+	reason      models.RejectionReason
+	hasDeadline bool
+}
+
+// mockRejectionNotifier records the rejections it is notified of
+type mockRejectionNotifier struct {
+	mu       sync.Mutex
+	received []notification
+	release  chan struct{} // if set, Notify blocks until it is closed
+	err      error
+}
+
+func (m *mockRejectionNotifier) Notify(ctx context.Context, lead *models.InboundLead, reason models.RejectionReason) error {
+	if m.release != nil {
+		<-m.release
+	}
+	_, hasDeadline := ctx.Deadline()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.received = append(m.received, notification{lead: *lead, reason: reason, hasDeadline: hasDeadline})
+	return m.err
+}
+
+func (m *mockRejectionNotifier) notifications() []notification {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]notification(nil), m.received...)
+}
+
+func TestAsyncRejectionNotifier(t *testing.T) {
+	slack := &mockRejectionNotifier{}
+	email := &mockRejectionNotifier{err: errors.New("mail server unavailable")}
+	notifier := NewAsyncRejectionNotifier([]string{"ZIP_NOT_66XXX", "NOT_HOMEOWNER"}, time.Second, slack, email)
+
+	sourceID := "partner-a"
+	lead := &models.InboundLead{ID: 42, SourceID: &sourceID, Status: models.LeadStatusRejected}
+	if err := notifier.Notify(context.Background(), lead, models.RejectionReasonNotHomeowner); err != nil {
+		t.Fatalf("Notify() failed: %v", err)
+	}
+	// Reasons that are not configured are not notified
+	if err := notifier.Notify(context.Background(), lead, models.RejectionReasonSpamFilter); err != nil {
+		t.Fatalf("Notify() failed: %v", err)
+	}
+	// Later changes to the lead do not reach the notifiers
+	lead.Status = models.LeadStatusFailed
+	notifier.Wait()
+
+	// A failing notifier does not keep the others from being notified
+	for name, mock := range map[string]*mockRejectionNotifier{"slack": slack, "email": email} {
+		received := mock.notifications()
+		if len(received) != 1 {
+			t.Fatalf("Expected 1 %s notification, got %d", name, len(received))
+		}
+		got := received[0]
+		if got.lead.ID != 42 || got.lead.Status != models.LeadStatusRejected || got.reason != models.RejectionReasonNotHomeowner {
+			t.Errorf("Expected %s to receive lead 42 rejected as NOT_HOMEOWNER, got lead %d (%s) with %s",
+				name, got.lead.ID, got.lead.Status, got.reason)
+		}
+		if !got.hasDeadline {
+			t.Errorf("Expected the %s notification to be bounded by the timeout", name)
+		}
+	}
+}
+
+func TestAsyncRejectionNotifier_DoesNotBlock(t *testing.T) {
+	slow := &mockRejectionNotifier{release: make(chan struct{})}
+	notifier := NewAsyncRejectionNotifier([]string{"ZIP_NOT_66XXX"}, time.Second, slow)
+
+	// A cancelled job context does not cancel the notification
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		notifier.Notify(ctx, &models.InboundLead{ID: 7}, models.RejectionReasonZipNotValid)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Notify to return while the notifier is still sending")
+	}
+	cancel()
+
+	close(slow.release)
+	notifier.Wait()
+	if received := slow.notifications(); len(received) != 1 || received[0].lead.ID != 7 {
+		t.Errorf("Expected the notification of lead 7 to complete, got %v", received)
+	}
+}
+
+func TestNewRejectionNotifierFromConfig(t *testing.T) {
+	if notifier := NewRejectionNotifierFromConfig(config.NotificationConfig{}); notifier != nil {
+		t.Error("Expected no notifier without Slack webhook and mail server")
+	}
+
+	notifier := NewRejectionNotifierFromConfig(config.NotificationConfig{
+		SlackWebhookURL: "https://hooks.slack.com/services/T000/B000/secret",
+		EmailSMTP:       config.SMTPConfig{Host: "smtp.example.com", Port: "587", From: "leads@example.com", To: []string{"ops@example.com"}},
+		Reasons:         []string{"NOT_HOMEOWNER"},
+	})
+	if notifier == nil || len(notifier.notifiers) != 2 {
+		t.Fatalf("Expected a Slack and an email notifier, got %+v", notifier)
+	}
+	if notifier.timeout != 10*time.Second {
+		t.Errorf("Expected the default timeout of 10s, got %v", notifier.timeout)
+	}
+}
+
+func TestSlackRejectionNotifier(t *testing.T) {
+	var message map[string]string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON POST, got %s with %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("Failed to decode Slack message: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	notifier := NewSlackRejectionNotifier(server.URL + "/services/T000/B000/secret")
+	lead := &models.InboundLead{
+		ID:         42,
+		ReceivedAt: time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC),
+		RawPayload: models.JSONB{"email": "max@example.com"},
+	}
+	if err := notifier.Notify(context.Background(), lead, models.RejectionReasonZipNotValid); err != nil {
+		t.Fatalf("Notify() failed: %v", err)
+	}
+	if want := "Lead 42 rejected: ZIP_NOT_66XXX, received 2026-03-01T09:30:00Z"; message["text"] != want {
+		t.Errorf("Expected message %q, got %q", want, message["text"])
+	}
+
+	status = http.StatusInternalServerError
+	if err := notifier.Notify(context.Background(), lead, models.RejectionReasonZipNotValid); err == nil {
+		t.Error("Expected an error for a failed webhook call")
+	}
+
+	// The webhook URL is the credential and must not appear in errors
+	notifier = NewSlackRejectionNotifier("http://127.0.0.1:1/services/T000/B000/secret")
+	err := notifier.Notify(context.Background(), lead, models.RejectionReasonZipNotValid)
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("Expected an error without the webhook URL, got %v", err)
+	}
+}
+
+func TestEmailRejectionNotifier_Message(t *testing.T) {
+	sourceID := "partner-a"
+	notifier := NewEmailRejectionNotifier(config.SMTPConfig{
+		Host: "smtp.example.com",
+		Port: "587",
+		From: "leads@example.com",
+		To:   []string{"ops@example.com", "sales@example.com"},
+	})
+
+	message := string(notifier.message(&models.InboundLead{ID: 42, SourceID: &sourceID}, models.RejectionReasonNotHomeowner))
+	for _, want := range []string{
+		"From: leads@example.com\r\n",
+		"To: ops@example.com, sales@example.com\r\n",
+		"Subject: Lead 42 rejected: NOT_HOMEOWNER\r\n",
+		"\r\n\r\nLead 42 rejected: NOT_HOMEOWNER (source partner-a)\r\n",
+	} {
+		if !strings.Contains(message, want) {
+			t.Errorf("Expected the message to contain %q, got %q", want, message)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Package alerting detects operational problems that need attention, such as leads
+// that no worker retries, and reports them as structured WARN logs and metrics.
+package alerting
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/repository"
+)
+
+// maxStuckLeadsLogged bounds the WARN logs of a single check; the count covers all stuck leads
+const maxStuckLeadsLogged = 100
+
+// StuckLeadDetector reports leads that have waited in FAILED status longer than the
+// threshold. A FAILED lead waits for a retry by the worker, so stuck leads usually mean
+// that no worker is running.
+type StuckLeadDetector struct {
+	repo      repository.StuckLeadRepository
+	threshold time.Duration
+	interval  time.Duration
+	now       func() time.Time
+	warn      func(ctx context.Context, msg string, args ...any)
+
+	stuckLeads atomic.Int64 // count found by the latest check
+}
+
+// NewStuckLeadDetector creates a new StuckLeadDetector from the alerting config
+func NewStuckLeadDetector(repo repository.StuckLeadRepository, cfg config.AlertingConfig) *StuckLeadDetector {
+	return &StuckLeadDetector{
+		repo:      repo,
+		threshold: cfg.StuckLeadThreshold,
+		interval:  cfg.StuckLeadCheckInterval,
+		now:       time.Now,
+		warn:      logger.Warn,
+	}
+}
+
+// Run checks for stuck leads immediately and then every check interval until ctx is cancelled
+func (d *StuckLeadDetector) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Check(ctx); err != nil {
+			logger.LogError(ctx, "Failed to check for stuck leads", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check logs a WARN per stuck lead and returns how many leads are stuck
+func (d *StuckLeadDetector) Check(ctx context.Context) (int, error) {
+	updatedBefore := d.now().Add(-d.threshold)
+
+	count, err := d.repo.CountStuckLeads(ctx, updatedBefore)
+	if err != nil {
+		return 0, err
+	}
+	d.stuckLeads.Store(int64(count))
+	if count == 0 {
+		return 0, nil
+	}
+
+	leads, err := d.repo.FindStuckLeads(ctx, updatedBefore, maxStuckLeadsLogged)
+	if err != nil {
+		return count, err
+	}
+	for _, lead := range leads {
+		args := []any{"lead_id", lead.LeadID, "updated_at", lead.UpdatedAt, "threshold", d.threshold}
+		if lead.LastError != nil {
+			args = append(args, "last_error", *lead.LastError)
+		}
+		d.warn(ctx, "Lead stuck in FAILED status", args...)
+	}
+	if count > len(leads) {
+		d.warn(ctx, "More stuck leads than logged", "stuck_leads", count, "logged", len(leads))
+	}
+	return count, nil
+}
+
+// StuckLeads returns how many leads are stuck now and the oldest of them, or nil if none is
+func (d *StuckLeadDetector) StuckLeads(ctx context.Context) (int, *models.StuckLead, error) {
+	updatedBefore := d.now().Add(-d.threshold)
+
+	count, err := d.repo.CountStuckLeads(ctx, updatedBefore)
+	if err != nil {
+		return 0, nil, err
+	}
+	if count == 0 {
+		return 0, nil, nil
+	}
+
+	oldest, err := d.repo.FindStuckLeads(ctx, updatedBefore, 1)
+	if err != nil {
+		return count, nil, err
+	}
+	if len(oldest) == 0 {
+		// The lead was retried since it was counted
+		return count, nil, nil
+	}
+	return count, &oldest[0], nil
+}
+
+// StuckLeadCount returns how many leads the latest check found stuck
+func (d *StuckLeadDetector) StuckLeadCount() int64 {
+	return d.stuckLeads.Load()
+}
+
+// Threshold returns how long a lead may wait in FAILED status before it is stuck
+func (d *StuckLeadDetector) Threshold() time.Duration {
+	return d.threshold
+}
@@ -0,0 +1,189 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// mockStuckLeadRepo serves FAILED leads by their last update, like the database query
+type mockStuckLeadRepo struct {
+	failed        []models.StuckLead // oldest first
+	updatedBefore time.Time          // cutoff of the latest query
+	err           error
+}
+
+func (m *mockStuckLeadRepo) CountStuckLeads(ctx context.Context, updatedBefore time.Time) (int, error) {
+	leads, err := m.FindStuckLeads(ctx, updatedBefore, len(m.failed))
+	return len(leads), err
+}
+
+func (m *mockStuckLeadRepo) FindStuckLeads(ctx context.Context, updatedBefore time.Time, limit int) ([]models.StuckLead, error) {
+	m.updatedBefore = updatedBefore
+	if m.err != nil {
+		return nil, m.err
+	}
+	var leads []models.StuckLead
+	for _, lead := range m.failed {
+		if lead.UpdatedAt.Before(updatedBefore) && len(leads) < limit {
+			leads = append(leads, lead)
+		}
+	}
+	return leads, nil
+}
+
+// loggedWarning is a WARN log emitted by the detector
+type loggedWarning struct {
+	msg  string
+	args map[string]any
+}
+
+// newTestDetector returns a detector with a fixed clock that records its WARN logs
+func newTestDetector(repo *mockStuckLeadRepo, now time.Time) (*StuckLeadDetector, *[]loggedWarning) {
+	detector := NewStuckLeadDetector(repo, config.AlertingConfig{StuckLeadThreshold: time.Hour, StuckLeadCheckInterval: time.Minute})
+	detector.now = func() time.Time { return now }
+
+	var warnings []loggedWarning
+	detector.warn = func(ctx context.Context, msg string, args ...any) {
+		fields := make(map[string]any, len(args)/2)
+		for i := 0; i+1 < len(args); i += 2 {
+			fields[fmt.Sprint(args[i])] = args[i+1]
+		}
+		warnings = append(warnings, loggedWarning{msg: msg, args: fields})
+	}
+	return detector, &warnings
+}
+
+func TestStuckLeadDetector_Check(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	lastError := "connection refused"
+	repo := &mockStuckLeadRepo{failed: []models.StuckLead{
+		{LeadID: 1, UpdatedAt: now.Add(-3 * time.Hour), LastError: &lastError},
+		{LeadID: 2, UpdatedAt: now.Add(-90 * time.Minute)},
+		{LeadID: 3, UpdatedAt: now.Add(-10 * time.Minute)}, // still within the threshold
+	}}
+	detector, warnings := newTestDetector(repo, now)
+
+	count, err := detector.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if count != 2 || detector.StuckLeadCount() != 2 {
+		t.Errorf("Expected 2 stuck leads, got %d (metric %d)", count, detector.StuckLeadCount())
+	}
+	if !repo.updatedBefore.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Expected leads updated before %v, got %v", now.Add(-time.Hour), repo.updatedBefore)
+	}
+	if len(*warnings) != 2 {
+		t.Fatalf("Expected a WARN per stuck lead, got %+v", *warnings)
+	}
+	first := (*warnings)[0].args
+	if first["lead_id"] != int64(1) || first["updated_at"] != now.Add(-3*time.Hour) || first["last_error"] != lastError {
+		t.Errorf("Expected lead_id, updated_at and last_error of lead 1, got %v", first)
+	}
+	if _, ok := (*warnings)[1].args["last_error"]; ok {
+		t.Errorf("Expected no last_error for a lead without delivery attempts, got %v", (*warnings)[1].args)
+	}
+}
+
+func TestStuckLeadDetector_CheckResetsCount(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	repo := &mockStuckLeadRepo{failed: []models.StuckLead{{LeadID: 1, UpdatedAt: now.Add(-2 * time.Hour)}}}
+	detector, warnings := newTestDetector(repo, now)
+
+	detector.Check(context.Background())
+	repo.failed = nil // the lead was retried
+	count, err := detector.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if count != 0 || detector.StuckLeadCount() != 0 {
+		t.Errorf("Expected no stuck leads after the retry, got %d (metric %d)", count, detector.StuckLeadCount())
+	}
+	if len(*warnings) != 1 {
+		t.Errorf("Expected only the first check to warn, got %+v", *warnings)
+	}
+}
+
+func TestStuckLeadDetector_CheckLimitsLogs(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	repo := &mockStuckLeadRepo{}
+	for id := int64(1); id <= maxStuckLeadsLogged+5; id++ {
+		repo.failed = append(repo.failed, models.StuckLead{LeadID: id, UpdatedAt: now.Add(-2 * time.Hour)})
+	}
+	detector, warnings := newTestDetector(repo, now)
+
+	count, _ := detector.Check(context.Background())
+
+	if count != maxStuckLeadsLogged+5 {
+		t.Errorf("Expected all stuck leads to be counted, got %d", count)
+	}
+	if len(*warnings) != maxStuckLeadsLogged+1 {
+		t.Errorf("Expected %d lead warnings and a summary, got %d", maxStuckLeadsLogged, len(*warnings))
+	}
+}
+
+func TestStuckLeadDetector_StuckLeads(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	repo := &mockStuckLeadRepo{failed: []models.StuckLead{
+		{LeadID: 4, UpdatedAt: now.Add(-5 * time.Hour)},
+		{LeadID: 9, UpdatedAt: now.Add(-2 * time.Hour)},
+	}}
+	detector, _ := newTestDetector(repo, now)
+
+	count, oldest, err := detector.StuckLeads(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count != 2 || oldest == nil || oldest.LeadID != 4 {
+		t.Errorf("Expected 2 stuck leads with lead 4 the oldest, got %d and %+v", count, oldest)
+	}
+
+	repo.failed = nil
+	count, oldest, err = detector.StuckLeads(context.Background())
+	if err != nil || count != 0 || oldest != nil {
+		t.Errorf("Expected no stuck leads, got %d, %+v, %v", count, oldest, err)
+	}
+}
+
+func TestStuckLeadDetector_RepositoryError(t *testing.T) {
+	repo := &mockStuckLeadRepo{err: errors.New("connection refused")}
+	detector, _ := newTestDetector(repo, time.Now())
+
+	if _, err := detector.Check(context.Background()); err == nil {
+		t.Error("Expected the repository error")
+	}
+	if _, _, err := detector.StuckLeads(context.Background()); err == nil {
+		t.Error("Expected the repository error")
+	}
+}
+
+func TestStuckLeadDetector_RunStopsOnCancel(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	repo := &mockStuckLeadRepo{failed: []models.StuckLead{{LeadID: 1, UpdatedAt: now.Add(-2 * time.Hour)}}}
+	detector, _ := newTestDetector(repo, now)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		detector.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Run to return once the context is cancelled")
+	}
+	if detector.StuckLeadCount() != 1 {
+		t.Errorf("Expected Run to check immediately, got count %d", detector.StuckLeadCount())
+	}
+}
@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+)
+
+// awsTestAuth uses the credentials of the AWS Signature Version 4 test suite
+var awsTestAuth = config.AWSAuthConfig{
+	Region:          "us-east-1",
+	Service:         "service",
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+// awsTestTime is the request time of the AWS Signature Version 4 test suite
+var awsTestTime = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+// sigV4Request records what a mock server received from the signing transport
+type sigV4Request struct {
+	authorization string
+	amzDate       string
+	securityToken string
+	body          string
+}
+
+// newSigV4Server returns a mock server recording the signed requests it receives
+func newSigV4Server(t *testing.T) (*httptest.Server, *[]sigV4Request) {
+	var received []sigV4Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, sigV4Request{
+			authorization: r.Header.Get("Authorization"),
+			amzDate:       r.Header.Get("X-Amz-Date"),
+			securityToken: r.Header.Get("X-Amz-Security-Token"),
+			body:          string(body),
+		})
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, &received
+}
+
+// sendSigned sends req through a signing transport with the test suite's clock
+func sendSigned(t *testing.T, auth config.AWSAuthConfig, req *http.Request) {
+	t.Helper()
+	transport := newAWSSigV4Transport(http.DefaultTransport, auth)
+	transport.now = func() time.Time { return awsTestTime }
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Failed to send signed request: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestAWSSigV4Transport_TestSuiteVectors(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		signature string
+	}{
+		{"get-vanilla", "/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-vanilla-query-order-key-case", "/?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, received := newSigV4Server(t)
+			req, _ := http.NewRequest(http.MethodGet, server.URL+tt.path, nil)
+			req.Host = "example.amazonaws.com"
+
+			sendSigned(t, awsTestAuth, req)
+
+			expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=" + tt.signature
+			if len(*received) != 1 {
+				t.Fatalf("Expected one request, got %d", len(*received))
+			}
+			if got := (*received)[0]; got.authorization != expected || got.amzDate != "20150830T123600Z" {
+				t.Errorf("Expected Authorization %q at 20150830T123600Z, got %q at %s", expected, got.authorization, got.amzDate)
+			}
+		})
+	}
+}
+
+func TestAWSSigV4Transport_SignsBodyHash(t *testing.T) {
+	server, received := newSigV4Server(t)
+	payload := `{"phone":"1234567890"}`
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/leads", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	canonicalRequest, signedHeaders := awsCanonicalRequest(req, []byte(payload))
+
+	// The canonical request ends with the SHA-256 of the body
+	if !strings.HasSuffix(canonicalRequest, "\n"+sha256Hex([]byte(payload))) {
+		t.Errorf("Expected the canonical request to end with the body hash, got %q", canonicalRequest)
+	}
+	if signedHeaders != "content-type;host" {
+		t.Errorf("Expected content-type and host to be signed, got %q", signedHeaders)
+	}
+
+	sendSigned(t, awsTestAuth, req)
+	other, _ := http.NewRequest(http.MethodPost, server.URL+"/leads", strings.NewReader(`{"phone":"0987654321"}`))
+	other.Header.Set("Content-Type", "application/json")
+	sendSigned(t, awsTestAuth, other)
+
+	first, second := (*received)[0], (*received)[1]
+	if first.body != payload {
+		t.Errorf("Expected the body to reach the server after hashing, got %q", first.body)
+	}
+	if signature(first.authorization) == signature(second.authorization) {
+		t.Error("Expected requests with different bodies to have different signatures")
+	}
+}
+
+func TestAWSSigV4Transport_SessionToken(t *testing.T) {
+	server, received := newSigV4Server(t)
+	auth := awsTestAuth
+	auth.SessionToken = "session-token"
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/", nil)
+	sendSigned(t, auth, req)
+
+	got := (*received)[0]
+	if got.securityToken != "session-token" {
+		t.Errorf("Expected X-Amz-Security-Token to be sent, got %q", got.securityToken)
+	}
+	if !strings.Contains(got.authorization, "SignedHeaders=host;x-amz-date;x-amz-security-token,") {
+		t.Errorf("Expected the session token to be signed, got %q", got.authorization)
+	}
+}
+
+func TestSendLead_SignsWithAWSAuth(t *testing.T) {
+	server, received := newSigV4Server(t)
+	client := NewCustomerAPIClientFromConfig(config.CustomerAPIConfig{
+		URL:     server.URL + "/prod/leads",
+		Token:   "test-token",
+		Timeout: 30 * time.Second,
+		AWSAuth: config.AWSAuthConfig{
+			Region:          "eu-central-1",
+			Service:         "execute-api",
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "secret",
+		},
+	})
+
+	if _, err := client.SendLead(context.Background(), map[string]interface{}{"phone": "1234567890"}, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got := (*received)[0]
+	scope := "Credential=AKIDEXAMPLE/" + time.Now().UTC().Format("20060102") + "/eu-central-1/execute-api/aws4_request, "
+	if !strings.HasPrefix(got.authorization, "AWS4-HMAC-SHA256 "+scope) {
+		t.Errorf("Expected a SigV4 Authorization with scope %q instead of the bearer token, got %q", scope, got.authorization)
+	}
+	if !strings.Contains(got.authorization, "SignedHeaders=content-type;host;x-amz-date, ") || len(signature(got.authorization)) != 64 {
+		t.Errorf("Expected signed headers and a hex signature, got %q", got.authorization)
+	}
+	if got.body == "" {
+		t.Error("Expected the lead payload to be sent")
+	}
+}
+
+// signature returns the Signature component of a SigV4 Authorization header
+func signature(authorization string) string {
+	_, sig, _ := strings.Cut(authorization, "Signature=")
+	return sig
+}
@@ -0,0 +1,56 @@
+package client
+
+import "context"
+
+// DeliveryConnectionPool bounds the number of simultaneous requests to the Customer API,
+// so that the concurrent jobs of a worker stay below the API's per-IP connection limit.
+// A nil pool or one of non-positive size does not limit requests. It is safe for concurrent use.
+type DeliveryConnectionPool struct {
+	slots chan struct{}
+}
+
+// NewDeliveryConnectionPool creates a pool allowing size requests in flight
+func NewDeliveryConnectionPool(size int) *DeliveryConnectionPool {
+	if size <= 0 {
+		return &DeliveryConnectionPool{}
+	}
+	return &DeliveryConnectionPool{slots: make(chan struct{}, size)}
+}
+
+// Acquire waits for a free slot. It returns the context error if ctx ends first, in which
+// case no slot is held. Every successful Acquire must be followed by Release.
+func (p *DeliveryConnectionPool) Acquire(ctx context.Context) error {
+	if p == nil || p.slots == nil {
+		return nil
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees the slot taken by Acquire
+func (p *DeliveryConnectionPool) Release() {
+	if p == nil || p.slots == nil {
+		return
+	}
+	<-p.slots
+}
+
+// InFlight returns the number of slots currently held
+func (p *DeliveryConnectionPool) InFlight() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.slots)
+}
+
+// Size returns the maximum number of requests in flight, 0 if unlimited
+func (p *DeliveryConnectionPool) Size() int {
+	if p == nil {
+		return 0
+	}
+	return cap(p.slots)
+}
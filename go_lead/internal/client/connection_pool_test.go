@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDeliveryConnectionPool_BlocksWhenFull(t *testing.T) {
+	pool := NewDeliveryConnectionPool(2)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := pool.Acquire(ctx); err != nil {
+			t.Fatalf("Failed to acquire slot %d: %v", i, err)
+		}
+	}
+	if pool.InFlight() != 2 {
+		t.Errorf("Expected 2 slots in flight, got %d", pool.InFlight())
+	}
+
+	// A third caller waits until a slot is released
+	acquired := make(chan struct{})
+	go func() {
+		if err := pool.Acquire(ctx); err == nil {
+			close(acquired)
+		}
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Expected Acquire to block while the pool is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	pool.Release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Acquire to succeed after Release")
+	}
+}
+
+func TestDeliveryConnectionPool_ContextCancelled(t *testing.T) {
+	pool := NewDeliveryConnectionPool(1)
+	if err := pool.Acquire(context.Background()); err != nil {
+		t.Fatalf("Failed to acquire slot: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := pool.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected DeadlineExceeded while the pool is full, got %v", err)
+	}
+	if pool.InFlight() != 1 {
+		t.Errorf("Expected the cancelled caller to hold no slot, got %d in flight", pool.InFlight())
+	}
+}
+
+func TestDeliveryConnectionPool_Unlimited(t *testing.T) {
+	var nilPool *DeliveryConnectionPool
+	for _, pool := range []*DeliveryConnectionPool{nilPool, NewDeliveryConnectionPool(0)} {
+		for i := 0; i < 100; i++ {
+			if err := pool.Acquire(context.Background()); err != nil {
+				t.Fatalf("Expected an unlimited pool never to block, got %v", err)
+			}
+		}
+		pool.Release()
+		if pool.Size() != 0 || pool.InFlight() != 0 {
+			t.Errorf("Expected an unlimited pool to report size and in-flight 0, got %d and %d", pool.Size(), pool.InFlight())
+		}
+	}
+}
+
+// BenchmarkDeliveryConnectionPool sends leads from a growing number of concurrent callers
+// through a pool of 4 connections to a Customer API that takes 2ms per request. Throughput
+// grows with the callers up to the pool size and then plateaus, while the API never sees
+// more than 4 requests at once (reported as max_in_flight).
+func BenchmarkDeliveryConnectionPool(b *testing.B) {
+	const poolSize = 4
+	var inFlight, maxInFlight atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			max := maxInFlight.Load()
+			if current <= max || maxInFlight.CompareAndSwap(max, current) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewCustomerAPIClient(server.URL, "token", 10*time.Second)
+	payload := map[string]interface{}{"phone": "1234567890"}
+
+	for _, callers := range []int{1, 2, 4, 8, 16} {
+		b.Run(fmt.Sprintf("callers=%d", callers), func(b *testing.B) {
+			pool := NewDeliveryConnectionPool(poolSize)
+			maxInFlight.Store(0)
+
+			var next atomic.Int64
+			var wg sync.WaitGroup
+			start := time.Now()
+			for i := 0; i < callers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for next.Add(1) <= int64(b.N) {
+						if err := pool.Acquire(context.Background()); err != nil {
+							b.Error(err)
+							return
+						}
+						_, err := client.SendLead(context.Background(), payload, nil)
+						pool.Release()
+						if err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "leads/s")
+			b.ReportMetric(float64(maxInFlight.Load()), "max_in_flight")
+			if maxInFlight.Load() > poolSize {
+				b.Errorf("Expected at most %d requests in flight, got %d", poolSize, maxInFlight.Load())
+			}
+		})
+	}
+}
@@ -3,13 +3,24 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/metrics"
 	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/tracing"
 )
 
 // CustomerAPIClient handles communication with the external Customer API
@@ -17,6 +28,26 @@ type CustomerAPIClient struct {
 	baseURL    string
 	token      string
 	httpClient *http.Client
+
+	errorCodeField         string
+	errorMessageField      string
+	retriableErrorCodes    map[string]bool
+	nonRetriableErrorCodes map[string]bool
+	statusOutcomes         map[int]string
+	statusBodyPatterns     map[int]*regexp.Regexp
+	duplicateStatusCodes   map[int]bool
+	hashResponses          bool
+	maxResponseBytes       int // 0 = unlimited
+
+	logObfuscator *logger.LogObfuscator
+	latency       *metrics.Histogram // optional
+}
+
+// NewLatencyHistogram creates the histogram of Customer API response times in seconds
+// by status code class ("2xx", "4xx", ..., or "error" for requests without a response)
+func NewLatencyHistogram(buckets []float64) *metrics.Histogram {
+	return metrics.NewHistogram("customer_api_request_duration_seconds",
+		"Customer API response times by status code class.", "status_code_class", buckets)
 }
 
 // NewCustomerAPIClient creates a new Customer API client
@@ -27,7 +58,98 @@ func NewCustomerAPIClient(baseURL, token string, timeout time.Duration) *Custome
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
+		errorCodeField:    "error_code",
+		errorMessageField: "message",
+		hashResponses:     true,
+	}
+}
+
+// NewCustomerAPIClientFromConfig creates a new Customer API client including
+// the structured error body shape and per-code retry overrides
+func NewCustomerAPIClientFromConfig(cfg config.CustomerAPIConfig) *CustomerAPIClient {
+	c := NewCustomerAPIClient(cfg.URL, cfg.Token, cfg.Timeout)
+	if cfg.ErrorCodeField != "" {
+		c.errorCodeField = cfg.ErrorCodeField
+	}
+	if cfg.ErrorMessageField != "" {
+		c.errorMessageField = cfg.ErrorMessageField
+	}
+	c.retriableErrorCodes = toSet(cfg.RetriableErrorCodes)
+	c.nonRetriableErrorCodes = toSet(cfg.NonRetriableErrorCodes)
+	c.statusOutcomes = cfg.StatusCodeMapping
+	c.hashResponses = cfg.ResponseHashEnabled
+	c.duplicateStatusCodes = make(map[int]bool, len(cfg.DuplicateStatusCodes))
+	for _, code := range cfg.DuplicateStatusCodes {
+		c.duplicateStatusCodes[code] = true
+	}
+	c.statusBodyPatterns = make(map[int]*regexp.Regexp, len(cfg.StatusCodeBodyPatterns))
+	for code, pattern := range cfg.StatusCodeBodyPatterns {
+		// Patterns are validated when the config is loaded
+		if re, err := regexp.Compile(pattern); err == nil {
+			c.statusBodyPatterns[code] = re
+		}
+	}
+	c.httpClient.Transport = newTransport(cfg)
+	if cfg.AWSAuth.Region != "" {
+		c.httpClient.Transport = newAWSSigV4Transport(c.httpClient.Transport, cfg.AWSAuth)
+	}
+	return c
+}
+
+// newTransport returns the transport for Customer API requests with the configured
+// connection reuse and proxy settings
+func newTransport(cfg config.CustomerAPIConfig) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.MaxIdleConns > 0 {
+		// All requests go to the same host, so the per-host limit (default 2) must match
+		transport.MaxIdleConns = cfg.MaxIdleConns
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConns
+	}
+	if cfg.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	if cfg.DisableKeepAlives {
+		// Every request opens a fresh direct connection, ignoring HTTP_PROXY from the environment
+		transport.DisableKeepAlives = true
+		transport.Proxy = nil
+	}
+	if cfg.ProxyURL != "" {
+		// The proxy URL is validated when the config is loaded
+		if proxyURL, err := url.Parse(cfg.ProxyURL); err == nil {
+			transport.Proxy = proxyFunc(proxyURL, cfg.NoProxy)
+		}
+	}
+	return transport
+}
+
+// SetLogObfuscator sets the obfuscator applied to logged request payloads
+func (c *CustomerAPIClient) SetLogObfuscator(obfuscator *logger.LogObfuscator) {
+	c.logObfuscator = obfuscator
+}
+
+// SetLatencyHistogram enables recording the response time of each request
+func (c *CustomerAPIClient) SetLatencyHistogram(latency *metrics.Histogram) {
+	c.latency = latency
+}
+
+// SetMaxResponseBytes truncates the bodies of failed responses returned by SendLead to
+// maxBytes (0 = unlimited). Bodies of successful responses are kept complete, since the
+// reconciler reads the customer ID from them. Classification and hashing always use the
+// complete body.
+func (c *CustomerAPIClient) SetMaxResponseBytes(maxBytes int) {
+	c.maxResponseBytes = maxBytes
+}
+
+// observeLatency records the time since start for a response with statusCode, 0 if none was received
+func (c *CustomerAPIClient) observeLatency(statusCode int, start time.Time) {
+	if c.latency == nil {
+		return
+	}
+	class := "error"
+	if statusCode > 0 {
+		class = fmt.Sprintf("%dxx", statusCode/100)
 	}
+	c.latency.Observe(class, time.Since(start).Seconds())
 }
 
 // DeliveryResponse represents the response from the Customer API
@@ -35,13 +157,20 @@ type DeliveryResponse struct {
 	StatusCode   int
 	Body         string
 	Success      bool
+	Duplicate    bool   // the customer already has the lead
+	BodyHash     string // hex SHA-256 of the complete body; empty if response hashing is disabled
 	ErrorMessage string
+
+	// Truncated is set when Body was cut to TruncatedAt bytes, the configured maximum
+	Truncated   bool
+	TruncatedAt int
 }
 
-// SendLead sends a lead to the Customer API
+// SendLead sends a lead to the Customer API, adding the given headers (optional, e.g.
+// forwarded from the webhook request) to the request
 // Returns DeliveryResponse and error
 // The error will be a *models.DeliveryError with Retriable flag set appropriately
-func (c *CustomerAPIClient) SendLead(ctx context.Context, payload map[string]interface{}) (*DeliveryResponse, error) {
+func (c *CustomerAPIClient) SendLead(ctx context.Context, payload map[string]interface{}, headers map[string]string) (*DeliveryResponse, error) {
 	// Marshal payload to JSON
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
@@ -54,13 +183,23 @@ func (c *CustomerAPIClient) SendLead(ctx context.Context, payload map[string]int
 		return nil, models.NewDeliveryError(0, "failed to create request", false, err)
 	}
 
-	// Set headers
+	// Set headers; forwarded headers never replace the ones the client controls
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	tracing.Inject(ctx, req.Header)
+
+	// Only the shape of the payload is logged, its values are contact data
+	c.logObfuscator.Info(ctx, "Sending lead to Customer API", "url", c.baseURL,
+		"payload_keys", payloadKeys(payload), "payload_bytes", len(jsonData))
 
 	// Execute request
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		c.observeLatency(0, start)
 		// Network errors are retriable
 		return nil, models.NewDeliveryError(0, "network error", true, err)
 	}
@@ -68,32 +207,189 @@ func (c *CustomerAPIClient) SendLead(ctx context.Context, payload map[string]int
 
 	// Read response body
 	bodyBytes, err := io.ReadAll(resp.Body)
+	c.observeLatency(resp.StatusCode, start)
 	if err != nil {
 		// Failed to read response body - treat as retriable
 		return nil, models.NewDeliveryError(resp.StatusCode, "failed to read response body", true, err)
 	}
 
-	bodyString := string(bodyBytes)
+	response := &DeliveryResponse{
+		StatusCode: resp.StatusCode,
+		Body:       string(bodyBytes),
+		BodyHash:   c.hashBody(bodyBytes),
+	}
+
+	// A configured mapping for the status code takes precedence over duplicate detection
+	if _, mapped := c.statusOutcomes[resp.StatusCode]; !mapped && c.duplicateStatusCodes[resp.StatusCode] {
+		response.Success = true
+		response.Duplicate = true
+		return response, nil
+	}
 
 	// Determine if the response indicates success
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		return &DeliveryResponse{
-			StatusCode: resp.StatusCode,
-			Body:       bodyString,
-			Success:    true,
-		}, nil
+	success, retriable := c.classifyStatus(resp.StatusCode, bodyBytes)
+	if success {
+		response.Success = true
+		return response, nil
 	}
 
-	// Handle error responses
-	retriable := isRetriableStatusCode(resp.StatusCode)
+	// Handle error responses, whose bodies (e.g. HTML error pages) are stored truncated
+	bodyString, truncated := c.truncateBody(bodyBytes)
+	response.Body = bodyString
+	if truncated {
+		response.Truncated = true
+		response.TruncatedAt = c.maxResponseBytes
+	}
 	errorMessage := fmt.Sprintf("HTTP %d: %s", resp.StatusCode, bodyString)
 
-	return &DeliveryResponse{
-		StatusCode:   resp.StatusCode,
-		Body:         bodyString,
-		Success:      false,
-		ErrorMessage: errorMessage,
-	}, models.NewDeliveryError(resp.StatusCode, errorMessage, retriable, nil)
+	// Prefer the structured error code and message when the body carries them
+	errorCode, parsedMessage := c.parseErrorBody(bodyBytes)
+	if parsedMessage != "" {
+		errorMessage = fmt.Sprintf("HTTP %d: %s", resp.StatusCode, parsedMessage)
+	}
+	if errorCode != "" {
+		errorMessage = fmt.Sprintf("%s (code %s)", errorMessage, errorCode)
+		if c.retriableErrorCodes[errorCode] {
+			retriable = true
+		} else if c.nonRetriableErrorCodes[errorCode] {
+			retriable = false
+		}
+	}
+
+	deliveryErr := models.NewDeliveryError(resp.StatusCode, errorMessage, retriable, nil)
+	deliveryErr.CustomerErrorCode = errorCode
+	deliveryErr.RawBody = bodyString
+
+	response.ErrorMessage = errorMessage
+	return response, deliveryErr
+}
+
+// truncateBody returns the body cut to the configured maximum, backing off to the
+// start of a UTF-8 character so that no partial character is stored, and whether
+// it was cut
+func (c *CustomerAPIClient) truncateBody(body []byte) (string, bool) {
+	if c.maxResponseBytes <= 0 || len(body) <= c.maxResponseBytes {
+		return string(body), false
+	}
+	end := c.maxResponseBytes
+	for back := 0; back < utf8.UTFMax-1 && end > 0 && !utf8.RuneStart(body[end]); back++ {
+		end--
+	}
+	return string(body[:end]), true
+}
+
+// payloadKeys returns the sorted top-level keys of a payload for logging
+func payloadKeys(payload map[string]interface{}) []string {
+	keys := make([]string, 0, len(payload))
+	for key := range payload {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// hashBody returns the hex-encoded SHA-256 hash of a response body, or "" if response
+// hashing is disabled
+func (c *CustomerAPIClient) hashBody(body []byte) string {
+	if !c.hashResponses {
+		return ""
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// LookupLead asks the Customer API read endpoint at readURL whether it has a lead.
+// A 2xx response means found and 404 or 410 means not found; any other response or
+// a network error is returned as an error, since it says nothing about the lead.
+func (c *CustomerAPIClient) LookupLead(ctx context.Context, readURL string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, readURL, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("network error: %w", err)
+	}
+	defer resp.Body.Close()
+	// Drain the body so that the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return true, nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status %d from read endpoint", resp.StatusCode)
+	}
+}
+
+// classifyStatus decides whether a response is a success and, if not, whether it is retriable.
+// A configured status code mapping takes precedence over the default classification when
+// its body pattern, if any, matches.
+func (c *CustomerAPIClient) classifyStatus(statusCode int, body []byte) (success bool, retriable bool) {
+	if outcome, ok := c.statusOutcomes[statusCode]; ok {
+		if pattern, hasPattern := c.statusBodyPatterns[statusCode]; !hasPattern || pattern.Match(body) {
+			switch outcome {
+			case config.StatusOutcomeSuccess:
+				return true, false
+			case config.StatusOutcomeRetriableFailure:
+				return false, true
+			case config.StatusOutcomePermanentFailure:
+				return false, false
+			}
+		}
+	}
+
+	if statusCode >= 200 && statusCode < 300 {
+		return true, false
+	}
+	return false, isRetriableStatusCode(statusCode)
+}
+
+// parseErrorBody extracts the error code and message from a structured JSON error body.
+// Returns empty strings for fields that are absent or when the body is not a JSON object.
+func (c *CustomerAPIClient) parseErrorBody(body []byte) (code string, message string) {
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", ""
+	}
+	return lookupString(parsed, c.errorCodeField), lookupString(parsed, c.errorMessageField)
+}
+
+// lookupString resolves a dot-separated path in a decoded JSON object to a string.
+// Numeric codes are formatted without a fractional part.
+func lookupString(object map[string]interface{}, path string) string {
+	var current interface{} = object
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		current = m[key]
+	}
+
+	switch v := current.(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%g", v)
+	default:
+		return ""
+	}
+}
+
+// toSet converts a list of codes into a lookup set
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
 }
 
 // isRetriableStatusCode determines if an HTTP status code should trigger a retry
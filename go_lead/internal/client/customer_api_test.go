@@ -3,14 +3,28 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/logger"
 	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/tracing"
 )
 
+func init() {
+	// Initialize logger for tests
+	logger.Init()
+}
+
 func TestSendLead_Success(t *testing.T) {
 	// Create a test server that returns 200 OK
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -46,7 +60,7 @@ func TestSendLead_Success(t *testing.T) {
 		"product": map[string]interface{}{"name": "Solar Panels"},
 	}
 
-	resp, err := client.SendLead(context.Background(), payload)
+	resp, err := client.SendLead(context.Background(), payload, nil)
 
 	// Verify no error
 	if err != nil {
@@ -89,7 +103,7 @@ func TestSendLead_2xxResponses(t *testing.T) {
 			client := NewCustomerAPIClient(server.URL, "token", 30*time.Second)
 			payload := map[string]interface{}{"phone": "1234567890"}
 
-			resp, err := client.SendLead(context.Background(), payload)
+			resp, err := client.SendLead(context.Background(), payload, nil)
 
 			if err != nil {
 				t.Fatalf("Expected no error for %d response, got %v", tc.statusCode, err)
@@ -129,7 +143,7 @@ func TestSendLead_NonRetriable4xxErrors(t *testing.T) {
 			client := NewCustomerAPIClient(server.URL, "token", 30*time.Second)
 			payload := map[string]interface{}{"phone": "1234567890"}
 
-			resp, err := client.SendLead(context.Background(), payload)
+			resp, err := client.SendLead(context.Background(), payload, nil)
 
 			// Should return an error
 			if err == nil {
@@ -181,7 +195,7 @@ func TestSendLead_Retriable5xxErrors(t *testing.T) {
 			client := NewCustomerAPIClient(server.URL, "token", 30*time.Second)
 			payload := map[string]interface{}{"phone": "1234567890"}
 
-			resp, err := client.SendLead(context.Background(), payload)
+			resp, err := client.SendLead(context.Background(), payload, nil)
 
 			// Should return an error
 			if err == nil {
@@ -221,7 +235,7 @@ func TestSendLead_429TooManyRequests_Retriable(t *testing.T) {
 	client := NewCustomerAPIClient(server.URL, "token", 30*time.Second)
 	payload := map[string]interface{}{"phone": "1234567890"}
 
-	resp, err := client.SendLead(context.Background(), payload)
+	resp, err := client.SendLead(context.Background(), payload, nil)
 
 	// Should return an error
 	if err == nil {
@@ -249,7 +263,7 @@ func TestSendLead_NetworkError(t *testing.T) {
 	client := NewCustomerAPIClient("http://invalid-host-that-does-not-exist-12345.com", "token", 1*time.Second)
 	payload := map[string]interface{}{"phone": "1234567890"}
 
-	_, err := client.SendLead(context.Background(), payload)
+	_, err := client.SendLead(context.Background(), payload, nil)
 
 	// Should return an error
 	if err == nil {
@@ -280,7 +294,7 @@ func TestSendLead_Timeout(t *testing.T) {
 	client := NewCustomerAPIClient(server.URL, "token", 100*time.Millisecond)
 	payload := map[string]interface{}{"phone": "1234567890"}
 
-	_, err := client.SendLead(context.Background(), payload)
+	_, err := client.SendLead(context.Background(), payload, nil)
 
 	// Should return an error
 	if err == nil {
@@ -312,7 +326,7 @@ func TestSendLead_InvalidPayload(t *testing.T) {
 		"invalid": make(chan int), // channels cannot be marshaled to JSON
 	}
 
-	_, err := client.SendLead(context.Background(), payload)
+	_, err := client.SendLead(context.Background(), payload, nil)
 
 	// Should return an error
 	if err == nil {
@@ -346,7 +360,7 @@ func TestSendLead_ContextCancellation(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // Cancel immediately
 
-	_, err := client.SendLead(ctx, payload)
+	_, err := client.SendLead(ctx, payload, nil)
 
 	// Should return an error
 	if err == nil {
@@ -395,7 +409,7 @@ func TestSendLead_RequestBodyParsing(t *testing.T) {
 		},
 	}
 
-	_, err := client.SendLead(context.Background(), payload)
+	_, err := client.SendLead(context.Background(), payload, nil)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -419,3 +433,620 @@ func TestSendLead_RequestBodyParsing(t *testing.T) {
 		t.Errorf("Expected product name Solar Panels, got %v", product["name"])
 	}
 }
+
+func TestSendLead_ParsesStructuredErrorBody(t *testing.T) {
+	rawBody := `{"error_code": "INVALID_ZIP", "message": "zipcode is not serviced"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(rawBody))
+	}))
+	defer server.Close()
+
+	client := NewCustomerAPIClient(server.URL, "token", 30*time.Second)
+
+	resp, err := client.SendLead(context.Background(), map[string]interface{}{"phone": "1234567890"}, nil)
+
+	deliveryErr, ok := err.(*models.DeliveryError)
+	if !ok {
+		t.Fatalf("Expected *models.DeliveryError, got %T", err)
+	}
+
+	if deliveryErr.CustomerErrorCode != "INVALID_ZIP" {
+		t.Errorf("Expected customer error code INVALID_ZIP, got %q", deliveryErr.CustomerErrorCode)
+	}
+
+	expectedMessage := "HTTP 400: zipcode is not serviced (code INVALID_ZIP)"
+	if deliveryErr.Message != expectedMessage {
+		t.Errorf("Expected message %q, got %q", expectedMessage, deliveryErr.Message)
+	}
+
+	if deliveryErr.RawBody != rawBody {
+		t.Errorf("Expected raw body to be retained, got %q", deliveryErr.RawBody)
+	}
+
+	if resp.Body != rawBody {
+		t.Errorf("Expected response body to be retained, got %q", resp.Body)
+	}
+}
+
+func TestSendLead_ErrorCodeRetriabilityOverrides(t *testing.T) {
+	testCases := []struct {
+		name              string
+		statusCode        int
+		body              string
+		expectedRetriable bool
+	}{
+		{"5xx mapped to non-retriable", http.StatusServiceUnavailable, `{"error": {"code": "DUPLICATE_LEAD"}}`, false},
+		{"4xx mapped to retriable", http.StatusConflict, `{"error": {"code": "LOCKED"}}`, true},
+		{"unmapped code keeps status default", http.StatusServiceUnavailable, `{"error": {"code": "OTHER"}}`, true},
+		{"unstructured body keeps status default", http.StatusBadRequest, `bad request`, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.statusCode)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			client := NewCustomerAPIClientFromConfig(config.CustomerAPIConfig{
+				URL:                    server.URL,
+				Token:                  "token",
+				Timeout:                30 * time.Second,
+				ErrorCodeField:         "error.code",
+				RetriableErrorCodes:    []string{"LOCKED"},
+				NonRetriableErrorCodes: []string{"DUPLICATE_LEAD"},
+			})
+
+			_, err := client.SendLead(context.Background(), map[string]interface{}{"phone": "1234567890"}, nil)
+
+			deliveryErr, ok := err.(*models.DeliveryError)
+			if !ok {
+				t.Fatalf("Expected *models.DeliveryError, got %T", err)
+			}
+
+			if deliveryErr.IsRetriable() != tc.expectedRetriable {
+				t.Errorf("Expected retriable=%v, got %v", tc.expectedRetriable, deliveryErr.IsRetriable())
+			}
+		})
+	}
+}
+
+func TestSendLead_StatusCodeMapping(t *testing.T) {
+	testCases := []struct {
+		name              string
+		statusCode        int
+		body              string
+		expectSuccess     bool
+		expectedRetriable bool
+	}{
+		{"409 mapped to retriable", http.StatusConflict, `{"message": "already exists"}`, false, true},
+		{"200 with rejection body mapped to permanent failure", http.StatusOK, `{"status": "rejected"}`, false, false},
+		{"200 without rejection body keeps success", http.StatusOK, `{"status": "accepted"}`, true, false},
+		{"422 mapped to success", http.StatusUnprocessableEntity, `duplicate`, true, false},
+		{"unmapped 400 keeps default", http.StatusBadRequest, `bad request`, false, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.statusCode)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			client := NewCustomerAPIClientFromConfig(config.CustomerAPIConfig{
+				URL:     server.URL,
+				Token:   "token",
+				Timeout: 30 * time.Second,
+				StatusCodeMapping: map[int]string{
+					http.StatusConflict:            config.StatusOutcomeRetriableFailure,
+					http.StatusOK:                  config.StatusOutcomePermanentFailure,
+					http.StatusUnprocessableEntity: config.StatusOutcomeSuccess,
+				},
+				StatusCodeBodyPatterns: map[int]string{
+					http.StatusOK: `"status":\s*"rejected"`,
+				},
+			})
+
+			response, err := client.SendLead(context.Background(), map[string]interface{}{"phone": "1234567890"}, nil)
+
+			if tc.expectSuccess {
+				if err != nil || response == nil || !response.Success {
+					t.Fatalf("Expected success, got response %+v and error %v", response, err)
+				}
+				return
+			}
+
+			deliveryErr, ok := err.(*models.DeliveryError)
+			if !ok {
+				t.Fatalf("Expected *models.DeliveryError, got %T", err)
+			}
+			if deliveryErr.IsRetriable() != tc.expectedRetriable {
+				t.Errorf("Expected retriable=%v, got %v", tc.expectedRetriable, deliveryErr.IsRetriable())
+			}
+		})
+	}
+}
+
+func TestSendLead_DuplicateStatusCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"message": "lead already exists"}`))
+	}))
+	defer server.Close()
+
+	client := NewCustomerAPIClientFromConfig(config.CustomerAPIConfig{
+		URL:                  server.URL,
+		Token:                "token",
+		Timeout:              30 * time.Second,
+		DuplicateStatusCodes: []int{http.StatusConflict},
+	})
+
+	response, err := client.SendLead(context.Background(), map[string]interface{}{"phone": "1234567890"}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error for a duplicate, got %v", err)
+	}
+	if !response.Success || !response.Duplicate {
+		t.Errorf("Expected successful duplicate response, got %+v", response)
+	}
+}
+
+func TestSendLead_ResponseHash(t *testing.T) {
+	status, body := http.StatusOK, `{"id": "lead-123"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client := NewCustomerAPIClient(server.URL, "token", 30*time.Second)
+	payload := map[string]interface{}{"phone": "1234567890"}
+
+	response, err := client.SendLead(context.Background(), payload, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := "5d0d84bdf616ca39ff9719e734e6ab3a4a5462d83baba48fd5e6fa12808382d0"; response.BodyHash != want {
+		t.Errorf("Expected SHA-256 %s of the body, got %s", want, response.BodyHash)
+	}
+
+	// Error responses are hashed as well
+	status, body = http.StatusServiceUnavailable, `{"message": "unavailable"}`
+	response, _ = client.SendLead(context.Background(), payload, nil)
+	if want := "42d24e831ae770768d07e5893430cf519895b93b5a3cd286dc277b03666865e4"; response.BodyHash != want {
+		t.Errorf("Expected SHA-256 %s of the error body, got %s", want, response.BodyHash)
+	}
+
+	client = NewCustomerAPIClientFromConfig(config.CustomerAPIConfig{URL: server.URL, Token: "token", Timeout: 30 * time.Second})
+	response, _ = client.SendLead(context.Background(), payload, nil)
+	if response.BodyHash != "" {
+		t.Errorf("Expected no hash with response hashing disabled, got %s", response.BodyHash)
+	}
+}
+
+// captureLogs returns what the global logger writes while fn runs
+func captureLogs(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	logger.Init()
+	defer func() {
+		os.Stdout = stdout
+		logger.Init()
+	}()
+
+	fn()
+	w.Close()
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read logs: %v", err)
+	}
+	return string(output)
+}
+
+func TestSendLead_DoesNotLogContactData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// The default config obfuscates no fields
+	client := NewCustomerAPIClientFromConfig(config.CustomerAPIConfig{URL: server.URL, Token: "token", Timeout: 30 * time.Second})
+	client.SetLogObfuscator(logger.NewLogObfuscator(nil))
+	payload := map[string]interface{}{"phone": "+4915112345678", "email": "max@example.com", "first_name": "Max"}
+
+	logs := captureLogs(t, func() {
+		if _, err := client.SendLead(context.Background(), payload, nil); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+	for _, value := range []string{"+4915112345678", "max@example.com", "Max\""} {
+		if strings.Contains(logs, value) {
+			t.Errorf("Expected %s not to be logged, got %s", value, logs)
+		}
+	}
+	if !strings.Contains(logs, `"payload_keys":["email","first_name","phone"]`) {
+		t.Errorf("Expected the payload keys to be logged, got %s", logs)
+	}
+}
+
+func TestSendLead_TruncatesResponseBody(t *testing.T) {
+	status, body := http.StatusBadGateway, strings.Repeat("a", 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client := NewCustomerAPIClient(server.URL, "token", 30*time.Second)
+	client.SetMaxResponseBytes(16)
+	payload := map[string]interface{}{"phone": "1234567890"}
+
+	// A body of exactly the limit is kept as is
+	response, _ := client.SendLead(context.Background(), payload, nil)
+	if response.Body != body || response.Truncated || response.TruncatedAt != 0 {
+		t.Errorf("Expected the complete body without truncation, got %q (truncated=%v at %d)",
+			response.Body, response.Truncated, response.TruncatedAt)
+	}
+
+	// A longer body is cut to the limit, the hash still covers the complete body
+	body = "<html>" + strings.Repeat("x", 50000) + "</html>"
+	response, err := client.SendLead(context.Background(), payload, nil)
+	if err == nil {
+		t.Fatal("Expected an error for a 502 response")
+	}
+	if response.Body != body[:16] || !response.Truncated || response.TruncatedAt != 16 {
+		t.Errorf("Expected the body cut to 16 bytes, got %q (truncated=%v at %d)",
+			response.Body, response.Truncated, response.TruncatedAt)
+	}
+	if response.BodyHash != client.hashBody([]byte(body)) {
+		t.Error("Expected the hash of the complete body")
+	}
+	if deliveryErr := err.(*models.DeliveryError); deliveryErr.RawBody != body[:16] {
+		t.Errorf("Expected the truncated raw body on the error, got %d bytes", len(deliveryErr.RawBody))
+	}
+
+	// A multibyte character crossing the limit is dropped entirely
+	body = strings.Repeat("a", 15) + "ä"
+	response, _ = client.SendLead(context.Background(), payload, nil)
+	if response.Body != strings.Repeat("a", 15) || !response.Truncated {
+		t.Errorf("Expected the body cut before the multibyte character, got %q", response.Body)
+	}
+
+	// A successful body is kept complete, so that the customer ID can still be read from it
+	status, body = http.StatusCreated, `{"padding":"`+strings.Repeat("x", 100)+`","id":"cust-42"}`
+	response, err = client.SendLead(context.Background(), payload, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.Body != body || response.Truncated {
+		t.Errorf("Expected the complete successful body, got %q (truncated=%v)", response.Body, response.Truncated)
+	}
+}
+
+func TestSendLead_StatusCodeMappingOverridesDuplicate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer server.Close()
+
+	client := NewCustomerAPIClientFromConfig(config.CustomerAPIConfig{
+		URL:                  server.URL,
+		Token:                "token",
+		Timeout:              30 * time.Second,
+		StatusCodeMapping:    map[int]string{http.StatusConflict: config.StatusOutcomeRetriableFailure},
+		DuplicateStatusCodes: []int{http.StatusConflict},
+	})
+
+	_, err := client.SendLead(context.Background(), map[string]interface{}{"phone": "1234567890"}, nil)
+
+	deliveryErr, ok := err.(*models.DeliveryError)
+	if !ok || !deliveryErr.IsRetriable() {
+		t.Errorf("Expected retriable DeliveryError, got %v", err)
+	}
+}
+
+func TestSendLead_PropagatesTraceContext(t *testing.T) {
+	exporter := tracing.NewInMemoryExporter()
+	tracing.SetTracer(tracing.NewTracer(exporter))
+	t.Cleanup(func() { tracing.SetTracer(nil) })
+
+	var receivedTraceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedTraceparent = r.Header.Get(tracing.TraceparentHeader)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewCustomerAPIClient(server.URL, "token", 30*time.Second)
+
+	ctx, span := tracing.Start(context.Background(), "delivery")
+	defer span.End()
+
+	if _, err := client.SendLead(ctx, map[string]interface{}{"phone": "1234567890"}, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if receivedTraceparent != span.Context.Traceparent() {
+		t.Errorf("Expected traceparent %q, got %q", span.Context.Traceparent(), receivedTraceparent)
+	}
+}
+
+func TestSendLead_ForwardedHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewCustomerAPIClient(server.URL, "test-token", 30*time.Second)
+	headers := map[string]string{
+		"X-Lead-Source": "partner-a",
+		"X-Client-ID":   "client-7",
+		"Authorization": "Bearer forged",
+	}
+
+	if _, err := client.SendLead(context.Background(), map[string]interface{}{"phone": "1234567890"}, headers); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if received.Get("X-Lead-Source") != "partner-a" || received.Get("X-Client-ID") != "client-7" {
+		t.Errorf("Expected forwarded headers in the request, got %v", received)
+	}
+	if received.Get("Authorization") != "Bearer test-token" {
+		t.Errorf("Expected forwarded headers not to replace Authorization, got %q", received.Get("Authorization"))
+	}
+}
+
+// newCountingServer returns a TLS server responding after 1ms that counts the connections it accepts
+func newCountingServer(tb testing.TB) (*httptest.Server, *atomic.Int64) {
+	var conns atomic.Int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.StartTLS()
+	tb.Cleanup(server.Close)
+	return server, &conns
+}
+
+// trustServer makes client accept the test server's certificate
+func trustServer(client *CustomerAPIClient, server *httptest.Server) {
+	client.httpClient.Transport.(*http.Transport).TLSClientConfig = server.Client().Transport.(*http.Transport).TLSClientConfig
+}
+
+func TestSendLead_ConnectionReuse(t *testing.T) {
+	tests := []struct {
+		name              string
+		disableKeepAlives bool
+		wantConns         int64
+	}{
+		{"keep-alive reuses one connection", false, 1},
+		{"keep-alive disabled opens one connection per request", true, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, conns := newCountingServer(t)
+			client := NewCustomerAPIClientFromConfig(config.CustomerAPIConfig{
+				URL:               server.URL,
+				Token:             "test-token",
+				Timeout:           30 * time.Second,
+				MaxIdleConns:      10,
+				IdleConnTimeout:   90 * time.Second,
+				DisableKeepAlives: tt.disableKeepAlives,
+			})
+			trustServer(client, server)
+
+			for i := 0; i < 5; i++ {
+				if _, err := client.SendLead(context.Background(), map[string]interface{}{"phone": "1234567890"}, nil); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+			if got := conns.Load(); got != tt.wantConns {
+				t.Errorf("Expected %d connections for 5 requests, got %d", tt.wantConns, got)
+			}
+		})
+	}
+}
+
+func TestNewTransport_Settings(t *testing.T) {
+	transport := newTransport(config.CustomerAPIConfig{
+		MaxIdleConns:      25,
+		IdleConnTimeout:   30 * time.Second,
+		DisableKeepAlives: true,
+	})
+
+	if transport.MaxIdleConns != 25 || transport.MaxIdleConnsPerHost != 25 {
+		t.Errorf("Expected 25 idle connections in total and per host, got %d and %d",
+			transport.MaxIdleConns, transport.MaxIdleConnsPerHost)
+	}
+	if transport.IdleConnTimeout != 30*time.Second {
+		t.Errorf("Expected IdleConnTimeout 30s, got %v", transport.IdleConnTimeout)
+	}
+	if !transport.DisableKeepAlives || transport.Proxy != nil {
+		t.Error("Expected keep-alives and the environment proxy to be disabled")
+	}
+}
+
+// TestSendLead_ConnectionReuseConcurrent verifies that concurrent deliveries share pooled
+// connections instead of opening one per request, counting the connections the server accepts
+func TestSendLead_ConnectionReuseConcurrent(t *testing.T) {
+	const workers, requestsPerWorker = 4, 10
+	tests := []struct {
+		name              string
+		disableKeepAlives bool
+		maxConns          int64
+	}{
+		// A dial racing with a connection being freed may add a connection while the pool fills
+		{"keep-alive reuses connections across requests", false, 2 * workers},
+		{"keep-alive disabled opens one connection per request", true, workers * requestsPerWorker},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, conns := newCountingServer(t)
+			client := NewCustomerAPIClientFromConfig(config.CustomerAPIConfig{
+				URL:               server.URL,
+				Token:             "test-token",
+				Timeout:           30 * time.Second,
+				MaxIdleConns:      10,
+				IdleConnTimeout:   90 * time.Second,
+				DisableKeepAlives: tt.disableKeepAlives,
+			})
+			trustServer(client, server)
+
+			var wg sync.WaitGroup
+			errs := make(chan error, workers*requestsPerWorker)
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < requestsPerWorker; i++ {
+						if _, err := client.SendLead(context.Background(), map[string]interface{}{"phone": "1234567890"}, nil); err != nil {
+							errs <- err
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			got := conns.Load()
+			if tt.disableKeepAlives && got != tt.maxConns {
+				t.Errorf("Expected %d connections, got %d", tt.maxConns, got)
+			}
+			if got > tt.maxConns {
+				t.Errorf("Expected at most %d connections for %d requests, got %d", tt.maxConns, workers*requestsPerWorker, got)
+			}
+		})
+	}
+}
+
+// BenchmarkSendLead compares delivery throughput with and without connection reuse
+// against an HTTPS endpoint taking 1ms per request, where reuse saves the TCP and TLS
+// handshake per request. Throughput is reported as leads/s, connections opened as conns/op.
+func BenchmarkSendLead(b *testing.B) {
+	b.Run("WithReuse", benchmarkSendLead(false))
+	b.Run("WithoutReuse", benchmarkSendLead(true))
+}
+
+func benchmarkSendLead(disableKeepAlives bool) func(b *testing.B) {
+	return func(b *testing.B) {
+		server, conns := newCountingServer(b)
+		client := NewCustomerAPIClientFromConfig(config.CustomerAPIConfig{
+			URL:               server.URL,
+			Token:             "test-token",
+			Timeout:           30 * time.Second,
+			MaxIdleConns:      10,
+			IdleConnTimeout:   90 * time.Second,
+			DisableKeepAlives: disableKeepAlives,
+		})
+		trustServer(client, server)
+		payload := map[string]interface{}{"phone": "1234567890"}
+
+		b.ResetTimer()
+		start := time.Now()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := client.SendLead(context.Background(), payload, nil); err != nil {
+					b.Fatalf("Unexpected error: %v", err)
+				}
+			}
+		})
+		b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "leads/s")
+		b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+	}
+}
+
+func TestSendLead_RecordsLatency(t *testing.T) {
+	statuses := []int{http.StatusOK, http.StatusOK, http.StatusBadRequest, http.StatusServiceUnavailable}
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first response is slow enough to land above the 0.1s bucket
+		if calls == 0 {
+			time.Sleep(150 * time.Millisecond)
+		}
+		w.WriteHeader(statuses[calls])
+		calls++
+	}))
+	defer server.Close()
+
+	latency := NewLatencyHistogram([]float64{0.1, 0.25, 0.5, 1.0, 2.5, 5.0, 10.0})
+	client := NewCustomerAPIClient(server.URL, "test-token", 30*time.Second)
+	client.SetLatencyHistogram(latency)
+	for range statuses {
+		client.SendLead(context.Background(), map[string]interface{}{"phone": "1234567890"}, nil)
+	}
+
+	// Requests without a response are recorded as errors
+	unreachable := NewCustomerAPIClient("http://127.0.0.1:1", "test-token", time.Second)
+	unreachable.SetLatencyHistogram(latency)
+	unreachable.SendLead(context.Background(), map[string]interface{}{"phone": "1234567890"}, nil)
+
+	snapshot := latency.Snapshot()
+	expected := map[string]uint64{"2xx": 2, "4xx": 1, "5xx": 1, "error": 1}
+	for class, count := range expected {
+		if snapshot[class].Count != count {
+			t.Errorf("Expected %d %s observations, got %d", count, class, snapshot[class].Count)
+		}
+	}
+	if ok := snapshot["2xx"]; ok.Counts[0] != 1 || ok.Counts[1] != 2 || ok.Sum < 0.15 {
+		t.Errorf("Expected one 2xx response within 0.1s and one within 0.25s, got %+v", ok)
+	}
+}
+
+func TestLookupLead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Expected GET request, got %s", r.Method)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer test-token" {
+			t.Errorf("Expected the bearer token, got %s", auth)
+		}
+		switch r.URL.Path {
+		case "/leads/found":
+			w.Write([]byte(`{"id": "found"}`))
+		case "/leads/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/leads/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewCustomerAPIClient(server.URL, "test-token", 30*time.Second)
+	tests := []struct {
+		path    string
+		found   bool
+		wantErr bool
+	}{
+		{"/leads/found", true, false},
+		{"/leads/unknown", false, false},
+		{"/leads/gone", false, false},
+		{"/leads/broken", false, true},
+	}
+	for _, tt := range tests {
+		found, err := client.LookupLead(context.Background(), server.URL+tt.path)
+		if found != tt.found || (err != nil) != tt.wantErr {
+			t.Errorf("LookupLead(%s) = %v, %v; expected found=%v, error=%v", tt.path, found, err, tt.found, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+)
+
+func TestSendLead_RoutesThroughProxy(t *testing.T) {
+	var proxiedHost, authorization, proxyAuthorization string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute customer URL
+		proxiedHost = r.URL.Host
+		authorization = r.Header.Get("Authorization")
+		proxyAuthorization = r.Header.Get("Proxy-Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	client := NewCustomerAPIClientFromConfig(config.CustomerAPIConfig{
+		URL:      "http://customer.example/lead",
+		Token:    "test-token",
+		Timeout:  30 * time.Second,
+		ProxyURL: "http://proxyuser:proxypass@" + proxy.Listener.Addr().String(),
+	})
+
+	response, err := client.SendLead(context.Background(), map[string]interface{}{"phone": "1234567890"}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !response.Success {
+		t.Errorf("Expected success, got %+v", response)
+	}
+
+	if proxiedHost != "customer.example" {
+		t.Errorf("Expected request for customer.example to reach the proxy, got host %q", proxiedHost)
+	}
+	if authorization != "Bearer test-token" {
+		t.Errorf("Expected Authorization 'Bearer test-token', got %q", authorization)
+	}
+	expectedProxyAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("proxyuser:proxypass"))
+	if proxyAuthorization != expectedProxyAuth {
+		t.Errorf("Expected Proxy-Authorization %q, got %q", expectedProxyAuth, proxyAuthorization)
+	}
+}
+
+func TestSendLead_NoProxyBypassesProxy(t *testing.T) {
+	proxyHit := false
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxyHit = true
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer proxy.Close()
+
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewCustomerAPIClientFromConfig(config.CustomerAPIConfig{
+		URL:      server.URL,
+		Token:    "test-token",
+		Timeout:  30 * time.Second,
+		ProxyURL: proxy.URL,
+		NoProxy:  []string{"127.0.0.0/8"},
+	})
+
+	if _, err := client.SendLead(context.Background(), map[string]interface{}{"phone": "1234567890"}, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if proxyHit {
+		t.Error("Expected request to bypass the proxy")
+	}
+	if authorization != "Bearer test-token" {
+		t.Errorf("Expected Authorization 'Bearer test-token', got %q", authorization)
+	}
+}
+
+func TestBypassProxy(t *testing.T) {
+	patterns := []string{"internal.example", ".corp.local", "10.0.0.0/8", "192.168.1.5"}
+
+	tests := []struct {
+		host   string
+		bypass bool
+	}{
+		{"internal.example", true},
+		{"api.internal.example", true},
+		{"INTERNAL.example", true},
+		{"notinternal.example", false},
+		{"corp.local", true},
+		{"svc.corp.local", true},
+		{"10.1.2.3", true},
+		{"11.1.2.3", false},
+		{"192.168.1.5", true},
+		{"192.168.1.6", false},
+		{"customer.example", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := bypassProxy(tt.host, patterns); got != tt.bypass {
+				t.Errorf("bypassProxy(%q) = %v, want %v", tt.host, got, tt.bypass)
+			}
+		})
+	}
+
+	if !bypassProxy("customer.example", []string{"*"}) {
+		t.Error("Expected '*' to bypass the proxy for every host")
+	}
+}
@@ -1,17 +1,29 @@
 package config
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/policy"
+	"github.com/checkfox/go_lead/internal/transform"
 	"github.com/joho/godotenv"
 )
 
 // Config holds all configuration for the application
 type Config struct {
+	Environment      string // APP_ENV, selects the section of the config file that overrides its defaults
 	Database         DatabaseConfig
 	API              APIConfig
 	Worker           WorkerConfig
@@ -19,8 +31,28 @@ type Config struct {
 	CustomerAPI      CustomerAPIConfig
 	Retry            RetryConfig
 	Auth             AuthConfig
+	Webhook          WebhookConfig
+	Tenant           TenantConfig
 	Logging          LoggingConfig
 	AttributeMapping AttributeMappingConfig
+	Validation       ValidationConfig
+	Normalizer       NormalizerConfig
+	Enrichment       EnrichmentConfig
+	Privacy          PrivacyConfig
+	Tracing          TracingConfig
+	SelfTest         SelfTestConfig
+	Health           HealthConfig
+	JSON             JSONConfig
+	LeadStatus       LeadStatusConfig
+	Deduplication    DeduplicationConfig
+	Performance      PerformanceConfig
+	SourceQuota      SourceQuotaConfig
+	Callback         CallbackConfig
+	Export           ExportConfig
+	Alerting         AlertingConfig
+	Reconciliation   ReconciliationConfig
+	Notification     NotificationConfig
+	Storage          StorageConfig
 }
 
 // DatabaseConfig holds database connection settings
@@ -31,24 +63,40 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+
+	WriteRetryAttempts int           // attempts for idempotent writes on transient errors
+	WriteRetryBackoff  time.Duration // initial delay between write retries
+	QueryTimeout       time.Duration // maximum duration of a database statement or transaction (0 = no limit)
 }
 
 // APIConfig holds API server settings
 type APIConfig struct {
-	Port string
-	Host string
+	Port           string
+	Host           string
+	HandlerTimeout time.Duration // maximum time a handler may take before a 503 is returned (0 = no limit)
+	MaxInflight    int           // maximum number of webhook requests handled simultaneously (0 = no limit)
 }
 
 // WorkerConfig holds worker settings
 type WorkerConfig struct {
-	PollInterval time.Duration
-	Concurrency  int
+	PollInterval    time.Duration
+	MaxPollInterval time.Duration // upper bound of the poll interval while the queue stays empty
+	Concurrency     int
+	MetricsPort     string        // serves /metrics when set
+	LatencyBuckets  []float64     // upper bounds in seconds of the Customer API latency histogram
+	MaxJobs         int           // one-shot mode: process at most this many jobs, then exit (0 = run as daemon)
+	LockTimeout     time.Duration // age after which a job's processing lock is considered abandoned
+
+	// MappingReloadInterval is how often the attribute mapping file is checked for changes;
+	// a change restarts the processor with freshly loaded configuration (0 disables)
+	MappingReloadInterval time.Duration
 }
 
 // QueueConfig holds queue settings
 type QueueConfig struct {
-	Type     string // "redis" or "database"
-	RedisURL string
+	Type           string // "redis" or "database"
+	RedisURL       string
+	JobMaxAttempts int // times a job may be dequeued before it is failed, e.g. after repeated worker crashes (0 = unlimited)
 }
 
 // CustomerAPIConfig holds Customer API client settings
@@ -57,12 +105,167 @@ type CustomerAPIConfig struct {
 	Token       string
 	Timeout     time.Duration
 	ProductName string
+
+	AsyncMode           bool          // treat 202 Accepted as pending until confirmed via callback
+	ConfirmationTimeout time.Duration // how long to wait for an async confirmation
+
+	ErrorCodeField         string   // JSON path of the error code in non-2xx response bodies
+	ErrorMessageField      string   // JSON path of the error message in non-2xx response bodies
+	RetriableErrorCodes    []string // customer error codes that are always retried
+	NonRetriableErrorCodes []string // customer error codes that are never retried
+
+	// StatusCodeMapping overrides the default classification of HTTP status codes
+	// (2xx success, 429/5xx retriable, everything else permanent) with one of the
+	// StatusOutcome* values. StatusCodeBodyPatterns optionally restricts an override
+	// to responses whose body matches the regular expression given for that code.
+	StatusCodeMapping      map[int]string
+	StatusCodeBodyPatterns map[int]string
+	DuplicateStatusCodes   []int // status codes meaning the customer already has the lead
+
+	// UnexpectedResponseOutcome classifies a response that reports neither success
+	// nor an error: StatusOutcomeRetriableFailure (default) or StatusOutcomePermanentFailure.
+	// Retries are bounded by MAX_RETRY_ATTEMPTS either way.
+	UnexpectedResponseOutcome string
+
+	// ResponseHashEnabled records the SHA-256 hash of each response body with the delivery
+	// attempt, e.g. to find identical responses to different leads
+	ResponseHashEnabled bool
+
+	// AllowedPayloadFields restricts the customer payload to these top-level fields
+	// in addition to phone and product; empty means no restriction
+	AllowedPayloadFields []string
+
+	// ProductConflictAction decides what happens to a product field in the lead payload,
+	// since product.name is always set from ProductName: ProductConflictIgnore (default),
+	// ProductConflictMerge or ProductConflictReject
+	ProductConflictAction string
+
+	// KeyCase is the case style of the customer payload keys: KeyCaseAsIs (default),
+	// KeyCaseSnake or KeyCaseCamel. Only top-level keys are converted unless KeyCaseNested
+	// is set; the fields of the product object are converted only if KeyCaseProduct is set.
+	KeyCase        string
+	KeyCaseNested  bool
+	KeyCaseProduct bool
+
+	// ForwardHeaders names webhook request headers (e.g. X-Lead-Source) that are sent along
+	// with each delivery, taken from the source headers recorded with the lead
+	ForwardHeaders []string
+
+	ProxyURL string   // HTTP(S) proxy for outbound requests; credentials may be given as user:pass@
+	NoProxy  []string // hosts, domain suffixes or CIDR ranges that bypass the proxy
+
+	// Connection reuse: MaxIdleConns idle connections are kept open for IdleConnTimeout.
+	// DisableKeepAlives opens a new connection per request instead.
+	MaxIdleConns      int
+	IdleConnTimeout   time.Duration
+	DisableKeepAlives bool
+
+	// MaxConnections bounds the simultaneous delivery requests of a worker process, e.g. to
+	// stay below the Customer API's per-IP connection limit (0 = unlimited)
+	MaxConnections int
+
+	// AWSAuth signs requests with AWS Signature Version 4 for endpoints behind
+	// AWS API Gateway with IAM authentication; signing is enabled when Region is set
+	AWSAuth AWSAuthConfig
+
+	DeliverySchedule DeliverySchedule
+
+	// ForwardingChain lists secondary endpoints (e.g. a backup data warehouse) that receive
+	// each lead after the primary endpoint above. Their failures never affect the lead status.
+	// Loaded from ForwardingChainFile by LoadForwardingChain.
+	ForwardingChainFile string
+	ForwardingChain     []CustomerAPIConfig
+
+	// FallbackURL receives the deliveries of a lead once FallbackAfterAttempts attempts at
+	// the primary endpoint failed with retriable errors; empty disables the fallback.
+	// FallbackToken defaults to Token.
+	FallbackURL           string
+	FallbackToken         string
+	FallbackAfterAttempts int
+}
+
+// Outcomes a Customer API status code can be mapped to
+const (
+	StatusOutcomeSuccess          = "success"
+	StatusOutcomeRetriableFailure = "retriable_failure"
+	StatusOutcomePermanentFailure = "permanent_failure"
+)
+
+// Actions for a product field in the lead payload
+const (
+	ProductConflictIgnore = "ignore" // drop the payload product
+	ProductConflictMerge  = "merge"  // add its subfields except name to the configured product
+	ProductConflictReject = "reject" // like merge, but fail mapping if its name differs from the configured one
+)
+
+// Case styles of the customer payload keys
+const (
+	KeyCaseAsIs  = "as_is" // keep the keys as mapped
+	KeyCaseSnake = "snake" // snake_case, e.g. phone_number
+	KeyCaseCamel = "camel" // lowerCamelCase, e.g. phoneNumber
+)
+
+// AWSAuthConfig holds the credentials and scope for AWS Signature Version 4 request signing
+type AWSAuthConfig struct {
+	Region          string // e.g. eu-central-1; empty disables signing
+	Service         string // signing name of the service, execute-api for API Gateway
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // only for temporary credentials
+}
+
+// DeliverySchedule restricts when leads may be delivered to the Customer API.
+// Empty lists place no restriction on hours or weekdays.
+type DeliverySchedule struct {
+	AllowedHours    []int          // hours of the day (0-23) in which delivery is allowed
+	AllowedWeekdays []time.Weekday // days of the week on which delivery is allowed
+	Timezone        string         // IANA timezone the schedule is evaluated in
 }
 
 // RetryConfig holds retry logic settings
 type RetryConfig struct {
-	MaxAttempts   int
-	BackoffBase   time.Duration
+	MaxAttempts     int
+	BackoffBase     time.Duration
+	PriorityPenalty int // queue priority added per failed delivery attempt
+	// AttemptRetention keeps at most this many delivery attempt rows per lead, dropping
+	// the oldest (0 = unlimited). Attempt numbering and the retry limit are unaffected.
+	AttemptRetention int
+}
+
+// Bounds of the retry schedule checked by RetryConfig.Validate
+const (
+	MaxRetryAttemptsLimit = 10             // highest allowed MAX_RETRY_ATTEMPTS
+	MaxRetryDelay         = 24 * time.Hour // longest allowed delay between two delivery attempts, waited out in the queue
+)
+
+// Delays returns the exponential backoff schedule: the delay after the i-th failed
+// delivery attempt (counted from 0) is BackoffBase * 2^i
+func (r RetryConfig) Delays() []time.Duration {
+	if r.MaxAttempts <= 0 {
+		return nil
+	}
+	delays := make([]time.Duration, r.MaxAttempts)
+	for i := range delays {
+		delays[i] = r.BackoffBase << uint(i)
+	}
+	return delays
+}
+
+// Validate checks that the retry schedule is bounded: MaxAttempts is between 1 and
+// MaxRetryAttemptsLimit, BackoffBase is positive and no delay exceeds MaxRetryDelay
+func (r RetryConfig) Validate() error {
+	if r.MaxAttempts < 1 || r.MaxAttempts > MaxRetryAttemptsLimit {
+		return fmt.Errorf("MAX_RETRY_ATTEMPTS must be between 1 and %d, got %d", MaxRetryAttemptsLimit, r.MaxAttempts)
+	}
+	if r.BackoffBase <= 0 {
+		return fmt.Errorf("RETRY_BACKOFF_BASE must be positive, got %v", r.BackoffBase)
+	}
+	// Compare against the base instead of computing the longest delay, which could overflow
+	if r.BackoffBase > MaxRetryDelay>>uint(r.MaxAttempts-1) {
+		return fmt.Errorf("RETRY_BACKOFF_BASE %v with MAX_RETRY_ATTEMPTS %d exceeds the maximum delay of %v (longest delay %v)",
+			r.BackoffBase, r.MaxAttempts, MaxRetryDelay, r.BackoffBase*time.Duration(1<<uint(r.MaxAttempts-1)))
+	}
+	return nil
 }
 
 // AuthConfig holds authentication settings
@@ -71,25 +274,362 @@ type AuthConfig struct {
 	SharedSecret string
 }
 
+// WebhookConfig holds webhook intake settings
+type WebhookConfig struct {
+	RequireSourceID     bool     // reject leads without a resolvable X-Source-ID
+	MinPayloadFields    int      // minimum number of top-level payload keys
+	RequiredPayloadKeys []string // top-level keys that must be present
+	ResponseFormat      string   // default success response format: "json", "text", or "xml"
+	AllowedCIDRs        []string // client IP ranges allowed to send webhooks; empty allows all
+	TrustedProxies      []string // proxy IPs or CIDRs whose X-Forwarded-For header is trusted
+	BodyTransformExpr   string   // jq expression reshaping the request body before the lead is created
+	MaxBodyBytes        int      // request bodies beyond this size are rejected with 413 (0 = no limit)
+
+	// AcceptanceRule restricts when new leads are accepted, as an RFC 5545 RRULE
+	// subset (e.g. FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR;BYHOUR=8,9,10,11,12,13,14,15,16,17).
+	// It is parsed into AcceptanceSchedule by LoadAcceptanceSchedule; empty accepts leads at any time.
+	AcceptanceRule     string
+	AcceptanceSchedule DeliverySchedule
+}
+
+// TenantConfig holds multi-tenant settings
+type TenantConfig struct {
+	Enabled bool // scope all API lead queries to the request tenant
+
+	// Secrets maps each tenant ID to the shared secret its callers authenticate with; the
+	// tenant of a request is the one whose secret it presents
+	Secrets map[string]string
+}
+
 // LoggingConfig holds logging settings
 type LoggingConfig struct {
 	Level  string
 	Format string
+
+	// MappingSample thins out the per-attribute mapping logs of successful decisions;
+	// failures and omissions are always logged
+	MappingSample LogSample
+}
+
+// LogSample keeps 1 in Every log lines, or at most PerSecond lines per second if set
+type LogSample struct {
+	Every     int
+	PerSecond int
 }
 
 // AttributeMappingConfig holds attribute mapping configuration
 type AttributeMappingConfig struct {
 	FilePath string
-	Mapping  map[string]AttributeDefinition
+	Mapping  map[string]AttributeDefinition // default profile
+
+	// ProfileDir optionally holds additional mapping files, one profile per
+	// <name>.json, selected per lead source or product
+	ProfileDir string
+	Profiles   map[string]map[string]AttributeDefinition
+
+	// DefaultMaxFieldBytes limits the size of string values of attributes without their
+	// own max_value_bytes (0 = no limit)
+	DefaultMaxFieldBytes int
+
+	// StrictUnknown decides what happens to payload fields without a mapping rule:
+	// StrictUnknownOff (default) delivers them as-is, StrictUnknownDrop omits them and
+	// StrictUnknownFail fails the mapping of the lead
+	StrictUnknown string
 }
 
 // AttributeDefinition defines validation rules for an attribute
 type AttributeDefinition struct {
-	Type     string   `json:"type"`     // "text", "dropdown", "range"
-	Required bool     `json:"required"` // true for core fields
-	Options  []string `json:"options"`  // for dropdown type
-	Min      *float64 `json:"min"`      // for range type
-	Max      *float64 `json:"max"`      // for range type
+	Type     string   `json:"type"`      // "text", "dropdown", "range", "email", "url", "date", "phone", "base64"
+	Required bool     `json:"required"`  // true for core fields
+	Options  []string `json:"options"`   // for dropdown type
+	Min      *float64 `json:"min"`       // for range type
+	Max      *float64 `json:"max"`       // for range type
+	OutputAs string   `json:"output_as"` // for range type: "number" (default) or "string"
+	Format   string   `json:"format"`    // for date type: Go time layout, default "2006-01-02"
+
+	MinLength int `json:"min_length"` // for text type: minimum length in characters (0 = no minimum)
+	MaxLength int `json:"max_length"` // for text type: maximum length in characters (0 = no limit)
+
+	MaxValueBytes int `json:"max_value_bytes"` // size limit of string values in bytes (0 = mapping default)
+
+	// MaxDecodedBytes limits the decoded size of base64 attachments (0 = no limit).
+	// max_value_bytes and the mapping default do not apply to base64 attributes.
+	MaxDecodedBytes int `json:"max_decoded_bytes"`
+
+	// FallbackFields are payload fields, e.g. ["mobile", "tel"] for phone, whose value is used
+	// in order when the attribute itself is missing or empty
+	FallbackFields []string `json:"fallback_fields"`
+}
+
+// Range attribute output forms
+const (
+	RangeOutputNumber = "number" // emit the validated value as a JSON number
+	RangeOutputString = "string" // emit the validated value in its original string form
+)
+
+// ValidationConfig holds additional lead validation rules
+type ValidationConfig struct {
+	DependencyRulesFile string // optional JSON file with cross-field dependency rules
+	DependencyRules     []FieldDependencyRule
+	// RuleSeverities sets a ValidationSeverity* per ValidationRule*; rules not
+	// listed reject the lead
+	RuleSeverities map[string]string
+	// LengthExceedAction is LengthExceedReject (default) or LengthExceedTruncate and
+	// decides what happens to text attributes longer than their max_length
+	LengthExceedAction string
+	// Leads matching any deny pattern are rejected as spam: case-insensitive regular
+	// expressions for the email and name fields, and exact phone numbers compared digits only
+	DenyEmailPatterns []string
+	DenyPhoneValues   []string
+	DenyNamePatterns  []string
+	// SuppressionRulesFile is an optional JSON file with suppression rules
+	SuppressionRulesFile string
+	SuppressionRules     []SuppressionRule
+	// SuppressionListEnabled rejects leads whose normalized phone or email is in the
+	// suppression_list table
+	SuppressionListEnabled bool
+	// PoliciesFile is an optional JSON array of policy expressions over the lead payload,
+	// written in the expression language of package policy; a lead is rejected if
+	// any of them evaluates to false
+	PoliciesFile string
+	Policies     []string
+}
+
+// Validation rules whose severity can be configured
+const (
+	ValidationRuleZipcode    = "zipcode"
+	ValidationRuleHomeowner  = "homeowner"
+	ValidationRuleDependency = "dependency"
+)
+
+// Severities of a failed validation rule
+const (
+	ValidationSeverityReject = "reject" // reject the lead
+	ValidationSeverityWarn   = "warn"   // deliver the lead with a flag
+)
+
+// Handling of payload fields without a mapping rule
+const (
+	StrictUnknownOff  = "off"  // deliver the field as-is
+	StrictUnknownDrop = "drop" // omit the field and record it as omitted
+	StrictUnknownFail = "fail" // fail the mapping, which fails the lead permanently
+)
+
+// Actions for text attributes exceeding their max_length
+const (
+	LengthExceedReject   = "reject"   // treat the attribute as invalid
+	LengthExceedTruncate = "truncate" // cut the value to max_length and log a warning
+)
+
+// NormalizerConfig holds additional lead normalization rules
+type NormalizerConfig struct {
+	ValueAliasesFile string // optional JSON file with per-field value aliases
+	// ValueAliases maps a dot-separated field path to replacements for its trimmed
+	// string values, e.g. {"house.is_owner": {"yes": true, "own": true}}
+	ValueAliases map[string]map[string]interface{}
+
+	// PhoneCountryCodeMode controls the country code of normalized phone numbers:
+	// keep (default) leaves the digits as received, prepend adds PhoneDefaultCC to
+	// national numbers and strip turns numbers with PhoneDefaultCC into national ones
+	PhoneCountryCodeMode string
+	PhoneDefaultCC       string // country code digits without "+", e.g. "49"
+	PhoneTrunkPrefix     string // national trunk prefix, e.g. "0" in Germany; empty if none
+
+	// NormalizeZip canonicalizes zip codes before validation: it strips ZipCountryPrefix
+	// (e.g. "DE-66123"), a ZIP+4 suffix ("66123-4567") and all non-alphanumerics and
+	// uppercases the rest
+	NormalizeZip     bool
+	ZipCountryPrefix string // letters, e.g. "DE"; empty if none
+
+	// NormalizeKeys converts camelCase payload keys (e.g. "phoneNumber") to snake_case
+	// before validation and mapping; AcronymPreservation lists acronyms such as "ID"
+	// that are kept as one word
+	NormalizeKeys       bool
+	AcronymPreservation []string
+}
+
+// Phone country code normalization modes
+const (
+	PhoneCountryCodeKeep    = "keep"
+	PhoneCountryCodePrepend = "prepend"
+	PhoneCountryCodeStrip   = "strip"
+)
+
+// EnrichmentConfig holds settings for the enrichment chain run before mapping
+type EnrichmentConfig struct {
+	Enrichers   []string // enricher names in execution order, e.g. "region"
+	FailOnError bool     // fail the lead instead of skipping a failing enricher
+}
+
+// PrivacyConfig holds settings for keeping PII out of log output and for compliance records
+type PrivacyConfig struct {
+	ObfuscatedFields []string // field names whose values are logged as REDACTED_<FIELD>
+
+	// HistoryMaskedFields are the field names whose values the normalization diff and
+	// audit of lead histories show as REDACTED_<FIELD>, in addition to ObfuscatedFields
+	HistoryMaskedFields []string
+
+	// NormalizationAuditEnabled records every field value normalization changed in the
+	// normalization_audit table
+	NormalizationAuditEnabled bool
+
+	// EncryptionKey is the base64-encoded 256-bit key under which the values of
+	// EncryptedFields are stored encrypted; encryption is disabled when empty
+	EncryptionKey   string
+	EncryptedFields []string // field names whose values are encrypted at rest
+}
+
+// TracingConfig holds OpenTelemetry trace export settings
+type TracingConfig struct {
+	OTLPEndpoint string // OTLP/HTTP collector base URL; tracing is disabled when empty
+	ServiceName  string // overrides the default service name of each binary
+}
+
+// SelfTestConfig holds startup self-test settings
+type SelfTestConfig struct {
+	Enabled bool // verify database, migrations, queue, mapping and Customer API wiring on startup
+}
+
+// JSONConfig holds settings for decoding lead and job payloads
+type JSONConfig struct {
+	UseNumber bool // keep numbers as json.Number so large integers are not rounded to float64
+}
+
+// CallbackConfig holds settings for outbound lead outcome notifications. A callback URL sent
+// with the lead (X-Callback-URL) takes precedence over the per-source URL, which takes
+// precedence over the default URL. Callbacks are signed with SHARED_SECRET and are disabled
+// without it.
+type CallbackConfig struct {
+	URL          string            // default URL notified of final lead outcomes (empty = only per-lead/per-source URLs)
+	SourceURLs   map[string]string // callback URL per source ID
+	AllowedHosts []string          // hosts accepted in X-Callback-URL (empty = any host with a public address)
+}
+
+// ExportConfig holds settings for the /export/leads endpoint
+type ExportConfig struct {
+	MaxRows int // maximum leads per export; larger exports are truncated (0 = unlimited)
+}
+
+// StorageConfig holds limits on what is stored per lead
+type StorageConfig struct {
+	// MaxAttemptResponseBytes truncates the Customer API response body stored with each
+	// failed delivery attempt to this many bytes (0 = unlimited)
+	MaxAttemptResponseBytes int
+}
+
+// AlertingConfig holds settings for operational alerts logged by the API server
+type AlertingConfig struct {
+	// StuckLeadThreshold is how long a lead may wait in FAILED status for a retry before it is
+	// reported as stuck, e.g. because no worker is running
+	StuckLeadThreshold     time.Duration
+	StuckLeadCheckInterval time.Duration // how often to check for stuck leads (0 disables the check)
+}
+
+// NotificationConfig holds settings for notifying operators of rejected leads. Slack and
+// email notifications are independent; each is enabled by its own settings.
+type NotificationConfig struct {
+	SlackWebhookURL string     // Slack incoming webhook notified of rejected leads (empty = disabled)
+	EmailSMTP       SMTPConfig // mail server and recipients of rejection emails (no host = disabled)
+
+	Reasons []string      // rejection reasons that are notified
+	Timeout time.Duration // maximum time a single notification may take
+}
+
+// SMTPConfig holds the mail server and addresses for email notifications
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string // PLAIN authentication is used if set
+	Password string
+	From     string
+	To       []string
+}
+
+// Placeholders of the Customer API read URL
+const (
+	ReadURLLeadID     = "{lead_id}"     // our lead ID
+	ReadURLCustomerID = "{customer_id}" // the ID from the customer's delivery response
+)
+
+// ReconciliationConfig holds settings for the worker's periodic check that delivered
+// leads exist at the Customer API
+type ReconciliationConfig struct {
+	// ReadURL is the Customer API endpoint returning a lead, with ReadURLLeadID or
+	// ReadURLCustomerID in place of the ID, e.g. https://api.example.com/leads/{customer_id}.
+	// Reconciliation is disabled when empty.
+	ReadURL string
+	// CustomerIDField is the JSON path of the customer's lead ID in the delivery response body
+	CustomerIDField string
+	Interval        time.Duration // how often to check recently delivered leads
+	Window          time.Duration // how long after delivery a lead is checked
+	BatchSize       int           // leads checked per run
+}
+
+// DeduplicationConfig holds settings for server-side duplicate submission detection
+type DeduplicationConfig struct {
+	TimeWindowSeconds int // leads with the same phone or email from the same IP within this window get 429 (0 disables)
+	// GracePeriodHours returns the existing lead for a payload received again within this many
+	// hours of that lead; later submissions create a new lead (0 disables)
+	GracePeriodHours int
+}
+
+// PerformanceConfig holds settings trading accuracy for throughput under high load
+type PerformanceConfig struct {
+	// DeduplicationSampleRate is the share of webhook payloads (0.0 to 1.0) looked up for a
+	// repeated submission within the grace period. The decision is derived from the payload
+	// hash, so the same payload is always either checked or skipped.
+	DeduplicationSampleRate float64
+}
+
+// SourceQuotaConfig holds the daily lead quotas of webhook sources
+type SourceQuotaConfig struct {
+	Quotas   map[string]int // maximum leads per day by source ID; sources not listed are unlimited
+	Window   string         // SourceQuotaWindowRolling (default) or SourceQuotaWindowCalendarDay
+	Action   string         // SourceQuotaActionReject (default) or SourceQuotaActionFlag
+	Timezone string         // IANA timezone in which calendar days start
+}
+
+// Windows a source quota is counted in
+const (
+	SourceQuotaWindowRolling     = "rolling"      // the last 24 hours
+	SourceQuotaWindowCalendarDay = "calendar_day" // since midnight in the quota timezone
+)
+
+// Actions for leads beyond a source's quota
+const (
+	SourceQuotaActionReject = "reject" // answer 429 without storing the lead
+	SourceQuotaActionFlag   = "flag"   // accept the lead and deliver it with a flag
+)
+
+// LeadStatusConfig holds settings for the lead status machine
+type LeadStatusConfig struct {
+	ExtraTransitions []string // "FROM>TO" transitions allowed in addition to the processing pipeline's
+}
+
+// HealthConfig holds settings for the /health/ready readiness checks
+type HealthConfig struct {
+	MaxPendingJobs    int           // the pod is unready while more due jobs are queued; 0 disables the check
+	CheckTimeout      time.Duration // timeout of each individual check
+	CustomerAPIMethod string        // HEAD or OPTIONS, for endpoints that reject one of them
+
+	// SchemaBehindDegraded reports pending migrations as degraded instead of unready,
+	// so the pod keeps receiving traffic while a rolling deploy migrates the database
+	SchemaBehindDegraded bool
+}
+
+// FieldDependencyRule requires the ThenRequired fields whenever IfPresent is set.
+// Fields are addressed by dot-separated paths, e.g. "house.solar_panel_type".
+type FieldDependencyRule struct {
+	IfPresent    string   `json:"if_present"`
+	ThenRequired []string `json:"then_required"`
+}
+
+// SuppressionRule permanently rejects leads whose Field value matches the regular expression
+// Pattern. Fields are addressed by dot-separated paths; Reason defaults to SUPPRESSED.
+type SuppressionRule struct {
+	Field   string                 `json:"field"`
+	Pattern string                 `json:"pattern"`
+	Reason  models.RejectionReason `json:"reason"`
 }
 
 // Load loads configuration from environment variables and files
@@ -97,7 +637,23 @@ func Load() (*Config, error) {
 	// Load .env file if it exists (ignore error if not found)
 	_ = godotenv.Load()
 
+	// Settings of the optional config file apply where no environment variable is set
+	environment := getEnv("APP_ENV", EnvironmentDevelopment)
+	fileValuesMu.Lock()
+	defer func() {
+		fileValues = nil
+		fileValuesMu.Unlock()
+	}()
+	if path := getEnv("CONFIG_FILE", ""); path != "" {
+		values, err := LoadConfigFile(path, environment)
+		if err != nil {
+			return nil, err
+		}
+		fileValues = values
+	}
+
 	cfg := &Config{
+		Environment: environment,
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
 			Port:     getEnv("DB_PORT", "5433"),
@@ -105,41 +661,232 @@ func Load() (*Config, error) {
 			Password: getEnv("DB_PASSWORD", "postgres"),
 			DBName:   getEnv("DB_NAME", "lead_gateway"),
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+
+			WriteRetryAttempts: parseInt(getEnv("DB_WRITE_RETRY_ATTEMPTS", "3"), 3),
+			WriteRetryBackoff:  parseDuration(getEnv("DB_WRITE_RETRY_BACKOFF", "100ms"), 100*time.Millisecond),
+			QueryTimeout:       parseDuration(getEnv("DB_QUERY_TIMEOUT", "30s"), 30*time.Second),
 		},
 		API: APIConfig{
-			Port: getEnv("API_PORT", "8080"),
-			Host: getEnv("API_HOST", "0.0.0.0"),
+			Port:           getEnv("API_PORT", "8080"),
+			Host:           getEnv("API_HOST", "0.0.0.0"),
+			HandlerTimeout: parseDuration(getEnv("HANDLER_TIMEOUT", "10s"), 10*time.Second),
+			MaxInflight:    parseInt(getEnv("MAX_INFLIGHT_REQUESTS", "100"), 100),
 		},
 		Worker: WorkerConfig{
-			PollInterval: parseDuration(getEnv("WORKER_POLL_INTERVAL", "5s"), 5*time.Second),
-			Concurrency:  parseInt(getEnv("WORKER_CONCURRENCY", "5"), 5),
+			PollInterval:    parseDuration(getEnv("WORKER_POLL_INTERVAL", "5s"), 5*time.Second),
+			MaxPollInterval: parseDuration(getEnv("WORKER_MAX_POLL_INTERVAL", "60s"), 60*time.Second),
+			Concurrency:     parseInt(getEnv("WORKER_CONCURRENCY", "5"), 5),
+			MetricsPort:     getEnv("WORKER_METRICS_PORT", ""),
+			LatencyBuckets:  parseFloatList(getEnv("CUSTOMER_API_LATENCY_BUCKETS", "0.1,0.25,0.5,1,2.5,5,10")),
+			MaxJobs:         parseInt(getEnv("WORKER_MAX_JOBS", "0"), 0),
+			LockTimeout:     parseDuration(getEnv("WORKER_LOCK_TIMEOUT", "10m"), 10*time.Minute),
+
+			MappingReloadInterval: parseDuration(getEnv("WORKER_MAPPING_RELOAD_INTERVAL", "5s"), 5*time.Second),
 		},
 		Queue: QueueConfig{
-			Type:     getEnv("QUEUE_TYPE", "redis"),
-			RedisURL: getEnv("REDIS_URL", "redis://localhost:6379/0"),
+			Type:           getEnv("QUEUE_TYPE", "redis"),
+			RedisURL:       getEnv("REDIS_URL", "redis://localhost:6379/0"),
+			JobMaxAttempts: parseInt(getEnv("QUEUE_JOB_MAX_ATTEMPTS", "10"), 10),
 		},
 		CustomerAPI: CustomerAPIConfig{
 			URL:         getEnv("CUSTOMER_API_URL", ""),
 			Token:       getEnv("CUSTOMER_API_TOKEN", ""),
 			Timeout:     parseDuration(getEnv("CUSTOMER_API_TIMEOUT", "30s"), 30*time.Second),
 			ProductName: getEnv("CUSTOMER_PRODUCT_NAME", ""),
+
+			AsyncMode:           parseBool(getEnv("CUSTOMER_API_ASYNC_MODE", "false")),
+			ConfirmationTimeout: parseDuration(getEnv("CUSTOMER_API_CONFIRMATION_TIMEOUT", "1h"), time.Hour),
+
+			ErrorCodeField:            getEnv("CUSTOMER_API_ERROR_CODE_FIELD", "error_code"),
+			ErrorMessageField:         getEnv("CUSTOMER_API_ERROR_MESSAGE_FIELD", "message"),
+			RetriableErrorCodes:       parseList(getEnv("CUSTOMER_API_RETRIABLE_ERROR_CODES", "")),
+			NonRetriableErrorCodes:    parseList(getEnv("CUSTOMER_API_NON_RETRIABLE_ERROR_CODES", "")),
+			StatusCodeMapping:         parseStatusCodeMap(getEnv("CUSTOMER_API_STATUS_CODE_MAPPING", "")),
+			DuplicateStatusCodes:      parseIntList(getEnv("CUSTOMER_API_DUPLICATE_STATUS_CODES", "409")),
+			UnexpectedResponseOutcome: getEnv("CUSTOMER_API_UNEXPECTED_RESPONSE_OUTCOME", StatusOutcomeRetriableFailure),
+			ResponseHashEnabled:       parseBool(getEnv("CUSTOMER_API_RESPONSE_HASH_ENABLED", "true")),
+			ForwardingChainFile:       getEnv("CUSTOMER_API_FORWARDING_CHAIN_FILE", ""),
+			FallbackURL:               getEnv("CUSTOMER_API_FALLBACK_URL", ""),
+			FallbackToken:             getEnv("CUSTOMER_API_FALLBACK_TOKEN", ""),
+			FallbackAfterAttempts:     parseInt(getEnv("CUSTOMER_API_FALLBACK_AFTER_ATTEMPTS", "3"), 3),
+			AllowedPayloadFields:      parseList(getEnv("CUSTOMER_API_ALLOWED_FIELDS", "")),
+			ProductConflictAction:     getEnv("CUSTOMER_PRODUCT_CONFLICT_ACTION", ProductConflictIgnore),
+			KeyCase:                   getEnv("CUSTOMER_KEY_CASE", KeyCaseAsIs),
+			KeyCaseNested:             parseBool(getEnv("CUSTOMER_KEY_CASE_NESTED", "false")),
+			KeyCaseProduct:            parseBool(getEnv("CUSTOMER_KEY_CASE_PRODUCT", "false")),
+			ForwardHeaders:            parseList(getEnv("CUSTOMER_API_FORWARD_HEADERS", "")),
+			ProxyURL:                  getEnv("CUSTOMER_API_PROXY_URL", ""),
+			NoProxy:                   parseList(getEnv("CUSTOMER_API_NO_PROXY", "")),
+			MaxIdleConns:              parseInt(getEnv("CUSTOMER_API_MAX_IDLE_CONNS", "10"), 10),
+			IdleConnTimeout:           parseDuration(getEnv("CUSTOMER_API_IDLE_CONN_TIMEOUT", "90s"), 90*time.Second),
+			DisableKeepAlives:         parseBool(getEnv("CUSTOMER_API_DISABLE_KEEP_ALIVES", "false")),
+			MaxConnections:            parseInt(getEnv("CUSTOMER_API_MAX_CONNECTIONS", "10"), 10),
+
+			AWSAuth: AWSAuthConfig{
+				Region:          getEnv("CUSTOMER_API_AWS_REGION", ""),
+				Service:         getEnv("CUSTOMER_API_AWS_SERVICE", "execute-api"),
+				AccessKeyID:     getEnv("CUSTOMER_API_AWS_ACCESS_KEY_ID", ""),
+				SecretAccessKey: getEnv("CUSTOMER_API_AWS_SECRET_ACCESS_KEY", ""),
+				SessionToken:    getEnv("CUSTOMER_API_AWS_SESSION_TOKEN", ""),
+			},
+
+			DeliverySchedule: DeliverySchedule{
+				AllowedHours:    parseIntList(getEnv("DELIVERY_ALLOWED_HOURS", "")),
+				AllowedWeekdays: parseWeekdays(getEnv("DELIVERY_ALLOWED_WEEKDAYS", "")),
+				Timezone:        getEnv("DELIVERY_TIMEZONE", "UTC"),
+			},
 		},
 		Retry: RetryConfig{
 			MaxAttempts: parseInt(getEnv("MAX_RETRY_ATTEMPTS", "5"), 5),
 			BackoffBase: parseDuration(getEnv("RETRY_BACKOFF_BASE", "30s"), 30*time.Second),
+
+			PriorityPenalty:  parseInt(getEnv("RETRY_PRIORITY_PENALTY", "1"), 1),
+			AttemptRetention: parseInt(getEnv("DELIVERY_ATTEMPT_RETENTION", "0"), 0),
 		},
 		Auth: AuthConfig{
 			Enabled:      parseBool(getEnv("ENABLE_AUTH", "false")),
 			SharedSecret: getEnv("SHARED_SECRET", ""),
 		},
+		Webhook: WebhookConfig{
+			RequireSourceID:     parseBool(getEnv("REQUIRE_SOURCE_ID", "false")),
+			MinPayloadFields:    parseInt(getEnv("WEBHOOK_MIN_PAYLOAD_FIELDS", "1"), 1),
+			RequiredPayloadKeys: parseList(getEnv("WEBHOOK_REQUIRED_PAYLOAD_KEYS", "")),
+			ResponseFormat:      getEnv("WEBHOOK_RESPONSE_FORMAT", "json"),
+			AllowedCIDRs:        parseList(getEnv("WEBHOOK_ALLOWED_CIDRS", "")),
+			TrustedProxies:      parseList(getEnv("WEBHOOK_TRUSTED_PROXIES", "")),
+			BodyTransformExpr:   getEnv("WEBHOOK_BODY_TRANSFORM", ""),
+			MaxBodyBytes:        parseInt(getEnv("WEBHOOK_MAX_BODY_BYTES", "1048576"), 1048576),
+			AcceptanceRule:      getEnv("WEBHOOK_ACCEPTANCE_RRULE", ""),
+			AcceptanceSchedule: DeliverySchedule{
+				Timezone: getEnv("WEBHOOK_ACCEPTANCE_TIMEZONE", "UTC"),
+			},
+		},
+		Tenant: TenantConfig{
+			Enabled: parseBool(getEnv("MULTI_TENANT_ENABLED", "false")),
+			Secrets: parseKeyValueMap(getEnv("TENANT_SECRETS", "")),
+		},
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
+
+			MappingSample: parseLogSample(getEnv("MAPPING_LOG_SAMPLE", "1")),
 		},
 		AttributeMapping: AttributeMappingConfig{
-			FilePath: getEnv("ATTRIBUTE_MAPPING_FILE", "./config/customer_attribute_mapping.json"),
+			FilePath:   getEnv("ATTRIBUTE_MAPPING_FILE", "./config/customer_attribute_mapping.json"),
+			ProfileDir: getEnv("MAPPING_PROFILE_DIR", ""),
+
+			DefaultMaxFieldBytes: parseInt(getEnv("ATTRIBUTE_MAX_FIELD_BYTES", "4096"), 4096),
+			StrictUnknown:        getEnv("MAPPING_STRICT_UNKNOWN", StrictUnknownOff),
 		},
+		Validation: ValidationConfig{
+			DependencyRulesFile: getEnv("VALIDATION_DEPENDENCY_RULES_FILE", ""),
+			RuleSeverities:      parseKeyValueMap(getEnv("VALIDATION_RULE_SEVERITIES", "")),
+			LengthExceedAction:  getEnv("VALIDATION_LENGTH_EXCEED_ACTION", LengthExceedReject),
+			DenyEmailPatterns:   parseList(getEnv("DENY_EMAIL_PATTERNS", "")),
+			DenyPhoneValues:     parseList(getEnv("DENY_PHONE_VALUES", "")),
+			DenyNamePatterns:    parseList(getEnv("DENY_NAME_PATTERNS", "")),
+
+			SuppressionRulesFile:   getEnv("VALIDATION_SUPPRESSION_RULES_FILE", ""),
+			SuppressionListEnabled: parseBool(getEnv("VALIDATION_SUPPRESSION_LIST_ENABLED", "false")),
+
+			PoliciesFile: getEnv("VALIDATION_POLICIES_FILE", ""),
+		},
+		Normalizer: NormalizerConfig{
+			ValueAliasesFile:     getEnv("VALUE_ALIASES_FILE", ""),
+			PhoneCountryCodeMode: getEnv("PHONE_COUNTRY_CODE_MODE", PhoneCountryCodeKeep),
+			PhoneDefaultCC:       strings.TrimPrefix(getEnv("PHONE_DEFAULT_CC", ""), "+"),
+			PhoneTrunkPrefix:     getEnv("PHONE_TRUNK_PREFIX", ""),
+			NormalizeZip:         parseBool(getEnv("NORMALIZE_ZIP", "false")),
+			ZipCountryPrefix:     strings.ToUpper(getEnv("NORMALIZE_ZIP_COUNTRY_PREFIX", "DE")),
+			NormalizeKeys:        parseBool(getEnv("NORMALIZE_KEYS", "false")),
+			AcronymPreservation:  parseList(getEnv("NORMALIZE_KEYS_ACRONYMS", "")),
+		},
+		Enrichment: EnrichmentConfig{
+			Enrichers:   parseList(getEnv("ENRICHMENT_ENRICHERS", "")),
+			FailOnError: parseBool(getEnv("ENRICHMENT_FAIL_ON_ERROR", "false")),
+		},
+		Privacy: PrivacyConfig{
+			ObfuscatedFields:          parseList(getEnv("PRIVACY_OBFUSCATED_FIELDS", "")),
+			HistoryMaskedFields:       parseList(getEnv("PRIVACY_HISTORY_MASKED_FIELDS", "email,phone")),
+			NormalizationAuditEnabled: parseBool(getEnv("PRIVACY_NORMALIZATION_AUDIT_ENABLED", "false")),
+			EncryptionKey:             getEnv("PRIVACY_ENCRYPTION_KEY", ""),
+			EncryptedFields:           parseList(getEnv("PRIVACY_ENCRYPTED_FIELDS", "email,phone")),
+		},
+		Tracing: TracingConfig{
+			OTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+			ServiceName:  getEnv("OTEL_SERVICE_NAME", ""),
+		},
+		SelfTest: SelfTestConfig{
+			Enabled: parseBool(getEnv("RUN_SELFTEST", "false")),
+		},
+		JSON: JSONConfig{
+			UseNumber: parseBool(getEnv("JSON_USE_NUMBER", "false")),
+		},
+		Health: HealthConfig{
+			MaxPendingJobs:       parseInt(getEnv("HEALTH_MAX_PENDING_JOBS", "1000"), 1000),
+			CheckTimeout:         parseDuration(getEnv("HEALTH_CHECK_TIMEOUT", "2s"), 2*time.Second),
+			CustomerAPIMethod:    strings.ToUpper(getEnv("HEALTH_CUSTOMER_API_METHOD", "HEAD")),
+			SchemaBehindDegraded: parseBool(getEnv("HEALTH_SCHEMA_BEHIND_DEGRADED", "false")),
+		},
+		Callback: CallbackConfig{
+			URL:          getEnv("CALLBACK_URL", ""),
+			SourceURLs:   parseKeyValueMap(getEnv("CALLBACK_SOURCE_URLS", "")),
+			AllowedHosts: parseList(getEnv("CALLBACK_ALLOWED_HOSTS", "")),
+		},
+		Export: ExportConfig{
+			MaxRows: parseInt(getEnv("EXPORT_MAX_ROWS", "100000"), 100000),
+		},
+		Storage: StorageConfig{
+			MaxAttemptResponseBytes: parseInt(getEnv("MAX_ATTEMPT_RESPONSE_BYTES", "4096"), 4096),
+		},
+		Alerting: AlertingConfig{
+			StuckLeadThreshold:     parseDuration(getEnv("STUCK_LEAD_THRESHOLD", "1h"), time.Hour),
+			StuckLeadCheckInterval: parseDuration(getEnv("STUCK_LEAD_CHECK_INTERVAL", "5m"), 5*time.Minute),
+		},
+		Notification: NotificationConfig{
+			SlackWebhookURL: getEnv("REJECTION_NOTIFY_SLACK_WEBHOOK_URL", ""),
+			EmailSMTP: SMTPConfig{
+				Host:     getEnv("REJECTION_NOTIFY_SMTP_HOST", ""),
+				Port:     getEnv("REJECTION_NOTIFY_SMTP_PORT", "587"),
+				Username: getEnv("REJECTION_NOTIFY_SMTP_USERNAME", ""),
+				Password: getEnv("REJECTION_NOTIFY_SMTP_PASSWORD", ""),
+				From:     getEnv("REJECTION_NOTIFY_EMAIL_FROM", ""),
+				To:       parseList(getEnv("REJECTION_NOTIFY_EMAIL_TO", "")),
+			},
+			Reasons: parseList(getEnv("REJECTION_NOTIFY_REASONS", "ZIP_NOT_66XXX,NOT_HOMEOWNER")),
+			Timeout: parseDuration(getEnv("REJECTION_NOTIFY_TIMEOUT", "10s"), 10*time.Second),
+		},
+		Reconciliation: ReconciliationConfig{
+			ReadURL:         getEnv("CUSTOMER_API_READ_URL", ""),
+			CustomerIDField: getEnv("RECONCILIATION_CUSTOMER_ID_FIELD", "id"),
+			Interval:        parseDuration(getEnv("RECONCILIATION_INTERVAL", "1h"), time.Hour),
+			Window:          parseDuration(getEnv("RECONCILIATION_WINDOW", "24h"), 24*time.Hour),
+			BatchSize:       parseInt(getEnv("RECONCILIATION_BATCH_SIZE", "100"), 100),
+		},
+		Deduplication: DeduplicationConfig{
+			TimeWindowSeconds: parseInt(getEnv("DEDUPLICATION_TIME_WINDOW_SECONDS", "0"), 0),
+			GracePeriodHours:  parseInt(getEnv("DEDUPLICATION_GRACE_PERIOD_HOURS", "0"), 0),
+		},
+		Performance: PerformanceConfig{
+			DeduplicationSampleRate: parseFloat(getEnv("PERFORMANCE_DEDUPLICATION_SAMPLE_RATE", "1.0")),
+		},
+		SourceQuota: SourceQuotaConfig{
+			Quotas:   parseIntValueMap(getEnv("SOURCE_QUOTAS", "")),
+			Window:   getEnv("SOURCE_QUOTA_WINDOW", SourceQuotaWindowRolling),
+			Action:   getEnv("SOURCE_QUOTA_ACTION", SourceQuotaActionReject),
+			Timezone: getEnv("SOURCE_QUOTA_TIMEZONE", "UTC"),
+		},
+		LeadStatus: LeadStatusConfig{
+			ExtraTransitions: parseList(getEnv("LEAD_STATUS_EXTRA_TRANSITIONS", "")),
+		},
+	}
+
+	// Body patterns are regular expressions, which may contain commas, so they are given as JSON
+	patterns, err := parseStatusCodePatterns(getEnv("CUSTOMER_API_STATUS_CODE_BODY_PATTERNS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CUSTOMER_API_STATUS_CODE_BODY_PATTERNS: %w", err)
 	}
+	cfg.CustomerAPI.StatusCodeBodyPatterns = patterns
 
 	// Validate required fields
 	if err := cfg.Validate(); err != nil {
@@ -151,11 +898,50 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to load attribute mapping: %w", err)
 	}
 
+	// Load additional mapping profiles from directory
+	if err := cfg.LoadMappingProfiles(); err != nil {
+		return nil, fmt.Errorf("failed to load mapping profiles: %w", err)
+	}
+
+	// Load field dependency rules from file
+	if err := cfg.LoadDependencyRules(); err != nil {
+		return nil, fmt.Errorf("failed to load dependency rules: %w", err)
+	}
+
+	// Load suppression rules from file
+	if err := cfg.LoadSuppressionRules(); err != nil {
+		return nil, fmt.Errorf("failed to load suppression rules: %w", err)
+	}
+
+	// Load and compile validation policies from file
+	if err := cfg.LoadPolicies(); err != nil {
+		return nil, fmt.Errorf("failed to load policies: %w", err)
+	}
+
+	// Load value aliases from file
+	if err := cfg.LoadValueAliases(); err != nil {
+		return nil, fmt.Errorf("failed to load value aliases: %w", err)
+	}
+
+	// Load secondary delivery endpoints from file
+	if err := cfg.LoadForwardingChain(); err != nil {
+		return nil, fmt.Errorf("failed to load forwarding chain: %w", err)
+	}
+
+	// Parse the webhook acceptance window
+	if err := cfg.LoadAcceptanceSchedule(); err != nil {
+		return nil, fmt.Errorf("failed to load acceptance schedule: %w", err)
+	}
+
 	return cfg, nil
 }
 
 // Validate checks that required configuration fields are set
 func (c *Config) Validate() error {
+	if c.Environment != "" && !isEnvironment(c.Environment) {
+		return fmt.Errorf("APP_ENV must be %s, %s or %s, got %q",
+			EnvironmentDevelopment, EnvironmentStaging, EnvironmentProduction, c.Environment)
+	}
 	if c.CustomerAPI.URL == "" {
 		return fmt.Errorf("CUSTOMER_API_URL is required")
 	}
@@ -168,9 +954,404 @@ func (c *Config) Validate() error {
 	if c.Auth.Enabled && c.Auth.SharedSecret == "" {
 		return fmt.Errorf("SHARED_SECRET is required when ENABLE_AUTH is true")
 	}
+	if c.Tenant.Enabled {
+		if !c.Auth.Enabled {
+			return fmt.Errorf("ENABLE_AUTH is required when MULTI_TENANT_ENABLED is true, as tenants are identified by their secret")
+		}
+		if len(c.Tenant.Secrets) == 0 {
+			return fmt.Errorf("TENANT_SECRETS is required when MULTI_TENANT_ENABLED is true")
+		}
+		seen := map[string]bool{c.Auth.SharedSecret: true}
+		for tenantID, secret := range c.Tenant.Secrets {
+			if secret == "" {
+				return fmt.Errorf("TENANT_SECRETS has no secret for tenant %q", tenantID)
+			}
+			if seen[secret] {
+				return fmt.Errorf("TENANT_SECRETS must give each tenant its own secret, distinct from SHARED_SECRET")
+			}
+			seen[secret] = true
+		}
+	}
+	for _, cidr := range c.Webhook.AllowedCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("WEBHOOK_ALLOWED_CIDRS has an invalid CIDR %q: %w", cidr, err)
+		}
+	}
+	for _, proxy := range c.Webhook.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("WEBHOOK_TRUSTED_PROXIES has an invalid IP or CIDR %q", proxy)
+		}
+	}
+	if c.Webhook.MaxBodyBytes < 0 {
+		return fmt.Errorf("WEBHOOK_MAX_BODY_BYTES must not be negative, got %d", c.Webhook.MaxBodyBytes)
+	}
+	if c.Webhook.BodyTransformExpr != "" {
+		if _, err := transform.Compile(c.Webhook.BodyTransformExpr); err != nil {
+			return fmt.Errorf("WEBHOOK_BODY_TRANSFORM is invalid: %w", err)
+		}
+	}
+	for code, outcome := range c.CustomerAPI.StatusCodeMapping {
+		switch outcome {
+		case StatusOutcomeSuccess, StatusOutcomeRetriableFailure, StatusOutcomePermanentFailure:
+		default:
+			return fmt.Errorf("CUSTOMER_API_STATUS_CODE_MAPPING has invalid outcome %q for status %d", outcome, code)
+		}
+	}
+	switch c.CustomerAPI.UnexpectedResponseOutcome {
+	case "", StatusOutcomeRetriableFailure, StatusOutcomePermanentFailure:
+	default:
+		return fmt.Errorf("CUSTOMER_API_UNEXPECTED_RESPONSE_OUTCOME must be %s or %s, got %q",
+			StatusOutcomeRetriableFailure, StatusOutcomePermanentFailure, c.CustomerAPI.UnexpectedResponseOutcome)
+	}
+	switch c.CustomerAPI.ProductConflictAction {
+	case "", ProductConflictIgnore, ProductConflictMerge, ProductConflictReject:
+	default:
+		return fmt.Errorf("CUSTOMER_PRODUCT_CONFLICT_ACTION must be %s, %s or %s, got %q",
+			ProductConflictIgnore, ProductConflictMerge, ProductConflictReject, c.CustomerAPI.ProductConflictAction)
+	}
+	switch c.CustomerAPI.KeyCase {
+	case "", KeyCaseAsIs, KeyCaseSnake, KeyCaseCamel:
+	default:
+		return fmt.Errorf("CUSTOMER_KEY_CASE must be %s, %s or %s, got %q",
+			KeyCaseAsIs, KeyCaseSnake, KeyCaseCamel, c.CustomerAPI.KeyCase)
+	}
+	for code, pattern := range c.CustomerAPI.StatusCodeBodyPatterns {
+		if _, ok := c.CustomerAPI.StatusCodeMapping[code]; !ok {
+			return fmt.Errorf("CUSTOMER_API_STATUS_CODE_BODY_PATTERNS has a pattern for unmapped status %d", code)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("CUSTOMER_API_STATUS_CODE_BODY_PATTERNS has an invalid pattern for status %d: %w", code, err)
+		}
+	}
+	if c.CustomerAPI.ProxyURL != "" {
+		proxyURL, err := url.Parse(c.CustomerAPI.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("CUSTOMER_API_PROXY_URL is invalid: %w", err)
+		}
+		if (proxyURL.Scheme != "http" && proxyURL.Scheme != "https") || proxyURL.Host == "" {
+			return fmt.Errorf("CUSTOMER_API_PROXY_URL must be an http:// or https:// URL with a host")
+		}
+	}
+	if c.CustomerAPI.AWSAuth.Region != "" {
+		if c.CustomerAPI.AWSAuth.Service == "" {
+			return fmt.Errorf("CUSTOMER_API_AWS_SERVICE is required when CUSTOMER_API_AWS_REGION is set")
+		}
+		if c.CustomerAPI.AWSAuth.AccessKeyID == "" || c.CustomerAPI.AWSAuth.SecretAccessKey == "" {
+			return fmt.Errorf("CUSTOMER_API_AWS_ACCESS_KEY_ID and CUSTOMER_API_AWS_SECRET_ACCESS_KEY are required when CUSTOMER_API_AWS_REGION is set")
+		}
+	}
+	if c.Database.QueryTimeout < 0 {
+		return fmt.Errorf("DB_QUERY_TIMEOUT must not be negative")
+	}
+	if err := c.Retry.Validate(); err != nil {
+		return err
+	}
+	if c.Retry.AttemptRetention < 0 {
+		return fmt.Errorf("DELIVERY_ATTEMPT_RETENTION must not be negative")
+	}
+	for _, name := range c.CustomerAPI.ForwardHeaders {
+		switch http.CanonicalHeaderKey(name) {
+		case "Authorization", "Content-Type", "Content-Length", "Host":
+			return fmt.Errorf("CUSTOMER_API_FORWARD_HEADERS must not include %s, it is set by the client", name)
+		}
+	}
+	if c.CustomerAPI.MaxConnections < 0 {
+		return fmt.Errorf("CUSTOMER_API_MAX_CONNECTIONS must not be negative, got %d", c.CustomerAPI.MaxConnections)
+	}
+	if c.CustomerAPI.MaxIdleConns < 0 {
+		return fmt.Errorf("CUSTOMER_API_MAX_IDLE_CONNS must not be negative")
+	}
+	if c.CustomerAPI.IdleConnTimeout < 0 {
+		return fmt.Errorf("CUSTOMER_API_IDLE_CONN_TIMEOUT must not be negative")
+	}
+	for rule, severity := range c.Validation.RuleSeverities {
+		switch rule {
+		case ValidationRuleZipcode, ValidationRuleHomeowner, ValidationRuleDependency:
+		default:
+			return fmt.Errorf("VALIDATION_RULE_SEVERITIES has unknown rule %q", rule)
+		}
+		if severity != ValidationSeverityReject && severity != ValidationSeverityWarn {
+			return fmt.Errorf("VALIDATION_RULE_SEVERITIES has invalid severity %q for rule %s", severity, rule)
+		}
+	}
+	if c.AttributeMapping.DefaultMaxFieldBytes < 0 {
+		return fmt.Errorf("ATTRIBUTE_MAX_FIELD_BYTES must not be negative, got %d", c.AttributeMapping.DefaultMaxFieldBytes)
+	}
+	switch c.AttributeMapping.StrictUnknown {
+	case "", StrictUnknownOff, StrictUnknownDrop, StrictUnknownFail:
+	default:
+		return fmt.Errorf("MAPPING_STRICT_UNKNOWN must be %s, %s or %s, got %q",
+			StrictUnknownOff, StrictUnknownDrop, StrictUnknownFail, c.AttributeMapping.StrictUnknown)
+	}
+	for i, expr := range c.Validation.Policies {
+		if _, err := policy.Compile(expr); err != nil {
+			return fmt.Errorf("policy at index %d is invalid: %w", i, err)
+		}
+	}
+	switch c.Validation.LengthExceedAction {
+	case "", LengthExceedReject, LengthExceedTruncate:
+	default:
+		return fmt.Errorf("VALIDATION_LENGTH_EXCEED_ACTION must be %s or %s, got %q",
+			LengthExceedReject, LengthExceedTruncate, c.Validation.LengthExceedAction)
+	}
+	denyPatterns := map[string][]string{
+		"DENY_EMAIL_PATTERNS": c.Validation.DenyEmailPatterns,
+		"DENY_NAME_PATTERNS":  c.Validation.DenyNamePatterns,
+	}
+	for name, patterns := range denyPatterns {
+		for _, pattern := range patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("%s has an invalid pattern %q: %w", name, pattern, err)
+			}
+		}
+	}
+	switch c.Normalizer.PhoneCountryCodeMode {
+	case "", PhoneCountryCodeKeep:
+	case PhoneCountryCodePrepend, PhoneCountryCodeStrip:
+		if !isDigits(c.Normalizer.PhoneDefaultCC) || len(c.Normalizer.PhoneDefaultCC) > 3 {
+			return fmt.Errorf("PHONE_DEFAULT_CC must be a country code of 1 to 3 digits when PHONE_COUNTRY_CODE_MODE is %s, got %q",
+				c.Normalizer.PhoneCountryCodeMode, c.Normalizer.PhoneDefaultCC)
+		}
+	default:
+		return fmt.Errorf("PHONE_COUNTRY_CODE_MODE must be %s, %s or %s, got %q",
+			PhoneCountryCodeKeep, PhoneCountryCodePrepend, PhoneCountryCodeStrip, c.Normalizer.PhoneCountryCodeMode)
+	}
+	if c.Normalizer.PhoneTrunkPrefix != "" && !isDigits(c.Normalizer.PhoneTrunkPrefix) {
+		return fmt.Errorf("PHONE_TRUNK_PREFIX must contain only digits, got %q", c.Normalizer.PhoneTrunkPrefix)
+	}
+	if c.Normalizer.ZipCountryPrefix != "" && !isLetters(c.Normalizer.ZipCountryPrefix) {
+		return fmt.Errorf("NORMALIZE_ZIP_COUNTRY_PREFIX must contain only letters, got %q", c.Normalizer.ZipCountryPrefix)
+	}
+	if _, err := time.LoadLocation(c.CustomerAPI.DeliverySchedule.Timezone); err != nil {
+		return fmt.Errorf("DELIVERY_TIMEZONE is invalid: %w", err)
+	}
+	for _, hour := range c.CustomerAPI.DeliverySchedule.AllowedHours {
+		if hour < 0 || hour > 23 {
+			return fmt.Errorf("DELIVERY_ALLOWED_HOURS must be between 0 and 23, got %d", hour)
+		}
+	}
+	if c.API.MaxInflight < 0 {
+		return fmt.Errorf("MAX_INFLIGHT_REQUESTS must not be negative, got %d", c.API.MaxInflight)
+	}
+	if c.CustomerAPI.FallbackURL != "" {
+		if !isAbsoluteHTTPURL(c.CustomerAPI.FallbackURL) {
+			return fmt.Errorf("CUSTOMER_API_FALLBACK_URL must be an absolute http(s) URL")
+		}
+		if c.CustomerAPI.FallbackAfterAttempts < 1 || c.CustomerAPI.FallbackAfterAttempts >= c.Retry.MaxAttempts {
+			return fmt.Errorf("CUSTOMER_API_FALLBACK_AFTER_ATTEMPTS must be between 1 and MAX_RETRY_ATTEMPTS - 1 (%d), got %d",
+				c.Retry.MaxAttempts-1, c.CustomerAPI.FallbackAfterAttempts)
+		}
+	}
+	if c.Notification.SlackWebhookURL != "" && !isAbsoluteHTTPURL(c.Notification.SlackWebhookURL) {
+		return fmt.Errorf("REJECTION_NOTIFY_SLACK_WEBHOOK_URL must be an absolute http(s) URL")
+	}
+	if smtp := c.Notification.EmailSMTP; smtp.Host != "" && (smtp.From == "" || len(smtp.To) == 0) {
+		return fmt.Errorf("REJECTION_NOTIFY_EMAIL_FROM and REJECTION_NOTIFY_EMAIL_TO are required when REJECTION_NOTIFY_SMTP_HOST is set")
+	}
+	if c.Notification.Timeout < 0 {
+		return fmt.Errorf("REJECTION_NOTIFY_TIMEOUT must not be negative")
+	}
+	if c.Callback.URL != "" && !isAbsoluteHTTPURL(c.Callback.URL) {
+		return fmt.Errorf("CALLBACK_URL must be an absolute http(s) URL")
+	}
+	for sourceID, callbackURL := range c.Callback.SourceURLs {
+		if !isAbsoluteHTTPURL(callbackURL) {
+			return fmt.Errorf("CALLBACK_SOURCE_URLS entry %s must be an absolute http(s) URL", sourceID)
+		}
+	}
+	if (c.Callback.URL != "" || len(c.Callback.SourceURLs) > 0) && c.Auth.SharedSecret == "" {
+		return fmt.Errorf("SHARED_SECRET is required to sign callbacks when CALLBACK_URL or CALLBACK_SOURCE_URLS is set")
+	}
+	if c.Queue.JobMaxAttempts < 0 {
+		return fmt.Errorf("QUEUE_JOB_MAX_ATTEMPTS must not be negative, got %d", c.Queue.JobMaxAttempts)
+	}
+	if c.Storage.MaxAttemptResponseBytes < 0 {
+		return fmt.Errorf("MAX_ATTEMPT_RESPONSE_BYTES must not be negative, got %d", c.Storage.MaxAttemptResponseBytes)
+	}
+	if c.Export.MaxRows < 0 {
+		return fmt.Errorf("EXPORT_MAX_ROWS must not be negative, got %d", c.Export.MaxRows)
+	}
+	for i, bound := range c.Worker.LatencyBuckets {
+		if bound <= 0 || (i > 0 && bound <= c.Worker.LatencyBuckets[i-1]) {
+			return fmt.Errorf("CUSTOMER_API_LATENCY_BUCKETS must be positive numbers in ascending order, got %v", c.Worker.LatencyBuckets)
+		}
+	}
+	switch c.Logging.Format {
+	case "", logger.FormatJSON, logger.FormatECS, logger.FormatText:
+	default:
+		return fmt.Errorf("LOG_FORMAT must be %s, %s or %s, got %q",
+			logger.FormatJSON, logger.FormatECS, logger.FormatText, c.Logging.Format)
+	}
+	switch strings.ToLower(c.Logging.Level) {
+	case "", "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("LOG_LEVEL must be debug, info, warn or error, got %q", c.Logging.Level)
+	}
+	if sample := c.Logging.MappingSample; sample.Every < 0 || sample.PerSecond < 0 {
+		return fmt.Errorf("MAPPING_LOG_SAMPLE must be a number N (1 in N lines) or a rate N/s")
+	}
+	if c.Alerting.StuckLeadThreshold < 0 {
+		return fmt.Errorf("STUCK_LEAD_THRESHOLD must not be negative, got %s", c.Alerting.StuckLeadThreshold)
+	}
+	if c.Alerting.StuckLeadCheckInterval < 0 {
+		return fmt.Errorf("STUCK_LEAD_CHECK_INTERVAL must not be negative, got %s", c.Alerting.StuckLeadCheckInterval)
+	}
+	if readURL := c.Reconciliation.ReadURL; readURL != "" {
+		if !isAbsoluteHTTPURL(readURL) {
+			return fmt.Errorf("CUSTOMER_API_READ_URL must be an absolute http(s) URL, got %q", readURL)
+		}
+		if !strings.Contains(readURL, ReadURLLeadID) && !strings.Contains(readURL, ReadURLCustomerID) {
+			return fmt.Errorf("CUSTOMER_API_READ_URL must contain %s or %s", ReadURLLeadID, ReadURLCustomerID)
+		}
+		if c.Reconciliation.Interval <= 0 {
+			return fmt.Errorf("RECONCILIATION_INTERVAL must be positive, got %s", c.Reconciliation.Interval)
+		}
+		if c.Reconciliation.Window <= 0 {
+			return fmt.Errorf("RECONCILIATION_WINDOW must be positive, got %s", c.Reconciliation.Window)
+		}
+		if c.Reconciliation.BatchSize <= 0 {
+			return fmt.Errorf("RECONCILIATION_BATCH_SIZE must be positive, got %d", c.Reconciliation.BatchSize)
+		}
+	}
+	if c.Privacy.EncryptionKey != "" {
+		key, err := base64.StdEncoding.DecodeString(c.Privacy.EncryptionKey)
+		if err != nil || len(key) != 32 {
+			return fmt.Errorf("PRIVACY_ENCRYPTION_KEY must be a base64-encoded 32-byte key")
+		}
+	}
+	if c.Deduplication.TimeWindowSeconds < 0 {
+		return fmt.Errorf("DEDUPLICATION_TIME_WINDOW_SECONDS must not be negative, got %d", c.Deduplication.TimeWindowSeconds)
+	}
+	if c.Deduplication.GracePeriodHours < 0 {
+		return fmt.Errorf("DEDUPLICATION_GRACE_PERIOD_HOURS must not be negative, got %d", c.Deduplication.GracePeriodHours)
+	}
+	if rate := c.Performance.DeduplicationSampleRate; rate < 0 || rate > 1 {
+		return fmt.Errorf("PERFORMANCE_DEDUPLICATION_SAMPLE_RATE must be between 0.0 and 1.0, got %v", rate)
+	}
+	for sourceID, quota := range c.SourceQuota.Quotas {
+		if quota <= 0 {
+			return fmt.Errorf("SOURCE_QUOTAS entry %s must be a positive number of leads", sourceID)
+		}
+	}
+	switch c.SourceQuota.Window {
+	case "", SourceQuotaWindowRolling, SourceQuotaWindowCalendarDay:
+	default:
+		return fmt.Errorf("SOURCE_QUOTA_WINDOW must be %s or %s, got %q",
+			SourceQuotaWindowRolling, SourceQuotaWindowCalendarDay, c.SourceQuota.Window)
+	}
+	switch c.SourceQuota.Action {
+	case "", SourceQuotaActionReject, SourceQuotaActionFlag:
+	default:
+		return fmt.Errorf("SOURCE_QUOTA_ACTION must be %s or %s, got %q",
+			SourceQuotaActionReject, SourceQuotaActionFlag, c.SourceQuota.Action)
+	}
+	if _, err := time.LoadLocation(c.SourceQuota.Timezone); err != nil {
+		return fmt.Errorf("SOURCE_QUOTA_TIMEZONE is invalid: %w", err)
+	}
+	if c.Health.MaxPendingJobs < 0 {
+		return fmt.Errorf("HEALTH_MAX_PENDING_JOBS must not be negative, got %d", c.Health.MaxPendingJobs)
+	}
+	switch c.Health.CustomerAPIMethod {
+	case "", "HEAD", "OPTIONS":
+	default:
+		return fmt.Errorf("HEALTH_CUSTOMER_API_METHOD must be %s or %s, got %q",
+			"HEAD", "OPTIONS", c.Health.CustomerAPIMethod)
+	}
+	if _, err := time.LoadLocation(c.Webhook.AcceptanceSchedule.Timezone); err != nil {
+		return fmt.Errorf("WEBHOOK_ACCEPTANCE_TIMEZONE is invalid: %w", err)
+	}
+	for _, transition := range c.LeadStatus.ExtraTransitions {
+		if _, _, err := models.ParseStatusTransition(transition); err != nil {
+			return fmt.Errorf("LEAD_STATUS_EXTRA_TRANSITIONS is invalid: %w", err)
+		}
+	}
+	return nil
+}
+
+// LoadAcceptanceSchedule parses the webhook acceptance rule into the acceptance schedule.
+// Leads are accepted at any time when no rule is configured.
+func (c *Config) LoadAcceptanceSchedule() error {
+	if c.Webhook.AcceptanceRule == "" {
+		return nil
+	}
+
+	hours, weekdays, err := parseScheduleRule(c.Webhook.AcceptanceRule)
+	if err != nil {
+		return fmt.Errorf("WEBHOOK_ACCEPTANCE_RRULE is invalid: %w", err)
+	}
+
+	c.Webhook.AcceptanceSchedule.AllowedHours = hours
+	c.Webhook.AcceptanceSchedule.AllowedWeekdays = weekdays
 	return nil
 }
 
+// rruleWeekdays maps RFC 5545 BYDAY codes to weekdays
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// parseScheduleRule parses the subset of an RFC 5545 RRULE that describes a recurring
+// hour-of-day and weekday window: FREQ=DAILY or FREQ=WEEKLY with optional BYDAY and BYHOUR.
+// WKST is accepted and ignored; all other rule parts are rejected.
+func parseScheduleRule(rule string) ([]int, []time.Weekday, error) {
+	rule = strings.TrimSpace(rule)
+	if len(rule) >= 6 && strings.EqualFold(rule[:6], "RRULE:") {
+		rule = rule[6:]
+	}
+
+	var hours []int
+	var weekdays []time.Weekday
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(rule, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if !ok || name == "" || value == "" {
+			return nil, nil, fmt.Errorf("malformed rule part %q", part)
+		}
+		if seen[name] {
+			return nil, nil, fmt.Errorf("duplicate rule part %s", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			if freq := strings.ToUpper(value); freq != "DAILY" && freq != "WEEKLY" {
+				return nil, nil, fmt.Errorf("FREQ must be DAILY or WEEKLY, got %s", value)
+			}
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day, ok := rruleWeekdays[strings.ToUpper(strings.TrimSpace(code))]
+				if !ok {
+					return nil, nil, fmt.Errorf("invalid BYDAY value %q", code)
+				}
+				weekdays = append(weekdays, day)
+			}
+		case "BYHOUR":
+			for _, item := range strings.Split(value, ",") {
+				hour, err := strconv.Atoi(strings.TrimSpace(item))
+				if err != nil || hour < 0 || hour > 23 {
+					return nil, nil, fmt.Errorf("BYHOUR must be between 0 and 23, got %q", item)
+				}
+				hours = append(hours, hour)
+			}
+		case "WKST":
+		default:
+			return nil, nil, fmt.Errorf("unsupported rule part %s", name)
+		}
+	}
+
+	if !seen["FREQ"] {
+		return nil, nil, fmt.Errorf("FREQ is required")
+	}
+	return hours, weekdays, nil
+}
+
 // LoadAttributeMapping loads attribute definitions from JSON file
 func (c *Config) LoadAttributeMapping() error {
 	data, err := os.ReadFile(c.AttributeMapping.FilePath)
@@ -178,55 +1359,281 @@ func (c *Config) LoadAttributeMapping() error {
 		return fmt.Errorf("failed to read attribute mapping file: %w", err)
 	}
 
-	// Support both current schema and legacy schema with metadata keys.
-	var raw map[string]json.RawMessage
-	if err := json.Unmarshal(data, &raw); err != nil {
-		return fmt.Errorf("failed to parse attribute mapping JSON: %w", err)
+	mapping, err := parseAttributeMapping(data)
+	if err != nil {
+		return err
 	}
 
-	mapping := make(map[string]AttributeDefinition)
-	for key, value := range raw {
-		if strings.HasPrefix(key, "_") {
-			continue
+	c.AttributeMapping.Mapping = mapping
+	return nil
+}
+
+// LoadMappingProfiles loads every <profile>.json in the profile directory.
+// A missing directory setting is not an error; only the default mapping is used.
+func (c *Config) LoadMappingProfiles() error {
+	if c.AttributeMapping.ProfileDir == "" {
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(c.AttributeMapping.ProfileDir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list mapping profiles: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no mapping profiles found in %s", c.AttributeMapping.ProfileDir)
+	}
+
+	profiles := make(map[string]map[string]AttributeDefinition, len(files))
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read mapping profile '%s': %w", name, err)
 		}
 
-		var def AttributeDefinition
-		if err := json.Unmarshal(value, &def); err == nil && def.Type != "" {
-			mapping[key] = def
-			continue
+		mapping, err := parseAttributeMapping(data)
+		if err != nil {
+			return fmt.Errorf("invalid mapping profile '%s': %w", name, err)
+		}
+		profiles[name] = mapping
+	}
+
+	c.AttributeMapping.Profiles = profiles
+	return nil
+}
+
+// parseAttributeMapping parses attribute definitions in the current or legacy schema.
+// The error lists every problem, see ValidateAttributeMapping.
+func parseAttributeMapping(data []byte) (map[string]AttributeDefinition, error) {
+	mapping, errs := decodeAttributeMapping(data)
+	if len(errs) > 0 {
+		return nil, MappingErrors(errs)
+	}
+	return mapping, nil
+}
+
+// LoadDependencyRules loads field dependency rules from the configured JSON file.
+// No rules are loaded when no file is configured.
+func (c *Config) LoadDependencyRules() error {
+	if c.Validation.DependencyRulesFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.Validation.DependencyRulesFile)
+	if err != nil {
+		return fmt.Errorf("failed to read dependency rules file: %w", err)
+	}
+
+	var rules []FieldDependencyRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return fmt.Errorf("failed to parse dependency rules JSON: %w", err)
+	}
+
+	for i, rule := range rules {
+		if rule.IfPresent == "" || len(rule.ThenRequired) == 0 {
+			return fmt.Errorf("invalid dependency rule at index %d: if_present and then_required are required", i)
+		}
+	}
+
+	c.Validation.DependencyRules = rules
+	return nil
+}
+
+// LoadSuppressionRules loads suppression rules from the configured JSON file and checks
+// their patterns. No rules are loaded when no file is configured.
+func (c *Config) LoadSuppressionRules() error {
+	if c.Validation.SuppressionRulesFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.Validation.SuppressionRulesFile)
+	if err != nil {
+		return fmt.Errorf("failed to read suppression rules file: %w", err)
+	}
+
+	var rules []SuppressionRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return fmt.Errorf("failed to parse suppression rules JSON: %w", err)
+	}
+
+	for i, rule := range rules {
+		if rule.Field == "" || rule.Pattern == "" {
+			return fmt.Errorf("invalid suppression rule at index %d: field and pattern are required", i)
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("invalid suppression rule at index %d: %w", i, err)
 		}
+		if rule.Reason == "" {
+			rules[i].Reason = models.RejectionReasonSuppressed
+		}
+	}
+
+	c.Validation.SuppressionRules = rules
+	return nil
+}
+
+// LoadPolicies loads validation policies from the configured JSON file and compiles
+// them, so that a syntax error stops startup. No policies are loaded when no file is configured.
+func (c *Config) LoadPolicies() error {
+	if c.Validation.PoliciesFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.Validation.PoliciesFile)
+	if err != nil {
+		return fmt.Errorf("failed to read policies file: %w", err)
+	}
+
+	var policies []string
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return fmt.Errorf("failed to parse policies JSON: %w", err)
+	}
+
+	for i, expr := range policies {
+		if _, err := policy.Compile(expr); err != nil {
+			return fmt.Errorf("invalid policy at index %d: %w", i, err)
+		}
+	}
+
+	c.Validation.Policies = policies
+	return nil
+}
+
+// LoadValueAliases loads per-field value aliases from the configured JSON file.
+// A missing file setting is not an error; no aliases are applied.
+func (c *Config) LoadValueAliases() error {
+	if c.Normalizer.ValueAliasesFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.Normalizer.ValueAliasesFile)
+	if err != nil {
+		return fmt.Errorf("failed to read value aliases file: %w", err)
+	}
+
+	var aliases map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return fmt.Errorf("failed to parse value aliases JSON: %w", err)
+	}
 
-		// Legacy schema support
-		var legacy struct {
-			AttributeType string   `json:"attribute_type"`
-			Values        []string `json:"values"`
+	for field := range aliases {
+		if strings.TrimSpace(field) == "" {
+			return fmt.Errorf("invalid value aliases: field name must not be empty")
 		}
-		if err := json.Unmarshal(value, &legacy); err != nil {
-			return fmt.Errorf("failed to parse attribute mapping JSON for key '%s': %w", key, err)
+	}
+
+	c.Normalizer.ValueAliases = aliases
+	return nil
+}
+
+// LoadForwardingChain loads the secondary delivery endpoints from the configured JSON file,
+// e.g. [{"url": "https://warehouse.example.com/leads", "token": "...", "timeout": "10s"}].
+// Endpoints inherit the primary timeout when none is given and always use the primary proxy settings.
+func (c *Config) LoadForwardingChain() error {
+	if c.CustomerAPI.ForwardingChainFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.CustomerAPI.ForwardingChainFile)
+	if err != nil {
+		return fmt.Errorf("failed to read forwarding chain file: %w", err)
+	}
+
+	var entries []struct {
+		URL     string `json:"url"`
+		Token   string `json:"token"`
+		Timeout string `json:"timeout"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse forwarding chain JSON: %w", err)
+	}
+
+	chain := make([]CustomerAPIConfig, 0, len(entries))
+	for i, entry := range entries {
+		if entry.URL == "" {
+			return fmt.Errorf("forwarding chain entry %d: url is required", i+1)
 		}
-		if legacy.AttributeType == "" {
-			return fmt.Errorf("invalid attribute mapping for key '%s': missing attribute_type/type", key)
+		if !isAbsoluteHTTPURL(entry.URL) {
+			return fmt.Errorf("forwarding chain entry %d: url must be an absolute http(s) URL", i+1)
 		}
 
-		mapping[key] = AttributeDefinition{
-			Type:     legacy.AttributeType,
-			Required: false,
-			Options:  legacy.Values,
-			Min:      nil,
-			Max:      nil,
+		timeout := c.CustomerAPI.Timeout
+		if entry.Timeout != "" {
+			if timeout, err = time.ParseDuration(entry.Timeout); err != nil || timeout <= 0 {
+				return fmt.Errorf("forwarding chain entry %d: invalid timeout %q", i+1, entry.Timeout)
+			}
 		}
+
+		chain = append(chain, CustomerAPIConfig{
+			URL:      entry.URL,
+			Token:    entry.Token,
+			Timeout:  timeout,
+			ProxyURL: c.CustomerAPI.ProxyURL,
+			NoProxy:  c.CustomerAPI.NoProxy,
+
+			MaxIdleConns:      c.CustomerAPI.MaxIdleConns,
+			IdleConnTimeout:   c.CustomerAPI.IdleConnTimeout,
+			DisableKeepAlives: c.CustomerAPI.DisableKeepAlives,
+		})
 	}
 
-	c.AttributeMapping.Mapping = mapping
+	c.CustomerAPI.ForwardingChain = chain
 	return nil
 }
 
+// Fallback returns the settings of the fallback endpoint, which match the primary
+// endpoint except for the URL and token, or nil if no fallback endpoint is configured
+func (c CustomerAPIConfig) Fallback() *CustomerAPIConfig {
+	if c.FallbackURL == "" {
+		return nil
+	}
+	fallback := c
+	fallback.URL = c.FallbackURL
+	if c.FallbackToken != "" {
+		fallback.Token = c.FallbackToken
+	}
+	fallback.ForwardingChain = nil
+	return &fallback
+}
+
 // Helper functions
 
+// isDigits reports whether s is a non-empty string of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isLetters reports whether s is a non-empty string of ASCII letters
+func isLetters(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+// getEnv returns the environment variable key, or else its value in the config file,
+// or else defaultValue
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
+	if value, ok := fileValues[key]; ok && value != "" {
+		return value
+	}
 	return defaultValue
 }
 
@@ -250,3 +1657,143 @@ func parseInt(value string, defaultValue int) int {
 func parseBool(value string) bool {
 	return value == "true" || value == "1" || value == "yes"
 }
+
+// isAbsoluteHTTPURL reports whether the value is an absolute http(s) URL
+func isAbsoluteHTTPURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// parseList splits a comma-separated value into trimmed, non-empty items
+func parseList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// parseStatusCodeMap parses comma-separated "code=value" pairs, skipping invalid entries
+func parseStatusCodeMap(value string) map[int]string {
+	result := make(map[int]string)
+	for _, item := range parseList(value) {
+		code, mapped, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		if n := parseInt(strings.TrimSpace(code), -1); n >= 100 && n <= 599 {
+			result[n] = strings.TrimSpace(mapped)
+		}
+	}
+	return result
+}
+
+// parseStatusCodePatterns parses a JSON object of status codes and regular expressions,
+// e.g. {"200": "\"status\":\\s*\"error\""}; an empty value yields an empty map
+func parseStatusCodePatterns(value string) (map[int]string, error) {
+	result := make(map[int]string)
+	if strings.TrimSpace(value) == "" {
+		return result, nil
+	}
+
+	var patterns map[string]string
+	if err := json.Unmarshal([]byte(value), &patterns); err != nil {
+		return nil, fmt.Errorf("expected a JSON object of status codes and patterns: %w", err)
+	}
+	for code, pattern := range patterns {
+		n := parseInt(strings.TrimSpace(code), -1)
+		if n < 100 || n > 599 {
+			return nil, fmt.Errorf("invalid status code %q", code)
+		}
+		result[n] = pattern
+	}
+	return result, nil
+}
+
+// parseKeyValueMap parses comma-separated "key=value" pairs, skipping invalid entries
+func parseKeyValueMap(value string) map[string]string {
+	result := make(map[string]string)
+	for _, item := range parseList(value) {
+		key, mapped, ok := strings.Cut(item, "=")
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			continue
+		}
+		result[key] = strings.TrimSpace(mapped)
+	}
+	return result
+}
+
+// parseIntValueMap parses comma-separated "key=number" pairs; values that are not
+// numbers are kept as -1 so validation can report them
+func parseIntValueMap(value string) map[string]int {
+	result := make(map[string]int)
+	for key, mapped := range parseKeyValueMap(value) {
+		result[key] = parseInt(mapped, -1)
+	}
+	return result
+}
+
+// parseLogSample parses "N" (1 in N lines) or "N/s" (N lines per second); values that are
+// not numbers are kept as -1 so validation can report them
+func parseLogSample(value string) LogSample {
+	if rate, ok := strings.CutSuffix(strings.TrimSpace(value), "/s"); ok {
+		return LogSample{PerSecond: parseInt(rate, -1)}
+	}
+	return LogSample{Every: parseInt(value, -1)}
+}
+
+// parseIntList splits a comma-separated list of integers, skipping invalid entries
+func parseIntList(value string) []int {
+	var result []int
+	for _, item := range parseList(value) {
+		if n := parseInt(item, -1); n >= 0 {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// parseFloat parses a number; a value that is not a number is returned as -1 so
+// validation can report it
+func parseFloat(value string) float64 {
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// parseFloatList splits a comma-separated list of numbers; values that are not numbers
+// are kept as -1 so validation can report them
+func parseFloatList(value string) []float64 {
+	var result []float64
+	for _, item := range parseList(value) {
+		n, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			n = -1
+		}
+		result = append(result, n)
+	}
+	return result
+}
+
+// parseWeekdays parses a comma-separated list of weekdays given as numbers (0 = Sunday)
+// or English names such as "mon" or "Monday", skipping invalid entries
+func parseWeekdays(value string) []time.Weekday {
+	var result []time.Weekday
+	for _, item := range parseList(value) {
+		if n := parseInt(item, -1); n >= 0 && n <= 6 {
+			result = append(result, time.Weekday(n))
+			continue
+		}
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.EqualFold(item, day.String()) || strings.EqualFold(item, day.String()[:3]) {
+				result = append(result, day)
+				break
+			}
+		}
+	}
+	return result
+}
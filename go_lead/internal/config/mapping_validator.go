@@ -0,0 +1,225 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// MappingError describes a single problem in an attribute mapping file
+type MappingError struct {
+	Key     string `json:"key,omitempty"`   // attribute key, empty for errors affecting the whole file
+	Field   string `json:"field,omitempty"` // definition field, e.g. "min_length"
+	Line    int    `json:"line,omitempty"`  // line of the attribute key, or of a syntax error
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (e MappingError) Error() string {
+	var location string
+	switch {
+	case e.Key != "" && e.Field != "":
+		location = fmt.Sprintf("attribute '%s' field '%s'", e.Key, e.Field)
+	case e.Key != "":
+		location = fmt.Sprintf("attribute '%s'", e.Key)
+	default:
+		location = "attribute mapping"
+	}
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s (line %d)", location, e.Line)
+	}
+	return fmt.Sprintf("%s: %s", location, e.Message)
+}
+
+// MappingErrors collects every problem found in an attribute mapping file
+type MappingErrors []MappingError
+
+// Error implements the error interface, listing all problems
+func (errs MappingErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return "invalid attribute mapping: " + strings.Join(messages, "; ")
+}
+
+// ValidateAttributeMapping checks attribute mapping JSON in the current or legacy schema
+// and returns every problem found, in file order. It returns nil for a valid mapping.
+func ValidateAttributeMapping(data []byte) []MappingError {
+	_, errs := decodeAttributeMapping(data)
+	return errs
+}
+
+// FormatMappingErrors writes errors as a human-readable table
+func FormatMappingErrors(w io.Writer, errs []MappingError) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LINE\tKEY\tFIELD\tMESSAGE")
+	for _, err := range errs {
+		line := "-"
+		if err.Line > 0 {
+			line = fmt.Sprint(err.Line)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", line, orDash(err.Key), orDash(err.Field), err.Message)
+	}
+	return tw.Flush()
+}
+
+// orDash returns "-" for an empty table cell
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// decodeAttributeMapping parses attribute definitions in the current or legacy schema,
+// collecting every problem instead of stopping at the first one
+func decodeAttributeMapping(data []byte) (map[string]AttributeDefinition, []MappingError) {
+	var errs []MappingError
+	mapping := make(map[string]AttributeDefinition)
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, []MappingError{syntaxError(data, dec, err, "attribute mapping must be a JSON object")}
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, append(errs, syntaxError(data, dec, err, ""))
+		}
+		key := tok.(string)
+		line := lineAt(data, dec.InputOffset())
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, append(errs, syntaxError(data, dec, err, ""))
+		}
+		if strings.HasPrefix(key, "_") {
+			continue
+		}
+
+		def, defErrs := decodeAttributeDefinition(key, value)
+		for i := range defErrs {
+			defErrs[i].Line = line
+		}
+		errs = append(errs, defErrs...)
+		if len(defErrs) == 0 {
+			mapping[key] = def
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, append(errs, syntaxError(data, dec, err, ""))
+	}
+
+	return mapping, errs
+}
+
+// decodeAttributeDefinition parses and checks a single attribute definition
+func decodeAttributeDefinition(key string, value json.RawMessage) (AttributeDefinition, []MappingError) {
+	var def AttributeDefinition
+	if err := json.Unmarshal(value, &def); err != nil {
+		return def, []MappingError{fieldTypeError(key, err)}
+	}
+
+	if def.Type == "" {
+		// Legacy schema support
+		var legacy struct {
+			AttributeType string   `json:"attribute_type"`
+			Values        []string `json:"values"`
+			MinLength     int      `json:"min_length"`
+			MaxLength     int      `json:"max_length"`
+			MaxValueBytes int      `json:"max_value_bytes"`
+		}
+		if err := json.Unmarshal(value, &legacy); err != nil {
+			return def, []MappingError{fieldTypeError(key, err)}
+		}
+		if legacy.AttributeType == "" {
+			return def, []MappingError{{Key: key, Field: "type", Message: "missing attribute_type/type"}}
+		}
+		def = AttributeDefinition{
+			Type:     legacy.AttributeType,
+			Required: false,
+			Options:  legacy.Values,
+			Min:      nil,
+			Max:      nil,
+
+			MinLength:     legacy.MinLength,
+			MaxLength:     legacy.MaxLength,
+			MaxValueBytes: legacy.MaxValueBytes,
+		}
+	}
+
+	var errs []MappingError
+	if def.OutputAs != "" && def.OutputAs != RangeOutputNumber && def.OutputAs != RangeOutputString {
+		errs = append(errs, MappingError{Key: key, Field: "output_as",
+			Message: fmt.Sprintf("invalid value '%s': must be %s or %s", def.OutputAs, RangeOutputNumber, RangeOutputString)})
+	}
+	if def.Min != nil && def.Max != nil && *def.Min > *def.Max {
+		errs = append(errs, MappingError{Key: key, Field: "min",
+			Message: fmt.Sprintf("min %v exceeds max %v", *def.Min, *def.Max)})
+	}
+	bounds := map[string]int{
+		"min_length": def.MinLength, "max_length": def.MaxLength,
+		"max_value_bytes": def.MaxValueBytes, "max_decoded_bytes": def.MaxDecodedBytes,
+	}
+	for field, bound := range bounds {
+		if bound < 0 {
+			errs = append(errs, MappingError{Key: key, Field: field, Message: fmt.Sprintf("must not be negative, got %d", bound)})
+		}
+	}
+	for _, field := range def.FallbackFields {
+		if field == "" || field == key {
+			errs = append(errs, MappingError{Key: key, Field: "fallback_fields",
+				Message: fmt.Sprintf("invalid fallback field %q: must name another payload field", field)})
+		}
+	}
+	if def.MaxLength > 0 && def.MinLength > def.MaxLength {
+		errs = append(errs, MappingError{Key: key, Field: "min_length",
+			Message: fmt.Sprintf("min_length %d exceeds max_length %d", def.MinLength, def.MaxLength)})
+	}
+	// Map iteration above is random; keep the output stable
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return def, errs
+}
+
+// fieldTypeError reports a definition field holding a value of the wrong JSON type
+func fieldTypeError(key string, err error) MappingError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field == "" {
+		return MappingError{Key: key, Message: fmt.Sprintf("definition must be a JSON object, got JSON %s", typeErr.Value)}
+	}
+	if errors.As(err, &typeErr) {
+		return MappingError{Key: key, Field: typeErr.Field,
+			Message: fmt.Sprintf("expected %s, got JSON %s", typeErr.Type, typeErr.Value)}
+	}
+	return MappingError{Key: key, Message: err.Error()}
+}
+
+// syntaxError reports malformed JSON at the line the decoder stopped
+func syntaxError(data []byte, dec *json.Decoder, err error, fallback string) MappingError {
+	offset := dec.InputOffset()
+	var jsonErr *json.SyntaxError
+	if errors.As(err, &jsonErr) {
+		offset = jsonErr.Offset
+	}
+
+	message := fallback
+	if err != nil {
+		message = "invalid JSON: " + err.Error()
+	}
+	return MappingError{Line: lineAt(data, offset), Message: message}
+}
+
+// lineAt returns the 1-based line of the given byte offset
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestValidateAttributeMapping_CollectsAllErrors(t *testing.T) {
+	content := `{
+  "_comment": "metadata keys are skipped",
+  "phone": {"type": "text", "required": true},
+  "roof_area": {"type": "range", "min": 200, "max": 10, "output_as": "integer"},
+  "comment": {"type": "text", "min_length": 10, "max_length": 5},
+  "house_type": {"values": ["Einfamilienhaus"]},
+  "notes": {"type": "text", "max_length": "long"},
+  "offer_pdf": {"type": "base64", "max_decoded_bytes": -1},
+  "email": {"type": "email", "fallback_fields": ["contact_email", "email"]}
+}`
+
+	errs := ValidateAttributeMapping([]byte(content))
+
+	want := []MappingError{
+		{Key: "roof_area", Field: "min", Line: 4},
+		{Key: "roof_area", Field: "output_as", Line: 4},
+		{Key: "comment", Field: "min_length", Line: 5},
+		{Key: "house_type", Field: "type", Line: 6},
+		{Key: "notes", Field: "max_length", Line: 7},
+		{Key: "offer_pdf", Field: "max_decoded_bytes", Line: 8},
+		{Key: "email", Field: "fallback_fields", Line: 9},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Expected %d errors, got %d: %+v", len(want), len(errs), errs)
+	}
+	for i, w := range want {
+		got := errs[i]
+		if got.Key != w.Key || got.Field != w.Field || got.Line != w.Line || got.Message == "" {
+			t.Errorf("Error %d: expected %s/%s on line %d, got %+v", i, w.Key, w.Field, w.Line, got)
+		}
+	}
+}
+
+func TestValidateAttributeMapping_Valid(t *testing.T) {
+	content := `{
+  "_comment": "legacy and current schema",
+  "solar_offer_type": {"attribute_type": "dropdown", "values": ["Kaufen", "Mieten"]},
+  "roof_area": {"type": "range", "min": 10, "max": 200},
+  "offer_pdf": {"type": "base64", "max_decoded_bytes": 5242880},
+  "phone": {"type": "phone", "required": true, "fallback_fields": ["mobile", "tel"]}
+}`
+
+	if errs := ValidateAttributeMapping([]byte(content)); len(errs) != 0 {
+		t.Errorf("Expected no errors, got %+v", errs)
+	}
+}
+
+func TestValidateAttributeMapping_SyntaxErrorLine(t *testing.T) {
+	content := "{\n  \"phone\": {\"type\": \"text\"},\n  \"comment\": {type: \"text\"}\n}"
+
+	errs := ValidateAttributeMapping([]byte(content))
+
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error, got %+v", errs)
+	}
+	if errs[0].Line != 3 || !strings.Contains(errs[0].Message, "invalid JSON") {
+		t.Errorf("Expected invalid JSON on line 3, got %+v", errs[0])
+	}
+}
+
+func TestFormatMappingErrors(t *testing.T) {
+	errs := []MappingError{
+		{Key: "roof_area", Field: "min", Line: 4, Message: "min 200 exceeds max 10"},
+		{Line: 9, Message: "invalid JSON: unexpected end of JSON input"},
+	}
+
+	var buf bytes.Buffer
+	if err := FormatMappingErrors(&buf, errs); err != nil {
+		t.Fatalf("FormatMappingErrors() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got:\n%s", buf.String())
+	}
+	if fields := strings.Fields(lines[0]); strings.Join(fields, " ") != "LINE KEY FIELD MESSAGE" {
+		t.Errorf("Unexpected header %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "4 ") || !strings.Contains(lines[1], "roof_area") || !strings.HasSuffix(lines[1], "min 200 exceeds max 10") {
+		t.Errorf("Unexpected row %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); fields[0] != "9" || fields[1] != "-" || fields[2] != "-" {
+		t.Errorf("Expected dashes for the missing key and field, got %q", lines[2])
+	}
+	// Columns are aligned
+	if strings.Index(lines[1], "min 200") != strings.Index(lines[2], "invalid JSON") {
+		t.Errorf("Expected aligned message column:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/logger"
+)
+
+// MappingWatcher holds the attribute mapping of a Config and reloads the mapping file and
+// profiles whenever the mapping file changes, like the worker does on a mapping change.
+// The file is polled for a changed modification time or size. A mapping that fails to
+// load is logged and the previous one is kept.
+type MappingWatcher struct {
+	filePath string
+	interval time.Duration
+
+	mu       sync.RWMutex
+	mapping  AttributeMappingConfig
+	loadedAt time.Time
+	state    mappingFileState
+}
+
+// mappingFileState is the modification time and size of the mapping file
+type mappingFileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewMappingWatcher creates a MappingWatcher holding the mapping already loaded into cfg,
+// checked for changes every interval once Run is called
+func NewMappingWatcher(cfg *Config, interval time.Duration) *MappingWatcher {
+	w := &MappingWatcher{
+		filePath: cfg.AttributeMapping.FilePath,
+		interval: interval,
+		mapping:  cfg.AttributeMapping,
+		loadedAt: time.Now(),
+	}
+	w.state, _ = w.fileState()
+	return w
+}
+
+// AttributeMapping returns the current mapping and when it was loaded. The returned
+// mapping is replaced, never modified, on reload and must not be modified by the caller.
+func (w *MappingWatcher) AttributeMapping() (AttributeMappingConfig, time.Time) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.mapping, w.loadedAt
+}
+
+// Run checks the mapping file every interval until ctx is cancelled
+func (w *MappingWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := w.Check()
+			if err != nil {
+				logger.Warn(ctx, "Failed to reload attribute mapping, keeping the loaded mapping", "error", err.Error())
+			} else if reloaded {
+				logger.Info(ctx, "Attribute mapping reloaded", "file", w.filePath)
+			}
+		}
+	}
+}
+
+// Check reloads the mapping if the mapping file changed since the last load and reports
+// whether it did
+func (w *MappingWatcher) Check() (bool, error) {
+	state, err := w.fileState()
+	if err != nil {
+		return false, err
+	}
+
+	w.mu.RLock()
+	current := w.mapping
+	unchanged := state.modTime.Equal(w.state.modTime) && state.size == w.state.size
+	w.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	reloaded := &Config{AttributeMapping: current}
+	if err := reloaded.LoadAttributeMapping(); err != nil {
+		return false, err
+	}
+	if err := reloaded.LoadMappingProfiles(); err != nil {
+		return false, err
+	}
+
+	w.mu.Lock()
+	w.mapping = reloaded.AttributeMapping
+	w.loadedAt = time.Now()
+	w.state = state
+	w.mu.Unlock()
+
+	return true, nil
+}
+
+// fileState identifies the current version of the mapping file
+func (w *MappingWatcher) fileState() (mappingFileState, error) {
+	info, err := os.Stat(w.filePath)
+	if err != nil {
+		return mappingFileState{}, err
+	}
+	return mappingFileState{modTime: info.ModTime(), size: info.Size()}, nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMappingWatcher_Check(t *testing.T) {
+	mappingFile := filepath.Join(t.TempDir(), "mapping.json")
+	if err := os.WriteFile(mappingFile, []byte(`{"phone": {"type": "text", "required": true}}`), 0644); err != nil {
+		t.Fatalf("Failed to write mapping file: %v", err)
+	}
+	cfg := &Config{AttributeMapping: AttributeMappingConfig{FilePath: mappingFile, DefaultMaxFieldBytes: 64}}
+	if err := cfg.LoadAttributeMapping(); err != nil {
+		t.Fatalf("Failed to load mapping: %v", err)
+	}
+	watcher := NewMappingWatcher(cfg, time.Second)
+
+	if reloaded, err := watcher.Check(); err != nil || reloaded {
+		t.Errorf("Expected an unchanged file not to be reloaded, got %v, %v", reloaded, err)
+	}
+
+	// An invalid mapping keeps the loaded one
+	if err := os.WriteFile(mappingFile, []byte(`{"phone": `), 0644); err != nil {
+		t.Fatalf("Failed to rewrite mapping file: %v", err)
+	}
+	if _, err := watcher.Check(); err == nil {
+		t.Error("Expected an error for an invalid mapping")
+	}
+	if mapping, _ := watcher.AttributeMapping(); mapping.Mapping["phone"].Type != "text" {
+		t.Errorf("Expected the previous mapping to be kept, got %+v", mapping.Mapping)
+	}
+
+	if err := os.WriteFile(mappingFile, []byte(`{"email": {"type": "email", "required": true}}`), 0644); err != nil {
+		t.Fatalf("Failed to rewrite mapping file: %v", err)
+	}
+	if reloaded, err := watcher.Check(); err != nil || !reloaded {
+		t.Fatalf("Expected the changed mapping to be reloaded, got %v, %v", reloaded, err)
+	}
+	mapping, _ := watcher.AttributeMapping()
+	if _, ok := mapping.Mapping["email"]; !ok || len(mapping.Mapping) != 1 {
+		t.Errorf("Expected the reloaded mapping, got %+v", mapping.Mapping)
+	}
+	if mapping.DefaultMaxFieldBytes != 64 {
+		t.Errorf("Expected the other mapping settings to be kept, got %d", mapping.DefaultMaxFieldBytes)
+	}
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/logger"
+)
+
+// Environments selected by APP_ENV. Each has an optional section of the config file
+// whose settings override the file's top-level settings.
+const (
+	EnvironmentDevelopment = "development"
+	EnvironmentStaging     = "staging"
+	EnvironmentProduction  = "production"
+)
+
+// environments lists the valid APP_ENV values, which are also the config file sections
+var environments = []string{EnvironmentDevelopment, EnvironmentStaging, EnvironmentProduction}
+
+var (
+	// fileValuesMu serializes Load, which consults fileValues through getEnv
+	fileValuesMu sync.Mutex
+
+	// fileValues holds the settings of the config file for the current environment during
+	// Load, keyed by environment variable name
+	fileValues map[string]string
+)
+
+// LoadConfigFile reads the JSON config file at path and returns its settings for the
+// given environment, keyed by environment variable name. Nested objects name a setting
+// by their keys joined with "_", so {"db": {"host": "x"}} sets DB_HOST. The top-level
+// "development", "staging" and "production" sections hold environment-specific settings;
+// the section of env overrides the top-level settings, the other sections are ignored.
+// Numbers and booleans are converted to their text, arrays to comma-separated lists.
+func LoadConfigFile(path, env string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var file map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	sections := make(map[string]map[string]interface{})
+	for key, value := range file {
+		if isEnvironment(key) {
+			section, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid config file %s: section %s must be an object", path, key)
+			}
+			sections[key] = section
+			continue
+		}
+		if err := flattenConfigValue(key, value, values); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+	for key, value := range sections[env] {
+		if err := flattenConfigValue(key, value, values); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+	return values, nil
+}
+
+// flattenConfigValue adds the setting at key, or the settings nested below it, to values
+func flattenConfigValue(key string, value interface{}, values map[string]string) error {
+	name := strings.ToUpper(key)
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		for nestedKey, nestedValue := range v {
+			if err := flattenConfigValue(key+"_"+nestedKey, nestedValue, values); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			text, ok := configText(item)
+			if !ok {
+				return fmt.Errorf("%s must be a list of strings, numbers or booleans", name)
+			}
+			items = append(items, text)
+		}
+		values[name] = strings.Join(items, ",")
+		return nil
+	default:
+		text, ok := configText(v)
+		if !ok {
+			return fmt.Errorf("%s has an unsupported value %v", name, v)
+		}
+		values[name] = text
+		return nil
+	}
+}
+
+// configText returns the text of a scalar config file value
+func configText(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		if v {
+			return "true", true
+		}
+		return "false", true
+	default:
+		return "", false
+	}
+}
+
+// isEnvironment reports whether env is a valid APP_ENV value
+func isEnvironment(env string) bool {
+	for _, e := range environments {
+		if env == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Dump logs the effective configuration at startup with secrets redacted
+func (c *Config) Dump(ctx context.Context) {
+	logger.Info(ctx, "Effective configuration",
+		"environment", c.Environment,
+		"config", c.Redacted())
+}
+
+// Redacted returns the configuration as nested maps keyed by field name, with passwords,
+// tokens, secrets and keys replaced by "REDACTED" and credentials removed from URLs
+func (c *Config) Redacted() map[string]interface{} {
+	return redactStruct(reflect.ValueOf(*c))
+}
+
+// sensitiveFieldNames are parts of config field names whose values are redacted
+// Webhook URLs, e.g. Slack's, carry their credential in the path.
+var sensitiveFieldNames = []string{"Password", "Token", "Secret", "EncryptionKey", "AccessKey", "WebhookURL"}
+
+// redactStruct converts a config struct to a map, redacting sensitive fields
+func redactStruct(v reflect.Value) map[string]interface{} {
+	result := make(map[string]interface{}, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		result[field.Name] = redactValue(field.Name, v.Field(i))
+	}
+	return result
+}
+
+// redactValue converts the value of the named config field for Redacted
+func redactValue(name string, v reflect.Value) interface{} {
+	for _, sensitive := range sensitiveFieldNames {
+		if strings.Contains(name, sensitive) {
+			if v.IsZero() {
+				return v.Interface()
+			}
+			return "REDACTED"
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return redactStruct(v)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Struct {
+			return v.Interface()
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = redactStruct(v.Index(i))
+		}
+		return items
+	case reflect.String:
+		return redactURL(v.String())
+	case reflect.Int64:
+		if d, ok := v.Interface().(time.Duration); ok {
+			return d.String()
+		}
+	}
+	return v.Interface()
+}
+
+// redactURL removes the password of a URL with credentials, e.g. a proxy URL
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return value
+	}
+	if _, ok := u.User.Password(); !ok {
+		return value
+	}
+	u.User = url.UserPassword(u.User.Username(), "REDACTED")
+	return u.String()
+}
@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // Config holds database connection configuration
@@ -21,6 +21,7 @@ type Config struct {
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
 	ConnMaxIdleTime time.Duration
+	QueryTimeout    time.Duration // maximum duration of a statement or transaction (0 = no limit)
 }
 
 // DB wraps sql.DB with additional functionality
@@ -38,10 +39,16 @@ func New(cfg Config) (*DB, error) {
 	)
 
 	// Open database connection
-	db, err := sql.Open("postgres", connStr)
+	connector, err := pq.NewConnector(connStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
+	var db *sql.DB
+	if cfg.QueryTimeout > 0 {
+		db = sql.OpenDB(WithQueryTimeout(connector, cfg.QueryTimeout))
+	} else {
+		db = sql.OpenDB(connector)
+	}
 
 	// Set connection pool parameters with defaults
 	if cfg.MaxOpenConns == 0 {
@@ -15,6 +15,8 @@ func InitFromConfig(cfg *config.Config) (*DB, error) {
 		Password: cfg.Database.Password,
 		DBName:   cfg.Database.DBName,
 		SSLMode:  cfg.Database.SSLMode,
+
+		QueryTimeout: cfg.Database.QueryTimeout,
 	}
 
 	db, err := New(dbConfig)
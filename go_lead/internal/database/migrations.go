@@ -63,6 +63,12 @@ func (mr *MigrationRunner) Run() error {
 		fmt.Printf("Applied migration %d: %s\n", migration.Version, migration.Name)
 	}
 
+	version, err := mr.CurrentVersion()
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	fmt.Printf("Schema at version %d\n", version)
+
 	return nil
 }
 
@@ -198,6 +204,52 @@ func (mr *MigrationRunner) applyMigration(migration Migration) error {
 	return nil
 }
 
+// Pending returns the migrations that have not been applied yet, in version order
+func (mr *MigrationRunner) Pending() ([]Migration, error) {
+	migrations, err := mr.loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	appliedVersions, err := mr.getAppliedMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	var pending []Migration
+	for _, migration := range migrations {
+		if !appliedVersions[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// CurrentVersion returns the highest migration version applied to the database, 0 if none
+func (mr *MigrationRunner) CurrentVersion() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var version int
+	err := mr.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// LatestVersion returns the highest migration version shipped with the application, 0 if none
+func (mr *MigrationRunner) LatestVersion() (int, error) {
+	migrations, err := mr.loadMigrations()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
 // Status returns the current migration status
 func (mr *MigrationRunner) Status() error {
 	migrations, err := mr.loadMigrations()
@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WithQueryTimeout returns a connector whose connections bound every statement by timeout,
+// on top of any deadline of the caller's context. A query's deadline also covers reading
+// its rows. A statement that runs into the timeout fails with an error wrapping
+// context.DeadlineExceeded. A transaction is bounded as a whole: its context, which the
+// driver watches until commit or rollback, expires timeout after BEGIN.
+//
+// The timeout applies to database work only, rather than to the context of a whole request
+// or job, so that Customer API calls and queue backoff are not counted against it.
+func WithQueryTimeout(connector driver.Connector, timeout time.Duration) driver.Connector {
+	return &timeoutConnector{Connector: connector, timeout: timeout}
+}
+
+// timeoutConnector opens connections that apply the query timeout
+type timeoutConnector struct {
+	driver.Connector
+	timeout time.Duration
+}
+
+func (c *timeoutConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &timeoutConn{Conn: conn, timeout: c.timeout}, nil
+}
+
+// timeoutConn applies the query timeout to the statements run on a connection. Optional
+// driver interfaces the wrapped connection lacks fall back to the database/sql defaults.
+type timeoutConn struct {
+	driver.Conn
+	timeout time.Duration
+}
+
+// withTimeout derives the context of a single statement
+func (c *timeoutConn) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// timeoutError marks err as caused by the query timeout if the statement context, but
+// not the caller's context, ran out
+func (c *timeoutConn) timeoutError(parent, ctx context.Context, err error) error {
+	if err == nil || parent.Err() != nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%w: query exceeded %s: %v", context.DeadlineExceeded, c.timeout, err)
+}
+
+func (c *timeoutConn) QueryContext(parent context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, cancel := c.withTimeout(parent)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		cancel()
+		return nil, c.timeoutError(parent, ctx, err)
+	}
+	return &timeoutRows{Rows: rows, cancel: cancel}, nil
+}
+
+func (c *timeoutConn) ExecContext(parent context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, cancel := c.withTimeout(parent)
+	defer cancel()
+	result, err := execer.ExecContext(ctx, query, args)
+	return result, c.timeoutError(parent, ctx, err)
+}
+
+func (c *timeoutConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &timeoutStmt{Stmt: stmt, conn: c}, nil
+}
+
+func (c *timeoutConn) BeginTx(parent context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	beginner, ok := c.Conn.(driver.ConnBeginTx)
+	if !ok {
+		return c.Conn.Begin()
+	}
+	ctx, cancel := c.withTimeout(parent)
+	tx, err := beginner.BeginTx(ctx, opts)
+	if err != nil {
+		cancel()
+		return nil, c.timeoutError(parent, ctx, err)
+	}
+	return &timeoutTx{Tx: tx, cancel: cancel}, nil
+}
+
+func (c *timeoutConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *timeoutConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *timeoutConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// timeoutStmt applies the query timeout to a prepared statement
+type timeoutStmt struct {
+	driver.Stmt
+	conn *timeoutConn
+}
+
+func (s *timeoutStmt) QueryContext(parent context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		return nil, errors.New("prepared statement does not support contexts")
+	}
+	ctx, cancel := s.conn.withTimeout(parent)
+	rows, err := queryer.QueryContext(ctx, args)
+	if err != nil {
+		cancel()
+		return nil, s.conn.timeoutError(parent, ctx, err)
+	}
+	return &timeoutRows{Rows: rows, cancel: cancel}, nil
+}
+
+func (s *timeoutStmt) ExecContext(parent context.Context, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		return nil, errors.New("prepared statement does not support contexts")
+	}
+	ctx, cancel := s.conn.withTimeout(parent)
+	defer cancel()
+	result, err := execer.ExecContext(ctx, args)
+	return result, s.conn.timeoutError(parent, ctx, err)
+}
+
+// timeoutTx releases the transaction context once the transaction ends
+type timeoutTx struct {
+	driver.Tx
+	cancel context.CancelFunc
+}
+
+func (tx *timeoutTx) Commit() error {
+	defer tx.cancel()
+	return tx.Tx.Commit()
+}
+
+func (tx *timeoutTx) Rollback() error {
+	defer tx.cancel()
+	return tx.Tx.Rollback()
+}
+
+// timeoutRows releases the statement context once the rows are closed
+type timeoutRows struct {
+	driver.Rows
+	cancel context.CancelFunc
+}
+
+func (r *timeoutRows) Close() error {
+	err := r.Rows.Close()
+	r.cancel()
+	return err
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// blockingConnector opens connections whose statements block until their context is done
+type blockingConnector struct{}
+
+func (blockingConnector) Connect(context.Context) (driver.Conn, error) { return blockingConn{}, nil }
+func (c blockingConnector) Driver() driver.Driver                      { return blockingDriver{c} }
+
+type blockingDriver struct{ connector blockingConnector }
+
+func (d blockingDriver) Open(string) (driver.Conn, error) {
+	return d.connector.Connect(context.Background())
+}
+
+type blockingConn struct{}
+
+func (blockingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (blockingConn) Close() error              { return nil }
+func (blockingConn) Begin() (driver.Tx, error) { return nil, errors.New("transactions not supported") }
+
+func (blockingConn) QueryContext(ctx context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if query == "SELECT 1" {
+		return &oneRow{}, nil
+	}
+	<-ctx.Done()
+	// Like lib/pq, report the cancellation as a driver error rather than the context error
+	return nil, errors.New("canceling statement due to user request")
+}
+
+// BeginTx blocks for read-only transactions. Like lib/pq, the transaction watches ctx
+// until it ends, and fails to commit once ctx is done.
+func (blockingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.ReadOnly {
+		<-ctx.Done()
+		return nil, errors.New("canceling statement due to user request")
+	}
+	return contextTx{ctx: ctx}, nil
+}
+
+type contextTx struct{ ctx context.Context }
+
+func (tx contextTx) Commit() error {
+	if tx.ctx.Err() != nil {
+		return errors.New("driver: bad connection")
+	}
+	return nil
+}
+
+func (contextTx) Rollback() error { return nil }
+
+func (blockingConn) ExecContext(ctx context.Context, _ string, _ []driver.NamedValue) (driver.Result, error) {
+	<-ctx.Done()
+	return nil, errors.New("canceling statement due to user request")
+}
+
+// oneRow returns a single row with the value 1
+type oneRow struct{ done bool }
+
+func (*oneRow) Columns() []string { return []string{"value"} }
+func (*oneRow) Close() error      { return nil }
+func (r *oneRow) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func TestWithQueryTimeout(t *testing.T) {
+	db := sql.OpenDB(WithQueryTimeout(blockingConnector{}, 50*time.Millisecond))
+	defer db.Close()
+	ctx := context.Background()
+
+	var value int
+	if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&value); err != nil || value != 1 {
+		t.Fatalf("Expected a fast query to succeed, got %d and %v", value, err)
+	}
+
+	start := time.Now()
+	_, err := db.QueryContext(ctx, "SELECT pg_sleep(60)")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a blocking query to fail with context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the query to be canceled after the timeout, took %s", elapsed)
+	}
+
+	if _, err := db.ExecContext(ctx, "UPDATE inbound_lead SET status = 'READY'"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a blocking statement to fail with context.DeadlineExceeded, got %v", err)
+	}
+
+	// A canceled caller context is reported as such, not as a query timeout
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := db.ExecContext(canceled, "UPDATE inbound_lead SET status = 'READY'"); errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a canceled context not to be reported as a timeout, got %v", err)
+	}
+}
+
+func TestWithQueryTimeout_Transactions(t *testing.T) {
+	db := sql.OpenDB(WithQueryTimeout(blockingConnector{}, 50*time.Millisecond))
+	defer db.Close()
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Errorf("Expected a fast transaction to commit, got %v", err)
+	}
+
+	// A statement within a transaction is bounded like any other
+	if tx, err = db.BeginTx(ctx, nil); err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE inbound_lead SET status = 'READY'"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a blocking statement to fail with context.DeadlineExceeded, got %v", err)
+	}
+	tx.Rollback()
+
+	// So is the transaction as a whole, even if each statement is fast
+	if tx, err = db.BeginTx(ctx, nil); err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := tx.Commit(); err == nil {
+		t.Error("Expected a transaction outlasting the timeout to fail to commit")
+	}
+
+	// And starting one
+	start := time.Now()
+	if _, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a blocking BEGIN to fail with context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected BEGIN to be canceled after the timeout, took %s", elapsed)
+	}
+}
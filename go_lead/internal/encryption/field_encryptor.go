@@ -0,0 +1,304 @@
+// Package encryption encrypts configured PII fields of lead payloads at rest.
+//
+// Values use envelope encryption: each value is sealed with AES-256-GCM under a fresh
+// data key, and the data key is sealed under the configured key-encryption key. The
+// result replaces the value in the payload as a string of the form
+//
+//	enc:v1:<key ID>:<sealed data key>:<sealed value>
+//
+// with both sealed parts base64-encoded. The key ID identifies the key-encryption key,
+// so values stored under another key are reported instead of failing obscurely.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// Marker prefixes encrypted values in stored payloads
+const Marker = "enc:v1:"
+
+// dataKeySize is the size of the per-value AES-256 data keys
+const dataKeySize = 32
+
+// ErrUnknownKey is returned when decrypting a value sealed under another key-encryption key
+var ErrUnknownKey = errors.New("value was encrypted with an unknown key")
+
+// FieldEncryptor encrypts and decrypts the configured fields of lead payloads. A nil
+// FieldEncryptor leaves payloads unchanged, so callers need not check whether encryption
+// is configured.
+type FieldEncryptor struct {
+	kek    cipher.AEAD
+	keyID  string
+	fields map[string]bool
+}
+
+// NewFieldEncryptor creates a FieldEncryptor that seals data keys with the given 32-byte
+// key and encrypts the values of fields, matched by key name at any depth and ignoring case
+func NewFieldEncryptor(key []byte, fields []string) (*FieldEncryptor, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	kek, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(key)
+	e := &FieldEncryptor{
+		kek:    kek,
+		keyID:  hex.EncodeToString(sum[:4]),
+		fields: make(map[string]bool, len(fields)),
+	}
+	for _, field := range fields {
+		e.fields[strings.ToLower(field)] = true
+	}
+	return e, nil
+}
+
+// NewFieldEncryptorFromConfig creates a FieldEncryptor from the privacy config, or returns
+// nil when no encryption key is configured
+func NewFieldEncryptorFromConfig(cfg config.PrivacyConfig) (*FieldEncryptor, error) {
+	if cfg.EncryptionKey == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(cfg.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return NewFieldEncryptor(key, cfg.EncryptedFields)
+}
+
+// IsEncrypted reports whether value is an encrypted field value
+func IsEncrypted(value interface{}) bool {
+	s, ok := value.(string)
+	return ok && strings.HasPrefix(s, Marker)
+}
+
+// EncryptPayload returns a copy of payload with the values of the configured fields
+// encrypted, including fields of nested objects and of objects in arrays. Null and
+// already encrypted values are kept. The payload itself is not modified.
+func (e *FieldEncryptor) EncryptPayload(payload models.JSONB) (models.JSONB, error) {
+	if e == nil || payload == nil {
+		return payload, nil
+	}
+	encrypted, err := e.encryptObject(payload)
+	if err != nil {
+		return nil, err
+	}
+	return encrypted, nil
+}
+
+// DecryptPayload returns a copy of payload with all encrypted values decrypted, whatever
+// their field. Payloads stored before encryption was enabled are returned as they are.
+func (e *FieldEncryptor) DecryptPayload(payload models.JSONB) (models.JSONB, error) {
+	if e == nil || payload == nil {
+		return payload, nil
+	}
+	decrypted, err := e.DecryptValue(map[string]interface{}(payload))
+	if err != nil {
+		return nil, err
+	}
+	return decrypted.(map[string]interface{}), nil
+}
+
+// EncryptField encrypts value if field, a dot-separated path, ends in a configured field
+func (e *FieldEncryptor) EncryptField(field string, value interface{}) (interface{}, error) {
+	if e == nil {
+		return value, nil
+	}
+	if i := strings.LastIndex(field, "."); i >= 0 {
+		field = field[i+1:]
+	}
+	if !e.fields[strings.ToLower(field)] || value == nil || IsEncrypted(value) {
+		return value, nil
+	}
+	return e.encrypt(value)
+}
+
+// DecryptValue decrypts an encrypted value, or the encrypted values within an object or
+// array; other values are returned unchanged
+func (e *FieldEncryptor) DecryptValue(value interface{}) (interface{}, error) {
+	if e == nil {
+		return value, nil
+	}
+	switch v := value.(type) {
+	case string:
+		if !IsEncrypted(v) {
+			return v, nil
+		}
+		return e.decrypt(v)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			decrypted, err := e.DecryptValue(val)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = decrypted
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, val := range v {
+			decrypted, err := e.DecryptValue(val)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = decrypted
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+// encryptObject encrypts the configured fields of an object and of the objects within it
+func (e *FieldEncryptor) encryptObject(object map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(object))
+	for key, value := range object {
+		var err error
+		if e.fields[strings.ToLower(key)] {
+			result[key], err = e.EncryptField(key, value)
+		} else {
+			result[key], err = e.encryptNested(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// encryptNested encrypts the configured fields of the objects within value
+func (e *FieldEncryptor) encryptNested(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return e.encryptObject(v)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, val := range v {
+			encrypted, err := e.encryptNested(val)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = encrypted
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+// encrypt seals the JSON encoding of value under a fresh data key
+func (e *FieldEncryptor) encrypt(value interface{}) (string, error) {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode field value: %w", err)
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	dek, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealedKey, err := seal(e.kek, dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealedValue, err := seal(dek, plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	return Marker + e.keyID + ":" +
+		base64.StdEncoding.EncodeToString(sealedKey) + ":" +
+		base64.StdEncoding.EncodeToString(sealedValue), nil
+}
+
+// decrypt opens a value sealed by encrypt
+func (e *FieldEncryptor) decrypt(value string) (interface{}, error) {
+	parts := strings.Split(strings.TrimPrefix(value, Marker), ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("failed to decrypt field value: malformed value")
+	}
+	if parts[0] != e.keyID {
+		return nil, fmt.Errorf("failed to decrypt field value: %w (key ID %s)", ErrUnknownKey, parts[0])
+	}
+	sealedKey, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt field value: %w", err)
+	}
+	sealedValue, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt field value: %w", err)
+	}
+
+	dataKey, err := open(e.kek, sealedKey)
+	if err != nil {
+		return nil, err
+	}
+	dek, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(dek, sealedValue)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	if err := models.DecodeJSON(plaintext, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode field value: %w", err)
+	}
+	return decoded, nil
+}
+
+// newAEAD creates an AES-GCM cipher for a 32-byte key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return aead, nil
+}
+
+// seal encrypts plaintext under a random nonce, which is prepended to the result
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts a result of seal
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("failed to decrypt field value: malformed value")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt field value: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,141 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+func testEncryptor(t *testing.T, keyByte byte) *FieldEncryptor {
+	t.Helper()
+	encryptor, err := NewFieldEncryptor(bytes.Repeat([]byte{keyByte}, 32), []string{"email", "Phone"})
+	if err != nil {
+		t.Fatalf("Failed to create encryptor: %v", err)
+	}
+	return encryptor
+}
+
+func TestFieldEncryptor_RoundTrip(t *testing.T) {
+	encryptor := testEncryptor(t, 1)
+	payload := models.JSONB{
+		"email":   "max@example.com",
+		"phone":   float64(4915112345678), // values of any type are encrypted
+		"zipcode": "66123",
+		"contact": map[string]interface{}{"PHONE": "0151 1234567", "name": "Max"},
+		"persons": []interface{}{map[string]interface{}{"email": "erika@example.com"}},
+		"notes":   nil,
+	}
+
+	encrypted, err := encryptor.EncryptPayload(payload)
+	if err != nil {
+		t.Fatalf("Failed to encrypt payload: %v", err)
+	}
+
+	for _, value := range []interface{}{
+		encrypted["email"],
+		encrypted["phone"],
+		encrypted["contact"].(map[string]interface{})["PHONE"],
+		encrypted["persons"].([]interface{})[0].(map[string]interface{})["email"],
+	} {
+		if !IsEncrypted(value) {
+			t.Errorf("Expected an encrypted value, got %v", value)
+		}
+	}
+	if encrypted["zipcode"] != "66123" || encrypted["contact"].(map[string]interface{})["name"] != "Max" || encrypted["notes"] != nil {
+		t.Errorf("Expected other fields to stay plaintext, got %v", encrypted)
+	}
+	data, _ := json.Marshal(encrypted)
+	if strings.Contains(string(data), "example.com") || strings.Contains(string(data), "1234567") {
+		t.Errorf("Expected no plaintext PII in the stored payload, got %s", data)
+	}
+	if payload["email"] != "max@example.com" {
+		t.Error("Expected the original payload to be left unchanged")
+	}
+
+	decrypted, err := encryptor.DecryptPayload(encrypted)
+	if err != nil {
+		t.Fatalf("Failed to decrypt payload: %v", err)
+	}
+	if !reflect.DeepEqual(decrypted, payload) {
+		t.Errorf("Expected %v, got %v", payload, decrypted)
+	}
+}
+
+func TestFieldEncryptor_EnvelopeFormat(t *testing.T) {
+	encryptor := testEncryptor(t, 1)
+
+	first, _ := encryptor.EncryptField("email", "max@example.com")
+	second, _ := encryptor.EncryptField("contact.email", "max@example.com")
+	if first == second {
+		t.Error("Expected a fresh data key and nonce for every value")
+	}
+
+	parts := strings.Split(strings.TrimPrefix(first.(string), Marker), ":")
+	if len(parts) != 3 || parts[0] != encryptor.keyID {
+		t.Fatalf("Expected enc:v1:<key ID>:<data key>:<value>, got %s", first)
+	}
+	sealedKey, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil || len(sealedKey) != encryptor.kek.NonceSize()+dataKeySize+encryptor.kek.Overhead() {
+		t.Errorf("Expected a sealed 32-byte data key, got %d bytes (%v)", len(sealedKey), err)
+	}
+
+	// Fields outside the configuration and encrypted values are kept
+	if value, _ := encryptor.EncryptField("zipcode", "66123"); value != "66123" {
+		t.Errorf("Expected zipcode to stay plaintext, got %v", value)
+	}
+	if value, _ := encryptor.EncryptField("email", first); value != first {
+		t.Error("Expected an encrypted value not to be encrypted twice")
+	}
+}
+
+func TestFieldEncryptor_DecryptErrors(t *testing.T) {
+	encrypted, err := testEncryptor(t, 1).EncryptField("email", "max@example.com")
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+
+	if _, err := testEncryptor(t, 2).DecryptValue(encrypted); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey for another key, got %v", err)
+	}
+
+	// Tampering with the sealed value is detected
+	s := encrypted.(string)
+	tampered := s[:len(s)-4] + "AAA="
+	if _, err := testEncryptor(t, 1).DecryptValue(tampered); err == nil {
+		t.Error("Expected an error for a tampered value")
+	}
+	if _, err := testEncryptor(t, 1).DecryptValue(Marker + "broken"); err == nil {
+		t.Error("Expected an error for a malformed value")
+	}
+}
+
+func TestFieldEncryptor_Disabled(t *testing.T) {
+	encryptor, err := NewFieldEncryptorFromConfig(config.PrivacyConfig{EncryptedFields: []string{"email"}})
+	if err != nil || encryptor != nil {
+		t.Fatalf("Expected no encryptor without a key, got %v, %v", encryptor, err)
+	}
+
+	// A nil encryptor leaves payloads unchanged
+	payload := models.JSONB{"email": "max@example.com"}
+	if encrypted, err := encryptor.EncryptPayload(payload); err != nil || encrypted["email"] != "max@example.com" {
+		t.Errorf("Expected the payload unchanged, got %v, %v", encrypted, err)
+	}
+
+	if _, err := NewFieldEncryptor(make([]byte, 16), nil); err == nil {
+		t.Error("Expected an error for a 16-byte key")
+	}
+	encryptor, err = NewFieldEncryptorFromConfig(config.PrivacyConfig{
+		EncryptionKey:   base64.StdEncoding.EncodeToString(make([]byte, 32)),
+		EncryptedFields: []string{"email"},
+	})
+	if err != nil || encryptor == nil {
+		t.Errorf("Expected an encryptor for a configured key, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/queue"
+	"github.com/checkfox/go_lead/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultPendingJobsLimit is the number of jobs returned when no limit is given
+	defaultPendingJobsLimit = 50
+
+	// maxPendingJobsLimit caps the limit query parameter
+	maxPendingJobsLimit = 500
+)
+
+// JobPeeker lists upcoming jobs without claiming them, implemented by queue.DBQueue
+type JobPeeker interface {
+	Peek(ctx context.Context, limit int) ([]*queue.Job, error)
+}
+
+// AdminQueue is the queue access needed by the admin endpoints, implemented by queue.DBQueue
+type AdminQueue interface {
+	JobPeeker
+	Enqueue(ctx context.Context, jobType string, payload map[string]interface{}) error
+}
+
+// AdminHandler handles operational endpoints for observability tooling
+type AdminHandler struct {
+	queue    AdminQueue
+	leadRepo repository.LeadRepository
+}
+
+// NewAdminHandler creates a new AdminHandler
+func NewAdminHandler(queue AdminQueue, leadRepo repository.LeadRepository) *AdminHandler {
+	return &AdminHandler{
+		queue:    queue,
+		leadRepo: leadRepo,
+	}
+}
+
+// PendingJobsResponse lists the next due jobs in dequeue order
+type PendingJobsResponse struct {
+	Jobs  []*queue.Job `json:"jobs"`
+	Count int          `json:"count"`
+}
+
+// HandlePendingJobs handles GET /admin/queue/pending?limit=N
+// The jobs are read without locking, so listing them never delays or blocks workers.
+func (h *AdminHandler) HandlePendingJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := defaultPendingJobsLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxPendingJobsLimit {
+			http.Error(w, "limit must be between 1 and "+strconv.Itoa(maxPendingJobsLimit), http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	jobs, err := h.queue.Peek(ctx, limit)
+	if err != nil {
+		logger.LogError(ctx, "Failed to peek pending jobs", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(PendingJobsResponse{
+		Jobs:  jobs,
+		Count: len(jobs),
+	})
+}
+
+// RedeliverResponse is returned once a delivery job has been enqueued
+type RedeliverResponse struct {
+	LeadID  int64  `json:"lead_id"`
+	JobType string `json:"job_type"`
+}
+
+// HandleRedeliver handles POST /admin/leads/{id}/deliver
+// It enqueues a process_lead job for a READY lead that has no pending job, e.g. after a
+// lost enqueue. The worker delivers READY leads without re-running validation and
+// transformation. Leads in any other state are refused, as they either have not been
+// transformed yet or have already reached a delivery outcome.
+func (h *AdminHandler) HandleRedeliver(w http.ResponseWriter, r *http.Request) {
+	correlationID := uuid.New().String()
+	ctx := context.WithValue(r.Context(), logger.CorrelationIDKey, correlationID)
+
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	leadID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || leadID <= 0 {
+		http.Error(w, "invalid lead ID", http.StatusBadRequest)
+		return
+	}
+	ctx = context.WithValue(ctx, logger.LeadIDKey, leadID)
+
+	lead, err := h.leadRepo.GetLeadByID(ctx, leadID)
+	if errors.Is(err, repository.ErrLeadNotFound) {
+		http.Error(w, "lead not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.LogError(ctx, "Failed to load lead for redelivery", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if lead.Status != models.LeadStatusReady {
+		logger.Warn(ctx, "Refusing redelivery of lead that is not ready", "status", lead.Status)
+		http.Error(w, "lead is not READY (status "+string(lead.Status)+")", http.StatusConflict)
+		return
+	}
+
+	jobPayload := queue.NewJobPayload(lead.ID)
+	jobPayload["correlation_id"] = correlationID
+	if err := h.queue.Enqueue(ctx, queue.JobTypeProcessLead, jobPayload); err != nil {
+		logger.LogError(ctx, "Failed to enqueue redelivery job", err)
+		http.Error(w, "queue unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	logger.Info(ctx, "Enqueued redelivery job")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Correlation-ID", correlationID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(RedeliverResponse{
+		LeadID:  lead.ID,
+		JobType: queue.JobTypeProcessLead,
+	})
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/queue"
+)
+
+// mockJobPeeker returns a fixed job list and records the requested limit and enqueued jobs
+type mockJobPeeker struct {
+	jobs       []*queue.Job
+	err        error
+	lastLimit  int
+	enqueued   []map[string]interface{}
+	enqueueErr error
+}
+
+func (m *mockJobPeeker) Enqueue(ctx context.Context, jobType string, payload map[string]interface{}) error {
+	if m.enqueueErr != nil {
+		return m.enqueueErr
+	}
+	if jobType != queue.JobTypeProcessLead {
+		return errors.New("unexpected job type " + jobType)
+	}
+	m.enqueued = append(m.enqueued, payload)
+	return nil
+}
+
+func (m *mockJobPeeker) Peek(ctx context.Context, limit int) ([]*queue.Job, error) {
+	m.lastLimit = limit
+	if m.err != nil {
+		return nil, m.err
+	}
+	if len(m.jobs) > limit {
+		return m.jobs[:limit], nil
+	}
+	return m.jobs, nil
+}
+
+func TestHandlePendingJobs(t *testing.T) {
+	peeker := &mockJobPeeker{
+		jobs: []*queue.Job{
+			{ID: 2, Type: queue.JobTypeProcessLead, Payload: queue.NewJobPayload(20)},
+			{ID: 1, Type: queue.JobTypeProcessLead, Payload: queue.NewJobPayload(10), Priority: 1},
+		},
+	}
+	handler := NewAdminHandler(peeker, &mockLeadRepoForStats{})
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/queue/pending", nil)
+	w := httptest.NewRecorder()
+	handler.HandlePendingJobs(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if peeker.lastLimit != defaultPendingJobsLimit {
+		t.Errorf("Expected default limit %d, got %d", defaultPendingJobsLimit, peeker.lastLimit)
+	}
+
+	var response PendingJobsResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Count != 2 || len(response.Jobs) != 2 {
+		t.Fatalf("Expected 2 jobs, got count %d with %d jobs", response.Count, len(response.Jobs))
+	}
+	if response.Jobs[0].ID != 2 || response.Jobs[1].ID != 1 {
+		t.Errorf("Expected jobs in peek order [2 1], got [%d %d]", response.Jobs[0].ID, response.Jobs[1].ID)
+	}
+}
+
+func TestHandlePendingJobs_Limit(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantLimit  int
+	}{
+		{"custom limit", "?limit=5", http.StatusOK, 5},
+		{"max limit", "?limit=500", http.StatusOK, 500},
+		{"zero limit", "?limit=0", http.StatusBadRequest, 0},
+		{"limit above max", "?limit=501", http.StatusBadRequest, 0},
+		{"non-numeric limit", "?limit=all", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peeker := &mockJobPeeker{}
+			handler := NewAdminHandler(peeker, &mockLeadRepoForStats{})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/queue/pending"+tt.query, nil)
+			w := httptest.NewRecorder()
+			handler.HandlePendingJobs(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if peeker.lastLimit != tt.wantLimit {
+				t.Errorf("Expected limit %d, got %d", tt.wantLimit, peeker.lastLimit)
+			}
+		})
+	}
+}
+
+func TestHandlePendingJobs_Errors(t *testing.T) {
+	handler := NewAdminHandler(&mockJobPeeker{err: errors.New("database unavailable")}, &mockLeadRepoForStats{})
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/queue/pending", nil)
+	w := httptest.NewRecorder()
+	handler.HandlePendingJobs(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 on peek error, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/queue/pending", nil)
+	w = httptest.NewRecorder()
+	handler.HandlePendingJobs(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for POST, got %d", w.Code)
+	}
+}
+
+// postRedeliver routes the request through a mux so the {id} path value is set
+func postRedeliver(handler *AdminHandler, method, path string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/leads/{id}/deliver", handler.HandleRedeliver)
+
+	req := httptest.NewRequest(method, path, nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandleRedeliver_ReadyLeadEnqueuesJob(t *testing.T) {
+	jobs := &mockJobPeeker{}
+	leadRepo := &mockLeadRepoForStats{
+		leads: []*models.InboundLead{{
+			ID:              7,
+			Status:          models.LeadStatusReady,
+			CustomerPayload: models.JSONB{"phone": "1234567890"},
+		}},
+	}
+	handler := NewAdminHandler(jobs, leadRepo)
+
+	w := postRedeliver(handler, http.MethodPost, "/admin/leads/7/deliver")
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(jobs.enqueued) != 1 {
+		t.Fatalf("Expected 1 enqueued job, got %d", len(jobs.enqueued))
+	}
+	if leadID, ok := queue.GetLeadID(jobs.enqueued[0]); !ok || leadID != 7 {
+		t.Errorf("Expected job for lead 7, got %v", jobs.enqueued[0])
+	}
+
+	var response RedeliverResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.LeadID != 7 || response.JobType != queue.JobTypeProcessLead {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+}
+
+func TestHandleRedeliver_Refused(t *testing.T) {
+	leadRepo := &mockLeadRepoForStats{
+		leads: []*models.InboundLead{
+			{ID: 7, Status: models.LeadStatusReceived},
+			{ID: 8, Status: models.LeadStatusDelivered},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"received lead", http.MethodPost, "/admin/leads/7/deliver", http.StatusConflict},
+		{"delivered lead", http.MethodPost, "/admin/leads/8/deliver", http.StatusConflict},
+		{"unknown lead", http.MethodPost, "/admin/leads/9/deliver", http.StatusNotFound},
+		{"invalid id", http.MethodPost, "/admin/leads/abc/deliver", http.StatusBadRequest},
+		{"get request", http.MethodGet, "/admin/leads/7/deliver", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs := &mockJobPeeker{}
+			w := postRedeliver(NewAdminHandler(jobs, leadRepo), tt.method, tt.path)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if len(jobs.enqueued) != 0 {
+				t.Errorf("Expected no enqueued job, got %d", len(jobs.enqueued))
+			}
+		})
+	}
+}
+
+func TestHandleRedeliver_QueueUnavailable(t *testing.T) {
+	leadRepo := &mockLeadRepoForStats{
+		leads: []*models.InboundLead{{ID: 7, Status: models.LeadStatusReady}},
+	}
+	handler := NewAdminHandler(&mockJobPeeker{enqueueErr: errors.New("database unavailable")}, leadRepo)
+
+	if w := postRedeliver(handler, http.MethodPost, "/admin/leads/7/deliver"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/queue"
+	"github.com/checkfox/go_lead/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Confirmation statuses reported by the Customer API
+const (
+	ConfirmationStatusSuccess = "success"
+	ConfirmationStatusFailure = "failure"
+)
+
+// OutcomeNotifier schedules the notification of a webhook sender about the final outcome of its lead
+type OutcomeNotifier interface {
+	Schedule(ctx context.Context, lead *models.InboundLead) error
+}
+
+// CallbackHandler handles asynchronous delivery confirmations from the Customer API
+type CallbackHandler struct {
+	leadRepo            repository.LeadRepository
+	deliveryAttemptRepo repository.DeliveryAttemptRepository
+	queue               queue.Queue
+	retry               config.RetryConfig
+	notifier            OutcomeNotifier
+}
+
+// NewCallbackHandler creates a new CallbackHandler. Leads whose delivery the Customer API
+// reports as failed are re-enqueued on q following the retry configuration.
+func NewCallbackHandler(leadRepo repository.LeadRepository, deliveryAttemptRepo repository.DeliveryAttemptRepository, q queue.Queue, retry config.RetryConfig) *CallbackHandler {
+	return &CallbackHandler{
+		leadRepo:            leadRepo,
+		deliveryAttemptRepo: deliveryAttemptRepo,
+		queue:               q,
+		retry:               retry,
+	}
+}
+
+// SetOutcomeNotifier sets the notifier informing webhook senders once a confirmation settles
+// their lead. Without it senders are not notified of confirmed outcomes.
+func (h *CallbackHandler) SetOutcomeNotifier(notifier OutcomeNotifier) {
+	h.notifier = notifier
+}
+
+// DeliveryConfirmationRequest is the body posted by the Customer API to confirm a delivery
+type DeliveryConfirmationRequest struct {
+	LeadID  int64  `json:"lead_id"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// DeliveryConfirmationResponse is returned after a confirmation has been applied
+type DeliveryConfirmationResponse struct {
+	LeadID int64  `json:"lead_id"`
+	Status string `json:"status"`
+}
+
+// HandleDeliveryConfirmation handles POST /callbacks/delivery-confirmation
+func (h *CallbackHandler) HandleDeliveryConfirmation(w http.ResponseWriter, r *http.Request) {
+	correlationID := uuid.New().String()
+	ctx := context.WithValue(r.Context(), logger.CorrelationIDKey, correlationID)
+
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		h.respondError(w, ctx, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req DeliveryConfirmationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.LogError(ctx, "Malformed confirmation payload", err)
+		h.respondError(w, ctx, http.StatusBadRequest, "malformed JSON payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.LeadID <= 0 {
+		h.respondError(w, ctx, http.StatusBadRequest, "missing lead_id")
+		return
+	}
+
+	if req.Status != ConfirmationStatusSuccess && req.Status != ConfirmationStatusFailure {
+		h.respondError(w, ctx, http.StatusBadRequest, "status must be 'success' or 'failure'")
+		return
+	}
+
+	ctx = context.WithValue(ctx, logger.LeadIDKey, req.LeadID)
+	logger.Info(ctx, "Received delivery confirmation", "confirmation_status", req.Status)
+
+	lead, err := h.leadRepo.GetLeadByID(ctx, req.LeadID)
+	if errors.Is(err, repository.ErrLeadNotFound) {
+		h.respondError(w, ctx, http.StatusNotFound, "lead not found")
+		return
+	}
+	if err != nil {
+		logger.LogError(ctx, "Failed to load lead for confirmation", err)
+		h.respondError(w, ctx, http.StatusServiceUnavailable, "database error")
+		return
+	}
+
+	// Late or duplicate confirmations must not overwrite a final state
+	if lead.Status != models.LeadStatusPendingConfirmation {
+		logger.Warn(ctx, "Lead is not awaiting confirmation", "status", lead.Status)
+		h.respondError(w, ctx, http.StatusConflict, "lead is not awaiting confirmation")
+		return
+	}
+
+	// The confirmation is recorded as the next delivery attempt for the audit trail
+	attemptCount, err := h.deliveryAttemptRepo.CountDeliveryAttempts(ctx, lead.ID)
+	if err != nil {
+		logger.LogError(ctx, "Failed to count delivery attempts", err)
+		h.respondError(w, ctx, http.StatusServiceUnavailable, "database error")
+		return
+	}
+	attemptNo := attemptCount + 1
+
+	attempt := models.NewDeliveryAttempt(lead.ID, attemptNo)
+	var newStatus models.LeadStatus
+	switch {
+	case req.Status == ConfirmationStatusSuccess:
+		attempt.MarkSuccess(http.StatusOK, req.Message)
+		newStatus = models.LeadStatusDelivered
+	case attemptNo >= h.retry.MaxAttempts:
+		attempt.MarkFailure(nil, "delivery rejected by Customer API: "+req.Message)
+		logger.Info(ctx, "Max retries exhausted, marking as PERMANENTLY_FAILED")
+		newStatus = models.LeadStatusPermanentlyFailed
+	default:
+		attempt.MarkFailure(nil, "delivery rejected by Customer API: "+req.Message)
+		newStatus = models.LeadStatusFailed
+	}
+
+	// Settle the lead and record the attempt atomically, so a confirmation losing the race
+	// against a concurrent confirmation or timeout leaves no attempt behind
+	err = repository.WithTx(ctx, h.leadRepo, func(tx *sql.Tx) error {
+		if err := h.leadRepo.UpdateLeadStatusFromTx(ctx, tx, lead.ID, models.LeadStatusPendingConfirmation, newStatus); err != nil {
+			return err
+		}
+		return h.deliveryAttemptRepo.CreateDeliveryAttemptTx(ctx, tx, attempt)
+	})
+	if err != nil {
+		logger.LogError(ctx, "Failed to apply delivery confirmation", err)
+		// The lead was settled by a concurrent confirmation or timeout
+		if errors.Is(err, models.ErrInvalidStatusTransition) {
+			h.respondError(w, ctx, http.StatusConflict, "lead is not awaiting confirmation")
+			return
+		}
+		h.respondError(w, ctx, http.StatusServiceUnavailable, "database error")
+		return
+	}
+	logger.LogStatusTransition(ctx, lead.ID, string(lead.Status), string(newStatus))
+	lead.Status = newStatus
+
+	if newStatus == models.LeadStatusFailed {
+		// The confirmation is applied either way; a lead left FAILED without a job is
+		// reported by the stuck lead detector
+		if err := h.requeueFailedLead(ctx, lead.ID, attemptNo); err != nil {
+			logger.LogError(ctx, "Failed to re-enqueue failed lead", err)
+		}
+	} else if h.notifier != nil {
+		if err := h.notifier.Schedule(ctx, lead); err != nil {
+			logger.LogError(ctx, "Failed to schedule sender notification", err)
+		}
+	}
+
+	h.respondJSON(w, ctx, http.StatusOK, DeliveryConfirmationResponse{
+		LeadID: lead.ID,
+		Status: string(newStatus),
+	})
+}
+
+// requeueFailedLead enqueues the next delivery attempt of a lead that has failed attemptNo
+// delivery attempts, with the same backoff and priority the worker applies to failed deliveries
+func (h *CallbackHandler) requeueFailedLead(ctx context.Context, leadID int64, attemptNo int) error {
+	var delay time.Duration
+	if delays := h.retry.Delays(); attemptNo <= len(delays) {
+		delay = delays[attemptNo-1]
+	}
+	priority := attemptNo * h.retry.PriorityPenalty
+	logger.Info(ctx, "Re-enqueueing failed lead", "attempt_no", attemptNo, "delay", delay, "priority", priority)
+
+	return h.queue.EnqueueWithPriority(ctx, queue.JobTypeProcessLead, queue.NewJobPayload(leadID), delay, priority)
+}
+
+// respondJSON sends a JSON response
+func (h *CallbackHandler) respondJSON(w http.ResponseWriter, ctx context.Context, statusCode int, data interface{}) {
+	if correlationID, ok := ctx.Value(logger.CorrelationIDKey).(string); ok {
+		w.Header().Set("X-Correlation-ID", correlationID)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logger.LogError(ctx, "Failed to encode response", err)
+	}
+}
+
+// respondError sends an error response
+func (h *CallbackHandler) respondError(w http.ResponseWriter, ctx context.Context, statusCode int, message string) {
+	correlationID, _ := ctx.Value(logger.CorrelationIDKey).(string)
+	h.respondJSON(w, ctx, statusCode, ErrorResponse{
+		Error:         message,
+		CorrelationID: correlationID,
+	})
+}
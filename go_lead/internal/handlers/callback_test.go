@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/queue"
+)
+
+func init() {
+	sql.Register("callbacktx", callbackTxDriver{})
+}
+
+// callbackTxDriver provides transactions that run no statements, for mocks taking a *sql.Tx
+type callbackTxDriver struct{}
+
+func (callbackTxDriver) Open(name string) (driver.Conn, error) { return callbackTxConn{}, nil }
+
+type callbackTxConn struct{}
+
+func (callbackTxConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (callbackTxConn) Close() error { return nil }
+
+func (callbackTxConn) Begin() (driver.Tx, error) { return callbackTxConn{}, nil }
+
+func (callbackTxConn) Commit() error { return nil }
+
+func (callbackTxConn) Rollback() error { return nil }
+
+// mockLeadRepoForCallback records status updates on top of the stats mock
+type mockLeadRepoForCallback struct {
+	mockLeadRepoForStats
+	db            *sql.DB
+	updatedStatus models.LeadStatus
+	updateErr     error
+}
+
+func (m *mockLeadRepoForCallback) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return m.db.BeginTx(ctx, nil)
+}
+
+func (m *mockLeadRepoForCallback) UpdateLeadStatusFromTx(ctx context.Context, tx *sql.Tx, id int64, from, to models.LeadStatus) error {
+	if m.updateErr != nil {
+		return m.updateErr
+	}
+	m.updatedStatus = to
+	return nil
+}
+
+// mockDeliveryAttemptRepoForCallback records created attempts on top of the stats mock
+type mockDeliveryAttemptRepoForCallback struct {
+	mockDeliveryAttemptRepoForStats
+	created []*models.DeliveryAttempt
+}
+
+func (m *mockDeliveryAttemptRepoForCallback) CreateDeliveryAttemptTx(ctx context.Context, tx *sql.Tx, attempt *models.DeliveryAttempt) error {
+	m.created = append(m.created, attempt)
+	return nil
+}
+
+// callbackRecordingQueue records the jobs enqueued with a delay and priority
+type callbackRecordingQueue struct {
+	MockQueue
+	jobTypes   []string
+	payloads   []map[string]interface{}
+	delays     []time.Duration
+	priorities []int
+}
+
+func (q *callbackRecordingQueue) EnqueueWithPriority(ctx context.Context, jobType string, payload map[string]interface{}, delay time.Duration, priority int) error {
+	q.jobTypes = append(q.jobTypes, jobType)
+	q.payloads = append(q.payloads, payload)
+	q.delays = append(q.delays, delay)
+	q.priorities = append(q.priorities, priority)
+	return nil
+}
+
+// recordingOutcomeNotifier records the leads whose outcome was scheduled for notification
+type recordingOutcomeNotifier struct {
+	scheduled []models.LeadStatus
+}
+
+func (n *recordingOutcomeNotifier) Schedule(ctx context.Context, lead *models.InboundLead) error {
+	n.scheduled = append(n.scheduled, lead.Status)
+	return nil
+}
+
+// callbackTestRetry allows 3 delivery attempts with backoffs of 1m, 2m and 4m
+var callbackTestRetry = config.RetryConfig{MaxAttempts: 3, BackoffBase: time.Minute, PriorityPenalty: 10}
+
+type callbackTestFixture struct {
+	leadRepo    *mockLeadRepoForCallback
+	attemptRepo *mockDeliveryAttemptRepoForCallback
+	queue       *callbackRecordingQueue
+	notifier    *recordingOutcomeNotifier
+}
+
+// newCallbackTestHandler creates a handler for lead 7, which has made attemptCount delivery attempts
+func newCallbackTestHandler(t *testing.T, status models.LeadStatus, attemptCount int) (*CallbackHandler, *callbackTestFixture) {
+	db, err := sql.Open("callbacktx", "")
+	if err != nil {
+		t.Fatalf("Failed to open transaction database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	attempts := make([]*models.DeliveryAttempt, attemptCount)
+	for i := range attempts {
+		attempts[i] = models.NewDeliveryAttempt(7, i+1)
+	}
+
+	f := &callbackTestFixture{
+		leadRepo: &mockLeadRepoForCallback{
+			mockLeadRepoForStats: mockLeadRepoForStats{
+				leads: []*models.InboundLead{{ID: 7, Status: status}},
+			},
+			db: db,
+		},
+		attemptRepo: &mockDeliveryAttemptRepoForCallback{
+			mockDeliveryAttemptRepoForStats: mockDeliveryAttemptRepoForStats{
+				attempts: map[int64][]*models.DeliveryAttempt{7: attempts},
+			},
+		},
+		queue:    &callbackRecordingQueue{},
+		notifier: &recordingOutcomeNotifier{},
+	}
+	handler := NewCallbackHandler(f.leadRepo, f.attemptRepo, f.queue, callbackTestRetry)
+	handler.SetOutcomeNotifier(f.notifier)
+	return handler, f
+}
+
+func postConfirmation(handler *CallbackHandler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/callbacks/delivery-confirmation", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	handler.HandleDeliveryConfirmation(rr, req)
+	return rr
+}
+
+func TestHandleDeliveryConfirmation_Success(t *testing.T) {
+	handler, f := newCallbackTestHandler(t, models.LeadStatusPendingConfirmation, 1)
+
+	rr := postConfirmation(handler, `{"lead_id": 7, "status": "success"}`)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	if f.leadRepo.updatedStatus != models.LeadStatusDelivered {
+		t.Errorf("Expected lead to be DELIVERED, got %q", f.leadRepo.updatedStatus)
+	}
+
+	if len(f.attemptRepo.created) != 1 {
+		t.Fatalf("Expected 1 confirmation attempt, got %d", len(f.attemptRepo.created))
+	}
+	attempt := f.attemptRepo.created[0]
+	if !attempt.Success || attempt.AttemptNo != 2 {
+		t.Errorf("Expected successful attempt #2, got success=%v attempt_no=%d", attempt.Success, attempt.AttemptNo)
+	}
+
+	var response DeliveryConfirmationResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Status != string(models.LeadStatusDelivered) {
+		t.Errorf("Expected response status DELIVERED, got %s", response.Status)
+	}
+
+	// The sender learns of the delivery; the lead is not retried
+	if len(f.notifier.scheduled) != 1 || f.notifier.scheduled[0] != models.LeadStatusDelivered {
+		t.Errorf("Expected a DELIVERED notification to be scheduled, got %v", f.notifier.scheduled)
+	}
+	if len(f.queue.jobTypes) != 0 {
+		t.Errorf("Expected no retry job, got %v", f.queue.jobTypes)
+	}
+}
+
+func TestHandleDeliveryConfirmation_Failure(t *testing.T) {
+	handler, f := newCallbackTestHandler(t, models.LeadStatusPendingConfirmation, 1)
+
+	rr := postConfirmation(handler, `{"lead_id": 7, "status": "failure", "message": "duplicate"}`)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	if f.leadRepo.updatedStatus != models.LeadStatusFailed {
+		t.Errorf("Expected lead to be FAILED, got %q", f.leadRepo.updatedStatus)
+	}
+
+	if len(f.attemptRepo.created) != 1 || f.attemptRepo.created[0].Success {
+		t.Error("Expected 1 failed confirmation attempt")
+	}
+
+	// The lead is retried after the backoff of its second attempt
+	if len(f.queue.jobTypes) != 1 || f.queue.jobTypes[0] != queue.JobTypeProcessLead {
+		t.Fatalf("Expected 1 process_lead retry job, got %v", f.queue.jobTypes)
+	}
+	if leadID, _ := queue.GetLeadID(f.queue.payloads[0]); leadID != 7 {
+		t.Errorf("Expected the retry job for lead 7, got %d", leadID)
+	}
+	if f.queue.delays[0] != 2*time.Minute || f.queue.priorities[0] != 20 {
+		t.Errorf("Expected the retry after 2m with priority 20, got %v and %d", f.queue.delays[0], f.queue.priorities[0])
+	}
+	if len(f.notifier.scheduled) != 0 {
+		t.Errorf("Expected no notification for a lead to be retried, got %v", f.notifier.scheduled)
+	}
+}
+
+func TestHandleDeliveryConfirmation_FailureExhaustsAttempts(t *testing.T) {
+	handler, f := newCallbackTestHandler(t, models.LeadStatusPendingConfirmation, 2)
+
+	rr := postConfirmation(handler, `{"lead_id": 7, "status": "failure", "message": "duplicate"}`)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	if f.leadRepo.updatedStatus != models.LeadStatusPermanentlyFailed {
+		t.Errorf("Expected lead to be PERMANENTLY_FAILED, got %q", f.leadRepo.updatedStatus)
+	}
+	if len(f.attemptRepo.created) != 1 || f.attemptRepo.created[0].AttemptNo != 3 {
+		t.Error("Expected failed confirmation attempt #3")
+	}
+	if len(f.queue.jobTypes) != 0 {
+		t.Errorf("Expected no retry job, got %v", f.queue.jobTypes)
+	}
+	if len(f.notifier.scheduled) != 1 || f.notifier.scheduled[0] != models.LeadStatusPermanentlyFailed {
+		t.Errorf("Expected a PERMANENTLY_FAILED notification to be scheduled, got %v", f.notifier.scheduled)
+	}
+}
+
+func TestHandleDeliveryConfirmation_Rejections(t *testing.T) {
+	tests := []struct {
+		name         string
+		leadStatus   models.LeadStatus
+		body         string
+		expectedCode int
+	}{
+		{"malformed JSON", models.LeadStatusPendingConfirmation, `{not json`, http.StatusBadRequest},
+		{"missing lead_id", models.LeadStatusPendingConfirmation, `{"status": "success"}`, http.StatusBadRequest},
+		{"unknown status", models.LeadStatusPendingConfirmation, `{"lead_id": 7, "status": "maybe"}`, http.StatusBadRequest},
+		{"unknown lead", models.LeadStatusPendingConfirmation, `{"lead_id": 99, "status": "success"}`, http.StatusNotFound},
+		{"not pending", models.LeadStatusDelivered, `{"lead_id": 7, "status": "failure"}`, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, f := newCallbackTestHandler(t, tt.leadStatus, 1)
+
+			rr := postConfirmation(handler, tt.body)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if f.leadRepo.updatedStatus != "" || len(f.attemptRepo.created) != 0 {
+				t.Error("Expected no changes to be persisted")
+			}
+		})
+	}
+}
+
+func TestHandleDeliveryConfirmation_SettledConcurrently(t *testing.T) {
+	handler, f := newCallbackTestHandler(t, models.LeadStatusPendingConfirmation, 1)
+	// The confirmation timeout marked the lead FAILED after it was loaded
+	f.leadRepo.updateErr = fmt.Errorf("lead 7 has status FAILED instead of PENDING_CONFIRMATION: %w", models.ErrInvalidStatusTransition)
+
+	rr := postConfirmation(handler, `{"lead_id": 7, "status": "success"}`)
+
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", rr.Code)
+	}
+	// The losing confirmation leaves neither an attempt, a job nor a notification behind
+	if len(f.attemptRepo.created) != 0 || len(f.queue.jobTypes) != 0 || len(f.notifier.scheduled) != 0 {
+		t.Errorf("Expected no side effects, got attempts %v, jobs %v and notifications %v",
+			f.attemptRepo.created, f.queue.jobTypes, f.notifier.scheduled)
+	}
+}
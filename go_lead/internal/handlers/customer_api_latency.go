@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+
+	"github.com/checkfox/go_lead/internal/metrics"
+)
+
+// LatencyPercentiles holds response time percentiles in seconds, estimated from histogram
+// buckets; they are omitted while there are no observations
+type LatencyPercentiles struct {
+	Count uint64   `json:"count"`
+	P50   *float64 `json:"p50_seconds,omitempty"`
+	P95   *float64 `json:"p95_seconds,omitempty"`
+	P99   *float64 `json:"p99_seconds,omitempty"`
+}
+
+// CustomerAPILatencyResponse represents the Customer API response times of all requests
+// and per status code class
+type CustomerAPILatencyResponse struct {
+	LatencyPercentiles
+	ByStatusCodeClass map[string]LatencyPercentiles `json:"by_status_code_class"`
+}
+
+// CustomerAPILatencyHandler reports the Customer API response times recorded by the worker
+type CustomerAPILatencyHandler struct {
+	latency *metrics.Histogram
+}
+
+// NewCustomerAPILatencyHandler creates a new CustomerAPILatencyHandler
+func NewCustomerAPILatencyHandler(latency *metrics.Histogram) *CustomerAPILatencyHandler {
+	return &CustomerAPILatencyHandler{
+		latency: latency,
+	}
+}
+
+// HandleLatency handles GET /stats/customer-api/latency
+func (h *CustomerAPILatencyHandler) HandleLatency(w http.ResponseWriter, r *http.Request) {
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := CustomerAPILatencyResponse{
+		LatencyPercentiles: latencyPercentiles(h.latency.Total()),
+		ByStatusCodeClass:  make(map[string]LatencyPercentiles),
+	}
+	for class, snapshot := range h.latency.Snapshot() {
+		response.ByStatusCodeClass[class] = latencyPercentiles(snapshot)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// latencyPercentiles estimates P50, P95 and P99 of a histogram series
+func latencyPercentiles(snapshot metrics.Snapshot) LatencyPercentiles {
+	percentiles := LatencyPercentiles{Count: snapshot.Count}
+	if snapshot.Count == 0 {
+		return percentiles
+	}
+	quantile := func(q float64) *float64 {
+		value := snapshot.Quantile(q)
+		if math.IsNaN(value) {
+			return nil
+		}
+		return &value
+	}
+	percentiles.P50 = quantile(0.5)
+	percentiles.P95 = quantile(0.95)
+	percentiles.P99 = quantile(0.99)
+	return percentiles
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/metrics"
+)
+
+func TestHandleLatency(t *testing.T) {
+	latency := metrics.NewHistogram("customer_api_request_duration_seconds", "Customer API response times by status code class.",
+		"status_code_class", []float64{0.1, 0.25, 0.5, 1.0, 2.5, 5.0, 10.0})
+	for i := 0; i < 90; i++ {
+		latency.Observe("2xx", 0.2)
+	}
+	for i := 0; i < 10; i++ {
+		latency.Observe("5xx", 3)
+	}
+	handler := NewCustomerAPILatencyHandler(latency)
+
+	rr := httptest.NewRecorder()
+	handler.HandleLatency(rr, httptest.NewRequest(http.MethodGet, "/stats/customer-api/latency", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	var response CustomerAPILatencyResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if response.Count != 100 {
+		t.Errorf("Expected 100 requests, got %d", response.Count)
+	}
+	if response.P50 == nil || *response.P50 < 0.1 || *response.P50 > 0.25 {
+		t.Errorf("Expected P50 between 0.1s and 0.25s, got %v", response.P50)
+	}
+	for name, p := range map[string]*float64{"P95": response.P95, "P99": response.P99} {
+		if p == nil || *p < 2.5 || *p > 5 {
+			t.Errorf("Expected %s between 2.5s and 5s, got %v", name, p)
+		}
+	}
+	if ok := response.ByStatusCodeClass["2xx"]; ok.Count != 90 || ok.P99 == nil || *ok.P99 > 0.25 {
+		t.Errorf("Expected 90 2xx responses within 0.25s, got %+v", ok)
+	}
+}
+
+func TestHandleLatency_NoRequests(t *testing.T) {
+	handler := NewCustomerAPILatencyHandler(metrics.NewHistogram("latency_seconds", "Test latency.", "status_code_class", []float64{1}))
+
+	rr := httptest.NewRecorder()
+	handler.HandleLatency(rr, httptest.NewRequest(http.MethodGet, "/stats/customer-api/latency", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if body := rr.Body.String(); body != "{\"count\":0,\"by_status_code_class\":{}}\n" {
+		t.Errorf("Expected a zero count without percentiles, got %s", body)
+	}
+}
+
+func TestHandleLatency_MethodNotAllowed(t *testing.T) {
+	handler := NewCustomerAPILatencyHandler(metrics.NewHistogram("latency_seconds", "Test latency.", "status_code_class", []float64{1}))
+
+	rr := httptest.NewRecorder()
+	handler.HandleLatency(rr, httptest.NewRequest(http.MethodPost, "/stats/customer-api/latency", nil))
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,387 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/repository"
+)
+
+const (
+	// exportBatchSize is the number of leads read and written per page
+	exportBatchSize = 1000
+
+	// exportWriteTimeout is the time allowed to write each page, extending the server's
+	// write timeout so that large exports are not cut off
+	exportWriteTimeout = 30 * time.Second
+
+	// ndjsonFlushInterval is the number of NDJSON lines written between flushes
+	ndjsonFlushInterval = 100
+
+	// ExportProgressHeader reports rows_written/estimated_total of a lead export
+	ExportProgressHeader = "X-Export-Progress"
+)
+
+// Formats of the lead export
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson" // one JSON object per line, application/x-ndjson
+)
+
+// exportColumns are the CSV columns, named after the inbound_lead columns. source_headers
+// is left out since it may hold credentials of the sender.
+var exportColumns = []string{
+	"id", "received_at", "raw_payload", "status",
+	"rejection_reason", "normalized_payload", "customer_payload",
+	"payload_hash", "created_at", "updated_at", "source_id", "tenant_id",
+	"attachments_metadata",
+}
+
+// ExportHandler streams lead data for offline analysis
+type ExportHandler struct {
+	repo    repository.LeadExportRepository
+	maxRows int
+}
+
+// NewExportHandler creates a new ExportHandler writing at most maxRows leads per export
+// (0 = unlimited)
+func NewExportHandler(repo repository.LeadExportRepository, maxRows int) *ExportHandler {
+	if maxRows <= 0 {
+		maxRows = math.MaxInt
+	}
+	return &ExportHandler{
+		repo:    repo,
+		maxRows: maxRows,
+	}
+}
+
+// HandleExportLeads handles GET /export/leads?status=...&from=...&to=...&format=csv|ndjson
+// The leads are read and written in pages of exportBatchSize, so an export never holds
+// more than one page in memory. from and to accept RFC 3339 timestamps or dates; a date
+// as to includes the whole day. Without format, an Accept header of application/x-ndjson
+// selects NDJSON; CSV is the default.
+func (h *ExportHandler) HandleExportLeads(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	format := negotiateExportFormat(r)
+	if format != ExportFormatCSV && format != ExportFormatNDJSON {
+		http.Error(w, "format must be csv or ndjson", http.StatusBadRequest)
+		return
+	}
+
+	var filter repository.LeadExportFilter
+	if status := query.Get("status"); status != "" {
+		filter.Status = models.LeadStatus(status)
+		if !filter.Status.IsValid() {
+			http.Error(w, "invalid status: "+status, http.StatusBadRequest)
+			return
+		}
+	}
+	var err error
+	if filter.From, err = parseExportTime(query.Get("from"), false); err != nil {
+		http.Error(w, "invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.To, err = parseExportTime(query.Get("to"), true); err != nil {
+		http.Error(w, "invalid to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	total, err := h.repo.CountLeads(ctx, filter)
+	if err != nil {
+		logger.LogError(ctx, "Failed to count leads for export", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	estimated := min(total, h.maxRows)
+	if total > h.maxRows {
+		logger.Warn(ctx, "Lead export exceeds the row limit and is truncated", "matching", total, "max_rows", h.maxRows)
+	}
+
+	pw := newExportProgressWriter(w, estimated)
+	if format == ExportFormatNDJSON {
+		h.writeNDJSON(ctx, pw, filter)
+		return
+	}
+	pw.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	pw.Header().Set("Content-Disposition", `attachment; filename="leads.csv"`)
+	pw.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(pw)
+	cw.Write(exportColumns)
+
+	// Once the header is sent, failures can only cut the export short
+	var afterID int64
+	for pw.rows < h.maxRows {
+		http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+
+		leads, err := h.repo.ListLeadsAfter(ctx, filter, afterID, min(exportBatchSize, h.maxRows-pw.rows))
+		if err != nil {
+			logger.LogError(ctx, "Failed to read leads for export", err, "rows_written", pw.rows)
+			return
+		}
+		for _, lead := range leads {
+			cw.Write(exportRecord(lead))
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			logger.LogError(ctx, "Failed to write lead export", err, "rows_written", pw.rows)
+			return
+		}
+		pw.addRows(len(leads))
+
+		if len(leads) < exportBatchSize {
+			break
+		}
+		afterID = leads[len(leads)-1].ID
+	}
+
+	logger.Info(ctx, "Exported leads", "rows_written", pw.rows)
+}
+
+// negotiateExportFormat returns the format query parameter, or else ndjson if the Accept
+// header names application/x-ndjson, or else csv
+func negotiateExportFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mediaType == "application/x-ndjson" {
+			return ExportFormatNDJSON
+		}
+	}
+	return ExportFormatCSV
+}
+
+// writeNDJSON streams the leads matching filter as newline-delimited JSON. The leads are
+// read page by page into a channel while they are written, and the response is flushed
+// every ndjsonFlushInterval leads.
+func (h *ExportHandler) writeNDJSON(ctx context.Context, pw *exportProgressWriter, filter repository.LeadExportFilter) {
+	pw.Header().Set("Content-Type", "application/x-ndjson")
+	pw.Header().Set("Content-Disposition", `attachment; filename="leads.ndjson"`)
+	pw.WriteHeader(http.StatusOK)
+
+	// Stop reading when the client is gone
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	leads := make(chan *models.InboundLead, exportBatchSize)
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- h.readExportLeads(ctx, filter, leads)
+	}()
+
+	// Once the header is sent, failures can only cut the export short
+	if err := encodeNDJSON(pw, leads); err != nil {
+		cancel()
+		<-readErr
+		logger.LogError(ctx, "Failed to write lead export", err, "rows_written", pw.rows)
+		return
+	}
+	if err := <-readErr; err != nil {
+		logger.LogError(ctx, "Failed to read leads for export", err, "rows_written", pw.rows)
+		return
+	}
+
+	logger.Info(ctx, "Exported leads", "rows_written", pw.rows)
+}
+
+// readExportLeads sends at most maxRows leads matching filter on leads, reading them in
+// pages of exportBatchSize, and closes leads when done. It stops early when ctx is done.
+func (h *ExportHandler) readExportLeads(ctx context.Context, filter repository.LeadExportFilter, leads chan<- *models.InboundLead) error {
+	defer close(leads)
+
+	var afterID int64
+	for sent := 0; sent < h.maxRows; {
+		page, err := h.repo.ListLeadsAfter(ctx, filter, afterID, min(exportBatchSize, h.maxRows-sent))
+		if err != nil {
+			return err
+		}
+		for _, lead := range page {
+			select {
+			case leads <- lead:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		sent += len(page)
+
+		if len(page) < exportBatchSize {
+			return nil
+		}
+		afterID = page[len(page)-1].ID
+	}
+	return nil
+}
+
+// encodeNDJSON writes each lead received on leads as a JSON object followed by a newline,
+// flushing every ndjsonFlushInterval leads and after the last one
+func encodeNDJSON(pw *exportProgressWriter, leads <-chan *models.InboundLead) error {
+	encoder := json.NewEncoder(pw)
+	pending := 0
+	for lead := range leads {
+		if pending == 0 {
+			http.NewResponseController(pw.ResponseWriter).SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+		}
+		// Encode terminates each object with a newline
+		if err := encoder.Encode(newExportObject(lead)); err != nil {
+			return err
+		}
+		pending++
+		if pending == ndjsonFlushInterval {
+			pw.addRows(pending)
+			pending = 0
+		}
+	}
+	if pending > 0 {
+		pw.addRows(pending)
+	}
+	return nil
+}
+
+// exportObject is the NDJSON form of a lead with the fields of exportColumns; NULL
+// columns are null
+type exportObject struct {
+	ID                  int64             `json:"id"`
+	ReceivedAt          time.Time         `json:"received_at"`
+	RawPayload          models.JSONB      `json:"raw_payload"`
+	Status              models.LeadStatus `json:"status"`
+	RejectionReason     *string           `json:"rejection_reason"`
+	NormalizedPayload   models.JSONB      `json:"normalized_payload"`
+	CustomerPayload     models.JSONB      `json:"customer_payload"`
+	PayloadHash         *string           `json:"payload_hash"`
+	CreatedAt           time.Time         `json:"created_at"`
+	UpdatedAt           time.Time         `json:"updated_at"`
+	SourceID            *string           `json:"source_id"`
+	TenantID            *string           `json:"tenant_id"`
+	AttachmentsMetadata models.JSONB      `json:"attachments_metadata"`
+}
+
+// newExportObject returns the NDJSON form of a lead
+func newExportObject(lead *models.InboundLead) exportObject {
+	return exportObject{
+		ID:                  lead.ID,
+		ReceivedAt:          lead.ReceivedAt,
+		RawPayload:          lead.RawPayload,
+		Status:              lead.Status,
+		RejectionReason:     lead.RejectionReason,
+		NormalizedPayload:   lead.NormalizedPayload,
+		CustomerPayload:     lead.CustomerPayload,
+		PayloadHash:         lead.PayloadHash,
+		CreatedAt:           lead.CreatedAt,
+		UpdatedAt:           lead.UpdatedAt,
+		SourceID:            lead.SourceID,
+		TenantID:            lead.TenantID,
+		AttachmentsMetadata: lead.AttachmentsMetadata,
+	}
+}
+
+// parseExportTime parses an RFC 3339 timestamp or a date. With endOfDay, a date is
+// moved to the start of the next day so that it includes the whole day.
+func parseExportTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 timestamp or a date (YYYY-MM-DD), got %q", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// exportRecord returns the CSV fields of a lead in exportColumns order
+func exportRecord(lead *models.InboundLead) []string {
+	return []string{
+		strconv.FormatInt(lead.ID, 10),
+		lead.ReceivedAt.Format(time.RFC3339Nano),
+		exportJSON(lead.RawPayload),
+		string(lead.Status),
+		exportString(lead.RejectionReason),
+		exportJSON(lead.NormalizedPayload),
+		exportJSON(lead.CustomerPayload),
+		exportString(lead.PayloadHash),
+		lead.CreatedAt.Format(time.RFC3339Nano),
+		lead.UpdatedAt.Format(time.RFC3339Nano),
+		exportString(lead.SourceID),
+		exportString(lead.TenantID),
+		exportJSON(lead.AttachmentsMetadata),
+	}
+}
+
+// exportJSON returns a JSONB column as JSON, or an empty field for NULL
+func exportJSON(value models.JSONB) string {
+	if value == nil {
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// exportString returns a nullable text column, or an empty field for NULL
+func exportString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// exportProgressWriter reports the progress of an export in the X-Export-Progress header
+// and flushes each page to the client. Headers cannot change once the response has started,
+// so the header carries 0/estimated and the progress after each page is set as a trailer,
+// which the client receives with the final row count.
+type exportProgressWriter struct {
+	http.ResponseWriter
+	estimated int
+	rows      int
+}
+
+// newExportProgressWriter wraps w for an export of about estimated rows
+func newExportProgressWriter(w http.ResponseWriter, estimated int) *exportProgressWriter {
+	pw := &exportProgressWriter{ResponseWriter: w, estimated: estimated}
+	w.Header().Set(ExportProgressHeader, pw.progress())
+	w.Header().Set("Trailer", ExportProgressHeader)
+	return pw
+}
+
+// addRows records n more written rows, updates the progress and flushes the page
+func (w *exportProgressWriter) addRows(n int) {
+	w.rows += n
+	w.Header().Set(ExportProgressHeader, w.progress())
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// progress formats the progress as rows_written/estimated_total
+func (w *exportProgressWriter) progress() string {
+	return fmt.Sprintf("%d/%d", w.rows, w.estimated)
+}
+
+// Unwrap returns the wrapped ResponseWriter for http.ResponseController
+func (w *exportProgressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
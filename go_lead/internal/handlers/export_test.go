@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/repository"
+)
+
+// exportPage records a ListLeadsAfter call
+type exportPage struct {
+	afterID int64
+	limit   int
+}
+
+// mockExportRepo serves leads with IDs 1..n, every third of them REJECTED
+type mockExportRepo struct {
+	leads    []*models.InboundLead
+	filter   repository.LeadExportFilter
+	pages    []exportPage
+	countErr error
+}
+
+func newMockExportRepo(n int) *mockExportRepo {
+	repo := &mockExportRepo{}
+	receivedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for id := int64(1); id <= int64(n); id++ {
+		status := models.LeadStatusDelivered
+		if id%3 == 0 {
+			status = models.LeadStatusRejected
+		}
+		repo.leads = append(repo.leads, &models.InboundLead{
+			ID:         id,
+			ReceivedAt: receivedAt,
+			RawPayload: models.JSONB{"email": fmt.Sprintf("lead%d@example.com", id)},
+			SourceHeaders: models.JSONB{
+				"Authorization": "Bearer sender-token",
+				"X-Source-Id":   "partner-a",
+			},
+			Status:    status,
+			CreatedAt: receivedAt,
+			UpdatedAt: receivedAt,
+		})
+	}
+	return repo
+}
+
+func (m *mockExportRepo) matches(lead *models.InboundLead, filter repository.LeadExportFilter) bool {
+	return filter.Status == "" || lead.Status == filter.Status
+}
+
+func (m *mockExportRepo) CountLeads(ctx context.Context, filter repository.LeadExportFilter) (int, error) {
+	if m.countErr != nil {
+		return 0, m.countErr
+	}
+	m.filter = filter
+	count := 0
+	for _, lead := range m.leads {
+		if m.matches(lead, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockExportRepo) ListLeadsAfter(ctx context.Context, filter repository.LeadExportFilter, afterID int64, limit int) ([]*models.InboundLead, error) {
+	m.pages = append(m.pages, exportPage{afterID: afterID, limit: limit})
+	var page []*models.InboundLead
+	for _, lead := range m.leads {
+		if lead.ID > afterID && m.matches(lead, filter) && len(page) < limit {
+			page = append(page, lead)
+		}
+	}
+	return page, nil
+}
+
+// readExport parses the CSV body of an export response
+func readExport(t *testing.T, rec *httptest.ResponseRecorder) [][]string {
+	t.Helper()
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	return records
+}
+
+func TestHandleExportLeads_CSV(t *testing.T) {
+	repo := newMockExportRepo(2500)
+	handler := NewExportHandler(repo, 100000)
+
+	req := httptest.NewRequest(http.MethodGet, "/export/leads?format=csv", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleExportLeads(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Expected a CSV content type, got %q", ct)
+	}
+
+	records := readExport(t, rec)
+	if !reflect.DeepEqual(records[0], exportColumns) {
+		t.Errorf("Expected the inbound_lead columns as header, got %v", records[0])
+	}
+	if len(records) != 2501 {
+		t.Fatalf("Expected 2500 rows after the header, got %d", len(records)-1)
+	}
+	if records[1][0] != "1" || records[2500][0] != "2500" {
+		t.Errorf("Expected the rows ordered by ID, got %s..%s", records[1][0], records[2500][0])
+	}
+	if records[1][2] != `{"email":"lead1@example.com"}` {
+		t.Errorf("Expected raw_payload as JSON, got %q", records[1][2])
+	}
+	if records[1][4] != "" {
+		t.Errorf("Expected an empty field for a NULL rejection_reason, got %q", records[1][4])
+	}
+	if strings.Contains(rec.Body.String(), "sender-token") {
+		t.Error("Expected source headers to be left out of the export")
+	}
+}
+
+// flushCountingRecorder counts the flushes of an export response
+type flushCountingRecorder struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (r *flushCountingRecorder) Flush() {
+	r.flushes++
+	r.ResponseRecorder.Flush()
+}
+
+// readNDJSONExport parses the NDJSON body of an export response line by line
+func readNDJSONExport(t *testing.T, rec *httptest.ResponseRecorder) []map[string]interface{} {
+	t.Helper()
+	var objects []map[string]interface{}
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var object map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &object); err != nil {
+			t.Fatalf("Failed to parse NDJSON line %d: %v", len(objects)+1, err)
+		}
+		objects = append(objects, object)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Failed to read NDJSON: %v", err)
+	}
+	return objects
+}
+
+func TestHandleExportLeads_NDJSON(t *testing.T) {
+	repo := newMockExportRepo(2500)
+	handler := NewExportHandler(repo, 100000)
+
+	req := httptest.NewRequest(http.MethodGet, "/export/leads", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+	rec := &flushCountingRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler.HandleExportLeads(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Expected the NDJSON content type, got %q", ct)
+	}
+
+	objects := readNDJSONExport(t, rec.ResponseRecorder)
+	if len(objects) != 2500 {
+		t.Fatalf("Expected 2500 leads, got %d", len(objects))
+	}
+	for i, object := range objects {
+		if id, _ := object["id"].(float64); id != float64(i+1) {
+			t.Fatalf("Expected the leads ordered by ID, got %v at line %d", object["id"], i+1)
+		}
+	}
+
+	// Every object has the CSV columns as fields
+	first := objects[0]
+	fields := make([]string, 0, len(first))
+	for field := range first {
+		fields = append(fields, field)
+	}
+	columns := append([]string(nil), exportColumns...)
+	sort.Strings(fields)
+	sort.Strings(columns)
+	if !reflect.DeepEqual(fields, columns) {
+		t.Errorf("Expected the fields %v, got %v", columns, fields)
+	}
+	if payload, ok := first["raw_payload"].(map[string]interface{}); !ok || payload["email"] != "lead1@example.com" {
+		t.Errorf("Expected raw_payload as a JSON object, got %v", first["raw_payload"])
+	}
+	if first["status"] != string(models.LeadStatusDelivered) || first["rejection_reason"] != nil {
+		t.Errorf("Expected a delivered lead without rejection reason, got %v and %v", first["status"], first["rejection_reason"])
+	}
+	if _, ok := first["source_headers"]; ok {
+		t.Error("Expected source headers to be left out of the export")
+	}
+	if first["received_at"] != "2026-03-01T12:00:00Z" {
+		t.Errorf("Expected received_at as RFC 3339, got %v", first["received_at"])
+	}
+
+	// Flushed after every 100 leads
+	if rec.flushes != 25 {
+		t.Errorf("Expected 25 flushes, got %d", rec.flushes)
+	}
+	expected := []exportPage{{0, 1000}, {1000, 1000}, {2000, 1000}}
+	if !reflect.DeepEqual(repo.pages, expected) {
+		t.Errorf("Expected pages %v, got %v", expected, repo.pages)
+	}
+	if progress := rec.Result().Trailer.Get(ExportProgressHeader); progress != "2500/2500" {
+		t.Errorf("Expected final progress 2500/2500, got %q", progress)
+	}
+}
+
+func TestHandleExportLeads_NDJSONFormatParameter(t *testing.T) {
+	repo := newMockExportRepo(250)
+	handler := NewExportHandler(repo, 1000)
+
+	// The format parameter takes precedence over the Accept header
+	req := httptest.NewRequest(http.MethodGet, "/export/leads?format=ndjson&status=REJECTED", nil)
+	req.Header.Set("Accept", "text/csv")
+	rec := &flushCountingRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler.HandleExportLeads(rec, req)
+
+	objects := readNDJSONExport(t, rec.ResponseRecorder)
+	if len(objects) != 83 {
+		t.Fatalf("Expected 83 rejected leads, got %d", len(objects))
+	}
+	for _, object := range objects {
+		if object["status"] != string(models.LeadStatusRejected) {
+			t.Errorf("Expected only rejected leads, got %v", object["status"])
+		}
+	}
+	// A partial batch is flushed after the last lead
+	if rec.flushes != 1 {
+		t.Errorf("Expected 1 flush, got %d", rec.flushes)
+	}
+}
+
+func TestHandleExportLeads_NDJSONMaxRows(t *testing.T) {
+	repo := newMockExportRepo(2500)
+	handler := NewExportHandler(repo, 1500)
+
+	req := httptest.NewRequest(http.MethodGet, "/export/leads?format=ndjson", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleExportLeads(rec, req)
+
+	if objects := readNDJSONExport(t, rec); len(objects) != 1500 {
+		t.Errorf("Expected the export truncated to 1500 leads, got %d", len(objects))
+	}
+	expected := []exportPage{{0, 1000}, {1000, 500}}
+	if !reflect.DeepEqual(repo.pages, expected) {
+		t.Errorf("Expected pages %v, got %v", expected, repo.pages)
+	}
+}
+
+func TestHandleExportLeads_StreamsInPages(t *testing.T) {
+	repo := newMockExportRepo(2500)
+	handler := NewExportHandler(repo, 100000)
+
+	req := httptest.NewRequest(http.MethodGet, "/export/leads", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleExportLeads(rec, req)
+
+	// Each page continues after the last ID of the previous one
+	expected := []exportPage{{0, 1000}, {1000, 1000}, {2000, 1000}}
+	if !reflect.DeepEqual(repo.pages, expected) {
+		t.Errorf("Expected pages %v, got %v", expected, repo.pages)
+	}
+	if !rec.Flushed {
+		t.Error("Expected the pages to be flushed to the client")
+	}
+
+	// The header carries the estimate, the trailer the final progress
+	result := rec.Result()
+	if progress := result.Header.Get(ExportProgressHeader); progress != "0/2500" {
+		t.Errorf("Expected initial progress 0/2500, got %q", progress)
+	}
+	if progress := result.Trailer.Get(ExportProgressHeader); progress != "2500/2500" {
+		t.Errorf("Expected final progress 2500/2500, got %q", progress)
+	}
+}
+
+func TestHandleExportLeads_MaxRows(t *testing.T) {
+	repo := newMockExportRepo(2500)
+	handler := NewExportHandler(repo, 1500)
+
+	req := httptest.NewRequest(http.MethodGet, "/export/leads", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleExportLeads(rec, req)
+
+	records := readExport(t, rec)
+	if len(records)-1 != 1500 {
+		t.Errorf("Expected the export truncated to 1500 rows, got %d", len(records)-1)
+	}
+	expected := []exportPage{{0, 1000}, {1000, 500}}
+	if !reflect.DeepEqual(repo.pages, expected) {
+		t.Errorf("Expected pages %v, got %v", expected, repo.pages)
+	}
+	if progress := rec.Result().Trailer.Get(ExportProgressHeader); progress != "1500/1500" {
+		t.Errorf("Expected final progress 1500/1500, got %q", progress)
+	}
+}
+
+func TestHandleExportLeads_Filters(t *testing.T) {
+	repo := newMockExportRepo(30)
+	handler := NewExportHandler(repo, 100000)
+
+	req := httptest.NewRequest(http.MethodGet, "/export/leads?status=REJECTED&from=2026-03-01T00:00:00Z&to=2026-03-31", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleExportLeads(rec, req)
+
+	records := readExport(t, rec)
+	if len(records)-1 != 10 {
+		t.Errorf("Expected 10 rejected leads, got %d", len(records)-1)
+	}
+	for _, record := range records[1:] {
+		if record[3] != string(models.LeadStatusRejected) {
+			t.Errorf("Expected only rejected leads, got %s", record[3])
+		}
+	}
+
+	if !repo.filter.From.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected from 2026-03-01T00:00:00Z, got %v", repo.filter.From)
+	}
+	// A date as upper bound includes the whole day
+	if !repo.filter.To.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected to before 2026-04-01, got %v", repo.filter.To)
+	}
+}
+
+func TestHandleExportLeads_InvalidParameters(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"unsupported format", "format=xlsx"},
+		{"unknown status", "status=LOST"},
+		{"invalid from", "from=yesterday"},
+		{"invalid to", "to=2026-13-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockExportRepo(10)
+			handler := NewExportHandler(repo, 100000)
+
+			req := httptest.NewRequest(http.MethodGet, "/export/leads?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			handler.HandleExportLeads(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", rec.Code)
+			}
+			if len(repo.pages) != 0 {
+				t.Error("Expected no leads to be read")
+			}
+		})
+	}
+}
+
+func TestHandleExportLeads_CountError(t *testing.T) {
+	repo := newMockExportRepo(10)
+	repo.countErr = errors.New("connection refused")
+	handler := NewExportHandler(repo, 100000)
+
+	req := httptest.NewRequest(http.MethodGet, "/export/leads", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleExportLeads(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rec.Code)
+	}
+}
+
+func TestHandleExportLeads_MethodNotAllowed(t *testing.T) {
+	handler := NewExportHandler(newMockExportRepo(0), 100000)
+
+	req := httptest.NewRequest(http.MethodPost, "/export/leads", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleExportLeads(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", rec.Code)
+	}
+}
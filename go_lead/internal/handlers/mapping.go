@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+)
+
+// DefaultMappingProfile names the mapping from ATTRIBUTE_MAPPING_FILE in mapping responses
+const DefaultMappingProfile = "default"
+
+// MappingSource provides the currently loaded attribute mapping, implemented by config.MappingWatcher
+type MappingSource interface {
+	AttributeMapping() (config.AttributeMappingConfig, time.Time)
+}
+
+// MappingHandler lets integrators look up the attributes the service recognizes
+type MappingHandler struct {
+	source      MappingSource
+	productName string
+}
+
+// NewMappingHandler creates a new MappingHandler reporting productName as the active product
+func NewMappingHandler(source MappingSource, productName string) *MappingHandler {
+	return &MappingHandler{
+		source:      source,
+		productName: productName,
+	}
+}
+
+// MappingResponse is the body of GET /admin/mapping
+type MappingResponse struct {
+	ProductName string                                `json:"product_name"`
+	Profile     string                                `json:"profile"`
+	Profiles    []string                              `json:"profiles"` // every loaded profile besides the default
+	LoadedAt    time.Time                             `json:"loaded_at"`
+	Attributes  map[string]config.AttributeDefinition `json:"attributes"`
+}
+
+// HandleMapping handles GET /admin/mapping?profile=NAME
+// It returns the attribute definitions of the default mapping, or of the given profile,
+// as currently loaded, so changes picked up by a mapping reload are reflected.
+func (h *MappingHandler) HandleMapping(w http.ResponseWriter, r *http.Request) {
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	mapping, loadedAt := h.source.AttributeMapping()
+
+	profiles := make([]string, 0, len(mapping.Profiles))
+	for name := range mapping.Profiles {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+
+	profile := r.URL.Query().Get("profile")
+	attributes := mapping.Mapping
+	if profile == "" || profile == DefaultMappingProfile {
+		profile = DefaultMappingProfile
+	} else {
+		var ok bool
+		if attributes, ok = mapping.Profiles[profile]; !ok {
+			http.Error(w, "unknown mapping profile", http.StatusNotFound)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(MappingResponse{
+		ProductName: h.productName,
+		Profile:     profile,
+		Profiles:    profiles,
+		LoadedAt:    loadedAt,
+		Attributes:  attributes,
+	})
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+)
+
+// newTestMappingWatcher loads the mapping file and the profiles of dir into a MappingWatcher
+func newTestMappingWatcher(t *testing.T, mappingFile, profileDir string) *config.MappingWatcher {
+	t.Helper()
+	cfg := &config.Config{AttributeMapping: config.AttributeMappingConfig{FilePath: mappingFile, ProfileDir: profileDir}}
+	if err := cfg.LoadAttributeMapping(); err != nil {
+		t.Fatalf("Failed to load mapping: %v", err)
+	}
+	if err := cfg.LoadMappingProfiles(); err != nil {
+		t.Fatalf("Failed to load mapping profiles: %v", err)
+	}
+	return config.NewMappingWatcher(cfg, time.Second)
+}
+
+// getMapping requests the mapping of profile and decodes the response
+func getMapping(t *testing.T, handler *MappingHandler, profile string) MappingResponse {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/admin/mapping?profile="+profile, nil)
+	rec := httptest.NewRecorder()
+	handler.HandleMapping(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response MappingResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return response
+}
+
+func TestHandleMapping(t *testing.T) {
+	dir := t.TempDir()
+	mappingFile := filepath.Join(dir, "mapping.json")
+	mapping := `{
+		"phone": {"type": "text", "required": true, "fallback_fields": ["mobile"]},
+		"solar_roof_area": {"type": "range", "min": 10, "max": 500.5},
+		"solar_usage": {"type": "dropdown", "options": ["Eigenverbrauch", "Einspeisung"]}
+	}`
+	if err := os.WriteFile(mappingFile, []byte(mapping), 0644); err != nil {
+		t.Fatalf("Failed to write mapping file: %v", err)
+	}
+	profileDir := filepath.Join(dir, "profiles")
+	if err := os.Mkdir(profileDir, 0755); err != nil {
+		t.Fatalf("Failed to create profile dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, "heatpump.json"), []byte(`{"zipcode": {"type": "text", "required": true}}`), 0644); err != nil {
+		t.Fatalf("Failed to write profile: %v", err)
+	}
+
+	watcher := newTestMappingWatcher(t, mappingFile, profileDir)
+	handler := NewMappingHandler(watcher, "solar")
+
+	response := getMapping(t, handler, "")
+	loaded, loadedAt := watcher.AttributeMapping()
+	if !reflect.DeepEqual(response.Attributes, loaded.Mapping) {
+		t.Errorf("Expected the loaded mapping %+v, got %+v", loaded.Mapping, response.Attributes)
+	}
+	if response.ProductName != "solar" || response.Profile != DefaultMappingProfile || !response.LoadedAt.Equal(loadedAt) {
+		t.Errorf("Expected product solar, the default profile and load time %v, got %+v", loadedAt, response)
+	}
+	if !reflect.DeepEqual(response.Profiles, []string{"heatpump"}) {
+		t.Errorf("Expected the heatpump profile to be listed, got %v", response.Profiles)
+	}
+
+	area := response.Attributes["solar_roof_area"]
+	if area.Type != "range" || area.Min == nil || *area.Min != 10 || area.Max == nil || *area.Max != 500.5 {
+		t.Errorf("Expected the range 10 to 500.5, got %+v", area)
+	}
+	if usage := response.Attributes["solar_usage"]; !reflect.DeepEqual(usage.Options, []string{"Eigenverbrauch", "Einspeisung"}) {
+		t.Errorf("Expected the dropdown options, got %v", usage.Options)
+	}
+
+	response = getMapping(t, handler, "heatpump")
+	if response.Profile != "heatpump" || !reflect.DeepEqual(response.Attributes, loaded.Profiles["heatpump"]) {
+		t.Errorf("Expected the heatpump profile, got %+v", response)
+	}
+
+	// A reloaded mapping is reflected
+	if err := os.WriteFile(mappingFile, []byte(`{"email": {"type": "email", "required": true}}`), 0644); err != nil {
+		t.Fatalf("Failed to rewrite mapping file: %v", err)
+	}
+	if reloaded, err := watcher.Check(); err != nil || !reloaded {
+		t.Fatalf("Expected the changed mapping to be reloaded, got %v, %v", reloaded, err)
+	}
+	response = getMapping(t, handler, "")
+	if _, ok := response.Attributes["email"]; !ok || len(response.Attributes) != 1 {
+		t.Errorf("Expected the reloaded mapping, got %+v", response.Attributes)
+	}
+}
+
+func TestHandleMapping_Errors(t *testing.T) {
+	mappingFile := filepath.Join(t.TempDir(), "mapping.json")
+	if err := os.WriteFile(mappingFile, []byte(`{"phone": {"type": "text", "required": true}}`), 0644); err != nil {
+		t.Fatalf("Failed to write mapping file: %v", err)
+	}
+	handler := NewMappingHandler(newTestMappingWatcher(t, mappingFile, ""), "solar")
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/mapping?profile=missing", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleMapping(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown profile, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/mapping", nil)
+	rec = httptest.NewRecorder()
+	handler.HandleMapping(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", rec.Code)
+	}
+}
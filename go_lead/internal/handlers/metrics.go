@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/checkfox/go_lead/internal/metrics"
+)
+
+// OmissionStatsSource exposes per-attribute omission counts and the total omissions of
+// attributes without a mapping rule, implemented by services.Mapper
+type OmissionStatsSource interface {
+	OmissionStats() map[string]int64
+	UnknownOmissions() int64
+}
+
+// RestartCountSource reports how often the worker processor was restarted,
+// implemented by the worker's GracefulRestarter
+type RestartCountSource interface {
+	RestartCount() int64
+}
+
+// StuckLeadCountSource reports how many leads the latest stuck lead check found,
+// implemented by alerting.StuckLeadDetector
+type StuckLeadCountSource interface {
+	StuckLeadCount() int64
+}
+
+// MetricsHandler exposes in-process counters in the Prometheus text format
+type MetricsHandler struct {
+	mapping    OmissionStatsSource  // optional, nil in the API server, which maps no leads
+	restarts   RestartCountSource   // optional
+	stuckLeads StuckLeadCountSource // optional
+	latency    *metrics.Histogram   // optional
+}
+
+// NewMetricsHandler creates a new MetricsHandler
+func NewMetricsHandler(mapping OmissionStatsSource) *MetricsHandler {
+	return &MetricsHandler{
+		mapping: mapping,
+	}
+}
+
+// SetRestartCount enables the worker restart gauge
+func (h *MetricsHandler) SetRestartCount(restarts RestartCountSource) {
+	h.restarts = restarts
+}
+
+// SetStuckLeadCount enables the stuck lead gauge
+func (h *MetricsHandler) SetStuckLeadCount(stuckLeads StuckLeadCountSource) {
+	h.stuckLeads = stuckLeads
+}
+
+// SetLatencyHistogram enables the Customer API latency histogram
+func (h *MetricsHandler) SetLatencyHistogram(latency *metrics.Histogram) {
+	h.latency = latency
+}
+
+// HandleMetrics handles GET /metrics
+func (h *MetricsHandler) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var b strings.Builder
+	if h.mapping != nil {
+		stats := h.mapping.OmissionStats()
+
+		// Sort keys so the output is stable between scrapes
+		keys := make([]string, 0, len(stats))
+		for key := range stats {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		b.WriteString("# HELP lead_mapping_attribute_omissions_total Optional attributes with a mapping rule omitted during mapping, per attribute.\n")
+		b.WriteString("# TYPE lead_mapping_attribute_omissions_total counter\n")
+		for _, key := range keys {
+			fmt.Fprintf(&b, "lead_mapping_attribute_omissions_total{attribute=%q} %d\n", key, stats[key])
+		}
+
+		// Unknown keys are chosen by the sender, so they get no label of their own
+		b.WriteString("# HELP lead_mapping_unknown_attribute_omissions_total Attributes without a mapping rule omitted during mapping.\n")
+		b.WriteString("# TYPE lead_mapping_unknown_attribute_omissions_total counter\n")
+		fmt.Fprintf(&b, "lead_mapping_unknown_attribute_omissions_total %d\n", h.mapping.UnknownOmissions())
+	}
+	if h.restarts != nil {
+		b.WriteString("# HELP lead_worker_restart_count Processor restarts after attribute mapping changes.\n")
+		b.WriteString("# TYPE lead_worker_restart_count gauge\n")
+		fmt.Fprintf(&b, "lead_worker_restart_count %d\n", h.restarts.RestartCount())
+	}
+	if h.stuckLeads != nil {
+		b.WriteString("# HELP stuck_leads_total Leads waiting in FAILED status longer than STUCK_LEAD_THRESHOLD at the latest check.\n")
+		b.WriteString("# TYPE stuck_leads_total gauge\n")
+		fmt.Fprintf(&b, "stuck_leads_total %d\n", h.stuckLeads.StuckLeadCount())
+	}
+	if h.latency != nil {
+		h.latency.WritePrometheus(&b)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(b.String()))
+}
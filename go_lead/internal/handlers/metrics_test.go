@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/metrics"
+)
+
+type staticOmissionStats map[string]int64
+
+func (s staticOmissionStats) OmissionStats() map[string]int64 {
+	return s
+}
+
+func (s staticOmissionStats) UnknownOmissions() int64 {
+	return 7
+}
+
+// TestHandleMetrics tests that omission counts are rendered as a labeled counter
+func TestHandleMetrics(t *testing.T) {
+	handler := NewMetricsHandler(staticOmissionStats{
+		"roof_area":    3,
+		"solar_energy": 12,
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleMetrics(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	body := rr.Body.String()
+	expected := []string{
+		"# TYPE lead_mapping_attribute_omissions_total counter",
+		`lead_mapping_attribute_omissions_total{attribute="roof_area"} 3`,
+		`lead_mapping_attribute_omissions_total{attribute="solar_energy"} 12`,
+		"# TYPE lead_mapping_unknown_attribute_omissions_total counter",
+		"lead_mapping_unknown_attribute_omissions_total 7",
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected metrics output to contain %q, got:\n%s", line, body)
+		}
+	}
+
+	if strings.Index(body, "roof_area") > strings.Index(body, "solar_energy") {
+		t.Error("Expected attributes to be sorted")
+	}
+}
+
+type staticRestartCount int64
+
+func (c staticRestartCount) RestartCount() int64 {
+	return int64(c)
+}
+
+// TestHandleMetrics_RestartCount tests that the worker restart gauge is rendered when enabled
+func TestHandleMetrics_RestartCount(t *testing.T) {
+	handler := NewMetricsHandler(staticOmissionStats{})
+
+	rr := httptest.NewRecorder()
+	handler.HandleMetrics(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if strings.Contains(rr.Body.String(), "lead_worker_restart_count") {
+		t.Errorf("Expected no restart gauge without a source, got:\n%s", rr.Body.String())
+	}
+
+	handler.SetRestartCount(staticRestartCount(2))
+	rr = httptest.NewRecorder()
+	handler.HandleMetrics(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rr.Body.String()
+	for _, line := range []string{"# TYPE lead_worker_restart_count gauge", "lead_worker_restart_count 2"} {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected metrics output to contain %q, got:\n%s", line, body)
+		}
+	}
+}
+
+// TestHandleMetrics_MethodNotAllowed tests that only GET is accepted
+func TestHandleMetrics_MethodNotAllowed(t *testing.T) {
+	handler := NewMetricsHandler(staticOmissionStats{})
+
+	req := httptest.NewRequest(http.MethodPost, "/metrics", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleMetrics(rr, req)
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", rr.Code)
+	}
+}
+
+type staticStuckLeadCount int64
+
+func (c staticStuckLeadCount) StuckLeadCount() int64 {
+	return int64(c)
+}
+
+// TestHandleMetrics_StuckLeads tests the stuck lead gauge of the API server, which maps no leads
+func TestHandleMetrics_StuckLeads(t *testing.T) {
+	handler := NewMetricsHandler(nil)
+	handler.SetStuckLeadCount(staticStuckLeadCount(4))
+
+	rr := httptest.NewRecorder()
+	handler.HandleMetrics(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rr.Body.String()
+	for _, line := range []string{"# TYPE stuck_leads_total gauge", "stuck_leads_total 4"} {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected metrics output to contain %q, got:\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "lead_mapping_attribute_omissions_total") {
+		t.Errorf("Expected no omission counter without a mapping source, got:\n%s", body)
+	}
+}
+
+// TestHandleMetrics_CustomerAPILatency tests that the worker exposes the Customer API latency histogram
+func TestHandleMetrics_CustomerAPILatency(t *testing.T) {
+	latency := metrics.NewHistogram("customer_api_request_duration_seconds", "Customer API response times by status code class.",
+		"status_code_class", []float64{0.5, 1})
+	latency.Observe("2xx", 0.3)
+
+	handler := NewMetricsHandler(nil)
+	handler.SetLatencyHistogram(latency)
+
+	rr := httptest.NewRecorder()
+	handler.HandleMetrics(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rr.Body.String()
+	for _, line := range []string{
+		"# TYPE customer_api_request_duration_seconds histogram",
+		`customer_api_request_duration_seconds_bucket{status_code_class="2xx",le="0.5"} 1`,
+		`customer_api_request_duration_seconds_count{status_code_class="2xx"} 1`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected metrics output to contain %q, got:\n%s", line, body)
+		}
+	}
+}
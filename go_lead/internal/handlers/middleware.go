@@ -1,28 +1,65 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/repository"
 	"github.com/google/uuid"
 )
 
 // AuthMiddleware provides authentication middleware for webhook endpoints
 type AuthMiddleware struct {
 	config *config.Config
+
+	// tenantsBySecret maps tenant secrets to their tenant; empty unless multi-tenancy is enabled
+	tenantsBySecret map[string]string
 }
 
 // NewAuthMiddleware creates a new AuthMiddleware
 func NewAuthMiddleware(cfg *config.Config) *AuthMiddleware {
-	return &AuthMiddleware{
-		config: cfg,
+	m := &AuthMiddleware{
+		config:          cfg,
+		tenantsBySecret: make(map[string]string),
+	}
+	if cfg.Tenant.Enabled {
+		for tenantID, secret := range cfg.Tenant.Secrets {
+			m.tenantsBySecret[secret] = tenantID
+		}
 	}
+	return m
 }
 
-// Authenticate validates the shared secret header if authentication is enabled
+// authenticatedTenantKey is the context key of the tenant bound to the request's credentials
+type authenticatedTenantKey struct{}
+
+// Authenticate validates the shared secret header if authentication is enabled.
+// Tenant secrets are rejected, so tenants cannot reach the cross-tenant endpoints.
 func (m *AuthMiddleware) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return m.authenticate(next, false)
+}
+
+// AuthenticateTenant validates the shared secret header like Authenticate, but also accepts
+// tenant secrets and binds the request to their tenant (see TenantMiddleware)
+func (m *AuthMiddleware) AuthenticateTenant(next http.HandlerFunc) http.HandlerFunc {
+	return m.authenticate(next, true)
+}
+
+// authenticate validates the shared secret header, accepting tenant secrets if allowTenants is set
+func (m *AuthMiddleware) authenticate(next http.HandlerFunc, allowTenants bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Skip authentication if not enabled
 		if !m.config.Auth.Enabled {
@@ -43,6 +80,11 @@ func (m *AuthMiddleware) Authenticate(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 		
+		if tenantID, ok := m.tenantsBySecret[providedSecret]; ok && allowTenants {
+			next(w, r.WithContext(context.WithValue(r.Context(), authenticatedTenantKey{}, tenantID)))
+			return
+		}
+		
 		if providedSecret != m.config.Auth.SharedSecret {
 			log.Printf("[%s] Authentication failed: invalid shared secret", correlationID)
 			respondUnauthorized(w, correlationID, "invalid authentication credentials")
@@ -71,6 +113,245 @@ func respondUnauthorized(w http.ResponseWriter, correlationID, message string) {
 	}
 }
 
+// TenantMiddleware resolves the request tenant and stores it in the request context
+type TenantMiddleware struct {
+	config *config.Config
+}
+
+// NewTenantMiddleware creates a new TenantMiddleware
+func NewTenantMiddleware(cfg *config.Config) *TenantMiddleware {
+	return &TenantMiddleware{
+		config: cfg,
+	}
+}
+
+// RequireTenant rejects requests not authenticated with a tenant secret when multi-tenancy
+// is enabled and exposes the tenant ID to repositories via repository.WithTenantID. It must
+// be wrapped in AuthMiddleware.AuthenticateTenant, which resolves the tenant of the credentials.
+func (m *TenantMiddleware) RequireTenant(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Skip tenant resolution if not enabled
+		if !m.config.Tenant.Enabled {
+			next(w, r)
+			return
+		}
+		
+		tenantID, _ := r.Context().Value(authenticatedTenantKey{}).(string)
+		if tenantID == "" {
+			correlationID := uuid.New().String()
+			log.Printf("[%s] Tenant resolution failed: credentials are not bound to a tenant", correlationID)
+			
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Correlation-ID", correlationID)
+			w.WriteHeader(http.StatusForbidden)
+			
+			response := ErrorResponse{
+				Error:         "missing tenant identification",
+				CorrelationID: correlationID,
+			}
+			if err := json.NewEncoder(w).Encode(response); err != nil {
+				log.Printf("[%s] Failed to encode tenant error response: %v", correlationID, err)
+			}
+			return
+		}
+		
+		next(w, r.WithContext(repository.WithTenantID(r.Context(), tenantID)))
+	}
+}
+
+// IPAllowlistMiddleware restricts requests to client IPs within the configured CIDRs
+type IPAllowlistMiddleware struct {
+	allowed        []*net.IPNet
+	trustedProxies []*net.IPNet
+}
+
+// NewIPAllowlistMiddleware creates a new IPAllowlistMiddleware from the webhook configuration
+func NewIPAllowlistMiddleware(cfg *config.Config) *IPAllowlistMiddleware {
+	return &IPAllowlistMiddleware{
+		allowed:        parseNetworks(cfg.Webhook.AllowedCIDRs),
+		trustedProxies: parseNetworks(cfg.Webhook.TrustedProxies),
+	}
+}
+
+// parseNetworks parses CIDRs and single IPs into networks.
+// Entries are validated when the config is loaded, so invalid ones are skipped.
+func parseNetworks(entries []string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			networks = append(networks, network)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		}
+	}
+	return networks
+}
+
+// containsIP reports whether any of the networks contains ip
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allow rejects requests whose client IP is outside the allowed CIDRs with 403 Forbidden.
+// The check is skipped when no CIDRs are configured.
+func (m *IPAllowlistMiddleware) Allow(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(m.allowed) == 0 {
+			next(w, r)
+			return
+		}
+		
+		clientIP := m.clientIP(r)
+		if clientIP != nil && containsIP(m.allowed, clientIP) {
+			next(w, r)
+			return
+		}
+		
+		correlationID := uuid.New().String()
+		log.Printf("[%s] IP allowlist rejected request from %v (remote address %s)", correlationID, clientIP, r.RemoteAddr)
+		
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Correlation-ID", correlationID)
+		w.WriteHeader(http.StatusForbidden)
+		
+		response := ErrorResponse{
+			Error:         "forbidden",
+			CorrelationID: correlationID,
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("[%s] Failed to encode forbidden response: %v", correlationID, err)
+		}
+	}
+}
+
+// clientIP returns the IP of the webhook sender
+func (m *IPAllowlistMiddleware) clientIP(r *http.Request) net.IP {
+	return resolveClientIP(r, m.trustedProxies)
+}
+
+// resolveClientIP returns the IP of the request sender. When the request arrives from a
+// trusted proxy, X-Forwarded-For is walked from the right and the first untrusted address
+// wins, so a client cannot spoof its IP by prepending entries.
+func resolveClientIP(r *http.Request, trustedProxies []*net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !containsIP(trustedProxies, ip) {
+		return ip
+	}
+	
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if hop == nil {
+			// An unparseable hop cannot be attributed, so the request is treated as unknown
+			return nil
+		}
+		ip = hop
+		if !containsIP(trustedProxies, hop) {
+			return hop
+		}
+	}
+	return ip
+}
+
+// ChecksumHeader is the optional request header carrying a hex-encoded MD5 or SHA-256 of the body
+const ChecksumHeader = "X-Payload-Checksum"
+
+// ChecksumMiddleware detects corrupted or truncated request bodies using ChecksumHeader
+type ChecksumMiddleware struct {
+	maxBodyBytes int64 // bodies beyond this size are rejected before hashing; 0 means no limit
+}
+
+// NewChecksumMiddleware creates a new ChecksumMiddleware reading at most maxBodyBytes of a
+// request body (0 = no limit)
+func NewChecksumMiddleware(maxBodyBytes int) *ChecksumMiddleware {
+	return &ChecksumMiddleware{maxBodyBytes: int64(maxBodyBytes)}
+}
+
+// VerifyChecksum compares the body against the checksum header when one is present.
+// The algorithm is detected from the checksum length: 32 hex characters for MD5, 64 for SHA-256.
+func (m *ChecksumMiddleware) VerifyChecksum(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		expected := strings.ToLower(strings.TrimSpace(r.Header.Get(ChecksumHeader)))
+		if expected == "" {
+			next(w, r)
+			return
+		}
+		
+		correlationID := uuid.New().String()
+		
+		if m.maxBodyBytes > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, m.maxBodyBytes)
+		}
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			log.Printf("[%s] Checksum verification failed: body exceeds %d bytes", correlationID, tooLarge.Limit)
+			respondChecksumError(w, correlationID, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		if err != nil {
+			log.Printf("[%s] Checksum verification failed: could not read body: %v", correlationID, err)
+			respondChecksumError(w, correlationID, http.StatusBadRequest, "failed to read request body")
+			return
+		}
+		
+		var actual string
+		switch len(expected) {
+		case hex.EncodedLen(md5.Size):
+			sum := md5.Sum(body)
+			actual = hex.EncodeToString(sum[:])
+		case hex.EncodedLen(sha256.Size):
+			sum := sha256.Sum256(body)
+			actual = hex.EncodeToString(sum[:])
+		default:
+			log.Printf("[%s] Checksum verification failed: unsupported checksum length %d", correlationID, len(expected))
+			respondChecksumError(w, correlationID, http.StatusBadRequest, "unsupported checksum format")
+			return
+		}
+		
+		if actual != expected {
+			log.Printf("[%s] Checksum verification failed: expected %s, computed %s", correlationID, expected, actual)
+			respondChecksumError(w, correlationID, http.StatusBadRequest, "checksum mismatch")
+			return
+		}
+		
+		// Re-attach the body for the next handler
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next(w, r)
+	}
+}
+
+// respondChecksumError sends an error response with status for a failed checksum verification
+func respondChecksumError(w http.ResponseWriter, correlationID string, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Correlation-ID", correlationID)
+	w.WriteHeader(status)
+	
+	response := ErrorResponse{
+		Error:         message,
+		CorrelationID: correlationID,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("[%s] Failed to encode checksum error response: %v", correlationID, err)
+	}
+}
+
 // RecoveryMiddleware recovers from panics and returns 500 Internal Server Error
 type RecoveryMiddleware struct{}
 
@@ -106,3 +387,170 @@ func (m *RecoveryMiddleware) Recover(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+
+// TimeoutMiddleware bounds how long a handler may take to respond
+type TimeoutMiddleware struct {
+	timeout time.Duration
+}
+
+// NewTimeoutMiddleware creates a new TimeoutMiddleware. A non-positive duration disables the timeout.
+func NewTimeoutMiddleware(timeout time.Duration) *TimeoutMiddleware {
+	return &TimeoutMiddleware{timeout: timeout}
+}
+
+// Timeout wraps a handler with a request deadline. The handler runs with a context that is
+// cancelled at the deadline; if it has not responded by then, the client receives a
+// 503 Service Unavailable JSON error and anything the handler writes later is discarded.
+// Panics in the handler are re-raised so an outer RecoveryMiddleware can handle them.
+func (m *TimeoutMiddleware) Timeout(next http.HandlerFunc) http.HandlerFunc {
+	if m.timeout <= 0 {
+		return next
+	}
+	
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), m.timeout)
+		defer cancel()
+		
+		tw := &timeoutWriter{header: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+		
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+			}()
+			next(tw, r.WithContext(ctx))
+			close(done)
+		}()
+		
+		select {
+		case p := <-panicked:
+			panic(p)
+			
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			
+			for key, values := range tw.header {
+				w.Header()[key] = values
+			}
+			if tw.code == 0 {
+				tw.code = http.StatusOK
+			}
+			w.WriteHeader(tw.code)
+			w.Write(tw.body.Bytes())
+			
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			tw.timedOut = true
+			
+			correlationID := uuid.New().String()
+			log.Printf("[%s] Request %s %s timed out after %s", correlationID, r.Method, r.URL.Path, m.timeout)
+			
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Correlation-ID", correlationID)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			
+			response := ErrorResponse{
+				Error:         "request timed out",
+				CorrelationID: correlationID,
+			}
+			if err := json.NewEncoder(w).Encode(response); err != nil {
+				log.Printf("[%s] Failed to encode error response: %v", correlationID, err)
+			}
+		}
+	}
+}
+
+// concurrencyLimitRetryAfter is the Retry-After value sent when the in-flight limit is reached
+const concurrencyLimitRetryAfter = "1"
+
+// ConcurrencyLimitMiddleware bounds the number of requests handled simultaneously, so a burst
+// of webhooks cannot exhaust the database connection pool
+type ConcurrencyLimitMiddleware struct {
+	slots chan struct{}
+}
+
+// NewConcurrencyLimitMiddleware creates a ConcurrencyLimitMiddleware allowing max in-flight
+// requests. A non-positive max disables the limit.
+func NewConcurrencyLimitMiddleware(max int) *ConcurrencyLimitMiddleware {
+	if max <= 0 {
+		return &ConcurrencyLimitMiddleware{}
+	}
+	return &ConcurrencyLimitMiddleware{slots: make(chan struct{}, max)}
+}
+
+// Limit rejects requests with 503 Service Unavailable and a Retry-After header while the
+// maximum number of requests is in flight. The slot is released when the handler returns.
+func (m *ConcurrencyLimitMiddleware) Limit(next http.HandlerFunc) http.HandlerFunc {
+	if m.slots == nil {
+		return next
+	}
+	
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case m.slots <- struct{}{}:
+			defer func() { <-m.slots }()
+			next(w, r)
+			
+		default:
+			correlationID := uuid.New().String()
+			log.Printf("[%s] Rejecting %s %s: %d requests in flight", correlationID, r.Method, r.URL.Path, cap(m.slots))
+			
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Correlation-ID", correlationID)
+			w.Header().Set("Retry-After", concurrencyLimitRetryAfter)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			
+			response := ErrorResponse{
+				Error:         "too many concurrent requests",
+				CorrelationID: correlationID,
+			}
+			if err := json.NewEncoder(w).Encode(response); err != nil {
+				log.Printf("[%s] Failed to encode error response: %v", correlationID, err)
+			}
+		}
+	}
+}
+
+// timeoutWriter buffers a handler's response until it completes within the deadline
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+// Header returns the buffered response headers
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// Write buffers the response body, failing once the request has timed out
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.body.Write(p)
+}
+
+// WriteHeader records the response status code
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
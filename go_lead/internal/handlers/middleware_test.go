@@ -1,12 +1,19 @@
 package handlers
 
 import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/repository"
 )
 
 // Test authentication middleware when auth is disabled
@@ -235,3 +242,546 @@ func TestRecoveryMiddleware_NormalExecution(t *testing.T) {
 		t.Errorf("Expected status 200, got %d", rr.Code)
 	}
 }
+
+// newTenantTestConfig returns a config with multi-tenancy enabled for tenant-a and tenant-b
+func newTenantTestConfig() *config.Config {
+	return &config.Config{
+		Auth: config.AuthConfig{Enabled: true, SharedSecret: "operator-secret"},
+		Tenant: config.TenantConfig{
+			Enabled: true,
+			Secrets: map[string]string{"tenant-a": "secret-a", "tenant-b": "secret-b"},
+		},
+	}
+}
+
+// Test tenant middleware stores the tenant of the caller's secret in the request context
+func TestTenantMiddleware_ResolvesTenant(t *testing.T) {
+	cfg := newTenantTestConfig()
+
+	var resolved string
+	wrappedHandler := NewAuthMiddleware(cfg).AuthenticateTenant(NewTenantMiddleware(cfg).RequireTenant(func(w http.ResponseWriter, r *http.Request) {
+		resolved, _ = repository.GetTenantID(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for secret, tenantID := range map[string]string{"secret-a": "tenant-a", "secret-b": "tenant-b"} {
+		req := httptest.NewRequest(http.MethodPost, "/test", nil)
+		req.Header.Set("X-Shared-Secret", secret)
+		rr := httptest.NewRecorder()
+
+		wrappedHandler(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", rr.Code)
+		}
+
+		if resolved != tenantID {
+			t.Errorf("Expected tenant '%s' in context, got '%s'", tenantID, resolved)
+		}
+	}
+}
+
+// Test a tenant header cannot select another tenant than the one of the caller's secret
+func TestTenantMiddleware_IgnoresTenantHeader(t *testing.T) {
+	cfg := newTenantTestConfig()
+
+	var resolved string
+	wrappedHandler := NewAuthMiddleware(cfg).AuthenticateTenant(NewTenantMiddleware(cfg).RequireTenant(func(w http.ResponseWriter, r *http.Request) {
+		resolved, _ = repository.GetTenantID(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/test", nil)
+	req.Header.Set("X-Shared-Secret", "secret-a")
+	req.Header.Set("X-Tenant-ID", "tenant-b")
+	rr := httptest.NewRecorder()
+
+	wrappedHandler(rr, req)
+
+	if resolved != "tenant-a" {
+		t.Errorf("Expected the tenant of the secret 'tenant-a', got '%s'", resolved)
+	}
+}
+
+// Test tenant middleware rejects requests whose credentials are not bound to a tenant
+func TestTenantMiddleware_MissingTenant(t *testing.T) {
+	cfg := newTenantTestConfig()
+
+	handlerCalled := false
+	wrappedHandler := NewAuthMiddleware(cfg).AuthenticateTenant(NewTenantMiddleware(cfg).RequireTenant(func(w http.ResponseWriter, r *http.Request) {
+		handlerCalled = true
+	}))
+
+	// The operator secret authenticates but belongs to no tenant
+	req := httptest.NewRequest(http.MethodPost, "/test", nil)
+	req.Header.Set("X-Shared-Secret", "operator-secret")
+	req.Header.Set("X-Tenant-ID", "tenant-a")
+	rr := httptest.NewRecorder()
+
+	wrappedHandler(rr, req)
+
+	if handlerCalled {
+		t.Error("Expected handler not to be called without a tenant")
+	}
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", rr.Code)
+	}
+
+	// Without authentication in front, no request has a tenant
+	rr = httptest.NewRecorder()
+	NewTenantMiddleware(cfg).RequireTenant(func(w http.ResponseWriter, r *http.Request) {
+		handlerCalled = true
+	})(rr, req)
+
+	if handlerCalled || rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 without authentication, got %d", rr.Code)
+	}
+}
+
+// Test tenant secrets are rejected on cross-tenant endpoints and while multi-tenancy is disabled
+func TestAuthMiddleware_TenantSecretRejected(t *testing.T) {
+	okHandler := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	cfg := newTenantTestConfig()
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/test", nil)
+	req.Header.Set("X-Shared-Secret", "secret-a")
+	NewAuthMiddleware(cfg).Authenticate(okHandler)(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for a tenant secret on a cross-tenant endpoint, got %d", rr.Code)
+	}
+
+	cfg.Tenant.Enabled = false
+	rr = httptest.NewRecorder()
+	NewAuthMiddleware(cfg).AuthenticateTenant(okHandler)(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 while multi-tenancy is disabled, got %d", rr.Code)
+	}
+
+	// The operator secret is accepted on both
+	req.Header.Set("X-Shared-Secret", "operator-secret")
+	rr = httptest.NewRecorder()
+	NewAuthMiddleware(cfg).AuthenticateTenant(okHandler)(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for the operator secret, got %d", rr.Code)
+	}
+}
+
+// Test tenant middleware is a no-op when multi-tenancy is disabled
+func TestTenantMiddleware_Disabled(t *testing.T) {
+	middleware := NewTenantMiddleware(&config.Config{})
+
+	handlerCalled := false
+	wrappedHandler := middleware.RequireTenant(func(w http.ResponseWriter, r *http.Request) {
+		handlerCalled = true
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/test", nil)
+	wrappedHandler(httptest.NewRecorder(), req)
+
+	if !handlerCalled {
+		t.Error("Expected handler to be called when multi-tenancy is disabled")
+	}
+}
+
+// checksumEchoHandler returns a handler that records the body it receives
+func checksumEchoHandler(called *bool, received *string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*called = true
+		body, _ := io.ReadAll(r.Body)
+		*received = string(body)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// Test checksum middleware accepts bodies matching an MD5 or SHA-256 checksum
+func TestChecksumMiddleware_MatchingChecksum(t *testing.T) {
+	body := `{"email": "test@example.com", "zipcode": "66123"}`
+	md5Sum := md5.Sum([]byte(body))
+	sha256Sum := sha256.Sum256([]byte(body))
+
+	checksums := map[string]string{
+		"md5":              hex.EncodeToString(md5Sum[:]),
+		"sha256":           hex.EncodeToString(sha256Sum[:]),
+		"sha256 uppercase": strings.ToUpper(hex.EncodeToString(sha256Sum[:])),
+	}
+
+	for name, checksum := range checksums {
+		t.Run(name, func(t *testing.T) {
+			var called bool
+			var received string
+			wrappedHandler := NewChecksumMiddleware(0).VerifyChecksum(checksumEchoHandler(&called, &received))
+
+			req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+			req.Header.Set(ChecksumHeader, checksum)
+			rr := httptest.NewRecorder()
+
+			wrappedHandler(rr, req)
+
+			if !called || rr.Code != http.StatusOK {
+				t.Fatalf("Expected handler to be called with status 200, got called=%v status=%d", called, rr.Code)
+			}
+
+			if received != body {
+				t.Errorf("Expected body to be re-attached, got %q", received)
+			}
+		})
+	}
+}
+
+// Test checksum middleware rejects bodies that do not match the checksum
+func TestChecksumMiddleware_MismatchingChecksum(t *testing.T) {
+	body := `{"email": "test@example.com", "zipcode": "66123"}`
+	sum := sha256.Sum256([]byte(body))
+
+	var called bool
+	var received string
+	wrappedHandler := NewChecksumMiddleware(0).VerifyChecksum(checksumEchoHandler(&called, &received))
+
+	// Simulate a truncated body
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body[:20]))
+	req.Header.Set(ChecksumHeader, hex.EncodeToString(sum[:]))
+	rr := httptest.NewRecorder()
+
+	wrappedHandler(rr, req)
+
+	if called {
+		t.Error("Expected handler not to be called on checksum mismatch")
+	}
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rr.Code)
+	}
+
+	var response ErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Error != "checksum mismatch" {
+		t.Errorf("Expected error 'checksum mismatch', got '%s'", response.Error)
+	}
+}
+
+// Test checksum middleware passes requests without a checksum header through untouched
+func TestChecksumMiddleware_MissingHeader(t *testing.T) {
+	body := `{"email": "test@example.com"}`
+
+	var called bool
+	var received string
+	wrappedHandler := NewChecksumMiddleware(0).VerifyChecksum(checksumEchoHandler(&called, &received))
+
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	wrappedHandler(rr, req)
+
+	if !called || rr.Code != http.StatusOK {
+		t.Errorf("Expected passthrough with status 200, got called=%v status=%d", called, rr.Code)
+	}
+
+	if received != body {
+		t.Errorf("Expected body %q, got %q", body, received)
+	}
+}
+
+// Test checksum middleware rejects bodies beyond its limit without hashing them
+func TestChecksumMiddleware_BodyTooLarge(t *testing.T) {
+	body := `{"email": "test@example.com", "zipcode": "66123"}`
+	sum := sha256.Sum256([]byte(body))
+
+	var called bool
+	var received string
+	wrappedHandler := NewChecksumMiddleware(len(body) - 1).VerifyChecksum(checksumEchoHandler(&called, &received))
+
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+	req.Header.Set(ChecksumHeader, hex.EncodeToString(sum[:]))
+	rr := httptest.NewRecorder()
+
+	wrappedHandler(rr, req)
+
+	if called {
+		t.Error("Expected handler not to be called for a body beyond the limit")
+	}
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", rr.Code)
+	}
+
+	var response ErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Error != "request body too large" {
+		t.Errorf("Expected error 'request body too large', got '%s'", response.Error)
+	}
+
+	// A body within the limit passes
+	called = false
+	wrappedHandler = NewChecksumMiddleware(len(body)).VerifyChecksum(checksumEchoHandler(&called, &received))
+	req = httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+	req.Header.Set(ChecksumHeader, hex.EncodeToString(sum[:]))
+	rr = httptest.NewRecorder()
+	wrappedHandler(rr, req)
+	if !called || rr.Code != http.StatusOK || received != body {
+		t.Errorf("Expected a body within the limit to pass, got called=%v status=%d", called, rr.Code)
+	}
+}
+
+// Test timeout middleware answers a slow handler with a 503 JSON error
+func TestTimeoutMiddleware_SlowHandler(t *testing.T) {
+	middleware := NewTimeoutMiddleware(50 * time.Millisecond)
+
+	slowHandler := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("too late"))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/stats/leads/recent", nil)
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	middleware.Timeout(slowHandler)(w, req)
+	elapsed := time.Since(start)
+
+	if elapsed > time.Second {
+		t.Errorf("Expected timely response, took %s", elapsed)
+	}
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %q", contentType)
+	}
+
+	var response ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Error != "request timed out" || response.CorrelationID == "" {
+		t.Errorf("Unexpected error response: %+v", response)
+	}
+}
+
+// Test timeout middleware passes a fast handler's response through unchanged
+func TestTimeoutMiddleware_FastHandler(t *testing.T) {
+	middleware := NewTimeoutMiddleware(time.Second)
+
+	fastHandler := func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("Expected handler context to carry a deadline")
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("accepted"))
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", nil)
+	w := httptest.NewRecorder()
+	middleware.Timeout(fastHandler)(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("Expected handler Content-Type to be kept, got %q", w.Header().Get("Content-Type"))
+	}
+	if w.Body.String() != "accepted" {
+		t.Errorf("Expected body 'accepted', got %q", w.Body.String())
+	}
+}
+
+// Test panics inside the timeout middleware still reach the recovery middleware
+func TestTimeoutMiddleware_PropagatesPanic(t *testing.T) {
+	handler := NewRecoveryMiddleware().Recover(
+		NewTimeoutMiddleware(time.Second).Timeout(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "/stats/leads/recent", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+}
+
+// Test concurrency limit middleware rejects requests beyond the in-flight limit
+func TestConcurrencyLimitMiddleware_RejectsBeyondLimit(t *testing.T) {
+	const limit, requests = 3, 10
+	middleware := NewConcurrencyLimitMiddleware(limit)
+
+	// Admitted requests block until every rejected request has returned
+	release := make(chan struct{})
+	handler := middleware.Limit(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	results := make(chan *httptest.ResponseRecorder, requests)
+	for i := 0; i < requests; i++ {
+		go func() {
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodPost, "/webhooks/leads", nil))
+			results <- w
+		}()
+	}
+
+	var accepted, rejected int
+	for i := 0; i < requests; i++ {
+		if i == requests-limit {
+			close(release)
+		}
+		w := <-results
+		switch w.Code {
+		case http.StatusAccepted:
+			accepted++
+		case http.StatusServiceUnavailable:
+			rejected++
+			if w.Header().Get("Retry-After") == "" {
+				t.Error("Expected a Retry-After header on rejected requests")
+			}
+		default:
+			t.Errorf("Unexpected status %d", w.Code)
+		}
+	}
+
+	if accepted != limit || rejected != requests-limit {
+		t.Errorf("Expected %d accepted and %d rejected requests, got %d and %d", limit, requests-limit, accepted, rejected)
+	}
+
+	// Slots are released once the handlers return
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, "/webhooks/leads", nil))
+	if w.Code != http.StatusAccepted {
+		t.Errorf("Expected a request after the burst to be accepted, got %d", w.Code)
+	}
+}
+
+// Test concurrency limit middleware is a no-op when disabled
+func TestConcurrencyLimitMiddleware_Disabled(t *testing.T) {
+	called := false
+	handler := NewConcurrencyLimitMiddleware(0).Limit(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/webhooks/leads", nil))
+	if !called {
+		t.Error("Expected the handler to be called without a limit")
+	}
+}
+
+// Test IP allowlist middleware allows and denies by remote address
+func TestIPAllowlistMiddleware_RemoteAddr(t *testing.T) {
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{
+			AllowedCIDRs: []string{"203.0.113.0/24", "2001:db8::/32"},
+		},
+	}
+	middleware := NewIPAllowlistMiddleware(cfg)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		wantStatus int
+	}{
+		{"inside IPv4 range", "203.0.113.42:5000", http.StatusOK},
+		{"outside IPv4 range", "198.51.100.7:5000", http.StatusForbidden},
+		{"inside IPv6 range", "[2001:db8::1]:5000", http.StatusOK},
+		{"outside IPv6 range", "[2001:db9::1]:5000", http.StatusForbidden},
+		{"spoofed X-Forwarded-For ignored without trusted proxies", "198.51.100.7:5000", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.Allow(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "203.0.113.42")
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus == http.StatusForbidden && w.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("Expected JSON error response, got Content-Type %q", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+// Test IP allowlist middleware uses X-Forwarded-For from trusted proxies
+func TestIPAllowlistMiddleware_TrustedProxy(t *testing.T) {
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{
+			AllowedCIDRs:   []string{"203.0.113.0/24"},
+			TrustedProxies: []string{"10.0.0.0/8", "192.0.2.1"},
+		},
+	}
+	middleware := NewIPAllowlistMiddleware(cfg)
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		wantStatus   int
+	}{
+		{"allowed client behind proxy", "10.1.2.3:443", "203.0.113.42", http.StatusOK},
+		{"denied client behind proxy", "10.1.2.3:443", "198.51.100.7", http.StatusForbidden},
+		{"client behind proxy chain", "10.1.2.3:443", "203.0.113.42, 192.0.2.1", http.StatusOK},
+		{"spoofed leftmost entry", "10.1.2.3:443", "203.0.113.42, 198.51.100.7", http.StatusForbidden},
+		{"proxy without header", "10.1.2.3:443", "", http.StatusForbidden},
+		{"invalid forwarded entry", "10.1.2.3:443", "not-an-ip", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.Allow(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
+
+// Test IP allowlist middleware is disabled without configured CIDRs
+func TestIPAllowlistMiddleware_Disabled(t *testing.T) {
+	middleware := NewIPAllowlistMiddleware(&config.Config{})
+
+	handlerCalled := false
+	handler := middleware.Allow(func(w http.ResponseWriter, r *http.Request) {
+		handlerCalled = true
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", nil)
+	req.RemoteAddr = "198.51.100.7:5000"
+	handler(httptest.NewRecorder(), req)
+
+	if !handlerCalled {
+		t.Error("Expected handler to be called when no CIDRs are configured")
+	}
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/database"
+	"github.com/checkfox/go_lead/internal/logger"
+)
+
+// Components reported by the readiness endpoint
+const (
+	ReadinessComponentSchema      = "database_schema"
+	ReadinessComponentQueue       = "queue"
+	ReadinessComponentCustomerAPI = "customer_api"
+)
+
+// defaultReadinessCheckTimeout bounds each readiness check when no timeout is configured
+const defaultReadinessCheckTimeout = 2 * time.Second
+
+// MigrationChecker lists migrations that have not been applied, implemented by database.MigrationRunner
+type MigrationChecker interface {
+	Pending() ([]database.Migration, error)
+}
+
+// PendingJobCounter counts the due jobs in the queue, implemented by queue.DBQueue
+type PendingJobCounter interface {
+	CountPending(ctx context.Context) (int, error)
+}
+
+// ReadinessConfig holds the dependencies checked by the readiness endpoint.
+// Checks whose dependency is not set are left out.
+type ReadinessConfig struct {
+	Migrations        MigrationChecker
+	Queue             PendingJobCounter
+	MaxPendingJobs    int // 0 disables the backlog limit; the queue must still be readable
+	CustomerAPIURL    string
+	CustomerAPIMethod string       // HEAD (default) or OPTIONS
+	HTTPClient        *http.Client // used for the Customer API probe
+	CheckTimeout      time.Duration
+
+	// SchemaBehindDegraded reports pending migrations as degraded (200) instead of failed (503)
+	SchemaBehindDegraded bool
+}
+
+// readinessCheck is a named check run by the readiness endpoint
+type readinessCheck struct {
+	component string
+	run       func(ctx context.Context) error
+}
+
+// ReadinessHandler answers Kubernetes readiness probes. Unlike /health, which only
+// reports that the process is alive, it verifies the dependencies needed to serve traffic.
+type ReadinessHandler struct {
+	checks  []readinessCheck
+	timeout time.Duration
+}
+
+// NewReadinessHandler creates a new ReadinessHandler
+func NewReadinessHandler(cfg ReadinessConfig) *ReadinessHandler {
+	h := &ReadinessHandler{
+		timeout: cfg.CheckTimeout,
+	}
+	if h.timeout <= 0 {
+		h.timeout = defaultReadinessCheckTimeout
+	}
+
+	if cfg.Migrations != nil {
+		h.checks = append(h.checks, readinessCheck{ReadinessComponentSchema, func(ctx context.Context) error {
+			return checkSchema(cfg.Migrations, cfg.SchemaBehindDegraded)
+		}})
+	}
+	if cfg.Queue != nil {
+		h.checks = append(h.checks, readinessCheck{ReadinessComponentQueue, func(ctx context.Context) error {
+			return checkQueueBacklog(ctx, cfg.Queue, cfg.MaxPendingJobs)
+		}})
+	}
+	if cfg.CustomerAPIURL != "" {
+		client := cfg.HTTPClient
+		if client == nil {
+			client = &http.Client{}
+		}
+		method := cfg.CustomerAPIMethod
+		if method == "" {
+			method = http.MethodHead
+		}
+		h.checks = append(h.checks, readinessCheck{ReadinessComponentCustomerAPI, func(ctx context.Context) error {
+			return checkCustomerAPIReachable(ctx, client, method, cfg.CustomerAPIURL)
+		}})
+	}
+
+	return h
+}
+
+// ComponentReadiness is the outcome of a single readiness check
+type ComponentReadiness struct {
+	Component  string `json:"component"`
+	Status     string `json:"status"` // "ok", "degraded" or "fail"
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// ReadinessResponse is the body of GET /health/ready
+type ReadinessResponse struct {
+	Status string               `json:"status"` // "ready", "degraded" or "not_ready"
+	Checks []ComponentReadiness `json:"checks"`
+}
+
+// HandleReady handles GET /health/ready
+// All checks run in parallel, each with its own timeout. Any failure answers 503 so
+// Kubernetes stops routing traffic to the pod until it recovers.
+func (h *ReadinessHandler) HandleReady(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	results := make([]ComponentReadiness, len(h.checks))
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func(i int, check readinessCheck) {
+			defer wg.Done()
+			results[i] = h.runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	response := ReadinessResponse{
+		Status: "ready",
+		Checks: results,
+	}
+	statusCode := http.StatusOK
+	for _, result := range results {
+		switch result.Status {
+		case "fail":
+			logger.Warn(ctx, "Readiness check failed",
+				"component", result.Component,
+				"error", result.Error)
+			response.Status = "not_ready"
+			statusCode = http.StatusServiceUnavailable
+		case "degraded":
+			logger.Warn(ctx, "Readiness check degraded",
+				"component", result.Component,
+				"error", result.Error)
+			if statusCode == http.StatusOK {
+				response.Status = "degraded"
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// runCheck runs a check with the per-check timeout. Checks that ignore their
+// context are abandoned once the timeout expires.
+func (h *ReadinessHandler) runCheck(ctx context.Context, check readinessCheck) ComponentReadiness {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.run(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", h.timeout)
+	}
+
+	result := ComponentReadiness{
+		Component:  check.component,
+		Status:     "ok",
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = "fail"
+		result.Error = err.Error()
+		if errors.As(err, new(degradedError)) {
+			result.Status = "degraded"
+		}
+	}
+	return result
+}
+
+// degradedError marks a check result that should not make the pod unready
+type degradedError struct {
+	err error
+}
+
+func (e degradedError) Error() string { return e.err.Error() }
+func (e degradedError) Unwrap() error { return e.err }
+
+// checkSchema fails if any migration has not been applied to the database, or only
+// reports it as degraded if behindDegraded is set
+func checkSchema(migrations MigrationChecker, behindDegraded bool) error {
+	pending, err := migrations.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		versions := make([]string, 0, len(pending))
+		for _, migration := range pending {
+			versions = append(versions, fmt.Sprintf("%03d", migration.Version))
+		}
+		err := fmt.Errorf("%d pending migration(s): %s", len(pending), strings.Join(versions, ", "))
+		if behindDegraded {
+			return degradedError{err}
+		}
+		return err
+	}
+	return nil
+}
+
+// checkQueueBacklog fails if the queue cannot be read or holds more than maxPending due jobs
+func checkQueueBacklog(ctx context.Context, queue PendingJobCounter, maxPending int) error {
+	count, err := queue.CountPending(ctx)
+	if err != nil {
+		return err
+	}
+	if maxPending > 0 && count > maxPending {
+		return fmt.Errorf("%d pending jobs exceed the limit of %d", count, maxPending)
+	}
+	return nil
+}
+
+// checkCustomerAPIReachable fails if the Customer API host cannot be reached.
+// Any HTTP response counts as reachable, since the endpoint may reject the probe method.
+func checkCustomerAPIReachable(ctx context.Context, client *http.Client, method, url string) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/database"
+)
+
+// mockMigrationChecker returns a fixed list of pending migrations
+type mockMigrationChecker struct {
+	pending []database.Migration
+	err     error
+}
+
+func (m *mockMigrationChecker) Pending() ([]database.Migration, error) {
+	return m.pending, m.err
+}
+
+// mockPendingJobCounter returns a fixed job count, optionally after a delay
+type mockPendingJobCounter struct {
+	count int
+	err   error
+	delay time.Duration
+}
+
+func (m *mockPendingJobCounter) CountPending(ctx context.Context) (int, error) {
+	if m.delay > 0 {
+		select {
+		case <-time.After(m.delay):
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+	return m.count, m.err
+}
+
+// healthyReadinessConfig returns a config whose checks all pass against the given Customer API
+func healthyReadinessConfig(apiURL string) ReadinessConfig {
+	return ReadinessConfig{
+		Migrations:     &mockMigrationChecker{},
+		Queue:          &mockPendingJobCounter{count: 3},
+		MaxPendingJobs: 10,
+		CustomerAPIURL: apiURL,
+		CheckTimeout:   time.Second,
+	}
+}
+
+func serveReady(t *testing.T, handler *ReadinessHandler) (int, ReadinessResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleReady(rec, req)
+
+	var response ReadinessResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return rec.Code, response
+}
+
+func failedComponents(response ReadinessResponse) []string {
+	var failed []string
+	for _, check := range response.Checks {
+		if check.Status != "ok" {
+			failed = append(failed, check.Component)
+		}
+	}
+	return failed
+}
+
+func TestHandleReady_AllChecksPass(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("Expected HEAD probe, got %s", r.Method)
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer server.Close()
+
+	code, response := serveReady(t, NewReadinessHandler(healthyReadinessConfig(server.URL)))
+
+	if code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", code)
+	}
+	if response.Status != "ready" {
+		t.Errorf("Expected status ready, got %s", response.Status)
+	}
+	if len(response.Checks) != 3 {
+		t.Errorf("Expected 3 checks, got %+v", response.Checks)
+	}
+	if failed := failedComponents(response); len(failed) != 0 {
+		t.Errorf("Expected no failed components, got %v", failed)
+	}
+}
+
+func TestHandleReady_CheckFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	unreachable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	unreachableURL := unreachable.URL
+	unreachable.Close()
+
+	tests := []struct {
+		name      string
+		configure func(cfg *ReadinessConfig)
+		component string
+	}{
+		{
+			"pending migrations",
+			func(cfg *ReadinessConfig) {
+				cfg.Migrations = &mockMigrationChecker{pending: []database.Migration{{Version: 11, Name: "create_delivery_chain_attempts"}}}
+			},
+			ReadinessComponentSchema,
+		},
+		{
+			"schema query error",
+			func(cfg *ReadinessConfig) {
+				cfg.Migrations = &mockMigrationChecker{err: errors.New("relation schema_migrations does not exist")}
+			},
+			ReadinessComponentSchema,
+		},
+		{
+			"queue backlog too large",
+			func(cfg *ReadinessConfig) { cfg.Queue = &mockPendingJobCounter{count: 11} },
+			ReadinessComponentQueue,
+		},
+		{
+			"queue unavailable",
+			func(cfg *ReadinessConfig) { cfg.Queue = &mockPendingJobCounter{err: errors.New("connection refused")} },
+			ReadinessComponentQueue,
+		},
+		{
+			"queue check timeout",
+			func(cfg *ReadinessConfig) {
+				cfg.Queue = &mockPendingJobCounter{delay: time.Second}
+				cfg.CheckTimeout = 50 * time.Millisecond
+			},
+			ReadinessComponentQueue,
+		},
+		{
+			"customer api unreachable",
+			func(cfg *ReadinessConfig) { cfg.CustomerAPIURL = unreachableURL },
+			ReadinessComponentCustomerAPI,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := healthyReadinessConfig(server.URL)
+			tt.configure(&cfg)
+
+			code, response := serveReady(t, NewReadinessHandler(cfg))
+
+			if code != http.StatusServiceUnavailable {
+				t.Errorf("Expected status 503, got %d", code)
+			}
+			if response.Status != "not_ready" {
+				t.Errorf("Expected status not_ready, got %s", response.Status)
+			}
+			failed := failedComponents(response)
+			if len(failed) != 1 || failed[0] != tt.component {
+				t.Errorf("Expected only %s to fail, got %v", tt.component, failed)
+			}
+			for _, check := range response.Checks {
+				if check.Component == tt.component && check.Error == "" {
+					t.Errorf("Expected an error message for %s", tt.component)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleReady_SchemaBehindDegraded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	cfg := healthyReadinessConfig(server.URL)
+	cfg.Migrations = &mockMigrationChecker{pending: []database.Migration{{Version: 12, Name: "create_submission_throttle"}}}
+	cfg.SchemaBehindDegraded = true
+
+	code, response := serveReady(t, NewReadinessHandler(cfg))
+
+	if code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", code)
+	}
+	if response.Status != "degraded" {
+		t.Errorf("Expected status degraded, got %s", response.Status)
+	}
+	for _, check := range response.Checks {
+		if check.Component == ReadinessComponentSchema && check.Status != "degraded" {
+			t.Errorf("Expected %s to be degraded, got %+v", ReadinessComponentSchema, check)
+		}
+	}
+
+	// A failing check still makes the pod unready
+	cfg.Queue = &mockPendingJobCounter{err: errors.New("connection refused")}
+	code, response = serveReady(t, NewReadinessHandler(cfg))
+	if code != http.StatusServiceUnavailable || response.Status != "not_ready" {
+		t.Errorf("Expected 503 not_ready, got %d %s", code, response.Status)
+	}
+}
+
+func TestHandleReady_ChecksRunInParallel(t *testing.T) {
+	cfg := ReadinessConfig{
+		Migrations:   &mockMigrationChecker{},
+		Queue:        &mockPendingJobCounter{delay: 200 * time.Millisecond},
+		CheckTimeout: 100 * time.Millisecond,
+	}
+	// A second slow dependency must not add to the first one's time
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	cfg.CustomerAPIURL = slow.URL
+
+	start := time.Now()
+	code, response := serveReady(t, NewReadinessHandler(cfg))
+	elapsed := time.Since(start)
+
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", code)
+	}
+	if failed := failedComponents(response); len(failed) != 2 {
+		t.Errorf("Expected queue and customer_api to time out, got %v", failed)
+	}
+	if elapsed >= 200*time.Millisecond {
+		t.Errorf("Expected checks to time out in parallel, took %s", elapsed)
+	}
+}
+
+func TestHandleReady_OptionsProbeAndDisabledBacklogLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodOptions {
+			t.Errorf("Expected OPTIONS probe, got %s", r.Method)
+		}
+	}))
+	defer server.Close()
+
+	cfg := healthyReadinessConfig(server.URL)
+	cfg.CustomerAPIMethod = http.MethodOptions
+	cfg.Queue = &mockPendingJobCounter{count: 100000}
+	cfg.MaxPendingJobs = 0
+
+	if code, response := serveReady(t, NewReadinessHandler(cfg)); code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d: %+v", code, response.Checks)
+	}
+}
+
+func TestHandleReady_MethodNotAllowed(t *testing.T) {
+	handler := NewReadinessHandler(ReadinessConfig{})
+
+	req := httptest.NewRequest(http.MethodPost, "/health/ready", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleReady(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", rec.Code)
+	}
+}
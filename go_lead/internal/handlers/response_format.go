@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Response formats supported by the webhook endpoint
+const (
+	ResponseFormatJSON = "json"
+	ResponseFormatText = "text"
+	ResponseFormatXML  = "xml"
+)
+
+// responseFormatKey is the context key for the format explicitly requested via the Accept header
+type responseFormatKey struct{}
+
+// webhookAckXML is the minimal XML acknowledgement returned for accepted leads
+type webhookAckXML struct {
+	XMLName       xml.Name `xml:"ack"`
+	LeadID        int64    `xml:"lead_id"`
+	Status        string   `xml:"status"`
+	CorrelationID string   `xml:"correlation_id"`
+}
+
+// errorXML is the XML form of ErrorResponse
+type errorXML struct {
+	XMLName       xml.Name `xml:"error"`
+	Message       string   `xml:"message"`
+	Code          string   `xml:"code,omitempty"`
+	CorrelationID string   `xml:"correlation_id,omitempty"`
+}
+
+// negotiateResponseFormat returns the first supported format named in the Accept header,
+// or an empty string if the client did not ask for a specific one
+func negotiateResponseFormat(r *http.Request) string {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "application/json":
+			return ResponseFormatJSON
+		case "text/plain":
+			return ResponseFormatText
+		case "application/xml", "text/xml":
+			return ResponseFormatXML
+		}
+	}
+	return ""
+}
+
+// withResponseFormat stores the negotiated response format in ctx
+func withResponseFormat(ctx context.Context, format string) context.Context {
+	return context.WithValue(ctx, responseFormatKey{}, format)
+}
+
+// negotiatedResponseFormat returns the format stored by withResponseFormat, if any
+func negotiatedResponseFormat(ctx context.Context) string {
+	format, _ := ctx.Value(responseFormatKey{}).(string)
+	return format
+}
@@ -3,16 +3,37 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/models"
 	"github.com/checkfox/go_lead/internal/repository"
+	"github.com/checkfox/go_lead/internal/services"
 )
 
 // StatsHandler handles statistics and observability endpoints
 type StatsHandler struct {
 	leadRepo            repository.LeadRepository
 	deliveryAttemptRepo repository.DeliveryAttemptRepository
+	normalizer          *services.Normalizer
+	logObfuscator       *logger.LogObfuscator
+	historyMask         *logger.LogObfuscator
+	stuckLeads          StuckLeadSource // optional
+	normalizationAudit  repository.NormalizationAuditRepository // optional
+}
+
+// StuckLeadSource reports leads waiting in FAILED status for too long,
+// implemented by alerting.StuckLeadDetector
+type StuckLeadSource interface {
+	StuckLeads(ctx context.Context) (int, *models.StuckLead, error)
+	Threshold() time.Duration
 }
 
 // NewStatsHandler creates a new StatsHandler
@@ -20,15 +41,50 @@ func NewStatsHandler(leadRepo repository.LeadRepository, deliveryAttemptRepo rep
 	return &StatsHandler{
 		leadRepo:            leadRepo,
 		deliveryAttemptRepo: deliveryAttemptRepo,
+		normalizer:          services.NewNormalizer(),
+		historyMask:         logger.NewLogObfuscator(DefaultHistoryMaskedFields),
 	}
 }
 
+// DefaultHistoryMaskedFields are the contact fields whose values lead histories mask
+// unless SetHistoryMaskedFields configures others
+var DefaultHistoryMaskedFields = []string{"email", "phone"}
+
+// SetNormalizer sets the normalizer applied to contact search terms; it should
+// match the worker's so searches find the stored values
+func (h *StatsHandler) SetNormalizer(normalizer *services.Normalizer) {
+	h.normalizer = normalizer
+}
+
+// SetLogObfuscator sets the obfuscator that redacts the before/after values of the
+// normalization diff in lead histories
+func (h *StatsHandler) SetLogObfuscator(obfuscator *logger.LogObfuscator) {
+	h.logObfuscator = obfuscator
+}
+
+// SetHistoryMaskedFields sets the fields whose values are masked as REDACTED_<FIELD> in the
+// normalization diff and audit of lead histories, in addition to the obfuscated fields
+func (h *StatsHandler) SetHistoryMaskedFields(fields []string) {
+	h.historyMask = logger.NewLogObfuscator(fields)
+}
+
+// SetStuckLeadSource enables GET /stats/stuck-leads
+func (h *StatsHandler) SetStuckLeadSource(source StuckLeadSource) {
+	h.stuckLeads = source
+}
+
+// SetNormalizationAuditRepository adds the recorded normalization audit to lead histories
+func (h *StatsHandler) SetNormalizationAuditRepository(repo repository.NormalizationAuditRepository) {
+	h.normalizationAudit = repo
+}
+
 // LeadCountsByStatus represents lead counts grouped by status
 type LeadCountsByStatus struct {
 	Received           int `json:"received"`
 	Rejected           int `json:"rejected"`
 	Ready              int `json:"ready"`
 	Delivered          int `json:"delivered"`
+	DeliveredDuplicate int `json:"delivered_duplicate"`
 	Failed             int `json:"failed"`
 	PermanentlyFailed  int `json:"permanently_failed"`
 	Total              int `json:"total"`
@@ -52,6 +108,29 @@ type LeadHistoryResponse struct {
 	NormalizedPayload map[string]interface{}   `json:"normalized_payload,omitempty"`
 	CustomerPayload   map[string]interface{}   `json:"customer_payload,omitempty"`
 	DeliveryAttempts  []DeliveryAttemptSummary `json:"delivery_attempts"`
+
+	// NormalizationDiff lists the fields normalization changed, computed from
+	// RawPayload and NormalizedPayload; omitted if the lead is not normalized yet
+	NormalizationDiff []NormalizationChange `json:"normalization_diff,omitempty"`
+
+	// NormalizationAudit lists the field changes recorded each time the lead was normalized
+	// while PRIVACY_NORMALIZATION_AUDIT_ENABLED was set; omitted if there are none
+	NormalizationAudit []models.NormalizationAudit `json:"normalization_audit,omitempty"`
+}
+
+// NormalizationChange is a field whose value normalization changed. Field is a
+// dot-separated path; Before or After is null if normalization added or removed the field.
+type NormalizationChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// StuckLeadsResponse reports the leads waiting in FAILED status longer than the threshold
+type StuckLeadsResponse struct {
+	Count     int               `json:"count"`
+	Threshold string            `json:"threshold"`
+	Oldest    *models.StuckLead `json:"oldest,omitempty"`
 }
 
 // DeliveryAttemptSummary represents a summary of a delivery attempt
@@ -61,9 +140,12 @@ type DeliveryAttemptSummary struct {
 	Success      bool    `json:"success"`
 	StatusCode   *int    `json:"status_code,omitempty"`
 	ErrorMessage *string `json:"error_message,omitempty"`
+	EndpointUsed string  `json:"endpoint_used,omitempty"` // primary or fallback
 }
 
 // HandleLeadCountsByStatus handles GET /stats/leads/counts
+// With ?source_id= the counts are restricted to that source; with ?group_by=source
+// the response maps every source ID to its counts.
 // Requirements: 8.3
 func (h *StatsHandler) HandleLeadCountsByStatus(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -76,34 +158,70 @@ func (h *StatsHandler) HandleLeadCountsByStatus(w http.ResponseWriter, r *http.R
 		return
 	}
 	
+	query := r.URL.Query()
+	sourceID := query.Get("source_id")
+	groupBy := query.Get("group_by")
+	if groupBy != "" && groupBy != "source" {
+		http.Error(w, "group_by must be 'source'", http.StatusBadRequest)
+		return
+	}
+	
+	if groupBy == "source" && sourceID == "" {
+		countsBySource, err := h.leadRepo.GetLeadCountsByStatusPerSource(ctx)
+		if err != nil {
+			logger.LogError(ctx, "Failed to get lead counts per source", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		
+		response := make(map[string]LeadCountsByStatus, len(countsBySource))
+		for source, counts := range countsBySource {
+			response[source] = newLeadCountsByStatus(counts)
+		}
+		
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+	
 	// Get counts from repository
-	counts, err := h.leadRepo.GetLeadCountsByStatus(ctx)
+	var counts map[string]int
+	var err error
+	if sourceID != "" {
+		counts, err = h.leadRepo.GetLeadCountsByStatusForSource(ctx, sourceID)
+	} else {
+		counts, err = h.leadRepo.GetLeadCountsByStatus(ctx)
+	}
 	if err != nil {
 		logger.LogError(ctx, "Failed to get lead counts", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newLeadCountsByStatus(counts))
+}
+
+// newLeadCountsByStatus builds the counts response from per-status counts
+func newLeadCountsByStatus(counts map[string]int) LeadCountsByStatus {
 	// Calculate total
 	total := 0
 	for _, count := range counts {
 		total += count
 	}
 	
-	// Build response
-	response := LeadCountsByStatus{
-		Received:          counts["RECEIVED"],
-		Rejected:          counts["REJECTED"],
-		Ready:             counts["READY"],
-		Delivered:         counts["DELIVERED"],
-		Failed:            counts["FAILED"],
-		PermanentlyFailed: counts["PERMANENTLY_FAILED"],
-		Total:             total,
+	return LeadCountsByStatus{
+		Received:           counts["RECEIVED"],
+		Rejected:           counts["REJECTED"],
+		Ready:              counts["READY"],
+		Delivered:          counts["DELIVERED"],
+		DeliveredDuplicate: counts["DELIVERED_DUPLICATE"],
+		Failed:             counts["FAILED"],
+		PermanentlyFailed:  counts["PERMANENTLY_FAILED"],
+		Total:              total,
 	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
 }
 
 // HandleRecentLeads handles GET /stats/leads/recent
@@ -168,9 +286,13 @@ func (h *StatsHandler) HandleLeadHistory(w http.ResponseWriter, r *http.Request)
 	
 	// Get lead from repository
 	lead, err := h.leadRepo.GetLeadByID(ctx, leadID)
+	if errors.Is(err, repository.ErrLeadNotFound) {
+		http.Error(w, "lead not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		logger.LogError(ctx, "Failed to get lead", err)
-		http.Error(w, "lead not found", http.StatusNotFound)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 	
@@ -191,6 +313,7 @@ func (h *StatsHandler) HandleLeadHistory(w http.ResponseWriter, r *http.Request)
 			Success:      attempt.Success,
 			StatusCode:   attempt.ResponseStatus,
 			ErrorMessage: attempt.ErrorMessage,
+			EndpointUsed: attempt.EndpointUsed,
 		}
 		attemptSummaries = append(attemptSummaries, summary)
 	}
@@ -205,17 +328,182 @@ func (h *StatsHandler) HandleLeadHistory(w http.ResponseWriter, r *http.Request)
 		CustomerPayload:   lead.CustomerPayload,
 		DeliveryAttempts:  attemptSummaries,
 	}
+	if lead.NormalizedPayload != nil {
+		response.NormalizationDiff = h.normalizationDiff(lead.RawPayload, lead.NormalizedPayload)
+	}
+	if h.normalizationAudit != nil {
+		audits, err := h.normalizationAudit.GetNormalizationAudit(ctx, leadID)
+		if err != nil {
+			logger.LogError(ctx, "Failed to get normalization audit", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		for i := range audits {
+			audits[i].OriginalValue = h.maskHistoryValue(audits[i].Field, audits[i].OriginalValue)
+			audits[i].NormalizedValue = h.maskHistoryValue(audits[i].Field, audits[i].NormalizedValue)
+		}
+		response.NormalizationAudit = audits
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// HandleStuckLeads handles GET /stats/stuck-leads
+func (h *StatsHandler) HandleStuckLeads(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.stuckLeads == nil {
+		http.Error(w, "stuck lead detection is not configured", http.StatusNotFound)
+		return
+	}
+
+	count, oldest, err := h.stuckLeads.StuckLeads(ctx)
+	if err != nil {
+		logger.LogError(ctx, "Failed to get stuck leads", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := StuckLeadsResponse{
+		Count:     count,
+		Threshold: h.stuckLeads.Threshold().String(),
+		Oldest:    oldest,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// maxSearchResults caps the number of leads returned by the contact search
+const maxSearchResults = 50
+
+// HandleSearchLeads handles GET /stats/leads/search?email=&phone=
+func (h *StatsHandler) HandleSearchLeads(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	
+	// Normalize search terms the same way the worker normalizes payloads
+	query := r.URL.Query()
+	email := ""
+	if raw := query.Get("email"); raw != "" {
+		email = h.normalizer.NormalizeEmail(raw)
+	}
+	phone := ""
+	if raw := query.Get("phone"); raw != "" {
+		phone = h.normalizer.NormalizePhone(raw)
+	}
+	
+	if email == "" && phone == "" {
+		http.Error(w, "email or phone query parameter is required", http.StatusBadRequest)
+		return
+	}
+	
+	logger.Info(ctx, "Searching leads by contact")
+	
+	leads, err := h.leadRepo.FindLeadsByContact(ctx, email, phone, maxSearchResults)
+	if err != nil {
+		logger.LogError(ctx, "Failed to search leads", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	
+	// Build response
+	response := make([]RecentLeadSummary, 0, len(leads))
+	for _, lead := range leads {
+		response = append(response, RecentLeadSummary{
+			ID:              lead.ID,
+			ReceivedAt:      lead.ReceivedAt.Format("2006-01-02T15:04:05Z07:00"),
+			Status:          string(lead.Status),
+			RejectionReason: lead.RejectionReason,
+		})
+	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
 
-// extractLeadIDFromPath extracts the lead ID from a URL path like /stats/leads/123/history
+// normalizationDiff returns the fields whose values differ between the raw and the
+// normalized payload, sorted by field, with the values of masked and obfuscated fields redacted
+func (h *StatsHandler) normalizationDiff(raw, normalized map[string]interface{}) []NormalizationChange {
+	changes := []NormalizationChange{}
+	diffPayloads("", raw, normalized, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	for i := range changes {
+		if changes[i].Before != nil {
+			changes[i].Before = h.maskHistoryValue(changes[i].Field, changes[i].Before)
+		}
+		if changes[i].After != nil {
+			changes[i].After = h.maskHistoryValue(changes[i].Field, changes[i].After)
+		}
+	}
+	return changes
+}
+
+// maskHistoryValue returns the value of a field as shown in a lead history, redacted if
+// the field is masked or obfuscated
+func (h *StatsHandler) maskHistoryValue(field string, value interface{}) interface{} {
+	return h.logObfuscator.Value(field, h.historyMask.Value(field, value))
+}
+
+// diffPayloads appends the changed leaf values of before and after under prefix to changes.
+// Nested objects are compared field by field; other values, including arrays, as a whole.
+func diffPayloads(prefix string, before, after map[string]interface{}, changes *[]NormalizationChange) {
+	keys := make(map[string]bool, len(before)+len(after))
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		field := key
+		if prefix != "" {
+			field = fmt.Sprintf("%s.%s", prefix, key)
+		}
+		beforeValue, afterValue := before[key], after[key]
+
+		beforeObject, beforeIsObject := beforeValue.(map[string]interface{})
+		afterObject, afterIsObject := afterValue.(map[string]interface{})
+		if beforeIsObject && afterIsObject {
+			diffPayloads(field, beforeObject, afterObject, changes)
+			continue
+		}
+		if !reflect.DeepEqual(beforeValue, afterValue) {
+			*changes = append(*changes, NormalizationChange{Field: field, Before: beforeValue, After: afterValue})
+		}
+	}
+}
+
+// extractLeadIDFromPath extracts the lead ID from a URL path like /stats/leads/123/history.
+// Returns 0 if the path does not have that form.
 func extractLeadIDFromPath(path string) int64 {
-	// Simple implementation - in production, use a proper router
-	var leadID int64
-	// Expected format: /stats/leads/{id}/history
-	// This is a placeholder - proper implementation would use a router
+	rest, ok := strings.CutPrefix(path, "/stats/leads/")
+	if !ok {
+		return 0
+	}
+	idPart, suffix, _ := strings.Cut(rest, "/")
+	if suffix != "history" {
+		return 0
+	}
+	leadID, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil || leadID <= 0 {
+		return 0
+	}
 	return leadID
 }
@@ -1,16 +1,22 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/checkfox/go_lead/internal/encryption"
 	"github.com/checkfox/go_lead/internal/logger"
 	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/repository"
 )
 
 func init() {
@@ -22,6 +28,9 @@ func init() {
 type mockLeadRepoForStats struct {
 	leads       []*models.InboundLead
 	countsByStatus map[string]int
+	countsBySource map[string]map[string]int
+	searchEmail string
+	searchPhone string
 }
 
 func (m *mockLeadRepoForStats) CreateLead(ctx context.Context, lead *models.InboundLead) error {
@@ -34,17 +43,25 @@ func (m *mockLeadRepoForStats) GetLeadByID(ctx context.Context, id int64) (*mode
 			return lead, nil
 		}
 	}
-	return nil, sql.ErrNoRows
+	return nil, fmt.Errorf("%w: %d", repository.ErrLeadNotFound, id)
 }
 
 func (m *mockLeadRepoForStats) UpdateLeadStatus(ctx context.Context, id int64, status models.LeadStatus) error {
 	return nil
 }
 
+func (m *mockLeadRepoForStats) UpdateLeadStatusFrom(ctx context.Context, id int64, from, to models.LeadStatus) error {
+	return nil
+}
+
 func (m *mockLeadRepoForStats) UpdateLeadWithPayloads(ctx context.Context, id int64, normalizedPayload, customerPayload models.JSONB) error {
 	return nil
 }
 
+func (m *mockLeadRepoForStats) UpdateLeadAttachments(ctx context.Context, id int64, metadata models.JSONB, attachmentKeys []string) error {
+	return nil
+}
+
 func (m *mockLeadRepoForStats) UpdateLeadRejection(ctx context.Context, id int64, reason models.RejectionReason) error {
 	return nil
 }
@@ -57,10 +74,26 @@ func (m *mockLeadRepoForStats) UpdateLeadStatusTx(ctx context.Context, tx *sql.T
 	return nil
 }
 
+func (m *mockLeadRepoForStats) UpdateLeadStatusFromTx(ctx context.Context, tx *sql.Tx, id int64, from, to models.LeadStatus) error {
+	return nil
+}
+
 func (m *mockLeadRepoForStats) GetLeadCountsByStatus(ctx context.Context) (map[string]int, error) {
 	return m.countsByStatus, nil
 }
 
+func (m *mockLeadRepoForStats) GetLeadCountsByStatusForSource(ctx context.Context, sourceID string) (map[string]int, error) {
+	return m.countsBySource[sourceID], nil
+}
+
+func (m *mockLeadRepoForStats) GetLeadCountsByStatusPerSource(ctx context.Context) (map[string]map[string]int, error) {
+	return m.countsBySource, nil
+}
+
+func (m *mockLeadRepoForStats) CountLeadsForSourceSince(ctx context.Context, sourceID string, since time.Time) (int, error) {
+	return 0, nil
+}
+
 func (m *mockLeadRepoForStats) GetRecentLeads(ctx context.Context, limit int) ([]*models.InboundLead, error) {
 	if len(m.leads) <= limit {
 		return m.leads, nil
@@ -68,6 +101,31 @@ func (m *mockLeadRepoForStats) GetRecentLeads(ctx context.Context, limit int) ([
 	return m.leads[:limit], nil
 }
 
+func (m *mockLeadRepoForStats) FindLeadsByContact(ctx context.Context, email, phone string, limit int) ([]*models.InboundLead, error) {
+	m.searchEmail = email
+	m.searchPhone = phone
+	
+	matches := []*models.InboundLead{}
+	for _, lead := range m.leads {
+		if (email != "" && lead.NormalizedPayload["email"] == email) ||
+			(phone != "" && lead.NormalizedPayload["phone"] == phone) {
+			matches = append(matches, lead)
+		}
+	}
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+func (m *mockLeadRepoForStats) FindLeadByPayloadHash(ctx context.Context, sourceID *string, payloadHash string, since time.Time) (*models.InboundLead, error) {
+	return nil, nil
+}
+
+func (m *mockLeadRepoForStats) FindInSuppressionList(ctx context.Context, phone, email string) (*models.SuppressionEntry, error) {
+	return nil, nil
+}
+
 // mockDeliveryAttemptRepoForStats is a mock implementation of DeliveryAttemptRepository for testing stats
 type mockDeliveryAttemptRepoForStats struct {
 	attempts map[int64][]*models.DeliveryAttempt
@@ -99,6 +157,14 @@ func (m *mockDeliveryAttemptRepoForStats) CountDeliveryAttempts(ctx context.Cont
 	return 0, nil
 }
 
+func (m *mockDeliveryAttemptRepoForStats) PruneDeliveryAttempts(ctx context.Context, leadID int64, keep int) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockDeliveryAttemptRepoForStats) GetAttemptsByResponseHash(ctx context.Context, hash string) ([]*models.DeliveryAttempt, error) {
+	return nil, nil
+}
+
 // TestHandleLeadCountsByStatus tests the lead counts endpoint
 // Requirements: 8.3
 func TestHandleLeadCountsByStatus(t *testing.T) {
@@ -161,6 +227,73 @@ func TestHandleLeadCountsByStatus(t *testing.T) {
 	}
 }
 
+// TestHandleLeadCountsByStatus_FilteredBySource tests the per-source lead counts
+func TestHandleLeadCountsByStatus_FilteredBySource(t *testing.T) {
+	mockRepo := &mockLeadRepoForStats{
+		countsByStatus: map[string]int{"RECEIVED": 7, "DELIVERED": 9},
+		countsBySource: map[string]map[string]int{
+			"partner-a": {"RECEIVED": 4, "DELIVERED": 6},
+			"partner-b": {"RECEIVED": 3, "DELIVERED": 3},
+		},
+	}
+	
+	handler := NewStatsHandler(mockRepo, &mockDeliveryAttemptRepoForStats{})
+	
+	req := httptest.NewRequest(http.MethodGet, "/stats/leads/counts?source_id=partner-a", nil)
+	w := httptest.NewRecorder()
+	handler.HandleLeadCountsByStatus(w, req)
+	
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	
+	var response LeadCountsByStatus
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Received != 4 || response.Delivered != 6 || response.Total != 10 {
+		t.Errorf("Expected partner-a counts (4 received, 6 delivered), got %+v", response)
+	}
+}
+
+// TestHandleLeadCountsByStatus_GroupedBySource tests the counts of all sources
+func TestHandleLeadCountsByStatus_GroupedBySource(t *testing.T) {
+	mockRepo := &mockLeadRepoForStats{
+		countsBySource: map[string]map[string]int{
+			"partner-a": {"RECEIVED": 4, "DELIVERED": 6},
+			"partner-b": {"REJECTED": 2},
+		},
+	}
+	
+	handler := NewStatsHandler(mockRepo, &mockDeliveryAttemptRepoForStats{})
+	
+	req := httptest.NewRequest(http.MethodGet, "/stats/leads/counts?group_by=source", nil)
+	w := httptest.NewRecorder()
+	handler.HandleLeadCountsByStatus(w, req)
+	
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	
+	var response map[string]LeadCountsByStatus
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response) != 2 {
+		t.Fatalf("Expected 2 sources, got %v", response)
+	}
+	if response["partner-a"].Total != 10 || response["partner-b"].Rejected != 2 {
+		t.Errorf("Unexpected per-source counts: %+v", response)
+	}
+	
+	req = httptest.NewRequest(http.MethodGet, "/stats/leads/counts?group_by=status", nil)
+	w = httptest.NewRecorder()
+	handler.HandleLeadCountsByStatus(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unsupported group_by, got %d", w.Code)
+	}
+}
+
 // TestHandleRecentLeads tests the recent leads endpoint
 // Requirements: 8.4
 func TestHandleRecentLeads(t *testing.T) {
@@ -269,12 +402,291 @@ func TestHandleLeadHistory(t *testing.T) {
 	
 	handler := NewStatsHandler(mockLeadRepo, mockAttemptRepo)
 	
-	// Note: This test is simplified because extractLeadIDFromPath is a placeholder
-	// In a real implementation with a proper router, we would test the full flow
-	
-	// For now, we can test that the handler exists and has the right signature
-	if handler == nil {
-		t.Error("Expected handler to be created")
+	req := httptest.NewRequest(http.MethodGet, "/stats/leads/123/history", nil)
+	w := httptest.NewRecorder()
+	handler.HandleLeadHistory(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var response LeadHistoryResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.ID != 123 || len(response.DeliveryAttempts) != 1 {
+		t.Errorf("Expected lead 123 with 1 delivery attempt, got %+v", response)
+	}
+	if len(response.NormalizationDiff) != 0 {
+		t.Errorf("Expected no normalization diff for an unchanged payload, got %+v", response.NormalizationDiff)
+	}
+}
+
+// failingLeadRepoForStats fails every lead lookup with err
+type failingLeadRepoForStats struct {
+	*mockLeadRepoForStats
+	err error
+}
+
+func (m *failingLeadRepoForStats) GetLeadByID(ctx context.Context, id int64) (*models.InboundLead, error) {
+	return nil, m.err
+}
+
+func TestHandleLeadHistory_LookupErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		leadRepo repository.LeadRepository
+		expected int
+	}{
+		{"unknown lead", &mockLeadRepoForStats{}, http.StatusNotFound},
+		{"database error", &failingLeadRepoForStats{&mockLeadRepoForStats{}, sql.ErrConnDone}, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewStatsHandler(tt.leadRepo, &mockDeliveryAttemptRepoForStats{})
+
+			req := httptest.NewRequest(http.MethodGet, "/stats/leads/123/history", nil)
+			w := httptest.NewRecorder()
+			handler.HandleLeadHistory(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
+
+// newNormalizedLeadRepo returns a lead whose email and phone were changed by normalization
+func newNormalizedLeadRepo() *mockLeadRepoForStats {
+	return &mockLeadRepoForStats{
+		leads: []*models.InboundLead{
+			{
+				ID:         7,
+				ReceivedAt: time.Now(),
+				Status:     models.LeadStatusReady,
+				RawPayload: models.JSONB{
+					"email":   " Max.Mustermann@Example.COM",
+					"phone":   "0151 1234-5678",
+					"zipcode": "66123",
+					"house":   map[string]interface{}{"is_owner": true},
+				},
+				NormalizedPayload: models.JSONB{
+					"email":   "max.mustermann@example.com",
+					"phone":   "+4915112345678",
+					"zipcode": "66123",
+					"house":   map[string]interface{}{"is_owner": true},
+				},
+			},
+		},
+	}
+}
+
+func TestHandleLeadHistory_NormalizationDiff(t *testing.T) {
+	handler := NewStatsHandler(newNormalizedLeadRepo(), &mockDeliveryAttemptRepoForStats{})
+	handler.SetHistoryMaskedFields(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/stats/leads/7/history", nil)
+	w := httptest.NewRecorder()
+	handler.HandleLeadHistory(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var response LeadHistoryResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	expected := []NormalizationChange{
+		{Field: "email", Before: " Max.Mustermann@Example.COM", After: "max.mustermann@example.com"},
+		{Field: "phone", Before: "0151 1234-5678", After: "+4915112345678"},
+	}
+	if !reflect.DeepEqual(response.NormalizationDiff, expected) {
+		t.Errorf("Expected diff %+v, got %+v", expected, response.NormalizationDiff)
+	}
+}
+
+func TestHandleLeadHistory_NormalizationDiffMaskedByDefault(t *testing.T) {
+	handler := NewStatsHandler(newNormalizedLeadRepo(), &mockDeliveryAttemptRepoForStats{})
+
+	req := httptest.NewRequest(http.MethodGet, "/stats/leads/7/history", nil)
+	w := httptest.NewRecorder()
+	handler.HandleLeadHistory(w, req)
+
+	var response LeadHistoryResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	expected := []NormalizationChange{
+		{Field: "email", Before: "REDACTED_EMAIL", After: "REDACTED_EMAIL"},
+		{Field: "phone", Before: "REDACTED_PHONE", After: "REDACTED_PHONE"},
+	}
+	if !reflect.DeepEqual(response.NormalizationDiff, expected) {
+		t.Errorf("Expected the contact fields to be masked, got %+v", response.NormalizationDiff)
+	}
+}
+
+func TestHandleLeadHistory_NormalizationDiffRedacted(t *testing.T) {
+	handler := NewStatsHandler(newNormalizedLeadRepo(), &mockDeliveryAttemptRepoForStats{})
+	handler.SetHistoryMaskedFields(nil)
+	handler.SetLogObfuscator(logger.NewLogObfuscator([]string{"email"}))
+
+	req := httptest.NewRequest(http.MethodGet, "/stats/leads/7/history", nil)
+	w := httptest.NewRecorder()
+	handler.HandleLeadHistory(w, req)
+
+	var response LeadHistoryResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.NormalizationDiff) != 2 {
+		t.Fatalf("Expected 2 changed fields, got %+v", response.NormalizationDiff)
+	}
+	if change := response.NormalizationDiff[0]; change.Before != "REDACTED_EMAIL" || change.After != "REDACTED_EMAIL" {
+		t.Errorf("Expected the email values to be redacted, got %+v", change)
+	}
+	if change := response.NormalizationDiff[1]; change.After != "+4915112345678" {
+		t.Errorf("Expected the phone values not to be redacted, got %+v", change)
+	}
+}
+
+// fakeNormalizationAuditRepo serves fixed normalization audits per lead
+type fakeNormalizationAuditRepo struct {
+	audits map[int64][]models.NormalizationAudit
+}
+
+func (r *fakeNormalizationAuditRepo) CreateNormalizationAudits(ctx context.Context, leadID int64, audits []models.NormalizationAudit) error {
+	r.audits[leadID] = append(r.audits[leadID], audits...)
+	return nil
+}
+
+func (r *fakeNormalizationAuditRepo) GetNormalizationAudit(ctx context.Context, leadID int64) ([]models.NormalizationAudit, error) {
+	return append([]models.NormalizationAudit{}, r.audits[leadID]...), nil
+}
+
+func TestHandleLeadHistory_NormalizationAudit(t *testing.T) {
+	handler := NewStatsHandler(newNormalizedLeadRepo(), &mockDeliveryAttemptRepoForStats{})
+	handler.SetHistoryMaskedFields([]string{"phone"})
+	handler.SetLogObfuscator(logger.NewLogObfuscator([]string{"email"}))
+	handler.SetNormalizationAuditRepository(&fakeNormalizationAuditRepo{audits: map[int64][]models.NormalizationAudit{
+		7: {
+			{LeadID: 7, Field: "email", OriginalValue: " Max.Mustermann@Example.COM", NormalizedValue: "max.mustermann@example.com", TransformerApplied: "email"},
+			{LeadID: 7, Field: "phone", OriginalValue: "0151 1234-5678", NormalizedValue: "+4915112345678", TransformerApplied: "phone"},
+		},
+	}})
+
+	req := httptest.NewRequest(http.MethodGet, "/stats/leads/7/history", nil)
+	w := httptest.NewRecorder()
+	handler.HandleLeadHistory(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var response LeadHistoryResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.NormalizationAudit) != 2 {
+		t.Fatalf("Expected 2 audit records, got %+v", response.NormalizationAudit)
+	}
+	if audit := response.NormalizationAudit[0]; audit.OriginalValue != "REDACTED_EMAIL" || audit.NormalizedValue != "REDACTED_EMAIL" {
+		t.Errorf("Expected the email values to be redacted, got %+v", audit)
+	}
+	if audit := response.NormalizationAudit[1]; audit.OriginalValue != "REDACTED_PHONE" || audit.NormalizedValue != "REDACTED_PHONE" || audit.TransformerApplied != "phone" {
+		t.Errorf("Expected the phone values to be masked, got %+v", audit)
+	}
+
+	// Without recorded changes the field is omitted
+	handler.SetNormalizationAuditRepository(&fakeNormalizationAuditRepo{})
+	w = httptest.NewRecorder()
+	handler.HandleLeadHistory(w, httptest.NewRequest(http.MethodGet, "/stats/leads/7/history", nil))
+	if strings.Contains(w.Body.String(), "normalization_audit") {
+		t.Errorf("Expected no normalization_audit field, got %s", w.Body.String())
+	}
+}
+
+func TestHandleLeadHistory_EncryptedPayloads(t *testing.T) {
+	encryptor, err := encryption.NewFieldEncryptor(bytes.Repeat([]byte{3}, 32), []string{"email", "phone"})
+	if err != nil {
+		t.Fatalf("Failed to create encryptor: %v", err)
+	}
+
+	// Store the lead as the encrypted repository writes it to the database
+	stored := newNormalizedLeadRepo()
+	lead := stored.leads[0]
+	if lead.RawPayload, err = encryptor.EncryptPayload(lead.RawPayload); err != nil {
+		t.Fatalf("Failed to encrypt payload: %v", err)
+	}
+	if lead.NormalizedPayload, err = encryptor.EncryptPayload(lead.NormalizedPayload); err != nil {
+		t.Fatalf("Failed to encrypt payload: %v", err)
+	}
+	if !encryption.IsEncrypted(lead.RawPayload["email"]) {
+		t.Fatalf("Expected the stored email to be ciphertext, got %v", lead.RawPayload["email"])
+	}
+
+	handler := NewStatsHandler(repository.NewEncryptedLeadRepository(stored, encryptor), &mockDeliveryAttemptRepoForStats{})
+	req := httptest.NewRequest(http.MethodGet, "/stats/leads/7/history", nil)
+	w := httptest.NewRecorder()
+	handler.HandleLeadHistory(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), encryption.Marker) {
+		t.Errorf("Expected no ciphertext in the history, got %s", w.Body.String())
+	}
+	var response LeadHistoryResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.RawPayload["email"] != " Max.Mustermann@Example.COM" || response.NormalizedPayload["phone"] != "+4915112345678" {
+		t.Errorf("Expected decrypted payloads, got %v and %v", response.RawPayload, response.NormalizedPayload)
+	}
+	if len(response.NormalizationDiff) != 2 {
+		t.Errorf("Expected the diff of the decrypted payloads, got %+v", response.NormalizationDiff)
+	}
+}
+
+func TestDiffPayloads_NestedAndAddedFields(t *testing.T) {
+	before := map[string]interface{}{
+		"address": map[string]interface{}{"street": "Hauptstr. 1 ", "zip": "66123"},
+		"notes":   "",
+	}
+	after := map[string]interface{}{
+		"address": map[string]interface{}{"street": "Hauptstr. 1", "zip": "66123"},
+		"country": "DE",
+	}
+
+	handler := NewStatsHandler(&mockLeadRepoForStats{}, &mockDeliveryAttemptRepoForStats{})
+	changes := handler.normalizationDiff(before, after)
+
+	expected := []NormalizationChange{
+		{Field: "address.street", Before: "Hauptstr. 1 ", After: "Hauptstr. 1"},
+		{Field: "country", Before: nil, After: "DE"},
+		{Field: "notes", Before: "", After: nil},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, changes)
+	}
+}
+
+func TestExtractLeadIDFromPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected int64
+	}{
+		{"/stats/leads/123/history", 123},
+		{"/stats/leads/123", 0},
+		{"/stats/leads/abc/history", 0},
+		{"/stats/leads/-1/history", 0},
+		{"/stats/leads/123/history/extra", 0},
+		{"/admin/leads/123/history", 0},
+	}
+
+	for _, tt := range tests {
+		if got := extractLeadIDFromPath(tt.path); got != tt.expected {
+			t.Errorf("extractLeadIDFromPath(%q) = %d, expected %d", tt.path, got, tt.expected)
+		}
 	}
 }
 
@@ -309,7 +721,155 @@ func TestStatisticsQueryAccuracy(t *testing.T) {
 	}
 }
 
+// TestHandleSearchLeads tests the contact search endpoint
+func TestHandleSearchLeads(t *testing.T) {
+	now := time.Now()
+	mockRepo := &mockLeadRepoForStats{
+		leads: []*models.InboundLead{
+			{
+				ID:                1,
+				ReceivedAt:        now,
+				Status:            models.LeadStatusDelivered,
+				NormalizedPayload: models.JSONB{"email": "anna@example.com", "phone": "491701234567"},
+			},
+			{
+				ID:                2,
+				ReceivedAt:        now,
+				Status:            models.LeadStatusReady,
+				NormalizedPayload: models.JSONB{"email": "ben@example.com", "phone": "491709999999"},
+			},
+		},
+	}
+	
+	handler := NewStatsHandler(mockRepo, &mockDeliveryAttemptRepoForStats{})
+	
+	req := httptest.NewRequest(http.MethodGet, "/stats/leads/search?email=%20Anna@Example.com%20", nil)
+	w := httptest.NewRecorder()
+	handler.HandleSearchLeads(w, req)
+	
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	
+	// Search terms are normalized before querying
+	if mockRepo.searchEmail != "anna@example.com" {
+		t.Errorf("Expected normalized email 'anna@example.com', got '%s'", mockRepo.searchEmail)
+	}
+	
+	var response []RecentLeadSummary
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	
+	if len(response) != 1 || response[0].ID != 1 {
+		t.Errorf("Expected only lead 1 to match, got %+v", response)
+	}
+	
+	// Phone search strips formatting
+	req = httptest.NewRequest(http.MethodGet, "/stats/leads/search?phone=%2B49%20170%20999%209999", nil)
+	w = httptest.NewRecorder()
+	handler.HandleSearchLeads(w, req)
+	
+	if mockRepo.searchPhone != "491709999999" {
+		t.Errorf("Expected normalized phone '491709999999', got '%s'", mockRepo.searchPhone)
+	}
+	
+	response = nil
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	
+	if len(response) != 1 || response[0].ID != 2 {
+		t.Errorf("Expected only lead 2 to match, got %+v", response)
+	}
+}
+
+// TestHandleSearchLeads_MissingParameters tests that a search term is required
+func TestHandleSearchLeads_MissingParameters(t *testing.T) {
+	handler := NewStatsHandler(&mockLeadRepoForStats{}, &mockDeliveryAttemptRepoForStats{})
+	
+	req := httptest.NewRequest(http.MethodGet, "/stats/leads/search", nil)
+	w := httptest.NewRecorder()
+	handler.HandleSearchLeads(w, req)
+	
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
 // stringPtr is a helper function to create a string pointer
 func stringPtr(s string) *string {
 	return &s
 }
+
+// staticStuckLeads reports a fixed set of stuck leads
+type staticStuckLeads struct {
+	count  int
+	oldest *models.StuckLead
+	err    error
+}
+
+func (s staticStuckLeads) StuckLeads(ctx context.Context) (int, *models.StuckLead, error) {
+	return s.count, s.oldest, s.err
+}
+
+func (s staticStuckLeads) Threshold() time.Duration {
+	return time.Hour
+}
+
+func TestHandleStuckLeads(t *testing.T) {
+	lastError := "connection refused"
+	updatedAt := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	handler := NewStatsHandler(&mockLeadRepoForStats{}, &mockDeliveryAttemptRepoForStats{})
+	handler.SetStuckLeadSource(staticStuckLeads{count: 3, oldest: &models.StuckLead{LeadID: 17, UpdatedAt: updatedAt, LastError: &lastError}})
+
+	w := httptest.NewRecorder()
+	handler.HandleStuckLeads(w, httptest.NewRequest(http.MethodGet, "/stats/stuck-leads", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var response StuckLeadsResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Count != 3 || response.Threshold != "1h0m0s" {
+		t.Errorf("Expected 3 stuck leads over 1h, got %+v", response)
+	}
+	if response.Oldest == nil || response.Oldest.LeadID != 17 || !response.Oldest.UpdatedAt.Equal(updatedAt) ||
+		response.Oldest.LastError == nil || *response.Oldest.LastError != lastError {
+		t.Errorf("Expected lead 17 as the oldest stuck lead, got %+v", response.Oldest)
+	}
+}
+
+func TestHandleStuckLeads_None(t *testing.T) {
+	handler := NewStatsHandler(&mockLeadRepoForStats{}, &mockDeliveryAttemptRepoForStats{})
+	handler.SetStuckLeadSource(staticStuckLeads{})
+
+	w := httptest.NewRecorder()
+	handler.HandleStuckLeads(w, httptest.NewRequest(http.MethodGet, "/stats/stuck-leads", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if body := w.Body.String(); strings.Contains(body, "oldest") || !strings.Contains(body, `"count":0`) {
+		t.Errorf("Expected a zero count without an oldest lead, got %s", body)
+	}
+}
+
+func TestHandleStuckLeads_Errors(t *testing.T) {
+	handler := NewStatsHandler(&mockLeadRepoForStats{}, &mockDeliveryAttemptRepoForStats{})
+
+	w := httptest.NewRecorder()
+	handler.HandleStuckLeads(w, httptest.NewRequest(http.MethodGet, "/stats/stuck-leads", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without a stuck lead source, got %d", w.Code)
+	}
+
+	handler.SetStuckLeadSource(staticStuckLeads{err: sql.ErrConnDone})
+	w = httptest.NewRecorder()
+	handler.HandleStuckLeads(w, httptest.NewRequest(http.MethodGet, "/stats/stuck-leads", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 on a repository error, got %d", w.Code)
+	}
+}
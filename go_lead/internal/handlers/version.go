@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/checkfox/go_lead/internal/logger"
+)
+
+// Schema states reported by the version endpoint
+const (
+	SchemaStatusCurrent = "current" // every migration shipped with the application is applied
+	SchemaStatusBehind  = "behind"  // migrations shipped with the application are missing
+	SchemaStatusAhead   = "ahead"   // a newer release has migrated the database, e.g. during a rolling deploy
+)
+
+// SchemaVersionReader reports the applied and the expected schema version,
+// implemented by database.MigrationRunner
+type SchemaVersionReader interface {
+	CurrentVersion() (int, error)
+	LatestVersion() (int, error)
+}
+
+// VersionHandler reports which application and schema version an instance runs
+type VersionHandler struct {
+	appVersion string
+	schema     SchemaVersionReader
+}
+
+// NewVersionHandler creates a new VersionHandler
+func NewVersionHandler(appVersion string, schema SchemaVersionReader) *VersionHandler {
+	return &VersionHandler{
+		appVersion: appVersion,
+		schema:     schema,
+	}
+}
+
+// VersionResponse is the body of GET /health/version
+type VersionResponse struct {
+	AppVersion            string `json:"app_version"`
+	SchemaVersion         int    `json:"schema_version"`
+	ExpectedSchemaVersion int    `json:"expected_schema_version"`
+	SchemaStatus          string `json:"schema_status"`
+}
+
+// HandleVersion handles GET /health/version
+func (h *VersionHandler) HandleVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	current, err := h.schema.CurrentVersion()
+	if err != nil {
+		logger.Error(ctx, "Failed to read schema version", "error", err.Error())
+		http.Error(w, "failed to read schema version", http.StatusServiceUnavailable)
+		return
+	}
+	expected, err := h.schema.LatestVersion()
+	if err != nil {
+		logger.Error(ctx, "Failed to read expected schema version", "error", err.Error())
+		http.Error(w, "failed to read expected schema version", http.StatusInternalServerError)
+		return
+	}
+
+	response := VersionResponse{
+		AppVersion:            h.appVersion,
+		SchemaVersion:         current,
+		ExpectedSchemaVersion: expected,
+		SchemaStatus:          SchemaStatusCurrent,
+	}
+	switch {
+	case current < expected:
+		response.SchemaStatus = SchemaStatusBehind
+	case current > expected:
+		response.SchemaStatus = SchemaStatusAhead
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
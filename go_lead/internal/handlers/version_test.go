@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// mockSchemaVersionReader returns fixed schema versions
+type mockSchemaVersionReader struct {
+	current int
+	latest  int
+	err     error
+}
+
+func (m *mockSchemaVersionReader) CurrentVersion() (int, error) {
+	return m.current, m.err
+}
+
+func (m *mockSchemaVersionReader) LatestVersion() (int, error) {
+	return m.latest, nil
+}
+
+func TestHandleVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		current    int
+		latest     int
+		wantStatus string
+	}{
+		{"schema current", 12, 12, SchemaStatusCurrent},
+		{"schema behind", 11, 12, SchemaStatusBehind},
+		{"schema ahead", 13, 12, SchemaStatusAhead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewVersionHandler("1.4.0", &mockSchemaVersionReader{current: tt.current, latest: tt.latest})
+
+			req := httptest.NewRequest(http.MethodGet, "/health/version", nil)
+			rec := httptest.NewRecorder()
+			handler.HandleVersion(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", rec.Code)
+			}
+			var response VersionResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			want := VersionResponse{
+				AppVersion:            "1.4.0",
+				SchemaVersion:         tt.current,
+				ExpectedSchemaVersion: tt.latest,
+				SchemaStatus:          tt.wantStatus,
+			}
+			if response != want {
+				t.Errorf("Expected %+v, got %+v", want, response)
+			}
+		})
+	}
+}
+
+func TestHandleVersion_SchemaUnreadable(t *testing.T) {
+	handler := NewVersionHandler("1.4.0", &mockSchemaVersionReader{err: errors.New("connection refused")})
+
+	req := httptest.NewRequest(http.MethodGet, "/health/version", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleVersion(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", rec.Code)
+	}
+}
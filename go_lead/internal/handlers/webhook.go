@@ -2,30 +2,161 @@ package handlers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
 	"io"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/checkfox/go_lead/internal/config"
 	"github.com/checkfox/go_lead/internal/logger"
 	"github.com/checkfox/go_lead/internal/models"
 	"github.com/checkfox/go_lead/internal/queue"
 	"github.com/checkfox/go_lead/internal/repository"
+	"github.com/checkfox/go_lead/internal/schedule"
+	"github.com/checkfox/go_lead/internal/services"
+	"github.com/checkfox/go_lead/internal/tracing"
+	"github.com/checkfox/go_lead/internal/transform"
 	"github.com/google/uuid"
 )
 
+// SourceIDHeader is the request header that identifies the webhook sender
+const SourceIDHeader = "X-Source-ID"
+
 // WebhookHandler handles webhook requests for lead reception
 type WebhookHandler struct {
 	leadRepo repository.LeadRepository
 	queue    queue.Queue
+	config   config.WebhookConfig
+
+	bodyTransformer  *BodyTransformer // nil when no transform is configured
+	acceptanceWindow *schedule.Window // nil when leads are accepted at any time
+	trustedProxies   []*net.IPNet
+	now              func() time.Time
+
+	// Duplicate submission throttling; disabled while throttle is nil
+	throttle       repository.SubmissionThrottleRepository
+	throttleWindow time.Duration
+	normalizer     *services.Normalizer
+
+	// Daily lead quotas per source; disabled while sourceQuota.Quotas is empty
+	sourceQuota   config.SourceQuotaConfig
+	quotaLocation *time.Location
+
+	// Repeated payloads within the grace period return the existing lead; disabled while zero.
+	// Only the given share of payloads is looked up.
+	gracePeriod             time.Duration
+	deduplicationSampleRate float64
+
+	// Attachment attributes are replaced by their metadata before storing; disabled while nil
+	attachmentMapping MappingSource
 }
 
-// NewWebhookHandler creates a new WebhookHandler
+// NewWebhookHandler creates a new WebhookHandler with default intake settings
 func NewWebhookHandler(leadRepo repository.LeadRepository, q queue.Queue) *WebhookHandler {
-	return &WebhookHandler{
+	return NewWebhookHandlerWithConfig(leadRepo, q, config.WebhookConfig{})
+}
+
+// NewWebhookHandlerWithConfig creates a new WebhookHandler with the given intake settings
+func NewWebhookHandlerWithConfig(leadRepo repository.LeadRepository, q queue.Queue, cfg config.WebhookConfig) *WebhookHandler {
+	h := &WebhookHandler{
 		leadRepo: leadRepo,
 		queue:    q,
+		config:         cfg,
+		trustedProxies: parseNetworks(cfg.TrustedProxies),
+		now:            time.Now,
+
+		deduplicationSampleRate: 1,
 	}
+	if len(cfg.AcceptanceSchedule.AllowedHours) > 0 || len(cfg.AcceptanceSchedule.AllowedWeekdays) > 0 {
+		h.acceptanceWindow = schedule.NewWindow(cfg.AcceptanceSchedule)
+	}
+	if cfg.BodyTransformExpr != "" {
+		// The expression is validated when the config is loaded
+		if transformer, err := NewBodyTransformer(cfg.BodyTransformExpr); err == nil {
+			h.bodyTransformer = transformer
+		}
+	}
+	return h
+}
+
+// SetDuplicateThrottle rejects leads with the same normalized phone or email from the same
+// client IP within window with 429 Too Many Requests. It should be called before serving requests.
+func (h *WebhookHandler) SetDuplicateThrottle(throttle repository.SubmissionThrottleRepository, window time.Duration, normalizer *services.Normalizer) {
+	h.throttle = throttle
+	h.throttleWindow = window
+	h.normalizer = normalizer
+}
+
+// SetSourceQuotas limits how many leads each listed source may send per day. Beyond its
+// quota a source gets 429 Too Many Requests, or its leads are flagged if the action is
+// config.SourceQuotaActionFlag. It should be called before serving requests.
+func (h *WebhookHandler) SetSourceQuotas(cfg config.SourceQuotaConfig) {
+	h.sourceQuota = cfg
+	// The timezone is validated when the config is loaded
+	h.quotaLocation, _ = time.LoadLocation(cfg.Timezone)
+	if h.quotaLocation == nil {
+		h.quotaLocation = time.UTC
+	}
+}
+
+// SetDeduplicationGracePeriod returns the existing lead instead of creating a new one for a
+// payload received again within period of that lead. It should be called before serving requests.
+func (h *WebhookHandler) SetDeduplicationGracePeriod(period time.Duration) {
+	h.gracePeriod = period
+}
+
+// SetDeduplicationSampleRate looks up only the given share (0.0 to 1.0) of payloads for a
+// repeated submission, saving the query for the others. It should be called before serving requests.
+func (h *WebhookHandler) SetDeduplicationSampleRate(rate float64) {
+	h.deduplicationSampleRate = rate
+}
+
+// SetAttachmentMapping strips the base64 attachment attributes of source's attribute mapping
+// from received payloads, so only their metadata is stored. It should be called before serving requests.
+func (h *WebhookHandler) SetAttachmentMapping(source MappingSource) {
+	h.attachmentMapping = source
+}
+
+// errTransformNotObject is returned when a body transform yields something other than an object
+var errTransformNotObject = errors.New("body transform did not produce a JSON object")
+
+// BodyTransformer reshapes non-standard webhook bodies, e.g. unwrapping {"data": {...}},
+// with a jq expression before the payload is validated and stored
+type BodyTransformer struct {
+	expr *transform.Expression
+}
+
+// NewBodyTransformer compiles a jq expression into a BodyTransformer
+func NewBodyTransformer(expr string) (*BodyTransformer, error) {
+	compiled, err := transform.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &BodyTransformer{expr: compiled}, nil
+}
+
+// Transform applies the expression to a decoded JSON body.
+// Returns errTransformNotObject if the result is not a JSON object.
+func (t *BodyTransformer) Transform(body interface{}) (map[string]interface{}, error) {
+	result, err := t.expr.Apply(body)
+	if err != nil {
+		return nil, err
+	}
+	payload, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, errTransformNotObject
+	}
+	return payload, nil
 }
 
 // WebhookResponse represents the response returned to webhook callers
@@ -35,9 +166,13 @@ type WebhookResponse struct {
 	CorrelationID string `json:"correlation_id"`
 }
 
+// ErrorCodeSourceQuotaExceeded is the error code of requests rejected for exceeding the source's daily quota
+const ErrorCodeSourceQuotaExceeded = "SOURCE_QUOTA_EXCEEDED"
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error         string `json:"error"`
+	Code          string `json:"code,omitempty"` // machine-readable reason, e.g. ErrorCodeSourceQuotaExceeded
 	CorrelationID string `json:"correlation_id,omitempty"`
 }
 
@@ -48,6 +183,10 @@ func (h *WebhookHandler) HandleLeadWebhook(w http.ResponseWriter, r *http.Reques
 	// Generate correlation ID for request tracing
 	correlationID := uuid.New().String()
 	ctx := context.WithValue(r.Context(), logger.CorrelationIDKey, correlationID)
+	ctx = withResponseFormat(ctx, negotiateResponseFormat(r))
+	ctx, span := tracing.Start(tracing.Extract(ctx, r.Header), "HandleLeadWebhook")
+	span.SetAttribute(tracing.AttributeCorrelationID, correlationID)
+	defer span.End()
 	
 	// Log incoming request
 	logger.Info(ctx, "Received webhook request",
@@ -61,8 +200,27 @@ func (h *WebhookHandler) HandleLeadWebhook(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	
-	// Read request body
+	// Turn leads away outside the acceptance window without storing them
+	if now := h.now(); h.acceptanceWindow != nil && !h.acceptanceWindow.Allows(now) {
+		logger.Warn(ctx, "Rejecting webhook request outside lead acceptance window")
+		if next, ok := h.acceptanceWindow.Next(now); ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(next.Sub(now).Seconds()))))
+		}
+		h.respondError(w, ctx, http.StatusServiceUnavailable, "outside lead acceptance hours")
+		return
+	}
+	
+	// Read request body, up to the configured limit
+	if h.config.MaxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, int64(h.config.MaxBodyBytes))
+	}
 	body, err := io.ReadAll(r.Body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		logger.Warn(ctx, "Rejecting request body larger than the limit", "limit_bytes", tooLarge.Limit)
+		h.respondError(w, ctx, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
 	if err != nil {
 		logger.LogError(ctx, "Failed to read request body", err)
 		h.respondError(w, ctx, http.StatusBadRequest, "failed to read request body")
@@ -72,9 +230,113 @@ func (h *WebhookHandler) HandleLeadWebhook(w http.ResponseWriter, r *http.Reques
 	
 	// Validate JSON
 	var rawPayload map[string]interface{}
-	if err := json.Unmarshal(body, &rawPayload); err != nil {
-		logger.LogError(ctx, "Malformed JSON payload", err)
-		h.respondError(w, ctx, http.StatusBadRequest, "malformed JSON payload")
+	if h.bodyTransformer == nil {
+		if err := models.DecodeJSON(body, &rawPayload); err != nil {
+			logger.LogError(ctx, "Malformed JSON payload", err)
+			h.respondError(w, ctx, http.StatusBadRequest, "malformed JSON payload")
+			return
+		}
+	} else {
+		// The body may be any JSON value until the transform has reshaped it
+		var document interface{}
+		if err := models.DecodeJSON(body, &document); err != nil {
+			logger.LogError(ctx, "Malformed JSON payload", err)
+			h.respondError(w, ctx, http.StatusBadRequest, "malformed JSON payload")
+			return
+		}
+		if rawPayload, err = h.bodyTransformer.Transform(document); err != nil {
+			logger.LogError(ctx, "Failed to transform request body", err)
+			h.respondError(w, ctx, http.StatusBadRequest, "request body does not match the expected shape")
+			return
+		}
+	}
+	
+	// Reject payloads that carry too little data to be worth storing
+	if reason := h.checkPayloadShape(rawPayload); reason != "" {
+		logger.Warn(ctx, "Rejecting webhook payload", "reason", reason)
+		h.respondError(w, ctx, http.StatusBadRequest, reason)
+		return
+	}
+	
+	// Resolve the sender identity
+	sourceID := resolveSourceID(r)
+	if sourceID == nil && h.config.RequireSourceID {
+		logger.Warn(ctx, "Rejecting webhook request without source identification")
+		h.respondError(w, ctx, http.StatusBadRequest, "missing source identification")
+		return
+	}
+	
+	// Add source_id and product to context for subsequent logging
+	if sourceID != nil {
+		ctx = context.WithValue(ctx, logger.SourceIDKey, *sourceID)
+	}
+	if product := models.JSONB(rawPayload).ProductName(); product != "" {
+		ctx = context.WithValue(ctx, logger.ProductKey, product)
+	}
+	
+	// Turn away a sender re-submitting the same contact within the deduplication window
+	throttleKeys := h.submissionKeys(r, rawPayload)
+	if retryAfter, throttled := h.checkDuplicateSubmission(ctx, throttleKeys); throttled {
+		logger.Warn(ctx, "Rejecting duplicate lead submission", "retry_after", retryAfter)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		h.respondError(w, ctx, http.StatusTooManyRequests, "duplicate lead submission")
+		return
+	}
+	
+	// Turn away or flag leads beyond the source's daily quota
+	quotaExceeded, retryAfter := h.checkSourceQuota(ctx, sourceID)
+	if quotaExceeded && h.sourceQuota.Action != config.SourceQuotaActionFlag {
+		logger.Warn(ctx, "Rejecting lead beyond source quota", "source_id", *sourceID)
+		if retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		}
+		h.respondErrorCode(w, ctx, http.StatusTooManyRequests, ErrorCodeSourceQuotaExceeded, "daily lead quota exceeded")
+		return
+	}
+	
+	// The callback URL is stored with the headers and used after delivery, so reject unusable ones now
+	if callbackURL := r.Header.Get(models.CallbackURLHeader); callbackURL != "" && !isValidCallbackURL(callbackURL) {
+		logger.Warn(ctx, "Rejecting webhook request with invalid callback URL")
+		h.respondError(w, ctx, http.StatusBadRequest, "invalid callback URL")
+		return
+	}
+	
+	// Keep only the metadata of attachments, never the attachment bytes
+	var attachments models.JSONB
+	if h.attachmentMapping != nil {
+		mapping, _ := h.attachmentMapping.AttributeMapping()
+		sender := ""
+		if sourceID != nil {
+			sender = *sourceID
+		}
+		if attachments, err = services.ExtractAttachments(mapping, sender, rawPayload); err != nil {
+			logger.Warn(ctx, "Rejecting webhook payload", "reason", err.Error())
+			h.respondError(w, ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	
+	// A payload received again within the grace period returns the existing lead
+	payloadHash, err := models.JSONB(rawPayload).Hash()
+	if err != nil {
+		logger.LogError(ctx, "Failed to hash payload", err)
+	}
+	if existing := h.findRecentDuplicate(ctx, sourceID, payloadHash); existing != nil {
+		// The earlier request may have stored the lead but failed to enqueue its job; a lead
+		// that already has an active job is not enqueued twice
+		if existing.Status == models.LeadStatusReceived {
+			if _, err := h.enqueueProcessing(ctx, existing.ID, correlationID); err != nil && !errors.Is(err, queue.ErrDuplicateJob) {
+				logger.LogError(ctx, "Failed to enqueue job for existing lead", err, "existing_lead_id", existing.ID)
+				h.respondError(w, ctx, http.StatusServiceUnavailable, "queue unavailable")
+				return
+			}
+		}
+		logger.Info(ctx, "Returning existing lead for repeated payload", "existing_lead_id", existing.ID)
+		h.respondAck(w, ctx, WebhookResponse{
+			LeadID:        existing.ID,
+			Status:        string(existing.Status),
+			CorrelationID: correlationID,
+		})
 		return
 	}
 	
@@ -85,15 +347,39 @@ func (h *WebhookHandler) HandleLeadWebhook(w http.ResponseWriter, r *http.Reques
 			headers[key] = values[0]
 		}
 	}
+	// The quota marker is only ever set by the handler itself
+	delete(headers, http.CanonicalHeaderKey(models.SourceQuotaExceededHeader))
+	if quotaExceeded {
+		logger.Warn(ctx, "Flagging lead beyond source quota", "source_id", *sourceID)
+		headers[http.CanonicalHeaderKey(models.SourceQuotaExceededHeader)] = "true"
+		w.Header().Set(models.SourceQuotaExceededHeader, "true")
+	}
 	
 	// Create lead record
 	lead := &models.InboundLead{
-		ReceivedAt:    time.Now(),
-		RawPayload:    rawPayload,
-		SourceHeaders: headers,
-		Status:        models.LeadStatusReceived,
+		ReceivedAt:          h.now(),
+		RawPayload:          rawPayload,
+		SourceHeaders:       headers,
+		SourceID:            sourceID,
+		Status:              models.LeadStatusReceived,
+		AttachmentsMetadata: attachments,
+	}
+	if payloadHash != "" {
+		lead.PayloadHash = &payloadHash
+	}
+	
+	// A request past its deadline has already been answered with an error the sender retries,
+	// so storing the lead now would create it twice
+	if err := ctx.Err(); err != nil {
+		logger.Warn(ctx, "Not storing lead of a request past its deadline", "error", err.Error())
+		h.respondError(w, ctx, http.StatusServiceUnavailable, "request timed out")
+		return
 	}
 	
+	// From here on the deadline must not separate the lead from its job: a lead stored
+	// without job would stay RECEIVED until the sender retries
+	ctx = context.WithoutCancel(ctx)
+	
 	// Store lead to database
 	if err := h.leadRepo.CreateLead(ctx, lead); err != nil {
 		logger.LogError(ctx, "Failed to create lead", err)
@@ -103,18 +389,22 @@ func (h *WebhookHandler) HandleLeadWebhook(w http.ResponseWriter, r *http.Reques
 	
 	// Add lead_id to context for subsequent logging
 	ctx = context.WithValue(ctx, logger.LeadIDKey, lead.ID)
+	span.SetAttribute(tracing.AttributeLeadID, lead.ID)
+	
+	// Only a stored lead counts as submission, so a rejected request can be retried at once
+	h.recordSubmission(ctx, throttleKeys)
 	
 	logger.Info(ctx, "Created lead", "status", lead.Status)
 	
 	// Enqueue background job for processing
-	jobPayload := queue.NewJobPayload(lead.ID)
-	if err := h.queue.Enqueue(ctx, "process_lead", jobPayload); err != nil {
+	jobID, err := h.enqueueProcessing(ctx, lead.ID, correlationID)
+	if err != nil {
 		logger.LogError(ctx, "Failed to enqueue job", err)
 		h.respondError(w, ctx, http.StatusServiceUnavailable, "queue unavailable")
 		return
 	}
 	
-	logger.Info(ctx, "Enqueued processing job")
+	logger.Info(ctx, "Enqueued processing job", "job_id", jobID)
 	
 	// Log slow operation if needed
 	duration := time.Since(startTime)
@@ -127,7 +417,176 @@ func (h *WebhookHandler) HandleLeadWebhook(w http.ResponseWriter, r *http.Reques
 		CorrelationID: correlationID,
 	}
 	
-	h.respondJSON(w, ctx, http.StatusOK, response)
+	h.respondAck(w, ctx, response)
+}
+
+// checkPayloadShape runs the configured pre-checks on a decoded payload
+// Returns an error message, or an empty string if the payload is acceptable
+func (h *WebhookHandler) checkPayloadShape(payload map[string]interface{}) string {
+	if len(payload) < h.config.MinPayloadFields {
+		return "payload too sparse"
+	}
+	for _, key := range h.config.RequiredPayloadKeys {
+		if _, ok := payload[key]; !ok {
+			return fmt.Sprintf("missing required field: %s", key)
+		}
+	}
+	return ""
+}
+
+// submissionKeys returns the throttle keys of a submission: the client IP combined with the
+// normalized phone and email of the payload. Nil if throttling is disabled.
+func (h *WebhookHandler) submissionKeys(r *http.Request, payload map[string]interface{}) []string {
+	if h.throttle == nil {
+		return nil
+	}
+	
+	clientIP := resolveClientIP(r, h.trustedProxies)
+	if clientIP == nil {
+		return nil
+	}
+	
+	payload = h.normalizer.NormalizeKeys(payload)
+	var keys []string
+	if phone := h.normalizer.NormalizePhone(contactValue(payload["phone"])); phone != "" {
+		keys = append(keys, clientIP.String()+":phone:"+phone)
+	}
+	if email := h.normalizer.NormalizeEmail(contactValue(payload["email"])); email != "" {
+		keys = append(keys, clientIP.String()+":email:"+email)
+	}
+	return keys
+}
+
+// checkDuplicateSubmission reports whether one of the keys was submitted within the throttle
+// window. Throttling errors are logged and let the lead through.
+func (h *WebhookHandler) checkDuplicateSubmission(ctx context.Context, keys []string) (time.Duration, bool) {
+	if len(keys) == 0 {
+		return 0, false
+	}
+	
+	allowed, retryAfter, err := h.throttle.CheckSubmission(ctx, keys, h.now(), h.throttleWindow)
+	if err != nil {
+		logger.LogError(ctx, "Failed to check for duplicate submission", err)
+		return 0, false
+	}
+	return retryAfter, !allowed
+}
+
+// recordSubmission records a stored lead under its throttle keys. Errors are logged only,
+// since the lead is already stored.
+func (h *WebhookHandler) recordSubmission(ctx context.Context, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	if err := h.throttle.RecordSubmission(ctx, keys, h.now()); err != nil {
+		logger.LogError(ctx, "Failed to record submission", err)
+	}
+}
+
+// enqueueProcessing enqueues the process_lead job of a lead, carrying the trace and
+// correlation ID to the worker. Returns queue.ErrDuplicateJob if the lead already has an active job.
+func (h *WebhookHandler) enqueueProcessing(ctx context.Context, leadID int64, correlationID string) (int64, error) {
+	jobPayload := queue.NewJobPayload(leadID)
+	jobPayload["correlation_id"] = correlationID
+	if traceparent := tracing.Traceparent(ctx); traceparent != "" {
+		jobPayload["traceparent"] = traceparent
+	}
+	return h.queue.EnqueueReturningID(ctx, queue.JobTypeProcessLead, jobPayload)
+}
+
+// findRecentDuplicate returns the lead of the same source with the same payload hash created
+// within the grace period, or nil. Lookup errors are logged and let the lead through.
+func (h *WebhookHandler) findRecentDuplicate(ctx context.Context, sourceID *string, payloadHash string) *models.InboundLead {
+	if h.gracePeriod <= 0 || payloadHash == "" {
+		return nil
+	}
+	if !inSample(payloadHash, h.deduplicationSampleRate) {
+		return nil
+	}
+	
+	existing, err := h.leadRepo.FindLeadByPayloadHash(ctx, sourceID, payloadHash, h.now().Add(-h.gracePeriod))
+	if err != nil {
+		logger.LogError(ctx, "Failed to check for repeated payload", err)
+		return nil
+	}
+	return existing
+}
+
+// inSample reports whether key falls into a sample of the given rate (0.0 to 1.0). The
+// decision depends only on the SHA-256 hash of key, so the same key is always sampled alike.
+func inSample(key string, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	hash := sha256.Sum256([]byte(key))
+	// The top 53 bits of the hash map uniformly onto [0, 1)
+	return float64(binary.BigEndian.Uint64(hash[:8])>>11)/(1<<53) < rate
+}
+
+// checkSourceQuota reports whether the source has already sent its daily quota of leads and,
+// for calendar-day quotas, how long until the quota resets. Counting errors are logged and let
+// the lead through. Concurrent requests may overshoot the quota slightly.
+func (h *WebhookHandler) checkSourceQuota(ctx context.Context, sourceID *string) (bool, time.Duration) {
+	if sourceID == nil {
+		return false, 0
+	}
+	quota, ok := h.sourceQuota.Quotas[*sourceID]
+	if !ok {
+		return false, 0
+	}
+	
+	now := h.now()
+	since := now.Add(-24 * time.Hour)
+	var resetIn time.Duration
+	if h.sourceQuota.Window == config.SourceQuotaWindowCalendarDay {
+		local := now.In(h.quotaLocation)
+		since = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, h.quotaLocation)
+		resetIn = since.AddDate(0, 0, 1).Sub(now)
+	}
+	
+	count, err := h.leadRepo.CountLeadsForSourceSince(ctx, *sourceID, since)
+	if err != nil {
+		logger.LogError(ctx, "Failed to check source quota", err)
+		return false, 0
+	}
+	return count >= quota, resetIn
+}
+
+// contactValue returns a phone or email payload value as a string; phone numbers may
+// arrive as JSON numbers
+func contactValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// resolveSourceID returns the sender identity from the X-Source-ID header,
+// or nil if the request does not carry one
+func resolveSourceID(r *http.Request) *string {
+	sourceID := strings.TrimSpace(r.Header.Get(SourceIDHeader))
+	if sourceID == "" {
+		return nil
+	}
+	return &sourceID
+}
+
+// isValidCallbackURL reports whether the value is an absolute http(s) URL
+func isValidCallbackURL(value string) bool {
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
 }
 
 // respondJSON sends a JSON response
@@ -143,16 +602,80 @@ func (h *WebhookHandler) respondJSON(w http.ResponseWriter, ctx context.Context,
 	}
 }
 
+// respondAck sends the success response in the negotiated format,
+// falling back to the configured default format
+func (h *WebhookHandler) respondAck(w http.ResponseWriter, ctx context.Context, response WebhookResponse) {
+	format := negotiatedResponseFormat(ctx)
+	if format == "" {
+		format = h.config.ResponseFormat
+	}
+	
+	switch format {
+	case ResponseFormatText:
+		h.respondText(w, ctx, http.StatusOK, "OK")
+	case ResponseFormatXML:
+		h.respondXML(w, ctx, http.StatusOK, webhookAckXML{
+			LeadID:        response.LeadID,
+			Status:        response.Status,
+			CorrelationID: response.CorrelationID,
+		})
+	default:
+		h.respondJSON(w, ctx, http.StatusOK, response)
+	}
+}
+
+// respondText sends a plain-text response
+func (h *WebhookHandler) respondText(w http.ResponseWriter, ctx context.Context, statusCode int, body string) {
+	if correlationID, ok := ctx.Value(logger.CorrelationIDKey).(string); ok {
+		w.Header().Set("X-Correlation-ID", correlationID)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(statusCode)
+	w.Write([]byte(body))
+}
+
+// respondXML sends an XML response
+func (h *WebhookHandler) respondXML(w http.ResponseWriter, ctx context.Context, statusCode int, data interface{}) {
+	if correlationID, ok := ctx.Value(logger.CorrelationIDKey).(string); ok {
+		w.Header().Set("X-Correlation-ID", correlationID)
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(statusCode)
+	
+	w.Write([]byte(xml.Header))
+	if err := xml.NewEncoder(w).Encode(data); err != nil {
+		logger.LogError(ctx, "Failed to encode response", err)
+	}
+}
+
 // respondError sends an error response
+// Errors stay JSON unless the client explicitly asked for another format
 func (h *WebhookHandler) respondError(w http.ResponseWriter, ctx context.Context, statusCode int, message string) {
+	h.respondErrorCode(w, ctx, statusCode, "", message)
+}
+
+// respondErrorCode sends an error response carrying a machine-readable error code
+func (h *WebhookHandler) respondErrorCode(w http.ResponseWriter, ctx context.Context, statusCode int, code, message string) {
 	correlationID := ""
 	if id, ok := ctx.Value(logger.CorrelationIDKey).(string); ok {
 		correlationID = id
 	}
 	
-	response := ErrorResponse{
-		Error:         message,
-		CorrelationID: correlationID,
+	switch negotiatedResponseFormat(ctx) {
+	case ResponseFormatText:
+		h.respondText(w, ctx, statusCode, message)
+	case ResponseFormatXML:
+		h.respondXML(w, ctx, statusCode, errorXML{
+			Message:       message,
+			Code:          code,
+			CorrelationID: correlationID,
+		})
+	default:
+		response := ErrorResponse{
+			Error:         message,
+			Code:          code,
+			CorrelationID: correlationID,
+		}
+		h.respondJSON(w, ctx, statusCode, response)
 	}
-	h.respondJSON(w, ctx, statusCode, response)
 }
@@ -98,10 +98,18 @@ func (m *MockLeadRepository) UpdateLeadStatus(ctx context.Context, id int64, sta
 	return nil
 }
 
+func (m *MockLeadRepository) UpdateLeadStatusFrom(ctx context.Context, id int64, from, to models.LeadStatus) error {
+	return nil
+}
+
 func (m *MockLeadRepository) UpdateLeadWithPayloads(ctx context.Context, id int64, normalizedPayload, customerPayload models.JSONB) error {
 	return nil
 }
 
+func (m *MockLeadRepository) UpdateLeadAttachments(ctx context.Context, id int64, metadata models.JSONB, attachmentKeys []string) error {
+	return nil
+}
+
 func (m *MockLeadRepository) UpdateLeadRejection(ctx context.Context, id int64, reason models.RejectionReason) error {
 	return nil
 }
@@ -114,14 +122,42 @@ func (m *MockLeadRepository) UpdateLeadStatusTx(ctx context.Context, tx *sql.Tx,
 	return nil
 }
 
+func (m *MockLeadRepository) UpdateLeadStatusFromTx(ctx context.Context, tx *sql.Tx, id int64, from, to models.LeadStatus) error {
+	return nil
+}
+
 func (m *MockLeadRepository) GetLeadCountsByStatus(ctx context.Context) (map[string]int, error) {
 	return make(map[string]int), nil
 }
 
+func (m *MockLeadRepository) GetLeadCountsByStatusForSource(ctx context.Context, sourceID string) (map[string]int, error) {
+	return make(map[string]int), nil
+}
+
+func (m *MockLeadRepository) GetLeadCountsByStatusPerSource(ctx context.Context) (map[string]map[string]int, error) {
+	return make(map[string]map[string]int), nil
+}
+
+func (m *MockLeadRepository) CountLeadsForSourceSince(ctx context.Context, sourceID string, since time.Time) (int, error) {
+	return 0, nil
+}
+
 func (m *MockLeadRepository) GetRecentLeads(ctx context.Context, limit int) ([]*models.InboundLead, error) {
 	return []*models.InboundLead{}, nil
 }
 
+func (m *MockLeadRepository) FindLeadsByContact(ctx context.Context, email, phone string, limit int) ([]*models.InboundLead, error) {
+	return []*models.InboundLead{}, nil
+}
+
+func (m *MockLeadRepository) FindLeadByPayloadHash(ctx context.Context, sourceID *string, payloadHash string, since time.Time) (*models.InboundLead, error) {
+	return nil, nil
+}
+
+func (m *MockLeadRepository) FindInSuppressionList(ctx context.Context, phone, email string) (*models.SuppressionEntry, error) {
+	return nil, nil
+}
+
 // MockQueue is a mock implementation of Queue for testing
 type MockQueue struct{}
 
@@ -130,10 +166,18 @@ func (m *MockQueue) Enqueue(ctx context.Context, jobType string, payload map[str
 	return nil
 }
 
+func (m *MockQueue) EnqueueReturningID(ctx context.Context, jobType string, payload map[string]interface{}) (int64, error) {
+	return 1, m.Enqueue(ctx, jobType, payload)
+}
+
 func (m *MockQueue) EnqueueWithDelay(ctx context.Context, jobType string, payload map[string]interface{}, delay time.Duration) error {
 	return nil
 }
 
+func (m *MockQueue) EnqueueWithPriority(ctx context.Context, jobType string, payload map[string]interface{}, delay time.Duration, priority int) error {
+	return nil
+}
+
 func (m *MockQueue) Dequeue(ctx context.Context) (*queue.Job, error) {
 	return nil, nil
 }
@@ -146,6 +190,10 @@ func (m *MockQueue) Retry(ctx context.Context, jobID int64, delay time.Duration)
 	return nil
 }
 
+func (m *MockQueue) RetryWithPriority(ctx context.Context, jobID int64, delay time.Duration, priority int) error {
+	return nil
+}
+
 func (m *MockQueue) Fail(ctx context.Context, jobID int64, errorMsg string) error {
 	return nil
 }
@@ -4,15 +4,23 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/checkfox/go_lead/internal/config"
 	"github.com/checkfox/go_lead/internal/models"
 	"github.com/checkfox/go_lead/internal/queue"
+	"github.com/checkfox/go_lead/internal/services"
+	"github.com/checkfox/go_lead/internal/tracing"
 )
 
 // Test successful lead acceptance
@@ -181,6 +189,1185 @@ func TestHandleLeadWebhook_QueueUnavailable(t *testing.T) {
 	}
 }
 
+// Test source id is required when REQUIRE_SOURCE_ID is set
+func TestHandleLeadWebhook_RequireSourceID_Missing(t *testing.T) {
+	mockRepo := &capturingLeadRepository{}
+	mockQueue := &MockQueue{}
+	handler := NewWebhookHandlerWithConfig(mockRepo, mockQueue, config.WebhookConfig{RequireSourceID: true})
+
+	payloadBytes, _ := json.Marshal(map[string]interface{}{"email": "test@example.com"})
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader(payloadBytes))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	handler.HandleLeadWebhook(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rr.Code)
+	}
+
+	var response ErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode error response: %v", err)
+	}
+
+	if response.Error != "missing source identification" {
+		t.Errorf("Expected error 'missing source identification', got '%s'", response.Error)
+	}
+
+	if mockRepo.created != nil {
+		t.Error("Expected no lead to be stored")
+	}
+}
+
+// Test source id is accepted and persisted when REQUIRE_SOURCE_ID is set
+func TestHandleLeadWebhook_RequireSourceID_Present(t *testing.T) {
+	mockRepo := &capturingLeadRepository{}
+	mockQueue := &MockQueue{}
+	handler := NewWebhookHandlerWithConfig(mockRepo, mockQueue, config.WebhookConfig{RequireSourceID: true})
+
+	payloadBytes, _ := json.Marshal(map[string]interface{}{"email": "test@example.com"})
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader(payloadBytes))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SourceIDHeader, " partner-a ")
+
+	rr := httptest.NewRecorder()
+	handler.HandleLeadWebhook(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	if mockRepo.created == nil || mockRepo.created.SourceID == nil {
+		t.Fatal("Expected lead to be stored with a source id")
+	}
+
+	if *mockRepo.created.SourceID != "partner-a" {
+		t.Errorf("Expected source id 'partner-a', got '%s'", *mockRepo.created.SourceID)
+	}
+}
+
+// Test anonymous leads are still accepted when REQUIRE_SOURCE_ID is not set
+func TestHandleLeadWebhook_SourceIDOptionalByDefault(t *testing.T) {
+	mockRepo := &capturingLeadRepository{}
+	mockQueue := &MockQueue{}
+	handler := NewWebhookHandler(mockRepo, mockQueue)
+
+	payloadBytes, _ := json.Marshal(map[string]interface{}{"email": "test@example.com"})
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader(payloadBytes))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	handler.HandleLeadWebhook(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	if mockRepo.created == nil {
+		t.Fatal("Expected lead to be stored")
+	}
+
+	if mockRepo.created.SourceID != nil {
+		t.Errorf("Expected no source id, got '%s'", *mockRepo.created.SourceID)
+	}
+}
+
+// Test large integers are stored exactly when numbers are decoded as json.Number
+func TestHandleLeadWebhook_UseNumberPreservesLargeIntegers(t *testing.T) {
+	models.SetJSONUseNumber(true)
+	defer models.SetJSONUseNumber(false)
+
+	mockRepo := &capturingLeadRepository{}
+	handler := NewWebhookHandler(mockRepo, &MockQueue{})
+
+	body := `{"email": "test@example.com", "provider_id": 1234567890123456789}`
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	handler.HandleLeadWebhook(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if got := mockRepo.created.RawPayload["provider_id"]; got != json.Number("1234567890123456789") {
+		t.Errorf("Expected provider_id to be preserved exactly, got %v (%T)", got, got)
+	}
+
+	stored, err := json.Marshal(mockRepo.created.RawPayload)
+	if err != nil {
+		t.Fatalf("Failed to marshal raw payload: %v", err)
+	}
+	if !strings.Contains(string(stored), `"provider_id":1234567890123456789`) {
+		t.Errorf("Expected stored payload to keep the exact number, got %s", stored)
+	}
+}
+
+// Test payload pre-checks reject sparse bodies before the lead is stored
+func TestHandleLeadWebhook_PayloadPreChecks(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        config.WebhookConfig
+		body          string
+		expectedError string
+	}{
+		{
+			name:          "empty payload",
+			config:        config.WebhookConfig{MinPayloadFields: 1},
+			body:          `{}`,
+			expectedError: "payload too sparse",
+		},
+		{
+			name:          "sparse payload",
+			config:        config.WebhookConfig{MinPayloadFields: 3},
+			body:          `{"email": "test@example.com", "phone": "123"}`,
+			expectedError: "payload too sparse",
+		},
+		{
+			name:          "missing required key",
+			config:        config.WebhookConfig{MinPayloadFields: 1, RequiredPayloadKeys: []string{"email", "zipcode"}},
+			body:          `{"email": "test@example.com"}`,
+			expectedError: "missing required field: zipcode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &capturingLeadRepository{}
+			handler := NewWebhookHandlerWithConfig(mockRepo, &MockQueue{}, tt.config)
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+
+			rr := httptest.NewRecorder()
+			handler.HandleLeadWebhook(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", rr.Code)
+			}
+
+			var response ErrorResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+
+			if response.Error != tt.expectedError {
+				t.Errorf("Expected error '%s', got '%s'", tt.expectedError, response.Error)
+			}
+
+			if mockRepo.created != nil {
+				t.Error("Expected no lead to be stored")
+			}
+		})
+	}
+}
+
+// Test payloads satisfying the pre-checks are accepted
+func TestHandleLeadWebhook_PayloadPreChecksPass(t *testing.T) {
+	cfg := config.WebhookConfig{MinPayloadFields: 2, RequiredPayloadKeys: []string{"email"}}
+	handler := NewWebhookHandlerWithConfig(&MockLeadRepository{}, &MockQueue{}, cfg)
+
+	body := `{"email": "test@example.com", "zipcode": "66123"}`
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	handler.HandleLeadWebhook(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rr.Code)
+	}
+}
+
+// Test bodies beyond the configured limit are rejected with 413 and bodies up to it are accepted
+func TestHandleLeadWebhook_MaxBodyBytes(t *testing.T) {
+	body := `{"email": "test@example.com", "zipcode": "66123"}`
+	tests := []struct {
+		name         string
+		maxBodyBytes int
+		expected     int
+	}{
+		{"within limit", len(body), http.StatusOK},
+		{"beyond limit", len(body) - 1, http.StatusRequestEntityTooLarge},
+		{"no limit", 0, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.WebhookConfig{MaxBodyBytes: tt.maxBodyBytes}
+			handler := NewWebhookHandlerWithConfig(&MockLeadRepository{}, &MockQueue{}, cfg)
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			handler.HandleLeadWebhook(rr, req)
+
+			if rr.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d", tt.expected, rr.Code)
+			}
+			if tt.expected == http.StatusRequestEntityTooLarge {
+				var response ErrorResponse
+				if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+					t.Fatalf("Failed to decode error response: %v", err)
+				}
+				if response.Error != "request body too large" {
+					t.Errorf("Expected error 'request body too large', got '%s'", response.Error)
+				}
+			}
+		})
+	}
+}
+
+// Test the configured body transform reshapes the payload before the lead is stored
+func TestHandleLeadWebhook_BodyTransform(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		body string
+		want map[string]interface{}
+	}{
+		{
+			name: "unwrap nested object",
+			expr: ".data",
+			body: `{"data": {"phone": "0151", "zipcode": "66123"}, "meta": {"v": 2}}`,
+			want: map[string]interface{}{"phone": "0151", "zipcode": "66123"},
+		},
+		{
+			name: "first array element",
+			expr: ".leads[0]",
+			body: `{"leads": [{"phone": "0151"}, {"phone": "0160"}]}`,
+			want: map[string]interface{}{"phone": "0151"},
+		},
+		{
+			name: "root array first element",
+			expr: ".[0]",
+			body: `[{"phone": "0151"}]`,
+			want: map[string]interface{}{"phone": "0151"},
+		},
+		{
+			name: "rename fields",
+			expr: "{phone: .tel, zipcode: .address.plz, email}",
+			body: `{"tel": "0151", "address": {"plz": "66123"}, "email": "test@example.com"}`,
+			want: map[string]interface{}{"phone": "0151", "zipcode": "66123", "email": "test@example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &capturingLeadRepository{}
+			handler := NewWebhookHandlerWithConfig(mockRepo, &MockQueue{}, config.WebhookConfig{BodyTransformExpr: tt.expr})
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader([]byte(tt.body)))
+			rr := httptest.NewRecorder()
+			handler.HandleLeadWebhook(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			if mockRepo.created == nil {
+				t.Fatal("Expected lead to be stored")
+			}
+			if !reflect.DeepEqual(map[string]interface{}(mockRepo.created.RawPayload), tt.want) {
+				t.Errorf("Stored payload = %v, want %v", mockRepo.created.RawPayload, tt.want)
+			}
+		})
+	}
+}
+
+// Test a body transform that yields no object is rejected before the lead is stored
+func TestHandleLeadWebhook_BodyTransformNonObject(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		body string
+	}{
+		{"missing wrapper", ".data", `{"phone": "0151"}`},
+		{"scalar result", ".data.phone", `{"data": {"phone": "0151"}}`},
+		{"empty array", ".leads[0]", `{"leads": []}`},
+		{"type error", ".data", `["not", "an", "object"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &capturingLeadRepository{}
+			handler := NewWebhookHandlerWithConfig(mockRepo, &MockQueue{}, config.WebhookConfig{BodyTransformExpr: tt.expr})
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader([]byte(tt.body)))
+			rr := httptest.NewRecorder()
+			handler.HandleLeadWebhook(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", rr.Code)
+			}
+			if mockRepo.created != nil {
+				t.Error("Expected no lead to be stored")
+			}
+		})
+	}
+}
+
+// Test leads are only accepted inside the acceptance window, with Retry-After pointing to the next opening
+func TestHandleLeadWebhook_AcceptanceSchedule(t *testing.T) {
+	// Weekdays 08:00-17:59 UTC; 2025-01-07 is a Tuesday, 2025-01-10 a Friday
+	cfg := config.WebhookConfig{
+		AcceptanceSchedule: config.DeliverySchedule{
+			AllowedHours:    []int{8, 9, 10, 11, 12, 13, 14, 15, 16, 17},
+			AllowedWeekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			Timezone:        "UTC",
+		},
+	}
+
+	tests := []struct {
+		name           string
+		now            time.Time
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{"opening boundary", time.Date(2025, 1, 7, 8, 0, 0, 0, time.UTC), http.StatusOK, ""},
+		{"last second of the window", time.Date(2025, 1, 7, 17, 59, 59, 0, time.UTC), http.StatusOK, ""},
+		{"closing boundary", time.Date(2025, 1, 7, 18, 0, 0, 0, time.UTC), http.StatusServiceUnavailable, "50400"},
+		{"just before opening", time.Date(2025, 1, 7, 7, 59, 30, 0, time.UTC), http.StatusServiceUnavailable, "30"},
+		{"partial second rounds up", time.Date(2025, 1, 7, 7, 59, 59, 500000000, time.UTC), http.StatusServiceUnavailable, "1"},
+		{"friday evening waits for monday", time.Date(2025, 1, 10, 18, 0, 0, 0, time.UTC), http.StatusServiceUnavailable, "223200"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &capturingLeadRepository{}
+			handler := NewWebhookHandlerWithConfig(mockRepo, &MockQueue{}, cfg)
+			handler.now = func() time.Time { return tt.now }
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader([]byte(`{"email": "test@example.com"}`)))
+			rr := httptest.NewRecorder()
+			handler.HandleLeadWebhook(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, rr.Code)
+			}
+			if got := rr.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Expected Retry-After %q, got %q", tt.wantRetryAfter, got)
+			}
+			if stored := mockRepo.created != nil; stored != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("Expected lead stored = %v, got %v", tt.wantStatus == http.StatusOK, stored)
+			}
+		})
+	}
+}
+
+// Test the acceptance window is evaluated in its configured timezone
+func TestHandleLeadWebhook_AcceptanceScheduleTimezone(t *testing.T) {
+	if _, err := time.LoadLocation("Europe/Berlin"); err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	cfg := config.WebhookConfig{
+		AcceptanceSchedule: config.DeliverySchedule{
+			AllowedHours: []int{8, 9, 10, 11, 12, 13, 14, 15, 16, 17},
+			Timezone:     "Europe/Berlin",
+		},
+	}
+	mockRepo := &capturingLeadRepository{}
+	handler := NewWebhookHandlerWithConfig(mockRepo, &MockQueue{}, cfg)
+	// 07:30 UTC is 08:30 in Berlin (CET)
+	handler.now = func() time.Time { return time.Date(2025, 1, 7, 7, 30, 0, 0, time.UTC) }
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader([]byte(`{"email": "test@example.com"}`)))
+	rr := httptest.NewRecorder()
+	handler.HandleLeadWebhook(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200 inside the Berlin window, got %d", rr.Code)
+	}
+}
+
+// memoryThrottle is an in-memory SubmissionThrottleRepository
+type memoryThrottle struct {
+	lastSubmitted map[string]time.Time
+}
+
+func (m *memoryThrottle) CheckSubmission(ctx context.Context, keys []string, at time.Time, window time.Duration) (bool, time.Duration, error) {
+	for _, key := range keys {
+		if last, ok := m.lastSubmitted[key]; ok && at.Sub(last) < window {
+			return false, last.Add(window).Sub(at), nil
+		}
+	}
+	return true, 0, nil
+}
+
+func (m *memoryThrottle) RecordSubmission(ctx context.Context, keys []string, at time.Time) error {
+	for _, key := range keys {
+		m.lastSubmitted[key] = at
+	}
+	return nil
+}
+
+func (m *memoryThrottle) DeleteExpiredSubmissions(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	for key, last := range m.lastSubmitted {
+		if last.Before(before) {
+			delete(m.lastSubmitted, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Test a sender re-submitting the same contact within the window receives 429
+func TestHandleLeadWebhook_DuplicateSubmissionThrottled(t *testing.T) {
+	mockRepo := &capturingLeadRepository{}
+	handler := NewWebhookHandler(mockRepo, &MockQueue{})
+	handler.SetDuplicateThrottle(&memoryThrottle{lastSubmitted: map[string]time.Time{}}, time.Minute, services.NewNormalizer())
+	start := time.Date(2025, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	submit := func(at time.Time, remoteAddr, body string) *httptest.ResponseRecorder {
+		handler.now = func() time.Time { return at }
+		mockRepo.created = nil
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader([]byte(body)))
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		handler.HandleLeadWebhook(rr, req)
+		return rr
+	}
+
+	if rr := submit(start, "203.0.113.7:5000", `{"phone": "+49 151 1234567"}`); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the first submission to be accepted, got %d", rr.Code)
+	}
+
+	// The same phone in another format is a duplicate
+	rr := submit(start.Add(20*time.Second), "203.0.113.7:5001", `{"phone": "+49 (151) 123-4567"}`)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429 within the window, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "40" {
+		t.Errorf("Expected Retry-After 40, got %q", got)
+	}
+	if mockRepo.created != nil {
+		t.Error("Expected the duplicate not to be stored")
+	}
+
+	// Another sender IP is not affected
+	if rr := submit(start.Add(30*time.Second), "198.51.100.7:5000", `{"phone": "+49 151 1234567"}`); rr.Code != http.StatusOK {
+		t.Errorf("Expected another IP to be accepted, got %d", rr.Code)
+	}
+
+	if rr := submit(start.Add(time.Minute), "203.0.113.7:5000", `{"phone": "+49 151 1234567"}`); rr.Code != http.StatusOK {
+		t.Errorf("Expected a submission after the window to be accepted, got %d", rr.Code)
+	}
+}
+
+// Test a rejected request does not throttle its corrected retry
+func TestHandleLeadWebhook_RejectedSubmissionNotThrottled(t *testing.T) {
+	throttle := &memoryThrottle{lastSubmitted: map[string]time.Time{}}
+	handler := NewWebhookHandler(&capturingLeadRepository{}, &MockQueue{})
+	handler.SetDuplicateThrottle(throttle, time.Minute, services.NewNormalizer())
+	handler.now = func() time.Time { return time.Date(2025, 1, 7, 10, 0, 0, 0, time.UTC) }
+
+	submit := func(callbackURL string) int {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader([]byte(`{"phone": "+49 151 1234567"}`)))
+		req.RemoteAddr = "203.0.113.7:5000"
+		if callbackURL != "" {
+			req.Header.Set(models.CallbackURLHeader, callbackURL)
+		}
+		rr := httptest.NewRecorder()
+		handler.HandleLeadWebhook(rr, req)
+		return rr.Code
+	}
+
+	if code := submit("not a url"); code != http.StatusBadRequest {
+		t.Fatalf("Expected the invalid callback URL to be rejected, got %d", code)
+	}
+	if len(throttle.lastSubmitted) != 0 {
+		t.Errorf("Expected the rejected submission not to be recorded, got %v", throttle.lastSubmitted)
+	}
+	if code := submit(""); code != http.StatusOK {
+		t.Errorf("Expected the corrected submission to be accepted, got %d", code)
+	}
+	if len(throttle.lastSubmitted) != 1 {
+		t.Errorf("Expected the stored lead to be recorded, got %v", throttle.lastSubmitted)
+	}
+}
+
+// slowLookupLeadRepository stores created leads; its duplicate lookup lasts until the
+// request context is done
+type slowLookupLeadRepository struct {
+	quotaLeadRepository
+}
+
+func (m *slowLookupLeadRepository) FindLeadByPayloadHash(ctx context.Context, sourceID *string, payloadHash string, since time.Time) (*models.InboundLead, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestHandleLeadWebhook_TimedOutRequestNotStored(t *testing.T) {
+	repo := &slowLookupLeadRepository{}
+	handler := NewWebhookHandler(repo, &MockQueue{})
+	handler.SetDeduplicationGracePeriod(time.Minute)
+
+	finished := make(chan struct{})
+	timed := NewTimeoutMiddleware(50 * time.Millisecond).Timeout(func(w http.ResponseWriter, r *http.Request) {
+		defer close(finished)
+		handler.HandleLeadWebhook(w, r)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader([]byte(`{"phone": "+49 151 1234567"}`)))
+	rr := httptest.NewRecorder()
+	timed(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d", rr.Code)
+	}
+	select {
+	case <-finished:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the handler to finish after the timeout")
+	}
+	if len(repo.leads) != 0 {
+		t.Errorf("Expected no lead to be stored after the timeout, got %d", len(repo.leads))
+	}
+}
+
+// slowCreateLeadRepository stores created leads after the given delay
+type slowCreateLeadRepository struct {
+	quotaLeadRepository
+	delay time.Duration
+}
+
+func (m *slowCreateLeadRepository) CreateLead(ctx context.Context, lead *models.InboundLead) error {
+	time.Sleep(m.delay)
+	return m.quotaLeadRepository.CreateLead(ctx, lead)
+}
+
+// contextCheckingQueue fails enqueuing with the context error, like a database call would
+type contextCheckingQueue struct {
+	leadJobQueue
+}
+
+func (q *contextCheckingQueue) EnqueueReturningID(ctx context.Context, jobType string, payload map[string]interface{}) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return q.leadJobQueue.EnqueueReturningID(ctx, jobType, payload)
+}
+
+// Test a lead whose storing outlasts the request deadline still gets its job
+func TestHandleLeadWebhook_DeadlineAfterStoringEnqueuesJob(t *testing.T) {
+	repo := &slowCreateLeadRepository{delay: 100 * time.Millisecond}
+	jobQueue := &contextCheckingQueue{}
+	handler := NewWebhookHandler(repo, jobQueue)
+
+	finished := make(chan struct{})
+	timed := NewTimeoutMiddleware(50 * time.Millisecond).Timeout(func(w http.ResponseWriter, r *http.Request) {
+		defer close(finished)
+		handler.HandleLeadWebhook(w, r)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader([]byte(`{"phone": "+49 151 1234567"}`)))
+	rr := httptest.NewRecorder()
+	timed(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d", rr.Code)
+	}
+	select {
+	case <-finished:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the handler to finish after the timeout")
+	}
+	if len(repo.leads) != 1 {
+		t.Fatalf("Expected the lead to be stored, got %d leads", len(repo.leads))
+	}
+	if len(jobQueue.active) != 1 {
+		t.Errorf("Expected the job of the stored lead to be enqueued, got %d jobs", len(jobQueue.active))
+	}
+}
+
+// quotaLeadRepository stores created leads and counts them per source
+type quotaLeadRepository struct {
+	MockLeadRepository
+	leads []*models.InboundLead
+}
+
+func (m *quotaLeadRepository) CreateLead(ctx context.Context, lead *models.InboundLead) error {
+	m.leads = append(m.leads, lead)
+	return m.MockLeadRepository.CreateLead(ctx, lead)
+}
+
+func (m *quotaLeadRepository) CountLeadsForSourceSince(ctx context.Context, sourceID string, since time.Time) (int, error) {
+	count := 0
+	for _, lead := range m.leads {
+		if lead.SourceID != nil && *lead.SourceID == sourceID && !lead.ReceivedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// submitFromSource posts a lead from the given source at the given time
+func submitFromSource(handler *WebhookHandler, at time.Time, sourceID string) *httptest.ResponseRecorder {
+	handler.now = func() time.Time { return at }
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader([]byte(`{"phone": "+49 151 1234567"}`)))
+	if sourceID != "" {
+		req.Header.Set(SourceIDHeader, sourceID)
+	}
+	rr := httptest.NewRecorder()
+	handler.HandleLeadWebhook(rr, req)
+	return rr
+}
+
+// Test a source is accepted up to its quota and rejected with 429 beyond it in a rolling 24h window
+func TestHandleLeadWebhook_SourceQuotaRolling(t *testing.T) {
+	mockRepo := &quotaLeadRepository{}
+	handler := NewWebhookHandler(mockRepo, &MockQueue{})
+	handler.SetSourceQuotas(config.SourceQuotaConfig{Quotas: map[string]int{"partner_a": 2}})
+	start := time.Date(2025, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if rr := submitFromSource(handler, start.Add(time.Duration(i)*time.Hour), "partner_a"); rr.Code != http.StatusOK {
+			t.Fatalf("Expected lead %d within the quota to be accepted, got %d", i+1, rr.Code)
+		}
+	}
+
+	rr := submitFromSource(handler, start.Add(2*time.Hour), "partner_a")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429 beyond the quota, got %d", rr.Code)
+	}
+	var response ErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Code != ErrorCodeSourceQuotaExceeded {
+		t.Errorf("Expected code %s, got %q", ErrorCodeSourceQuotaExceeded, response.Code)
+	}
+	if len(mockRepo.leads) != 2 {
+		t.Errorf("Expected the rejected lead not to be stored, got %d leads", len(mockRepo.leads))
+	}
+
+	// Other sources and leads without a source have no quota
+	if rr := submitFromSource(handler, start.Add(2*time.Hour), "partner_b"); rr.Code != http.StatusOK {
+		t.Errorf("Expected a source without quota to be accepted, got %d", rr.Code)
+	}
+	if rr := submitFromSource(handler, start.Add(2*time.Hour), ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected a lead without source to be accepted, got %d", rr.Code)
+	}
+
+	// The first lead leaves the window once it is more than 24 hours old
+	if rr := submitFromSource(handler, start.Add(24*time.Hour+time.Minute), "partner_a"); rr.Code != http.StatusOK {
+		t.Errorf("Expected a lead after the first one left the window to be accepted, got %d", rr.Code)
+	}
+}
+
+// hashLeadRepository stores created leads and finds them by payload hash
+type hashLeadRepository struct {
+	MockLeadRepository
+	leads []*models.InboundLead
+}
+
+func (m *hashLeadRepository) CreateLead(ctx context.Context, lead *models.InboundLead) error {
+	lead.ID = int64(len(m.leads) + 1)
+	lead.CreatedAt = lead.ReceivedAt
+	m.leads = append(m.leads, lead)
+	return nil
+}
+
+func (m *hashLeadRepository) FindLeadByPayloadHash(ctx context.Context, sourceID *string, payloadHash string, since time.Time) (*models.InboundLead, error) {
+	for i := len(m.leads) - 1; i >= 0; i-- {
+		lead := m.leads[i]
+		sameSource := (lead.SourceID == nil && sourceID == nil) || (lead.SourceID != nil && sourceID != nil && *lead.SourceID == *sourceID)
+		if sameSource && lead.PayloadHash != nil && *lead.PayloadHash == payloadHash && lead.CreatedAt.After(since) {
+			return lead, nil
+		}
+	}
+	return nil, nil
+}
+
+// Test a repeated payload returns the existing lead within the grace period and creates a new lead after it
+func TestHandleLeadWebhook_DeduplicationGracePeriod(t *testing.T) {
+	mockRepo := &hashLeadRepository{}
+	handler := NewWebhookHandler(mockRepo, &MockQueue{})
+	handler.SetDeduplicationGracePeriod(24 * time.Hour)
+	start := time.Date(2025, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	submit := func(at time.Time, body string) WebhookResponse {
+		t.Helper()
+		handler.now = func() time.Time { return at }
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader([]byte(body)))
+		rr := httptest.NewRecorder()
+		handler.HandleLeadWebhook(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+		var response WebhookResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	first := submit(start, `{"phone": "+49 151 1234567", "zipcode": "66123"}`)
+
+	// Key order does not matter for the payload hash
+	repeated := submit(start.Add(23*time.Hour), `{"zipcode": "66123", "phone": "+49 151 1234567"}`)
+	if repeated.LeadID != first.LeadID {
+		t.Errorf("Expected the existing lead %d within the grace period, got %d", first.LeadID, repeated.LeadID)
+	}
+	if len(mockRepo.leads) != 1 {
+		t.Fatalf("Expected no new lead within the grace period, got %d leads", len(mockRepo.leads))
+	}
+
+	if other := submit(start.Add(time.Hour), `{"phone": "+49 151 7654321", "zipcode": "66123"}`); other.LeadID == first.LeadID {
+		t.Error("Expected a different payload to create a new lead")
+	}
+
+	later := submit(start.Add(25*time.Hour), `{"phone": "+49 151 1234567", "zipcode": "66123"}`)
+	if later.LeadID == first.LeadID {
+		t.Error("Expected a new lead after the grace period")
+	}
+	if len(mockRepo.leads) != 3 {
+		t.Errorf("Expected 3 leads, got %d", len(mockRepo.leads))
+	}
+
+	// The grace period restarts with the new lead
+	if again := submit(start.Add(30*time.Hour), `{"phone": "+49 151 1234567", "zipcode": "66123"}`); again.LeadID != later.LeadID {
+		t.Errorf("Expected the newest lead %d, got %d", later.LeadID, again.LeadID)
+	}
+}
+
+// Test an identical payload from another source creates its own lead
+func TestHandleLeadWebhook_DeduplicationPerSource(t *testing.T) {
+	mockRepo := &hashLeadRepository{}
+	handler := NewWebhookHandler(mockRepo, &MockQueue{})
+	handler.SetDeduplicationGracePeriod(24 * time.Hour)
+	start := time.Date(2025, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	if rr := submitFromSource(handler, start, "partner_a"); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if rr := submitFromSource(handler, start.Add(time.Minute), "partner_b"); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if rr := submitFromSource(handler, start.Add(2*time.Minute), ""); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if len(mockRepo.leads) != 3 {
+		t.Fatalf("Expected a lead per source, got %d leads", len(mockRepo.leads))
+	}
+
+	if rr := submitFromSource(handler, start.Add(3*time.Minute), "partner_b"); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if len(mockRepo.leads) != 3 {
+		t.Errorf("Expected the repeated payload of partner_b to return its lead, got %d leads", len(mockRepo.leads))
+	}
+}
+
+// Test a sample rate looks up only the sampled payloads, and always the same ones
+func TestHandleLeadWebhook_DeduplicationSampleRate(t *testing.T) {
+	mockRepo := &hashLeadRepository{}
+	handler := NewWebhookHandler(mockRepo, &MockQueue{})
+	handler.SetDeduplicationGracePeriod(24 * time.Hour)
+	handler.SetDeduplicationSampleRate(0.5)
+	handler.now = func() time.Time { return time.Date(2025, 1, 7, 10, 0, 0, 0, time.UTC) }
+
+	sampled := 0
+	for i := 0; i < 20; i++ {
+		payload := models.JSONB{"phone": fmt.Sprintf("+49 151 %07d", i)}
+		body, _ := json.Marshal(payload)
+		hash, err := payload.Hash()
+		if err != nil {
+			t.Fatalf("Failed to hash payload: %v", err)
+		}
+
+		before := len(mockRepo.leads)
+		for attempt := 0; attempt < 3; attempt++ {
+			rr := httptest.NewRecorder()
+			handler.HandleLeadWebhook(rr, httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader(body)))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", rr.Code)
+			}
+		}
+
+		// A sampled payload keeps its first lead; a skipped one creates a lead per request
+		created := len(mockRepo.leads) - before
+		if inSample(hash, 0.5) {
+			sampled++
+			if created != 1 {
+				t.Errorf("Expected sampled payload %d to be deduplicated, got %d leads", i, created)
+			}
+		} else if created != 3 {
+			t.Errorf("Expected skipped payload %d never to be looked up, got %d leads", i, created)
+		}
+	}
+	if sampled == 0 || sampled == 20 {
+		t.Errorf("Expected some but not all payloads to be sampled, got %d of 20", sampled)
+	}
+}
+
+func TestInSample_Rate(t *testing.T) {
+	const iterations = 10000
+	sampled := 0
+	for i := 0; i < iterations; i++ {
+		if inSample(fmt.Sprintf("request-%d", i), 0.5) {
+			sampled++
+		}
+	}
+
+	// Four standard deviations of a fair coin over 10000 draws
+	if sampled < 4800 || sampled > 5200 {
+		t.Errorf("Expected about 5000 of %d keys to be sampled, got %d", iterations, sampled)
+	}
+}
+
+func TestInSample_Deterministic(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("request-%d", i)
+		first := inSample(key, 0.5)
+		for j := 0; j < 3; j++ {
+			if inSample(key, 0.5) != first {
+				t.Fatalf("Expected key %q to always make the same decision", key)
+			}
+		}
+
+		// A key sampled at some rate is sampled at every higher rate
+		if first && !inSample(key, 0.75) {
+			t.Errorf("Expected key %q sampled at 0.5 to be sampled at 0.75", key)
+		}
+		if !inSample(key, 1) || inSample(key, 0) {
+			t.Errorf("Expected key %q to be always sampled at 1 and never at 0", key)
+		}
+	}
+}
+
+// leadJobQueue holds at most one active process_lead job per lead, like the active lead
+// index of the database queue, and fails enqueuing while unavailable is set
+type leadJobQueue struct {
+	MockQueue
+	unavailable bool
+	active      map[int64]bool
+}
+
+func (q *leadJobQueue) EnqueueReturningID(ctx context.Context, jobType string, payload map[string]interface{}) (int64, error) {
+	if q.unavailable {
+		return 0, queue.ErrQueueUnavailable
+	}
+	leadID, _ := queue.GetLeadID(payload)
+	if q.active[leadID] {
+		return 0, queue.ErrDuplicateJob
+	}
+	if q.active == nil {
+		q.active = make(map[int64]bool)
+	}
+	q.active[leadID] = true
+	return leadID, nil
+}
+
+// Test a repeated payload enqueues the job of a received lead whose first request failed to enqueue it
+func TestHandleLeadWebhook_DeduplicationEnqueuesStrandedLead(t *testing.T) {
+	mockRepo := &hashLeadRepository{}
+	jobQueue := &leadJobQueue{unavailable: true}
+	handler := NewWebhookHandler(mockRepo, jobQueue)
+	handler.SetDeduplicationGracePeriod(24 * time.Hour)
+	start := time.Date(2025, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	if rr := submitFromSource(handler, start, "partner_a"); rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503 while the queue is unavailable, got %d", rr.Code)
+	}
+	if len(mockRepo.leads) != 1 || len(jobQueue.active) != 0 {
+		t.Fatalf("Expected a stored lead without job, got %d leads and %d jobs", len(mockRepo.leads), len(jobQueue.active))
+	}
+
+	// The retry is answered 503 as well while the job still cannot be enqueued
+	if rr := submitFromSource(handler, start.Add(time.Minute), "partner_a"); rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503 while the queue is unavailable, got %d", rr.Code)
+	}
+
+	jobQueue.unavailable = false
+	if rr := submitFromSource(handler, start.Add(2*time.Minute), "partner_a"); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if len(mockRepo.leads) != 1 {
+		t.Fatalf("Expected the existing lead to be returned, got %d leads", len(mockRepo.leads))
+	}
+	if !jobQueue.active[mockRepo.leads[0].ID] {
+		t.Fatal("Expected the job of the existing lead to be enqueued")
+	}
+
+	// A lead that already has its job is acknowledged without another one
+	if rr := submitFromSource(handler, start.Add(3*time.Minute), "partner_a"); rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for a lead with an active job, got %d", rr.Code)
+	}
+}
+
+// Test a calendar-day quota resets at midnight in the configured timezone
+func TestHandleLeadWebhook_SourceQuotaCalendarDay(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Timezone data not available: %v", err)
+	}
+	mockRepo := &quotaLeadRepository{}
+	handler := NewWebhookHandler(mockRepo, &MockQueue{})
+	handler.SetSourceQuotas(config.SourceQuotaConfig{
+		Quotas:   map[string]int{"partner_a": 1},
+		Window:   config.SourceQuotaWindowCalendarDay,
+		Timezone: "Europe/Berlin",
+	})
+	lateEvening := time.Date(2025, 1, 7, 23, 30, 0, 0, berlin)
+
+	if rr := submitFromSource(handler, lateEvening, "partner_a"); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the first lead to be accepted, got %d", rr.Code)
+	}
+
+	rr := submitFromSource(handler, lateEvening.Add(15*time.Minute), "partner_a")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429 beyond the quota, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "900" {
+		t.Errorf("Expected Retry-After until midnight (900), got %q", got)
+	}
+
+	if rr := submitFromSource(handler, lateEvening.Add(35*time.Minute), "partner_a"); rr.Code != http.StatusOK {
+		t.Errorf("Expected a lead on the next day to be accepted, got %d", rr.Code)
+	}
+}
+
+// Test leads beyond the quota are accepted and flagged when the action is flag
+func TestHandleLeadWebhook_SourceQuotaFlag(t *testing.T) {
+	mockRepo := &quotaLeadRepository{}
+	handler := NewWebhookHandler(mockRepo, &MockQueue{})
+	handler.SetSourceQuotas(config.SourceQuotaConfig{
+		Quotas: map[string]int{"partner_a": 1},
+		Action: config.SourceQuotaActionFlag,
+	})
+	start := time.Date(2025, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	if rr := submitFromSource(handler, start, "partner_a"); rr.Code != http.StatusOK || rr.Header().Get(models.SourceQuotaExceededHeader) != "" {
+		t.Fatalf("Expected the first lead to be accepted unflagged, got %d", rr.Code)
+	}
+	rr := submitFromSource(handler, start.Add(time.Minute), "partner_a")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected the lead beyond the quota to be accepted, got %d", rr.Code)
+	}
+	if rr.Header().Get(models.SourceQuotaExceededHeader) != "true" {
+		t.Error("Expected the response to report the exceeded quota")
+	}
+
+	if len(mockRepo.leads) != 2 {
+		t.Fatalf("Expected 2 stored leads, got %d", len(mockRepo.leads))
+	}
+	if mockRepo.leads[0].SourceQuotaExceeded() || !mockRepo.leads[1].SourceQuotaExceeded() {
+		t.Error("Expected only the lead beyond the quota to be flagged")
+	}
+}
+
+// Test the callback URL header is kept with the source headers for sender notifications
+func TestHandleLeadWebhook_CallbackURLStored(t *testing.T) {
+	mockRepo := &capturingLeadRepository{}
+	handler := NewWebhookHandler(mockRepo, &MockQueue{})
+
+	payloadBytes, _ := json.Marshal(map[string]interface{}{"email": "test@example.com"})
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader(payloadBytes))
+	req.Header.Set(models.CallbackURLHeader, "https://sender.example.com/outcome")
+
+	rr := httptest.NewRecorder()
+	handler.HandleLeadWebhook(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	if got := mockRepo.created.CallbackURL(); got != "https://sender.example.com/outcome" {
+		t.Errorf("Expected stored callback URL, got '%s'", got)
+	}
+}
+
+// Test unusable callback URLs are rejected before the lead is stored
+func TestHandleLeadWebhook_InvalidCallbackURL(t *testing.T) {
+	for _, callbackURL := range []string{"not a url", "ftp://sender.example.com/outcome", "/relative/path"} {
+		mockRepo := &capturingLeadRepository{}
+		handler := NewWebhookHandler(mockRepo, &MockQueue{})
+
+		payloadBytes, _ := json.Marshal(map[string]interface{}{"email": "test@example.com"})
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader(payloadBytes))
+		req.Header.Set(models.CallbackURLHeader, callbackURL)
+
+		rr := httptest.NewRecorder()
+		handler.HandleLeadWebhook(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %q, got %d", callbackURL, rr.Code)
+		}
+
+		if mockRepo.created != nil {
+			t.Errorf("Expected no lead to be stored for %q", callbackURL)
+		}
+	}
+}
+
+// Test Accept: text/plain returns a plain-text acknowledgement
+func TestHandleLeadWebhook_AcceptTextPlain(t *testing.T) {
+	handler := NewWebhookHandler(&MockLeadRepository{}, &MockQueue{})
+
+	payloadBytes, _ := json.Marshal(map[string]interface{}{"email": "test@example.com"})
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader(payloadBytes))
+	req.Header.Set("Accept", "text/plain")
+
+	rr := httptest.NewRecorder()
+	handler.HandleLeadWebhook(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	if contentType := rr.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("Expected text/plain content type, got %s", contentType)
+	}
+
+	if body := rr.Body.String(); body != "OK" {
+		t.Errorf("Expected body 'OK', got '%s'", body)
+	}
+}
+
+// Test Accept: application/xml returns a minimal XML acknowledgement with the lead id
+func TestHandleLeadWebhook_AcceptXML(t *testing.T) {
+	handler := NewWebhookHandler(&MockLeadRepository{}, &MockQueue{})
+
+	payloadBytes, _ := json.Marshal(map[string]interface{}{"email": "test@example.com"})
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader(payloadBytes))
+	req.Header.Set("Accept", "application/xml")
+
+	rr := httptest.NewRecorder()
+	handler.HandleLeadWebhook(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/xml" {
+		t.Errorf("Expected application/xml content type, got %s", contentType)
+	}
+
+	var ack struct {
+		XMLName xml.Name `xml:"ack"`
+		LeadID  int64    `xml:"lead_id"`
+		Status  string   `xml:"status"`
+	}
+	if err := xml.Unmarshal(rr.Body.Bytes(), &ack); err != nil {
+		t.Fatalf("Failed to decode XML ack: %v (%s)", err, rr.Body.String())
+	}
+
+	if ack.LeadID != 12345 {
+		t.Errorf("Expected lead_id 12345, got %d", ack.LeadID)
+	}
+
+	if ack.Status != "RECEIVED" {
+		t.Errorf("Expected status RECEIVED, got %s", ack.Status)
+	}
+}
+
+// Test WEBHOOK_RESPONSE_FORMAT sets the default success format but errors stay JSON
+func TestHandleLeadWebhook_ConfiguredResponseFormat(t *testing.T) {
+	handler := NewWebhookHandlerWithConfig(&MockLeadRepository{}, &MockQueue{}, config.WebhookConfig{ResponseFormat: ResponseFormatText})
+
+	payloadBytes, _ := json.Marshal(map[string]interface{}{"email": "test@example.com"})
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader(payloadBytes))
+
+	rr := httptest.NewRecorder()
+	handler.HandleLeadWebhook(rr, req)
+
+	if body := rr.Body.String(); body != "OK" {
+		t.Errorf("Expected configured plain-text body 'OK', got '%s'", body)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewBufferString("{invalid"))
+	rr = httptest.NewRecorder()
+	handler.HandleLeadWebhook(rr, req)
+
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected JSON error response, got %s", contentType)
+	}
+}
+
+// Test errors follow an explicitly negotiated format
+func TestHandleLeadWebhook_ErrorNegotiatedXML(t *testing.T) {
+	handler := NewWebhookHandler(&MockLeadRepository{}, &MockQueue{})
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewBufferString("{invalid"))
+	req.Header.Set("Accept", "text/xml, */*;q=0.1")
+
+	rr := httptest.NewRecorder()
+	handler.HandleLeadWebhook(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", rr.Code)
+	}
+
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/xml" {
+		t.Errorf("Expected application/xml content type, got %s", contentType)
+	}
+
+	if !strings.Contains(rr.Body.String(), "<message>malformed JSON payload</message>") {
+		t.Errorf("Expected XML error message, got %s", rr.Body.String())
+	}
+}
+
+func TestHandleLeadWebhook_StoresOnlyAttachmentMetadata(t *testing.T) {
+	pdf := base64.StdEncoding.EncodeToString([]byte("%PDF-1.4\n" + strings.Repeat("x", 100)))
+	mapping := config.NewMappingWatcher(&config.Config{AttributeMapping: config.AttributeMappingConfig{
+		Mapping: map[string]config.AttributeDefinition{
+			"utility_bill": {Type: "base64", Required: true},
+			"photo":        {Type: "base64"},
+		},
+	}}, time.Second)
+
+	repo := &capturingLeadRepository{}
+	handler := NewWebhookHandler(repo, &MockQueue{})
+	handler.SetAttachmentMapping(mapping)
+
+	body := `{"phone": "+49 151 1234567", "utility_bill": "` + pdf + `", "photo": "not base64!"}`
+	rr := httptest.NewRecorder()
+	handler.HandleLeadWebhook(rr, httptest.NewRequest(http.MethodPost, "/webhooks/leads", strings.NewReader(body)))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if repo.created == nil {
+		t.Fatal("Expected the lead to be stored")
+	}
+	if _, ok := repo.created.RawPayload["utility_bill"]; ok {
+		t.Error("Expected the attachment bytes not to be stored")
+	}
+	if _, ok := repo.created.RawPayload["photo"]; ok {
+		t.Error("Expected the invalid optional attachment to be dropped")
+	}
+	expected := models.JSONB{"utility_bill": map[string]interface{}{"size_bytes": 109, "mime_type": "application/pdf"}}
+	if !reflect.DeepEqual(repo.created.AttachmentsMetadata, expected) {
+		t.Errorf("Expected attachment metadata %v, got %v", expected, repo.created.AttachmentsMetadata)
+	}
+
+	// An invalid required attachment is rejected without storing the lead
+	repo.created = nil
+	rr = httptest.NewRecorder()
+	body = `{"phone": "+49 151 1234567", "utility_bill": "not base64!"}`
+	handler.HandleLeadWebhook(rr, httptest.NewRequest(http.MethodPost, "/webhooks/leads", strings.NewReader(body)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rr.Code)
+	}
+	if repo.created != nil {
+		t.Error("Expected no lead to be stored")
+	}
+}
+
+// capturingLeadRepository records the lead passed to CreateLead
+type capturingLeadRepository struct {
+	MockLeadRepository
+	created *models.InboundLead
+}
+
+func (m *capturingLeadRepository) CreateLead(ctx context.Context, lead *models.InboundLead) error {
+	m.created = lead
+	return m.MockLeadRepository.CreateLead(ctx, lead)
+}
+
 // MockLeadRepositoryWithError simulates repository errors
 type MockLeadRepositoryWithError struct {
 	createLeadError error
@@ -202,10 +1389,18 @@ func (m *MockLeadRepositoryWithError) UpdateLeadStatus(ctx context.Context, id i
 	return nil
 }
 
+func (m *MockLeadRepositoryWithError) UpdateLeadStatusFrom(ctx context.Context, id int64, from, to models.LeadStatus) error {
+	return nil
+}
+
 func (m *MockLeadRepositoryWithError) UpdateLeadWithPayloads(ctx context.Context, id int64, normalizedPayload, customerPayload models.JSONB) error {
 	return nil
 }
 
+func (m *MockLeadRepositoryWithError) UpdateLeadAttachments(ctx context.Context, id int64, metadata models.JSONB, attachmentKeys []string) error {
+	return nil
+}
+
 func (m *MockLeadRepositoryWithError) UpdateLeadRejection(ctx context.Context, id int64, reason models.RejectionReason) error {
 	return nil
 }
@@ -218,14 +1413,42 @@ func (m *MockLeadRepositoryWithError) UpdateLeadStatusTx(ctx context.Context, tx
 	return nil
 }
 
+func (m *MockLeadRepositoryWithError) UpdateLeadStatusFromTx(ctx context.Context, tx *sql.Tx, id int64, from, to models.LeadStatus) error {
+	return nil
+}
+
 func (m *MockLeadRepositoryWithError) GetLeadCountsByStatus(ctx context.Context) (map[string]int, error) {
 	return make(map[string]int), nil
 }
 
+func (m *MockLeadRepositoryWithError) GetLeadCountsByStatusForSource(ctx context.Context, sourceID string) (map[string]int, error) {
+	return make(map[string]int), nil
+}
+
+func (m *MockLeadRepositoryWithError) GetLeadCountsByStatusPerSource(ctx context.Context) (map[string]map[string]int, error) {
+	return make(map[string]map[string]int), nil
+}
+
+func (m *MockLeadRepositoryWithError) CountLeadsForSourceSince(ctx context.Context, sourceID string, since time.Time) (int, error) {
+	return 0, nil
+}
+
 func (m *MockLeadRepositoryWithError) GetRecentLeads(ctx context.Context, limit int) ([]*models.InboundLead, error) {
 	return []*models.InboundLead{}, nil
 }
 
+func (m *MockLeadRepositoryWithError) FindLeadsByContact(ctx context.Context, email, phone string, limit int) ([]*models.InboundLead, error) {
+	return []*models.InboundLead{}, nil
+}
+
+func (m *MockLeadRepositoryWithError) FindLeadByPayloadHash(ctx context.Context, sourceID *string, payloadHash string, since time.Time) (*models.InboundLead, error) {
+	return nil, nil
+}
+
+func (m *MockLeadRepositoryWithError) FindInSuppressionList(ctx context.Context, phone, email string) (*models.SuppressionEntry, error) {
+	return nil, nil
+}
+
 // MockQueueWithError simulates queue errors
 type MockQueueWithError struct {
 	enqueueError error
@@ -238,10 +1461,21 @@ func (m *MockQueueWithError) Enqueue(ctx context.Context, jobType string, payloa
 	return nil
 }
 
+func (m *MockQueueWithError) EnqueueReturningID(ctx context.Context, jobType string, payload map[string]interface{}) (int64, error) {
+	if err := m.Enqueue(ctx, jobType, payload); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
 func (m *MockQueueWithError) EnqueueWithDelay(ctx context.Context, jobType string, payload map[string]interface{}, delay time.Duration) error {
 	return nil
 }
 
+func (m *MockQueueWithError) EnqueueWithPriority(ctx context.Context, jobType string, payload map[string]interface{}, delay time.Duration, priority int) error {
+	return nil
+}
+
 func (m *MockQueueWithError) Dequeue(ctx context.Context) (*queue.Job, error) {
 	return nil, nil
 }
@@ -254,6 +1488,10 @@ func (m *MockQueueWithError) Retry(ctx context.Context, jobID int64, delay time.
 	return nil
 }
 
+func (m *MockQueueWithError) RetryWithPriority(ctx context.Context, jobID int64, delay time.Duration, priority int) error {
+	return nil
+}
+
 func (m *MockQueueWithError) Fail(ctx context.Context, jobID int64, errorMsg string) error {
 	return nil
 }
@@ -265,3 +1503,57 @@ func (m *MockQueueWithError) HealthCheck(ctx context.Context) error {
 func (m *MockQueueWithError) Close() error {
 	return nil
 }
+
+// payloadRecordingQueue captures the payload of enqueued jobs
+type payloadRecordingQueue struct {
+	MockQueue
+	payloads []map[string]interface{}
+}
+
+func (q *payloadRecordingQueue) Enqueue(ctx context.Context, jobType string, payload map[string]interface{}) error {
+	q.payloads = append(q.payloads, payload)
+	return nil
+}
+
+func (q *payloadRecordingQueue) EnqueueReturningID(ctx context.Context, jobType string, payload map[string]interface{}) (int64, error) {
+	q.payloads = append(q.payloads, payload)
+	return int64(len(q.payloads)), nil
+}
+
+func TestHandleLeadWebhook_TracesRequest(t *testing.T) {
+	exporter := tracing.NewInMemoryExporter()
+	tracing.SetTracer(tracing.NewTracer(exporter))
+	t.Cleanup(func() { tracing.SetTracer(nil) })
+
+	jobQueue := &payloadRecordingQueue{}
+	handler := NewWebhookHandler(&MockLeadRepository{}, jobQueue)
+
+	payloadBytes, _ := json.Marshal(map[string]interface{}{"zipcode": "66001"})
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/leads", bytes.NewReader(payloadBytes))
+	req.Header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	rr := httptest.NewRecorder()
+	handler.HandleLeadWebhook(rr, req)
+
+	spans := exporter.Spans()
+	if len(spans) != 1 || spans[0].Name != "HandleLeadWebhook" {
+		t.Fatalf("Expected a HandleLeadWebhook span, got %+v", spans)
+	}
+	span := spans[0]
+	if span.Attributes[tracing.AttributeLeadID] != "12345" {
+		t.Errorf("Expected lead.id attribute 12345, got %q", span.Attributes[tracing.AttributeLeadID])
+	}
+	if span.Attributes[tracing.AttributeCorrelationID] != rr.Header().Get("X-Correlation-ID") {
+		t.Errorf("Expected correlation.id attribute to match the response header")
+	}
+	if traceID, _ := tracing.ParseTraceparent(req.Header.Get(tracing.TraceparentHeader)); span.Context.TraceID != traceID.TraceID {
+		t.Error("Expected the span to continue the incoming trace")
+	}
+
+	if len(jobQueue.payloads) != 1 {
+		t.Fatalf("Expected 1 enqueued job, got %d", len(jobQueue.payloads))
+	}
+	if traceparent, _ := jobQueue.payloads[0]["traceparent"].(string); traceparent != span.Context.Traceparent() {
+		t.Errorf("Expected job payload to carry the span's traceparent, got %q", traceparent)
+	}
+}
@@ -0,0 +1,197 @@
+//go:build integration
+
+package integration
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/database"
+	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/queue"
+)
+
+// schemaDB is the migrated database inspected by TestDatabaseSchema; nil when unavailable
+var schemaDB *sql.DB
+
+// schemaUnavailable explains why schemaDB is nil
+var schemaUnavailable string
+
+// TestMain applies the migrations and creates the queue table once before the tests run
+func TestMain(m *testing.M) {
+	logger.Init()
+
+	dbWrapper, err := migrateTestDatabase()
+	if err != nil {
+		schemaUnavailable = err.Error()
+	} else {
+		schemaDB = dbWrapper.DB
+	}
+
+	code := m.Run()
+	if dbWrapper != nil {
+		dbWrapper.Close()
+	}
+	os.Exit(code)
+}
+
+// migrateTestDatabase connects to the configured database and brings its schema up to date
+func migrateTestDatabase() (*database.DB, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	dbWrapper, err := database.InitFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := dbWrapper.DB.Ping(); err != nil {
+		dbWrapper.Close()
+		return nil, fmt.Errorf("database not available: %w", err)
+	}
+
+	if err := database.RunMigrations(dbWrapper, "../../migrations"); err != nil {
+		dbWrapper.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+	// The queue creates its table on startup rather than through a migration
+	if _, err := queue.NewDBQueue(dbWrapper.DB); err != nil {
+		dbWrapper.Close()
+		return nil, fmt.Errorf("failed to create queue table: %w", err)
+	}
+	return dbWrapper, nil
+}
+
+// quotedValuePattern matches the string literals of a check clause, e.g. 'READY'::character varying
+var quotedValuePattern = regexp.MustCompile(`'([^']*)'`)
+
+// TestDatabaseSchema verifies the constraints the application relies on exist after migration
+func TestDatabaseSchema(t *testing.T) {
+	if schemaDB == nil {
+		t.Skipf("Skipping test - %s", schemaUnavailable)
+	}
+
+	t.Run("inbound_lead.status is NOT NULL", func(t *testing.T) {
+		assertNotNull(t, schemaDB, "inbound_lead", "status")
+	})
+
+	t.Run("inbound_lead.status check matches LeadStatus values", func(t *testing.T) {
+		var expected []string
+		for _, status := range models.AllLeadStatuses() {
+			expected = append(expected, string(status))
+		}
+		assertCheckValues(t, schemaDB, "inbound_lead", "status", expected)
+	})
+
+	t.Run("delivery_attempt.lead_id references inbound_lead.id", func(t *testing.T) {
+		assertForeignKey(t, schemaDB, "delivery_attempt", "lead_id", "inbound_lead", "id")
+	})
+
+	t.Run("background_jobs.status has a check constraint", func(t *testing.T) {
+		assertNotNull(t, schemaDB, "background_jobs", "status")
+		assertCheckValues(t, schemaDB, "background_jobs", "status", []string{"pending", "processing", "completed", "failed"})
+	})
+}
+
+// assertNotNull fails unless the column exists and is NOT NULL
+func assertNotNull(t *testing.T, db *sql.DB, table, column string) {
+	t.Helper()
+
+	var isNullable string
+	err := db.QueryRow(`
+		SELECT is_nullable
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2
+	`, table, column).Scan(&isNullable)
+	if err == sql.ErrNoRows {
+		t.Fatalf("Column %s.%s does not exist", table, column)
+	}
+	if err != nil {
+		t.Fatalf("Failed to inspect column %s.%s: %v", table, column, err)
+	}
+	if isNullable != "NO" {
+		t.Errorf("Expected %s.%s to be NOT NULL", table, column)
+	}
+}
+
+// assertCheckValues fails unless a check constraint on the column allows exactly the expected values
+func assertCheckValues(t *testing.T, db *sql.DB, table, column string, expected []string) {
+	t.Helper()
+
+	// NOT NULL columns show up as check constraints too, so those clauses are skipped
+	rows, err := db.Query(`
+		SELECT cc.check_clause
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.check_constraints cc
+			ON cc.constraint_schema = tc.constraint_schema AND cc.constraint_name = tc.constraint_name
+		JOIN information_schema.constraint_column_usage ccu
+			ON ccu.constraint_schema = tc.constraint_schema AND ccu.constraint_name = tc.constraint_name
+		WHERE tc.table_schema = current_schema() AND tc.table_name = $1
+			AND tc.constraint_type = 'CHECK' AND ccu.column_name = $2
+			AND cc.check_clause NOT LIKE '%IS NOT NULL'
+	`, table, column)
+	if err != nil {
+		t.Fatalf("Failed to inspect check constraints of %s.%s: %v", table, column, err)
+	}
+	defer rows.Close()
+
+	var clauses []string
+	for rows.Next() {
+		var clause string
+		if err := rows.Scan(&clause); err != nil {
+			t.Fatalf("Failed to scan check clause: %v", err)
+		}
+		clauses = append(clauses, clause)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Failed to read check constraints: %v", err)
+	}
+	if len(clauses) == 0 {
+		t.Fatalf("Expected a check constraint on %s.%s", table, column)
+	}
+
+	want := append([]string{}, expected...)
+	sort.Strings(want)
+	for _, clause := range clauses {
+		var allowed []string
+		for _, match := range quotedValuePattern.FindAllStringSubmatch(clause, -1) {
+			allowed = append(allowed, match[1])
+		}
+		sort.Strings(allowed)
+		if fmt.Sprint(allowed) == fmt.Sprint(want) {
+			return
+		}
+	}
+	t.Errorf("Expected a check constraint on %s.%s allowing %v, got %v", table, column, want, clauses)
+}
+
+// assertForeignKey fails unless the column has a foreign key to the referenced column
+func assertForeignKey(t *testing.T, db *sql.DB, table, column, refTable, refColumn string) {
+	t.Helper()
+
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*)
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name
+		JOIN information_schema.constraint_column_usage ccu
+			ON ccu.constraint_schema = tc.constraint_schema AND ccu.constraint_name = tc.constraint_name
+		WHERE tc.table_schema = current_schema() AND tc.constraint_type = 'FOREIGN KEY'
+			AND tc.table_name = $1 AND kcu.column_name = $2
+			AND ccu.table_name = $3 AND ccu.column_name = $4
+	`, table, column, refTable, refColumn).Scan(&count)
+	if err != nil {
+		t.Fatalf("Failed to inspect foreign keys of %s.%s: %v", table, column, err)
+	}
+	if count == 0 {
+		t.Errorf("Expected %s.%s to reference %s.%s", table, column, refTable, refColumn)
+	}
+}
@@ -0,0 +1,85 @@
+//go:build integration
+
+package integration
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/database"
+)
+
+// TestSchemaVersion verifies a fresh database reports the newest migration's version once migrated
+func TestSchemaVersion(t *testing.T) {
+	if schemaDB == nil {
+		t.Skipf("Skipping test - %s", schemaUnavailable)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	dbName := fmt.Sprintf("%s_version_%d", cfg.Database.DBName, time.Now().UnixNano())
+	if _, err := schemaDB.Exec("CREATE DATABASE " + dbName); err != nil {
+		t.Skipf("Skipping test - cannot create a fresh database: %v", err)
+	}
+	defer schemaDB.Exec("DROP DATABASE IF EXISTS " + dbName)
+
+	freshDB, err := database.New(database.Config{
+		Host:     cfg.Database.Host,
+		Port:     cfg.Database.Port,
+		User:     cfg.Database.User,
+		Password: cfg.Database.Password,
+		DBName:   dbName,
+		SSLMode:  cfg.Database.SSLMode,
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to fresh database: %v", err)
+	}
+	defer freshDB.Close()
+
+	runner := database.NewMigrationRunner(freshDB, "../../migrations")
+	if version, err := runner.CurrentVersion(); err == nil {
+		t.Fatalf("Expected no schema_migrations table before migrating, got version %d", version)
+	}
+
+	if err := database.RunMigrations(freshDB, "../../migrations"); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	current, err := runner.CurrentVersion()
+	if err != nil {
+		t.Fatalf("Failed to read schema version: %v", err)
+	}
+	latest, err := runner.LatestVersion()
+	if err != nil {
+		t.Fatalf("Failed to read expected schema version: %v", err)
+	}
+	if want := newestMigrationFile(t); current != want || latest != want {
+		t.Errorf("Expected schema version %d, got current %d and latest %d", want, current, latest)
+	}
+}
+
+// newestMigrationFile returns the version prefix of the newest file in the migrations directory
+func newestMigrationFile(t *testing.T) int {
+	t.Helper()
+	files, err := filepath.Glob("../../migrations/*.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("Failed to list migrations: %v", err)
+	}
+
+	newest := 0
+	for _, file := range files {
+		prefix, _, _ := strings.Cut(filepath.Base(file), "_")
+		if version, err := strconv.Atoi(prefix); err == nil && version > newest {
+			newest = version
+		}
+	}
+	return newest
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// ECSVersion is the Elastic Common Schema version of FormatECS log records
+const ECSVersion = "8.11.0"
+
+// ecsFields maps the log fields of this application to their ECS equivalents, as
+// the object and field name they are nested under
+var ecsFields = map[string][2]string{
+	"error":     {"error", "message"},
+	"trace_id":  {"trace", "id"},
+	"operation": {"event", "action"},
+}
+
+// NewECSHandler returns a handler writing log records as JSON following the Elastic
+// Common Schema: time, level and msg become @timestamp, log.level and message, every
+// record carries ecs.version, and the error, trace_id and operation fields become
+// error.message, trace.id and event.action. Other fields are written unchanged.
+// A ReplaceAttr function in opts is applied before the ECS mapping.
+func NewECSHandler(w io.Writer, opts *slog.HandlerOptions) LogHandler {
+	ecsOpts := slog.HandlerOptions{}
+	if opts != nil {
+		ecsOpts = *opts
+	}
+	replace := ecsOpts.ReplaceAttr
+	ecsOpts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if replace != nil {
+			a = replace(groups, a)
+		}
+		if len(groups) > 0 {
+			return a
+		}
+		return ecsAttr(a)
+	}
+
+	handler := slog.NewJSONHandler(w, &ecsOpts)
+	return handler.WithAttrs([]slog.Attr{slog.String("ecs.version", ECSVersion)})
+}
+
+// ecsAttr converts a top-level attribute to its ECS equivalent
+func ecsAttr(a slog.Attr) slog.Attr {
+	switch a.Key {
+	case slog.TimeKey:
+		if t, ok := a.Value.Any().(time.Time); ok {
+			return slog.String("@timestamp", t.UTC().Format("2006-01-02T15:04:05.000Z07:00"))
+		}
+		return slog.Attr{Key: "@timestamp", Value: a.Value}
+	case slog.LevelKey:
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			return slog.String("log.level", strings.ToLower(level.String()))
+		}
+		return slog.Attr{Key: "log.level", Value: a.Value}
+	case slog.MessageKey:
+		return slog.Attr{Key: "message", Value: a.Value}
+	}
+
+	if field, ok := ecsFields[a.Key]; ok {
+		return slog.Group(field[0], slog.Attr{Key: field[1], Value: a.Value})
+	}
+	return a
+}
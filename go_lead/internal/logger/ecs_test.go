@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestECSHandler_RequiredFields(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger = slog.New(NewECSHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	SetTraceIDFunc(func(ctx context.Context) string { return "4bf92f3577b34da6a3ce929d0e0e4736" })
+	defer SetTraceIDFunc(nil)
+
+	ctx := context.WithValue(context.Background(), LeadIDKey, int64(42))
+	LogError(ctx, "Delivery failed", errors.New("connection refused"), "operation", "deliver_lead")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse ECS log output: %v", err)
+	}
+
+	timestamp, ok := entry["@timestamp"].(string)
+	if !ok {
+		t.Fatalf("Expected @timestamp as a string, got %T", entry["@timestamp"])
+	}
+	if _, err := time.Parse(time.RFC3339, timestamp); err != nil || !strings.HasSuffix(timestamp, "Z") {
+		t.Errorf("Expected @timestamp in RFC 3339 UTC, got %s", timestamp)
+	}
+	expected := map[string]string{
+		"log.level":   "error",
+		"message":     "Delivery failed",
+		"ecs.version": ECSVersion,
+	}
+	for field, value := range expected {
+		if entry[field] != value {
+			t.Errorf("Expected %s=%q, got %v (%T)", field, value, entry[field], entry[field])
+		}
+	}
+	for _, field := range []string{"time", "level", "msg", "trace_id", "operation"} {
+		if _, ok := entry[field]; ok {
+			t.Errorf("Expected %s to be replaced by its ECS field", field)
+		}
+	}
+
+	nested := map[string][2]string{
+		"error": {"message", "connection refused"},
+		"trace": {"id", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		"event": {"action", "deliver_lead"},
+	}
+	for object, field := range nested {
+		fields, ok := entry[object].(map[string]interface{})
+		if !ok {
+			t.Errorf("Expected %s to be an object, got %T", object, entry[object])
+			continue
+		}
+		if fields[field[0]] != field[1] {
+			t.Errorf("Expected %s.%s=%q, got %v", object, field[0], field[1], fields[field[0]])
+		}
+	}
+
+	// Fields without an ECS equivalent are kept
+	if entry["lead_id"] != float64(42) {
+		t.Errorf("Expected lead_id=42, got %v", entry["lead_id"])
+	}
+}
+
+func TestECSHandler_Levels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewECSHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+
+	var levels []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Failed to parse ECS log output: %v", err)
+		}
+		levels = append(levels, entry["log.level"].(string))
+	}
+	if strings.Join(levels, ",") != "debug,info,warn" {
+		t.Errorf("Expected lowercase ECS levels, got %v", levels)
+	}
+}
+
+func TestNewHandler_Formats(t *testing.T) {
+	tests := []struct {
+		format   string
+		contains string
+	}{
+		{"", `"msg":"hello"`},
+		{FormatJSON, `"msg":"hello"`},
+		{FormatECS, `"message":"hello"`},
+		{FormatText, `msg=hello`},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		handler, err := NewHandler(&buf, tt.format, nil)
+		if err != nil {
+			t.Fatalf("NewHandler(%q) failed: %v", tt.format, err)
+		}
+		slog.New(handler).Info("hello")
+		if !strings.Contains(buf.String(), tt.contains) {
+			t.Errorf("Expected %q output to contain %s, got %s", tt.format, tt.contains, buf.String())
+		}
+	}
+
+	if _, err := NewHandler(&bytes.Buffer{}, "xml", nil); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestConfigure_InvalidLevel(t *testing.T) {
+	if err := Configure(FormatJSON, "verbose"); err == nil {
+		t.Error("Expected an error for an unknown log level")
+	}
+}
@@ -2,6 +2,8 @@ package logger
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"time"
@@ -15,10 +17,29 @@ const (
 	LeadIDKey ContextKey = "lead_id"
 	// CorrelationIDKey is the context key for correlation_id
 	CorrelationIDKey ContextKey = "correlation_id"
+	// SourceIDKey is the context key for source_id
+	SourceIDKey ContextKey = "source_id"
+	// ProductKey is the context key for product
+	ProductKey ContextKey = "product"
+)
+
+// Log formats selected by LOG_FORMAT
+const (
+	FormatJSON = "json"     // slog JSON with time, level and msg
+	FormatECS  = "json-ecs" // JSON following the Elastic Common Schema
+	FormatText = "text"     // logfmt-style text for development
 )
 
 var defaultLogger *slog.Logger
 
+// traceIDFunc returns the trace ID of a context for the trace_id field, if set
+var traceIDFunc func(ctx context.Context) string
+
+// LogHandler formats and writes log records; see NewHandler for the available formats
+type LogHandler interface {
+	slog.Handler
+}
+
 // Init initializes the global structured logger with JSON output
 func Init() {
 	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
@@ -28,7 +49,47 @@ func Init() {
 	slog.SetDefault(defaultLogger)
 }
 
-// WithContext creates a logger with context values (lead_id, correlation_id)
+// Configure replaces the global logger with one writing the given format at the given
+// level (debug, info, warn or error) to stdout; empty values keep JSON and info
+func Configure(format, level string) error {
+	var minLevel slog.Level
+	if level != "" {
+		if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+			return fmt.Errorf("invalid log level %q", level)
+		}
+	}
+	
+	handler, err := NewHandler(os.Stdout, format, &slog.HandlerOptions{Level: minLevel})
+	if err != nil {
+		return err
+	}
+	defaultLogger = slog.New(handler)
+	slog.SetDefault(defaultLogger)
+	return nil
+}
+
+// NewHandler returns the handler writing log records in format (FormatJSON, FormatECS
+// or FormatText) to w; an empty format means FormatJSON
+func NewHandler(w io.Writer, format string, opts *slog.HandlerOptions) (LogHandler, error) {
+	switch format {
+	case FormatJSON, "":
+		return slog.NewJSONHandler(w, opts), nil
+	case FormatECS:
+		return NewECSHandler(w, opts), nil
+	case FormatText:
+		return slog.NewTextHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// SetTraceIDFunc sets the function that resolves the trace ID logged with each message
+// as trace_id, e.g. tracing.TraceID; messages outside a trace carry no trace ID
+func SetTraceIDFunc(fn func(ctx context.Context) string) {
+	traceIDFunc = fn
+}
+
+// WithContext creates a logger with context values (lead_id, correlation_id, source_id, product)
 func WithContext(ctx context.Context) *slog.Logger {
 	logger := defaultLogger
 	
@@ -40,6 +101,20 @@ func WithContext(ctx context.Context) *slog.Logger {
 		logger = logger.With("correlation_id", correlationID)
 	}
 	
+	if sourceID, ok := ctx.Value(SourceIDKey).(string); ok && sourceID != "" {
+		logger = logger.With("source_id", sourceID)
+	}
+	
+	if product, ok := ctx.Value(ProductKey).(string); ok && product != "" {
+		logger = logger.With("product", product)
+	}
+	
+	if traceIDFunc != nil {
+		if traceID := traceIDFunc(ctx); traceID != "" {
+			logger = logger.With("trace_id", traceID)
+		}
+	}
+	
 	return logger
 }
 
@@ -114,6 +114,56 @@ func TestLeadIDPropagation(t *testing.T) {
 	}
 }
 
+// TestSourceIDAndProductPropagation tests that source_id and product are propagated through context
+func TestSourceIDAndProductPropagation(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})
+	defaultLogger = slog.New(handler)
+	
+	ctx := context.WithValue(context.Background(), SourceIDKey, "partner-a")
+	ctx = context.WithValue(ctx, ProductKey, "solar_panel_installation")
+	
+	Info(ctx, "test message with source and product")
+	
+	var logEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &logEntry); err != nil {
+		t.Fatalf("Failed to parse JSON log output: %v", err)
+	}
+	
+	if logEntry["source_id"] != "partner-a" {
+		t.Errorf("Expected source_id='partner-a', got %v", logEntry["source_id"])
+	}
+	if logEntry["product"] != "solar_panel_installation" {
+		t.Errorf("Expected product='solar_panel_installation', got %v", logEntry["product"])
+	}
+}
+
+// TestSourceIDAndProductAbsent tests that source_id and product are omitted when not in context
+func TestSourceIDAndProductAbsent(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})
+	defaultLogger = slog.New(handler)
+	
+	ctx := context.WithValue(context.Background(), LeadIDKey, int64(12345))
+	
+	Info(ctx, "test message without source and product")
+	
+	var logEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &logEntry); err != nil {
+		t.Fatalf("Failed to parse JSON log output: %v", err)
+	}
+	
+	for _, key := range []string{"source_id", "product"} {
+		if value, present := logEntry[key]; present {
+			t.Errorf("Expected no %s field, got %v", key, value)
+		}
+	}
+}
+
 // TestStatusTransitionLogging tests that status transitions are logged correctly
 // Requirements: 8.1
 func TestStatusTransitionLogging(t *testing.T) {
@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"context"
+	"strings"
+)
+
+// LogObfuscator redacts the values of configured fields before they are logged.
+// A nil *LogObfuscator logs values unchanged.
+type LogObfuscator struct {
+	fields map[string]bool
+}
+
+// NewLogObfuscator creates a new LogObfuscator for the given field names (case-insensitive)
+func NewLogObfuscator(fields []string) *LogObfuscator {
+	o := &LogObfuscator{fields: make(map[string]bool, len(fields))}
+	for _, field := range fields {
+		o.fields[strings.ToLower(strings.TrimSpace(field))] = true
+	}
+	return o
+}
+
+// Value returns REDACTED_<FIELD> if key is obfuscated, otherwise value unchanged.
+// For dotted keys only the last segment is matched, e.g. "contact.phone" matches "phone".
+func (o *LogObfuscator) Value(key string, value any) any {
+	if o == nil || len(o.fields) == 0 {
+		return value
+	}
+
+	field := strings.ToLower(key)
+	if i := strings.LastIndex(field, "."); i >= 0 {
+		field = field[i+1:]
+	}
+	if !o.fields[field] {
+		return value
+	}
+	return "REDACTED_" + strings.ToUpper(field)
+}
+
+// Payload returns a copy of payload with obfuscated fields redacted at any depth
+func (o *LogObfuscator) Payload(payload map[string]interface{}) map[string]interface{} {
+	if o == nil || len(o.fields) == 0 {
+		return payload
+	}
+
+	redacted := make(map[string]interface{}, len(payload))
+	for key, value := range payload {
+		if nested, ok := value.(map[string]interface{}); ok {
+			redacted[key] = o.Payload(nested)
+			continue
+		}
+		redacted[key] = o.Value(key, value)
+	}
+	return redacted
+}
+
+// Args redacts the values of key/value pairs as passed to Info, Warn, and friends
+func (o *LogObfuscator) Args(args ...any) []any {
+	if o == nil || len(o.fields) == 0 {
+		return args
+	}
+
+	redacted := make([]any, len(args))
+	copy(redacted, args)
+	for i := 0; i+1 < len(redacted); i += 2 {
+		key, ok := redacted[i].(string)
+		if !ok {
+			continue
+		}
+		switch value := redacted[i+1].(type) {
+		case map[string]interface{}:
+			redacted[i+1] = o.Payload(value)
+		default:
+			redacted[i+1] = o.Value(key, value)
+		}
+	}
+	return redacted
+}
+
+// Info logs an info message with obfuscated args
+func (o *LogObfuscator) Info(ctx context.Context, msg string, args ...any) {
+	Info(ctx, msg, o.Args(args...)...)
+}
+
+// Warn logs a warning message with obfuscated args
+func (o *LogObfuscator) Warn(ctx context.Context, msg string, args ...any) {
+	Warn(ctx, msg, o.Args(args...)...)
+}
+
+// Debug logs a debug message with obfuscated args
+func (o *LogObfuscator) Debug(ctx context.Context, msg string, args ...any) {
+	Debug(ctx, msg, o.Args(args...)...)
+}
+
+// LogError logs an error with obfuscated args
+func (o *LogObfuscator) LogError(ctx context.Context, msg string, err error, args ...any) {
+	LogError(ctx, msg, err, o.Args(args...)...)
+}
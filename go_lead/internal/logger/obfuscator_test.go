@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// captureLogs redirects the default logger to a buffer for the duration of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	previous := defaultLogger
+	defaultLogger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	t.Cleanup(func() { defaultLogger = previous })
+	return &buf
+}
+
+func TestLogObfuscator_RedactsConfiguredFields(t *testing.T) {
+	buf := captureLogs(t)
+	obfuscator := NewLogObfuscator([]string{"phone", "Email"})
+
+	obfuscator.Info(context.Background(), "Lead received",
+		"phone", "+491234567890",
+		"email", "jane@example.com",
+		"zipcode", "66123")
+
+	output := buf.String()
+	if strings.Contains(output, "+491234567890") || strings.Contains(output, "jane@example.com") {
+		t.Errorf("Expected phone and email to be redacted, got %s", output)
+	}
+	if !strings.Contains(output, "REDACTED_PHONE") || !strings.Contains(output, "REDACTED_EMAIL") {
+		t.Errorf("Expected redaction markers, got %s", output)
+	}
+	if !strings.Contains(output, "66123") {
+		t.Errorf("Expected non-obfuscated fields to be logged, got %s", output)
+	}
+}
+
+func TestLogObfuscator_RedactsNestedPayload(t *testing.T) {
+	buf := captureLogs(t)
+	obfuscator := NewLogObfuscator([]string{"phone", "email"})
+
+	payload := map[string]interface{}{
+		"phone":   "+491234567890",
+		"contact": map[string]interface{}{"email": "jane@example.com"},
+	}
+	obfuscator.Info(context.Background(), "Sending lead", "payload", payload)
+
+	output := buf.String()
+	if strings.Contains(output, "+491234567890") || strings.Contains(output, "jane@example.com") {
+		t.Errorf("Expected nested phone and email to be redacted, got %s", output)
+	}
+	if payload["phone"] != "+491234567890" {
+		t.Error("Expected the original payload to be left unchanged")
+	}
+}
+
+func TestLogObfuscator_Disabled(t *testing.T) {
+	buf := captureLogs(t)
+
+	var obfuscator *LogObfuscator
+	obfuscator.Info(context.Background(), "Lead received", "phone", "+491234567890")
+
+	if !strings.Contains(buf.String(), "+491234567890") {
+		t.Errorf("Expected values to be logged unchanged without obfuscation, got %s", buf.String())
+	}
+}
+
+func TestLogObfuscator_DottedKey(t *testing.T) {
+	obfuscator := NewLogObfuscator([]string{"phone"})
+
+	if got := obfuscator.Value("contact.phone", "+491234567890"); got != "REDACTED_PHONE" {
+		t.Errorf("Expected REDACTED_PHONE, got %v", got)
+	}
+}
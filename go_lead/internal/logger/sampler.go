@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sampler thins out high-volume log lines: it lets through 1 in every n lines, or at most
+// perSecond lines per second. Only lines that are safe to lose should be sampled; failures
+// are logged without asking the Sampler. It is safe for concurrent use, and a nil Sampler
+// lets every line through.
+type Sampler struct {
+	every     uint64
+	perSecond int
+	now       func() time.Time
+
+	seen atomic.Uint64 // lines offered for 1-in-n sampling
+
+	mu          sync.Mutex
+	window      int64 // Unix second of the current rate window
+	windowLines int   // lines let through in the current rate window
+}
+
+// NewSampler creates a Sampler letting through 1 in every lines, or at most perSecond lines
+// per second if perSecond is positive. An every of 1 or less lets every line through.
+func NewSampler(every, perSecond int) *Sampler {
+	return &Sampler{
+		every:     uint64(max(every, 1)),
+		perSecond: perSecond,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether the next line should be logged
+func (s *Sampler) Allow() bool {
+	if s == nil {
+		return true
+	}
+	if s.perSecond > 0 {
+		return s.allowRate()
+	}
+	// The first line is always logged, then every n-th
+	return (s.seen.Add(1)-1)%s.every == 0
+}
+
+// allowRate lets through the first perSecond lines of each second
+func (s *Sampler) allowRate() bool {
+	second := s.now().Unix()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if second != s.window {
+		s.window = second
+		s.windowLines = 0
+	}
+	if s.windowLines >= s.perSecond {
+		return false
+	}
+	s.windowLines++
+	return true
+}
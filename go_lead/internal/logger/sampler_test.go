@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSampler_OneInN(t *testing.T) {
+	sampler := NewSampler(10, 0)
+
+	// Concurrent callers share the counter, so exactly every tenth line gets through
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if sampler.Allow() {
+					allowed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed.Load() != 100 {
+		t.Errorf("Expected 100 of 1000 lines to be allowed, got %d", allowed.Load())
+	}
+}
+
+func TestSampler_PerSecond(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	sampler := NewSampler(0, 3)
+	sampler.now = func() time.Time { return now }
+
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if sampler.Allow() {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Errorf("Expected 3 lines in the first second, got %d", allowed)
+	}
+
+	now = now.Add(time.Second)
+	if !sampler.Allow() {
+		t.Error("Expected the limit to reset in the next second")
+	}
+}
+
+func TestSampler_AllowsEverything(t *testing.T) {
+	var nilSampler *Sampler
+	for _, sampler := range []*Sampler{nilSampler, NewSampler(1, 0), NewSampler(0, 0)} {
+		for i := 0; i < 5; i++ {
+			if !sampler.Allow() {
+				t.Fatalf("Expected every line to be allowed by %+v", sampler)
+			}
+		}
+	}
+}
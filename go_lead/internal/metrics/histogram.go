@@ -0,0 +1,137 @@
+// Package metrics keeps in-process metrics that are exposed in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Histogram counts observations in buckets per value of a single label, like a Prometheus
+// histogram vector. It is safe for concurrent use.
+type Histogram struct {
+	name    string
+	help    string
+	label   string
+	buckets []float64 // upper bounds, ascending; the implicit +Inf bucket is not listed
+
+	mu     sync.Mutex
+	series map[string]*Snapshot
+}
+
+// NewHistogram creates a histogram with the given bucket upper bounds, which must be ascending
+func NewHistogram(name, help, label string, buckets []float64) *Histogram {
+	return &Histogram{
+		name:    name,
+		help:    help,
+		label:   label,
+		buckets: append([]float64(nil), buckets...),
+		series:  make(map[string]*Snapshot),
+	}
+}
+
+// Observe records value in the series of labelValue
+func (h *Histogram) Observe(labelValue string, value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[labelValue]
+	if !ok {
+		s = &Snapshot{Buckets: h.buckets, Counts: make([]uint64, len(h.buckets))}
+		h.series[labelValue] = s
+	}
+	for i, upper := range h.buckets {
+		if value <= upper {
+			s.Counts[i]++
+		}
+	}
+	s.Count++
+	s.Sum += value
+}
+
+// Snapshot returns a copy of each series by label value
+func (h *Histogram) Snapshot() map[string]Snapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snapshot := make(map[string]Snapshot, len(h.series))
+	for labelValue, s := range h.series {
+		snapshot[labelValue] = Snapshot{
+			Buckets: s.Buckets,
+			Counts:  append([]uint64(nil), s.Counts...),
+			Count:   s.Count,
+			Sum:     s.Sum,
+		}
+	}
+	return snapshot
+}
+
+// Total returns all series added up
+func (h *Histogram) Total() Snapshot {
+	total := Snapshot{Buckets: h.buckets, Counts: make([]uint64, len(h.buckets))}
+	for _, s := range h.Snapshot() {
+		for i := range s.Counts {
+			total.Counts[i] += s.Counts[i]
+		}
+		total.Count += s.Count
+		total.Sum += s.Sum
+	}
+	return total
+}
+
+// WritePrometheus writes the histogram in the Prometheus text format, series sorted by label value
+func (h *Histogram) WritePrometheus(b *strings.Builder) {
+	snapshot := h.Snapshot()
+	labelValues := make([]string, 0, len(snapshot))
+	for labelValue := range snapshot {
+		labelValues = append(labelValues, labelValue)
+	}
+	sort.Strings(labelValues)
+
+	fmt.Fprintf(b, "# HELP %s %s\n", h.name, h.help)
+	fmt.Fprintf(b, "# TYPE %s histogram\n", h.name)
+	for _, labelValue := range labelValues {
+		s := snapshot[labelValue]
+		for i, upper := range s.Buckets {
+			fmt.Fprintf(b, "%s_bucket{%s=%q,le=%q} %d\n", h.name, h.label, labelValue, strconv.FormatFloat(upper, 'g', -1, 64), s.Counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{%s=%q,le=\"+Inf\"} %d\n", h.name, h.label, labelValue, s.Count)
+		fmt.Fprintf(b, "%s_sum{%s=%q} %s\n", h.name, h.label, labelValue, strconv.FormatFloat(s.Sum, 'g', -1, 64))
+		fmt.Fprintf(b, "%s_count{%s=%q} %d\n", h.name, h.label, labelValue, s.Count)
+	}
+}
+
+// Snapshot holds the observations of one histogram series
+type Snapshot struct {
+	Buckets []float64 // upper bounds, ascending
+	Counts  []uint64  // cumulative count of observations up to each upper bound
+	Count   uint64    // all observations, including those above the last bound
+	Sum     float64
+}
+
+// Quantile estimates the q-quantile (0 <= q <= 1) like Prometheus' histogram_quantile:
+// it finds the bucket holding the rank and interpolates linearly within it, taking 0 as
+// the lower bound of the first bucket. Ranks above the last bound return that bound.
+// It returns NaN if there are no observations.
+func (s Snapshot) Quantile(q float64) float64 {
+	if s.Count == 0 || len(s.Buckets) == 0 {
+		return math.NaN()
+	}
+
+	rank := q * float64(s.Count)
+	lower, below := 0.0, uint64(0)
+	for i, upper := range s.Buckets {
+		if float64(s.Counts[i]) >= rank {
+			inBucket := s.Counts[i] - below
+			if inBucket == 0 {
+				return upper
+			}
+			return lower + (upper-lower)*(rank-float64(below))/float64(inBucket)
+		}
+		lower, below = upper, s.Counts[i]
+	}
+	return s.Buckets[len(s.Buckets)-1]
+}
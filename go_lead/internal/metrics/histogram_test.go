@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var testBuckets = []float64{0.1, 0.25, 0.5, 1.0, 2.5, 5.0, 10.0}
+
+func TestHistogram_Observe(t *testing.T) {
+	h := NewHistogram("latency_seconds", "Test latency.", "status_code_class", testBuckets)
+	h.Observe("2xx", 0.05)
+	h.Observe("2xx", 0.3)
+	h.Observe("2xx", 0.3)
+	h.Observe("5xx", 12)
+
+	snapshot := h.Snapshot()
+	ok := snapshot["2xx"]
+	expected := []uint64{1, 1, 3, 3, 3, 3, 3}
+	for i, count := range expected {
+		if ok.Counts[i] != count {
+			t.Errorf("Expected %d observations up to %v, got %d", count, testBuckets[i], ok.Counts[i])
+		}
+	}
+	if ok.Count != 3 || math.Abs(ok.Sum-0.65) > 1e-9 {
+		t.Errorf("Expected 3 observations summing to 0.65, got %d and %v", ok.Count, ok.Sum)
+	}
+
+	// An observation above the last bound is only counted in +Inf
+	if failed := snapshot["5xx"]; failed.Count != 1 || failed.Counts[len(testBuckets)-1] != 0 {
+		t.Errorf("Expected one 5xx observation above all buckets, got %+v", failed)
+	}
+	if total := h.Total(); total.Count != 4 || total.Counts[2] != 3 {
+		t.Errorf("Expected 4 observations in total, got %+v", total)
+	}
+}
+
+func TestHistogram_ConcurrentObserve(t *testing.T) {
+	h := NewHistogram("latency_seconds", "Test latency.", "status_code_class", testBuckets)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				h.Observe("2xx", 0.2)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if total := h.Total(); total.Count != 1000 || total.Counts[1] != 1000 {
+		t.Errorf("Expected 1000 observations up to 0.25s, got %+v", total)
+	}
+}
+
+func TestSnapshot_Quantile(t *testing.T) {
+	h := NewHistogram("latency_seconds", "Test latency.", "status_code_class", testBuckets)
+	// 90 fast responses and 10 slow ones
+	for i := 0; i < 90; i++ {
+		h.Observe("2xx", 0.2)
+	}
+	for i := 0; i < 10; i++ {
+		h.Observe("5xx", 3)
+	}
+	total := h.Total()
+
+	tests := []struct {
+		q        float64
+		min, max float64
+	}{
+		{0.5, 0.1, 0.25}, // within the 0.1-0.25 bucket
+		{0.95, 2.5, 5.0}, // within the 2.5-5 bucket
+		{0.99, 2.5, 5.0},
+	}
+	for _, tt := range tests {
+		if got := total.Quantile(tt.q); got < tt.min || got > tt.max {
+			t.Errorf("Expected P%v between %v and %v, got %v", tt.q*100, tt.min, tt.max, got)
+		}
+	}
+
+	// Prometheus interpolates linearly: rank 50 of 90 in the 0.1-0.25 bucket
+	if got, expected := total.Quantile(0.5), 0.1+0.15*50/90; math.Abs(got-expected) > 1e-9 {
+		t.Errorf("Expected P50 %v, got %v", expected, got)
+	}
+}
+
+func TestSnapshot_QuantileEdgeCases(t *testing.T) {
+	h := NewHistogram("latency_seconds", "Test latency.", "status_code_class", testBuckets)
+	if got := h.Total().Quantile(0.5); !math.IsNaN(got) {
+		t.Errorf("Expected NaN without observations, got %v", got)
+	}
+
+	h.Observe("error", 30)
+	if got := h.Total().Quantile(0.99); got != 10 {
+		t.Errorf("Expected the last bound for ranks above it, got %v", got)
+	}
+}
+
+func TestHistogram_WritePrometheus(t *testing.T) {
+	h := NewHistogram("latency_seconds", "Test latency.", "status_code_class", []float64{0.5, 1})
+	h.Observe("4xx", 0.75)
+	h.Observe("2xx", 0.25)
+
+	var b strings.Builder
+	h.WritePrometheus(&b)
+
+	expected := `# HELP latency_seconds Test latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{status_code_class="2xx",le="0.5"} 1
+latency_seconds_bucket{status_code_class="2xx",le="1"} 1
+latency_seconds_bucket{status_code_class="2xx",le="+Inf"} 1
+latency_seconds_sum{status_code_class="2xx"} 0.25
+latency_seconds_count{status_code_class="2xx"} 1
+latency_seconds_bucket{status_code_class="4xx",le="0.5"} 0
+latency_seconds_bucket{status_code_class="4xx",le="1"} 1
+latency_seconds_bucket{status_code_class="4xx",le="+Inf"} 1
+latency_seconds_sum{status_code_class="4xx"} 0.75
+latency_seconds_count{status_code_class="4xx"} 1
+`
+	if b.String() != expected {
+		t.Errorf("Expected Prometheus output:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"time"
 )
 
 // ValidationError represents an error that occurred during lead validation
@@ -60,10 +61,13 @@ func NewTransformationError(stage, field, message string, err error) *Transforma
 
 // DeliveryError represents an error that occurred during delivery to the Customer API
 type DeliveryError struct {
-	StatusCode int
-	Message    string
-	Retriable  bool
-	Err        error
+	StatusCode        int
+	Message           string
+	Retriable         bool
+	Err               error
+	CustomerErrorCode string     // error code parsed from a structured response body, if any
+	RawBody           string     // unparsed response body
+	NextAttemptAt     *time.Time // earliest time the delivery may be retried, if known
 }
 
 func (e *DeliveryError) Error() string {
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync/atomic"
+)
+
+// jsonUseNumber makes DecodeJSON keep numbers as json.Number instead of float64
+var jsonUseNumber atomic.Bool
+
+// SetJSONUseNumber controls whether DecodeJSON preserves numbers as json.Number.
+// Enabling it keeps large integers such as 19-digit provider IDs exact, which a
+// float64 cannot represent. It should be set once at startup.
+func SetJSONUseNumber(enabled bool) {
+	jsonUseNumber.Store(enabled)
+}
+
+// DecodeJSON unmarshals data into v like json.Unmarshal, decoding numbers in
+// interface{} values as json.Number when SetJSONUseNumber is enabled
+func DecodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if jsonUseNumber.Load() {
+		decoder.UseNumber()
+	}
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+
+	// Like json.Unmarshal, reject anything after the top-level value
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level JSON value")
+	}
+	return nil
+}
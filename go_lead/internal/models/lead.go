@@ -1,9 +1,12 @@
 package models
 
 import (
+	"crypto/sha256"
 	"database/sql/driver"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"time"
 )
 
@@ -18,6 +21,30 @@ func (j JSONB) Value() (driver.Value, error) {
 	return json.Marshal(j)
 }
 
+// ProductName returns the product of a payload, given either as a string or as an
+// object with a name field, or "" when there is none
+func (j JSONB) ProductName() string {
+	switch v := j["product"].(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		name, _ := v["name"].(string)
+		return name
+	}
+	return ""
+}
+
+// Hash returns the hex-encoded SHA-256 hash of the JSON encoding, which lists object
+// keys in sorted order so that equal payloads hash equally regardless of key order
+func (j JSONB) Hash() (string, error) {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode payload: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 // Scan implements the sql.Scanner interface for JSONB
 func (j *JSONB) Scan(value interface{}) error {
 	if value == nil {
@@ -31,7 +58,7 @@ func (j *JSONB) Scan(value interface{}) error {
 	}
 	
 	var result map[string]interface{}
-	if err := json.Unmarshal(bytes, &result); err != nil {
+	if err := DecodeJSON(bytes, &result); err != nil {
 		return err
 	}
 	
@@ -45,45 +72,83 @@ type InboundLead struct {
 	ReceivedAt         time.Time  `json:"received_at" db:"received_at"`
 	RawPayload         JSONB      `json:"raw_payload" db:"raw_payload"`
 	SourceHeaders      JSONB      `json:"source_headers,omitempty" db:"source_headers"`
+	SourceID           *string    `json:"source_id,omitempty" db:"source_id"`
+	TenantID           *string    `json:"tenant_id,omitempty" db:"tenant_id"`
 	Status             LeadStatus `json:"status" db:"status"`
 	RejectionReason    *string    `json:"rejection_reason,omitempty" db:"rejection_reason"`
 	NormalizedPayload  JSONB      `json:"normalized_payload,omitempty" db:"normalized_payload"`
 	CustomerPayload    JSONB      `json:"customer_payload,omitempty" db:"customer_payload"`
 	PayloadHash        *string    `json:"payload_hash,omitempty" db:"payload_hash"`
+	// AttachmentsMetadata summarizes the base64 attachment attributes, whose raw values
+	// are removed from the stored payloads
+	AttachmentsMetadata JSONB `json:"attachments_metadata,omitempty" db:"attachments_metadata"`
+	// ValidationFlags holds the warn-level validation failures of the current processing run.
+	// It is not persisted on its own; the flags are delivered in the customer payload.
+	ValidationFlags    []string   `json:"-" db:"-"`
 	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt          time.Time  `json:"updated_at" db:"updated_at"`
 }
 
-// CanTransitionTo checks if the lead can transition from its current status to the target status
-func (l *InboundLead) CanTransitionTo(target LeadStatus) bool {
-	// Terminal states cannot transition
-	if l.Status.IsTerminal() {
-		return false
-	}
-	
-	switch l.Status {
-	case LeadStatusReceived:
-		// RECEIVED can transition to REJECTED or READY
-		return target == LeadStatusRejected || target == LeadStatusReady
-		
-	case LeadStatusReady:
-		// READY can transition to DELIVERED, FAILED, or PERMANENTLY_FAILED
-		return target == LeadStatusDelivered || target == LeadStatusFailed || target == LeadStatusPermanentlyFailed
-		
-	case LeadStatusFailed:
-		// FAILED can transition to DELIVERED or PERMANENTLY_FAILED (after retries)
-		return target == LeadStatusDelivered || target == LeadStatusPermanentlyFailed
-		
-	default:
-		return false
+// CallbackURLHeader is the optional webhook request header naming the URL
+// that is notified of the final lead outcome
+const CallbackURLHeader = "X-Callback-URL"
+
+// CallbackURL returns the sender callback URL recorded in the source headers, or an empty string
+func (l *InboundLead) CallbackURL() string {
+	// Source headers are stored under their canonical names
+	callbackURL, _ := l.SourceHeaders[http.CanonicalHeaderKey(CallbackURLHeader)].(string)
+	return callbackURL
+}
+
+// ProviderRefHeader is the optional webhook request header carrying the sender's own
+// reference for the lead, echoed back in outcome notifications
+const ProviderRefHeader = "X-Provider-Ref"
+
+// ProviderRef returns the sender reference recorded in the source headers, or an empty string
+func (l *InboundLead) ProviderRef() string {
+	providerRef, _ := l.SourceHeaders[http.CanonicalHeaderKey(ProviderRefHeader)].(string)
+	return providerRef
+}
+
+// SourceQuotaExceededHeader is recorded in the source headers of a lead accepted although
+// its source had exceeded its daily quota, and answered on such webhook requests
+const SourceQuotaExceededHeader = "X-Source-Quota-Exceeded"
+
+// SourceQuotaExceeded reports whether the lead was accepted beyond its source's daily quota
+func (l *InboundLead) SourceQuotaExceeded() bool {
+	exceeded, _ := l.SourceHeaders[http.CanonicalHeaderKey(SourceQuotaExceededHeader)].(string)
+	return exceeded == "true"
+}
+
+// ForwardedHeaders returns the recorded source headers among names, keyed by their
+// canonical names, or nil when none of them was sent
+func (l *InboundLead) ForwardedHeaders(names []string) map[string]string {
+	var headers map[string]string
+	for _, name := range names {
+		key := http.CanonicalHeaderKey(name)
+		value, ok := l.SourceHeaders[key].(string)
+		if !ok {
+			continue
+		}
+		if headers == nil {
+			headers = make(map[string]string, len(names))
+		}
+		headers[key] = value
 	}
+	return headers
+}
+
+// CanTransitionTo checks if the lead can transition from its current status to the target
+// status according to the status machine enforced by status updates
+func (l *InboundLead) CanTransitionTo(target LeadStatus) bool {
+	return CurrentStatusMachine().CanTransition(l.Status, target)
 }
 
 // TransitionTo attempts to transition the lead to a new status
-// Returns an error if the transition is not allowed
+// Returns an error wrapping ErrInvalidStatusTransition if the transition is not allowed
 func (l *InboundLead) TransitionTo(target LeadStatus) error {
-	if !l.CanTransitionTo(target) {
-		return fmt.Errorf("invalid status transition from %s to %s", l.Status, target)
+	if err := CurrentStatusMachine().ValidateTransition(l.Status, target); err != nil {
+		return err
 	}
 	
 	l.Status = target
@@ -112,6 +177,16 @@ func (l *InboundLead) MarkDelivered() error {
 	return l.TransitionTo(LeadStatusDelivered)
 }
 
+// MarkDeliveredDuplicate marks the lead as already known to the Customer API
+func (l *InboundLead) MarkDeliveredDuplicate() error {
+	return l.TransitionTo(LeadStatusDeliveredDuplicate)
+}
+
+// MarkPendingConfirmation marks the lead as accepted by the Customer API and awaiting confirmation
+func (l *InboundLead) MarkPendingConfirmation() error {
+	return l.TransitionTo(LeadStatusPendingConfirmation)
+}
+
 // MarkFailed marks the lead as failed (retriable)
 func (l *InboundLead) MarkFailed() error {
 	return l.TransitionTo(LeadStatusFailed)
@@ -122,6 +197,12 @@ func (l *InboundLead) MarkPermanentlyFailed() error {
 	return l.TransitionTo(LeadStatusPermanentlyFailed)
 }
 
+// Customer API endpoints a delivery attempt can be sent to
+const (
+	DeliveryEndpointPrimary  = "primary"
+	DeliveryEndpointFallback = "fallback" // CUSTOMER_API_FALLBACK_URL
+)
+
 // DeliveryAttempt represents a single attempt to deliver a lead to the Customer API
 type DeliveryAttempt struct {
 	ID             int64      `json:"id" db:"id"`
@@ -130,16 +211,65 @@ type DeliveryAttempt struct {
 	RequestedAt    time.Time  `json:"requested_at" db:"requested_at"`
 	ResponseStatus *int       `json:"response_status,omitempty" db:"response_status"`
 	ResponseBody   *string    `json:"response_body,omitempty" db:"response_body"`
+	ResponseHash   *string    `json:"response_hash,omitempty" db:"response_hash"` // SHA-256 of the response body
 	ErrorMessage   *string    `json:"error_message,omitempty" db:"error_message"`
 	Success        bool       `json:"success" db:"success"`
+	EndpointUsed   string     `json:"endpoint_used" db:"endpoint_used"`
 	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+
+	// Truncated is set when ResponseBody was cut to TruncatedAtBytes (MAX_ATTEMPT_RESPONSE_BYTES)
+	Truncated        bool `json:"truncated" db:"truncated"`
+	TruncatedAtBytes *int `json:"truncated_at_bytes,omitempty" db:"truncated_at_bytes"`
 }
 
 // NewDeliveryAttempt creates a new delivery attempt for a lead
 func NewDeliveryAttempt(leadID int64, attemptNo int) *DeliveryAttempt {
 	now := time.Now()
 	return &DeliveryAttempt{
+		LeadID:       leadID,
+		AttemptNo:    attemptNo,
+		RequestedAt:  now,
+		Success:      false,
+		EndpointUsed: DeliveryEndpointPrimary,
+		CreatedAt:    now,
+	}
+}
+
+// MarkSuccess marks the delivery attempt as successful
+func (d *DeliveryAttempt) MarkSuccess(statusCode int, responseBody string) {
+	d.Success = true
+	d.ResponseStatus = &statusCode
+	d.ResponseBody = &responseBody
+}
+
+// MarkFailure marks the delivery attempt as failed
+func (d *DeliveryAttempt) MarkFailure(statusCode *int, errorMessage string) {
+	d.Success = false
+	d.ResponseStatus = statusCode
+	d.ErrorMessage = &errorMessage
+}
+
+// DeliveryChainAttempt represents a single delivery of a lead to a secondary endpoint
+// of the forwarding chain. ChainIndex starts at 1; the primary endpoint is tracked by DeliveryAttempt.
+type DeliveryChainAttempt struct {
+	ID             int64     `json:"id" db:"id"`
+	LeadID         int64     `json:"lead_id" db:"lead_id"`
+	ChainIndex     int       `json:"chain_index" db:"chain_index"`
+	AttemptNo      int       `json:"attempt_no" db:"attempt_no"` // primary delivery attempt it belongs to
+	RequestedAt    time.Time `json:"requested_at" db:"requested_at"`
+	ResponseStatus *int      `json:"response_status,omitempty" db:"response_status"`
+	ResponseBody   *string   `json:"response_body,omitempty" db:"response_body"`
+	ErrorMessage   *string   `json:"error_message,omitempty" db:"error_message"`
+	Success        bool      `json:"success" db:"success"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// NewDeliveryChainAttempt creates a new forwarding chain delivery attempt for a lead
+func NewDeliveryChainAttempt(leadID int64, chainIndex int, attemptNo int) *DeliveryChainAttempt {
+	now := time.Now()
+	return &DeliveryChainAttempt{
 		LeadID:      leadID,
+		ChainIndex:  chainIndex,
 		AttemptNo:   attemptNo,
 		RequestedAt: now,
 		Success:     false,
@@ -147,16 +277,115 @@ func NewDeliveryAttempt(leadID int64, attemptNo int) *DeliveryAttempt {
 	}
 }
 
-// MarkSuccess marks the delivery attempt as successful
-func (d *DeliveryAttempt) MarkSuccess(statusCode int, responseBody string) {
+// MarkSuccess marks the forwarding chain attempt as successful
+func (d *DeliveryChainAttempt) MarkSuccess(statusCode int, responseBody string) {
 	d.Success = true
 	d.ResponseStatus = &statusCode
 	d.ResponseBody = &responseBody
 }
 
-// MarkFailure marks the delivery attempt as failed
-func (d *DeliveryAttempt) MarkFailure(statusCode *int, errorMessage string) {
+// MarkFailure marks the forwarding chain attempt as failed
+func (d *DeliveryChainAttempt) MarkFailure(statusCode *int, errorMessage string) {
 	d.Success = false
 	d.ResponseStatus = statusCode
 	d.ErrorMessage = &errorMessage
 }
+
+// CallbackAttempt represents a single attempt to notify the webhook sender of a lead outcome
+type CallbackAttempt struct {
+	ID             int64     `json:"id" db:"id"`
+	LeadID         int64     `json:"lead_id" db:"lead_id"`
+	AttemptNo      int       `json:"attempt_no" db:"attempt_no"`
+	CallbackURL    string    `json:"callback_url" db:"callback_url"`
+	RequestedAt    time.Time `json:"requested_at" db:"requested_at"`
+	ResponseStatus *int      `json:"response_status,omitempty" db:"response_status"`
+	ErrorMessage   *string   `json:"error_message,omitempty" db:"error_message"`
+	Success        bool      `json:"success" db:"success"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// NewCallbackAttempt creates a new callback attempt for a lead
+func NewCallbackAttempt(leadID int64, attemptNo int, callbackURL string) *CallbackAttempt {
+	now := time.Now()
+	return &CallbackAttempt{
+		LeadID:      leadID,
+		AttemptNo:   attemptNo,
+		CallbackURL: callbackURL,
+		RequestedAt: now,
+		Success:     false,
+		CreatedAt:   now,
+	}
+}
+
+// MarkSuccess marks the callback attempt as successful
+func (c *CallbackAttempt) MarkSuccess(statusCode int) {
+	c.Success = true
+	c.ResponseStatus = &statusCode
+}
+
+// MarkFailure marks the callback attempt as failed
+func (c *CallbackAttempt) MarkFailure(statusCode *int, errorMessage string) {
+	c.Success = false
+	c.ResponseStatus = statusCode
+	c.ErrorMessage = &errorMessage
+}
+
+// ProcessingLock marks a job as being processed by a worker. It is written when
+// the job starts and deleted when it ends, so a lock that outlives its worker
+// identifies a job interrupted by a crash.
+type ProcessingLock struct {
+	JobID    int64     `json:"job_id" db:"job_id"`
+	LeadID   int64     `json:"lead_id" db:"lead_id"`
+	WorkerID string    `json:"worker_id" db:"worker_id"`
+	LockedAt time.Time `json:"locked_at" db:"locked_at"`
+}
+
+// SuppressionEntry is a phone number or email address in the suppression list, e.g. from a
+// do-not-call list. Values are stored normalized.
+type SuppressionEntry struct {
+	ID        int64     `json:"id" db:"id"`
+	Phone     *string   `json:"phone,omitempty" db:"phone"`
+	Email     *string   `json:"email,omitempty" db:"email"`
+	Reason    *string   `json:"reason,omitempty" db:"reason"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ReconciliationStatus records whether a DELIVERED lead was found at the Customer API
+type ReconciliationStatus string
+
+const (
+	// ReconciliationStatusFound means the Customer API read endpoint returned the lead
+	ReconciliationStatusFound ReconciliationStatus = "FOUND"
+
+	// ReconciliationStatusMissing means the Customer API read endpoint did not know the lead
+	ReconciliationStatusMissing ReconciliationStatus = "MISSING"
+)
+
+// ReconciliationCandidate is a recently delivered lead to be confirmed at the Customer API
+type ReconciliationCandidate struct {
+	LeadID      int64
+	DeliveredAt time.Time
+	// ResponseBody is the body of the successful delivery response, which may carry the
+	// customer's own ID for the lead
+	ResponseBody *string
+}
+
+// StuckLead is a lead that has waited in FAILED status for a retry longer than expected
+type StuckLead struct {
+	LeadID    int64     `json:"lead_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+	LastError *string   `json:"last_error,omitempty"` // error of the latest delivery attempt
+}
+
+// NormalizationAudit records a field value changed by normalization. Field is the
+// dot-separated path in the normalized payload; TransformerApplied lists the
+// normalization steps that changed the value, separated by commas.
+type NormalizationAudit struct {
+	ID                 int64       `json:"id" db:"id"`
+	LeadID             int64       `json:"lead_id" db:"lead_id"`
+	Field              string      `json:"field" db:"field"`
+	OriginalValue      interface{} `json:"original_value" db:"original_value"`
+	NormalizedValue    interface{} `json:"normalized_value" db:"normalized_value"`
+	TransformerApplied string      `json:"transformer_applied" db:"transformer_applied"`
+	Timestamp          time.Time   `json:"timestamp" db:"normalized_at"`
+}
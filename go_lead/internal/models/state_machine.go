@@ -0,0 +1,143 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrInvalidStatusTransition is returned when a lead status change is not allowed by the status machine
+var ErrInvalidStatusTransition = errors.New("invalid lead status transition")
+
+// defaultStatusTransitions lists the status changes of the processing pipeline
+var defaultStatusTransitions = map[LeadStatus][]LeadStatus{
+	// Validation accepts or rejects a received lead
+	LeadStatusReceived: {LeadStatusReady, LeadStatusRejected},
+	// Transformation failures and delivery outcomes
+	LeadStatusReady: {
+		LeadStatusFailed, LeadStatusPermanentlyFailed, LeadStatusDelivered,
+		LeadStatusDeliveredDuplicate, LeadStatusPendingConfirmation,
+	},
+	// A failed lead is requeued and passes validation again before the next attempt,
+	// which may reject it, e.g. once its contact is on the suppression list
+	LeadStatusFailed: {LeadStatusReady, LeadStatusRejected},
+	// The asynchronous confirmation callback or its timeout settles a pending lead
+	LeadStatusPendingConfirmation: {LeadStatusDelivered, LeadStatusFailed, LeadStatusPermanentlyFailed},
+}
+
+// StatusMachine defines which lead status transitions are allowed.
+// Setting a lead to the status it already has is always allowed, which keeps status
+// updates idempotent when they are retried.
+type StatusMachine struct {
+	transitions map[LeadStatus]map[LeadStatus]bool
+}
+
+// NewStatusMachine creates a StatusMachine allowing the given transitions, keyed by source status
+func NewStatusMachine(transitions map[LeadStatus][]LeadStatus) *StatusMachine {
+	m := &StatusMachine{transitions: make(map[LeadStatus]map[LeadStatus]bool)}
+	for from, targets := range transitions {
+		for _, to := range targets {
+			m.allow(from, to)
+		}
+	}
+	return m
+}
+
+// DefaultStatusMachine creates a StatusMachine allowing the transitions of the processing pipeline
+func DefaultStatusMachine() *StatusMachine {
+	return NewStatusMachine(defaultStatusTransitions)
+}
+
+// WithTransitions returns a copy of m that additionally allows the given transitions,
+// each written as "FROM>TO" (e.g. "PERMANENTLY_FAILED>READY")
+func (m *StatusMachine) WithTransitions(transitions []string) (*StatusMachine, error) {
+	extended := NewStatusMachine(nil)
+	for from, targets := range m.transitions {
+		for to := range targets {
+			extended.allow(from, to)
+		}
+	}
+
+	for _, transition := range transitions {
+		from, to, err := ParseStatusTransition(transition)
+		if err != nil {
+			return nil, err
+		}
+		extended.allow(from, to)
+	}
+	return extended, nil
+}
+
+// CanTransition reports whether a lead may change from one status to another
+func (m *StatusMachine) CanTransition(from, to LeadStatus) bool {
+	if !to.IsValid() {
+		return false
+	}
+	return from == to || m.transitions[from][to]
+}
+
+// ValidateTransition returns an error wrapping ErrInvalidStatusTransition if the change is not allowed
+func (m *StatusMachine) ValidateTransition(from, to LeadStatus) error {
+	if !m.CanTransition(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, from, to)
+	}
+	return nil
+}
+
+// SourcesOf returns every status a lead may be in to change to the given status,
+// including the status itself. It is empty for an unknown status.
+func (m *StatusMachine) SourcesOf(to LeadStatus) []string {
+	if !to.IsValid() {
+		return []string{}
+	}
+
+	sources := []string{string(to)}
+	for from, targets := range m.transitions {
+		if from != to && targets[to] {
+			sources = append(sources, string(from))
+		}
+	}
+	return sources
+}
+
+// allow adds a single transition
+func (m *StatusMachine) allow(from, to LeadStatus) {
+	if m.transitions[from] == nil {
+		m.transitions[from] = make(map[LeadStatus]bool)
+	}
+	m.transitions[from][to] = true
+}
+
+// ParseStatusTransition parses a transition written as "FROM>TO"
+func ParseStatusTransition(transition string) (LeadStatus, LeadStatus, error) {
+	fromStr, toStr, ok := strings.Cut(transition, ">")
+	if !ok {
+		return "", "", fmt.Errorf("invalid status transition %q: expected FROM>TO", transition)
+	}
+
+	from := LeadStatus(strings.ToUpper(strings.TrimSpace(fromStr)))
+	to := LeadStatus(strings.ToUpper(strings.TrimSpace(toStr)))
+	if !from.IsValid() || !to.IsValid() {
+		return "", "", fmt.Errorf("invalid status transition %q: unknown lead status", transition)
+	}
+	return from, to, nil
+}
+
+// statusMachine is the StatusMachine enforced by status updates
+var statusMachine atomic.Pointer[StatusMachine]
+
+func init() {
+	statusMachine.Store(DefaultStatusMachine())
+}
+
+// SetStatusMachine replaces the StatusMachine enforced by status updates.
+// It should be set once at startup.
+func SetStatusMachine(m *StatusMachine) {
+	statusMachine.Store(m)
+}
+
+// CurrentStatusMachine returns the StatusMachine enforced by status updates
+func CurrentStatusMachine() *StatusMachine {
+	return statusMachine.Load()
+}
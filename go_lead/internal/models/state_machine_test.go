@@ -0,0 +1,128 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"testing"
+)
+
+func TestDefaultStatusMachine_TransitionMatrix(t *testing.T) {
+	allowed := map[LeadStatus][]LeadStatus{
+		LeadStatusReceived: {LeadStatusReady, LeadStatusRejected},
+		LeadStatusReady: {
+			LeadStatusFailed, LeadStatusPermanentlyFailed, LeadStatusDelivered,
+			LeadStatusDeliveredDuplicate, LeadStatusPendingConfirmation,
+		},
+		LeadStatusFailed:              {LeadStatusReady, LeadStatusRejected},
+		LeadStatusPendingConfirmation: {LeadStatusDelivered, LeadStatusFailed, LeadStatusPermanentlyFailed},
+	}
+
+	machine := DefaultStatusMachine()
+	for _, from := range AllLeadStatuses() {
+		for _, to := range AllLeadStatuses() {
+			expected := from == to
+			for _, target := range allowed[from] {
+				expected = expected || target == to
+			}
+
+			if got := machine.CanTransition(from, to); got != expected {
+				t.Errorf("CanTransition(%s, %s) = %v, expected %v", from, to, got, expected)
+			}
+
+			err := machine.ValidateTransition(from, to)
+			if expected && err != nil {
+				t.Errorf("ValidateTransition(%s, %s) returned %v", from, to, err)
+			}
+			if !expected && !errors.Is(err, ErrInvalidStatusTransition) {
+				t.Errorf("ValidateTransition(%s, %s) = %v, expected ErrInvalidStatusTransition", from, to, err)
+			}
+		}
+	}
+}
+
+func TestStatusMachine_RejectsUnknownStatus(t *testing.T) {
+	machine := DefaultStatusMachine()
+
+	if machine.CanTransition(LeadStatusReady, "BOGUS") {
+		t.Error("Expected a transition to an unknown status to be rejected")
+	}
+	if machine.CanTransition("BOGUS", "BOGUS") {
+		t.Error("Expected an unknown status not to transition to itself")
+	}
+	if sources := machine.SourcesOf("BOGUS"); len(sources) != 0 {
+		t.Errorf("Expected no sources for an unknown status, got %v", sources)
+	}
+}
+
+func TestStatusMachine_SourcesOf(t *testing.T) {
+	sources := DefaultStatusMachine().SourcesOf(LeadStatusDelivered)
+	sort.Strings(sources)
+
+	expected := []string{"DELIVERED", "PENDING_CONFIRMATION", "READY"}
+	if len(sources) != len(expected) {
+		t.Fatalf("Expected sources %v, got %v", expected, sources)
+	}
+	for i := range expected {
+		if sources[i] != expected[i] {
+			t.Fatalf("Expected sources %v, got %v", expected, sources)
+		}
+	}
+}
+
+func TestStatusMachine_WithTransitions(t *testing.T) {
+	base := DefaultStatusMachine()
+
+	extended, err := base.WithTransitions([]string{"permanently_failed > ready"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !extended.CanTransition(LeadStatusPermanentlyFailed, LeadStatusReady) {
+		t.Error("Expected the extra transition to be allowed")
+	}
+	if !extended.CanTransition(LeadStatusReceived, LeadStatusReady) {
+		t.Error("Expected the default transitions to be kept")
+	}
+	if base.CanTransition(LeadStatusPermanentlyFailed, LeadStatusReady) {
+		t.Error("Expected the base machine to be unchanged")
+	}
+
+	for _, invalid := range []string{"DELIVERED", "DELIVERED>BOGUS", ">READY"} {
+		if _, err := base.WithTransitions([]string{invalid}); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestInboundLead_TransitionsFollowStatusMachine(t *testing.T) {
+	machine := DefaultStatusMachine()
+	for _, from := range AllLeadStatuses() {
+		for _, to := range AllLeadStatuses() {
+			lead := &InboundLead{Status: from}
+			if got, expected := lead.CanTransitionTo(to), machine.CanTransition(from, to); got != expected {
+				t.Errorf("CanTransitionTo(%s -> %s) = %v, expected %v", from, to, got, expected)
+			}
+		}
+	}
+
+	// A failed lead passes validation again before it is delivered
+	lead := &InboundLead{Status: LeadStatusFailed}
+	if err := lead.MarkDelivered(); !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Errorf("Expected FAILED -> DELIVERED to be rejected, got %v", err)
+	}
+	if err := lead.MarkReady(); err != nil || lead.Status != LeadStatusReady {
+		t.Errorf("Expected FAILED -> READY to be allowed, got %v and %s", err, lead.Status)
+	}
+
+	// Configured extra transitions apply to leads as well
+	extended, err := machine.WithTransitions([]string{"PERMANENTLY_FAILED>READY"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	SetStatusMachine(extended)
+	defer SetStatusMachine(machine)
+
+	lead = &InboundLead{Status: LeadStatusPermanentlyFailed}
+	if err := lead.MarkReady(); err != nil {
+		t.Errorf("Expected the configured transition to be allowed, got %v", err)
+	}
+}
@@ -16,6 +16,13 @@ const (
 	// LeadStatusDelivered indicates the lead was successfully sent to the Customer API
 	LeadStatusDelivered LeadStatus = "DELIVERED"
 	
+	// LeadStatusDeliveredDuplicate indicates the Customer API reported it already has the lead (e.g. 409 Conflict)
+	LeadStatusDeliveredDuplicate LeadStatus = "DELIVERED_DUPLICATE"
+	
+	// LeadStatusPendingConfirmation indicates the Customer API accepted the lead (202) and
+	// delivery will be confirmed asynchronously via the delivery confirmation callback
+	LeadStatusPendingConfirmation LeadStatus = "PENDING_CONFIRMATION"
+	
 	// LeadStatusFailed indicates delivery attempt failed but may be retried
 	LeadStatusFailed LeadStatus = "FAILED"
 	
@@ -23,11 +30,21 @@ const (
 	LeadStatusPermanentlyFailed LeadStatus = "PERMANENTLY_FAILED"
 )
 
+// AllLeadStatuses returns every valid LeadStatus value
+func AllLeadStatuses() []LeadStatus {
+	return []LeadStatus{
+		LeadStatusReceived, LeadStatusRejected, LeadStatusReady,
+		LeadStatusDelivered, LeadStatusFailed, LeadStatusPermanentlyFailed,
+		LeadStatusPendingConfirmation, LeadStatusDeliveredDuplicate,
+	}
+}
+
 // IsValid checks if the status is a valid LeadStatus value
 func (s LeadStatus) IsValid() bool {
 	switch s {
 	case LeadStatusReceived, LeadStatusRejected, LeadStatusReady, 
-		LeadStatusDelivered, LeadStatusFailed, LeadStatusPermanentlyFailed:
+		LeadStatusDelivered, LeadStatusFailed, LeadStatusPermanentlyFailed,
+		LeadStatusPendingConfirmation, LeadStatusDeliveredDuplicate:
 		return true
 	default:
 		return false
@@ -36,7 +53,8 @@ func (s LeadStatus) IsValid() bool {
 
 // IsTerminal returns true if the status represents a terminal state
 func (s LeadStatus) IsTerminal() bool {
-	return s == LeadStatusRejected || s == LeadStatusDelivered || s == LeadStatusPermanentlyFailed
+	return s == LeadStatusRejected || s == LeadStatusDelivered || s == LeadStatusDeliveredDuplicate ||
+		s == LeadStatusPermanentlyFailed
 }
 
 // RejectionReason represents specific reasons why a lead was rejected during validation
@@ -51,6 +69,19 @@ const (
 	
 	// RejectionReasonMissingRequiredField indicates a required field is missing from the payload
 	RejectionReasonMissingRequiredField RejectionReason = "MISSING_REQUIRED_FIELD"
+	
+	// RejectionReasonMissingDependentField indicates a field required by another present field is missing
+	RejectionReasonMissingDependentField RejectionReason = "MISSING_DEPENDENT_FIELD"
+	
+	// RejectionReasonSpamFilter indicates the lead matched a configured deny pattern for bot/test submissions
+	RejectionReasonSpamFilter RejectionReason = "SPAM_FILTER"
+	
+	// RejectionReasonSuppressed indicates the lead matched a suppression rule or the suppression list
+	RejectionReasonSuppressed RejectionReason = "SUPPRESSED"
+	
+	// RejectionReasonPolicyViolation indicates a configured policy evaluated to false;
+	// the stored reason names the policy, e.g. POLICY_VIOLATION[0]: age >= 18
+	RejectionReasonPolicyViolation RejectionReason = "POLICY_VIOLATION"
 )
 
 // String returns the string representation of the rejection reason
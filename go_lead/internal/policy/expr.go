@@ -0,0 +1,498 @@
+// Package policy evaluates validation policies against a decoded JSON payload. The
+// policies are boolean expressions in a small expression language of this package.
+//
+// Supported syntax:
+//   - literals: 42, 2.5, 'text', "text", true, false, [1, 2]
+//   - payload fields: top-level fields are variables, e.g. zipcode, house.roof_type,
+//     house["roof_type"], items[0]
+//   - operators by precedence: ?:, ||, &&, == != < <= > >= in, + -, * / %, ! and unary -
+//   - functions: has(house.roof_area), size(x) or x.size(), s.contains(t), s.startsWith(t),
+//     s.endsWith(t), s.matches(regex), int(x), string(x)
+//
+// All numbers are doubles, as in JSON, so 7 / 2 is 3.5; int(x) truncates toward zero.
+// has() is false when any part of its path is missing. Selecting a missing field is an
+// error, which && and || absorb: false && <error> is false and true || <error> is true.
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrNoSuchKey is returned when an expression selects a field the payload does not have
+var ErrNoSuchKey = errors.New("no such key")
+
+// Program is a compiled policy expression
+type Program struct {
+	source string
+	root   node
+}
+
+// Compile parses a policy expression into its syntax tree
+func Compile(expr string) (*Program, error) {
+	p := &parser{input: expr}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return &Program{source: expr, root: root}, nil
+}
+
+// Eval evaluates the policy against a payload. It returns an error if the expression
+// cannot be evaluated, e.g. because it selects a missing field, or is not a boolean.
+func (p *Program) Eval(payload map[string]interface{}) (bool, error) {
+	value, err := p.root.eval(payload)
+	if err != nil {
+		return false, err
+	}
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("policy evaluated to %s, not bool", typeName(value))
+	}
+	return result, nil
+}
+
+// String returns the expression source
+func (p *Program) String() string {
+	return p.source
+}
+
+// node is an evaluable part of an expression
+type node interface {
+	eval(vars map[string]interface{}) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n literalNode) eval(vars map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+// identNode looks up a top-level payload field
+type identNode struct {
+	name string
+}
+
+func (n identNode) eval(vars map[string]interface{}) (interface{}, error) {
+	value, ok := vars[n.name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoSuchKey, n.name)
+	}
+	return normalize(value), nil
+}
+
+// selectNode looks up a field of an object
+type selectNode struct {
+	operand node
+	field   string
+}
+
+func (n selectNode) eval(vars map[string]interface{}) (interface{}, error) {
+	value, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot select field %q of %s", n.field, typeName(value))
+	}
+	field, ok := object[n.field]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoSuchKey, n.field)
+	}
+	return normalize(field), nil
+}
+
+// indexNode looks up a list element or an object field by a computed key
+type indexNode struct {
+	operand node
+	index   node
+}
+
+func (n indexNode) eval(vars map[string]interface{}) (interface{}, error) {
+	value, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		i, ok := index.(float64)
+		if !ok || i != math.Trunc(i) {
+			return nil, fmt.Errorf("cannot index list with %s", typeName(index))
+		}
+		if i < 0 || int(i) >= len(v) {
+			return nil, fmt.Errorf("index %d out of range", int(i))
+		}
+		return normalize(v[int(i)]), nil
+	case map[string]interface{}:
+		key, ok := index.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot index map with %s", typeName(index))
+		}
+		field, ok := v[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNoSuchKey, key)
+		}
+		return normalize(field), nil
+	default:
+		return nil, fmt.Errorf("cannot index %s", typeName(value))
+	}
+}
+
+// hasNode reports whether a field is present; without operand it checks a top-level field
+type hasNode struct {
+	operand node
+	field   string
+}
+
+func (n hasNode) eval(vars map[string]interface{}) (interface{}, error) {
+	if n.operand == nil {
+		_, ok := vars[n.field]
+		return ok, nil
+	}
+	value, err := n.operand.eval(vars)
+	if errors.Is(err, ErrNoSuchKey) {
+		return false, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return false, nil
+	}
+	_, ok = object[n.field]
+	return ok, nil
+}
+
+type listNode []node
+
+func (n listNode) eval(vars map[string]interface{}) (interface{}, error) {
+	list := make([]interface{}, len(n))
+	for i, element := range n {
+		value, err := element.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = value
+	}
+	return list, nil
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n unaryNode) eval(vars map[string]interface{}) (interface{}, error) {
+	value, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case bool:
+		if n.op == "!" {
+			return !v, nil
+		}
+	case float64:
+		if n.op == "-" {
+			return -v, nil
+		}
+	}
+	return nil, fmt.Errorf("no such overload: %s%s", n.op, typeName(value))
+}
+
+// logicalNode is && or ||, which absorb an error of one side if the other side decides the result
+type logicalNode struct {
+	and         bool
+	left, right node
+}
+
+func (n logicalNode) eval(vars map[string]interface{}) (interface{}, error) {
+	left, leftErr := evalBool(n.left, vars)
+	if leftErr == nil && left != n.and {
+		return left, nil
+	}
+	right, rightErr := evalBool(n.right, vars)
+	if rightErr == nil && right != n.and {
+		return right, nil
+	}
+	if leftErr != nil {
+		return nil, leftErr
+	}
+	if rightErr != nil {
+		return nil, rightErr
+	}
+	return n.and, nil
+}
+
+// evalBool evaluates a node that must yield a bool
+func evalBool(n node, vars map[string]interface{}) (bool, error) {
+	value, err := n.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected bool, got %s", typeName(value))
+	}
+	return b, nil
+}
+
+type conditionalNode struct {
+	condition, then, otherwise node
+}
+
+func (n conditionalNode) eval(vars map[string]interface{}) (interface{}, error) {
+	condition, err := evalBool(n.condition, vars)
+	if err != nil {
+		return nil, err
+	}
+	if condition {
+		return n.then.eval(vars)
+	}
+	return n.otherwise.eval(vars)
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n binaryNode) eval(vars map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		return contains(right, left)
+	case "<", "<=", ">", ">=":
+		return compare(n.op, left, right)
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("no such overload: %s %s %s", typeName(left), n.op, typeName(right))
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return l / r, nil
+	default: // "%"
+		if r == 0 {
+			return nil, errors.New("modulus by zero")
+		}
+		return math.Mod(l, r), nil
+	}
+}
+
+func equal(left, right interface{}) bool {
+	return reflect.DeepEqual(left, right)
+}
+
+// contains implements "element in list"
+func contains(collection, element interface{}) (bool, error) {
+	list, ok := collection.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("no such overload: %s in %s", typeName(element), typeName(collection))
+	}
+	for _, item := range list {
+		if equal(normalize(item), element) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func compare(op string, left, right interface{}) (bool, error) {
+	var cmp int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return false, fmt.Errorf("no such overload: %s %s %s", typeName(left), op, typeName(right))
+		}
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false, fmt.Errorf("no such overload: %s %s %s", typeName(left), op, typeName(right))
+		}
+		cmp = strings.Compare(l, r)
+	default:
+		return false, fmt.Errorf("no such overload: %s %s %s", typeName(left), op, typeName(right))
+	}
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default: // ">="
+		return cmp >= 0, nil
+	}
+}
+
+// callNode calls a function, with the receiver of a method call as first argument
+type callNode struct {
+	function string
+	args     []node
+	pattern  *regexp.Regexp // matches() with a literal pattern, compiled once
+}
+
+// functionArity is the number of arguments of each function, including the receiver
+var functionArity = map[string]int{
+	"size": 1, "int": 1, "string": 1,
+	"contains": 2, "startsWith": 2, "endsWith": 2, "matches": 2,
+}
+
+func (n callNode) eval(vars map[string]interface{}) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	switch n.function {
+	case "size":
+		switch v := args[0].(type) {
+		case string:
+			return float64(len([]rune(v))), nil
+		case []interface{}:
+			return float64(len(v)), nil
+		}
+	case "int":
+		switch v := args[0].(type) {
+		case float64:
+			return math.Trunc(v), nil
+		case string:
+			i, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot convert %q to int", v)
+			}
+			return float64(i), nil
+		}
+	case "string":
+		if v, ok := args[0].(float64); ok {
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		}
+	default:
+		s, sok := args[0].(string)
+		t, tok := args[1].(string)
+		if !sok || !tok {
+			break
+		}
+		switch n.function {
+		case "contains":
+			return strings.Contains(s, t), nil
+		case "startsWith":
+			return strings.HasPrefix(s, t), nil
+		case "endsWith":
+			return strings.HasSuffix(s, t), nil
+		case "matches":
+			pattern := n.pattern
+			if pattern == nil {
+				var err error
+				if pattern, err = regexp.Compile(t); err != nil {
+					return nil, fmt.Errorf("invalid pattern %q: %w", t, err)
+				}
+			}
+			return pattern.MatchString(s), nil
+		}
+	}
+
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = typeName(arg)
+	}
+	return nil, fmt.Errorf("no such overload: %s(%s)", n.function, strings.Join(types, ", "))
+}
+
+// normalize converts decoded JSON numbers to float64 so that all numbers compare alike
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, item := range v {
+			normalized[i] = normalize(item)
+		}
+		return normalized
+	default:
+		return value
+	}
+}
+
+// typeName names a value by its type in error messages
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64:
+		return "double"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,138 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testPayload(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var payload map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(`{
+		"zipcode": "66123",
+		"phone": "+49 170 1234567",
+		"age": 42,
+		"owner": true,
+		"house": {"roof_area": 85.5, "roof_type": "Satteldach"},
+		"tags": ["solar", "urgent"]
+	}`))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	return payload
+}
+
+func TestProgram_Eval(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`zipcode.startsWith("66")`, true},
+		{`zipcode.startsWith('10')`, false},
+		{`age >= 18 && owner`, true},
+		{`age > 50 || house.roof_area > 80`, true},
+		{`house.roof_type in ["Satteldach", "Flachdach"]`, true},
+		{`"urgent" in tags && size(tags) == 2`, true},
+		{`tags[0] == "solar"`, true},
+		{`house["roof_type"].contains("dach")`, true},
+		{`phone.matches("^\\+49")`, true},
+		{`int(zipcode) / 1000 >= 66 && string(age) == "42"`, true},
+		{`!(age < 18) && -age < 0`, true},
+		{`house.roof_area * 2 + 1 == 172 && age % 5 == 2`, true},
+		{`zipcode.size() == 5 ? owner : false`, true},
+		{`has(house.roof_area) && !has(house.orientation)`, true},
+		{`age != 18 && age <= 42 && age - 2 == 40 && int(house.roof_area) == 85`, true},
+	}
+	payload := testPayload(t)
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			program, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("Failed to compile: %v", err)
+			}
+			result, err := program.Eval(payload)
+			if err != nil {
+				t.Fatalf("Failed to evaluate: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestProgram_EvalMissingFields(t *testing.T) {
+	payload := testPayload(t)
+
+	// Selecting a missing field is an error, not a panic
+	for _, expr := range []string{`email.endsWith(".de")`, `house.orientation == "south"`, `solar.panels.count > 3`} {
+		program, err := Compile(expr)
+		if err != nil {
+			t.Fatalf("Failed to compile %s: %v", expr, err)
+		}
+		if _, err := program.Eval(payload); !errors.Is(err, ErrNoSuchKey) {
+			t.Errorf("Expected ErrNoSuchKey for %s, got %v", expr, err)
+		}
+	}
+
+	// has() and short-circuiting guard against missing fields
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`!has(email) || email.endsWith(".de")`, true},
+		{`has(solar.panels.count)`, false},
+		{`email.endsWith(".de") || owner`, true},
+		{`email.endsWith(".de") && age < 18`, false},
+	}
+	for _, tt := range tests {
+		program, err := Compile(tt.expr)
+		if err != nil {
+			t.Fatalf("Failed to compile %s: %v", tt.expr, err)
+		}
+		result, err := program.Eval(payload)
+		if err != nil || result != tt.expected {
+			t.Errorf("Expected %s to be %v, got %v (error: %v)", tt.expr, tt.expected, result, err)
+		}
+	}
+}
+
+func TestProgram_EvalErrors(t *testing.T) {
+	payload := testPayload(t)
+	// Strings and lists are not concatenated, and in applies to lists only
+	for _, expr := range []string{`age`, `zipcode > 5`, `age / 0 > 1`, `house.roof_type.size`, `zipcode + "0" == "661230"`, `"roof_type" in house`} {
+		program, err := Compile(expr)
+		if err != nil {
+			t.Fatalf("Failed to compile %s: %v", expr, err)
+		}
+		if _, err := program.Eval(payload); err == nil {
+			t.Errorf("Expected an error for %s", expr)
+		}
+	}
+}
+
+func TestCompile_InvalidSyntax(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`age >`,
+		`zipcode.startsWith("66"`,
+		`age >= 18 &&& owner`,
+		`"unterminated`,
+		`lookup(zipcode)`,
+		`zipcode.contains()`,
+		`has(1)`,
+		`phone.matches("(")`,
+		`age # 2`,
+		`double(age) > 1`,
+		`zipcode == "66\n"`,
+	} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("Expected a syntax error for %q", expr)
+		} else if !strings.HasPrefix(err.Error(), "invalid policy expression at offset") {
+			t.Errorf("Expected the error to name the offset, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOp
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	pos   int
+	value interface{} // parsed literal of number and string tokens
+}
+
+// operators lists the operator tokens, longest first so that "<=" is not read as "<"
+var operators = []string{
+	"&&", "||", "==", "!=", "<=", ">=",
+	"<", ">", "+", "-", "*", "/", "%", "!", "?", ":", ".", ",", "(", ")", "[", "]",
+}
+
+type parser struct {
+	input  string
+	tokens []token
+	pos    int // index of the current token
+}
+
+// tokenize splits the input into tokens, ending with an EOF token
+func (p *parser) tokenize() error {
+	i := 0
+	for {
+		for i < len(p.input) && strings.IndexByte(" \t\r\n", p.input[i]) >= 0 {
+			i++
+		}
+		if i >= len(p.input) {
+			p.tokens = append(p.tokens, token{kind: tokenEOF, pos: i})
+			return nil
+		}
+
+		start := i
+		c := p.input[i]
+		switch {
+		case isDigit(c):
+			for i < len(p.input) && (isDigit(p.input[i]) || p.input[i] == '.') {
+				i++
+			}
+			value, err := strconv.ParseFloat(p.input[start:i], 64)
+			if err != nil {
+				return fmt.Errorf("invalid policy expression at offset %d: invalid number %q", start, p.input[start:i])
+			}
+			p.tokens = append(p.tokens, token{kind: tokenNumber, text: p.input[start:i], pos: start, value: value})
+		case c == '"' || c == '\'':
+			value, end, err := scanString(p.input, i)
+			if err != nil {
+				return err
+			}
+			i = end
+			p.tokens = append(p.tokens, token{kind: tokenString, text: p.input[start:i], pos: start, value: value})
+		case isIdentStart(c):
+			for i < len(p.input) && (isIdentStart(p.input[i]) || isDigit(p.input[i])) {
+				i++
+			}
+			p.tokens = append(p.tokens, token{kind: tokenIdent, text: p.input[start:i], pos: start})
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(p.input[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return fmt.Errorf("invalid policy expression at offset %d: unexpected %q", start, c)
+			}
+			i += len(op)
+			p.tokens = append(p.tokens, token{kind: tokenOp, text: op, pos: start})
+		}
+	}
+}
+
+// scanString reads a single- or double-quoted string starting at start and returns its
+// value and the offset after the closing quote
+func scanString(input string, start int) (string, int, error) {
+	quote := input[start]
+	var b strings.Builder
+	for i := start + 1; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\' && i+1 < len(input):
+			i++
+			switch input[i] {
+			case '\\', '"', '\'':
+				b.WriteByte(input[i])
+			default:
+				return "", 0, fmt.Errorf("invalid policy expression at offset %d: invalid escape \\%c", i-1, input[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("invalid policy expression at offset %d: unterminated string", start)
+}
+
+// parseExpr parses a conditional: cond ? then : otherwise
+func (p *parser) parseExpr() (node, error) {
+	condition, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept("?") {
+		return condition, nil
+	}
+	then, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	return conditionalNode{condition: condition, then: then, otherwise: otherwise}, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{and: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseRelation()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseRelation()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseRelation() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		isRelation := tok.kind == tokenIdent && tok.text == "in"
+		switch tok.text {
+		case "==", "!=", "<", "<=", ">", ">=":
+			isRelation = tok.kind == tokenOp
+		}
+		if !isRelation {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: tok.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind != tokenOp || (tok.text != "+" && tok.text != "-") {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: tok.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind != tokenOp || (tok.text != "*" && tok.text != "/" && tok.text != "%") {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: tok.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if tok := p.peek(); tok.kind == tokenOp && (tok.text == "!" || tok.text == "-") {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op: tok.text, operand: operand}, nil
+	}
+	return p.parseMember()
+}
+
+// parseMember parses a primary followed by field selections, indexes and method calls
+func (p *parser) parseMember() (node, error) {
+	operand, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("."):
+			tok := p.peek()
+			if tok.kind != tokenIdent {
+				return nil, p.errorf(tok, "expected field name after '.'")
+			}
+			p.pos++
+			if p.accept("(") {
+				args, err := p.parseArgs(")")
+				if err != nil {
+					return nil, err
+				}
+				if operand, err = p.newCall(tok, append([]node{operand}, args...)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			operand = selectNode{operand: operand, field: tok.text}
+		case p.accept("["):
+			index, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			operand = indexNode{operand: operand, index: index}
+		default:
+			return operand, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokenNumber, tokenString:
+		p.pos++
+		return literalNode{value: tok.value}, nil
+	case tokenIdent:
+		p.pos++
+		switch tok.text {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		}
+		if !p.accept("(") {
+			return identNode{name: tok.text}, nil
+		}
+		if tok.text == "has" {
+			return p.parseHas(tok)
+		}
+		args, err := p.parseArgs(")")
+		if err != nil {
+			return nil, err
+		}
+		return p.newCall(tok, args)
+	case tokenOp:
+		switch tok.text {
+		case "(":
+			p.pos++
+			inner, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return inner, p.expect(")")
+		case "[":
+			p.pos++
+			elements, err := p.parseArgs("]")
+			if err != nil {
+				return nil, err
+			}
+			return listNode(elements), nil
+		}
+	case tokenEOF:
+		return nil, p.errorf(tok, "unexpected end of expression")
+	}
+	return nil, p.errorf(tok, "unexpected %q", tok.text)
+}
+
+// parseHas parses the argument of has(), which must be a field such as a or a.b
+func (p *parser) parseHas(tok token) (node, error) {
+	arg, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	switch selection := arg.(type) {
+	case identNode:
+		return hasNode{field: selection.name}, nil
+	case selectNode:
+		return hasNode{operand: selection.operand, field: selection.field}, nil
+	default:
+		return nil, p.errorf(tok, "has() requires a field such as has(a) or has(a.b)")
+	}
+}
+
+// parseArgs parses a comma-separated list of expressions up to the closing token
+func (p *parser) parseArgs(closing string) ([]node, error) {
+	var args []node
+	if p.accept(closing) {
+		return args, nil
+	}
+	for {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.accept(closing) {
+			return args, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// newCall checks a function call against the known functions
+func (p *parser) newCall(tok token, args []node) (node, error) {
+	arity, ok := functionArity[tok.text]
+	if !ok {
+		return nil, p.errorf(tok, "unknown function %s", tok.text)
+	}
+	if len(args) != arity {
+		return nil, p.errorf(tok, "%s expects %d argument(s), got %d", tok.text, arity, len(args))
+	}
+	call := callNode{function: tok.text, args: args}
+	if literal, ok := args[len(args)-1].(literalNode); ok && tok.text == "matches" {
+		if pattern, isString := literal.value.(string); isString {
+			compiled, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, p.errorf(tok, "invalid pattern %q: %v", pattern, err)
+			}
+			call.pattern = compiled
+		}
+	}
+	return call, nil
+}
+
+// accept consumes the current token if it is the given operator
+func (p *parser) accept(op string) bool {
+	if tok := p.peek(); tok.kind == tokenOp && tok.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		tok := p.peek()
+		return p.errorf(tok, "expected %q", op)
+	}
+	return nil
+}
+
+// peek returns the current token; the last token is always EOF
+func (p *parser) peek() token {
+	if p.pos >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return fmt.Errorf("invalid policy expression at offset %d: %s", tok.pos, fmt.Sprintf(format, args...))
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -4,13 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/lib/pq"
 )
 
 // DBQueue implements Queue interface using PostgreSQL
 type DBQueue struct {
-	db *sql.DB
+	db                 *sql.DB
+	defaultMaxAttempts int // max attempts of jobs enqueued without a limit of their own (0 = unlimited)
 }
 
 // NewDBQueue creates a new database-backed queue
@@ -29,6 +34,13 @@ func NewDBQueue(db *sql.DB) (*DBQueue, error) {
 	return queue, nil
 }
 
+// SetDefaultMaxAttempts limits how often jobs enqueued without a limit of their own may be
+// dequeued. A job that is retried after its last attempt fails instead of staying pending,
+// e.g. one whose worker keeps crashing. 0 leaves such jobs unlimited.
+func (q *DBQueue) SetDefaultMaxAttempts(maxAttempts int) {
+	q.defaultMaxAttempts = maxAttempts
+}
+
 // ensureTable creates the jobs table if it doesn't exist
 func (q *DBQueue) ensureTable(ctx context.Context) error {
 	query := `
@@ -42,53 +54,155 @@ func (q *DBQueue) ensureTable(ctx context.Context) error {
 			status VARCHAR(20) NOT NULL DEFAULT 'pending',
 			error_message TEXT,
 			completed_at TIMESTAMP,
-			failed_at TIMESTAMP
+			failed_at TIMESTAMP,
+			current_priority INT NOT NULL DEFAULT 0,
+			max_attempts INT
 		);
 
+		ALTER TABLE background_jobs
+		ADD COLUMN IF NOT EXISTS current_priority INT NOT NULL DEFAULT 0;
+
+		ALTER TABLE background_jobs
+		ADD COLUMN IF NOT EXISTS max_attempts INT;
+
 		CREATE INDEX IF NOT EXISTS idx_background_jobs_next_run 
 		ON background_jobs(next_run_at) 
 		WHERE status = 'pending';
 
 		CREATE INDEX IF NOT EXISTS idx_background_jobs_status 
 		ON background_jobs(status);
+
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'check_background_jobs_status') THEN
+				ALTER TABLE background_jobs ADD CONSTRAINT check_background_jobs_status
+				CHECK (status IN ('pending', 'processing', 'completed', 'failed'));
+			END IF;
+		END
+		$$;
+
+		-- At most one pending or processing process_lead job per lead, so a lead is never
+		-- processed by two jobs at once. Duplicates queued before the index existed are
+		-- completed, keeping a processing job, else the oldest. The index replaces one that
+		-- covered pending jobs only.
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'idx_background_jobs_active_lead') THEN
+				DROP INDEX IF EXISTS idx_background_jobs_pending_lead;
+
+				UPDATE background_jobs
+				SET status = 'completed', completed_at = NOW(), error_message = 'duplicate job'
+				WHERE job_type = 'process_lead' AND status IN ('pending', 'processing') AND payload ? 'lead_id'
+				AND id NOT IN (
+					SELECT DISTINCT ON (payload->>'lead_id') id FROM background_jobs
+					WHERE job_type = 'process_lead' AND status IN ('pending', 'processing') AND payload ? 'lead_id'
+					ORDER BY payload->>'lead_id', status = 'processing' DESC, id
+				);
+
+				CREATE UNIQUE INDEX idx_background_jobs_active_lead
+				ON background_jobs ((payload->>'lead_id'))
+				WHERE job_type = 'process_lead' AND status IN ('pending', 'processing');
+			END IF;
+		END
+		$$;
+
+		-- Jobs left pending after their last attempt by earlier versions are failed,
+		-- freeing the pending slot of their lead
+		UPDATE background_jobs
+		SET status = 'failed', failed_at = NOW(), error_message = 'max attempts exceeded'
+		WHERE status = 'pending' AND max_attempts IS NOT NULL AND attempts >= max_attempts;
 	`
 
 	_, err := q.db.ExecContext(ctx, query)
 	return err
 }
 
-// Enqueue adds a new job to the queue
+// Enqueue adds a new job to the queue. A process_lead job for a lead that already has
+// a pending or processing one is not added; that job processes the lead.
 func (q *DBQueue) Enqueue(ctx context.Context, jobType string, payload map[string]interface{}) error {
 	return q.EnqueueWithDelay(ctx, jobType, payload, 0)
 }
 
+// EnqueueReturningID adds a new job to the queue and returns its ID, which can be
+// passed to Complete, Retry and Fail. Returns ErrDuplicateJob if a process_lead job
+// for the lead is already pending or processing.
+func (q *DBQueue) EnqueueReturningID(ctx context.Context, jobType string, payload map[string]interface{}) (int64, error) {
+	return q.insert(ctx, jobType, payload, 0, 0, 0)
+}
+
 // EnqueueWithDelay adds a job to be processed after a delay
 func (q *DBQueue) EnqueueWithDelay(ctx context.Context, jobType string, payload map[string]interface{}, delay time.Duration) error {
+	return q.EnqueueWithPriority(ctx, jobType, payload, delay, 0)
+}
+
+// EnqueueWithPriority adds a job to be processed after a delay with the given priority
+// Among due jobs, lower priorities are dequeued first
+func (q *DBQueue) EnqueueWithPriority(ctx context.Context, jobType string, payload map[string]interface{}, delay time.Duration, priority int) error {
+	_, err := q.insert(ctx, jobType, payload, delay, priority, 0)
+	if errors.Is(err, ErrDuplicateJob) {
+		return nil
+	}
+	return err
+}
+
+// EnqueueWithMaxAttempts adds a job that is dequeued at most maxAttempts times, independent
+// of the delivery attempts of its lead. Once its attempts are used up, Retry fails the job
+// instead of putting it back, and GetExhaustedJobs reports it. A maxAttempts of 0 applies
+// the default limit, like the other Enqueue methods (see SetDefaultMaxAttempts).
+func (q *DBQueue) EnqueueWithMaxAttempts(ctx context.Context, jobType string, payload map[string]interface{}, maxAttempts int) error {
+	if maxAttempts < 0 {
+		return fmt.Errorf("max attempts must not be negative, got %d", maxAttempts)
+	}
+	_, err := q.insert(ctx, jobType, payload, 0, 0, maxAttempts)
+	if errors.Is(err, ErrDuplicateJob) {
+		return nil
+	}
+	return err
+}
+
+// insert stores a job and returns its ID. A maxAttempts of 0 stores the default limit.
+func (q *DBQueue) insert(ctx context.Context, jobType string, payload map[string]interface{}, delay time.Duration, priority int, maxAttempts int) (int64, error) {
 	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal job payload: %w", err)
+		return 0, fmt.Errorf("failed to marshal job payload: %w", err)
 	}
 
 	nextRunAt := time.Now().Add(delay)
 
+	if maxAttempts == 0 {
+		maxAttempts = q.defaultMaxAttempts
+	}
+	var limit sql.NullInt64
+	if maxAttempts > 0 {
+		limit = sql.NullInt64{Int64: int64(maxAttempts), Valid: true}
+	}
+
 	query := `
-		INSERT INTO background_jobs (job_type, payload, next_run_at)
-		VALUES ($1, $2, $3)
+		INSERT INTO background_jobs (job_type, payload, next_run_at, current_priority, max_attempts)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT ((payload->>'lead_id')) WHERE job_type = 'process_lead' AND status IN ('pending', 'processing')
+		DO NOTHING
+		RETURNING id
 	`
 
-	_, err = q.db.ExecContext(ctx, query, jobType, payloadJSON, nextRunAt)
+	var id int64
+	err = q.db.QueryRowContext(ctx, query, jobType, payloadJSON, nextRunAt, priority, limit).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, ErrDuplicateJob
+	}
 	if err != nil {
 		// Check if error is due to database unavailability
 		if isDatabaseUnavailable(err) {
-			return fmt.Errorf("%w: %v", ErrQueueUnavailable, err)
+			return 0, fmt.Errorf("%w: %v", ErrQueueUnavailable, err)
 		}
-		return fmt.Errorf("failed to enqueue job: %w", err)
+		return 0, fmt.Errorf("failed to enqueue job: %w", err)
 	}
 
-	return nil
+	return id, nil
 }
 
 // Dequeue retrieves the next available job from the queue
+// Pending jobs that used up their max attempts are skipped
 func (q *DBQueue) Dequeue(ctx context.Context) (*Job, error) {
 	// Use SELECT FOR UPDATE SKIP LOCKED for concurrent workers
 	query := `
@@ -97,11 +211,12 @@ func (q *DBQueue) Dequeue(ctx context.Context) (*Job, error) {
 		WHERE id = (
 			SELECT id FROM background_jobs
 			WHERE status = 'pending' AND next_run_at <= NOW()
-			ORDER BY next_run_at ASC
+			AND (max_attempts IS NULL OR attempts < max_attempts)
+			ORDER BY current_priority ASC, next_run_at ASC
 			LIMIT 1
 			FOR UPDATE SKIP LOCKED
 		)
-		RETURNING id, job_type, payload, created_at, next_run_at, attempts
+		RETURNING id, job_type, payload, created_at, next_run_at, attempts, current_priority, COALESCE(max_attempts, 0)
 	`
 
 	var job Job
@@ -114,6 +229,8 @@ func (q *DBQueue) Dequeue(ctx context.Context) (*Job, error) {
 		&job.CreatedAt,
 		&job.NextRunAt,
 		&job.Attempts,
+		&job.Priority,
+		&job.MaxAttempts,
 	)
 
 	if err == sql.ErrNoRows {
@@ -125,13 +242,105 @@ func (q *DBQueue) Dequeue(ctx context.Context) (*Job, error) {
 	}
 
 	// Unmarshal payload
-	if err := json.Unmarshal(payloadJSON, &job.Payload); err != nil {
+	if err := models.DecodeJSON(payloadJSON, &job.Payload); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal job payload: %w", err)
 	}
 
 	return &job, nil
 }
 
+// Peek returns up to limit due pending jobs in the order Dequeue would claim them,
+// without locking or modifying them. The result is a snapshot: a concurrent Dequeue
+// may claim a returned job at any time.
+func (q *DBQueue) Peek(ctx context.Context, limit int) ([]*Job, error) {
+	query := `
+		SELECT id, job_type, payload, created_at, next_run_at, attempts, current_priority, COALESCE(max_attempts, 0)
+		FROM background_jobs
+		WHERE status = 'pending' AND next_run_at <= NOW()
+		AND (max_attempts IS NULL OR attempts < max_attempts)
+		ORDER BY current_priority ASC, next_run_at ASC
+		LIMIT $1
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to peek jobs: %w", err)
+	}
+	defer rows.Close()
+
+	return scanJobs(rows, limit)
+}
+
+// GetExhaustedJobs returns the jobs that failed because they used up their max attempts,
+// oldest first
+func (q *DBQueue) GetExhaustedJobs(ctx context.Context) ([]*Job, error) {
+	query := `
+		SELECT id, job_type, payload, created_at, next_run_at, attempts, current_priority, COALESCE(max_attempts, 0)
+		FROM background_jobs
+		WHERE status = 'failed' AND max_attempts IS NOT NULL AND attempts >= max_attempts
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := q.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exhausted jobs: %w", err)
+	}
+	defer rows.Close()
+
+	return scanJobs(rows, 0)
+}
+
+// scanJobs reads the jobs selected by Peek and GetExhaustedJobs
+func scanJobs(rows *sql.Rows, capacity int) ([]*Job, error) {
+	jobs := make([]*Job, 0, capacity)
+	for rows.Next() {
+		var job Job
+		var payloadJSON []byte
+		if err := rows.Scan(
+			&job.ID,
+			&job.Type,
+			&payloadJSON,
+			&job.CreatedAt,
+			&job.NextRunAt,
+			&job.Attempts,
+			&job.Priority,
+			&job.MaxAttempts,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+
+		if err := models.DecodeJSON(payloadJSON, &job.Payload); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal job payload: %w", err)
+		}
+
+		jobs = append(jobs, &job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// CountPending returns the number of due pending jobs, i.e. the current backlog.
+// Jobs scheduled for a later retry and jobs that used up their max attempts are not counted.
+func (q *DBQueue) CountPending(ctx context.Context) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM background_jobs
+		WHERE status = 'pending' AND next_run_at <= NOW()
+		AND (max_attempts IS NULL OR attempts < max_attempts)
+	`
+
+	var count int
+	if err := q.db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count pending jobs: %w", err)
+	}
+
+	return count, nil
+}
+
 // Complete marks a job as successfully completed
 func (q *DBQueue) Complete(ctx context.Context, jobID int64) error {
 	query := `
@@ -157,17 +366,39 @@ func (q *DBQueue) Complete(ctx context.Context, jobID int64) error {
 	return nil
 }
 
-// Retry reschedules a job for retry with a delay
+// Retry reschedules a job for retry with a delay. A job that used up its max attempts
+// is failed instead, so it neither stays pending forever nor blocks new jobs for its lead.
+// A processing job keeps the slot of its lead, so no other job for the lead can be queued
+// meanwhile.
 func (q *DBQueue) Retry(ctx context.Context, jobID int64, delay time.Duration) error {
+	return q.reschedule(ctx, jobID, delay, sql.NullInt64{})
+}
+
+// RetryWithPriority reschedules a job like Retry and changes its priority
+func (q *DBQueue) RetryWithPriority(ctx context.Context, jobID int64, delay time.Duration, priority int) error {
+	return q.reschedule(ctx, jobID, delay, sql.NullInt64{Int64: int64(priority), Valid: true})
+}
+
+// reschedule puts a job back into the queue, keeping its priority unless one is given
+func (q *DBQueue) reschedule(ctx context.Context, jobID int64, delay time.Duration, priority sql.NullInt64) error {
 	nextRunAt := time.Now().Add(delay)
 
 	query := `
 		UPDATE background_jobs
-		SET status = 'pending', next_run_at = $2
+		SET status = CASE WHEN max_attempts IS NOT NULL AND attempts >= max_attempts THEN 'failed' ELSE 'pending' END,
+			next_run_at = $2,
+			current_priority = COALESCE($3, current_priority),
+			failed_at = CASE WHEN max_attempts IS NOT NULL AND attempts >= max_attempts THEN NOW() ELSE failed_at END,
+			error_message = CASE WHEN max_attempts IS NOT NULL AND attempts >= max_attempts THEN 'max attempts exceeded' ELSE error_message END
 		WHERE id = $1
 	`
 
-	result, err := q.db.ExecContext(ctx, query, jobID, nextRunAt)
+	result, err := q.db.ExecContext(ctx, query, jobID, nextRunAt, priority)
+	if isUniqueViolation(err) {
+		// A finished job was retried while another job for the lead is queued, which
+		// processes the lead instead
+		return q.Complete(ctx, jobID)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to retry job: %w", err)
 	}
@@ -247,6 +478,12 @@ func isDatabaseUnavailable(err error) bool {
 		})
 }
 
+// isUniqueViolation checks if an error is a violation of a unique index
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
 // containsAny checks if a string contains any of the given substrings
 func containsAny(s string, substrs []string) bool {
 	for _, substr := range substrs {
@@ -3,6 +3,7 @@ package queue
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
@@ -185,6 +186,139 @@ func TestDBQueue_Complete(t *testing.T) {
 	}
 }
 
+func TestDBQueue_EnqueueReturningID(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	queue, err := NewDBQueue(db)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+
+	ctx := context.Background()
+
+	ids := make(map[string]int64)
+	for i, outcome := range []string{"completed", "retried", "failed"} {
+		id, err := queue.EnqueueReturningID(ctx, "process_lead", NewJobPayload(int64(100+i)))
+		if err != nil {
+			t.Fatalf("Failed to enqueue job: %v", err)
+		}
+		ids[outcome] = id
+	}
+
+	// The returned ID is the row holding the job
+	var leadID int64
+	err = db.QueryRow("SELECT (payload->>'lead_id')::bigint FROM background_jobs WHERE id = $1", ids["retried"]).Scan(&leadID)
+	if err != nil {
+		t.Fatalf("Failed to query job %d: %v", ids["retried"], err)
+	}
+	if leadID != 101 {
+		t.Errorf("Expected job %d to carry lead 101, got %d", ids["retried"], leadID)
+	}
+
+	job, err := queue.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Failed to dequeue job: %v", err)
+	}
+	if job.ID != ids["completed"] {
+		t.Errorf("Expected to dequeue job %d, got %d", ids["completed"], job.ID)
+	}
+
+	if err := queue.Complete(ctx, ids["completed"]); err != nil {
+		t.Fatalf("Failed to complete job: %v", err)
+	}
+	if err := queue.Retry(ctx, ids["retried"], time.Hour); err != nil {
+		t.Fatalf("Failed to retry job: %v", err)
+	}
+	if err := queue.Fail(ctx, ids["failed"], "permanent error"); err != nil {
+		t.Fatalf("Failed to fail job: %v", err)
+	}
+
+	expected := map[string]string{"completed": "completed", "retried": "pending", "failed": "failed"}
+	for outcome, id := range ids {
+		var status string
+		if err := db.QueryRow("SELECT status FROM background_jobs WHERE id = $1", id).Scan(&status); err != nil {
+			t.Fatalf("Failed to query job status: %v", err)
+		}
+		if status != expected[outcome] {
+			t.Errorf("Expected job %d to be %s, got %s", id, expected[outcome], status)
+		}
+	}
+}
+
+func TestDBQueue_DuplicateEnqueue(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	queue, err := NewDBQueue(db)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+
+	ctx := context.Background()
+
+	// A retried webhook or a redelivery enqueues the same lead again
+	if err := queue.Enqueue(ctx, JobTypeProcessLead, NewJobPayload(601)); err != nil {
+		t.Fatalf("Failed to enqueue job: %v", err)
+	}
+	if err := queue.Enqueue(ctx, JobTypeProcessLead, NewJobPayload(601)); err != nil {
+		t.Fatalf("Expected duplicate enqueue to be a no-op, got %v", err)
+	}
+	if _, err := queue.EnqueueReturningID(ctx, JobTypeProcessLead, NewJobPayload(601)); !errors.Is(err, ErrDuplicateJob) {
+		t.Errorf("Expected ErrDuplicateJob, got %v", err)
+	}
+
+	var pending int
+	err = db.QueryRow("SELECT COUNT(*) FROM background_jobs WHERE payload->>'lead_id' = '601' AND status = 'pending'").Scan(&pending)
+	if err != nil {
+		t.Fatalf("Failed to count pending jobs: %v", err)
+	}
+	if pending != 1 {
+		t.Errorf("Expected 1 pending job for the lead, got %d", pending)
+	}
+
+	// Other job types and other leads are not affected
+	if err := queue.Enqueue(ctx, JobTypeNotifySender, NewJobPayload(601)); err != nil {
+		t.Fatalf("Failed to enqueue notification: %v", err)
+	}
+	if err := queue.Enqueue(ctx, JobTypeProcessLead, NewJobPayload(602)); err != nil {
+		t.Fatalf("Failed to enqueue job: %v", err)
+	}
+	if count, err := queue.CountPending(ctx); err != nil || count != 3 {
+		t.Errorf("Expected 3 pending jobs, got %d (%v)", count, err)
+	}
+
+	// While the job is being processed, the lead keeps its slot and is not queued twice
+	job, err := queue.Dequeue(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("Failed to dequeue job: %v", err)
+	}
+	if _, err := queue.EnqueueReturningID(ctx, job.Type, job.Payload); !errors.Is(err, ErrDuplicateJob) {
+		t.Errorf("Expected ErrDuplicateJob while the job is processing, got %v", err)
+	}
+
+	// A failed delivery reschedules the same job at a lower priority
+	if err := queue.RetryWithPriority(ctx, job.ID, 0, 1); err != nil {
+		t.Fatalf("Failed to retry job: %v", err)
+	}
+	var status string
+	var priority int
+	if err := db.QueryRow("SELECT status, current_priority FROM background_jobs WHERE id = $1", job.ID).Scan(&status, &priority); err != nil {
+		t.Fatalf("Failed to query job status: %v", err)
+	}
+	if status != "pending" || priority != 1 {
+		t.Errorf("Expected the retried job to be pending at priority 1, got %s at %d", status, priority)
+	}
+}
+
 func TestDBQueue_Retry(t *testing.T) {
 	db := setupTestDB(t)
 	if db == nil {
@@ -345,6 +479,288 @@ func TestDBQueue_EnqueueWithDelay(t *testing.T) {
 	}
 }
 
+func TestDBQueue_EnqueueWithPriority(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	queue, err := NewDBQueue(db)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+
+	ctx := context.Background()
+
+	// Enqueue a deprioritized retry before a fresh job
+	if err := queue.EnqueueWithPriority(ctx, "process_lead", NewJobPayload(301), 0, 3); err != nil {
+		t.Fatalf("Failed to enqueue job with priority: %v", err)
+	}
+	if err := queue.Enqueue(ctx, "process_lead", NewJobPayload(302)); err != nil {
+		t.Fatalf("Failed to enqueue job: %v", err)
+	}
+
+	// The fresh job should be dequeued first despite being enqueued later
+	job, err := queue.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Failed to dequeue: %v", err)
+	}
+	if job == nil {
+		t.Fatal("Expected a job to be dequeued")
+	}
+
+	if leadID, _ := GetLeadID(job.Payload); leadID != 302 {
+		t.Errorf("Expected fresh lead 302 to be dequeued first, got %d", leadID)
+	}
+	if job.Priority != 0 {
+		t.Errorf("Expected priority 0, got %d", job.Priority)
+	}
+}
+
+func TestDBQueue_Peek(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	queue, err := NewDBQueue(db)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+
+	ctx := context.Background()
+
+	// A deprioritized retry, two fresh jobs and one job that is not yet due
+	if err := queue.EnqueueWithPriority(ctx, "process_lead", NewJobPayload(401), 0, 2); err != nil {
+		t.Fatalf("Failed to enqueue job with priority: %v", err)
+	}
+	if err := queue.Enqueue(ctx, "process_lead", NewJobPayload(402)); err != nil {
+		t.Fatalf("Failed to enqueue job: %v", err)
+	}
+	if err := queue.Enqueue(ctx, "process_lead", NewJobPayload(403)); err != nil {
+		t.Fatalf("Failed to enqueue job: %v", err)
+	}
+	if err := queue.EnqueueWithDelay(ctx, "process_lead", NewJobPayload(404), time.Hour); err != nil {
+		t.Fatalf("Failed to enqueue delayed job: %v", err)
+	}
+
+	jobs, err := queue.Peek(ctx, 10)
+	if err != nil {
+		t.Fatalf("Failed to peek: %v", err)
+	}
+
+	want := []int64{402, 403, 401}
+	if len(jobs) != len(want) {
+		t.Fatalf("Expected %d due jobs, got %d", len(want), len(jobs))
+	}
+	for i, job := range jobs {
+		if leadID, _ := GetLeadID(job.Payload); leadID != want[i] {
+			t.Errorf("Job %d: expected lead %d, got %d", i, want[i], leadID)
+		}
+	}
+
+	// Peeking must not claim or modify the jobs
+	var pending int
+	if err := db.QueryRow("SELECT COUNT(*) FROM background_jobs WHERE status = 'pending' AND attempts = 0").Scan(&pending); err != nil {
+		t.Fatalf("Failed to count pending jobs: %v", err)
+	}
+	if pending != 4 {
+		t.Errorf("Expected all 4 jobs to remain pending and unattempted, got %d", pending)
+	}
+
+	// The limit caps the result, and Dequeue still claims the first peeked job
+	jobs, err = queue.Peek(ctx, 1)
+	if err != nil {
+		t.Fatalf("Failed to peek: %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("Expected 1 job with limit 1, got %d", len(jobs))
+	}
+
+	job, err := queue.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Failed to dequeue: %v", err)
+	}
+	if job == nil || job.ID != jobs[0].ID {
+		t.Errorf("Expected Dequeue to claim peeked job %d, got %v", jobs[0].ID, job)
+	}
+}
+
+func TestDBQueue_CountPending(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	queue, err := NewDBQueue(db)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if err := queue.Enqueue(ctx, "process_lead", NewJobPayload(501)); err != nil {
+		t.Fatalf("Failed to enqueue job: %v", err)
+	}
+	if err := queue.Enqueue(ctx, "process_lead", NewJobPayload(502)); err != nil {
+		t.Fatalf("Failed to enqueue job: %v", err)
+	}
+	// Not yet due, so not part of the backlog
+	if err := queue.EnqueueWithDelay(ctx, "process_lead", NewJobPayload(503), time.Hour); err != nil {
+		t.Fatalf("Failed to enqueue delayed job: %v", err)
+	}
+
+	count, err := queue.CountPending(ctx)
+	if err != nil {
+		t.Fatalf("Failed to count pending jobs: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 pending jobs, got %d", count)
+	}
+
+	// Claimed jobs are no longer pending
+	if _, err := queue.Dequeue(ctx); err != nil {
+		t.Fatalf("Failed to dequeue: %v", err)
+	}
+	if count, err = queue.CountPending(ctx); err != nil || count != 1 {
+		t.Errorf("Expected 1 pending job after dequeue, got %d (err %v)", count, err)
+	}
+}
+
+func TestDBQueue_MaxAttempts(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	queue, err := NewDBQueue(db)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if err := queue.EnqueueWithMaxAttempts(ctx, "process_lead", NewJobPayload(601), 3); err != nil {
+		t.Fatalf("Failed to enqueue job: %v", err)
+	}
+
+	// The job is dequeued and put back three times, like a job whose worker crashed
+	var jobID int64
+	for attempt := 1; attempt <= 3; attempt++ {
+		job, err := queue.Dequeue(ctx)
+		if err != nil {
+			t.Fatalf("Failed to dequeue job: %v", err)
+		}
+		if job == nil {
+			t.Fatalf("Expected the job to be dequeued on attempt %d", attempt)
+		}
+		if job.Attempts != attempt || job.MaxAttempts != 3 {
+			t.Errorf("Expected attempt %d of 3, got %d of %d", attempt, job.Attempts, job.MaxAttempts)
+		}
+		jobID = job.ID
+		if err := queue.Retry(ctx, job.ID, 0); err != nil {
+			t.Fatalf("Failed to retry job: %v", err)
+		}
+	}
+
+	// The job used up its attempts, so the last retry failed it
+	job, err := queue.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Failed to dequeue: %v", err)
+	}
+	if job != nil {
+		t.Errorf("Expected no job after 3 attempts, got job %d with %d attempts", job.ID, job.Attempts)
+	}
+	if count, err := queue.CountPending(ctx); err != nil || count != 0 {
+		t.Errorf("Expected an exhausted job not to count as backlog, got %d (err %v)", count, err)
+	}
+
+	var status string
+	if err := db.QueryRowContext(ctx, "SELECT status FROM background_jobs WHERE id = $1", jobID).Scan(&status); err != nil {
+		t.Fatalf("Failed to read job status: %v", err)
+	}
+	if status != "failed" {
+		t.Errorf("Expected the exhausted job to be failed, got %s", status)
+	}
+
+	// The failed job no longer holds the pending slot of its lead
+	if _, err := queue.EnqueueReturningID(ctx, "process_lead", NewJobPayload(601)); err != nil {
+		t.Errorf("Expected a new job for the lead of the exhausted job, got %v", err)
+	}
+
+	// Jobs without a limit are not exhausted
+	if err := queue.Enqueue(ctx, "process_lead", NewJobPayload(602)); err != nil {
+		t.Fatalf("Failed to enqueue job: %v", err)
+	}
+
+	exhausted, err := queue.GetExhaustedJobs(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get exhausted jobs: %v", err)
+	}
+	if len(exhausted) != 1 || exhausted[0].ID != jobID {
+		t.Fatalf("Expected job %d to be exhausted, got %v", jobID, exhausted)
+	}
+	if exhausted[0].Attempts != 3 || exhausted[0].MaxAttempts != 3 {
+		t.Errorf("Expected 3 of 3 attempts, got %d of %d", exhausted[0].Attempts, exhausted[0].MaxAttempts)
+	}
+	if leadID, _ := GetLeadID(exhausted[0].Payload); leadID != 601 {
+		t.Errorf("Expected the payload of lead 601, got %v", exhausted[0].Payload)
+	}
+
+	if err := queue.EnqueueWithMaxAttempts(ctx, "process_lead", NewJobPayload(603), -1); err == nil {
+		t.Error("Expected an error for negative max attempts")
+	}
+}
+
+func TestDBQueue_DefaultMaxAttempts(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	queue, err := NewDBQueue(db)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	queue.SetDefaultMaxAttempts(2)
+
+	ctx := context.Background()
+
+	if err := queue.Enqueue(ctx, "process_lead", NewJobPayload(611)); err != nil {
+		t.Fatalf("Failed to enqueue job: %v", err)
+	}
+	if err := queue.EnqueueWithMaxAttempts(ctx, "process_lead", NewJobPayload(612), 5); err != nil {
+		t.Fatalf("Failed to enqueue job: %v", err)
+	}
+
+	limits := map[int64]int{}
+	for i := 0; i < 2; i++ {
+		job, err := queue.Dequeue(ctx)
+		if err != nil || job == nil {
+			t.Fatalf("Failed to dequeue job: %v", err)
+		}
+		leadID, _ := GetLeadID(job.Payload)
+		limits[leadID] = job.MaxAttempts
+	}
+
+	if limits[611] != 2 {
+		t.Errorf("Expected the default limit of 2 attempts, got %d", limits[611])
+	}
+	if limits[612] != 5 {
+		t.Errorf("Expected the job's own limit of 5 attempts, got %d", limits[612])
+	}
+}
+
 func TestDBQueue_JobSerializationRoundTrip(t *testing.T) {
 	db := setupTestDB(t)
 	if db == nil {
@@ -11,6 +11,9 @@ var (
 
 	// ErrInvalidPayload indicates the job payload is invalid
 	ErrInvalidPayload = errors.New("invalid job payload")
+
+	// ErrDuplicateJob indicates a process_lead job for the lead is already pending or processing
+	ErrDuplicateJob = errors.New("job already queued for lead")
 )
 
 // IsUnavailableError checks if an error indicates queue unavailability
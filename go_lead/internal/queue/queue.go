@@ -6,6 +6,18 @@ import (
 	"time"
 )
 
+// Job types handled by the worker
+const (
+	// JobTypeProcessLead runs a lead through validation, transformation, and delivery
+	JobTypeProcessLead = "process_lead"
+
+	// JobTypeConfirmationTimeout fails a lead whose async delivery was never confirmed
+	JobTypeConfirmationTimeout = "confirmation_timeout"
+
+	// JobTypeNotifySender posts the final lead outcome to the sender's callback URL
+	JobTypeNotifySender = "notify_sender"
+)
+
 // Job represents a background job to be processed
 type Job struct {
 	ID        int64                  `json:"id"`
@@ -14,16 +26,26 @@ type Job struct {
 	CreatedAt time.Time              `json:"created_at"`
 	NextRunAt time.Time              `json:"next_run_at"`
 	Attempts  int                    `json:"attempts"`
+	Priority  int                    `json:"priority"` // lower values are dequeued first
+
+	MaxAttempts int `json:"max_attempts,omitempty"` // times the job may be dequeued (0 = unlimited)
 }
 
 // Queue defines the interface for job queue operations
 type Queue interface {
 	// Enqueue adds a new job to the queue
+	// A process_lead job for a lead that already has a pending or processing one is not added
 	Enqueue(ctx context.Context, jobType string, payload map[string]interface{}) error
 
+	// EnqueueReturningID adds a new job to the queue and returns the ID of the created job
+	EnqueueReturningID(ctx context.Context, jobType string, payload map[string]interface{}) (int64, error)
+
 	// EnqueueWithDelay adds a job to be processed after a delay
 	EnqueueWithDelay(ctx context.Context, jobType string, payload map[string]interface{}, delay time.Duration) error
 
+	// EnqueueWithPriority adds a job to be processed after a delay, behind all due jobs with a lower priority
+	EnqueueWithPriority(ctx context.Context, jobType string, payload map[string]interface{}, delay time.Duration, priority int) error
+
 	// Dequeue retrieves the next available job from the queue
 	// Returns nil if no jobs are available
 	Dequeue(ctx context.Context) (*Job, error)
@@ -34,6 +56,9 @@ type Queue interface {
 	// Retry reschedules a job for retry with a delay
 	Retry(ctx context.Context, jobID int64, delay time.Duration) error
 
+	// RetryWithPriority reschedules a job for retry with a delay and a new priority
+	RetryWithPriority(ctx context.Context, jobID int64, delay time.Duration, priority int) error
+
 	// Fail marks a job as permanently failed
 	Fail(ctx context.Context, jobID int64, errorMsg string) error
 
@@ -53,13 +78,20 @@ func NewJobPayload(leadID int64) map[string]interface{} {
 
 // GetLeadID extracts lead_id from job payload
 func GetLeadID(payload map[string]interface{}) (int64, bool) {
-	leadID, ok := payload["lead_id"]
+	return GetInt64(payload, "lead_id")
+}
+
+// GetInt64 extracts a numeric value from job payload
+func GetInt64(payload map[string]interface{}, key string) (int64, bool) {
+	value, ok := payload[key]
 	if !ok {
 		return 0, false
 	}
 
 	// Handle different numeric types
-	switch v := leadID.(type) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
 	case int64:
 		return v, true
 	case float64:
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// CallbackAttemptRepository defines the interface for sender callback attempt persistence operations
+type CallbackAttemptRepository interface {
+	// CreateCallbackAttempt creates a new callback attempt record
+	CreateCallbackAttempt(ctx context.Context, attempt *models.CallbackAttempt) error
+}
+
+// callbackAttemptRepository is the concrete implementation of CallbackAttemptRepository
+type callbackAttemptRepository struct {
+	db          *sql.DB
+	retryPolicy WriteRetryPolicy
+}
+
+// NewCallbackAttemptRepository creates a new CallbackAttemptRepository instance
+func NewCallbackAttemptRepository(db *sql.DB) CallbackAttemptRepository {
+	return NewCallbackAttemptRepositoryWithRetry(db, DefaultWriteRetryPolicy())
+}
+
+// NewCallbackAttemptRepositoryWithRetry creates a new CallbackAttemptRepository that retries
+// inserts on transient database errors according to the given policy
+func NewCallbackAttemptRepositoryWithRetry(db *sql.DB, policy WriteRetryPolicy) CallbackAttemptRepository {
+	return &callbackAttemptRepository{
+		db:          db,
+		retryPolicy: policy,
+	}
+}
+
+// CreateCallbackAttempt creates a new callback attempt record
+// Transient database errors are retried; the unique (lead_id, attempt_no) index
+// guarantees a retried insert cannot record the same attempt twice
+func (r *callbackAttemptRepository) CreateCallbackAttempt(ctx context.Context, attempt *models.CallbackAttempt) error {
+	query := `
+		INSERT INTO callback_attempts (
+			lead_id, attempt_no, callback_url, requested_at,
+			response_status, error_message, success, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+
+	now := time.Now()
+	if attempt.RequestedAt.IsZero() {
+		attempt.RequestedAt = now
+	}
+	if attempt.CreatedAt.IsZero() {
+		attempt.CreatedAt = now
+	}
+
+	err := withWriteRetry(ctx, r.retryPolicy, func() error {
+		return r.db.QueryRowContext(
+			ctx,
+			query,
+			attempt.LeadID,
+			attempt.AttemptNo,
+			attempt.CallbackURL,
+			attempt.RequestedAt,
+			attempt.ResponseStatus,
+			attempt.ErrorMessage,
+			attempt.Success,
+			attempt.CreatedAt,
+		).Scan(&attempt.ID)
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create callback attempt: %w", err)
+	}
+
+	return nil
+}
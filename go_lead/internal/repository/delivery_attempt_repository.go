@@ -21,31 +21,51 @@ type DeliveryAttemptRepository interface {
 	GetDeliveryAttemptsByLeadID(ctx context.Context, leadID int64) ([]*models.DeliveryAttempt, error)
 	
 	// GetLatestDeliveryAttempt retrieves the most recent delivery attempt for a lead
+	// Returns an error wrapping ErrDeliveryAttemptNotFound if the lead has no attempts
 	GetLatestDeliveryAttempt(ctx context.Context, leadID int64) (*models.DeliveryAttempt, error)
 	
-	// CountDeliveryAttempts returns the number of delivery attempts for a lead
+	// CountDeliveryAttempts returns the number of delivery attempts made for a lead,
+	// including attempts whose rows were pruned
 	CountDeliveryAttempts(ctx context.Context, leadID int64) (int, error)
+	
+	// PruneDeliveryAttempts deletes all but the keep most recent delivery attempts of a lead
+	// and returns the number of deleted rows
+	PruneDeliveryAttempts(ctx context.Context, leadID int64, keep int) (int64, error)
+	
+	// GetAttemptsByResponseHash retrieves all delivery attempts whose response body had the given hash
+	GetAttemptsByResponseHash(ctx context.Context, hash string) ([]*models.DeliveryAttempt, error)
 }
 
 // deliveryAttemptRepository is the concrete implementation of DeliveryAttemptRepository
 type deliveryAttemptRepository struct {
-	db *sql.DB
+	db          *sql.DB
+	retryPolicy WriteRetryPolicy
 }
 
 // NewDeliveryAttemptRepository creates a new DeliveryAttemptRepository instance
 func NewDeliveryAttemptRepository(db *sql.DB) DeliveryAttemptRepository {
+	return NewDeliveryAttemptRepositoryWithRetry(db, DefaultWriteRetryPolicy())
+}
+
+// NewDeliveryAttemptRepositoryWithRetry creates a new DeliveryAttemptRepository that retries
+// non-transactional inserts on transient database errors according to the given policy
+func NewDeliveryAttemptRepositoryWithRetry(db *sql.DB, policy WriteRetryPolicy) DeliveryAttemptRepository {
 	return &deliveryAttemptRepository{
-		db: db,
+		db:          db,
+		retryPolicy: policy,
 	}
 }
 
 // CreateDeliveryAttempt creates a new delivery attempt record
+// Transient database errors are retried; the unique (lead_id, attempt_no) index
+// guarantees a retried insert cannot record the same attempt twice
 func (r *deliveryAttemptRepository) CreateDeliveryAttempt(ctx context.Context, attempt *models.DeliveryAttempt) error {
 	query := `
 		INSERT INTO delivery_attempt (
 			lead_id, attempt_no, requested_at, response_status,
-			response_body, error_message, success, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			response_body, response_hash, error_message, success, endpoint_used, created_at,
+			truncated, truncated_at_bytes
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id
 	`
 	
@@ -56,19 +76,28 @@ func (r *deliveryAttemptRepository) CreateDeliveryAttempt(ctx context.Context, a
 	if attempt.CreatedAt.IsZero() {
 		attempt.CreatedAt = now
 	}
+	if attempt.EndpointUsed == "" {
+		attempt.EndpointUsed = models.DeliveryEndpointPrimary
+	}
 	
-	err := r.db.QueryRowContext(
-		ctx,
-		query,
-		attempt.LeadID,
-		attempt.AttemptNo,
-		attempt.RequestedAt,
-		attempt.ResponseStatus,
-		attempt.ResponseBody,
-		attempt.ErrorMessage,
-		attempt.Success,
-		attempt.CreatedAt,
-	).Scan(&attempt.ID)
+	err := withWriteRetry(ctx, r.retryPolicy, func() error {
+		return r.db.QueryRowContext(
+			ctx,
+			query,
+			attempt.LeadID,
+			attempt.AttemptNo,
+			attempt.RequestedAt,
+			attempt.ResponseStatus,
+			attempt.ResponseBody,
+			attempt.ResponseHash,
+			attempt.ErrorMessage,
+			attempt.Success,
+			attempt.EndpointUsed,
+			attempt.CreatedAt,
+			attempt.Truncated,
+			attempt.TruncatedAtBytes,
+		).Scan(&attempt.ID)
+	})
 	
 	if err != nil {
 		return fmt.Errorf("failed to create delivery attempt: %w", err)
@@ -82,8 +111,9 @@ func (r *deliveryAttemptRepository) CreateDeliveryAttemptTx(ctx context.Context,
 	query := `
 		INSERT INTO delivery_attempt (
 			lead_id, attempt_no, requested_at, response_status,
-			response_body, error_message, success, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			response_body, response_hash, error_message, success, endpoint_used, created_at,
+			truncated, truncated_at_bytes
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id
 	`
 	
@@ -94,6 +124,9 @@ func (r *deliveryAttemptRepository) CreateDeliveryAttemptTx(ctx context.Context,
 	if attempt.CreatedAt.IsZero() {
 		attempt.CreatedAt = now
 	}
+	if attempt.EndpointUsed == "" {
+		attempt.EndpointUsed = models.DeliveryEndpointPrimary
+	}
 	
 	err := tx.QueryRowContext(
 		ctx,
@@ -103,9 +136,13 @@ func (r *deliveryAttemptRepository) CreateDeliveryAttemptTx(ctx context.Context,
 		attempt.RequestedAt,
 		attempt.ResponseStatus,
 		attempt.ResponseBody,
+		attempt.ResponseHash,
 		attempt.ErrorMessage,
 		attempt.Success,
+		attempt.EndpointUsed,
 		attempt.CreatedAt,
+		attempt.Truncated,
+		attempt.TruncatedAtBytes,
 	).Scan(&attempt.ID)
 	
 	if err != nil {
@@ -120,7 +157,8 @@ func (r *deliveryAttemptRepository) GetDeliveryAttemptsByLeadID(ctx context.Cont
 	query := `
 		SELECT 
 			id, lead_id, attempt_no, requested_at, response_status,
-			response_body, error_message, success, created_at
+			response_body, response_hash, error_message, success, endpoint_used, created_at,
+			truncated, truncated_at_bytes
 		FROM delivery_attempt
 		WHERE lead_id = $1
 		ORDER BY attempt_no ASC
@@ -142,9 +180,13 @@ func (r *deliveryAttemptRepository) GetDeliveryAttemptsByLeadID(ctx context.Cont
 			&attempt.RequestedAt,
 			&attempt.ResponseStatus,
 			&attempt.ResponseBody,
+			&attempt.ResponseHash,
 			&attempt.ErrorMessage,
 			&attempt.Success,
+			&attempt.EndpointUsed,
 			&attempt.CreatedAt,
+			&attempt.Truncated,
+			&attempt.TruncatedAtBytes,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan delivery attempt: %w", err)
@@ -164,7 +206,8 @@ func (r *deliveryAttemptRepository) GetLatestDeliveryAttempt(ctx context.Context
 	query := `
 		SELECT 
 			id, lead_id, attempt_no, requested_at, response_status,
-			response_body, error_message, success, created_at
+			response_body, response_hash, error_message, success, endpoint_used, created_at,
+			truncated, truncated_at_bytes
 		FROM delivery_attempt
 		WHERE lead_id = $1
 		ORDER BY attempt_no DESC
@@ -179,13 +222,17 @@ func (r *deliveryAttemptRepository) GetLatestDeliveryAttempt(ctx context.Context
 		&attempt.RequestedAt,
 		&attempt.ResponseStatus,
 		&attempt.ResponseBody,
+		&attempt.ResponseHash,
 		&attempt.ErrorMessage,
 		&attempt.Success,
+		&attempt.EndpointUsed,
 		&attempt.CreatedAt,
+		&attempt.Truncated,
+		&attempt.TruncatedAtBytes,
 	)
 	
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("no delivery attempts found for lead: %d", leadID)
+		return nil, fmt.Errorf("%w: %d", ErrDeliveryAttemptNotFound, leadID)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get latest delivery attempt: %w", err)
@@ -194,10 +241,12 @@ func (r *deliveryAttemptRepository) GetLatestDeliveryAttempt(ctx context.Context
 	return attempt, nil
 }
 
-// CountDeliveryAttempts returns the number of delivery attempts for a lead
+// CountDeliveryAttempts returns the number of delivery attempts made for a lead
+// Attempts are numbered from 1 without gaps, so the highest attempt number stays
+// correct after older rows were pruned
 func (r *deliveryAttemptRepository) CountDeliveryAttempts(ctx context.Context, leadID int64) (int, error) {
 	query := `
-		SELECT COUNT(*)
+		SELECT COALESCE(MAX(attempt_no), 0)
 		FROM delivery_attempt
 		WHERE lead_id = $1
 	`
@@ -210,3 +259,86 @@ func (r *deliveryAttemptRepository) CountDeliveryAttempts(ctx context.Context, l
 	
 	return count, nil
 }
+
+// PruneDeliveryAttempts deletes all but the keep most recent delivery attempts of a lead
+// Transient database errors are retried; deleting again is harmless
+func (r *deliveryAttemptRepository) PruneDeliveryAttempts(ctx context.Context, leadID int64, keep int) (int64, error) {
+	query := `
+		DELETE FROM delivery_attempt
+		WHERE lead_id = $1
+		AND attempt_no NOT IN (
+			SELECT attempt_no
+			FROM delivery_attempt
+			WHERE lead_id = $1
+			ORDER BY attempt_no DESC
+			LIMIT $2
+		)
+	`
+	
+	var deleted int64
+	err := withWriteRetry(ctx, r.retryPolicy, func() error {
+		result, err := r.db.ExecContext(ctx, query, leadID, keep)
+		if err != nil {
+			return err
+		}
+		deleted, err = result.RowsAffected()
+		return err
+	})
+	
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune delivery attempts: %w", err)
+	}
+	
+	return deleted, nil
+}
+
+// GetAttemptsByResponseHash retrieves all delivery attempts whose response body had the given hash
+// Attempts of several leads with the same hash may indicate responses cached or replayed by the
+// Customer API instead of being produced for each lead
+func (r *deliveryAttemptRepository) GetAttemptsByResponseHash(ctx context.Context, hash string) ([]*models.DeliveryAttempt, error) {
+	query := `
+		SELECT 
+			id, lead_id, attempt_no, requested_at, response_status,
+			response_body, response_hash, error_message, success, endpoint_used, created_at,
+			truncated, truncated_at_bytes
+		FROM delivery_attempt
+		WHERE response_hash = $1
+		ORDER BY requested_at ASC, id ASC
+	`
+	
+	rows, err := r.db.QueryContext(ctx, query, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query delivery attempts by response hash: %w", err)
+	}
+	defer rows.Close()
+	
+	var attempts []*models.DeliveryAttempt
+	for rows.Next() {
+		attempt := &models.DeliveryAttempt{}
+		err := rows.Scan(
+			&attempt.ID,
+			&attempt.LeadID,
+			&attempt.AttemptNo,
+			&attempt.RequestedAt,
+			&attempt.ResponseStatus,
+			&attempt.ResponseBody,
+			&attempt.ResponseHash,
+			&attempt.ErrorMessage,
+			&attempt.Success,
+			&attempt.EndpointUsed,
+			&attempt.CreatedAt,
+			&attempt.Truncated,
+			&attempt.TruncatedAtBytes,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan delivery attempt: %w", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+	
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating delivery attempts: %w", err)
+	}
+	
+	return attempts, nil
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/checkfox/go_lead/internal/models"
@@ -161,8 +163,21 @@ func TestDeliveryAttemptRepository_GetLatestDeliveryAttempt(t *testing.T) {
 
 	// Test non-existent lead
 	_, err = attemptRepo.GetLatestDeliveryAttempt(ctx, 999999)
+	if !errors.Is(err, ErrDeliveryAttemptNotFound) {
+		t.Errorf("Expected ErrDeliveryAttemptNotFound for non-existent lead, got %v", err)
+	}
+}
+
+func TestDeliveryAttemptRepository_GetLatestDeliveryAttempt_DatabaseErrorIsNotNotFound(t *testing.T) {
+	db, _ := openFlakyDB(t, 1, errors.New("read tcp: connection reset by peer"))
+	repo := NewDeliveryAttemptRepository(db)
+
+	_, err := repo.GetLatestDeliveryAttempt(context.Background(), 1)
 	if err == nil {
-		t.Error("Expected error when getting latest attempt for non-existent lead")
+		t.Fatal("Expected error from failing database")
+	}
+	if errors.Is(err, ErrDeliveryAttemptNotFound) {
+		t.Errorf("Expected a database error not to be ErrDeliveryAttemptNotFound, got %v", err)
 	}
 }
 
@@ -292,3 +307,124 @@ func TestDeliveryAttemptRepository_CreateDeliveryAttemptTx(t *testing.T) {
 		t.Errorf("Expected status DELIVERED after commit, got %s", retrievedLead.Status)
 	}
 }
+
+func TestDeliveryAttemptRepository_PruneDeliveryAttempts(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	leadRepo := NewLeadRepository(db)
+	attemptRepo := NewDeliveryAttemptRepository(db)
+	ctx := context.Background()
+
+	lead := &models.InboundLead{
+		RawPayload: models.JSONB{"email": "test@example.com"},
+		Status:     models.LeadStatusReady,
+	}
+	if err := leadRepo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	// Create more attempts than are retained
+	for i := 1; i <= 5; i++ {
+		attempt := models.NewDeliveryAttempt(lead.ID, i)
+		attempt.MarkFailure(nil, "connection refused")
+		if err := attemptRepo.CreateDeliveryAttempt(ctx, attempt); err != nil {
+			t.Fatalf("Failed to create delivery attempt %d: %v", i, err)
+		}
+	}
+
+	deleted, err := attemptRepo.PruneDeliveryAttempts(ctx, lead.ID, 2)
+	if err != nil {
+		t.Fatalf("Failed to prune delivery attempts: %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("Expected 3 pruned attempts, got %d", deleted)
+	}
+
+	attempts, err := attemptRepo.GetDeliveryAttemptsByLeadID(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to get delivery attempts: %v", err)
+	}
+	if len(attempts) != 2 || attempts[0].AttemptNo != 4 || attempts[1].AttemptNo != 5 {
+		t.Fatalf("Expected attempts 4 and 5 to be kept, got %+v", attempts)
+	}
+
+	// Attempt numbering continues after the highest pruned attempt
+	count, err := attemptRepo.CountDeliveryAttempts(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to count delivery attempts: %v", err)
+	}
+	if count != 5 {
+		t.Errorf("Expected 5 attempts made, got %d", count)
+	}
+
+	// Pruning within the limit deletes nothing
+	if deleted, err := attemptRepo.PruneDeliveryAttempts(ctx, lead.ID, 2); err != nil || deleted != 0 {
+		t.Errorf("Expected nothing to prune, got %d (error: %v)", deleted, err)
+	}
+}
+
+func TestDeliveryAttemptRepository_GetAttemptsByResponseHash(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	leadRepo := NewLeadRepository(db)
+	attemptRepo := NewDeliveryAttemptRepository(db)
+	ctx := context.Background()
+
+	sharedHash := "5d0d84bdf616ca39ff9719e734e6ab3a4a5462d83baba48fd5e6fa12808382d0"
+	otherHash := "42d24e831ae770768d07e5893430cf519895b93b5a3cd286dc277b03666865e4"
+
+	// Two leads receive the same response, a third a different one
+	var leadIDs []int64
+	for i, hash := range []string{sharedHash, sharedHash, otherHash} {
+		lead := &models.InboundLead{
+			RawPayload: models.JSONB{"phone": fmt.Sprintf("123456789%d", i)},
+			Status:     models.LeadStatusReady,
+		}
+		if err := leadRepo.CreateLead(ctx, lead); err != nil {
+			t.Fatalf("Failed to create lead: %v", err)
+		}
+		leadIDs = append(leadIDs, lead.ID)
+
+		attempt := models.NewDeliveryAttempt(lead.ID, 1)
+		attempt.MarkSuccess(200, `{"id": "lead-123"}`)
+		hash := hash
+		attempt.ResponseHash = &hash
+		if err := attemptRepo.CreateDeliveryAttempt(ctx, attempt); err != nil {
+			t.Fatalf("Failed to create delivery attempt: %v", err)
+		}
+	}
+
+	attempts, err := attemptRepo.GetAttemptsByResponseHash(ctx, sharedHash)
+	if err != nil {
+		t.Fatalf("Failed to get delivery attempts by response hash: %v", err)
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("Expected 2 delivery attempts with the shared hash, got %d", len(attempts))
+	}
+	for i, attempt := range attempts {
+		if attempt.LeadID != leadIDs[i] {
+			t.Errorf("Expected attempt of lead %d, got lead %d", leadIDs[i], attempt.LeadID)
+		}
+		if attempt.ResponseHash == nil || *attempt.ResponseHash != sharedHash {
+			t.Errorf("Expected stored response hash %s, got %v", sharedHash, attempt.ResponseHash)
+		}
+	}
+
+	attempts, err = attemptRepo.GetAttemptsByResponseHash(ctx, "unknown")
+	if err != nil {
+		t.Fatalf("Failed to get delivery attempts by response hash: %v", err)
+	}
+	if len(attempts) != 0 {
+		t.Errorf("Expected no delivery attempts for an unknown hash, got %d", len(attempts))
+	}
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// DeliveryChainAttemptRepository defines the interface for forwarding chain attempt persistence operations
+type DeliveryChainAttemptRepository interface {
+	// CreateChainAttempt creates a new forwarding chain attempt record
+	CreateChainAttempt(ctx context.Context, attempt *models.DeliveryChainAttempt) error
+
+	// GetChainAttemptsByLeadID retrieves all forwarding chain attempts for a lead,
+	// ordered by chain index and attempt number
+	GetChainAttemptsByLeadID(ctx context.Context, leadID int64) ([]*models.DeliveryChainAttempt, error)
+
+	// HasSuccessfulChainAttempt reports whether the endpoint at chainIndex already accepted the lead
+	HasSuccessfulChainAttempt(ctx context.Context, leadID int64, chainIndex int) (bool, error)
+}
+
+// deliveryChainAttemptRepository is the concrete implementation of DeliveryChainAttemptRepository
+type deliveryChainAttemptRepository struct {
+	db          *sql.DB
+	retryPolicy WriteRetryPolicy
+}
+
+// NewDeliveryChainAttemptRepository creates a new DeliveryChainAttemptRepository instance
+func NewDeliveryChainAttemptRepository(db *sql.DB) DeliveryChainAttemptRepository {
+	return NewDeliveryChainAttemptRepositoryWithRetry(db, DefaultWriteRetryPolicy())
+}
+
+// NewDeliveryChainAttemptRepositoryWithRetry creates a new DeliveryChainAttemptRepository that
+// retries inserts on transient database errors according to the given policy
+func NewDeliveryChainAttemptRepositoryWithRetry(db *sql.DB, policy WriteRetryPolicy) DeliveryChainAttemptRepository {
+	return &deliveryChainAttemptRepository{
+		db:          db,
+		retryPolicy: policy,
+	}
+}
+
+// CreateChainAttempt creates a new forwarding chain attempt record
+// Transient database errors are retried; the unique (lead_id, chain_index, attempt_no)
+// index guarantees a retried insert cannot record the same attempt twice
+func (r *deliveryChainAttemptRepository) CreateChainAttempt(ctx context.Context, attempt *models.DeliveryChainAttempt) error {
+	query := `
+		INSERT INTO delivery_chain_attempts (
+			lead_id, chain_index, attempt_no, requested_at, response_status,
+			response_body, error_message, success, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+
+	now := time.Now()
+	if attempt.RequestedAt.IsZero() {
+		attempt.RequestedAt = now
+	}
+	if attempt.CreatedAt.IsZero() {
+		attempt.CreatedAt = now
+	}
+
+	err := withWriteRetry(ctx, r.retryPolicy, func() error {
+		return r.db.QueryRowContext(
+			ctx,
+			query,
+			attempt.LeadID,
+			attempt.ChainIndex,
+			attempt.AttemptNo,
+			attempt.RequestedAt,
+			attempt.ResponseStatus,
+			attempt.ResponseBody,
+			attempt.ErrorMessage,
+			attempt.Success,
+			attempt.CreatedAt,
+		).Scan(&attempt.ID)
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create delivery chain attempt: %w", err)
+	}
+
+	return nil
+}
+
+// GetChainAttemptsByLeadID retrieves all forwarding chain attempts for a lead
+func (r *deliveryChainAttemptRepository) GetChainAttemptsByLeadID(ctx context.Context, leadID int64) ([]*models.DeliveryChainAttempt, error) {
+	query := `
+		SELECT
+			id, lead_id, chain_index, attempt_no, requested_at, response_status,
+			response_body, error_message, success, created_at
+		FROM delivery_chain_attempts
+		WHERE lead_id = $1
+		ORDER BY chain_index ASC, attempt_no ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, leadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query delivery chain attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []*models.DeliveryChainAttempt
+	for rows.Next() {
+		attempt := &models.DeliveryChainAttempt{}
+		err := rows.Scan(
+			&attempt.ID,
+			&attempt.LeadID,
+			&attempt.ChainIndex,
+			&attempt.AttemptNo,
+			&attempt.RequestedAt,
+			&attempt.ResponseStatus,
+			&attempt.ResponseBody,
+			&attempt.ErrorMessage,
+			&attempt.Success,
+			&attempt.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan delivery chain attempt: %w", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating delivery chain attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// HasSuccessfulChainAttempt reports whether the endpoint at chainIndex already accepted the lead
+func (r *deliveryChainAttemptRepository) HasSuccessfulChainAttempt(ctx context.Context, leadID int64, chainIndex int) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM delivery_chain_attempts
+			WHERE lead_id = $1 AND chain_index = $2 AND success
+		)
+	`
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, leadID, chainIndex).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check delivery chain attempts: %w", err)
+	}
+
+	return exists, nil
+}
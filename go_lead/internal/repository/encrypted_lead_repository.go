@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// PayloadEncryptor encrypts PII fields of payloads before they are stored and decrypts
+// them when read, implemented by encryption.FieldEncryptor
+type PayloadEncryptor interface {
+	EncryptPayload(payload models.JSONB) (models.JSONB, error)
+	DecryptPayload(payload models.JSONB) (models.JSONB, error)
+	EncryptField(field string, value interface{}) (interface{}, error)
+	DecryptValue(value interface{}) (interface{}, error)
+}
+
+// encryptedLeadRepository stores the raw, normalized and customer payloads of leads with
+// their PII fields encrypted, and decrypts them on every read
+type encryptedLeadRepository struct {
+	LeadRepository
+	encryptor PayloadEncryptor
+}
+
+// NewEncryptedLeadRepository wraps repo so that the PII fields of lead payloads are
+// encrypted at rest. Callers see plaintext payloads only. Queries comparing payload
+// values in the database, like FindLeadsByContact, do not match encrypted fields.
+func NewEncryptedLeadRepository(repo LeadRepository, encryptor PayloadEncryptor) LeadRepository {
+	return &encryptedLeadRepository{LeadRepository: repo, encryptor: encryptor}
+}
+
+// CreateLead stores the lead with encrypted payloads; the lead keeps its plaintext payloads
+func (r *encryptedLeadRepository) CreateLead(ctx context.Context, lead *models.InboundLead) error {
+	stored := *lead
+	var err error
+	if stored.RawPayload, err = r.encryptor.EncryptPayload(lead.RawPayload); err != nil {
+		return fmt.Errorf("failed to create lead: %w", err)
+	}
+	if stored.NormalizedPayload, err = r.encryptor.EncryptPayload(lead.NormalizedPayload); err != nil {
+		return fmt.Errorf("failed to create lead: %w", err)
+	}
+	if stored.CustomerPayload, err = r.encryptor.EncryptPayload(lead.CustomerPayload); err != nil {
+		return fmt.Errorf("failed to create lead: %w", err)
+	}
+
+	if err := r.LeadRepository.CreateLead(ctx, &stored); err != nil {
+		return err
+	}
+	stored.RawPayload, stored.NormalizedPayload, stored.CustomerPayload = lead.RawPayload, lead.NormalizedPayload, lead.CustomerPayload
+	*lead = stored
+	return nil
+}
+
+// GetLeadByID retrieves a lead with decrypted payloads
+func (r *encryptedLeadRepository) GetLeadByID(ctx context.Context, id int64) (*models.InboundLead, error) {
+	lead, err := r.LeadRepository.GetLeadByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return lead, r.decryptLead(lead)
+}
+
+// UpdateLeadWithPayloads stores the normalized and customer payloads encrypted
+func (r *encryptedLeadRepository) UpdateLeadWithPayloads(ctx context.Context, id int64, normalizedPayload, customerPayload models.JSONB) error {
+	normalized, err := r.encryptor.EncryptPayload(normalizedPayload)
+	if err != nil {
+		return fmt.Errorf("failed to update lead payloads: %w", err)
+	}
+	customer, err := r.encryptor.EncryptPayload(customerPayload)
+	if err != nil {
+		return fmt.Errorf("failed to update lead payloads: %w", err)
+	}
+	return r.LeadRepository.UpdateLeadWithPayloads(ctx, id, normalized, customer)
+}
+
+// GetRecentLeads returns the most recent leads with decrypted payloads
+func (r *encryptedLeadRepository) GetRecentLeads(ctx context.Context, limit int) ([]*models.InboundLead, error) {
+	leads, err := r.LeadRepository.GetRecentLeads(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	return leads, r.decryptLeads(leads)
+}
+
+// FindLeadsByContact returns the matching leads with decrypted payloads
+func (r *encryptedLeadRepository) FindLeadsByContact(ctx context.Context, email, phone string, limit int) ([]*models.InboundLead, error) {
+	leads, err := r.LeadRepository.FindLeadsByContact(ctx, email, phone, limit)
+	if err != nil {
+		return nil, err
+	}
+	return leads, r.decryptLeads(leads)
+}
+
+// FindLeadByPayloadHash returns the matching lead with decrypted payloads. The payload
+// hash is computed from the plaintext payload, so duplicates are found as before.
+func (r *encryptedLeadRepository) FindLeadByPayloadHash(ctx context.Context, sourceID *string, payloadHash string, since time.Time) (*models.InboundLead, error) {
+	lead, err := r.LeadRepository.FindLeadByPayloadHash(ctx, sourceID, payloadHash, since)
+	if err != nil || lead == nil {
+		return lead, err
+	}
+	return lead, r.decryptLead(lead)
+}
+
+// decryptLeads decrypts the payloads of leads in place
+func (r *encryptedLeadRepository) decryptLeads(leads []*models.InboundLead) error {
+	for _, lead := range leads {
+		if err := r.decryptLead(lead); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decryptLead decrypts the payloads of a lead in place
+func (r *encryptedLeadRepository) decryptLead(lead *models.InboundLead) error {
+	var err error
+	if lead.RawPayload, err = r.encryptor.DecryptPayload(lead.RawPayload); err != nil {
+		return fmt.Errorf("failed to decrypt payload of lead %d: %w", lead.ID, err)
+	}
+	if lead.NormalizedPayload, err = r.encryptor.DecryptPayload(lead.NormalizedPayload); err != nil {
+		return fmt.Errorf("failed to decrypt payload of lead %d: %w", lead.ID, err)
+	}
+	if lead.CustomerPayload, err = r.encryptor.DecryptPayload(lead.CustomerPayload); err != nil {
+		return fmt.Errorf("failed to decrypt payload of lead %d: %w", lead.ID, err)
+	}
+	return nil
+}
+
+// encryptedNormalizationAuditRepository stores the audited values of PII fields encrypted
+type encryptedNormalizationAuditRepository struct {
+	NormalizationAuditRepository
+	encryptor PayloadEncryptor
+}
+
+// NewEncryptedNormalizationAuditRepository wraps repo so that audited values of the
+// encrypted fields are stored encrypted like the lead payloads
+func NewEncryptedNormalizationAuditRepository(repo NormalizationAuditRepository, encryptor PayloadEncryptor) NormalizationAuditRepository {
+	return &encryptedNormalizationAuditRepository{NormalizationAuditRepository: repo, encryptor: encryptor}
+}
+
+// CreateNormalizationAudits records the audits with the values of encrypted fields encrypted
+func (r *encryptedNormalizationAuditRepository) CreateNormalizationAudits(ctx context.Context, leadID int64, audits []models.NormalizationAudit) error {
+	stored := make([]models.NormalizationAudit, len(audits))
+	for i, audit := range audits {
+		var err error
+		if audit.OriginalValue, err = r.encryptor.EncryptField(audit.Field, audit.OriginalValue); err != nil {
+			return fmt.Errorf("failed to create normalization audit: %w", err)
+		}
+		if audit.NormalizedValue, err = r.encryptor.EncryptField(audit.Field, audit.NormalizedValue); err != nil {
+			return fmt.Errorf("failed to create normalization audit: %w", err)
+		}
+		stored[i] = audit
+	}
+
+	if err := r.NormalizationAuditRepository.CreateNormalizationAudits(ctx, leadID, stored); err != nil {
+		return err
+	}
+	for i := range audits {
+		audits[i].ID, audits[i].LeadID, audits[i].Timestamp = stored[i].ID, stored[i].LeadID, stored[i].Timestamp
+	}
+	return nil
+}
+
+// GetNormalizationAudit retrieves the audits of a lead with decrypted values
+func (r *encryptedNormalizationAuditRepository) GetNormalizationAudit(ctx context.Context, leadID int64) ([]models.NormalizationAudit, error) {
+	audits, err := r.NormalizationAuditRepository.GetNormalizationAudit(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range audits {
+		if audits[i].OriginalValue, err = r.encryptor.DecryptValue(audits[i].OriginalValue); err != nil {
+			return nil, fmt.Errorf("failed to decrypt normalization audit: %w", err)
+		}
+		if audits[i].NormalizedValue, err = r.encryptor.DecryptValue(audits[i].NormalizedValue); err != nil {
+			return nil, fmt.Errorf("failed to decrypt normalization audit: %w", err)
+		}
+	}
+	return audits, nil
+}
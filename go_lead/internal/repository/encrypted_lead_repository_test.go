@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/encryption"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+func testFieldEncryptor(t *testing.T) *encryption.FieldEncryptor {
+	t.Helper()
+	encryptor, err := encryption.NewFieldEncryptor(bytes.Repeat([]byte{7}, 32), []string{"email", "phone"})
+	if err != nil {
+		t.Fatalf("Failed to create encryptor: %v", err)
+	}
+	return encryptor
+}
+
+// memoryLeadRepo keeps leads in memory as they would be stored in the database
+type memoryLeadRepo struct {
+	LeadRepository
+	leads map[int64]models.InboundLead
+}
+
+func (r *memoryLeadRepo) CreateLead(ctx context.Context, lead *models.InboundLead) error {
+	lead.ID = int64(len(r.leads) + 1)
+	r.leads[lead.ID] = *lead
+	return nil
+}
+
+func (r *memoryLeadRepo) GetLeadByID(ctx context.Context, id int64) (*models.InboundLead, error) {
+	lead, ok := r.leads[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrLeadNotFound, id)
+	}
+	return &lead, nil
+}
+
+func (r *memoryLeadRepo) UpdateLeadWithPayloads(ctx context.Context, id int64, normalizedPayload, customerPayload models.JSONB) error {
+	lead := r.leads[id]
+	lead.NormalizedPayload, lead.CustomerPayload = normalizedPayload, customerPayload
+	r.leads[id] = lead
+	return nil
+}
+
+func TestEncryptedLeadRepository_EncryptsAtRest(t *testing.T) {
+	stored := &memoryLeadRepo{leads: make(map[int64]models.InboundLead)}
+	repo := NewEncryptedLeadRepository(stored, testFieldEncryptor(t))
+	ctx := context.Background()
+
+	lead := &models.InboundLead{RawPayload: models.JSONB{"email": "max@example.com", "zipcode": "66123"}}
+	if err := repo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+	if lead.ID == 0 || lead.RawPayload["email"] != "max@example.com" {
+		t.Errorf("Expected the lead to get an ID and keep its plaintext payload, got %+v", lead)
+	}
+	if err := repo.UpdateLeadWithPayloads(ctx, lead.ID, models.JSONB{"email": "max@example.com"}, models.JSONB{"phone": "4915112345678"}); err != nil {
+		t.Fatalf("Failed to update payloads: %v", err)
+	}
+
+	raw := stored.leads[lead.ID]
+	if !encryption.IsEncrypted(raw.RawPayload["email"]) || !encryption.IsEncrypted(raw.NormalizedPayload["email"]) ||
+		!encryption.IsEncrypted(raw.CustomerPayload["phone"]) {
+		t.Errorf("Expected the PII fields to be stored encrypted, got %+v", raw)
+	}
+	if raw.RawPayload["zipcode"] != "66123" {
+		t.Errorf("Expected zipcode to be stored as plaintext, got %v", raw.RawPayload["zipcode"])
+	}
+
+	loaded, err := repo.GetLeadByID(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to get lead: %v", err)
+	}
+	if loaded.RawPayload["email"] != "max@example.com" || loaded.NormalizedPayload["email"] != "max@example.com" ||
+		loaded.CustomerPayload["phone"] != "4915112345678" {
+		t.Errorf("Expected decrypted payloads, got %+v", loaded)
+	}
+}
+
+func TestEncryptedLeadRepository_Database(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	repo := NewEncryptedLeadRepository(NewLeadRepository(db), testFieldEncryptor(t))
+	ctx := context.Background()
+
+	lead := &models.InboundLead{RawPayload: models.JSONB{"email": "max@example.com", "phone": "0151 1234567"}}
+	if err := repo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	var email string
+	if err := db.QueryRow("SELECT raw_payload->>'email' FROM inbound_lead WHERE id = $1", lead.ID).Scan(&email); err != nil {
+		t.Fatalf("Failed to read raw payload: %v", err)
+	}
+	if !encryption.IsEncrypted(email) {
+		t.Errorf("Expected ciphertext in the database, got %q", email)
+	}
+
+	loaded, err := repo.GetLeadByID(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to get lead: %v", err)
+	}
+	if loaded.RawPayload["email"] != "max@example.com" || loaded.RawPayload["phone"] != "0151 1234567" {
+		t.Errorf("Expected the decrypted payload, got %v", loaded.RawPayload)
+	}
+}
+
+// memoryAuditRepo keeps normalization audits in memory as they would be stored
+type memoryAuditRepo struct {
+	audits []models.NormalizationAudit
+}
+
+func (r *memoryAuditRepo) CreateNormalizationAudits(ctx context.Context, leadID int64, audits []models.NormalizationAudit) error {
+	for i := range audits {
+		audits[i].ID, audits[i].LeadID = int64(len(r.audits)+1), leadID
+		r.audits = append(r.audits, audits[i])
+	}
+	return nil
+}
+
+func (r *memoryAuditRepo) GetNormalizationAudit(ctx context.Context, leadID int64) ([]models.NormalizationAudit, error) {
+	return append([]models.NormalizationAudit{}, r.audits...), nil
+}
+
+func TestEncryptedNormalizationAuditRepository(t *testing.T) {
+	stored := &memoryAuditRepo{}
+	repo := NewEncryptedNormalizationAuditRepository(stored, testFieldEncryptor(t))
+	ctx := context.Background()
+
+	audits := []models.NormalizationAudit{
+		{Field: "email", OriginalValue: " Max@Example.COM", NormalizedValue: "max@example.com", TransformerApplied: "email"},
+		{Field: "city", OriginalValue: " Saarbrücken", NormalizedValue: "Saarbrücken", TransformerApplied: "whitespace"},
+	}
+	if err := repo.CreateNormalizationAudits(ctx, 1, audits); err != nil {
+		t.Fatalf("Failed to create audits: %v", err)
+	}
+	if audits[0].ID == 0 || audits[0].OriginalValue != " Max@Example.COM" {
+		t.Errorf("Expected the audit to get an ID and keep its plaintext values, got %+v", audits[0])
+	}
+	if !encryption.IsEncrypted(stored.audits[0].OriginalValue) || !encryption.IsEncrypted(stored.audits[0].NormalizedValue) {
+		t.Errorf("Expected the email values to be stored encrypted, got %+v", stored.audits[0])
+	}
+	if stored.audits[1].OriginalValue != " Saarbrücken" {
+		t.Errorf("Expected city values to be stored as plaintext, got %+v", stored.audits[1])
+	}
+
+	loaded, err := repo.GetNormalizationAudit(ctx, 1)
+	if err != nil {
+		t.Fatalf("Failed to get audits: %v", err)
+	}
+	if loaded[0].OriginalValue != " Max@Example.COM" || loaded[0].NormalizedValue != "max@example.com" {
+		t.Errorf("Expected decrypted values, got %+v", loaded[0])
+	}
+}
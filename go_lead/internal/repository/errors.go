@@ -0,0 +1,12 @@
+package repository
+
+import "errors"
+
+var (
+	// ErrLeadNotFound indicates the requested lead does not exist or is not visible
+	// to the tenant of the context
+	ErrLeadNotFound = errors.New("lead not found")
+
+	// ErrDeliveryAttemptNotFound indicates a lead has no delivery attempts
+	ErrDeliveryAttemptNotFound = errors.New("no delivery attempts found for lead")
+)
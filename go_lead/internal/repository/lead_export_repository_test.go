@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+func TestLeadExportRepository_ListLeadsAfter(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	leadRepo := NewLeadRepository(db)
+	exportRepo := NewLeadExportRepository(db)
+	ctx := context.Background()
+
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		lead := &models.InboundLead{
+			RawPayload: models.JSONB{"email": "export@example.com"},
+			Status:     models.LeadStatusReceived,
+		}
+		if i%2 == 1 {
+			lead.Status = models.LeadStatusRejected
+		}
+		if err := leadRepo.CreateLead(ctx, lead); err != nil {
+			t.Fatalf("Failed to create lead: %v", err)
+		}
+		ids = append(ids, lead.ID)
+	}
+
+	filter := LeadExportFilter{From: time.Now().Add(-time.Hour), To: time.Now().Add(time.Hour)}
+	count, err := exportRepo.CountLeads(ctx, filter)
+	if err != nil {
+		t.Fatalf("Failed to count leads: %v", err)
+	}
+	if count != 5 {
+		t.Errorf("Expected 5 leads, got %d", count)
+	}
+
+	// Paging with the last ID returns the remaining leads in ID order
+	page, err := exportRepo.ListLeadsAfter(ctx, filter, 0, 3)
+	if err != nil {
+		t.Fatalf("Failed to list leads: %v", err)
+	}
+	if len(page) != 3 || page[0].ID != ids[0] || page[2].ID != ids[2] {
+		t.Fatalf("Expected the first 3 leads, got %d", len(page))
+	}
+	page, err = exportRepo.ListLeadsAfter(ctx, filter, page[2].ID, 3)
+	if err != nil {
+		t.Fatalf("Failed to list leads: %v", err)
+	}
+	if len(page) != 2 || page[0].ID != ids[3] || page[1].ID != ids[4] {
+		t.Errorf("Expected the last 2 leads, got %d", len(page))
+	}
+
+	filter.Status = models.LeadStatusRejected
+	page, err = exportRepo.ListLeadsAfter(ctx, filter, 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to list leads: %v", err)
+	}
+	if len(page) != 2 {
+		t.Errorf("Expected 2 rejected leads, got %d", len(page))
+	}
+
+	// Leads received before the window are excluded
+	filter = LeadExportFilter{To: time.Now().Add(-time.Hour)}
+	count, err = exportRepo.CountLeads(ctx, filter)
+	if err != nil {
+		t.Fatalf("Failed to count leads: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no leads before the window, got %d", count)
+	}
+}
@@ -3,10 +3,13 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/checkfox/go_lead/internal/models"
+	"github.com/lib/pq"
 )
 
 // LeadRepository defines the interface for lead data persistence operations
@@ -15,15 +18,25 @@ type LeadRepository interface {
 	CreateLead(ctx context.Context, lead *models.InboundLead) error
 	
 	// GetLeadByID retrieves a lead by its ID
+	// Returns an error wrapping ErrLeadNotFound if the lead does not exist
 	GetLeadByID(ctx context.Context, id int64) (*models.InboundLead, error)
 	
 	// UpdateLeadStatus updates the status of a lead atomically
 	UpdateLeadStatus(ctx context.Context, id int64, status models.LeadStatus) error
 	
+	// UpdateLeadStatusFrom updates the status of a lead only if it still has status from.
+	// Returns an error wrapping models.ErrInvalidStatusTransition if the lead has another status.
+	UpdateLeadStatusFrom(ctx context.Context, id int64, from, to models.LeadStatus) error
+	
 	// UpdateLeadWithPayloads updates the lead with normalized and customer payloads
 	UpdateLeadWithPayloads(ctx context.Context, id int64, normalizedPayload, customerPayload models.JSONB) error
 	
-	// UpdateLeadRejection marks a lead as rejected with a reason
+	// UpdateLeadAttachments stores the attachment metadata of a lead and removes the given
+	// attachment keys from its raw payload
+	UpdateLeadAttachments(ctx context.Context, id int64, metadata models.JSONB, attachmentKeys []string) error
+	
+	// UpdateLeadRejection marks a lead as rejected with a reason.
+	// Returns an error wrapping models.ErrInvalidStatusTransition if the lead may not be rejected from its status.
 	UpdateLeadRejection(ctx context.Context, id int64, reason models.RejectionReason) error
 	
 	// BeginTx starts a new database transaction
@@ -32,23 +45,75 @@ type LeadRepository interface {
 	// UpdateLeadStatusTx updates the status of a lead within a transaction
 	UpdateLeadStatusTx(ctx context.Context, tx *sql.Tx, id int64, status models.LeadStatus) error
 	
+	// UpdateLeadStatusFromTx is UpdateLeadStatusFrom within a transaction
+	UpdateLeadStatusFromTx(ctx context.Context, tx *sql.Tx, id int64, from, to models.LeadStatus) error
+	
 	// GetLeadCountsByStatus returns counts of leads grouped by status
 	GetLeadCountsByStatus(ctx context.Context) (map[string]int, error)
 	
+	// GetLeadCountsByStatusForSource returns counts of leads from a single source grouped by status
+	GetLeadCountsByStatusForSource(ctx context.Context, sourceID string) (map[string]int, error)
+	
+	// GetLeadCountsByStatusPerSource returns counts of leads grouped by source and status.
+	// Leads without a source are counted under the empty source ID.
+	GetLeadCountsByStatusPerSource(ctx context.Context) (map[string]map[string]int, error)
+	
+	// CountLeadsForSourceSince returns how many leads from a source were received at or after since
+	CountLeadsForSourceSince(ctx context.Context, sourceID string, since time.Time) (int, error)
+	
 	// GetRecentLeads returns the most recent leads ordered by received_at
 	GetRecentLeads(ctx context.Context, limit int) ([]*models.InboundLead, error)
+	
+	// FindLeadsByContact returns leads whose normalized payload matches the given email or phone
+	FindLeadsByContact(ctx context.Context, email, phone string, limit int) ([]*models.InboundLead, error)
+	
+	// FindLeadByPayloadHash returns the most recent lead of the source with the given payload
+	// hash created after since, or nil if there is none. A nil sourceID matches leads without source.
+	FindLeadByPayloadHash(ctx context.Context, sourceID *string, payloadHash string, since time.Time) (*models.InboundLead, error)
+	
+	// FindInSuppressionList returns the suppression list entry matching the normalized phone
+	// or email, or nil if neither is listed
+	FindInSuppressionList(ctx context.Context, phone, email string) (*models.SuppressionEntry, error)
 }
 
 // leadRepository is the concrete implementation of LeadRepository
 type leadRepository struct {
-	db *sql.DB
+	db           *sql.DB
+	retryPolicy  WriteRetryPolicy
+	tenantScoped bool
 }
 
 // NewLeadRepository creates a new LeadRepository instance
 func NewLeadRepository(db *sql.DB) LeadRepository {
+	return NewLeadRepositoryWithRetry(db, DefaultWriteRetryPolicy())
+}
+
+// NewLeadRepositoryWithRetry creates a new LeadRepository that retries idempotent
+// writes on transient database errors according to the given policy
+func NewLeadRepositoryWithRetry(db *sql.DB, policy WriteRetryPolicy) LeadRepository {
 	return &leadRepository{
-		db: db,
+		db:          db,
+		retryPolicy: policy,
+	}
+}
+
+// NewTenantScopedLeadRepository creates a LeadRepository for multi-tenant deployments.
+// Every query is restricted to the tenant ID carried in the context (see WithTenantID);
+// operations fail with ErrMissingTenantID when the context has none.
+func NewTenantScopedLeadRepository(db *sql.DB, policy WriteRetryPolicy) LeadRepository {
+	return &leadRepository{
+		db:           db,
+		retryPolicy:  policy,
+		tenantScoped: true,
+	}
+}
+
+// scope restricts a query to the context tenant when the repository is tenant-scoped
+func (r *leadRepository) scope(ctx context.Context, query string, args ...interface{}) (string, []interface{}, error) {
+	if !r.tenantScoped {
+		return query, args, nil
 	}
+	return scopeQueryToTenant(ctx, query, args)
 }
 
 // CreateLead creates a new inbound lead record
@@ -57,8 +122,9 @@ func (r *leadRepository) CreateLead(ctx context.Context, lead *models.InboundLea
 		INSERT INTO inbound_lead (
 			received_at, raw_payload, source_headers, status, 
 			rejection_reason, normalized_payload, customer_payload, 
-			payload_hash, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			payload_hash, created_at, updated_at, source_id, tenant_id,
+			attachments_metadata
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		RETURNING id
 	`
 	
@@ -75,6 +141,13 @@ func (r *leadRepository) CreateLead(ctx context.Context, lead *models.InboundLea
 	if lead.Status == "" {
 		lead.Status = models.LeadStatusReceived
 	}
+	if r.tenantScoped {
+		tenantID, ok := GetTenantID(ctx)
+		if !ok {
+			return fmt.Errorf("failed to create lead: %w", ErrMissingTenantID)
+		}
+		lead.TenantID = &tenantID
+	}
 	
 	err := r.db.QueryRowContext(
 		ctx,
@@ -89,6 +162,9 @@ func (r *leadRepository) CreateLead(ctx context.Context, lead *models.InboundLea
 		lead.PayloadHash,
 		lead.CreatedAt,
 		lead.UpdatedAt,
+		lead.SourceID,
+		lead.TenantID,
+		lead.AttachmentsMetadata,
 	).Scan(&lead.ID)
 	
 	if err != nil {
@@ -104,13 +180,19 @@ func (r *leadRepository) GetLeadByID(ctx context.Context, id int64) (*models.Inb
 		SELECT 
 			id, received_at, raw_payload, source_headers, status,
 			rejection_reason, normalized_payload, customer_payload,
-			payload_hash, created_at, updated_at
+			payload_hash, created_at, updated_at, source_id, tenant_id,
+			attachments_metadata
 		FROM inbound_lead
 		WHERE id = $1
 	`
 	
+	query, args, err := r.scope(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lead: %w", err)
+	}
+	
 	lead := &models.InboundLead{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(
 		&lead.ID,
 		&lead.ReceivedAt,
 		&lead.RawPayload,
@@ -122,10 +204,13 @@ func (r *leadRepository) GetLeadByID(ctx context.Context, id int64) (*models.Inb
 		&lead.PayloadHash,
 		&lead.CreatedAt,
 		&lead.UpdatedAt,
+		&lead.SourceID,
+		&lead.TenantID,
+		&lead.AttachmentsMetadata,
 	)
 	
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("lead not found: %d", id)
+		return nil, fmt.Errorf("%w: %d", ErrLeadNotFound, id)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get lead: %w", err)
@@ -135,14 +220,28 @@ func (r *leadRepository) GetLeadByID(ctx context.Context, id int64) (*models.Inb
 }
 
 // UpdateLeadStatus updates the status of a lead atomically
+// Transient database errors are retried since the update is idempotent
 func (r *leadRepository) UpdateLeadStatus(ctx context.Context, id int64, status models.LeadStatus) error {
+	// The status machine check is part of the WHERE clause, so a concurrent status
+	// change cannot slip in between checking and updating
 	query := `
 		UPDATE inbound_lead
 		SET status = $1, updated_at = $2
-		WHERE id = $3
+		WHERE id = $3 AND status = ANY($4)
 	`
 	
-	result, err := r.db.ExecContext(ctx, query, status, time.Now(), id)
+	sources := pq.Array(models.CurrentStatusMachine().SourcesOf(status))
+	query, args, err := r.scope(ctx, query, status, time.Now(), id, sources)
+	if err != nil {
+		return fmt.Errorf("failed to update lead status: %w", err)
+	}
+	
+	var result sql.Result
+	err = withWriteRetry(ctx, r.retryPolicy, func() error {
+		var execErr error
+		result, execErr = r.db.ExecContext(ctx, query, args...)
+		return execErr
+	})
 	if err != nil {
 		return fmt.Errorf("failed to update lead status: %w", err)
 	}
@@ -153,7 +252,94 @@ func (r *leadRepository) UpdateLeadStatus(ctx context.Context, id int64, status
 	}
 	
 	if rowsAffected == 0 {
-		return fmt.Errorf("lead not found: %d", id)
+		return r.statusUpdateError(ctx, r.db.QueryRowContext, id, status)
+	}
+	
+	return nil
+}
+
+// UpdateLeadStatusFrom updates the status of a lead only if it still has status from, so a
+// status change made concurrently, e.g. by a confirmation callback, is never overwritten
+func (r *leadRepository) UpdateLeadStatusFrom(ctx context.Context, id int64, from, to models.LeadStatus) error {
+	if err := models.CurrentStatusMachine().ValidateTransition(from, to); err != nil {
+		return fmt.Errorf("lead %d: %w", id, err)
+	}
+	
+	query := `
+		UPDATE inbound_lead
+		SET status = $1, updated_at = $2
+		WHERE id = $3 AND status = $4
+	`
+	
+	query, args, err := r.scope(ctx, query, to, time.Now(), id, from)
+	if err != nil {
+		return fmt.Errorf("failed to update lead status: %w", err)
+	}
+	
+	var result sql.Result
+	err = withWriteRetry(ctx, r.retryPolicy, func() error {
+		var execErr error
+		result, execErr = r.db.ExecContext(ctx, query, args...)
+		return execErr
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update lead status: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	if rowsAffected == 0 {
+		current, err := r.currentStatus(ctx, r.db.QueryRowContext, id)
+		if err != nil {
+			return err
+		}
+		// A retried update that already went through is not an error
+		if current == to {
+			return nil
+		}
+		return fmt.Errorf("lead %d has status %s instead of %s: %w", id, current, from, models.ErrInvalidStatusTransition)
+	}
+	
+	return nil
+}
+
+// UpdateLeadStatusFromTx updates the status of a lead within a transaction only if it still
+// has status from. Returns an error wrapping models.ErrInvalidStatusTransition otherwise.
+func (r *leadRepository) UpdateLeadStatusFromTx(ctx context.Context, tx *sql.Tx, id int64, from, to models.LeadStatus) error {
+	if err := models.CurrentStatusMachine().ValidateTransition(from, to); err != nil {
+		return fmt.Errorf("lead %d: %w", id, err)
+	}
+	
+	query := `
+		UPDATE inbound_lead
+		SET status = $1, updated_at = $2
+		WHERE id = $3 AND status = $4
+	`
+	
+	query, args, err := r.scope(ctx, query, to, time.Now(), id, from)
+	if err != nil {
+		return fmt.Errorf("failed to update lead status in transaction: %w", err)
+	}
+	
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update lead status in transaction: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	if rowsAffected == 0 {
+		current, err := r.currentStatus(ctx, tx.QueryRowContext, id)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("lead %d has status %s instead of %s: %w", id, current, from, models.ErrInvalidStatusTransition)
 	}
 	
 	return nil
@@ -167,7 +353,17 @@ func (r *leadRepository) UpdateLeadWithPayloads(ctx context.Context, id int64, n
 		WHERE id = $4
 	`
 	
-	result, err := r.db.ExecContext(ctx, query, normalizedPayload, customerPayload, time.Now(), id)
+	query, args, err := r.scope(ctx, query, normalizedPayload, customerPayload, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update lead payloads: %w", err)
+	}
+	
+	var result sql.Result
+	err = withWriteRetry(ctx, r.retryPolicy, func() error {
+		var execErr error
+		result, execErr = r.db.ExecContext(ctx, query, args...)
+		return execErr
+	})
 	if err != nil {
 		return fmt.Errorf("failed to update lead payloads: %w", err)
 	}
@@ -178,22 +374,70 @@ func (r *leadRepository) UpdateLeadWithPayloads(ctx context.Context, id int64, n
 	}
 	
 	if rowsAffected == 0 {
-		return fmt.Errorf("lead not found: %d", id)
+		return fmt.Errorf("%w: %d", ErrLeadNotFound, id)
 	}
 	
 	return nil
 }
 
-// UpdateLeadRejection marks a lead as rejected with a reason
+// UpdateLeadAttachments stores the attachment metadata of a lead and removes the given
+// attachment keys from its raw payload, so the attachment bytes are not kept
+func (r *leadRepository) UpdateLeadAttachments(ctx context.Context, id int64, metadata models.JSONB, attachmentKeys []string) error {
+	query := `
+		UPDATE inbound_lead
+		SET attachments_metadata = $1, raw_payload = raw_payload - $2::text[], updated_at = $3
+		WHERE id = $4
+	`
+	
+	query, args, err := r.scope(ctx, query, metadata, pq.Array(attachmentKeys), time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update lead attachments: %w", err)
+	}
+	
+	var result sql.Result
+	err = withWriteRetry(ctx, r.retryPolicy, func() error {
+		var execErr error
+		result, execErr = r.db.ExecContext(ctx, query, args...)
+		return execErr
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update lead attachments: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %d", ErrLeadNotFound, id)
+	}
+	
+	return nil
+}
+
+// UpdateLeadRejection marks a lead as rejected with a reason, unless the status machine
+// does not allow its current status, e.g. DELIVERED, to change to REJECTED
 func (r *leadRepository) UpdateLeadRejection(ctx context.Context, id int64, reason models.RejectionReason) error {
 	query := `
 		UPDATE inbound_lead
 		SET status = $1, rejection_reason = $2, updated_at = $3
-		WHERE id = $4
+		WHERE id = $4 AND status = ANY($5)
 	`
 	
 	reasonStr := reason.String()
-	result, err := r.db.ExecContext(ctx, query, models.LeadStatusRejected, reasonStr, time.Now(), id)
+	sources := pq.Array(models.CurrentStatusMachine().SourcesOf(models.LeadStatusRejected))
+	query, args, err := r.scope(ctx, query, models.LeadStatusRejected, reasonStr, time.Now(), id, sources)
+	if err != nil {
+		return fmt.Errorf("failed to update lead rejection: %w", err)
+	}
+	
+	var result sql.Result
+	err = withWriteRetry(ctx, r.retryPolicy, func() error {
+		var execErr error
+		result, execErr = r.db.ExecContext(ctx, query, args...)
+		return execErr
+	})
 	if err != nil {
 		return fmt.Errorf("failed to update lead rejection: %w", err)
 	}
@@ -204,7 +448,7 @@ func (r *leadRepository) UpdateLeadRejection(ctx context.Context, id int64, reas
 	}
 	
 	if rowsAffected == 0 {
-		return fmt.Errorf("lead not found: %d", id)
+		return r.statusUpdateError(ctx, r.db.QueryRowContext, id, models.LeadStatusRejected)
 	}
 	
 	return nil
@@ -224,10 +468,16 @@ func (r *leadRepository) UpdateLeadStatusTx(ctx context.Context, tx *sql.Tx, id
 	query := `
 		UPDATE inbound_lead
 		SET status = $1, updated_at = $2
-		WHERE id = $3
+		WHERE id = $3 AND status = ANY($4)
 	`
 	
-	result, err := tx.ExecContext(ctx, query, status, time.Now(), id)
+	sources := pq.Array(models.CurrentStatusMachine().SourcesOf(status))
+	query, args, err := r.scope(ctx, query, status, time.Now(), id, sources)
+	if err != nil {
+		return fmt.Errorf("failed to update lead status in transaction: %w", err)
+	}
+	
+	result, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to update lead status in transaction: %w", err)
 	}
@@ -238,12 +488,51 @@ func (r *leadRepository) UpdateLeadStatusTx(ctx context.Context, tx *sql.Tx, id
 	}
 	
 	if rowsAffected == 0 {
-		return fmt.Errorf("lead not found: %d", id)
+		return r.statusUpdateError(ctx, tx.QueryRowContext, id, status)
 	}
 	
 	return nil
 }
 
+// statusUpdateError explains why a status update matched no row: either the lead does not
+// exist or the status machine does not allow the change from its current status
+func (r *leadRepository) statusUpdateError(ctx context.Context, queryRow func(ctx context.Context, query string, args ...interface{}) *sql.Row, id int64, status models.LeadStatus) error {
+	current, err := r.currentStatus(ctx, queryRow, id)
+	if err != nil {
+		return err
+	}
+	
+	if err := models.CurrentStatusMachine().ValidateTransition(current, status); err != nil {
+		return fmt.Errorf("lead %d: %w", id, err)
+	}
+	// The lead changed to a valid source status after the update ran
+	return fmt.Errorf("lead %d changed status concurrently to %s", id, current)
+}
+
+// currentStatus loads the status of a lead
+// Returns an error wrapping ErrLeadNotFound if the lead does not exist
+func (r *leadRepository) currentStatus(ctx context.Context, queryRow func(ctx context.Context, query string, args ...interface{}) *sql.Row, id int64) (models.LeadStatus, error) {
+	query := `
+		SELECT status
+		FROM inbound_lead
+		WHERE id = $1
+	`
+	
+	query, args, err := r.scope(ctx, query, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to load lead status: %w", err)
+	}
+	
+	var current models.LeadStatus
+	if err := queryRow(ctx, query, args...).Scan(&current); err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("%w: %d", ErrLeadNotFound, id)
+		}
+		return "", fmt.Errorf("failed to load lead status: %w", err)
+	}
+	return current, nil
+}
+
 // GetLeadCountsByStatus returns counts of leads grouped by status
 // Requirements: 8.3
 func (r *leadRepository) GetLeadCountsByStatus(ctx context.Context) (map[string]int, error) {
@@ -253,7 +542,12 @@ func (r *leadRepository) GetLeadCountsByStatus(ctx context.Context) (map[string]
 		GROUP BY status
 	`
 	
-	rows, err := r.db.QueryContext(ctx, query)
+	query, args, err := r.scope(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lead counts: %w", err)
+	}
+	
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query lead counts: %w", err)
 	}
@@ -276,6 +570,173 @@ func (r *leadRepository) GetLeadCountsByStatus(ctx context.Context) (map[string]
 	return counts, nil
 }
 
+// GetLeadCountsByStatusForSource returns counts of leads from a single source grouped by status
+func (r *leadRepository) GetLeadCountsByStatusForSource(ctx context.Context, sourceID string) (map[string]int, error) {
+	query := `
+		SELECT status, COUNT(*) as count
+		FROM inbound_lead
+		WHERE source_id = $1
+		GROUP BY status
+	`
+	
+	query, args, err := r.scope(ctx, query, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lead counts for source: %w", err)
+	}
+	
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lead counts for source: %w", err)
+	}
+	defer rows.Close()
+	
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		counts[status] = count
+	}
+	
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	
+	return counts, nil
+}
+
+// CountLeadsForSourceSince returns how many leads from a source were received at or after since
+func (r *leadRepository) CountLeadsForSourceSince(ctx context.Context, sourceID string, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM inbound_lead
+		WHERE source_id = $1 AND received_at >= $2
+	`
+	
+	// received_at is stored as local wall-clock time without a zone
+	query, args, err := r.scope(ctx, query, sourceID, since.Local())
+	if err != nil {
+		return 0, fmt.Errorf("failed to count leads for source: %w", err)
+	}
+	
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count leads for source: %w", err)
+	}
+	return count, nil
+}
+
+// GetLeadCountsByStatusPerSource returns counts of leads grouped by source and status.
+// Leads without a source are counted under the empty source ID.
+func (r *leadRepository) GetLeadCountsByStatusPerSource(ctx context.Context) (map[string]map[string]int, error) {
+	query := `
+		SELECT COALESCE(source_id, '') as source_id, status, COUNT(*) as count
+		FROM inbound_lead
+		GROUP BY source_id, status
+	`
+	
+	query, args, err := r.scope(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lead counts per source: %w", err)
+	}
+	
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lead counts per source: %w", err)
+	}
+	defer rows.Close()
+	
+	counts := make(map[string]map[string]int)
+	for rows.Next() {
+		var sourceID, status string
+		var count int
+		if err := rows.Scan(&sourceID, &status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if counts[sourceID] == nil {
+			counts[sourceID] = make(map[string]int)
+		}
+		counts[sourceID][status] = count
+	}
+	
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	
+	return counts, nil
+}
+
+// FindLeadByPayloadHash returns the most recent lead of the source with the given payload
+// hash created after since, or nil if there is none. A nil sourceID matches leads without source.
+func (r *leadRepository) FindLeadByPayloadHash(ctx context.Context, sourceID *string, payloadHash string, since time.Time) (*models.InboundLead, error) {
+	query := `
+		SELECT 
+			id, received_at, status, payload_hash, created_at, updated_at, source_id
+		FROM inbound_lead
+		WHERE payload_hash = $1 AND created_at > $2 AND source_id IS NOT DISTINCT FROM $3
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	
+	// created_at is stored as local wall-clock time without a zone
+	query, args, err := r.scope(ctx, query, payloadHash, since.Local(), sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find lead by payload hash: %w", err)
+	}
+	
+	lead := &models.InboundLead{}
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(
+		&lead.ID,
+		&lead.ReceivedAt,
+		&lead.Status,
+		&lead.PayloadHash,
+		&lead.CreatedAt,
+		&lead.UpdatedAt,
+		&lead.SourceID,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find lead by payload hash: %w", err)
+	}
+	return lead, nil
+}
+
+// FindInSuppressionList returns the suppression list entry matching the normalized phone
+// or email, or nil if neither is listed. The suppression list applies to all tenants.
+func (r *leadRepository) FindInSuppressionList(ctx context.Context, phone, email string) (*models.SuppressionEntry, error) {
+	if phone == "" && email == "" {
+		return nil, nil
+	}
+	
+	query := `
+		SELECT id, phone, email, reason, created_at
+		FROM suppression_list
+		WHERE ($1 <> '' AND phone = $1) OR ($2 <> '' AND email = $2)
+		ORDER BY id
+		LIMIT 1
+	`
+	
+	entry := &models.SuppressionEntry{}
+	err := r.db.QueryRowContext(ctx, query, phone, email).Scan(
+		&entry.ID,
+		&entry.Phone,
+		&entry.Email,
+		&entry.Reason,
+		&entry.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search suppression list: %w", err)
+	}
+	return entry, nil
+}
+
 // GetRecentLeads returns the most recent leads ordered by received_at
 // Requirements: 8.4
 func (r *leadRepository) GetRecentLeads(ctx context.Context, limit int) ([]*models.InboundLead, error) {
@@ -289,7 +750,12 @@ func (r *leadRepository) GetRecentLeads(ctx context.Context, limit int) ([]*mode
 		LIMIT $1
 	`
 	
-	rows, err := r.db.QueryContext(ctx, query, limit)
+	query, args, err := r.scope(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recent leads: %w", err)
+	}
+	
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query recent leads: %w", err)
 	}
@@ -338,3 +804,74 @@ func (r *leadRepository) GetRecentLeads(ctx context.Context, limit int) ([]*mode
 	
 	return leads, nil
 }
+
+// FindLeadsByContact returns leads whose normalized payload matches the given email or phone
+// Empty arguments are ignored; at least one of email or phone must be set.
+// Uses JSONB containment so the lookup is served by the normalized_payload GIN index.
+func (r *leadRepository) FindLeadsByContact(ctx context.Context, email, phone string, limit int) ([]*models.InboundLead, error) {
+	var conditions []string
+	var args []interface{}
+	
+	contacts := []struct{ field, value string }{
+		{"email", email},
+		{"phone", phone},
+	}
+	for _, contact := range contacts {
+		if contact.value == "" {
+			continue
+		}
+		filter, err := json.Marshal(map[string]string{contact.field: contact.value})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build %s filter: %w", contact.field, err)
+		}
+		args = append(args, string(filter))
+		conditions = append(conditions, fmt.Sprintf("normalized_payload @> $%d::jsonb", len(args)))
+	}
+	
+	if len(conditions) == 0 {
+		return nil, fmt.Errorf("email or phone is required")
+	}
+	
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT 
+			id, received_at, status, rejection_reason, created_at, updated_at
+		FROM inbound_lead
+		WHERE %s
+		ORDER BY received_at DESC
+		LIMIT $%d
+	`, strings.Join(conditions, " OR "), len(args))
+	
+	query, args, err := r.scope(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search leads by contact: %w", err)
+	}
+	
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search leads by contact: %w", err)
+	}
+	defer rows.Close()
+	
+	leads := make([]*models.InboundLead, 0)
+	for rows.Next() {
+		lead := &models.InboundLead{}
+		if err := rows.Scan(
+			&lead.ID,
+			&lead.ReceivedAt,
+			&lead.Status,
+			&lead.RejectionReason,
+			&lead.CreatedAt,
+			&lead.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan lead: %w", err)
+		}
+		leads = append(leads, lead)
+	}
+	
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	
+	return leads, nil
+}
@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/checkfox/go_lead/internal/models"
 	_ "github.com/lib/pq"
@@ -121,8 +123,21 @@ func TestLeadRepository_GetLeadByID(t *testing.T) {
 
 	// Test non-existent lead
 	_, err = repo.GetLeadByID(ctx, 999999)
+	if !errors.Is(err, ErrLeadNotFound) {
+		t.Errorf("Expected ErrLeadNotFound when getting non-existent lead, got %v", err)
+	}
+}
+
+func TestLeadRepository_GetLeadByID_DatabaseErrorIsNotNotFound(t *testing.T) {
+	db, _ := openFlakyDB(t, 1, errors.New("read tcp: connection reset by peer"))
+	repo := NewLeadRepository(db)
+
+	_, err := repo.GetLeadByID(context.Background(), 1)
 	if err == nil {
-		t.Error("Expected error when getting non-existent lead")
+		t.Fatal("Expected error from failing database")
+	}
+	if errors.Is(err, ErrLeadNotFound) {
+		t.Errorf("Expected a database error not to be ErrLeadNotFound, got %v", err)
 	}
 }
 
@@ -165,6 +180,49 @@ func TestLeadRepository_UpdateLeadStatus(t *testing.T) {
 	}
 }
 
+func TestLeadRepository_UpdateLeadStatusFrom(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	repo := NewLeadRepository(db)
+	ctx := context.Background()
+
+	lead := &models.InboundLead{
+		RawPayload: models.JSONB{"email": "test@example.com"},
+		Status:     models.LeadStatusPendingConfirmation,
+	}
+	if err := repo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	// The confirmation callback settles the lead first
+	if err := repo.UpdateLeadStatusFrom(ctx, lead.ID, models.LeadStatusPendingConfirmation, models.LeadStatusDelivered); err != nil {
+		t.Fatalf("Failed to update lead status: %v", err)
+	}
+
+	// The confirmation timeout must not overwrite it
+	err := repo.UpdateLeadStatusFrom(ctx, lead.ID, models.LeadStatusPendingConfirmation, models.LeadStatusFailed)
+	if !errors.Is(err, models.ErrInvalidStatusTransition) {
+		t.Errorf("Expected ErrInvalidStatusTransition, got %v", err)
+	}
+
+	retrieved, err := repo.GetLeadByID(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to get lead: %v", err)
+	}
+	if retrieved.Status != models.LeadStatusDelivered {
+		t.Errorf("Expected status DELIVERED, got %s", retrieved.Status)
+	}
+
+	if err := repo.UpdateLeadStatusFrom(ctx, lead.ID+1000, models.LeadStatusPendingConfirmation, models.LeadStatusFailed); !errors.Is(err, ErrLeadNotFound) {
+		t.Errorf("Expected ErrLeadNotFound for a missing lead, got %v", err)
+	}
+}
+
 func TestLeadRepository_UpdateLeadRejection(t *testing.T) {
 	db := setupTestDB(t)
 	if db == nil {
@@ -210,6 +268,44 @@ func TestLeadRepository_UpdateLeadRejection(t *testing.T) {
 	}
 }
 
+func TestLeadRepository_UpdateLeadRejectionRespectsStatusMachine(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	repo := NewLeadRepository(db)
+	ctx := context.Background()
+
+	lead := &models.InboundLead{
+		RawPayload: models.JSONB{"email": "test@example.com"},
+		Status:     models.LeadStatusDelivered,
+	}
+	if err := repo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	// A delivered lead must not be rejected afterwards
+	err := repo.UpdateLeadRejection(ctx, lead.ID, models.RejectionReasonZipNotValid)
+	if !errors.Is(err, models.ErrInvalidStatusTransition) {
+		t.Errorf("Expected ErrInvalidStatusTransition, got %v", err)
+	}
+
+	retrieved, err := repo.GetLeadByID(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to get lead: %v", err)
+	}
+	if retrieved.Status != models.LeadStatusDelivered || retrieved.RejectionReason != nil {
+		t.Errorf("Expected the lead to stay DELIVERED without a reason, got %s", retrieved.Status)
+	}
+
+	if err := repo.UpdateLeadRejection(ctx, lead.ID+1000, models.RejectionReasonZipNotValid); !errors.Is(err, ErrLeadNotFound) {
+		t.Errorf("Expected ErrLeadNotFound for a missing lead, got %v", err)
+	}
+}
+
 func TestLeadRepository_Transaction(t *testing.T) {
 	db := setupTestDB(t)
 	if db == nil {
@@ -366,3 +462,305 @@ func TestLeadRepository_UpdateLeadWithPayloads(t *testing.T) {
 		t.Error("Expected customer payload to be set")
 	}
 }
+
+func TestLeadRepository_FindLeadsByContact(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	repo := NewLeadRepository(db)
+	ctx := context.Background()
+
+	contacts := []models.JSONB{
+		{"email": "anna@example.com", "phone": "491701111111"},
+		{"email": "ben@example.com", "phone": "491702222222"},
+		{"email": "anna@example.com", "phone": "491703333333"},
+		{"phone": "491702222222"},
+	}
+
+	ids := make([]int64, len(contacts))
+	for i, normalized := range contacts {
+		lead := &models.InboundLead{
+			RawPayload: models.JSONB{"source": "test"},
+			Status:     models.LeadStatusReceived,
+		}
+		if err := repo.CreateLead(ctx, lead); err != nil {
+			t.Fatalf("Failed to create lead: %v", err)
+		}
+		if err := repo.UpdateLeadWithPayloads(ctx, lead.ID, normalized, models.JSONB{}); err != nil {
+			t.Fatalf("Failed to update lead payloads: %v", err)
+		}
+		ids[i] = lead.ID
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		phone    string
+		expected []int64
+	}{
+		{"by email", "anna@example.com", "", []int64{ids[0], ids[2]}},
+		{"by phone", "", "491702222222", []int64{ids[1], ids[3]}},
+		{"email or phone", "ben@example.com", "491701111111", []int64{ids[0], ids[1]}},
+		{"no match", "nobody@example.com", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leads, err := repo.FindLeadsByContact(ctx, tt.email, tt.phone, 10)
+			if err != nil {
+				t.Fatalf("Failed to search leads: %v", err)
+			}
+
+			found := make(map[int64]bool)
+			for _, lead := range leads {
+				found[lead.ID] = true
+			}
+
+			if len(found) != len(tt.expected) {
+				t.Errorf("Expected %d leads, got %d", len(tt.expected), len(found))
+			}
+			for _, id := range tt.expected {
+				if !found[id] {
+					t.Errorf("Expected lead %d in search results", id)
+				}
+			}
+		})
+	}
+
+	// At least one search term is required
+	if _, err := repo.FindLeadsByContact(ctx, "", "", 10); err == nil {
+		t.Error("Expected error when searching without email or phone")
+	}
+
+	// Results are capped by the limit
+	leads, err := repo.FindLeadsByContact(ctx, "anna@example.com", "", 1)
+	if err != nil {
+		t.Fatalf("Failed to search leads: %v", err)
+	}
+	if len(leads) != 1 {
+		t.Errorf("Expected 1 lead with limit 1, got %d", len(leads))
+	}
+}
+
+func TestLeadRepository_FindLeadByPayloadHash(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	repo := NewLeadRepository(db)
+	ctx := context.Background()
+
+	payload := models.JSONB{"phone": "491701111111"}
+	hash, err := payload.Hash()
+	if err != nil {
+		t.Fatalf("Failed to hash payload: %v", err)
+	}
+
+	old := &models.InboundLead{
+		RawPayload:  payload,
+		Status:      models.LeadStatusReceived,
+		PayloadHash: &hash,
+		CreatedAt:   time.Now().Add(-48 * time.Hour),
+	}
+	if err := repo.CreateLead(ctx, old); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	// Only the lead created before the grace period exists, so a new lead may be created
+	gracePeriod := 24 * time.Hour
+	found, err := repo.FindLeadByPayloadHash(ctx, nil, hash, time.Now().Add(-gracePeriod))
+	if err != nil {
+		t.Fatalf("Failed to find lead by payload hash: %v", err)
+	}
+	if found != nil {
+		t.Fatalf("Expected no lead within the grace period, got lead %d", found.ID)
+	}
+
+	recent := &models.InboundLead{
+		RawPayload:  payload,
+		Status:      models.LeadStatusReceived,
+		PayloadHash: &hash,
+	}
+	if err := repo.CreateLead(ctx, recent); err != nil {
+		t.Fatalf("Failed to create lead with a repeated payload hash: %v", err)
+	}
+	if recent.ID == old.ID {
+		t.Fatal("Expected a new record for the repeated payload")
+	}
+
+	// Within the grace period the existing lead is returned
+	found, err = repo.FindLeadByPayloadHash(ctx, nil, hash, time.Now().Add(-gracePeriod))
+	if err != nil {
+		t.Fatalf("Failed to find lead by payload hash: %v", err)
+	}
+	if found == nil || found.ID != recent.ID {
+		t.Errorf("Expected lead %d within the grace period, got %v", recent.ID, found)
+	}
+
+	// Other payloads have no match
+	found, err = repo.FindLeadByPayloadHash(ctx, nil, "unknown", time.Now().Add(-gracePeriod))
+	if err != nil {
+		t.Fatalf("Failed to find lead by payload hash: %v", err)
+	}
+	if found != nil {
+		t.Errorf("Expected no lead for an unknown hash, got lead %d", found.ID)
+	}
+
+	// The same payload from a source only matches leads of that source
+	sourceID := "partner_a"
+	found, err = repo.FindLeadByPayloadHash(ctx, &sourceID, hash, time.Now().Add(-gracePeriod))
+	if err != nil {
+		t.Fatalf("Failed to find lead by payload hash: %v", err)
+	}
+	if found != nil {
+		t.Errorf("Expected no lead of another source, got lead %d", found.ID)
+	}
+	fromSource := &models.InboundLead{
+		RawPayload:  payload,
+		Status:      models.LeadStatusReceived,
+		PayloadHash: &hash,
+		SourceID:    &sourceID,
+	}
+	if err := repo.CreateLead(ctx, fromSource); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+	found, err = repo.FindLeadByPayloadHash(ctx, &sourceID, hash, time.Now().Add(-gracePeriod))
+	if err != nil {
+		t.Fatalf("Failed to find lead by payload hash: %v", err)
+	}
+	if found == nil || found.ID != fromSource.ID {
+		t.Errorf("Expected lead %d of the source, got %v", fromSource.ID, found)
+	}
+	found, err = repo.FindLeadByPayloadHash(ctx, nil, hash, time.Now().Add(-gracePeriod))
+	if err != nil {
+		t.Fatalf("Failed to find lead by payload hash: %v", err)
+	}
+	if found == nil || found.ID != recent.ID {
+		t.Errorf("Expected lead %d without source, got %v", recent.ID, found)
+	}
+}
+
+func TestLeadRepository_FindInSuppressionList(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer db.Exec("DELETE FROM suppression_list")
+
+	repo := NewLeadRepository(db)
+	ctx := context.Background()
+
+	if _, err := db.Exec(`
+		INSERT INTO suppression_list (phone, email, reason) VALUES
+			('4915100000000', NULL, 'DO_NOT_CALL'),
+			(NULL, 'blocked@example.com', NULL)
+	`); err != nil {
+		t.Fatalf("Failed to fill suppression list: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		phone  string
+		email  string
+		found  bool
+		reason string
+	}{
+		{"by phone", "4915100000000", "someone@example.com", true, "DO_NOT_CALL"},
+		{"by email", "4915112345678", "blocked@example.com", true, ""},
+		{"no match", "4915112345678", "someone@example.com", false, ""},
+		{"empty contact does not match", "", "", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := repo.FindInSuppressionList(ctx, tt.phone, tt.email)
+			if err != nil {
+				t.Fatalf("Failed to search suppression list: %v", err)
+			}
+			if (entry != nil) != tt.found {
+				t.Fatalf("Expected found=%v, got entry %+v", tt.found, entry)
+			}
+			if entry == nil {
+				return
+			}
+			reason := ""
+			if entry.Reason != nil {
+				reason = *entry.Reason
+			}
+			if reason != tt.reason {
+				t.Errorf("Expected reason %q, got %q", tt.reason, reason)
+			}
+		})
+	}
+}
+
+func TestLeadRepository_GetLeadCountsByStatusForSource(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	repo := NewLeadRepository(db)
+	ctx := context.Background()
+
+	sourceA := "partner-a"
+	sourceB := "partner-b"
+	seeds := []struct {
+		sourceID *string
+		status   models.LeadStatus
+	}{
+		{&sourceA, models.LeadStatusReceived},
+		{&sourceA, models.LeadStatusDelivered},
+		{&sourceA, models.LeadStatusDelivered},
+		{&sourceB, models.LeadStatusRejected},
+		{&sourceB, models.LeadStatusDelivered},
+		{nil, models.LeadStatusReceived},
+	}
+	for _, seed := range seeds {
+		lead := &models.InboundLead{
+			RawPayload: models.JSONB{"email": "test@example.com"},
+			SourceID:   seed.sourceID,
+			Status:     seed.status,
+		}
+		if err := repo.CreateLead(ctx, lead); err != nil {
+			t.Fatalf("Failed to create lead: %v", err)
+		}
+	}
+
+	countsA, err := repo.GetLeadCountsByStatusForSource(ctx, sourceA)
+	if err != nil {
+		t.Fatalf("Failed to get counts for %s: %v", sourceA, err)
+	}
+	if len(countsA) != 2 || countsA["RECEIVED"] != 1 || countsA["DELIVERED"] != 2 {
+		t.Errorf("Unexpected counts for %s: %v", sourceA, countsA)
+	}
+
+	countsB, err := repo.GetLeadCountsByStatusForSource(ctx, sourceB)
+	if err != nil {
+		t.Fatalf("Failed to get counts for %s: %v", sourceB, err)
+	}
+	if len(countsB) != 2 || countsB["REJECTED"] != 1 || countsB["DELIVERED"] != 1 {
+		t.Errorf("Unexpected counts for %s: %v", sourceB, countsB)
+	}
+
+	perSource, err := repo.GetLeadCountsByStatusPerSource(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get counts per source: %v", err)
+	}
+	if len(perSource) != 3 {
+		t.Fatalf("Expected counts for 2 sources and unattributed leads, got %v", perSource)
+	}
+	if perSource[sourceA]["DELIVERED"] != 2 || perSource[sourceB]["REJECTED"] != 1 || perSource[""]["RECEIVED"] != 1 {
+		t.Errorf("Unexpected counts per source: %v", perSource)
+	}
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// NormalizationAuditRepository stores the field values changed by lead normalization
+type NormalizationAuditRepository interface {
+	// CreateNormalizationAudits records the changed fields of the latest normalization of a
+	// lead, replacing those of an earlier one
+	CreateNormalizationAudits(ctx context.Context, leadID int64, audits []models.NormalizationAudit) error
+
+	// GetNormalizationAudit retrieves all recorded changes of a lead, oldest first
+	GetNormalizationAudit(ctx context.Context, leadID int64) ([]models.NormalizationAudit, error)
+}
+
+// normalizationAuditRepository is the concrete implementation of NormalizationAuditRepository
+type normalizationAuditRepository struct {
+	db *sql.DB
+}
+
+// NewNormalizationAuditRepository creates a new NormalizationAuditRepository instance
+func NewNormalizationAuditRepository(db *sql.DB) NormalizationAuditRepository {
+	return &normalizationAuditRepository{db: db}
+}
+
+// CreateNormalizationAudits records the changed fields of one normalization of a lead in a
+// single transaction, so a normalization is either audited completely or not at all. The
+// records of an earlier normalization of the lead are replaced, so a lead transformed again,
+// e.g. when its job is retried, is not audited twice. All records share one timestamp;
+// LeadID, ID and Timestamp of the audits are set.
+func (r *normalizationAuditRepository) CreateNormalizationAudits(ctx context.Context, leadID int64, audits []models.NormalizationAudit) error {
+	query := `
+		INSERT INTO normalization_audit (
+			lead_id, field, original_value, normalized_value, transformer_applied, normalized_at
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM normalization_audit WHERE lead_id = $1`, leadID); err != nil {
+		return fmt.Errorf("failed to delete earlier normalization audit: %w", err)
+	}
+
+	now := time.Now()
+	for i := range audits {
+		audit := &audits[i]
+		audit.LeadID = leadID
+		audit.Timestamp = now
+
+		original, err := encodeAuditValue(audit.OriginalValue)
+		if err != nil {
+			return err
+		}
+		normalized, err := encodeAuditValue(audit.NormalizedValue)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, query, leadID, audit.Field, original, normalized,
+			audit.TransformerApplied, audit.Timestamp).Scan(&audit.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create normalization audit: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetNormalizationAudit retrieves all recorded changes of a lead, ordered by time and field
+func (r *normalizationAuditRepository) GetNormalizationAudit(ctx context.Context, leadID int64) ([]models.NormalizationAudit, error) {
+	query := `
+		SELECT id, lead_id, field, original_value, normalized_value, transformer_applied, normalized_at
+		FROM normalization_audit
+		WHERE lead_id = $1
+		ORDER BY normalized_at ASC, field ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, leadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query normalization audit: %w", err)
+	}
+	defer rows.Close()
+
+	audits := []models.NormalizationAudit{}
+	for rows.Next() {
+		var audit models.NormalizationAudit
+		var original, normalized []byte
+		err := rows.Scan(&audit.ID, &audit.LeadID, &audit.Field, &original, &normalized,
+			&audit.TransformerApplied, &audit.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan normalization audit: %w", err)
+		}
+		if audit.OriginalValue, err = decodeAuditValue(original); err != nil {
+			return nil, err
+		}
+		if audit.NormalizedValue, err = decodeAuditValue(normalized); err != nil {
+			return nil, err
+		}
+		audits = append(audits, audit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating normalization audit: %w", err)
+	}
+	return audits, nil
+}
+
+// encodeAuditValue encodes a field value as JSON; a missing value is stored as NULL
+func encodeAuditValue(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode normalization audit value: %w", err)
+	}
+	return data, nil
+}
+
+// decodeAuditValue decodes a field value stored by encodeAuditValue
+func decodeAuditValue(data []byte) (interface{}, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var value interface{}
+	if err := models.DecodeJSON(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to decode normalization audit value: %w", err)
+	}
+	return value, nil
+}
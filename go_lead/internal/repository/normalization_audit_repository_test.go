@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+func TestNormalizationAuditRepository_CreateAndGet(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	leadRepo := NewLeadRepository(db)
+	auditRepo := NewNormalizationAuditRepository(db)
+	ctx := context.Background()
+
+	lead := &models.InboundLead{RawPayload: models.JSONB{"email": " Max@Example.COM ", "owner": " Ja "}, Status: models.LeadStatusReady}
+	if err := leadRepo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	audits := []models.NormalizationAudit{
+		{Field: "email", OriginalValue: " Max@Example.COM ", NormalizedValue: "max@example.com", TransformerApplied: "email"},
+		{Field: "owner", OriginalValue: " Ja ", NormalizedValue: true, TransformerApplied: "whitespace,value_alias"},
+	}
+	if err := auditRepo.CreateNormalizationAudits(ctx, lead.ID, audits); err != nil {
+		t.Fatalf("Failed to create normalization audits: %v", err)
+	}
+	if audits[0].ID == 0 || audits[0].LeadID != lead.ID || audits[0].Timestamp.IsZero() {
+		t.Errorf("Expected ID, lead ID and timestamp to be set, got %+v", audits[0])
+	}
+
+	stored, err := auditRepo.GetNormalizationAudit(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to get normalization audit: %v", err)
+	}
+	if len(stored) != 2 {
+		t.Fatalf("Expected 2 audit rows, got %d", len(stored))
+	}
+	if stored[0].Field != "email" || stored[0].OriginalValue != " Max@Example.COM " || stored[0].NormalizedValue != "max@example.com" {
+		t.Errorf("Unexpected email audit %+v", stored[0])
+	}
+	if stored[1].Field != "owner" || stored[1].NormalizedValue != true || stored[1].TransformerApplied != "whitespace,value_alias" {
+		t.Errorf("Unexpected owner audit %+v", stored[1])
+	}
+
+	// Normalizing the lead again replaces its audit
+	again := []models.NormalizationAudit{
+		{Field: "email", OriginalValue: " Max@Example.COM ", NormalizedValue: "max@example.com", TransformerApplied: "email"},
+	}
+	if err := auditRepo.CreateNormalizationAudits(ctx, lead.ID, again); err != nil {
+		t.Fatalf("Failed to create normalization audits again: %v", err)
+	}
+	stored, err = auditRepo.GetNormalizationAudit(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to get normalization audit: %v", err)
+	}
+	if len(stored) != 1 || stored[0].ID != again[0].ID {
+		t.Errorf("Expected the audit to be replaced by the latest normalization, got %+v", stored)
+	}
+
+	// Leads without changed fields have an empty audit
+	stored, err = auditRepo.GetNormalizationAudit(ctx, lead.ID+1)
+	if err != nil || len(stored) != 0 {
+		t.Errorf("Expected no audit rows, got %v, %v", stored, err)
+	}
+}
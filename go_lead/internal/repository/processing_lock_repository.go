@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// ProcessingLockRepository defines the interface for job processing lock persistence operations
+type ProcessingLockRepository interface {
+	// AcquireLock records that a worker started processing a job
+	AcquireLock(ctx context.Context, lock *models.ProcessingLock) error
+
+	// ReleaseLock removes the lock of a job once processing has ended
+	ReleaseLock(ctx context.Context, jobID int64) error
+
+	// GetStaleLocks retrieves locks acquired before the given time
+	GetStaleLocks(ctx context.Context, lockedBefore time.Time) ([]*models.ProcessingLock, error)
+
+	// RecoverLock marks delivery attempts recorded under the lock that never got a
+	// response as errored and releases the lock, in a single transaction.
+	// Returns the number of attempts marked.
+	RecoverLock(ctx context.Context, lock *models.ProcessingLock, errorMessage string) (int64, error)
+}
+
+// processingLockRepository is the concrete implementation of ProcessingLockRepository
+type processingLockRepository struct {
+	db          *sql.DB
+	retryPolicy WriteRetryPolicy
+}
+
+// NewProcessingLockRepository creates a new ProcessingLockRepository instance
+func NewProcessingLockRepository(db *sql.DB) ProcessingLockRepository {
+	return NewProcessingLockRepositoryWithRetry(db, DefaultWriteRetryPolicy())
+}
+
+// NewProcessingLockRepositoryWithRetry creates a new ProcessingLockRepository that retries
+// lock writes on transient database errors according to the given policy
+func NewProcessingLockRepositoryWithRetry(db *sql.DB, policy WriteRetryPolicy) ProcessingLockRepository {
+	return &processingLockRepository{
+		db:          db,
+		retryPolicy: policy,
+	}
+}
+
+// AcquireLock records that a worker started processing a job
+// A job that is processed again after recovery takes over its previous lock
+func (r *processingLockRepository) AcquireLock(ctx context.Context, lock *models.ProcessingLock) error {
+	query := `
+		INSERT INTO processing_locks (job_id, lead_id, worker_id, locked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (job_id) DO UPDATE
+		SET lead_id = EXCLUDED.lead_id, worker_id = EXCLUDED.worker_id, locked_at = EXCLUDED.locked_at
+	`
+
+	if lock.LockedAt.IsZero() {
+		lock.LockedAt = time.Now()
+	}
+
+	err := withWriteRetry(ctx, r.retryPolicy, func() error {
+		_, err := r.db.ExecContext(ctx, query, lock.JobID, lock.LeadID, lock.WorkerID, lock.LockedAt)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to acquire processing lock: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseLock removes the lock of a job once processing has ended
+func (r *processingLockRepository) ReleaseLock(ctx context.Context, jobID int64) error {
+	query := `DELETE FROM processing_locks WHERE job_id = $1`
+
+	err := withWriteRetry(ctx, r.retryPolicy, func() error {
+		_, err := r.db.ExecContext(ctx, query, jobID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release processing lock: %w", err)
+	}
+
+	return nil
+}
+
+// GetStaleLocks retrieves locks acquired before the given time
+func (r *processingLockRepository) GetStaleLocks(ctx context.Context, lockedBefore time.Time) ([]*models.ProcessingLock, error) {
+	query := `
+		SELECT job_id, lead_id, worker_id, locked_at
+		FROM processing_locks
+		WHERE locked_at < $1
+		ORDER BY locked_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, lockedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stale processing locks: %w", err)
+	}
+	defer rows.Close()
+
+	var locks []*models.ProcessingLock
+	for rows.Next() {
+		lock := &models.ProcessingLock{}
+		if err := rows.Scan(&lock.JobID, &lock.LeadID, &lock.WorkerID, &lock.LockedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan processing lock: %w", err)
+		}
+		locks = append(locks, lock)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating processing locks: %w", err)
+	}
+
+	return locks, nil
+}
+
+// RecoverLock marks delivery attempts recorded under the lock that never got a
+// response as errored and releases the lock, in a single transaction
+func (r *processingLockRepository) RecoverLock(ctx context.Context, lock *models.ProcessingLock, errorMessage string) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE delivery_attempt
+		SET error_message = $3
+		WHERE lead_id = $1
+			AND requested_at >= $2
+			AND success = FALSE
+			AND response_status IS NULL
+			AND error_message IS NULL
+	`, lock.LeadID, lock.LockedAt, errorMessage)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark unanswered delivery attempts: %w", err)
+	}
+
+	marked, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM processing_locks WHERE job_id = $1`, lock.JobID); err != nil {
+		return 0, fmt.Errorf("failed to release processing lock: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return marked, nil
+}
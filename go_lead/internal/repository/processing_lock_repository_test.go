@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+func TestProcessingLockRepository_RecoverLock(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	leadRepo := NewLeadRepository(db)
+	attemptRepo := NewDeliveryAttemptRepository(db)
+	lockRepo := NewProcessingLockRepository(db)
+	ctx := context.Background()
+
+	lead := &models.InboundLead{
+		RawPayload: models.JSONB{"email": "test@example.com"},
+		Status:     models.LeadStatusReady,
+	}
+	if err := leadRepo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	// Simulate a crash: the lock was acquired and an attempt recorded, but no response
+	lock := &models.ProcessingLock{JobID: 9001, LeadID: lead.ID, WorkerID: "worker-a", LockedAt: time.Now().Add(-time.Hour)}
+	if err := lockRepo.AcquireLock(ctx, lock); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	if err := attemptRepo.CreateDeliveryAttempt(ctx, models.NewDeliveryAttempt(lead.ID, 1)); err != nil {
+		t.Fatalf("Failed to create delivery attempt: %v", err)
+	}
+
+	stale, err := lockRepo.GetStaleLocks(ctx, time.Now().Add(-10*time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to get stale locks: %v", err)
+	}
+	if len(stale) != 1 || stale[0].JobID != lock.JobID {
+		t.Fatalf("Expected the lock to be stale, got %+v", stale)
+	}
+
+	marked, err := lockRepo.RecoverLock(ctx, stale[0], "worker crashed")
+	if err != nil {
+		t.Fatalf("Failed to recover lock: %v", err)
+	}
+	if marked != 1 {
+		t.Errorf("Expected 1 attempt to be marked, got %d", marked)
+	}
+
+	attempts, err := attemptRepo.GetDeliveryAttemptsByLeadID(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to get delivery attempts: %v", err)
+	}
+	if len(attempts) != 1 || attempts[0].ErrorMessage == nil || *attempts[0].ErrorMessage != "worker crashed" {
+		t.Errorf("Expected attempt to be marked as errored, got %+v", attempts)
+	}
+
+	stale, err = lockRepo.GetStaleLocks(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to get stale locks: %v", err)
+	}
+	if len(stale) != 0 {
+		t.Errorf("Expected the lock to be released, got %+v", stale)
+	}
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tenantContextKey is the context key under which the tenant ID is stored
+type tenantContextKey struct{}
+
+// ErrMissingTenantID is returned by tenant-scoped repositories when the context carries no tenant ID
+var ErrMissingTenantID = errors.New("tenant id missing from context")
+
+// WithTenantID returns a copy of ctx carrying the given tenant ID
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// GetTenantID extracts the tenant ID stored by WithTenantID
+func GetTenantID(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantContextKey{}).(string)
+	if !ok || tenantID == "" {
+		return "", false
+	}
+	return tenantID, true
+}
+
+// queryLogHook receives every tenant-scoped query before it is executed.
+// It is a no-op by default and is replaced in tests to inspect the generated SQL.
+var queryLogHook = func(query string, args []interface{}) {}
+
+var (
+	trailingClausePattern = regexp.MustCompile(`(?i)\b(GROUP BY|ORDER BY|LIMIT|RETURNING)\b`)
+	wherePattern          = regexp.MustCompile(`(?i)\bWHERE\b`)
+)
+
+// scopeQueryToTenant appends a tenant_id filter to a single-table query using the tenant ID from ctx.
+// Existing WHERE conditions are parenthesized so the filter cannot be bypassed by an OR.
+// Returns ErrMissingTenantID rather than running the query unfiltered.
+func scopeQueryToTenant(ctx context.Context, query string, args []interface{}) (string, []interface{}, error) {
+	tenantID, ok := GetTenantID(ctx)
+	if !ok {
+		return "", nil, ErrMissingTenantID
+	}
+
+	scopedArgs := append(append([]interface{}{}, args...), tenantID)
+	filter := fmt.Sprintf("tenant_id = $%d", len(scopedArgs))
+
+	// Split off trailing clauses so the filter lands inside the WHERE clause
+	head, tail := query, ""
+	if loc := trailingClausePattern.FindStringIndex(query); loc != nil {
+		head, tail = query[:loc[0]], query[loc[0]:]
+	}
+
+	if loc := wherePattern.FindStringIndex(head); loc != nil {
+		conditions := strings.TrimSpace(head[loc[1]:])
+		head = fmt.Sprintf("%s WHERE (%s) AND %s\n\t\t", strings.TrimRight(head[:loc[0]], " \t\n"), conditions, filter)
+	} else {
+		head = fmt.Sprintf("%s WHERE %s\n\t\t", strings.TrimRight(head, " \t\n"), filter)
+	}
+
+	scoped := head + tail
+	queryLogHook(scoped, scopedArgs)
+	return scoped, scopedArgs, nil
+}
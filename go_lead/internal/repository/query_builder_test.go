@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// captureQueries replaces the query log hook for the duration of the test
+func captureQueries(t *testing.T) *[]string {
+	var queries []string
+	previous := queryLogHook
+	queryLogHook = func(query string, args []interface{}) {
+		queries = append(queries, query)
+	}
+	t.Cleanup(func() { queryLogHook = previous })
+	return &queries
+}
+
+func TestGetTenantID(t *testing.T) {
+	if _, ok := GetTenantID(context.Background()); ok {
+		t.Error("Expected no tenant ID in empty context")
+	}
+
+	ctx := WithTenantID(context.Background(), "tenant-a")
+	tenantID, ok := GetTenantID(ctx)
+	if !ok || tenantID != "tenant-a" {
+		t.Errorf("Expected tenant-a, got %q (ok=%v)", tenantID, ok)
+	}
+}
+
+func TestScopeQueryToTenant_MissingTenant(t *testing.T) {
+	queries := captureQueries(t)
+
+	_, _, err := scopeQueryToTenant(context.Background(), "SELECT id FROM inbound_lead WHERE id = $1", []interface{}{1})
+	if !errors.Is(err, ErrMissingTenantID) {
+		t.Fatalf("Expected ErrMissingTenantID, got %v", err)
+	}
+
+	if len(*queries) != 0 {
+		t.Errorf("Expected no query to be built, got %v", *queries)
+	}
+}
+
+func TestScopeQueryToTenant(t *testing.T) {
+	ctx := WithTenantID(context.Background(), "tenant-a")
+
+	tests := []struct {
+		name     string
+		query    string
+		args     []interface{}
+		expected string
+	}{
+		{
+			name:     "existing where clause",
+			query:    "SELECT id FROM inbound_lead WHERE id = $1",
+			args:     []interface{}{1},
+			expected: "SELECT id FROM inbound_lead WHERE (id = $1) AND tenant_id = $2",
+		},
+		{
+			name:     "or conditions are parenthesized",
+			query:    "SELECT id FROM inbound_lead WHERE a = $1 OR b = $2 ORDER BY id LIMIT $3",
+			args:     []interface{}{1, 2, 3},
+			expected: "SELECT id FROM inbound_lead WHERE (a = $1 OR b = $2) AND tenant_id = $4 ORDER BY id LIMIT $3",
+		},
+		{
+			name:     "no where clause",
+			query:    "SELECT status, COUNT(*) FROM inbound_lead GROUP BY status",
+			expected: "SELECT status, COUNT(*) FROM inbound_lead WHERE tenant_id = $1 GROUP BY status",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := scopeQueryToTenant(ctx, tt.query, tt.args)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if strings.Join(strings.Fields(query), " ") != tt.expected {
+				t.Errorf("Expected query %q, got %q", tt.expected, query)
+			}
+
+			if len(args) != len(tt.args)+1 || args[len(args)-1] != "tenant-a" {
+				t.Errorf("Expected tenant ID appended to args, got %v", args)
+			}
+		})
+	}
+}
+
+func TestTenantScopedLeadRepository_AlwaysFiltersByTenant(t *testing.T) {
+	db, _ := openFlakyDB(t, 0, nil)
+	repo := NewTenantScopedLeadRepository(db, fastRetryPolicy())
+	queries := captureQueries(t)
+	ctx := WithTenantID(context.Background(), "tenant-a")
+
+	// Results are irrelevant here; the fake driver only returns an id column
+	_, _ = repo.GetLeadByID(ctx, 1)
+	_ = repo.UpdateLeadStatus(ctx, 1, models.LeadStatusReady)
+	_ = repo.UpdateLeadWithPayloads(ctx, 1, models.JSONB{}, models.JSONB{})
+	_ = repo.UpdateLeadRejection(ctx, 1, models.RejectionReasonNotHomeowner)
+	_, _ = repo.GetLeadCountsByStatus(ctx)
+	_, _ = repo.GetRecentLeads(ctx, 10)
+	_, _ = repo.FindLeadsByContact(ctx, "a@example.com", "123", 10)
+
+	if len(*queries) != 7 {
+		t.Fatalf("Expected 7 scoped queries, got %d", len(*queries))
+	}
+
+	for _, query := range *queries {
+		if !strings.Contains(query, "WHERE") || !strings.Contains(query, "tenant_id = $") {
+			t.Errorf("Expected tenant filter in query:\n%s", query)
+		}
+	}
+}
+
+func TestTenantScopedLeadRepository_RejectsMissingTenant(t *testing.T) {
+	db, state := openFlakyDB(t, 0, nil)
+	repo := NewTenantScopedLeadRepository(db, fastRetryPolicy())
+	ctx := context.Background()
+
+	if _, err := repo.GetLeadByID(ctx, 1); !errors.Is(err, ErrMissingTenantID) {
+		t.Errorf("GetLeadByID: expected ErrMissingTenantID, got %v", err)
+	}
+	if err := repo.UpdateLeadStatus(ctx, 1, models.LeadStatusReady); !errors.Is(err, ErrMissingTenantID) {
+		t.Errorf("UpdateLeadStatus: expected ErrMissingTenantID, got %v", err)
+	}
+	if _, err := repo.GetRecentLeads(ctx, 10); !errors.Is(err, ErrMissingTenantID) {
+		t.Errorf("GetRecentLeads: expected ErrMissingTenantID, got %v", err)
+	}
+	if err := repo.CreateLead(ctx, &models.InboundLead{}); !errors.Is(err, ErrMissingTenantID) {
+		t.Errorf("CreateLead: expected ErrMissingTenantID, got %v", err)
+	}
+
+	if state.calls != 0 {
+		t.Errorf("Expected no statements to reach the database, got %d", state.calls)
+	}
+}
+
+func TestTenantScopedLeadExportRepository_FiltersByTenant(t *testing.T) {
+	db, state := openFlakyDB(t, 0, nil)
+	repo := NewTenantScopedLeadExportRepository(db)
+	queries := captureQueries(t)
+
+	if _, err := repo.CountLeads(context.Background(), LeadExportFilter{}); !errors.Is(err, ErrMissingTenantID) {
+		t.Errorf("CountLeads: expected ErrMissingTenantID, got %v", err)
+	}
+	if _, err := repo.ListLeadsAfter(context.Background(), LeadExportFilter{}, 0, 10); !errors.Is(err, ErrMissingTenantID) {
+		t.Errorf("ListLeadsAfter: expected ErrMissingTenantID, got %v", err)
+	}
+	if state.calls != 0 {
+		t.Errorf("Expected no statements to reach the database, got %d", state.calls)
+	}
+
+	// Results are irrelevant here; the fake driver only returns an id column
+	ctx := WithTenantID(context.Background(), "tenant-a")
+	_, _ = repo.CountLeads(ctx, LeadExportFilter{Status: models.LeadStatusDelivered})
+	_, _ = repo.ListLeadsAfter(ctx, LeadExportFilter{Status: models.LeadStatusDelivered}, 0, 10)
+
+	if len(*queries) != 2 {
+		t.Fatalf("Expected 2 scoped queries, got %d", len(*queries))
+	}
+	for _, query := range *queries {
+		if !strings.Contains(query, "tenant_id = $") {
+			t.Errorf("Expected tenant filter in query:\n%s", query)
+		}
+	}
+}
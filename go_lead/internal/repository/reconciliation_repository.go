@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// ReconciliationRepository finds delivered leads to confirm at the Customer API and
+// records the outcome
+type ReconciliationRepository interface {
+	// FindLeadsToReconcile returns up to limit DELIVERED leads delivered since deliveredAfter
+	// that were not yet found at the Customer API, unchecked leads first
+	FindLeadsToReconcile(ctx context.Context, deliveredAfter time.Time, limit int) ([]models.ReconciliationCandidate, error)
+
+	// UpdateReconciliationStatus records the outcome of checking a lead
+	UpdateReconciliationStatus(ctx context.Context, leadID int64, status models.ReconciliationStatus) error
+}
+
+// reconciliationRepository is the concrete implementation of ReconciliationRepository.
+// Reconciliation covers all tenants, so queries are not scoped to a tenant.
+type reconciliationRepository struct {
+	db *sql.DB
+}
+
+// NewReconciliationRepository creates a new ReconciliationRepository instance
+func NewReconciliationRepository(db *sql.DB) ReconciliationRepository {
+	return &reconciliationRepository{db: db}
+}
+
+// FindLeadsToReconcile returns up to limit DELIVERED leads delivered since deliveredAfter
+// whose reconciliation status is not FOUND, with the body of their successful delivery
+// response. Unchecked leads come first, then those checked longest ago.
+func (r *reconciliationRepository) FindLeadsToReconcile(ctx context.Context, deliveredAfter time.Time, limit int) ([]models.ReconciliationCandidate, error) {
+	query := `
+		SELECT l.id, l.updated_at, (
+			SELECT d.response_body
+			FROM delivery_attempt d
+			WHERE d.lead_id = l.id AND d.success
+			ORDER BY d.attempt_no DESC
+			LIMIT 1
+		)
+		FROM inbound_lead l
+		WHERE l.status = $1 AND l.updated_at >= $2
+			AND (l.reconciliation_status IS NULL OR l.reconciliation_status <> $3)
+		ORDER BY l.reconciled_at NULLS FIRST, l.updated_at, l.id
+		LIMIT $4
+	`
+
+	// updated_at is stored as local wall-clock time without a zone
+	rows, err := r.db.QueryContext(ctx, query, models.LeadStatusDelivered, deliveredAfter.Local(), models.ReconciliationStatusFound, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find leads to reconcile: %w", err)
+	}
+	defer rows.Close()
+
+	var leads []models.ReconciliationCandidate
+	for rows.Next() {
+		var lead models.ReconciliationCandidate
+		if err := rows.Scan(&lead.LeadID, &lead.DeliveredAt, &lead.ResponseBody); err != nil {
+			return nil, fmt.Errorf("failed to scan lead to reconcile: %w", err)
+		}
+		leads = append(leads, lead)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating leads to reconcile: %w", err)
+	}
+	return leads, nil
+}
+
+// UpdateReconciliationStatus records the outcome of checking a lead. It leaves updated_at
+// alone, which marks when the lead was delivered.
+func (r *reconciliationRepository) UpdateReconciliationStatus(ctx context.Context, leadID int64, status models.ReconciliationStatus) error {
+	query := `
+		UPDATE inbound_lead
+		SET reconciliation_status = $1, reconciled_at = NOW()
+		WHERE id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, status, leadID)
+	if err != nil {
+		return fmt.Errorf("failed to update reconciliation status: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: %d", ErrLeadNotFound, leadID)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+func TestReconciliationRepository_FindAndUpdate(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	leadRepo := NewLeadRepository(db)
+	attemptRepo := NewDeliveryAttemptRepository(db)
+	reconciliationRepo := NewReconciliationRepository(db)
+	ctx := context.Background()
+
+	var ids []int64
+	for _, status := range []models.LeadStatus{models.LeadStatusDelivered, models.LeadStatusDelivered, models.LeadStatusDelivered, models.LeadStatusReady} {
+		lead := &models.InboundLead{RawPayload: models.JSONB{"phone": "1234567890"}, Status: status}
+		if err := leadRepo.CreateLead(ctx, lead); err != nil {
+			t.Fatalf("Failed to create lead: %v", err)
+		}
+		ids = append(ids, lead.ID)
+	}
+	// The third lead was delivered before the window
+	if _, err := db.Exec("UPDATE inbound_lead SET updated_at = NOW() - INTERVAL '2 days' WHERE id = $1", ids[2]); err != nil {
+		t.Fatalf("Failed to age lead: %v", err)
+	}
+	failed := models.NewDeliveryAttempt(ids[0], 1)
+	failed.MarkFailure(nil, "timeout")
+	delivered := models.NewDeliveryAttempt(ids[0], 2)
+	delivered.MarkSuccess(201, `{"id": "cust-1"}`)
+	for _, attempt := range []*models.DeliveryAttempt{failed, delivered} {
+		if err := attemptRepo.CreateDeliveryAttempt(ctx, attempt); err != nil {
+			t.Fatalf("Failed to create delivery attempt: %v", err)
+		}
+	}
+
+	deliveredAfter := time.Now().Add(-24 * time.Hour)
+	leads, err := reconciliationRepo.FindLeadsToReconcile(ctx, deliveredAfter, 10)
+	if err != nil {
+		t.Fatalf("Failed to find leads to reconcile: %v", err)
+	}
+	if len(leads) != 2 || leads[0].LeadID != ids[0] || leads[1].LeadID != ids[1] {
+		t.Fatalf("Expected the 2 leads delivered within the window, got %+v", leads)
+	}
+	if leads[0].ResponseBody == nil || *leads[0].ResponseBody != `{"id": "cust-1"}` {
+		t.Errorf("Expected the successful delivery response, got %v", leads[0].ResponseBody)
+	}
+	if leads[1].ResponseBody != nil {
+		t.Errorf("Expected no response without delivery attempts, got %q", *leads[1].ResponseBody)
+	}
+
+	// Found leads are done; missing ones are checked again after the unchecked ones
+	if err := reconciliationRepo.UpdateReconciliationStatus(ctx, ids[0], models.ReconciliationStatusFound); err != nil {
+		t.Fatalf("Failed to update reconciliation status: %v", err)
+	}
+	if err := reconciliationRepo.UpdateReconciliationStatus(ctx, ids[1], models.ReconciliationStatusMissing); err != nil {
+		t.Fatalf("Failed to update reconciliation status: %v", err)
+	}
+	leads, err = reconciliationRepo.FindLeadsToReconcile(ctx, deliveredAfter, 10)
+	if err != nil {
+		t.Fatalf("Failed to find leads to reconcile: %v", err)
+	}
+	if len(leads) != 1 || leads[0].LeadID != ids[1] {
+		t.Errorf("Expected only the missing lead, got %+v", leads)
+	}
+
+	var status string
+	if err := db.QueryRow("SELECT reconciliation_status FROM inbound_lead WHERE id = $1", ids[1]).Scan(&status); err != nil {
+		t.Fatalf("Failed to read reconciliation status: %v", err)
+	}
+	if status != string(models.ReconciliationStatusMissing) {
+		t.Errorf("Expected MISSING, got %s", status)
+	}
+
+	if err := reconciliationRepo.UpdateReconciliationStatus(ctx, 999999, models.ReconciliationStatusFound); err == nil {
+		t.Error("Expected an error for an unknown lead")
+	}
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// WriteRetryPolicy controls how idempotent repository writes are retried
+// when the database reports a transient error
+type WriteRetryPolicy struct {
+	MaxAttempts int           // total attempts including the first one
+	Backoff     time.Duration // delay before the first retry, doubled for each further retry
+}
+
+// DefaultWriteRetryPolicy returns the retry policy used when none is configured
+func DefaultWriteRetryPolicy() WriteRetryPolicy {
+	return WriteRetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     100 * time.Millisecond,
+	}
+}
+
+// withWriteRetry runs op, retrying it according to the policy while it fails with a transient error.
+// Only use this for writes that are safe to repeat (idempotent updates or inserts guarded by a unique key).
+func withWriteRetry(ctx context.Context, policy WriteRetryPolicy, op func() error) error {
+	attempts := policy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	delay := policy.Backoff
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = op(); err == nil || !IsTransientError(err) || attempt == attempts {
+			return err
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+		delay *= 2
+	}
+	return err
+}
+
+// IsTransientError reports whether a database error is likely to succeed on retry,
+// e.g. serialization failures, deadlocks, or dropped connections
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "40001", // serialization_failure
+			"40P01", // deadlock_detected
+			"57P01": // admin_shutdown
+			return true
+		}
+		// Class 08: connection exception
+		return pqErr.Code.Class() == "08"
+	}
+
+	errStr := err.Error()
+	return strings.Contains(errStr, "connection reset") ||
+		strings.Contains(errStr, "broken pipe")
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/lib/pq"
+)
+
+// flakyDB is the shared state behind a flaky test database connection.
+// The first `failures` statements fail with `err`, all later statements succeed.
+type flakyDB struct {
+	mu       sync.Mutex
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flakyDB) next() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.calls <= f.failures {
+		return f.err
+	}
+	return nil
+}
+
+var (
+	flakyDBsMu sync.Mutex
+	flakyDBs   = map[string]*flakyDB{}
+)
+
+func init() {
+	sql.Register("flaky", flakyDriver{})
+}
+
+// openFlakyDB returns a *sql.DB whose statements fail `failures` times with err before succeeding
+func openFlakyDB(t *testing.T, failures int, err error) (*sql.DB, *flakyDB) {
+	state := &flakyDB{failures: failures, err: err}
+
+	flakyDBsMu.Lock()
+	flakyDBs[t.Name()] = state
+	flakyDBsMu.Unlock()
+
+	db, openErr := sql.Open("flaky", t.Name())
+	if openErr != nil {
+		t.Fatalf("Failed to open flaky database: %v", openErr)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, state
+}
+
+type flakyDriver struct{}
+
+func (flakyDriver) Open(name string) (driver.Conn, error) {
+	flakyDBsMu.Lock()
+	defer flakyDBsMu.Unlock()
+	return &flakyConn{state: flakyDBs[name]}, nil
+}
+
+type flakyConn struct {
+	state *flakyDB
+}
+
+func (c *flakyConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c *flakyConn) Close() error { return nil }
+
+func (c *flakyConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+
+func (c *flakyConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.state.next(); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *flakyConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.state.next(); err != nil {
+		return nil, err
+	}
+	return &idRows{id: 42}, nil
+}
+
+// idRows returns a single row with a single id column
+type idRows struct {
+	id   int64
+	done bool
+}
+
+func (r *idRows) Columns() []string { return []string{"id"} }
+
+func (r *idRows) Close() error { return nil }
+
+func (r *idRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.id
+	return nil
+}
+
+func fastRetryPolicy() WriteRetryPolicy {
+	return WriteRetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+}
+
+func TestWriteRetry_UpdateLeadStatusRecoversFromTransientError(t *testing.T) {
+	db, state := openFlakyDB(t, 1, &pq.Error{Code: "40001", Message: "could not serialize access"})
+	repo := NewLeadRepositoryWithRetry(db, fastRetryPolicy())
+
+	err := repo.UpdateLeadStatus(context.Background(), 1, models.LeadStatusReady)
+	if err != nil {
+		t.Fatalf("Expected write to succeed after retry, got %v", err)
+	}
+
+	if state.calls != 2 {
+		t.Errorf("Expected 2 calls (1 failure + 1 success), got %d", state.calls)
+	}
+}
+
+func TestWriteRetry_CreateDeliveryAttemptRecoversFromConnectionReset(t *testing.T) {
+	db, state := openFlakyDB(t, 1, errors.New("read tcp: connection reset by peer"))
+	repo := NewDeliveryAttemptRepositoryWithRetry(db, fastRetryPolicy())
+
+	attempt := models.NewDeliveryAttempt(1, 1)
+	if err := repo.CreateDeliveryAttempt(context.Background(), attempt); err != nil {
+		t.Fatalf("Expected insert to succeed after retry, got %v", err)
+	}
+
+	if attempt.ID != 42 {
+		t.Errorf("Expected attempt ID 42, got %d", attempt.ID)
+	}
+
+	if state.calls != 2 {
+		t.Errorf("Expected 2 calls (1 failure + 1 success), got %d", state.calls)
+	}
+}
+
+func TestWriteRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	db, state := openFlakyDB(t, 10, &pq.Error{Code: "40P01", Message: "deadlock detected"})
+	repo := NewLeadRepositoryWithRetry(db, fastRetryPolicy())
+
+	err := repo.UpdateLeadStatus(context.Background(), 1, models.LeadStatusReady)
+	if err == nil {
+		t.Fatal("Expected error after exhausting retries")
+	}
+
+	if state.calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", state.calls)
+	}
+}
+
+func TestWriteRetry_DoesNotRetryPermanentError(t *testing.T) {
+	db, state := openFlakyDB(t, 1, &pq.Error{Code: "23514", Message: "check constraint violation"})
+	repo := NewLeadRepositoryWithRetry(db, fastRetryPolicy())
+
+	err := repo.UpdateLeadStatus(context.Background(), 1, models.LeadStatus("BOGUS"))
+	if err == nil {
+		t.Fatal("Expected error for permanent failure")
+	}
+
+	if state.calls != 1 {
+		t.Errorf("Expected 1 call, got %d", state.calls)
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil", nil, false},
+		{"serialization failure", &pq.Error{Code: "40001"}, true},
+		{"deadlock", &pq.Error{Code: "40P01"}, true},
+		{"connection failure", &pq.Error{Code: "08006"}, true},
+		{"unique violation", &pq.Error{Code: "23505"}, false},
+		{"bad connection", driver.ErrBadConn, true},
+		{"connection reset", errors.New("read: connection reset by peer"), true},
+		{"no rows", sql.ErrNoRows, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransientError(tt.err); got != tt.expected {
+				t.Errorf("IsTransientError(%v) = %v, expected %v", tt.err, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// StuckLeadRepository finds leads waiting in FAILED status for a retry
+type StuckLeadRepository interface {
+	// CountStuckLeads returns how many FAILED leads were last updated before updatedBefore
+	CountStuckLeads(ctx context.Context, updatedBefore time.Time) (int, error)
+
+	// FindStuckLeads returns up to limit FAILED leads last updated before updatedBefore,
+	// oldest first, with the error of their latest delivery attempt
+	FindStuckLeads(ctx context.Context, updatedBefore time.Time, limit int) ([]models.StuckLead, error)
+}
+
+// stuckLeadRepository is the concrete implementation of StuckLeadRepository.
+// Stuck leads are an operational concern, so queries are not scoped to a tenant.
+type stuckLeadRepository struct {
+	db *sql.DB
+}
+
+// NewStuckLeadRepository creates a new StuckLeadRepository instance
+func NewStuckLeadRepository(db *sql.DB) StuckLeadRepository {
+	return &stuckLeadRepository{db: db}
+}
+
+// CountStuckLeads returns how many FAILED leads were last updated before updatedBefore
+func (r *stuckLeadRepository) CountStuckLeads(ctx context.Context, updatedBefore time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM inbound_lead
+		WHERE status = $1 AND updated_at < $2
+	`
+
+	var count int
+	// updated_at is stored as local wall-clock time without a zone
+	if err := r.db.QueryRowContext(ctx, query, models.LeadStatusFailed, updatedBefore.Local()).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count stuck leads: %w", err)
+	}
+	return count, nil
+}
+
+// FindStuckLeads returns up to limit FAILED leads last updated before updatedBefore, oldest first
+func (r *stuckLeadRepository) FindStuckLeads(ctx context.Context, updatedBefore time.Time, limit int) ([]models.StuckLead, error) {
+	query := `
+		SELECT l.id, l.updated_at, (
+			SELECT d.error_message
+			FROM delivery_attempt d
+			WHERE d.lead_id = l.id
+			ORDER BY d.attempt_no DESC
+			LIMIT 1
+		)
+		FROM inbound_lead l
+		WHERE l.status = $1 AND l.updated_at < $2
+		ORDER BY l.updated_at, l.id
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, models.LeadStatusFailed, updatedBefore.Local(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stuck leads: %w", err)
+	}
+	defer rows.Close()
+
+	var leads []models.StuckLead
+	for rows.Next() {
+		var lead models.StuckLead
+		if err := rows.Scan(&lead.LeadID, &lead.UpdatedAt, &lead.LastError); err != nil {
+			return nil, fmt.Errorf("failed to scan stuck lead: %w", err)
+		}
+		leads = append(leads, lead)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stuck leads: %w", err)
+	}
+	return leads, nil
+}
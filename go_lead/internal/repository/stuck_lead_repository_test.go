@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+func TestStuckLeadRepository_FindStuckLeads(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer cleanupTestData(t, db)
+
+	leadRepo := NewLeadRepository(db)
+	attemptRepo := NewDeliveryAttemptRepository(db)
+	stuckRepo := NewStuckLeadRepository(db)
+	ctx := context.Background()
+
+	var ids []int64
+	for _, status := range []models.LeadStatus{models.LeadStatusFailed, models.LeadStatusFailed, models.LeadStatusReady} {
+		lead := &models.InboundLead{RawPayload: models.JSONB{"phone": "1234567890"}, Status: status}
+		if err := leadRepo.CreateLead(ctx, lead); err != nil {
+			t.Fatalf("Failed to create lead: %v", err)
+		}
+		ids = append(ids, lead.ID)
+	}
+	// The first lead failed longest ago
+	if _, err := db.Exec("UPDATE inbound_lead SET updated_at = NOW() - INTERVAL '3 hours' WHERE id = $1", ids[0]); err != nil {
+		t.Fatalf("Failed to age lead: %v", err)
+	}
+	if _, err := db.Exec("UPDATE inbound_lead SET updated_at = NOW() - INTERVAL '2 hours' WHERE id IN ($1, $2)", ids[1], ids[2]); err != nil {
+		t.Fatalf("Failed to age leads: %v", err)
+	}
+	for attemptNo, message := range []string{"timeout", "connection refused"} {
+		attempt := models.NewDeliveryAttempt(ids[0], attemptNo+1)
+		attempt.MarkFailure(nil, message)
+		if err := attemptRepo.CreateDeliveryAttempt(ctx, attempt); err != nil {
+			t.Fatalf("Failed to create delivery attempt: %v", err)
+		}
+	}
+
+	updatedBefore := time.Now().Add(-time.Hour)
+	count, err := stuckRepo.CountStuckLeads(ctx, updatedBefore)
+	if err != nil {
+		t.Fatalf("Failed to count stuck leads: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected the 2 FAILED leads to be stuck, got %d", count)
+	}
+
+	leads, err := stuckRepo.FindStuckLeads(ctx, updatedBefore, 10)
+	if err != nil {
+		t.Fatalf("Failed to find stuck leads: %v", err)
+	}
+	if len(leads) != 2 || leads[0].LeadID != ids[0] || leads[1].LeadID != ids[1] {
+		t.Fatalf("Expected the FAILED leads oldest first, got %+v", leads)
+	}
+	if leads[0].LastError == nil || *leads[0].LastError != "connection refused" {
+		t.Errorf("Expected the error of the latest attempt, got %v", leads[0].LastError)
+	}
+	if leads[1].LastError != nil {
+		t.Errorf("Expected no error without delivery attempts, got %q", *leads[1].LastError)
+	}
+}
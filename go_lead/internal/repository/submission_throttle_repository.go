@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SubmissionThrottleRepository defines the interface for duplicate submission throttling
+type SubmissionThrottleRepository interface {
+	// CheckSubmission reports whether a submission at the given time is allowed, i.e. none of
+	// the keys has a recorded submission within the window. If not, retryAfter is the time
+	// until the most recent of those submissions leaves the window.
+	CheckSubmission(ctx context.Context, keys []string, at time.Time, window time.Duration) (allowed bool, retryAfter time.Duration, err error)
+
+	// RecordSubmission records a submission at the given time under all keys
+	RecordSubmission(ctx context.Context, keys []string, at time.Time) error
+
+	// DeleteExpiredSubmissions deletes the submissions recorded before the given time and
+	// returns how many were deleted
+	DeleteExpiredSubmissions(ctx context.Context, before time.Time) (int64, error)
+}
+
+// submissionThrottleRepository is the concrete implementation of SubmissionThrottleRepository
+type submissionThrottleRepository struct {
+	db *sql.DB
+}
+
+// NewSubmissionThrottleRepository creates a new SubmissionThrottleRepository instance
+func NewSubmissionThrottleRepository(db *sql.DB) SubmissionThrottleRepository {
+	return &submissionThrottleRepository{db: db}
+}
+
+// CheckSubmission reports whether none of the keys has a submission within the window.
+// TIMESTAMP columns carry no zone, so times are stored and compared in UTC.
+func (r *submissionThrottleRepository) CheckSubmission(ctx context.Context, keys []string, at time.Time, window time.Duration) (bool, time.Duration, error) {
+	query := `
+		SELECT MAX(last_submitted_at)
+		FROM submission_throttle
+		WHERE throttle_key = ANY($1) AND last_submitted_at > $2
+	`
+
+	at = at.UTC()
+	var lastSubmittedAt sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query, pq.Array(keys), at.Add(-window)).Scan(&lastSubmittedAt); err != nil {
+		return false, 0, fmt.Errorf("failed to check submission: %w", err)
+	}
+	if !lastSubmittedAt.Valid {
+		return true, 0, nil
+	}
+	return false, lastSubmittedAt.Time.Add(window).Sub(at), nil
+}
+
+// RecordSubmission records a submission under all keys. A later submission time is never
+// replaced by an earlier one, so concurrent requests keep the most recent submission.
+func (r *submissionThrottleRepository) RecordSubmission(ctx context.Context, keys []string, at time.Time) error {
+	query := `
+		INSERT INTO submission_throttle (throttle_key, last_submitted_at)
+		SELECT key, $2 FROM UNNEST($1::text[]) AS key
+		ON CONFLICT (throttle_key) DO UPDATE
+		SET last_submitted_at = GREATEST(submission_throttle.last_submitted_at, EXCLUDED.last_submitted_at)
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, pq.Array(keys), at.UTC()); err != nil {
+		return fmt.Errorf("failed to record submission: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredSubmissions deletes the submissions recorded before the given time
+func (r *submissionThrottleRepository) DeleteExpiredSubmissions(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM submission_throttle
+		WHERE last_submitted_at < $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired submissions: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSubmissionThrottleRepository_CheckAndRecordSubmission(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer db.Exec("DELETE FROM submission_throttle")
+
+	repo := NewSubmissionThrottleRepository(db)
+	ctx := context.Background()
+	window := time.Minute
+	start := time.Now().Truncate(time.Second)
+	keys := []string{"203.0.113.7:phone:4915112345678", "203.0.113.7:email:max@example.com"}
+
+	allowed, _, err := repo.CheckSubmission(ctx, keys, start, window)
+	if err != nil {
+		t.Fatalf("Failed to check submission: %v", err)
+	}
+	if !allowed {
+		t.Fatal("Expected the first submission to be allowed")
+	}
+
+	// A checked submission is not recorded until RecordSubmission
+	if allowed, _, err := repo.CheckSubmission(ctx, keys, start.Add(time.Second), window); err != nil || !allowed {
+		t.Fatalf("Expected an unrecorded submission not to throttle, got allowed=%v err=%v", allowed, err)
+	}
+	if err := repo.RecordSubmission(ctx, keys, start); err != nil {
+		t.Fatalf("Failed to record submission: %v", err)
+	}
+
+	// Sharing only the email with the first submission is enough to be throttled
+	otherPhone := []string{"203.0.113.7:phone:4915199999999", keys[1]}
+	allowed, retryAfter, err := repo.CheckSubmission(ctx, otherPhone, start.Add(20*time.Second), window)
+	if err != nil {
+		t.Fatalf("Failed to check submission: %v", err)
+	}
+	if allowed || retryAfter != 40*time.Second {
+		t.Errorf("Expected the second submission to be throttled for 40s, got allowed=%v retry_after=%v", allowed, retryAfter)
+	}
+
+	allowed, _, err = repo.CheckSubmission(ctx, keys, start.Add(window+time.Second), window)
+	if err != nil {
+		t.Fatalf("Failed to check submission: %v", err)
+	}
+	if !allowed {
+		t.Error("Expected a submission after the window to be allowed")
+	}
+
+	// An earlier submission time does not replace a later one
+	if err := repo.RecordSubmission(ctx, keys[:1], start.Add(-time.Hour)); err != nil {
+		t.Fatalf("Failed to record submission: %v", err)
+	}
+	if allowed, _, _ := repo.CheckSubmission(ctx, keys[:1], start.Add(time.Second), window); allowed {
+		t.Error("Expected the later submission to be kept")
+	}
+
+	deleted, err := repo.DeleteExpiredSubmissions(ctx, start.Add(time.Second))
+	if err != nil {
+		t.Fatalf("Failed to delete expired submissions: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Expected 2 expired submissions to be deleted, got %d", deleted)
+	}
+}
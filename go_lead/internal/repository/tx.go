@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// TxBeginner starts database transactions, e.g. a LeadRepository
+type TxBeginner interface {
+	BeginTx(ctx context.Context) (*sql.Tx, error)
+}
+
+// WithTx runs fn in a transaction begun on db. The transaction is committed if fn returns
+// nil and rolled back if fn returns an error or panics; fn's error is returned unchanged.
+func WithTx(ctx context.Context, db TxBeginner, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	// Rolling back a committed transaction is a no-op
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
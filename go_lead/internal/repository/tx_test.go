@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// txLog records the statements and transaction outcomes of a recording test database
+type txLog struct {
+	mu        sync.Mutex
+	execs     []string
+	commits   int
+	rollbacks int
+}
+
+var (
+	txLogsMu sync.Mutex
+	txLogs   = map[string]*txLog{}
+)
+
+func init() {
+	sql.Register("txrecorder", txRecorderDriver{})
+}
+
+// openTxRecorderDB returns a *sql.DB that records statements and commits/rollbacks
+func openTxRecorderDB(t *testing.T) (*sql.DB, *txLog) {
+	log := &txLog{}
+
+	txLogsMu.Lock()
+	txLogs[t.Name()] = log
+	txLogsMu.Unlock()
+
+	db, err := sql.Open("txrecorder", t.Name())
+	if err != nil {
+		t.Fatalf("Failed to open recording database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, log
+}
+
+type txRecorderDriver struct{}
+
+func (txRecorderDriver) Open(name string) (driver.Conn, error) {
+	txLogsMu.Lock()
+	defer txLogsMu.Unlock()
+	return &txRecorderConn{log: txLogs[name]}, nil
+}
+
+type txRecorderConn struct {
+	log *txLog
+}
+
+func (c *txRecorderConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c *txRecorderConn) Close() error { return nil }
+
+func (c *txRecorderConn) Begin() (driver.Tx, error) {
+	return &txRecorderTx{log: c.log}, nil
+}
+
+func (c *txRecorderConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.log.mu.Lock()
+	defer c.log.mu.Unlock()
+	c.log.execs = append(c.log.execs, query)
+	return driver.RowsAffected(1), nil
+}
+
+type txRecorderTx struct {
+	log *txLog
+}
+
+func (tx *txRecorderTx) Commit() error {
+	tx.log.mu.Lock()
+	defer tx.log.mu.Unlock()
+	tx.log.commits++
+	return nil
+}
+
+func (tx *txRecorderTx) Rollback() error {
+	tx.log.mu.Lock()
+	defer tx.log.mu.Unlock()
+	tx.log.rollbacks++
+	return nil
+}
+
+func TestWithTx_CommitsOnSuccess(t *testing.T) {
+	db, log := openTxRecorderDB(t)
+	repo := NewLeadRepository(db)
+
+	err := WithTx(context.Background(), repo, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE inbound_lead SET status = 'READY'")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Expected the transaction to succeed, got %v", err)
+	}
+
+	if log.commits != 1 || log.rollbacks != 0 {
+		t.Errorf("Expected 1 commit and no rollback, got %d commits and %d rollbacks", log.commits, log.rollbacks)
+	}
+}
+
+func TestWithTx_RollsBackOnError(t *testing.T) {
+	db, log := openTxRecorderDB(t)
+	repo := NewLeadRepository(db)
+	errClosure := errors.New("delivery attempt rejected")
+
+	// The closure fails after its first statement; the second one must never run
+	err := WithTx(context.Background(), repo, func(tx *sql.Tx) error {
+		if _, err := tx.Exec("UPDATE inbound_lead SET status = 'FAILED'"); err != nil {
+			return err
+		}
+		if errClosure != nil {
+			return errClosure
+		}
+		_, err := tx.Exec("INSERT INTO delivery_attempt DEFAULT VALUES")
+		return err
+	})
+	if !errors.Is(err, errClosure) {
+		t.Fatalf("Expected the closure's error, got %v", err)
+	}
+
+	if log.commits != 0 || log.rollbacks != 1 {
+		t.Errorf("Expected no commit and 1 rollback, got %d commits and %d rollbacks", log.commits, log.rollbacks)
+	}
+	if len(log.execs) != 1 {
+		t.Errorf("Expected only the statement before the error to run, got %v", log.execs)
+	}
+}
+
+func TestWithTx_RollsBackOnPanic(t *testing.T) {
+	db, log := openTxRecorderDB(t)
+	repo := NewLeadRepository(db)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected the panic to propagate")
+			}
+		}()
+		WithTx(context.Background(), repo, func(tx *sql.Tx) error {
+			panic("unexpected")
+		})
+	}()
+
+	if log.commits != 0 || log.rollbacks != 1 {
+		t.Errorf("Expected no commit and 1 rollback, got %d commits and %d rollbacks", log.commits, log.rollbacks)
+	}
+}
+
+// failingBeginner fails to start a transaction
+type failingBeginner struct{ err error }
+
+func (b failingBeginner) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return nil, b.err
+}
+
+func TestWithTx_BeginError(t *testing.T) {
+	beginErr := errors.New("connection refused")
+	called := false
+
+	err := WithTx(context.Background(), failingBeginner{err: beginErr}, func(tx *sql.Tx) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, beginErr) {
+		t.Errorf("Expected the begin error, got %v", err)
+	}
+	if called {
+		t.Error("Expected the closure not to run without a transaction")
+	}
+}
@@ -0,0 +1,69 @@
+// Package schedule evaluates hour-of-day and weekday windows such as the
+// delivery schedule and the webhook acceptance schedule.
+package schedule
+
+import (
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+)
+
+// maxLookahead bounds the search for the next open window
+const maxLookahead = 8 * 24 * time.Hour
+
+// Window decides whether a schedule is open at a given time
+type Window struct {
+	hours    map[int]bool
+	weekdays map[time.Weekday]bool
+	location *time.Location
+}
+
+// NewWindow builds a window from the configured schedule.
+// An unknown timezone falls back to UTC; the config is validated on load.
+func NewWindow(schedule config.DeliverySchedule) *Window {
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	w := &Window{location: location}
+	if len(schedule.AllowedHours) > 0 {
+		w.hours = make(map[int]bool, len(schedule.AllowedHours))
+		for _, hour := range schedule.AllowedHours {
+			w.hours[hour] = true
+		}
+	}
+	if len(schedule.AllowedWeekdays) > 0 {
+		w.weekdays = make(map[time.Weekday]bool, len(schedule.AllowedWeekdays))
+		for _, day := range schedule.AllowedWeekdays {
+			w.weekdays[day] = true
+		}
+	}
+	return w
+}
+
+// Allows reports whether the schedule is open at t
+func (w *Window) Allows(t time.Time) bool {
+	local := t.In(w.location)
+	if w.hours != nil && !w.hours[local.Hour()] {
+		return false
+	}
+	if w.weekdays != nil && !w.weekdays[local.Weekday()] {
+		return false
+	}
+	return true
+}
+
+// Next returns the start of the next open window after t.
+// Returns false if the schedule is never open.
+func (w *Window) Next(t time.Time) (time.Time, bool) {
+	local := t.In(w.location)
+	hourStart := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), 0, 0, 0, w.location)
+
+	for candidate := hourStart.Add(time.Hour); candidate.Sub(hourStart) <= maxLookahead; candidate = candidate.Add(time.Hour) {
+		if w.Allows(candidate) {
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
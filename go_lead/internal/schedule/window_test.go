@@ -0,0 +1,88 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+)
+
+// offPeakSchedule allows delivery between 22:00 and 05:59 on weekdays in Berlin
+var offPeakSchedule = config.DeliverySchedule{
+	AllowedHours:    []int{22, 23, 0, 1, 2, 3, 4, 5},
+	AllowedWeekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	Timezone:        "Europe/Berlin",
+}
+
+func TestWindow_Allows(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	window := NewWindow(offPeakSchedule)
+
+	tests := []struct {
+		name   string
+		at     time.Time
+		expect bool
+	}{
+		{"weekday night", time.Date(2025, 1, 7, 23, 30, 0, 0, berlin), true},
+		{"weekday early morning", time.Date(2025, 1, 7, 5, 59, 0, 0, berlin), true},
+		{"weekday business hours", time.Date(2025, 1, 7, 12, 0, 0, 0, berlin), false},
+		{"weekend night", time.Date(2025, 1, 11, 23, 0, 0, 0, berlin), false},
+		{"utc time converted to schedule timezone", time.Date(2025, 1, 7, 21, 30, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := window.Allows(tt.at); got != tt.expect {
+				t.Errorf("Expected allows=%v at %v, got %v", tt.expect, tt.at, got)
+			}
+		})
+	}
+}
+
+func TestWindow_EmptyScheduleAllowsAlways(t *testing.T) {
+	window := NewWindow(config.DeliverySchedule{Timezone: "UTC"})
+
+	if !window.Allows(time.Date(2025, 1, 11, 12, 0, 0, 0, time.UTC)) {
+		t.Error("Expected empty schedule to allow delivery at any time")
+	}
+}
+
+func TestWindow_Next(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	window := NewWindow(offPeakSchedule)
+
+	tests := []struct {
+		name   string
+		from   time.Time
+		expect time.Time
+	}{
+		{"later the same day", time.Date(2025, 1, 7, 12, 15, 0, 0, berlin), time.Date(2025, 1, 7, 22, 0, 0, 0, berlin)},
+		{"saturday waits for monday", time.Date(2025, 1, 11, 12, 0, 0, 0, berlin), time.Date(2025, 1, 13, 0, 0, 0, 0, berlin)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, ok := window.Next(tt.from)
+			if !ok {
+				t.Fatal("Expected a next delivery window")
+			}
+			if !next.Equal(tt.expect) {
+				t.Errorf("Expected next window %v, got %v", tt.expect, next)
+			}
+		})
+	}
+}
+
+func TestWindow_NextWithoutAllowedHours(t *testing.T) {
+	window := NewWindow(config.DeliverySchedule{AllowedHours: []int{25}, Timezone: "UTC"})
+
+	if _, ok := window.Next(time.Date(2025, 1, 7, 12, 0, 0, 0, time.UTC)); ok {
+		t.Error("Expected no next window for a schedule that never allows delivery")
+	}
+}
@@ -0,0 +1,258 @@
+// Package selftest verifies the wiring of a service on startup: database,
+// migrations, queue table, attribute mapping and Customer API reachability.
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/database"
+	"github.com/checkfox/go_lead/internal/logger"
+)
+
+// Check names as they appear in the report
+const (
+	CheckDatabase         = "database"
+	CheckMigrations       = "migrations"
+	CheckQueueTable       = "queue_table"
+	CheckAttributeMapping = "attribute_mapping"
+	CheckCustomerAPI      = "customer_api"
+)
+
+// Check outcomes
+const (
+	StatusPass = "pass"
+	StatusFail = "fail" // hard failure, startup should abort
+	StatusWarn = "warn" // soft failure, startup continues
+	StatusSkip = "skip"
+)
+
+// customerAPITimeout bounds the Customer API reachability probe
+const customerAPITimeout = 5 * time.Second
+
+// knownAttributeTypes are the attribute types understood by services.Mapper
+var knownAttributeTypes = map[string]bool{
+	"text":     true,
+	"dropdown": true,
+	"range":    true,
+	"email":    true,
+	"url":      true,
+	"date":     true,
+	"phone":    true,
+}
+
+// Deps holds what the self-test inspects
+type Deps struct {
+	DB             *database.DB // database checks are skipped when nil
+	MigrationsPath string       // directory of migration files
+	Config         *config.Config
+	HTTPClient     *http.Client // used for the Customer API probe; defaults to a client with a short timeout
+}
+
+// Result is the outcome of a single check
+type Result struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Message  string        `json:"message,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report is the outcome of all checks in the order they ran
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Failed reports whether any hard check failed
+func (r *Report) Failed() bool {
+	for _, result := range r.Results {
+		if result.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// Err summarizes the hard failures, or returns nil if there are none
+func (r *Report) Err() error {
+	var failures []string
+	for _, result := range r.Results {
+		if result.Status == StatusFail {
+			failures = append(failures, fmt.Sprintf("%s: %s", result.Name, result.Message))
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("self-test failed: %s", strings.Join(failures, "; "))
+}
+
+// Run performs all checks and logs a structured report.
+// Returns an error if a hard check (database, migrations, queue table, attribute mapping) failed;
+// an unreachable Customer API is only reported as a warning.
+func Run(ctx context.Context, deps Deps) (*Report, error) {
+	report := &Report{}
+	run := func(name string, check func() (string, string)) {
+		start := time.Now()
+		status, message := check()
+		report.Results = append(report.Results, Result{
+			Name:     name,
+			Status:   status,
+			Message:  message,
+			Duration: time.Since(start),
+		})
+	}
+
+	dbReachable := false
+	run(CheckDatabase, func() (string, string) {
+		if deps.DB == nil {
+			return StatusSkip, "no database configured"
+		}
+		if err := deps.DB.PingContext(ctx); err != nil {
+			return StatusFail, err.Error()
+		}
+		dbReachable = true
+		return StatusPass, ""
+	})
+	run(CheckMigrations, func() (string, string) {
+		if !dbReachable {
+			return StatusSkip, "database unavailable"
+		}
+		return checkMigrations(deps.DB, deps.MigrationsPath)
+	})
+	run(CheckQueueTable, func() (string, string) {
+		if !dbReachable {
+			return StatusSkip, "database unavailable"
+		}
+		return checkQueueTable(ctx, deps.DB)
+	})
+	run(CheckAttributeMapping, func() (string, string) {
+		return checkAttributeMapping(deps.Config)
+	})
+	run(CheckCustomerAPI, func() (string, string) {
+		return checkCustomerAPI(ctx, deps.HTTPClient, deps.Config)
+	})
+
+	logReport(ctx, report)
+	return report, report.Err()
+}
+
+// checkMigrations fails if any migration file has not been applied
+func checkMigrations(db *database.DB, migrationsPath string) (string, string) {
+	pending, err := database.NewMigrationRunner(db, migrationsPath).Pending()
+	if err != nil {
+		return StatusFail, err.Error()
+	}
+	if len(pending) > 0 {
+		versions := make([]string, 0, len(pending))
+		for _, migration := range pending {
+			versions = append(versions, fmt.Sprintf("%03d", migration.Version))
+		}
+		return StatusFail, fmt.Sprintf("%d pending migration(s): %s", len(pending), strings.Join(versions, ", "))
+	}
+	return StatusPass, ""
+}
+
+// checkQueueTable fails if the background job table does not exist
+func checkQueueTable(ctx context.Context, db *database.DB) (string, string) {
+	var exists bool
+	if err := db.QueryRowContext(ctx, "SELECT to_regclass('background_jobs') IS NOT NULL").Scan(&exists); err != nil {
+		return StatusFail, err.Error()
+	}
+	if !exists {
+		return StatusFail, "table background_jobs does not exist"
+	}
+	return StatusPass, ""
+}
+
+// checkAttributeMapping fails if the default mapping or a profile is empty or has unusable definitions
+func checkAttributeMapping(cfg *config.Config) (string, string) {
+	if cfg == nil {
+		return StatusFail, "no configuration"
+	}
+	if err := validateMapping(cfg.AttributeMapping.Mapping); err != nil {
+		return StatusFail, err.Error()
+	}
+	for name, profile := range cfg.AttributeMapping.Profiles {
+		if err := validateMapping(profile); err != nil {
+			return StatusFail, fmt.Sprintf("profile %s: %v", name, err)
+		}
+	}
+	return StatusPass, fmt.Sprintf("%d attributes, %d profiles", len(cfg.AttributeMapping.Mapping), len(cfg.AttributeMapping.Profiles))
+}
+
+// validateMapping checks that a mapping has attributes and each definition can be applied
+func validateMapping(mapping map[string]config.AttributeDefinition) error {
+	if len(mapping) == 0 {
+		return errors.New("no attributes loaded")
+	}
+	for key, def := range mapping {
+		if !knownAttributeTypes[def.Type] {
+			return fmt.Errorf("attribute %s has unknown type %q", key, def.Type)
+		}
+		if def.Type == "dropdown" && len(def.Options) == 0 {
+			return fmt.Errorf("dropdown attribute %s has no options", key)
+		}
+		if def.Min != nil && def.Max != nil && *def.Min > *def.Max {
+			return fmt.Errorf("range attribute %s has min %g above max %g", key, *def.Min, *def.Max)
+		}
+	}
+	return nil
+}
+
+// checkCustomerAPI warns if the Customer API host cannot be reached. Any HTTP
+// response counts as reachable, since the endpoint may reject HEAD requests.
+func checkCustomerAPI(ctx context.Context, client *http.Client, cfg *config.Config) (string, string) {
+	if cfg == nil || cfg.CustomerAPI.URL == "" {
+		return StatusWarn, "no Customer API URL configured"
+	}
+	if client == nil {
+		client = &http.Client{Timeout: customerAPITimeout}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, cfg.CustomerAPI.URL, nil)
+	if err != nil {
+		return StatusWarn, err.Error()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return StatusWarn, err.Error()
+	}
+	resp.Body.Close()
+	return StatusPass, fmt.Sprintf("HTTP %d", resp.StatusCode)
+}
+
+// logReport logs one line per check and a summary
+func logReport(ctx context.Context, report *Report) {
+	counts := make(map[string]int)
+	for _, result := range report.Results {
+		counts[result.Status]++
+		args := []interface{}{
+			"check", result.Name,
+			"status", result.Status,
+			"duration_ms", result.Duration.Milliseconds(),
+		}
+		if result.Message != "" {
+			args = append(args, "message", result.Message)
+		}
+
+		switch result.Status {
+		case StatusFail:
+			logger.Error(ctx, "Self-test check failed", args...)
+		case StatusWarn:
+			logger.Warn(ctx, "Self-test check warning", args...)
+		default:
+			logger.Info(ctx, "Self-test check", args...)
+		}
+	}
+
+	logger.Info(ctx, "Self-test completed",
+		"passed", counts[StatusPass],
+		"failed", counts[StatusFail],
+		"warnings", counts[StatusWarn],
+		"skipped", counts[StatusSkip])
+}
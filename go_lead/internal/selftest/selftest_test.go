@@ -0,0 +1,124 @@
+package selftest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/logger"
+)
+
+func init() {
+	logger.Init()
+}
+
+func testConfig(apiURL string, mapping map[string]config.AttributeDefinition) *config.Config {
+	return &config.Config{
+		CustomerAPI: config.CustomerAPIConfig{
+			URL: apiURL,
+		},
+		AttributeMapping: config.AttributeMappingConfig{
+			Mapping: mapping,
+		},
+	}
+}
+
+func resultByName(t *testing.T, report *Report, name string) Result {
+	t.Helper()
+	for _, result := range report.Results {
+		if result.Name == name {
+			return result
+		}
+	}
+	t.Fatalf("Report has no %s check: %+v", name, report.Results)
+	return Result{}
+}
+
+func TestRun_Passing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("Expected HEAD probe, got %s", r.Method)
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer server.Close()
+
+	cfg := testConfig(server.URL, map[string]config.AttributeDefinition{
+		"phone":     {Type: "text", Required: true},
+		"roof_type": {Type: "dropdown", Options: []string{"flat", "pitched"}},
+	})
+
+	report, err := Run(context.Background(), Deps{Config: cfg})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if report.Failed() {
+		t.Errorf("Expected report to pass, got %+v", report.Results)
+	}
+
+	if got := resultByName(t, report, CheckAttributeMapping).Status; got != StatusPass {
+		t.Errorf("Expected mapping check to pass, got %s", got)
+	}
+	// Any HTTP response, even 405, proves the Customer API is reachable
+	if got := resultByName(t, report, CheckCustomerAPI).Status; got != StatusPass {
+		t.Errorf("Expected Customer API check to pass, got %s", got)
+	}
+	for _, name := range []string{CheckDatabase, CheckMigrations, CheckQueueTable} {
+		if got := resultByName(t, report, name).Status; got != StatusSkip {
+			t.Errorf("Expected %s check to be skipped without a database, got %s", name, got)
+		}
+	}
+}
+
+func TestRun_BadMappingFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		mapping map[string]config.AttributeDefinition
+	}{
+		{"empty mapping", nil},
+		{"unknown type", map[string]config.AttributeDefinition{"phone": {Type: "textarea"}}},
+		{"dropdown without options", map[string]config.AttributeDefinition{"roof_type": {Type: "dropdown"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Run(context.Background(), Deps{Config: testConfig(server.URL, tt.mapping)})
+			if err == nil {
+				t.Fatal("Expected Run() to fail on a bad mapping")
+			}
+			if !report.Failed() {
+				t.Error("Expected report to be marked as failed")
+			}
+			if got := resultByName(t, report, CheckAttributeMapping).Status; got != StatusFail {
+				t.Errorf("Expected mapping check to fail, got %s", got)
+			}
+			// Later checks still run so the report is complete
+			if got := resultByName(t, report, CheckCustomerAPI).Status; got != StatusPass {
+				t.Errorf("Expected Customer API check to pass, got %s", got)
+			}
+		})
+	}
+}
+
+func TestRun_UnreachableCustomerAPIWarns(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	apiURL := server.URL
+	server.Close()
+
+	cfg := testConfig(apiURL, map[string]config.AttributeDefinition{
+		"phone": {Type: "text", Required: true},
+	})
+
+	report, err := Run(context.Background(), Deps{Config: cfg})
+	if err != nil {
+		t.Fatalf("Expected an unreachable Customer API not to fail the self-test, got %v", err)
+	}
+	if got := resultByName(t, report, CheckCustomerAPI).Status; got != StatusWarn {
+		t.Errorf("Expected Customer API check to warn, got %s", got)
+	}
+}
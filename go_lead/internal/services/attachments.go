@@ -0,0 +1,50 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// ExtractAttachments removes the base64 attachment attributes from a lead payload as
+// received, so the attachment bytes are never stored, and returns the metadata of the valid
+// attachments keyed by attribute, or nil if there are none. Invalid optional attachments are
+// dropped; an invalid required attachment is returned as error. The rules of the mapping
+// profile that applies to the lead are used; payload keys must match the attribute names.
+func ExtractAttachments(mapping config.AttributeMappingConfig, sourceID string, payload models.JSONB) (models.JSONB, error) {
+	attributeMapping := mapping.Mapping
+	if profile := profileFor(mapping.Profiles, sourceID, payload); profile != "" {
+		attributeMapping = mapping.Profiles[profile]
+	}
+
+	var metadata models.JSONB
+	var invalidRequired []string
+	for key, def := range attributeMapping {
+		value, ok := payload[key]
+		if !ok || def.Type != "base64" {
+			continue
+		}
+		delete(payload, key)
+
+		valid, summary := summarizeAttachment(key, value, def)
+		if !valid {
+			if def.Required {
+				invalidRequired = append(invalidRequired, key)
+			}
+			continue
+		}
+		if metadata == nil {
+			metadata = make(models.JSONB)
+		}
+		metadata[key] = summary
+	}
+
+	if len(invalidRequired) > 0 {
+		sort.Strings(invalidRequired)
+		return nil, fmt.Errorf("invalid required attachment: %s", strings.Join(invalidRequired, ", "))
+	}
+	return metadata, nil
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+func TestExtractAttachments(t *testing.T) {
+	png := base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\n0000"))
+	mapping := config.AttributeMappingConfig{
+		Mapping: map[string]config.AttributeDefinition{
+			"photo": {Type: "base64", MaxDecodedBytes: 8},
+		},
+		Profiles: map[string]map[string]config.AttributeDefinition{
+			"solar": {"roof_photo": {Type: "base64", Required: true}},
+		},
+	}
+
+	// Attachments of the default mapping; oversized optional ones are dropped
+	payload := models.JSONB{"phone": "+49151", "photo": png, "roof_photo": png}
+	metadata, err := ExtractAttachments(mapping, "", payload)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if metadata != nil {
+		t.Errorf("Expected no metadata for the oversized photo, got %v", metadata)
+	}
+	if !reflect.DeepEqual(payload, models.JSONB{"phone": "+49151", "roof_photo": png}) {
+		t.Errorf("Expected only the base64 attribute of the default mapping to be removed, got %v", payload)
+	}
+
+	// The profile of the source applies
+	payload = models.JSONB{"phone": "+49151", "roof_photo": png}
+	metadata, err = ExtractAttachments(mapping, "solar", payload)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := models.JSONB{"roof_photo": map[string]interface{}{"size_bytes": 12, "mime_type": "image/png"}}
+	if !reflect.DeepEqual(metadata, expected) {
+		t.Errorf("Expected metadata %v, got %v", expected, metadata)
+	}
+	if _, ok := payload["roof_photo"]; ok {
+		t.Error("Expected the attachment to be removed from the payload")
+	}
+
+	// An invalid required attachment is an error
+	if _, err := ExtractAttachments(mapping, "solar", models.JSONB{"roof_photo": "not base64!"}); err == nil {
+		t.Error("Expected an invalid required attachment to be rejected")
+	}
+}
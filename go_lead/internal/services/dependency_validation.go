@@ -0,0 +1,61 @@
+package services
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// DependencyValidationStage enforces cross-field rules of the form
+// "if field A is present, fields B... must be present too"
+type DependencyValidationStage struct {
+	rules []config.FieldDependencyRule
+}
+
+// NewDependencyValidationStage creates a new DependencyValidationStage for the given rules
+func NewDependencyValidationStage(rules []config.FieldDependencyRule) *DependencyValidationStage {
+	return &DependencyValidationStage{
+		rules: rules,
+	}
+}
+
+// Validate checks every rule whose triggering field is present in the payload.
+// A field set to null counts as absent, both as trigger and as dependency.
+func (s *DependencyValidationStage) Validate(payload models.JSONB) *ValidationResult {
+	result := &ValidationResult{
+		Valid:  true,
+		Errors: []string{},
+	}
+
+	for _, rule := range s.rules {
+		if !fieldPresent(payload, rule.IfPresent) {
+			continue
+		}
+
+		for _, required := range rule.ThenRequired {
+			if fieldPresent(payload, required) {
+				continue
+			}
+
+			log.Printf("[VALIDATION] Field '%s' requires '%s', which is missing", rule.IfPresent, required)
+			result.Valid = false
+			reason := models.RejectionReasonMissingDependentField
+			result.RejectionReason = &reason
+			result.Errors = append(result.Errors, fmt.Sprintf("%s is required when %s is present", required, rule.IfPresent))
+			result.Context = map[string]string{
+				"field":      required,
+				"depends_on": rule.IfPresent,
+			}
+			return result // Return immediately on first failure
+		}
+	}
+
+	return result
+}
+
+// fieldPresent reports whether the dot-separated path resolves to a non-null value
+func fieldPresent(payload models.JSONB, path string) bool {
+	return fieldValue(payload, path) != nil
+}
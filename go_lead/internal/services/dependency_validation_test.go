@@ -0,0 +1,151 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+var solarRules = []config.FieldDependencyRule{
+	{IfPresent: "house.solar_panel_type", ThenRequired: []string{"house.roof_area"}},
+}
+
+var chainedRules = []config.FieldDependencyRule{
+	{IfPresent: "house.solar_panel_type", ThenRequired: []string{"house.roof_area"}},
+	{IfPresent: "house.roof_area", ThenRequired: []string{"house.roof_orientation"}},
+}
+
+func TestDependencyValidation_DependentFieldPresent(t *testing.T) {
+	stage := NewDependencyValidationStage(solarRules)
+
+	payload := models.JSONB{
+		"house": map[string]interface{}{
+			"solar_panel_type": "mono",
+			"roof_area":        42.0,
+		},
+	}
+
+	if result := stage.Validate(payload); !result.Valid {
+		t.Errorf("Expected validation to pass, got errors %v", result.Errors)
+	}
+}
+
+func TestDependencyValidation_DependentFieldMissing(t *testing.T) {
+	stage := NewDependencyValidationStage(solarRules)
+
+	payload := models.JSONB{
+		"house": map[string]interface{}{
+			"solar_panel_type": "mono",
+		},
+	}
+
+	result := stage.Validate(payload)
+
+	if result.Valid {
+		t.Fatal("Expected validation to fail when house.roof_area is missing")
+	}
+	if result.RejectionReason == nil || *result.RejectionReason != models.RejectionReasonMissingDependentField {
+		t.Errorf("Expected rejection reason MISSING_DEPENDENT_FIELD, got %v", result.RejectionReason)
+	}
+	if result.Context["field"] != "house.roof_area" || result.Context["depends_on"] != "house.solar_panel_type" {
+		t.Errorf("Unexpected rejection context: %v", result.Context)
+	}
+}
+
+func TestDependencyValidation_DependentFieldNull(t *testing.T) {
+	stage := NewDependencyValidationStage(solarRules)
+
+	payload := models.JSONB{
+		"house": map[string]interface{}{
+			"solar_panel_type": "mono",
+			"roof_area":        nil,
+		},
+	}
+
+	if result := stage.Validate(payload); result.Valid {
+		t.Error("Expected validation to fail when house.roof_area is null")
+	}
+}
+
+func TestDependencyValidation_TriggerAbsent(t *testing.T) {
+	stage := NewDependencyValidationStage(solarRules)
+
+	payloads := []models.JSONB{
+		{"house": map[string]interface{}{"is_owner": true}},
+		{"house": map[string]interface{}{"solar_panel_type": nil}},
+		{"zipcode": "66123"},
+	}
+
+	for _, payload := range payloads {
+		if result := stage.Validate(payload); !result.Valid {
+			t.Errorf("Expected no dependency check for payload %v, got errors %v", payload, result.Errors)
+		}
+	}
+}
+
+func TestDependencyValidation_ChainedDependencies(t *testing.T) {
+	stage := NewDependencyValidationStage(chainedRules)
+
+	tests := []struct {
+		name          string
+		house         map[string]interface{}
+		expectValid   bool
+		expectedField string
+	}{
+		{
+			name:        "full chain present",
+			house:       map[string]interface{}{"solar_panel_type": "mono", "roof_area": 42.0, "roof_orientation": "south"},
+			expectValid: true,
+		},
+		{
+			name:          "first link missing",
+			house:         map[string]interface{}{"solar_panel_type": "mono"},
+			expectedField: "house.roof_area",
+		},
+		{
+			name:          "second link missing",
+			house:         map[string]interface{}{"solar_panel_type": "mono", "roof_area": 42.0},
+			expectedField: "house.roof_orientation",
+		},
+		{
+			name:          "chain starts in the middle",
+			house:         map[string]interface{}{"roof_area": 42.0},
+			expectedField: "house.roof_orientation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := stage.Validate(models.JSONB{"house": tt.house})
+
+			if result.Valid != tt.expectValid {
+				t.Fatalf("Expected valid=%v, got %v (%v)", tt.expectValid, result.Valid, result.Errors)
+			}
+			if !tt.expectValid && result.Context["field"] != tt.expectedField {
+				t.Errorf("Expected missing field %s, got %s", tt.expectedField, result.Context["field"])
+			}
+		})
+	}
+}
+
+func TestValidateLead_DependencyRulesRunAfterCoreRules(t *testing.T) {
+	validator := NewValidatorWithDependencyRules(solarRules)
+
+	payload := models.JSONB{
+		"zipcode": "66123",
+		"house": map[string]interface{}{
+			"is_owner":         true,
+			"solar_panel_type": "mono",
+		},
+	}
+
+	result := validator.ValidateLead(payload)
+
+	if result.Valid {
+		t.Fatal("Expected validation to fail on missing dependent field")
+	}
+	if *result.RejectionReason != models.RejectionReasonMissingDependentField {
+		t.Errorf("Expected rejection reason MISSING_DEPENDENT_FIELD, got %s", *result.RejectionReason)
+	}
+}
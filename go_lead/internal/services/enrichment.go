@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// Enricher derives additional fields from a normalized lead payload.
+// Enrichers run between normalization and mapping.
+type Enricher interface {
+	Enrich(ctx context.Context, payload models.JSONB) (models.JSONB, error)
+}
+
+// NoopEnricher returns the payload unchanged
+type NoopEnricher struct{}
+
+// Enrich returns the payload unchanged
+func (NoopEnricher) Enrich(ctx context.Context, payload models.JSONB) (models.JSONB, error) {
+	return payload, nil
+}
+
+// EnrichmentChain runs enrichers in order, passing each one the output of the previous
+type EnrichmentChain struct {
+	enrichers   []Enricher
+	failOnError bool
+}
+
+// NewEnrichmentChain creates a new EnrichmentChain.
+// With failOnError unset, a failing enricher is logged and skipped.
+func NewEnrichmentChain(failOnError bool, enrichers ...Enricher) *EnrichmentChain {
+	return &EnrichmentChain{
+		enrichers:   enrichers,
+		failOnError: failOnError,
+	}
+}
+
+// NewEnrichmentChainFromConfig builds the chain from the configured enricher names
+func NewEnrichmentChainFromConfig(cfg config.EnrichmentConfig) (*EnrichmentChain, error) {
+	enrichers := make([]Enricher, 0, len(cfg.Enrichers))
+	for _, name := range cfg.Enrichers {
+		switch name {
+		case "region":
+			enrichers = append(enrichers, NewRegionEnricher(nil))
+		default:
+			return nil, fmt.Errorf("unknown enricher: %s", name)
+		}
+	}
+	return NewEnrichmentChain(cfg.FailOnError, enrichers...), nil
+}
+
+// Enrich runs every enricher in the chain
+func (c *EnrichmentChain) Enrich(ctx context.Context, payload models.JSONB) (models.JSONB, error) {
+	for i, enricher := range c.enrichers {
+		enriched, err := enricher.Enrich(ctx, payload)
+		if err != nil {
+			if c.failOnError {
+				return nil, fmt.Errorf("enricher %d (%T) failed: %w", i, enricher, err)
+			}
+			logger.LogError(ctx, "Enricher failed, continuing", err,
+				"enricher_index", i, "enricher", fmt.Sprintf("%T", enricher))
+			continue
+		}
+		payload = enriched
+	}
+	return payload, nil
+}
+
+// DefaultZipRegions maps the leading digit of a German zip code to its postal region
+var DefaultZipRegions = map[string]string{
+	"0": "Sachsen",
+	"1": "Berlin/Brandenburg",
+	"2": "Hamburg/Schleswig-Holstein",
+	"3": "Niedersachsen",
+	"4": "Nordrhein-Westfalen Nord",
+	"5": "Nordrhein-Westfalen Süd",
+	"6": "Hessen/Saarland",
+	"7": "Baden-Württemberg",
+	"8": "Bayern Süd",
+	"9": "Bayern Nord/Thüringen",
+}
+
+// RegionEnricher sets "region" from the longest matching prefix of "zipcode"
+type RegionEnricher struct {
+	regions map[string]string
+}
+
+// NewRegionEnricher creates a new RegionEnricher.
+// A nil regions map uses DefaultZipRegions.
+func NewRegionEnricher(regions map[string]string) *RegionEnricher {
+	if regions == nil {
+		regions = DefaultZipRegions
+	}
+	return &RegionEnricher{
+		regions: regions,
+	}
+}
+
+// Enrich adds the region for the lead's zipcode. Leads without a known prefix are left unchanged.
+func (e *RegionEnricher) Enrich(ctx context.Context, payload models.JSONB) (models.JSONB, error) {
+	zipcode, ok := payload["zipcode"].(string)
+	if !ok || zipcode == "" {
+		return payload, nil
+	}
+
+	region := ""
+	longest := 0
+	for prefix, name := range e.regions {
+		if len(prefix) > longest && strings.HasPrefix(zipcode, prefix) {
+			region = name
+			longest = len(prefix)
+		}
+	}
+	if region == "" {
+		return payload, nil
+	}
+
+	enriched := make(models.JSONB, len(payload)+1)
+	for key, value := range payload {
+		enriched[key] = value
+	}
+	enriched["region"] = region
+	return enriched, nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// appendEnricher appends its name to the "trace" field to record execution order
+type appendEnricher struct {
+	name string
+	err  error
+}
+
+func (e appendEnricher) Enrich(ctx context.Context, payload models.JSONB) (models.JSONB, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	trace, _ := payload["trace"].(string)
+	payload["trace"] = trace + e.name
+	return payload, nil
+}
+
+func TestRegionEnricher_DerivesRegionFromZipPrefix(t *testing.T) {
+	enricher := NewRegionEnricher(nil)
+
+	result, err := enricher.Enrich(context.Background(), models.JSONB{"zipcode": "66123"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result["region"] != "Hessen/Saarland" {
+		t.Errorf("Expected region Hessen/Saarland, got %v", result["region"])
+	}
+}
+
+func TestRegionEnricher_PrefersLongestPrefix(t *testing.T) {
+	enricher := NewRegionEnricher(map[string]string{
+		"6":   "South West",
+		"661": "Saarbrücken",
+	})
+
+	tests := []struct {
+		zipcode string
+		expect  interface{}
+	}{
+		{"66123", "Saarbrücken"},
+		{"66500", "South West"},
+		{"10115", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.zipcode, func(t *testing.T) {
+			result, err := enricher.Enrich(context.Background(), models.JSONB{"zipcode": tt.zipcode})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result["region"] != tt.expect {
+				t.Errorf("Expected region %v, got %v", tt.expect, result["region"])
+			}
+		})
+	}
+}
+
+func TestRegionEnricher_MissingZipcode(t *testing.T) {
+	enricher := NewRegionEnricher(nil)
+
+	result, err := enricher.Enrich(context.Background(), models.JSONB{"email": "a@example.com"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := result["region"]; ok {
+		t.Error("Expected no region without a zipcode")
+	}
+}
+
+func TestEnrichmentChain_RunsInOrder(t *testing.T) {
+	chain := NewEnrichmentChain(false, appendEnricher{name: "a"}, appendEnricher{name: "b"}, appendEnricher{name: "c"})
+
+	result, err := chain.Enrich(context.Background(), models.JSONB{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result["trace"] != "abc" {
+		t.Errorf("Expected enrichers to run in order abc, got %v", result["trace"])
+	}
+}
+
+func TestEnrichmentChain_SkipsFailingEnricherByDefault(t *testing.T) {
+	chain := NewEnrichmentChain(false, appendEnricher{name: "a"}, appendEnricher{err: errors.New("lookup failed")}, appendEnricher{name: "c"})
+
+	result, err := chain.Enrich(context.Background(), models.JSONB{})
+	if err != nil {
+		t.Fatalf("Expected failing enricher to be skipped, got %v", err)
+	}
+	if result["trace"] != "ac" {
+		t.Errorf("Expected trace ac, got %v", result["trace"])
+	}
+}
+
+func TestEnrichmentChain_FailOnError(t *testing.T) {
+	chain := NewEnrichmentChain(true, appendEnricher{name: "a"}, appendEnricher{err: errors.New("lookup failed")})
+
+	if _, err := chain.Enrich(context.Background(), models.JSONB{}); err == nil {
+		t.Error("Expected chain to fail when failOnError is set")
+	}
+}
+
+func TestNewEnrichmentChainFromConfig_UnknownEnricher(t *testing.T) {
+	if _, err := NewEnrichmentChainFromConfig(config.EnrichmentConfig{Enrichers: []string{"region", "unknown"}}); err == nil {
+		t.Error("Expected error for unknown enricher")
+	}
+}
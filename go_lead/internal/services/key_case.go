@@ -0,0 +1,122 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// CustomerKeyCase rewrites the keys of the customer payload in the case style the
+// Customer API expects. Top-level keys are always converted; nested objects only if
+// configured, and the fields of the reserved product object only if configured as well.
+// Keys with a leading underscore, such as "_flags", keep it.
+type CustomerKeyCase struct {
+	style   string // config.KeyCase* style
+	nested  bool
+	product bool
+	snake   *KeyNormalizer
+}
+
+// NewCustomerKeyCase creates a CustomerKeyCase from the Customer API config. Acronyms such
+// as "ID" are treated as single words when splitting camelCase keys, as in key normalization.
+func NewCustomerKeyCase(cfg config.CustomerAPIConfig, acronyms []string) *CustomerKeyCase {
+	return &CustomerKeyCase{
+		style:   cfg.KeyCase,
+		nested:  cfg.KeyCaseNested,
+		product: cfg.KeyCaseProduct,
+		snake:   NewKeyNormalizer(acronyms),
+	}
+}
+
+// Apply returns the payload with its keys converted, or the payload itself for as_is.
+// If converted keys collide, a key already in the target case wins; among the others
+// the first in sort order wins.
+func (c *CustomerKeyCase) Apply(payload models.JSONB) models.JSONB {
+	if c == nil || c.style == "" || c.style == config.KeyCaseAsIs || payload == nil {
+		return payload
+	}
+	return c.convertObject(payload, true)
+}
+
+// convertObject converts the keys of an object, descending into nested objects if configured
+func (c *CustomerKeyCase) convertObject(object map[string]interface{}, topLevel bool) map[string]interface{} {
+	result := make(map[string]interface{}, len(object))
+	var renamed []string
+	for key, value := range object {
+		if c.convertKey(key) == key {
+			result[key] = c.convertField(key, value, topLevel)
+		} else {
+			renamed = append(renamed, key)
+		}
+	}
+
+	sort.Strings(renamed)
+	for _, key := range renamed {
+		converted := c.convertKey(key)
+		if _, exists := result[converted]; !exists {
+			result[converted] = c.convertField(key, object[key], topLevel)
+		}
+	}
+	return result
+}
+
+// convertField converts the keys inside a field value where configured
+func (c *CustomerKeyCase) convertField(key string, value interface{}, topLevel bool) interface{} {
+	if topLevel && key == "product" {
+		if !c.product {
+			return value
+		}
+		if fields, ok := value.(map[string]interface{}); ok {
+			return c.convertObject(fields, false)
+		}
+		return value
+	}
+	if !c.nested {
+		return value
+	}
+	return c.convertValue(value)
+}
+
+// convertValue converts the keys of objects in a value, including objects inside arrays
+func (c *CustomerKeyCase) convertValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return c.convertObject(v, false)
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = c.convertValue(item)
+		}
+		return converted
+	default:
+		return value
+	}
+}
+
+// convertKey converts a single key, keeping leading underscores
+func (c *CustomerKeyCase) convertKey(key string) string {
+	name := strings.TrimLeft(key, "_")
+	prefix := key[:len(key)-len(name)]
+
+	snake := c.snake.ToSnakeCase(name)
+	if c.style == config.KeyCaseSnake {
+		return prefix + snake
+	}
+	return prefix + toCamelCase(snake)
+}
+
+// toCamelCase converts a snake_case key to lowerCamelCase, e.g. "phone_number" -> "phoneNumber"
+func toCamelCase(key string) string {
+	words := strings.Split(key, "_")
+	var b strings.Builder
+	b.WriteString(words[0])
+	for _, word := range words[1:] {
+		if word == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// mappedPayload is a customer payload as produced by mapping, with mixed key styles
+func mappedPayload() models.JSONB {
+	return models.JSONB{
+		"phone":         "+491234567890",
+		"first_name":    "Jane",
+		"roofType":      "flat",
+		"_flags":        []interface{}{"soft_zip_mismatch"},
+		"house_details": map[string]interface{}{"is_owner": true, "buildYear": 1990},
+		"product":       map[string]interface{}{"name": "solar", "product_line": "home"},
+	}
+}
+
+func TestCustomerKeyCase_Styles(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cfg      config.CustomerAPIConfig
+		expected models.JSONB
+	}{
+		{
+			name: "snake",
+			cfg:  config.CustomerAPIConfig{KeyCase: config.KeyCaseSnake},
+			expected: models.JSONB{
+				"phone":         "+491234567890",
+				"first_name":    "Jane",
+				"roof_type":     "flat",
+				"_flags":        []interface{}{"soft_zip_mismatch"},
+				"house_details": map[string]interface{}{"is_owner": true, "buildYear": 1990},
+				"product":       map[string]interface{}{"name": "solar", "product_line": "home"},
+			},
+		},
+		{
+			name: "camel",
+			cfg:  config.CustomerAPIConfig{KeyCase: config.KeyCaseCamel},
+			expected: models.JSONB{
+				"phone":        "+491234567890",
+				"firstName":    "Jane",
+				"roofType":     "flat",
+				"_flags":       []interface{}{"soft_zip_mismatch"},
+				"houseDetails": map[string]interface{}{"is_owner": true, "buildYear": 1990},
+				"product":      map[string]interface{}{"name": "solar", "product_line": "home"},
+			},
+		},
+		{
+			name: "camel nested",
+			cfg:  config.CustomerAPIConfig{KeyCase: config.KeyCaseCamel, KeyCaseNested: true},
+			expected: models.JSONB{
+				"phone":        "+491234567890",
+				"firstName":    "Jane",
+				"roofType":     "flat",
+				"_flags":       []interface{}{"soft_zip_mismatch"},
+				"houseDetails": map[string]interface{}{"isOwner": true, "buildYear": 1990},
+				"product":      map[string]interface{}{"name": "solar", "product_line": "home"},
+			},
+		},
+		{
+			name: "camel product",
+			cfg:  config.CustomerAPIConfig{KeyCase: config.KeyCaseCamel, KeyCaseProduct: true},
+			expected: models.JSONB{
+				"phone":        "+491234567890",
+				"firstName":    "Jane",
+				"roofType":     "flat",
+				"_flags":       []interface{}{"soft_zip_mismatch"},
+				"houseDetails": map[string]interface{}{"is_owner": true, "buildYear": 1990},
+				"product":      map[string]interface{}{"name": "solar", "productLine": "home"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := NewCustomerKeyCase(tc.cfg, nil).Apply(mappedPayload())
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCustomerKeyCase_SameLogicalPayload(t *testing.T) {
+	// Converting a payload to one style and back yields the same payload in every style
+	for _, style := range []string{config.KeyCaseSnake, config.KeyCaseCamel} {
+		other := config.KeyCaseCamel
+		if style == config.KeyCaseCamel {
+			other = config.KeyCaseSnake
+		}
+		cfg := config.CustomerAPIConfig{KeyCase: style, KeyCaseNested: true, KeyCaseProduct: true}
+		otherCfg := config.CustomerAPIConfig{KeyCase: other, KeyCaseNested: true, KeyCaseProduct: true}
+
+		once := NewCustomerKeyCase(cfg, nil).Apply(mappedPayload())
+		roundTrip := NewCustomerKeyCase(cfg, nil).Apply(NewCustomerKeyCase(otherCfg, nil).Apply(once))
+		if !reflect.DeepEqual(once, roundTrip) {
+			t.Errorf("Expected %s keys to survive a round trip through %s, got %v and %v", style, other, once, roundTrip)
+		}
+	}
+}
+
+func TestCustomerKeyCase_AsIsIsNoOp(t *testing.T) {
+	payload := mappedPayload()
+	before, _ := json.Marshal(payload)
+
+	for _, keyCase := range []*CustomerKeyCase{
+		nil,
+		NewCustomerKeyCase(config.CustomerAPIConfig{}, nil),
+		NewCustomerKeyCase(config.CustomerAPIConfig{KeyCase: config.KeyCaseAsIs, KeyCaseNested: true, KeyCaseProduct: true}, nil),
+	} {
+		got := keyCase.Apply(payload)
+		after, _ := json.Marshal(got)
+		if string(after) != string(before) {
+			t.Errorf("Expected as_is to keep the payload unchanged, got %s", after)
+		}
+	}
+}
+
+func TestCustomerKeyCase_CollidingKeys(t *testing.T) {
+	keyCase := NewCustomerKeyCase(config.CustomerAPIConfig{KeyCase: config.KeyCaseCamel}, []string{"ID"})
+	got := keyCase.Apply(models.JSONB{
+		"firstName":  "kept",
+		"first_name": "dropped",
+		"source_id":  "web",
+	})
+
+	expected := models.JSONB{"firstName": "kept", "sourceId": "web"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected the key already in camelCase to win, got %v", got)
+	}
+}
+
+func TestToCamelCase(t *testing.T) {
+	testCases := map[string]string{
+		"phone":          "phone",
+		"phone_number":   "phoneNumber",
+		"address2_line":  "address2Line",
+		"house_is_owner": "houseIsOwner",
+		"trailing_":      "trailing",
+	}
+	for input, expected := range testCases {
+		if got := toCamelCase(input); got != expected {
+			t.Errorf("toCamelCase(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var (
+	// acronymBoundary splits an uppercase run from a following word, e.g. "USAOwner" -> "USA_Owner"
+	acronymBoundary = regexp.MustCompile(`([A-Z]+)([A-Z][a-z])`)
+
+	// wordBoundary splits a lowercase letter or digit from a following capital, e.g. "isOwner" -> "is_Owner"
+	wordBoundary = regexp.MustCompile(`([a-z\d])([A-Z])`)
+)
+
+// KeyNormalizer converts camelCase payload keys to the snake_case keys the
+// validation and mapping rules expect, e.g. "phoneNumber" -> "phone_number"
+type KeyNormalizer struct {
+	// acronyms are kept as one word even where the boundaries are ambiguous, longest first
+	acronyms []string
+}
+
+// NewKeyNormalizer creates a KeyNormalizer. Acronyms such as "ID" or "URL" are
+// treated as single words, so "userIDs" becomes "user_ids" instead of "user_i_ds".
+func NewKeyNormalizer(acronyms []string) *KeyNormalizer {
+	k := &KeyNormalizer{}
+	for _, acronym := range acronyms {
+		if acronym = strings.ToUpper(strings.TrimSpace(acronym)); acronym != "" {
+			k.acronyms = append(k.acronyms, acronym)
+		}
+	}
+	// Longer acronyms first, so "HTTPS" is not split by "HTTP"
+	sort.SliceStable(k.acronyms, func(i, j int) bool {
+		return len(k.acronyms[i]) > len(k.acronyms[j])
+	})
+	return k
+}
+
+// ToSnakeCase converts a single key to snake_case.
+// Keys without uppercase letters are returned unchanged.
+func (k *KeyNormalizer) ToSnakeCase(key string) string {
+	if strings.IndexFunc(key, unicode.IsUpper) < 0 {
+		return key
+	}
+
+	// Title-case listed acronyms so the boundaries below treat them as one word
+	for _, acronym := range k.acronyms {
+		key = strings.ReplaceAll(key, acronym, acronym[:1]+strings.ToLower(acronym[1:]))
+	}
+
+	key = acronymBoundary.ReplaceAllString(key, "${1}_${2}")
+	key = wordBoundary.ReplaceAllString(key, "${1}_${2}")
+	return strings.ToLower(key)
+}
+
+// NormalizeKeys returns a copy of the payload with all object keys converted to
+// snake_case, including those of nested objects and objects inside arrays.
+// If a converted key collides with a key that is already snake_case, the
+// snake_case key wins; among colliding converted keys the first in sort order wins.
+func (k *KeyNormalizer) NormalizeKeys(payload map[string]interface{}) map[string]interface{} {
+	if payload == nil {
+		return nil
+	}
+
+	result := make(map[string]interface{}, len(payload))
+	var camelKeys []string
+	for key, value := range payload {
+		if k.ToSnakeCase(key) == key {
+			result[key] = k.normalizeValue(value)
+		} else {
+			camelKeys = append(camelKeys, key)
+		}
+	}
+
+	sort.Strings(camelKeys)
+	for _, key := range camelKeys {
+		snake := k.ToSnakeCase(key)
+		if _, exists := result[snake]; !exists {
+			result[snake] = k.normalizeValue(payload[key])
+		}
+	}
+	return result
+}
+
+// normalizeValue converts the keys of objects nested in a value
+func (k *KeyNormalizer) normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return k.NormalizeKeys(v)
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, item := range v {
+			normalized[i] = k.normalizeValue(item)
+		}
+		return normalized
+	default:
+		return value
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+func TestKeyNormalizer_ToSnakeCase(t *testing.T) {
+	testCases := []struct {
+		name     string
+		acronyms []string
+		input    string
+		expected string
+	}{
+		// Simple camelCase
+		{"two words", nil, "emailAddress", "email_address"},
+		{"three words", nil, "houseIsOwner", "house_is_owner"},
+		{"pascal case", nil, "PhoneNumber", "phone_number"},
+		{"digit before capital", nil, "address2Line", "address2_line"},
+		{"single word", nil, "phone", "phone"},
+		// Already snake_case
+		{"snake case", nil, "phone_number", "phone_number"},
+		{"snake case with digits", nil, "line_2", "line_2"},
+		{"mixed", nil, "house_isOwner", "house_is_owner"},
+		// Acronyms
+		{"acronym before word", nil, "isUSAOwner", "is_usa_owner"},
+		{"trailing acronym", nil, "leadID", "lead_id"},
+		{"plural acronym unlisted", nil, "userIDs", "user_i_ds"},
+		{"plural acronym listed", []string{"ID"}, "userIDs", "user_ids"},
+		{"adjacent acronyms unlisted", nil, "sourceHTTPSURL", "source_httpsurl"},
+		{"adjacent acronyms listed", []string{"URL", "HTTPS"}, "sourceHTTPSURL", "source_https_url"},
+		{"listed acronym before word", []string{"usa"}, "isUSAOwner", "is_usa_owner"},
+		{"longer acronym preferred", []string{"HTTP", "HTTPS"}, "HTTPSEnabled", "https_enabled"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := NewKeyNormalizer(tc.acronyms).ToSnakeCase(tc.input)
+			if result != tc.expected {
+				t.Errorf("ToSnakeCase(%q) = %q, expected %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestKeyNormalizer_NormalizeKeysNested(t *testing.T) {
+	input := map[string]interface{}{
+		"phoneNumber": "0151",
+		"zipcode":     "66123",
+		"house": map[string]interface{}{
+			"isOwner": true,
+			"roofDetails": map[string]interface{}{
+				"roofType": "flat",
+				"solarPanels": []interface{}{
+					map[string]interface{}{"panelID": "a1"},
+					"plain value",
+				},
+			},
+		},
+	}
+
+	expected := map[string]interface{}{
+		"phone_number": "0151",
+		"zipcode":      "66123",
+		"house": map[string]interface{}{
+			"is_owner": true,
+			"roof_details": map[string]interface{}{
+				"roof_type": "flat",
+				"solar_panels": []interface{}{
+					map[string]interface{}{"panel_id": "a1"},
+					"plain value",
+				},
+			},
+		},
+	}
+
+	result := NewKeyNormalizer([]string{"ID"}).NormalizeKeys(input)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("NormalizeKeys() = %v, expected %v", result, expected)
+	}
+
+	// The input is not modified
+	if _, ok := input["phoneNumber"]; !ok {
+		t.Error("Expected input payload to keep its original keys")
+	}
+}
+
+func TestKeyNormalizer_SnakeCaseKeyWinsCollision(t *testing.T) {
+	input := map[string]interface{}{
+		"phoneNumber":  "camel",
+		"phone_number": "snake",
+	}
+
+	result := NewKeyNormalizer(nil).NormalizeKeys(input)
+	if len(result) != 1 || result["phone_number"] != "snake" {
+		t.Errorf("Expected the snake_case key to win, got %v", result)
+	}
+}
+
+func TestNormalizeLeadWithFieldMapping_NormalizeKeys(t *testing.T) {
+	payload := models.JSONB{
+		"emailAddress": " Test@Example.com ",
+		"phone":        "+49 151 1234567",
+		"house":        map[string]interface{}{"isOwner": "yes"},
+	}
+
+	disabled := NewNormalizerFromConfig(config.NormalizerConfig{})
+	if result := disabled.NormalizeLeadWithFieldMapping(payload); result["emailAddress"] == nil {
+		t.Errorf("Expected keys to be kept when key normalization is disabled, got %v", result)
+	}
+
+	enabled := NewNormalizerFromConfig(config.NormalizerConfig{
+		NormalizeKeys: true,
+		ValueAliases: map[string]map[string]interface{}{
+			"house.is_owner": {"yes": true},
+		},
+	})
+	result := enabled.NormalizeLeadWithFieldMapping(payload)
+	if result["email_address"] != "Test@Example.com" {
+		t.Errorf("Expected email_address to be normalized, got %v", result)
+	}
+	house, _ := result["house"].(map[string]interface{})
+	if house["is_owner"] != true {
+		t.Errorf("Expected aliases to apply to the normalized key, got %v", result["house"])
+	}
+}
@@ -1,27 +1,74 @@
 package services
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"context"
 	"fmt"
-	"log"
+	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/logger"
 	"github.com/checkfox/go_lead/internal/models"
 )
 
+// mappingLogCtx is the context of mapping log messages, as the Mapper API takes none
+var mappingLogCtx = context.Background()
+
+// defaultDateFormat is the layout of date attributes without a configured format
+const defaultDateFormat = "2006-01-02"
+
+var (
+	// emailPattern matches the dot-atom form of an RFC 5322 address with a DNS domain
+	emailPattern = regexp.MustCompile(`^[A-Za-z0-9!#$%&'*+/=?^_\x60{|}~-]+(\.[A-Za-z0-9!#$%&'*+/=?^_\x60{|}~-]+)*@([A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?\.)+[A-Za-z]{2,}$`)
+	// e164Pattern matches international numbers such as +4915112345678
+	e164Pattern = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+	// nationalPhonePattern matches national numbers with a trunk prefix such as 015112345678
+	nationalPhonePattern = regexp.MustCompile(`^0\d{6,14}$`)
+	// phoneSeparators are stripped before a phone number is checked
+	phoneSeparators = strings.NewReplacer(" ", "", "-", "", "/", "", "(", "", ")", "", ".", "")
+)
+
 // MappingResult represents the outcome of mapping a lead to customer format
 type MappingResult struct {
 	Success           bool
 	CustomerPayload   models.JSONB
 	OmittedAttributes []string
 	Errors            []string
+	
+	// Attachments holds the metadata of the valid base64 attachments in the customer payload,
+	// and AttachmentKeys every base64 attachment attribute of the lead, valid or not
+	Attachments    models.JSONB
+	AttachmentKeys []string
+
+	// FallbackSources names, per attribute, the fallback field its value was taken from
+	FallbackSources map[string]string
 }
 
 // Mapper provides lead mapping functionality with permissive attribute handling
 type Mapper struct {
 	attributeMapping map[string]config.AttributeDefinition
+	profiles         map[string]map[string]config.AttributeDefinition // additional mappings by profile name
 	productName      string
+	allowedFields    map[string]bool // nil when every field may be delivered
+	productConflict  string          // config.ProductConflict* action for a payload product field
+	logObfuscator    *logger.LogObfuscator
+	verboseLogs      *logger.Sampler // samples the logs of successful per-attribute decisions
+	truncateLong     bool // cut text attributes exceeding max_length instead of rejecting them
+	maxFieldBytes    int  // size limit of string values without their own max_value_bytes (0 = no limit)
+	strictUnknown    string // config.StrictUnknown* handling of fields without a mapping rule
+	normalizer       *Normalizer // normalizes fallback values for the attribute they fill
+
+	omissionsMu      sync.Mutex
+	omissions        map[string]int64 // invalid optional attributes omitted, per mapped attribute key
+	unknownOmissions int64            // attributes without a mapping rule omitted, in total
 }
 
 // NewMapper creates a new Mapper instance
@@ -31,39 +78,201 @@ func NewMapper(cfg *config.Config) *Mapper {
 		productName = "default_product"
 	}
 	
+	var allowedFields map[string]bool
+	if len(cfg.CustomerAPI.AllowedPayloadFields) > 0 {
+		allowedFields = make(map[string]bool, len(cfg.CustomerAPI.AllowedPayloadFields))
+		for _, field := range cfg.CustomerAPI.AllowedPayloadFields {
+			allowedFields[field] = true
+		}
+	}
+	
 	return &Mapper{
 		attributeMapping: cfg.AttributeMapping.Mapping,
+		profiles:         cfg.AttributeMapping.Profiles,
 		productName:      productName,
+		allowedFields:    allowedFields,
+		productConflict:  cfg.CustomerAPI.ProductConflictAction,
+		logObfuscator:    logger.NewLogObfuscator(cfg.Privacy.ObfuscatedFields),
+		verboseLogs:      logger.NewSampler(cfg.Logging.MappingSample.Every, cfg.Logging.MappingSample.PerSecond),
+		truncateLong:     cfg.Validation.LengthExceedAction == config.LengthExceedTruncate,
+		maxFieldBytes:    cfg.AttributeMapping.DefaultMaxFieldBytes,
+		strictUnknown:    cfg.AttributeMapping.StrictUnknown,
+		normalizer:       NewNormalizerFromConfig(cfg.Normalizer),
+		omissions:        make(map[string]int64),
+	}
+}
+
+// OmissionStats returns a snapshot of how often each optional attribute was omitted
+// as invalid across all leads mapped by this Mapper. Attributes without a mapping rule
+// are counted by UnknownOmissions instead, as their keys are chosen by the sender.
+func (m *Mapper) OmissionStats() map[string]int64 {
+	m.omissionsMu.Lock()
+	defer m.omissionsMu.Unlock()
+
+	stats := make(map[string]int64, len(m.omissions))
+	for key, count := range m.omissions {
+		stats[key] = count
+	}
+	return stats
+}
+
+// UnknownOmissions returns how often an attribute without a mapping rule was omitted
+// across all leads mapped by this Mapper
+func (m *Mapper) UnknownOmissions() int64 {
+	m.omissionsMu.Lock()
+	defer m.omissionsMu.Unlock()
+	return m.unknownOmissions
+}
+
+// recordOmissions adds the omitted attributes of a single lead to the tally. Attributes
+// without a rule in attributeMapping are only counted in total.
+func (m *Mapper) recordOmissions(keys []string, attributeMapping map[string]config.AttributeDefinition) {
+	if len(keys) == 0 {
+		return
+	}
+
+	m.omissionsMu.Lock()
+	defer m.omissionsMu.Unlock()
+
+	for _, key := range keys {
+		if _, mapped := attributeMapping[key]; mapped {
+			m.omissions[key]++
+		} else {
+			m.unknownOmissions++
+		}
+	}
+}
+
+// logVerbose logs a successful per-attribute decision if the MAPPING_LOG_SAMPLE sampler
+// lets it through. Failures and omissions are logged as warnings and never sampled.
+func (m *Mapper) logVerbose(msg string, args ...any) {
+	if m.verboseLogs.Allow() {
+		logger.Info(mappingLogCtx, msg, args...)
+	}
+}
+
+// AllowsField reports whether a top-level field may reach the Customer API under the
+// CUSTOMER_API_ALLOWED_FIELDS whitelist
+func (m *Mapper) AllowsField(key string) bool {
+	return m.allowedFields == nil || m.allowedFields[key]
+}
+
+// ProfileFor returns the mapping profile for a lead: the profile named after its source ID,
+// else the one named after the product in its payload, else "" for the default mapping
+func (m *Mapper) ProfileFor(sourceID string, payload models.JSONB) string {
+	return profileFor(m.profiles, sourceID, payload)
+}
+
+// profileFor returns the name of the profile in profiles that applies to a lead, or ""
+func profileFor(profiles map[string]map[string]config.AttributeDefinition, sourceID string, payload models.JSONB) string {
+	if _, ok := profiles[sourceID]; ok && sourceID != "" {
+		return sourceID
+	}
+	
+	product := payload.ProductName()
+	if _, ok := profiles[product]; ok && product != "" {
+		return product
+	}
+	
+	return ""
+}
+
+// mapProduct builds the product object from the configured product name and, depending
+// on the product conflict action, the product field of the payload (nil when absent)
+func (m *Mapper) mapProduct(payloadProduct interface{}) (map[string]interface{}, error) {
+	product := map[string]interface{}{
+		"name": m.productName,
+	}
+	if payloadProduct == nil {
+		return product, nil
+	}
+	if m.productConflict != config.ProductConflictMerge && m.productConflict != config.ProductConflictReject {
+		logger.Info(mappingLogCtx, "Ignoring product field of the payload")
+		return product, nil
+	}
+
+	var payloadName interface{}
+	fields, isObject := payloadProduct.(map[string]interface{})
+	if isObject {
+		payloadName = fields["name"]
+	} else {
+		payloadName = payloadProduct
+	}
+	if payloadName != nil && payloadName != m.productName {
+		if m.productConflict == config.ProductConflictReject {
+			return nil, fmt.Errorf("payload product name %v conflicts with configured product name %s", payloadName, m.productName)
+		}
+		logger.Warn(mappingLogCtx, "Payload product name conflicts with configured product name, keeping the configured name",
+			"payload_product", payloadName, "product", m.productName)
+	}
+
+	for key, value := range fields {
+		if key != "name" {
+			product[key] = value
+		}
 	}
+	return product, nil
 }
 
-// MapToCustomerFormat maps a normalized lead payload to customer format
+// MapToCustomerFormat maps a normalized lead payload to customer format using the default mapping
 // Requirements: 3.1, 3.2, 3.5, 3.6, 3.8
 func (m *Mapper) MapToCustomerFormat(normalizedPayload models.JSONB) *MappingResult {
+	return m.MapToCustomerFormatWithProfile(normalizedPayload, "")
+}
+
+// MapToCustomerFormatWithProfile maps a normalized lead payload to customer format using the
+// attribute rules of the given profile. Unknown or empty profiles use the default mapping.
+func (m *Mapper) MapToCustomerFormatWithProfile(normalizedPayload models.JSONB, profile string) *MappingResult {
+	attributeMapping := m.attributeMapping
+	if profile != "" {
+		if profileMapping, ok := m.profiles[profile]; ok {
+			attributeMapping = profileMapping
+			logger.Info(mappingLogCtx, "Using mapping profile", "profile", profile)
+		} else {
+			logger.Warn(mappingLogCtx, "Unknown mapping profile, using default mapping", "profile", profile)
+		}
+	}
+	
 	result := &MappingResult{
 		Success:           true,
 		CustomerPayload:   make(models.JSONB),
 		OmittedAttributes: []string{},
 		Errors:            []string{},
+		Attachments:       make(models.JSONB),
 	}
 	
+	// Fill missing attributes from their fallback fields before anything is declared missing
+	normalizedPayload, result.FallbackSources = m.applyFallbacks(normalizedPayload, attributeMapping)
+	
 	// Validate and set required Core Customer Fields
 	// Requirement 3.5: phone is required
 	phone, phoneOk := normalizedPayload["phone"]
 	if !phoneOk || phone == nil || phone == "" {
 		result.Success = false
 		result.Errors = append(result.Errors, "missing required field: phone")
-		log.Printf("[MAPPING] Missing required Core Customer Field: phone")
+		logger.Warn(mappingLogCtx, "Missing required Core Customer Field", "field", "phone")
+		return result
+	}
+	if size, limit, exceeded := m.exceedsMaxBytes(phone, attributeMapping["phone"]); exceeded {
+		result.Success = false
+		result.Errors = append(result.Errors, fmt.Sprintf("required field phone exceeds max_value_bytes %d (size %d bytes)", limit, size))
+		logger.Warn(mappingLogCtx, "Required Core Customer Field exceeds max_value_bytes",
+			"field", "phone", "size_bytes", size, "max_value_bytes", limit)
 		return result
 	}
 	result.CustomerPayload["phone"] = phone
-	log.Printf("[MAPPING] Set required field phone: %v", phone)
+	m.logVerbose("Set required field", "field", "phone", "value", m.logObfuscator.Value("phone", phone))
 	
 	// Requirement 3.8: product.name is required and set from configuration
-	result.CustomerPayload["product"] = map[string]interface{}{
-		"name": m.productName,
+	product, err := m.mapProduct(normalizedPayload["product"])
+	if err != nil {
+		result.Success = false
+		result.Errors = append(result.Errors, err.Error())
+		logger.LogError(mappingLogCtx, "Failed to map product", err)
+		return result
 	}
-	log.Printf("[MAPPING] Set required field product.name: %s", m.productName)
+	result.CustomerPayload["product"] = product
+	m.logVerbose("Set required field", "field", "product.name", "value", m.productName)
 	
 	// Process all other attributes with permissive validation
 	for key, value := range normalizedPayload {
@@ -72,13 +281,45 @@ func (m *Mapper) MapToCustomerFormat(normalizedPayload models.JSONB) *MappingRes
 			continue
 		}
 		
+		if def, ok := attributeMapping[key]; ok && def.Type == "base64" {
+			result.AttachmentKeys = append(result.AttachmentKeys, key)
+		}
+		
+		// Keep fields outside the configured whitelist away from the Customer API
+		if !m.AllowsField(key) {
+			_, mapped := attributeMapping[key]
+			if m.verboseLogs.Allow() {
+				logger.Debug(mappingLogCtx, "Stripping field not in allowed payload fields", "field", key, "mapped_attribute", mapped)
+			}
+			continue
+		}
+		
 		// Check if attribute has validation rules
-		attrDef, hasRules := m.attributeMapping[key]
+		attrDef, hasRules := attributeMapping[key]
 		
 		if !hasRules {
-			// No validation rules defined - include as-is
+			// In strict mode fields without a mapping rule never reach the Customer API
+			switch m.strictUnknown {
+			case config.StrictUnknownDrop:
+				result.OmittedAttributes = append(result.OmittedAttributes, key)
+				logger.Warn(mappingLogCtx, "Omitting unknown attribute without mapping rule", "attribute", key)
+				continue
+			case config.StrictUnknownFail:
+				result.Success = false
+				result.Errors = append(result.Errors, fmt.Sprintf("unknown attribute '%s' has no mapping rule", key))
+				logger.Warn(mappingLogCtx, "Unknown attribute has no mapping rule", "attribute", key)
+				continue
+			}
+			
+			// No validation rules defined - include as-is unless it exceeds the default size limit
+			if size, limit, exceeded := m.exceedsMaxBytes(value, attrDef); exceeded {
+				result.OmittedAttributes = append(result.OmittedAttributes, key)
+				logger.Warn(mappingLogCtx, "Omitting attribute exceeding max_value_bytes",
+					"attribute", key, "size_bytes", size, "max_value_bytes", limit)
+				continue
+			}
 			result.CustomerPayload[key] = value
-			log.Printf("[MAPPING] No validation rules for '%s', including as-is", key)
+			m.logVerbose("No validation rules, including attribute as-is", "attribute", key)
 			continue
 		}
 		
@@ -87,34 +328,92 @@ func (m *Mapper) MapToCustomerFormat(normalizedPayload models.JSONB) *MappingRes
 		
 		if valid {
 			result.CustomerPayload[key] = validatedValue
-			log.Printf("[MAPPING] Attribute '%s' validated successfully", key)
+			if attrDef.Type == "base64" {
+				result.Attachments[key] = validatedValue
+			}
+			m.logVerbose("Attribute validated successfully", "attribute", key)
 		} else {
 			// Requirement 3.6: Omit invalid optional attributes
 			if !attrDef.Required {
 				result.OmittedAttributes = append(result.OmittedAttributes, key)
-				log.Printf("[MAPPING] Omitting invalid optional attribute: %s", key)
+				logger.Warn(mappingLogCtx, "Omitting invalid optional attribute", "attribute", key)
 			} else {
 				// Required attribute is invalid - this is an error
 				result.Success = false
-				result.Errors = append(result.Errors, fmt.Sprintf("required attribute '%s' is invalid", key))
-				log.Printf("[MAPPING] Required attribute '%s' is invalid", key)
+				reason := m.invalidAttributeReason(value, attrDef)
+				result.Errors = append(result.Errors, fmt.Sprintf("required attribute '%s' %s", key, reason))
+				logger.Warn(mappingLogCtx, "Required attribute is invalid", "attribute", key, "reason", reason)
 			}
 		}
 	}
 	
+	m.recordOmissions(result.OmittedAttributes, attributeMapping)
+	
 	if len(result.OmittedAttributes) > 0 {
-		log.Printf("[MAPPING] Omitted %d invalid optional attributes: %v", 
-			len(result.OmittedAttributes), result.OmittedAttributes)
+		logger.Warn(mappingLogCtx, "Omitted invalid optional or unknown attributes",
+			"count", len(result.OmittedAttributes), "attributes", result.OmittedAttributes)
 	}
 	
 	return result
 }
 
+// applyFallbacks returns the payload with every missing or empty attribute that has fallback
+// fields set to the first non-empty of them, and the fallback field used per attribute.
+// The fallback value is normalized like a value of the attribute, e.g. a phone number taken
+// from a field the normalizer does not treat as phone. The payload is returned unchanged
+// when no fallback applies.
+func (m *Mapper) applyFallbacks(payload models.JSONB, attributeMapping map[string]config.AttributeDefinition) (models.JSONB, map[string]string) {
+	var resolved models.JSONB
+	var sources map[string]string
+	for key, def := range attributeMapping {
+		if len(def.FallbackFields) == 0 || !isEmptyValue(payload[key]) {
+			continue
+		}
+		for _, field := range def.FallbackFields {
+			value := payload[field]
+			if isEmptyValue(value) {
+				continue
+			}
+			if resolved == nil {
+				// Copy so the caller's payload keeps the lead as received
+				resolved = make(models.JSONB, len(payload)+1)
+				for k, v := range payload {
+					resolved[k] = v
+				}
+				sources = make(map[string]string)
+			}
+			resolved[key] = m.normalizer.NormalizeField(key, value)
+			sources[key] = field
+			logger.Info(mappingLogCtx, "Attribute missing, using fallback field", "attribute", key, "fallback_field", field)
+			break
+		}
+	}
+	if resolved == nil {
+		return payload, nil
+	}
+	return resolved, sources
+}
+
+// isEmptyValue reports whether a payload value is absent, null or a blank string
+func isEmptyValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	s, ok := value.(string)
+	return ok && strings.TrimSpace(s) == ""
+}
+
 // validateAttribute validates a single attribute according to its type definition
 // Returns (valid, validatedValue)
 func (m *Mapper) validateAttribute(key string, value interface{}, def config.AttributeDefinition) (bool, interface{}) {
 	if value == nil {
-		log.Printf("[MAPPING] Attribute '%s' is nil", key)
+		logger.Warn(mappingLogCtx, "Attribute is nil", "attribute", key)
+		return false, nil
+	}
+	
+	// Oversized values are rejected before any type-specific parsing
+	if size, limit, exceeded := m.exceedsMaxBytes(value, def); exceeded {
+		logger.Warn(mappingLogCtx, "Attribute exceeds max_value_bytes", "attribute", key, "size_bytes", size, "max_value_bytes", limit)
 		return false, nil
 	}
 	
@@ -125,42 +424,134 @@ func (m *Mapper) validateAttribute(key string, value interface{}, def config.Att
 		return m.validateDropdownAttribute(key, value, def)
 	case "range":
 		return m.validateRangeAttribute(key, value, def)
+	case "email":
+		return m.validateEmailAttribute(key, value, def)
+	case "url":
+		return m.validateURLAttribute(key, value, def)
+	case "date":
+		return m.validateDateAttribute(key, value, def)
+	case "phone":
+		return m.validatePhoneAttribute(key, value, def)
+	case "base64":
+		return m.validateBase64Attribute(key, value, def)
 	default:
-		log.Printf("[MAPPING] Unknown attribute type '%s' for '%s'", def.Type, key)
+		logger.Warn(mappingLogCtx, "Unknown attribute type", "attribute", key, "type", def.Type)
 		return false, nil
 	}
 }
 
+// exceedsMaxBytes reports whether value is a string larger than the max_value_bytes of def,
+// or the mapping default when def sets none, and returns its size and the limit
+func (m *Mapper) exceedsMaxBytes(value interface{}, def config.AttributeDefinition) (int, int, bool) {
+	// Attachments are limited by their decoded size instead
+	if def.Type == "base64" {
+		return 0, 0, false
+	}
+	limit := def.MaxValueBytes
+	if limit == 0 {
+		limit = m.maxFieldBytes
+	}
+	strValue, ok := value.(string)
+	if !ok || limit == 0 || len(strValue) <= limit {
+		return 0, limit, false
+	}
+	return len(strValue), limit, true
+}
+
 // validateTextAttribute validates a text attribute
 func (m *Mapper) validateTextAttribute(key string, value interface{}, def config.AttributeDefinition) (bool, interface{}) {
-	// Text attributes should be strings
+	// Text attributes should be strings; numbers decoded as json.Number keep their exact digits
+	if number, ok := value.(json.Number); ok {
+		return m.checkTextLength(key, number.String(), def)
+	}
 	strValue, ok := value.(string)
 	if !ok {
-		log.Printf("[MAPPING] Text attribute '%s' is not a string: %T", key, value)
+		logger.Warn(mappingLogCtx, "Text attribute is not a string", "attribute", key, "value_type", fmt.Sprintf("%T", value))
 		return false, nil
 	}
 	
 	// Empty strings are invalid
 	if strings.TrimSpace(strValue) == "" {
-		log.Printf("[MAPPING] Text attribute '%s' is empty", key)
+		logger.Warn(mappingLogCtx, "Text attribute is empty", "attribute", key)
+		return false, nil
+	}
+	
+	return m.checkTextLength(key, strValue, def)
+}
+
+// checkTextLength enforces the min_length and max_length of a text attribute, counted in characters.
+// Values over max_length are rejected or, in truncate mode, cut to max_length.
+func (m *Mapper) checkTextLength(key, strValue string, def config.AttributeDefinition) (bool, interface{}) {
+	length := utf8.RuneCountInString(strValue)
+	
+	if def.MinLength > 0 && length < def.MinLength {
+		logger.Warn(mappingLogCtx, "Text attribute is shorter than min_length", "attribute", key, "length", length, "min_length", def.MinLength)
 		return false, nil
 	}
 	
+	if def.MaxLength > 0 && length > def.MaxLength {
+		if !m.truncateLong {
+			logger.Warn(mappingLogCtx, "Text attribute exceeds max_length", "attribute", key, "length", length, "max_length", def.MaxLength)
+			return false, nil
+		}
+		logger.Warn(mappingLogCtx, "Truncating text attribute exceeding max_length", "attribute", key, "length", length, "max_length", def.MaxLength)
+		return true, string([]rune(strValue)[:def.MaxLength])
+	}
+	
 	return true, strValue
 }
 
+// invalidAttributeReason describes why a value failed validation, naming the violated
+// size or length bound so that oversized required values are easy to spot
+func (m *Mapper) invalidAttributeReason(value interface{}, def config.AttributeDefinition) string {
+	if size, limit, exceeded := m.exceedsMaxBytes(value, def); exceeded {
+		return fmt.Sprintf("exceeds max_value_bytes %d (size %d bytes)", limit, size)
+	}
+	
+	var strValue string
+	switch v := value.(type) {
+	case string:
+		strValue = v
+	case json.Number:
+		strValue = v.String()
+	default:
+		return "is invalid"
+	}
+	
+	if def.Type == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(strValue))
+		if err != nil {
+			return "is not valid base64"
+		}
+		if def.MaxDecodedBytes > 0 && len(decoded) > def.MaxDecodedBytes {
+			return fmt.Sprintf("exceeds max_decoded_bytes %d (size %d bytes)", def.MaxDecodedBytes, len(decoded))
+		}
+	}
+	
+	if def.Type == "text" && strings.TrimSpace(strValue) != "" {
+		length := utf8.RuneCountInString(strValue)
+		if def.MaxLength > 0 && length > def.MaxLength {
+			return fmt.Sprintf("exceeds max_length %d (length %d)", def.MaxLength, length)
+		}
+		if def.MinLength > 0 && length < def.MinLength {
+			return fmt.Sprintf("is below min_length %d (length %d)", def.MinLength, length)
+		}
+	}
+	return "is invalid"
+}
+
 // validateDropdownAttribute validates a dropdown attribute
 func (m *Mapper) validateDropdownAttribute(key string, value interface{}, def config.AttributeDefinition) (bool, interface{}) {
 	// Dropdown values should be strings
 	strValue, ok := value.(string)
 	if !ok {
-		log.Printf("[MAPPING] Dropdown attribute '%s' is not a string: %T", key, value)
+		logger.Warn(mappingLogCtx, "Dropdown attribute is not a string", "attribute", key, "value_type", fmt.Sprintf("%T", value))
 		return false, nil
 	}
 	
 	// Check if value is in allowed options
 	if len(def.Options) == 0 {
-		log.Printf("[MAPPING] Dropdown attribute '%s' has no options defined", key)
+		logger.Warn(mappingLogCtx, "Dropdown attribute has no options defined", "attribute", key)
 		return true, strValue // No options means any value is valid
 	}
 	
@@ -170,8 +561,8 @@ func (m *Mapper) validateDropdownAttribute(key string, value interface{}, def co
 		}
 	}
 	
-	log.Printf("[MAPPING] Dropdown attribute '%s' value '%s' not in allowed options: %v", 
-		key, strValue, def.Options)
+	logger.Warn(mappingLogCtx, "Dropdown attribute value not in allowed options",
+		"attribute", key, "value", m.logObfuscator.Value(key, strValue), "options", def.Options)
 	return false, nil
 }
 
@@ -189,38 +580,178 @@ func (m *Mapper) validateRangeAttribute(key string, value interface{}, def confi
 		numValue = float64(v)
 	case int64:
 		numValue = float64(v)
+	case json.Number:
+		parsed, err := v.Float64()
+		if err != nil {
+			logger.Warn(mappingLogCtx, "Range attribute value cannot be parsed as number", "attribute", key, "value", m.logObfuscator.Value(key, v.String()))
+			return false, nil
+		}
+		numValue = parsed
 	case string:
 		// Try to parse string as number
 		parsed, err := strconv.ParseFloat(v, 64)
 		if err != nil {
-			log.Printf("[MAPPING] Range attribute '%s' string value '%s' cannot be parsed as number", key, v)
+			logger.Warn(mappingLogCtx, "Range attribute string value cannot be parsed as number", "attribute", key, "value", m.logObfuscator.Value(key, v))
 			return false, nil
 		}
 		numValue = parsed
 	default:
-		log.Printf("[MAPPING] Range attribute '%s' is not numeric: %T", key, value)
+		logger.Warn(mappingLogCtx, "Range attribute is not numeric", "attribute", key, "value_type", fmt.Sprintf("%T", value))
 		return false, nil
 	}
 	
 	// Check min bound
 	if def.Min != nil && numValue < *def.Min {
-		log.Printf("[MAPPING] Range attribute '%s' value %f is below minimum %f", key, numValue, *def.Min)
+		logger.Warn(mappingLogCtx, "Range attribute value is below minimum", "attribute", key, "value", m.logObfuscator.Value(key, numValue), "min", *def.Min)
 		return false, nil
 	}
 	
 	// Check max bound
 	if def.Max != nil && numValue > *def.Max {
-		log.Printf("[MAPPING] Range attribute '%s' value %f is above maximum %f", key, numValue, *def.Max)
+		logger.Warn(mappingLogCtx, "Range attribute value is above maximum", "attribute", key, "value", m.logObfuscator.Value(key, numValue), "max", *def.Max)
 		return false, nil
 	}
 	
+	// The bounds are checked as float64, but a json.Number is passed on with its exact digits
+	number, isNumber := value.(json.Number)
+	
+	// Some customers expect range values as strings; keep the original string when there is one
+	if def.OutputAs == config.RangeOutputString {
+		if s, ok := value.(string); ok {
+			return true, s
+		}
+		if isNumber {
+			return true, number.String()
+		}
+		return true, strconv.FormatFloat(numValue, 'f', -1, 64)
+	}
+	
+	if isNumber {
+		return true, number
+	}
 	return true, numValue
 }
 
+// validateEmailAttribute validates an email attribute
+func (m *Mapper) validateEmailAttribute(key string, value interface{}, def config.AttributeDefinition) (bool, interface{}) {
+	strValue, ok := value.(string)
+	if !ok {
+		logger.Warn(mappingLogCtx, "Email attribute is not a string", "attribute", key, "value_type", fmt.Sprintf("%T", value))
+		return false, nil
+	}
+	
+	strValue = strings.TrimSpace(strValue)
+	if !emailPattern.MatchString(strValue) {
+		logger.Warn(mappingLogCtx, "Email attribute value is not a valid email address", "attribute", key, "value", m.logObfuscator.Value(key, strValue))
+		return false, nil
+	}
+	
+	return true, strValue
+}
+
+// validateURLAttribute validates a URL attribute; only absolute http(s) URLs are accepted
+func (m *Mapper) validateURLAttribute(key string, value interface{}, def config.AttributeDefinition) (bool, interface{}) {
+	strValue, ok := value.(string)
+	if !ok {
+		logger.Warn(mappingLogCtx, "URL attribute is not a string", "attribute", key, "value_type", fmt.Sprintf("%T", value))
+		return false, nil
+	}
+	
+	strValue = strings.TrimSpace(strValue)
+	parsed, err := url.Parse(strValue)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		logger.Warn(mappingLogCtx, "URL attribute value is not a valid http(s) URL", "attribute", key, "value", m.logObfuscator.Value(key, strValue))
+		return false, nil
+	}
+	
+	return true, strValue
+}
+
+// validateDateAttribute validates a date attribute against its configured format
+func (m *Mapper) validateDateAttribute(key string, value interface{}, def config.AttributeDefinition) (bool, interface{}) {
+	strValue, ok := value.(string)
+	if !ok {
+		logger.Warn(mappingLogCtx, "Date attribute is not a string", "attribute", key, "value_type", fmt.Sprintf("%T", value))
+		return false, nil
+	}
+	
+	format := def.Format
+	if format == "" {
+		format = defaultDateFormat
+	}
+	
+	strValue = strings.TrimSpace(strValue)
+	if _, err := time.Parse(format, strValue); err != nil {
+		logger.Warn(mappingLogCtx, "Date attribute value does not match format", "attribute", key, "value", m.logObfuscator.Value(key, strValue), "format", format)
+		return false, nil
+	}
+	
+	return true, strValue
+}
+
+// validatePhoneAttribute validates a phone attribute in E.164 (+4915112345678) or
+// national (015112345678) format; spaces, dashes, dots, slashes and parentheses are ignored
+func (m *Mapper) validatePhoneAttribute(key string, value interface{}, def config.AttributeDefinition) (bool, interface{}) {
+	strValue, ok := value.(string)
+	if !ok {
+		logger.Warn(mappingLogCtx, "Phone attribute is not a string", "attribute", key, "value_type", fmt.Sprintf("%T", value))
+		return false, nil
+	}
+	
+	strValue = strings.TrimSpace(strValue)
+	compact := phoneSeparators.Replace(strValue)
+	if !e164Pattern.MatchString(compact) && !nationalPhonePattern.MatchString(compact) {
+		logger.Warn(mappingLogCtx, "Phone attribute value is not an E.164 or national number", "attribute", key, "value", m.logObfuscator.Value(key, strValue))
+		return false, nil
+	}
+	
+	return true, strValue
+}
+
+// validateBase64Attribute validates a base64-encoded attachment. The valid value is replaced
+// by its metadata (decoded size and MIME type detected from the content), so the attachment
+// bytes never reach the customer payload.
+func (m *Mapper) validateBase64Attribute(key string, value interface{}, def config.AttributeDefinition) (bool, interface{}) {
+	return summarizeAttachment(key, value, def)
+}
+
+// summarizeAttachment validates a base64-encoded attachment and returns its metadata
+func summarizeAttachment(key string, value interface{}, def config.AttributeDefinition) (bool, interface{}) {
+	strValue, ok := value.(string)
+	if !ok {
+		logger.Warn(mappingLogCtx, "Base64 attribute is not a string", "attribute", key, "value_type", fmt.Sprintf("%T", value))
+		return false, nil
+	}
+	
+	strValue = strings.TrimSpace(strValue)
+	if strValue == "" {
+		logger.Warn(mappingLogCtx, "Base64 attribute is empty", "attribute", key)
+		return false, nil
+	}
+	
+	decoded, err := base64.StdEncoding.DecodeString(strValue)
+	if err != nil {
+		logger.LogError(mappingLogCtx, "Base64 attribute is not valid base64", err, "attribute", key)
+		return false, nil
+	}
+	if def.MaxDecodedBytes > 0 && len(decoded) > def.MaxDecodedBytes {
+		logger.Warn(mappingLogCtx, "Base64 attribute exceeds max_decoded_bytes",
+			"attribute", key, "size_bytes", len(decoded), "max_decoded_bytes", def.MaxDecodedBytes)
+		return false, nil
+	}
+	
+	mimeType, _, _ := strings.Cut(http.DetectContentType(decoded), ";")
+	return true, map[string]interface{}{
+		"size_bytes": len(decoded),
+		"mime_type":  mimeType,
+	}
+}
+
 // ValidateRequiredFields checks if all required Core Customer Fields are present
 // Requirement 3.5
 func (m *Mapper) ValidateRequiredFields(payload models.JSONB) error {
-	// Check phone
+	// Check phone, or its fallback fields
+	payload, _ = m.applyFallbacks(payload, m.attributeMapping)
 	phone, ok := payload["phone"]
 	if !ok || phone == nil || phone == "" {
 		return fmt.Errorf("missing required Core Customer Field: phone")
@@ -1,12 +1,50 @@
 package services
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/logger"
 	"github.com/checkfox/go_lead/internal/models"
 )
 
+func init() {
+	// Initialize logger for tests
+	logger.Init()
+}
+
+// captureLogs returns what the global logger writes while fn runs
+func captureLogs(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	logger.Init()
+	defer func() {
+		os.Stdout = stdout
+		logger.Init()
+	}()
+
+	// Read while fn runs, so that a full pipe never blocks the logger
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- data
+	}()
+	fn()
+	w.Close()
+	return string(<-output)
+}
+
 // Test text attribute validation
 func TestValidateTextAttribute(t *testing.T) {
 	cfg := &config.Config{
@@ -47,6 +85,45 @@ func TestValidateTextAttribute(t *testing.T) {
 	}
 }
 
+// Test text attribute length bounds in reject and truncate mode
+func TestValidateTextAttribute_Length(t *testing.T) {
+	def := config.AttributeDefinition{Type: "text", MinLength: 3, MaxLength: 5}
+	
+	tests := []struct {
+		name      string
+		action    string
+		value     interface{}
+		wantValid bool
+		wantValue interface{}
+	}{
+		{"exactly max_length", config.LengthExceedReject, "abcde", true, "abcde"},
+		{"exactly min_length", config.LengthExceedReject, "abc", true, "abc"},
+		{"below min_length", config.LengthExceedReject, "ab", false, nil},
+		{"max_length+1 rejected", config.LengthExceedReject, "abcdef", false, nil},
+		{"max_length+1 truncated", config.LengthExceedTruncate, "abcdef", true, "abcde"},
+		{"below min_length in truncate mode", config.LengthExceedTruncate, "ab", false, nil},
+		{"characters not bytes", config.LengthExceedReject, "äöüßé", true, "äöüßé"},
+		{"multibyte truncated", config.LengthExceedTruncate, "äöüßéè", true, "äöüßé"},
+		{"json number checked", config.LengthExceedReject, json.Number("123456"), false, nil},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := NewMapper(&config.Config{
+				Validation: config.ValidationConfig{LengthExceedAction: tt.action},
+			})
+			
+			valid, value := mapper.validateTextAttribute("comment", tt.value, def)
+			if valid != tt.wantValid {
+				t.Errorf("validateTextAttribute() valid = %v, want %v", valid, tt.wantValid)
+			}
+			if value != tt.wantValue {
+				t.Errorf("validateTextAttribute() value = %v, want %v", value, tt.wantValue)
+			}
+		})
+	}
+}
+
 // Test dropdown attribute validation
 func TestValidateDropdownAttribute(t *testing.T) {
 	cfg := &config.Config{
@@ -138,6 +215,432 @@ func TestValidateRangeAttribute(t *testing.T) {
 	}
 }
 
+// Test range attribute output form
+func TestValidateRangeAttribute_OutputAs(t *testing.T) {
+	tests := []struct {
+		name     string
+		outputAs string
+		value    interface{}
+		want     interface{}
+	}{
+		{"default emits number", "", "500", 500.0},
+		{"number emits number", config.RangeOutputNumber, "500", 500.0},
+		{"string keeps original string", config.RangeOutputString, "500", "500"},
+		{"string keeps original formatting", config.RangeOutputString, "500.50", "500.50"},
+		{"string formats numeric input", config.RangeOutputString, 500.5, "500.5"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				CustomerAPI: config.CustomerAPIConfig{
+					ProductName: "test_product",
+				},
+				AttributeMapping: config.AttributeMappingConfig{
+					Mapping: map[string]config.AttributeDefinition{
+						"phone": {
+							Type:     "text",
+							Required: true,
+						},
+						"roof_area": {
+							Type:     "range",
+							Required: false,
+							OutputAs: tt.outputAs,
+						},
+					},
+				},
+			}
+			
+			mapper := NewMapper(cfg)
+			result := mapper.MapToCustomerFormat(models.JSONB{
+				"phone":     "1234567890",
+				"roof_area": tt.value,
+			})
+			if !result.Success {
+				t.Fatalf("MapToCustomerFormat() failed: %v", result.Errors)
+			}
+			
+			got := result.CustomerPayload["roof_area"]
+			if got != tt.want {
+				t.Errorf("roof_area = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test email, url, date and phone attribute validation
+func TestValidateFormatAttributes(t *testing.T) {
+	mapper := NewMapper(&config.Config{
+		CustomerAPI: config.CustomerAPIConfig{
+			ProductName: "test_product",
+		},
+	})
+
+	tests := []struct {
+		name      string
+		def       config.AttributeDefinition
+		value     interface{}
+		wantValid bool
+	}{
+		{"email valid", config.AttributeDefinition{Type: "email"}, "max.mustermann@example.de", true},
+		{"email valid with plus", config.AttributeDefinition{Type: "email"}, "max+solar@mail.example.com", true},
+		{"email missing at", config.AttributeDefinition{Type: "email"}, "max.example.de", false},
+		{"email missing domain dot", config.AttributeDefinition{Type: "email"}, "max@localhost", false},
+		{"email double dot", config.AttributeDefinition{Type: "email"}, "max..mustermann@example.de", false},
+		{"email non-string", config.AttributeDefinition{Type: "email"}, 42, false},
+		{"url valid https", config.AttributeDefinition{Type: "url"}, "https://example.de/angebot?id=1", true},
+		{"url valid http", config.AttributeDefinition{Type: "url"}, "http://example.de", true},
+		{"url missing scheme", config.AttributeDefinition{Type: "url"}, "example.de/angebot", false},
+		{"url unsupported scheme", config.AttributeDefinition{Type: "url"}, "ftp://example.de", false},
+		{"url missing host", config.AttributeDefinition{Type: "url"}, "https://", false},
+		{"date valid default format", config.AttributeDefinition{Type: "date"}, "2024-03-15", true},
+		{"date invalid default format", config.AttributeDefinition{Type: "date"}, "15.03.2024", false},
+		{"date impossible day", config.AttributeDefinition{Type: "date"}, "2024-02-30", false},
+		{"date valid custom format", config.AttributeDefinition{Type: "date", Format: "02.01.2006"}, "15.03.2024", true},
+		{"date invalid custom format", config.AttributeDefinition{Type: "date", Format: "02.01.2006"}, "2024-03-15", false},
+		{"date non-string", config.AttributeDefinition{Type: "date"}, 20240315, false},
+		{"phone valid e164", config.AttributeDefinition{Type: "phone"}, "+4915112345678", true},
+		{"phone valid e164 with separators", config.AttributeDefinition{Type: "phone"}, "+49 151 1234-5678", true},
+		{"phone valid national", config.AttributeDefinition{Type: "phone"}, "0151 12345678", true},
+		{"phone valid national with area code", config.AttributeDefinition{Type: "phone"}, "(030) 1234567", true},
+		{"phone letters", config.AttributeDefinition{Type: "phone"}, "0151-CALLME", false},
+		{"phone missing prefix", config.AttributeDefinition{Type: "phone"}, "15112345678", false},
+		{"phone too long", config.AttributeDefinition{Type: "phone"}, "+49151123456789012", false},
+		{"phone non-string", config.AttributeDefinition{Type: "phone"}, 4915112345678, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, value := mapper.validateAttribute("contact", tt.value, tt.def)
+			if valid != tt.wantValid {
+				t.Errorf("validateAttribute() = %v, want %v", valid, tt.wantValid)
+			}
+			if valid && value != strings.TrimSpace(tt.value.(string)) {
+				t.Errorf("validateAttribute() value = %#v, want %#v", value, tt.value)
+			}
+		})
+	}
+}
+
+// Test that invalid format attributes are omitted when optional and fail the mapping when required
+func TestFormatAttributes_OptionalOmittedRequiredFails(t *testing.T) {
+	tests := []struct {
+		name        string
+		def         config.AttributeDefinition
+		value       string
+		wantSuccess bool
+		wantOmitted bool
+	}{
+		{"optional valid email kept", config.AttributeDefinition{Type: "email"}, "max@example.de", true, false},
+		{"optional invalid email omitted", config.AttributeDefinition{Type: "email"}, "not-an-email", true, true},
+		{"required invalid email fails", config.AttributeDefinition{Type: "email", Required: true}, "not-an-email", false, false},
+		{"optional invalid url omitted", config.AttributeDefinition{Type: "url"}, "no url", true, true},
+		{"required invalid url fails", config.AttributeDefinition{Type: "url", Required: true}, "no url", false, false},
+		{"optional invalid date omitted", config.AttributeDefinition{Type: "date"}, "yesterday", true, true},
+		{"required invalid date fails", config.AttributeDefinition{Type: "date", Required: true}, "yesterday", false, false},
+		{"optional invalid phone omitted", config.AttributeDefinition{Type: "phone"}, "12", true, true},
+		{"required invalid phone fails", config.AttributeDefinition{Type: "phone", Required: true}, "12", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := NewMapper(&config.Config{
+				CustomerAPI: config.CustomerAPIConfig{
+					ProductName: "test_product",
+				},
+				AttributeMapping: config.AttributeMappingConfig{
+					Mapping: map[string]config.AttributeDefinition{
+						"phone": {
+							Type:     "text",
+							Required: true,
+						},
+						"contact": tt.def,
+					},
+				},
+			})
+
+			result := mapper.MapToCustomerFormat(models.JSONB{
+				"phone":   "1234567890",
+				"contact": tt.value,
+			})
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("MapToCustomerFormat() success = %v, want %v (errors: %v)", result.Success, tt.wantSuccess, result.Errors)
+			}
+			if !result.Success {
+				return
+			}
+
+			_, present := result.CustomerPayload["contact"]
+			if present == tt.wantOmitted {
+				t.Errorf("contact present = %v, want omitted = %v", present, tt.wantOmitted)
+			}
+			omitted := len(result.OmittedAttributes) == 1 && result.OmittedAttributes[0] == "contact"
+			if omitted != tt.wantOmitted {
+				t.Errorf("OmittedAttributes = %v, want omitted = %v", result.OmittedAttributes, tt.wantOmitted)
+			}
+		})
+	}
+}
+
+// Test over-length text attributes are truncated, omitted when optional, and fail mapping when required
+func TestMapToCustomerFormat_OverLengthText(t *testing.T) {
+	tests := []struct {
+		name        string
+		action      string
+		required    bool
+		wantSuccess bool
+		wantValue   interface{} // nil when the attribute is omitted
+	}{
+		{"optional truncated", config.LengthExceedTruncate, false, true, "Maxim"},
+		{"required truncated", config.LengthExceedTruncate, true, true, "Maxim"},
+		{"optional omitted", config.LengthExceedReject, false, true, nil},
+		{"required fails", config.LengthExceedReject, true, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := NewMapper(&config.Config{
+				CustomerAPI: config.CustomerAPIConfig{
+					ProductName: "test_product",
+				},
+				AttributeMapping: config.AttributeMappingConfig{
+					Mapping: map[string]config.AttributeDefinition{
+						"name": {Type: "text", Required: tt.required, MaxLength: 5},
+					},
+				},
+				Validation: config.ValidationConfig{LengthExceedAction: tt.action},
+			})
+
+			result := mapper.MapToCustomerFormat(models.JSONB{
+				"phone": "1234567890",
+				"name":  "Maximilian",
+			})
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("MapToCustomerFormat() success = %v, want %v (errors: %v)", result.Success, tt.wantSuccess, result.Errors)
+			}
+			if !result.Success {
+				want := "required attribute 'name' exceeds max_length 5 (length 10)"
+				if len(result.Errors) != 1 || result.Errors[0] != want {
+					t.Errorf("Errors = %v, want [%s]", result.Errors, want)
+				}
+				return
+			}
+
+			if got := result.CustomerPayload["name"]; got != tt.wantValue {
+				t.Errorf("name = %#v, want %#v", got, tt.wantValue)
+			}
+			omitted := len(result.OmittedAttributes) == 1 && result.OmittedAttributes[0] == "name"
+			if omitted != (tt.wantValue == nil) {
+				t.Errorf("OmittedAttributes = %v, want omitted = %v", result.OmittedAttributes, tt.wantValue == nil)
+			}
+		})
+	}
+}
+
+// Test oversized values fail required fields and are omitted from optional ones
+func TestMapToCustomerFormat_MaxFieldBytes(t *testing.T) {
+	oversized := strings.Repeat("1", 5000)
+	mapper := NewMapper(&config.Config{
+		CustomerAPI: config.CustomerAPIConfig{
+			ProductName: "test_product",
+		},
+		AttributeMapping: config.AttributeMappingConfig{
+			Mapping: map[string]config.AttributeDefinition{
+				"phone":   {Type: "text", Required: true},
+				"comment": {Type: "text"},
+				"notes":   {Type: "text", MaxValueBytes: 8192},
+			},
+			DefaultMaxFieldBytes: 4096,
+		},
+	})
+
+	t.Run("required phone fails", func(t *testing.T) {
+		result := mapper.MapToCustomerFormat(models.JSONB{"phone": oversized})
+		if result.Success {
+			t.Fatal("Expected mapping to fail for a 5000-byte phone")
+		}
+		want := "required field phone exceeds max_value_bytes 4096 (size 5000 bytes)"
+		if len(result.Errors) != 1 || result.Errors[0] != want {
+			t.Errorf("Errors = %v, want [%s]", result.Errors, want)
+		}
+	})
+
+	t.Run("optional fields omitted", func(t *testing.T) {
+		result := mapper.MapToCustomerFormat(models.JSONB{
+			"phone":    "1234567890",
+			"comment":  oversized,
+			"unmapped": oversized,
+			"notes":    oversized,
+		})
+		if !result.Success {
+			t.Fatalf("MapToCustomerFormat() failed: %v", result.Errors)
+		}
+		for _, key := range []string{"comment", "unmapped"} {
+			if _, present := result.CustomerPayload[key]; present {
+				t.Errorf("Expected oversized %s to be omitted", key)
+			}
+		}
+		if len(result.OmittedAttributes) != 2 {
+			t.Errorf("OmittedAttributes = %v, want comment and unmapped", result.OmittedAttributes)
+		}
+		if result.CustomerPayload["notes"] != oversized {
+			t.Error("Expected notes to be kept within its own max_value_bytes")
+		}
+	})
+}
+
+// Test base64 attachments are replaced by their metadata, omitted when invalid or oversized
+// and fail mapping when required
+func TestMapToCustomerFormat_Base64Attachments(t *testing.T) {
+	pdf := base64.StdEncoding.EncodeToString([]byte("%PDF-1.4\n" + strings.Repeat("x", 6000)))
+	tests := []struct {
+		name        string
+		required    bool
+		value       string
+		wantSuccess bool
+		wantValid   bool
+	}{
+		{"valid attachment summarized", false, pdf, true, true},
+		{"optional invalid base64 omitted", false, "not base64!", true, false},
+		{"required invalid base64 fails", true, "not base64!", false, false},
+		{"optional oversized omitted", false, base64.StdEncoding.EncodeToString(make([]byte, 20000)), true, false},
+		{"required oversized fails", true, base64.StdEncoding.EncodeToString(make([]byte, 20000)), false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := NewMapper(&config.Config{
+				CustomerAPI: config.CustomerAPIConfig{
+					ProductName: "test_product",
+				},
+				AttributeMapping: config.AttributeMappingConfig{
+					Mapping: map[string]config.AttributeDefinition{
+						"offer_pdf": {Type: "base64", Required: tt.required, MaxDecodedBytes: 10000},
+					},
+					// The attachment is larger than the default limit for string values
+					DefaultMaxFieldBytes: 4096,
+				},
+			})
+
+			result := mapper.MapToCustomerFormat(models.JSONB{
+				"phone":     "1234567890",
+				"offer_pdf": tt.value,
+			})
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("MapToCustomerFormat() success = %v, want %v (errors: %v)", result.Success, tt.wantSuccess, result.Errors)
+			}
+			if len(result.AttachmentKeys) != 1 || result.AttachmentKeys[0] != "offer_pdf" {
+				t.Errorf("AttachmentKeys = %v, want [offer_pdf]", result.AttachmentKeys)
+			}
+			if !result.Success {
+				return
+			}
+
+			got, present := result.CustomerPayload["offer_pdf"]
+			if present != tt.wantValid {
+				t.Fatalf("offer_pdf present = %v, want %v", present, tt.wantValid)
+			}
+			if !tt.wantValid {
+				if len(result.OmittedAttributes) != 1 || result.OmittedAttributes[0] != "offer_pdf" {
+					t.Errorf("OmittedAttributes = %v, want [offer_pdf]", result.OmittedAttributes)
+				}
+				if len(result.Attachments) != 0 {
+					t.Errorf("Attachments = %v, want none", result.Attachments)
+				}
+				return
+			}
+
+			metadata, ok := got.(map[string]interface{})
+			if !ok {
+				t.Fatalf("offer_pdf = %#v, want metadata object", got)
+			}
+			if metadata["size_bytes"] != 6009 || metadata["mime_type"] != "application/pdf" {
+				t.Errorf("offer_pdf metadata = %v, want size_bytes 6009 and mime_type application/pdf", metadata)
+			}
+			if _, ok := result.Attachments["offer_pdf"]; !ok {
+				t.Errorf("Attachments = %v, want offer_pdf metadata", result.Attachments)
+			}
+		})
+	}
+}
+
+// Test large integers survive decoding, storage, normalization and mapping unchanged
+// when numbers are decoded as json.Number
+func TestLargeIntegerPreservedThroughToCustomerPayload(t *testing.T) {
+	models.SetJSONUseNumber(true)
+	defer models.SetJSONUseNumber(false)
+
+	const providerID = "1234567890123456789" // not representable as float64
+	body := `{"phone": "0151 1234567", "provider_id": ` + providerID + `, "panel_count": ` + providerID + `}`
+
+	// Webhook decoding
+	var raw models.JSONB
+	if err := models.DecodeJSON([]byte(body), &raw); err != nil {
+		t.Fatalf("DecodeJSON() error: %v", err)
+	}
+
+	// Storage round trip, as the worker loads the lead from the database
+	stored, err := raw.Value()
+	if err != nil {
+		t.Fatalf("Value() error: %v", err)
+	}
+	var loaded models.JSONB
+	if err := loaded.Scan(stored); err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	normalized := NewNormalizer().NormalizeLeadWithFieldMapping(loaded)
+
+	mapper := NewMapper(&config.Config{
+		CustomerAPI: config.CustomerAPIConfig{
+			ProductName: "test_product",
+		},
+		AttributeMapping: config.AttributeMappingConfig{
+			Mapping: map[string]config.AttributeDefinition{
+				"phone":       {Type: "text", Required: true},
+				"provider_id": {Type: "text"},
+				"panel_count": {Type: "range"},
+			},
+		},
+	})
+	result := mapper.MapToCustomerFormat(normalized)
+	if !result.Success {
+		t.Fatalf("MapToCustomerFormat() failed: %v", result.Errors)
+	}
+
+	if got := result.CustomerPayload["provider_id"]; got != providerID {
+		t.Errorf("Expected text attribute provider_id %s, got %v (%T)", providerID, got, got)
+	}
+
+	// The payload as sent to the Customer API
+	sent, err := json.Marshal(result.CustomerPayload)
+	if err != nil {
+		t.Fatalf("Failed to marshal customer payload: %v", err)
+	}
+	if !strings.Contains(string(sent), `"panel_count":`+providerID) {
+		t.Errorf("Expected range attribute panel_count to keep all digits, got %s", sent)
+	}
+}
+
+// Test json.Number values in range and text validation
+func TestValidateAttributes_JSONNumber(t *testing.T) {
+	min, max := 1.0, 100.0
+	mapper := NewMapper(&config.Config{})
+
+	if valid, value := mapper.validateRangeAttribute("panels", json.Number("42"), config.AttributeDefinition{Type: "range", Min: &min, Max: &max}); !valid || value != json.Number("42") {
+		t.Errorf("Expected json.Number 42 to be valid and kept, got %v, %v", valid, value)
+	}
+	if valid, _ := mapper.validateRangeAttribute("panels", json.Number("101"), config.AttributeDefinition{Type: "range", Min: &min, Max: &max}); valid {
+		t.Error("Expected json.Number above max to be invalid")
+	}
+	if valid, value := mapper.validateRangeAttribute("panels", json.Number("42"), config.AttributeDefinition{Type: "range", OutputAs: config.RangeOutputString}); !valid || value != "42" {
+		t.Errorf("Expected json.Number to be output as string 42, got %v, %v", valid, value)
+	}
+	if valid, value := mapper.validateTextAttribute("ref", json.Number("007"), config.AttributeDefinition{Type: "text"}); !valid || value != "007" {
+		t.Errorf("Expected json.Number text to keep its digits, got %v, %v", valid, value)
+	}
+}
+
 // Test missing required fields handling
 func TestMissingRequiredFields(t *testing.T) {
 	cfg := &config.Config{
@@ -201,6 +704,62 @@ func TestMissingRequiredFields(t *testing.T) {
 	}
 }
 
+// Test a missing required field is taken from the first non-empty of its fallback fields
+func TestMissingRequiredFields_Fallback(t *testing.T) {
+	mapper := NewMapper(&config.Config{
+		CustomerAPI: config.CustomerAPIConfig{
+			ProductName: "test_product",
+		},
+		AttributeMapping: config.AttributeMappingConfig{
+			Mapping: map[string]config.AttributeDefinition{
+				"phone": {Type: "text", Required: true, FallbackFields: []string{"mobile", "tel"}},
+			},
+		},
+	})
+
+	tests := []struct {
+		name        string
+		payload     models.JSONB
+		wantSuccess bool
+		wantPhone   interface{}
+		wantSource  string
+	}{
+		{"phone absent uses mobile", models.JSONB{"mobile": "015112345678"}, true, "015112345678", "mobile"},
+		{"empty phone uses mobile", models.JSONB{"phone": "", "mobile": "015112345678"}, true, "015112345678", "mobile"},
+		{"fallbacks tried in order", models.JSONB{"mobile": "015112345678", "tel": "0681123456"}, true, "015112345678", "mobile"},
+		{"empty fallback skipped", models.JSONB{"mobile": " ", "tel": "0681123456"}, true, "0681123456", "tel"},
+		{"phone takes precedence", models.JSONB{"phone": "0681999999", "mobile": "015112345678"}, true, "0681999999", ""},
+		{"no fallback present", models.JSONB{"email": "test@example.com"}, false, nil, ""},
+		{"fallback normalized as phone", models.JSONB{"mobile": " 0151 1234-5678 "}, true, "015112345678", "mobile"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			phoneBefore, hadPhone := tt.payload["phone"]
+			result := mapper.MapToCustomerFormat(tt.payload)
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("MapToCustomerFormat() success = %v, want %v (errors: %v)", result.Success, tt.wantSuccess, result.Errors)
+			}
+			if phoneAfter, hasPhone := tt.payload["phone"]; hasPhone != hadPhone || phoneAfter != phoneBefore {
+				t.Error("Expected the normalized payload to be left unchanged")
+			}
+			if err := mapper.ValidateRequiredFields(tt.payload); (err == nil) != tt.wantSuccess {
+				t.Errorf("ValidateRequiredFields() error = %v, want success %v", err, tt.wantSuccess)
+			}
+			if !result.Success {
+				return
+			}
+
+			if got := result.CustomerPayload["phone"]; got != tt.wantPhone {
+				t.Errorf("phone = %v, want %v", got, tt.wantPhone)
+			}
+			if got := result.FallbackSources["phone"]; got != tt.wantSource {
+				t.Errorf("FallbackSources[phone] = %q, want %q", got, tt.wantSource)
+			}
+		})
+	}
+}
+
 // Test invalid optional attribute omission
 func TestInvalidOptionalAttributeOmission(t *testing.T) {
 	min := 0.0
@@ -370,3 +929,436 @@ func TestProductNameSetFromConfig(t *testing.T) {
 		t.Errorf("product.name = %v, want %v", productName, "solar_panel_installation")
 	}
 }
+
+// Test handling of a product field in the payload for each product conflict action
+func TestProductConflictAction(t *testing.T) {
+	tests := []struct {
+		name        string
+		action      string
+		product     interface{}
+		wantSuccess bool
+		wantProduct map[string]interface{}
+	}{
+		{
+			name:        "ignore drops payload product",
+			action:      config.ProductConflictIgnore,
+			product:     map[string]interface{}{"campaign_id": "c-42"},
+			wantSuccess: true,
+			wantProduct: map[string]interface{}{"name": "solar"},
+		},
+		{
+			name:        "merge adds subfields alongside configured name",
+			action:      config.ProductConflictMerge,
+			product:     map[string]interface{}{"campaign_id": "c-42"},
+			wantSuccess: true,
+			wantProduct: map[string]interface{}{"name": "solar", "campaign_id": "c-42"},
+		},
+		{
+			name:        "merge keeps configured name on conflict",
+			action:      config.ProductConflictMerge,
+			product:     map[string]interface{}{"name": "heat_pump", "campaign_id": "c-42"},
+			wantSuccess: true,
+			wantProduct: map[string]interface{}{"name": "solar", "campaign_id": "c-42"},
+		},
+		{
+			name:        "merge ignores scalar product",
+			action:      config.ProductConflictMerge,
+			product:     "heat_pump",
+			wantSuccess: true,
+			wantProduct: map[string]interface{}{"name": "solar"},
+		},
+		{
+			name:        "reject merges subfields without conflict",
+			action:      config.ProductConflictReject,
+			product:     map[string]interface{}{"name": "solar", "campaign_id": "c-42"},
+			wantSuccess: true,
+			wantProduct: map[string]interface{}{"name": "solar", "campaign_id": "c-42"},
+		},
+		{
+			name:        "reject fails on conflicting name",
+			action:      config.ProductConflictReject,
+			product:     map[string]interface{}{"name": "heat_pump", "campaign_id": "c-42"},
+			wantSuccess: false,
+		},
+		{
+			name:        "reject fails on conflicting scalar product",
+			action:      config.ProductConflictReject,
+			product:     "heat_pump",
+			wantSuccess: false,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := NewMapper(&config.Config{
+				CustomerAPI: config.CustomerAPIConfig{
+					ProductName:           "solar",
+					ProductConflictAction: tt.action,
+				},
+			})
+			
+			result := mapper.MapToCustomerFormat(models.JSONB{
+				"phone":   "1234567890",
+				"product": tt.product,
+			})
+			
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("Success = %v, want %v (errors: %v)", result.Success, tt.wantSuccess, result.Errors)
+			}
+			if !tt.wantSuccess {
+				if len(result.Errors) == 0 {
+					t.Error("expected a mapping error for the conflicting product name")
+				}
+				return
+			}
+			
+			product, ok := result.CustomerPayload["product"].(map[string]interface{})
+			if !ok {
+				t.Fatal("product field is not a map")
+			}
+			if len(product) != len(tt.wantProduct) {
+				t.Errorf("product = %v, want %v", product, tt.wantProduct)
+			}
+			for key, want := range tt.wantProduct {
+				if product[key] != want {
+					t.Errorf("product.%s = %v, want %v", key, product[key], want)
+				}
+			}
+		})
+	}
+}
+
+// Test the allowed payload fields whitelist
+func TestMapToCustomerFormat_AllowedPayloadFields(t *testing.T) {
+	cfg := &config.Config{
+		CustomerAPI: config.CustomerAPIConfig{
+			ProductName:          "test_product",
+			AllowedPayloadFields: []string{"roof_type"},
+		},
+		AttributeMapping: config.AttributeMappingConfig{
+			Mapping: map[string]config.AttributeDefinition{
+				"roof_type": {
+					Type:    "dropdown",
+					Options: []string{"flat", "pitched"},
+				},
+				"email": {
+					Type: "text",
+				},
+			},
+		},
+	}
+	
+	mapper := NewMapper(cfg)
+	result := mapper.MapToCustomerFormat(models.JSONB{
+		"phone":     "1234567890",
+		"roof_type": "flat",
+		"email":     "jane@example.com", // mapped but not allowed
+		"birthdate": "1980-01-01",       // unmapped and not allowed
+	})
+	
+	if !result.Success {
+		t.Fatalf("MapToCustomerFormat() failed: %v", result.Errors)
+	}
+	if result.CustomerPayload["phone"] != "1234567890" {
+		t.Errorf("Expected phone to always be included, got %v", result.CustomerPayload["phone"])
+	}
+	if _, ok := result.CustomerPayload["product"]; !ok {
+		t.Error("Expected product to always be included")
+	}
+	if result.CustomerPayload["roof_type"] != "flat" {
+		t.Errorf("Expected allowed roof_type to be included, got %v", result.CustomerPayload["roof_type"])
+	}
+	for _, field := range []string{"email", "birthdate"} {
+		if _, ok := result.CustomerPayload[field]; ok {
+			t.Errorf("Expected %s to be stripped", field)
+		}
+	}
+	if len(result.CustomerPayload) != 3 {
+		t.Errorf("Expected only phone, product and roof_type, got %v", result.CustomerPayload)
+	}
+	// Stripped fields are not invalid, so they are not reported as omitted
+	if len(result.OmittedAttributes) != 0 {
+		t.Errorf("Expected no omitted attributes, got %v", result.OmittedAttributes)
+	}
+}
+
+// Test an empty whitelist places no restriction on the payload
+func TestMapToCustomerFormat_EmptyAllowedPayloadFields(t *testing.T) {
+	cfg := &config.Config{
+		CustomerAPI: config.CustomerAPIConfig{
+			ProductName: "test_product",
+		},
+	}
+	
+	mapper := NewMapper(cfg)
+	result := mapper.MapToCustomerFormat(models.JSONB{
+		"phone":     "1234567890",
+		"birthdate": "1980-01-01",
+	})
+	
+	if result.CustomerPayload["birthdate"] != "1980-01-01" {
+		t.Errorf("Expected birthdate to be included without a whitelist, got %v", result.CustomerPayload)
+	}
+}
+
+// Test MAPPING_STRICT_UNKNOWN for a payload with an attribute without mapping rule
+func TestMapToCustomerFormat_StrictUnknown(t *testing.T) {
+	payload := models.JSONB{
+		"phone":       "1234567890",
+		"roof_type":   "flat",
+		"tracking_id": "abc-123", // no mapping rule
+	}
+	newMapper := func(strictUnknown string) *Mapper {
+		return NewMapper(&config.Config{
+			CustomerAPI: config.CustomerAPIConfig{ProductName: "test_product"},
+			AttributeMapping: config.AttributeMappingConfig{
+				Mapping: map[string]config.AttributeDefinition{
+					"roof_type": {Type: "dropdown", Options: []string{"flat", "pitched"}},
+				},
+				StrictUnknown: strictUnknown,
+			},
+		})
+	}
+	
+	// Default: unknown attributes pass through
+	for _, strictUnknown := range []string{"", config.StrictUnknownOff} {
+		result := newMapper(strictUnknown).MapToCustomerFormat(payload)
+		if !result.Success || result.CustomerPayload["tracking_id"] != "abc-123" {
+			t.Errorf("Expected tracking_id to pass through with %q, got %v (%v)", strictUnknown, result.CustomerPayload, result.Errors)
+		}
+	}
+	
+	// Drop: the unknown attribute is omitted and recorded
+	mapper := newMapper(config.StrictUnknownDrop)
+	result := mapper.MapToCustomerFormat(payload)
+	if !result.Success {
+		t.Fatalf("Expected the lead to map without unknown attributes, got %v", result.Errors)
+	}
+	if _, ok := result.CustomerPayload["tracking_id"]; ok {
+		t.Error("Expected tracking_id to be dropped")
+	}
+	if result.CustomerPayload["roof_type"] != "flat" {
+		t.Errorf("Expected mapped roof_type to be kept, got %v", result.CustomerPayload["roof_type"])
+	}
+	if len(result.OmittedAttributes) != 1 || result.OmittedAttributes[0] != "tracking_id" {
+		t.Errorf("Expected tracking_id to be recorded as omitted, got %v", result.OmittedAttributes)
+	}
+	if stats := mapper.OmissionStats(); len(stats) != 0 {
+		t.Errorf("Expected no per-attribute stats for an unknown attribute, got %v", stats)
+	}
+	if count := mapper.UnknownOmissions(); count != 1 {
+		t.Errorf("Expected the omission to be counted as unknown, got %d", count)
+	}
+	
+	// Fail: the mapping fails naming the unknown attribute
+	result = newMapper(config.StrictUnknownFail).MapToCustomerFormat(payload)
+	if result.Success {
+		t.Fatal("Expected the mapping to fail for an unknown attribute")
+	}
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "tracking_id") {
+		t.Errorf("Expected an error naming tracking_id, got %v", result.Errors)
+	}
+}
+
+// Test the mapping profile of a lead's product is applied
+func TestMapToCustomerFormatWithProfile_SelectsProductProfile(t *testing.T) {
+	cfg := &config.Config{
+		CustomerAPI: config.CustomerAPIConfig{
+			ProductName: "test_product",
+		},
+		AttributeMapping: config.AttributeMappingConfig{
+			Mapping: map[string]config.AttributeDefinition{
+				"roof_type": {Type: "dropdown", Options: []string{"flat"}},
+			},
+			Profiles: map[string]map[string]config.AttributeDefinition{
+				"solar": {
+					"roof_type": {Type: "dropdown", Options: []string{"flat", "pitched"}},
+				},
+				"partner-a": {
+					"roof_type": {Type: "dropdown", Options: []string{"gable"}},
+				},
+			},
+		},
+	}
+	
+	mapper := NewMapper(cfg)
+	payload := models.JSONB{
+		"phone":     "1234567890",
+		"product":   map[string]interface{}{"name": "solar"},
+		"roof_type": "pitched",
+	}
+	
+	profile := mapper.ProfileFor("", payload)
+	if profile != "solar" {
+		t.Fatalf("Expected solar profile for the lead's product, got %q", profile)
+	}
+	
+	result := mapper.MapToCustomerFormatWithProfile(payload, profile)
+	if result.CustomerPayload["roof_type"] != "pitched" {
+		t.Errorf("Expected solar profile to accept 'pitched', got %v", result.CustomerPayload)
+	}
+	
+	// The default mapping only allows "flat"
+	result = mapper.MapToCustomerFormat(payload)
+	if _, ok := result.CustomerPayload["roof_type"]; ok {
+		t.Errorf("Expected default mapping to omit 'pitched', got %v", result.CustomerPayload)
+	}
+	
+	// The product name from configuration is still delivered
+	product := result.CustomerPayload["product"].(map[string]interface{})
+	if product["name"] != "test_product" {
+		t.Errorf("Expected configured product name, got %v", product["name"])
+	}
+}
+
+// Test mapping profile resolution order
+func TestProfileFor(t *testing.T) {
+	cfg := &config.Config{
+		AttributeMapping: config.AttributeMappingConfig{
+			Profiles: map[string]map[string]config.AttributeDefinition{
+				"solar":     {},
+				"partner-a": {},
+			},
+		},
+	}
+	mapper := NewMapper(cfg)
+	
+	tests := []struct {
+		name     string
+		sourceID string
+		payload  models.JSONB
+		want     string
+	}{
+		{"source profile wins", "partner-a", models.JSONB{"product": "solar"}, "partner-a"},
+		{"product name string", "partner-b", models.JSONB{"product": "solar"}, "solar"},
+		{"product object", "", models.JSONB{"product": map[string]interface{}{"name": "solar"}}, "solar"},
+		{"unknown product falls back", "", models.JSONB{"product": "heatpump"}, ""},
+		{"no source or product", "", models.JSONB{}, ""},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mapper.ProfileFor(tt.sourceID, tt.payload); got != tt.want {
+				t.Errorf("ProfileFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test omission tally across many concurrently mapped leads
+func TestOmissionStats_ConsistentlyInvalidAttribute(t *testing.T) {
+	cfg := &config.Config{
+		CustomerAPI: config.CustomerAPIConfig{
+			ProductName: "test_product",
+		},
+		AttributeMapping: config.AttributeMappingConfig{
+			Mapping: map[string]config.AttributeDefinition{
+				"roof_type": {
+					Type:     "dropdown",
+					Required: false,
+					Options:  []string{"flat", "pitched", "mixed"},
+				},
+			},
+		},
+	}
+
+	mapper := NewMapper(cfg)
+
+	const leads = 200
+	var wg sync.WaitGroup
+	for i := 0; i < leads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mapper.MapToCustomerFormat(models.JSONB{
+				"phone":     "+491234567890",
+				"roof_type": "thatched", // never a valid option
+			})
+		}()
+	}
+	wg.Wait()
+
+	stats := mapper.OmissionStats()
+	if stats["roof_type"] != leads {
+		t.Errorf("Expected roof_type to be omitted %d times, got %d", leads, stats["roof_type"])
+	}
+	if len(stats) != 1 {
+		t.Errorf("Expected only roof_type to be tallied, got %v", stats)
+	}
+}
+
+func TestMapToCustomerFormat_ObfuscatesLoggedPII(t *testing.T) {
+	cfg := &config.Config{
+		CustomerAPI: config.CustomerAPIConfig{
+			ProductName: "test_product",
+		},
+		AttributeMapping: config.AttributeMappingConfig{
+			Mapping: map[string]config.AttributeDefinition{
+				"email": {
+					Type:     "dropdown",
+					Required: false,
+					Options:  []string{"none"},
+				},
+			},
+		},
+		Privacy: config.PrivacyConfig{
+			ObfuscatedFields: []string{"phone", "email"},
+		},
+	}
+
+	mapper := NewMapper(cfg)
+	output := captureLogs(t, func() {
+		mapper.MapToCustomerFormat(models.JSONB{
+			"phone": "+491234567890",
+			"email": "jane@example.com", // invalid option, logged with its value
+		})
+	})
+
+	if strings.Contains(output, "+491234567890") || strings.Contains(output, "jane@example.com") {
+		t.Errorf("Expected phone and email to be redacted, got log output:\n%s", output)
+	}
+	if !strings.Contains(output, "REDACTED_PHONE") || !strings.Contains(output, "REDACTED_EMAIL") {
+		t.Errorf("Expected redaction markers in log output:\n%s", output)
+	}
+}
+
+func TestMapToCustomerFormat_SamplesVerboseLogs(t *testing.T) {
+	cfg := &config.Config{
+		CustomerAPI: config.CustomerAPIConfig{
+			ProductName: "test_product",
+		},
+		AttributeMapping: config.AttributeMappingConfig{
+			Mapping: map[string]config.AttributeDefinition{
+				"email":     {Type: "text"},
+				"roof_type": {Type: "dropdown", Options: []string{"flat", "gable"}},
+			},
+		},
+		Logging: config.LoggingConfig{
+			MappingSample: config.LogSample{Every: 10},
+		},
+	}
+
+	mapper := NewMapper(cfg)
+	const leads = 100
+	output := captureLogs(t, func() {
+		for i := 0; i < leads; i++ {
+			mapper.MapToCustomerFormat(models.JSONB{
+				"phone":     "+491234567890",
+				"email":     "jane@example.com",
+				"roof_type": "thatched", // never a valid option
+			})
+		}
+	})
+
+	// Each lead sets phone and product.name and validates email: 300 verbose lines
+	verbose := strings.Count(output, "Set required field") + strings.Count(output, "validated successfully")
+	if verbose != 30 {
+		t.Errorf("Expected 1 in 10 of 300 verbose lines to be logged, got %d", verbose)
+	}
+	if omitted := strings.Count(output, `"msg":"Omitting invalid optional attribute","attribute":"roof_type"`); omitted != leads {
+		t.Errorf("Expected all %d omission lines to be logged, got %d", leads, omitted)
+	}
+	if failed := strings.Count(output, "not in allowed options"); failed != leads {
+		t.Errorf("Expected all %d validation failures to be logged, got %d", leads, failed)
+	}
+}
@@ -1,15 +1,36 @@
 package services
 
 import (
+	"encoding/json"
 	"regexp"
 	"strings"
 
+	"github.com/checkfox/go_lead/internal/config"
 	"github.com/checkfox/go_lead/internal/models"
 )
 
+// zipPlus4Pattern matches a 5-digit zip code with a ZIP+4 suffix, e.g. "66123-4567"
+var zipPlus4Pattern = regexp.MustCompile(`^(\d{5})[\s-]*\d{4}$`)
+
+// zipSeparatorPattern matches everything but the letters and digits of an uppercased zip code
+var zipSeparatorPattern = regexp.MustCompile(`[^0-9A-Z]+`)
+
 // Normalizer provides data normalization functionality
 type Normalizer struct {
 	phonePattern *regexp.Regexp
+	valueAliases map[string]map[string]interface{}
+
+	// Country code handling of normalized phone numbers, see config.NormalizerConfig
+	phoneCountryCodeMode string
+	phoneDefaultCC       string
+	phoneTrunkPrefix     string
+
+	// Zip code canonicalization, see config.NormalizerConfig
+	normalizeZip     bool
+	zipCountryPrefix string
+
+	// keyNormalizer converts camelCase keys to snake_case; nil leaves keys as received
+	keyNormalizer *KeyNormalizer
 }
 
 // NewNormalizer creates a new Normalizer instance
@@ -22,6 +43,38 @@ func NewNormalizer() *Normalizer {
 	}
 }
 
+// NewNormalizerWithValueAliases creates a Normalizer that additionally replaces
+// known string values of the given fields, keyed by dot-separated field path
+func NewNormalizerWithValueAliases(aliases map[string]map[string]interface{}) *Normalizer {
+	n := NewNormalizer()
+	n.valueAliases = aliases
+	return n
+}
+
+// NewNormalizerFromConfig creates a Normalizer with the configured value aliases,
+// phone country code handling and zip code canonicalization
+func NewNormalizerFromConfig(cfg config.NormalizerConfig) *Normalizer {
+	n := NewNormalizerWithValueAliases(cfg.ValueAliases)
+	n.phoneCountryCodeMode = cfg.PhoneCountryCodeMode
+	n.phoneDefaultCC = cfg.PhoneDefaultCC
+	n.phoneTrunkPrefix = cfg.PhoneTrunkPrefix
+	n.normalizeZip = cfg.NormalizeZip
+	n.zipCountryPrefix = strings.ToUpper(cfg.ZipCountryPrefix)
+	if cfg.NormalizeKeys {
+		n.keyNormalizer = NewKeyNormalizer(cfg.AcronymPreservation)
+	}
+	return n
+}
+
+// NormalizeKeys returns the payload with camelCase keys converted to snake_case
+// when key normalization is enabled, or the payload unchanged otherwise
+func (n *Normalizer) NormalizeKeys(payload models.JSONB) models.JSONB {
+	if n.keyNormalizer == nil {
+		return payload
+	}
+	return n.keyNormalizer.NormalizeKeys(payload)
+}
+
 // NormalizeLead normalizes all fields in a lead payload
 // Requirements: 3.3, 3.4
 func (n *Normalizer) NormalizeLead(rawPayload models.JSONB) models.JSONB {
@@ -99,7 +152,96 @@ func (n *Normalizer) NormalizePhone(phone string) string {
 	// Join all digits together
 	normalized := strings.Join(digits, "")
 	
-	return normalized
+	return n.applyCountryCode(phone, normalized)
+}
+
+// applyCountryCode prepends or strips the default country code according to the
+// configured mode. A number counts as international if it was written with a
+// leading "+" or "00", or - when a trunk prefix is configured - if it starts with
+// the default country code instead of the trunk prefix. International numbers of
+// other countries are never changed.
+func (n *Normalizer) applyCountryCode(raw string, digits string) string {
+	mode := n.phoneCountryCodeMode
+	if digits == "" || (mode != config.PhoneCountryCodePrepend && mode != config.PhoneCountryCodeStrip) {
+		return digits
+	}
+	
+	raw = strings.TrimSpace(raw)
+	international := strings.HasPrefix(raw, "+") || strings.HasPrefix(digits, "00")
+	if strings.HasPrefix(digits, "00") {
+		digits = digits[2:]
+	}
+	if !international && n.phoneTrunkPrefix != "" &&
+		!strings.HasPrefix(digits, n.phoneTrunkPrefix) && strings.HasPrefix(digits, n.phoneDefaultCC) {
+		international = true
+	}
+	
+	// Subscriber number without country code or trunk prefix
+	var national string
+	switch {
+	case !international:
+		national = strings.TrimPrefix(digits, n.phoneTrunkPrefix)
+	case strings.HasPrefix(digits, n.phoneDefaultCC):
+		national = digits[len(n.phoneDefaultCC):]
+	default:
+		return digits
+	}
+	
+	if mode == config.PhoneCountryCodePrepend {
+		return n.phoneDefaultCC + national
+	}
+	return n.phoneTrunkPrefix + national
+}
+
+// NormalizeZip canonicalizes a zip code: it is trimmed and uppercased, the configured
+// country prefix (e.g. "DE-66123") and a ZIP+4 suffix (e.g. "66123-4567") are removed,
+// and everything but letters and digits is stripped
+func (n *Normalizer) NormalizeZip(zip string) string {
+	zip = strings.ToUpper(strings.TrimSpace(zip))
+	
+	// The prefix is only stripped when no letter follows, so it is never taken from a word
+	if n.zipCountryPrefix != "" && strings.HasPrefix(zip, n.zipCountryPrefix) {
+		rest := zip[len(n.zipCountryPrefix):]
+		if rest != "" && (rest[0] < 'A' || rest[0] > 'Z') {
+			zip = rest
+		}
+	}
+	
+	zip = strings.TrimFunc(zip, func(r rune) bool {
+		return (r < '0' || r > '9') && (r < 'A' || r > 'Z')
+	})
+	if match := zipPlus4Pattern.FindStringSubmatch(zip); match != nil {
+		zip = match[1]
+	}
+	
+	return zipSeparatorPattern.ReplaceAllString(zip, "")
+}
+
+// NormalizeZipFields returns a copy of the payload with its zip code fields canonicalized
+// when zip normalization is enabled, or the payload unchanged otherwise. It lets the
+// validator see the same zip code that normalization later stores.
+func (n *Normalizer) NormalizeZipFields(payload models.JSONB) models.JSONB {
+	if !n.normalizeZip {
+		return payload
+	}
+	result := make(models.JSONB, len(payload))
+	for key, value := range payload {
+		if zip, ok := value.(string); ok && isZipField(key) {
+			result[key] = n.NormalizeZip(zip)
+		} else {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// isZipField reports whether a top-level field holds a zip code
+func isZipField(key string) bool {
+	switch key {
+	case "zipcode", "zip", "zip_code", "postal_code":
+		return true
+	}
+	return false
 }
 
 // NormalizeBooleanString converts string representations of booleans to actual booleans
@@ -140,31 +282,87 @@ func (n *Normalizer) TrimString(s string) string {
 // Applies special normalization rules for known fields like email and phone
 // Requirement: 3.3, 3.4
 func (n *Normalizer) NormalizeLeadWithFieldMapping(rawPayload models.JSONB) models.JSONB {
+	// Field handling below relies on snake_case keys
+	rawPayload = n.NormalizeKeys(rawPayload)
 	normalized := make(models.JSONB)
 	
 	for key, value := range rawPayload {
-		switch key {
-		case "email":
-			// Special handling for email fields
-			if email, ok := value.(string); ok {
-				normalized[key] = n.NormalizeEmail(email)
-			} else {
-				normalized[key] = value
-			}
-			
-		case "phone", "phone_number", "telephone":
-			// Special handling for phone fields
-			if phone, ok := value.(string); ok {
-				normalized[key] = n.NormalizePhone(phone)
+		normalized[key], _ = n.normalizeField(key, value)
+	}
+	
+	// Aliases are matched against the trimmed values
+	return n.ApplyValueAliases(normalized)
+}
+
+// NormalizeField normalizes a value as the top-level field key of a lead, like
+// NormalizeLeadWithFieldMapping does
+func (n *Normalizer) NormalizeField(key string, value interface{}) interface{} {
+	normalized, _ := n.normalizeField(key, value)
+	return n.ApplyValueAliases(models.JSONB{key: normalized})[key]
+}
+
+// normalizeField normalizes the value of a top-level field and returns the transformer
+// that applies to it, see NormalizeLeadWithAudit
+func (n *Normalizer) normalizeField(key string, value interface{}) (interface{}, string) {
+	// Zip code fields are handled like other fields unless zip normalization is enabled
+	if zip, ok := value.(string); ok && n.normalizeZip && isZipField(key) {
+		return n.NormalizeZip(zip), TransformerZip
+	}
+	
+	switch key {
+	case "email":
+		// Special handling for email fields
+		if email, ok := value.(string); ok {
+			return n.NormalizeEmail(email), TransformerEmail
+		}
+		return value, TransformerEmail
+		
+	case "phone", "phone_number", "telephone":
+		// Special handling for phone fields; a number decoded as json.Number keeps all digits
+		if phone, ok := value.(string); ok {
+			return n.NormalizePhone(phone), TransformerPhone
+		} else if number, ok := value.(json.Number); ok {
+			return n.NormalizePhone(number.String()), TransformerPhone
+		}
+		return value, TransformerPhone
+		
+	default:
+		// Default normalization for other fields
+		return n.normalizeValue(value), TransformerWhitespace
+	}
+}
+
+// ApplyValueAliases returns a copy of the payload with configured value aliases applied.
+// String values are trimmed before they are looked up; values without an alias are kept.
+// The payload is returned unchanged when no aliases are configured.
+func (n *Normalizer) ApplyValueAliases(payload models.JSONB) models.JSONB {
+	if len(n.valueAliases) == 0 {
+		return payload
+	}
+	return n.applyValueAliases("", payload)
+}
+
+// applyValueAliases applies the aliases to an object whose fields are addressed below prefix
+func (n *Normalizer) applyValueAliases(prefix string, object map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(object))
+	for key, value := range object {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		
+		switch v := value.(type) {
+		case map[string]interface{}:
+			result[key] = n.applyValueAliases(path, v)
+		case string:
+			if alias, ok := n.valueAliases[path][strings.TrimSpace(v)]; ok {
+				result[key] = alias
 			} else {
-				normalized[key] = value
+				result[key] = v
 			}
-			
 		default:
-			// Default normalization for other fields
-			normalized[key] = n.normalizeValue(value)
+			result[key] = value
 		}
 	}
-	
-	return normalized
+	return result
 }
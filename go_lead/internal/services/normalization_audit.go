@@ -0,0 +1,85 @@
+package services
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// Transformers named in normalization audits
+const (
+	TransformerEmail      = "email"       // NormalizeEmail
+	TransformerPhone      = "phone"       // NormalizePhone
+	TransformerZip        = "zip"         // NormalizeZip
+	TransformerWhitespace = "whitespace"  // trimming and whitespace cleanup of other fields
+	TransformerValueAlias = "value_alias" // configured value aliases
+)
+
+// NormalizeLeadWithAudit normalizes a lead like NormalizeLeadWithFieldMapping and also
+// returns one audit record per field whose value normalization changed, sorted by field.
+// Nested objects are compared field by field, other values as a whole. Converting keys to
+// snake_case changes no values, so fields are named by their normalized keys. A value changed
+// by several transformers lists them in order; a value changed back to its original is not
+// recorded. LeadID and Timestamp of the records are left to the caller.
+func (n *Normalizer) NormalizeLeadWithAudit(rawPayload models.JSONB) (models.JSONB, []models.NormalizationAudit) {
+	rawPayload = n.NormalizeKeys(rawPayload)
+	normalized := make(models.JSONB, len(rawPayload))
+	changes := make(map[string]*models.NormalizationAudit)
+
+	for key, value := range rawPayload {
+		var transformer string
+		normalized[key], transformer = n.normalizeField(key, value)
+		recordNormalization(key, value, normalized[key], transformer, changes)
+	}
+
+	aliased := n.ApplyValueAliases(normalized)
+	for key, value := range aliased {
+		recordNormalization(key, normalized[key], value, TransformerValueAlias, changes)
+	}
+
+	audits := make([]models.NormalizationAudit, 0, len(changes))
+	for _, change := range changes {
+		if !reflect.DeepEqual(change.OriginalValue, change.NormalizedValue) {
+			audits = append(audits, *change)
+		}
+	}
+	sort.Slice(audits, func(i, j int) bool { return audits[i].Field < audits[j].Field })
+	return aliased, audits
+}
+
+// recordNormalization adds the leaf values that differ between before and after to changes,
+// merging them with the changes of earlier transformers
+func recordNormalization(field string, before, after interface{}, transformer string, changes map[string]*models.NormalizationAudit) {
+	beforeObject, beforeIsObject := before.(map[string]interface{})
+	afterObject, afterIsObject := after.(map[string]interface{})
+	if beforeIsObject && afterIsObject {
+		for key, value := range beforeObject {
+			recordNormalization(field+"."+key, value, afterObject[key], transformer, changes)
+		}
+		for key, value := range afterObject {
+			if _, ok := beforeObject[key]; !ok {
+				recordNormalization(field+"."+key, nil, value, transformer, changes)
+			}
+		}
+		return
+	}
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+
+	if change, ok := changes[field]; ok {
+		change.NormalizedValue = after
+		if !strings.Contains(","+change.TransformerApplied+",", ","+transformer+",") {
+			change.TransformerApplied += "," + transformer
+		}
+		return
+	}
+	changes[field] = &models.NormalizationAudit{
+		Field:              field,
+		OriginalValue:      before,
+		NormalizedValue:    after,
+		TransformerApplied: transformer,
+	}
+}
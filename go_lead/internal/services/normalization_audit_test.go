@@ -0,0 +1,71 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+func TestNormalizeLeadWithAudit_OneRecordPerChangedField(t *testing.T) {
+	normalizer := NewNormalizerWithValueAliases(map[string]map[string]interface{}{
+		"house.is_owner": {"yes": true},
+		"roof_type":      {"Satteldach": "pitched"},
+	})
+
+	input := models.JSONB{
+		"email":     " Max@Example.COM ",
+		"phone":     "+49 (170) 123-4567",
+		"zipcode":   "66123",            // unchanged
+		"city":      "  Saar   brücken", // changed by whitespace cleanup
+		"roof_type": "Satteldach",
+		"house": map[string]interface{}{
+			"is_owner":  " yes ",
+			"roof_area": 85.5, // unchanged
+		},
+	}
+
+	normalized, audits := normalizer.NormalizeLeadWithAudit(input)
+
+	// The payload is the same as without the audit
+	if expected := normalizer.NormalizeLeadWithFieldMapping(input); !reflect.DeepEqual(normalized, expected) {
+		t.Errorf("Expected %v, got %v", expected, normalized)
+	}
+
+	expected := []models.NormalizationAudit{
+		{Field: "city", OriginalValue: "  Saar   brücken", NormalizedValue: "Saar brücken", TransformerApplied: TransformerWhitespace},
+		{Field: "email", OriginalValue: " Max@Example.COM ", NormalizedValue: "max@example.com", TransformerApplied: TransformerEmail},
+		{Field: "house.is_owner", OriginalValue: " yes ", NormalizedValue: true, TransformerApplied: "whitespace,value_alias"},
+		{Field: "phone", OriginalValue: "+49 (170) 123-4567", NormalizedValue: "491701234567", TransformerApplied: TransformerPhone},
+		{Field: "roof_type", OriginalValue: "Satteldach", NormalizedValue: "pitched", TransformerApplied: TransformerValueAlias},
+	}
+	if !reflect.DeepEqual(audits, expected) {
+		t.Errorf("Expected one record per changed field\n%+v\ngot\n%+v", expected, audits)
+	}
+}
+
+func TestNormalizeLeadWithAudit_NoChanges(t *testing.T) {
+	_, audits := NewNormalizer().NormalizeLeadWithAudit(models.JSONB{
+		"email":   "max@example.com",
+		"zipcode": "66123",
+		"tags":    []interface{}{"solar"},
+	})
+	if len(audits) != 0 {
+		t.Errorf("Expected no records for an already normalized lead, got %+v", audits)
+	}
+}
+
+func TestNormalizeLeadWithAudit_NormalizedKeys(t *testing.T) {
+	normalizer := NewNormalizerFromConfig(config.NormalizerConfig{NormalizeKeys: true})
+
+	_, audits := normalizer.NormalizeLeadWithAudit(models.JSONB{"firstName": "Max", "lastName": " Mustermann "})
+
+	// Renaming a key is not a value change; fields are named by their normalized keys
+	expected := []models.NormalizationAudit{
+		{Field: "last_name", OriginalValue: " Mustermann ", NormalizedValue: "Mustermann", TransformerApplied: TransformerWhitespace},
+	}
+	if !reflect.DeepEqual(audits, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, audits)
+	}
+}
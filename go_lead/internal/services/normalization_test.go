@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/checkfox/go_lead/internal/config"
 	"github.com/checkfox/go_lead/internal/models"
 )
 
@@ -73,6 +74,117 @@ func TestNormalizePhone(t *testing.T) {
 	}
 }
 
+// Test country code handling of phone numbers
+func TestNormalizePhone_CountryCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mode     string
+		trunk    string
+		input    string
+		expected string
+	}{
+		// Prepend when absent
+		{"prepend to national number", config.PhoneCountryCodePrepend, "0", "0151 1234567", "491511234567"},
+		{"prepend without trunk prefix", config.PhoneCountryCodePrepend, "", "151 1234567", "491511234567"},
+		{"prepend keeps plus number", config.PhoneCountryCodePrepend, "0", "+49 151 1234567", "491511234567"},
+		{"prepend keeps 00 number", config.PhoneCountryCodePrepend, "0", "0049 151 1234567", "491511234567"},
+		{"prepend keeps bare international number", config.PhoneCountryCodePrepend, "0", "491511234567", "491511234567"},
+		{"prepend keeps foreign number", config.PhoneCountryCodePrepend, "0", "+1 555 123 4567", "15551234567"},
+		// Strip when present
+		{"strip plus number", config.PhoneCountryCodeStrip, "0", "+49 151 1234567", "01511234567"},
+		{"strip 00 number", config.PhoneCountryCodeStrip, "0", "0049 151 1234567", "01511234567"},
+		{"strip bare international number", config.PhoneCountryCodeStrip, "0", "491511234567", "01511234567"},
+		{"strip without trunk prefix", config.PhoneCountryCodeStrip, "", "+49 151 1234567", "1511234567"},
+		{"strip keeps national number", config.PhoneCountryCodeStrip, "0", "0151 1234567", "01511234567"},
+		{"strip keeps foreign number", config.PhoneCountryCodeStrip, "0", "+1 555 123 4567", "15551234567"},
+		// Keep
+		{"keep national number", config.PhoneCountryCodeKeep, "0", "0151 1234567", "01511234567"},
+		{"keep international number", config.PhoneCountryCodeKeep, "0", "+49 151 1234567", "491511234567"},
+		{"empty string", config.PhoneCountryCodePrepend, "0", "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			normalizer := NewNormalizerFromConfig(config.NormalizerConfig{
+				PhoneCountryCodeMode: tc.mode,
+				PhoneDefaultCC:       "49",
+				PhoneTrunkPrefix:     tc.trunk,
+			})
+			result := normalizer.NormalizePhone(tc.input)
+			if result != tc.expected {
+				t.Errorf("NormalizePhone(%q) = %q, expected %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+// Test zip code canonicalization
+func TestNormalizeZip(t *testing.T) {
+	normalizer := NewNormalizerFromConfig(config.NormalizerConfig{NormalizeZip: true, ZipCountryPrefix: "DE"})
+	
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain", "66123", "66123"},
+		{"whitespace padded", " 66123 ", "66123"},
+		{"plus-4 suffix", "66123-4567", "66123"},
+		{"plus-4 suffix with space", "66123 4567", "66123"},
+		{"plus-4 suffix without separator", "661234567", "66123"},
+		{"country prefix with dash", "DE-66123", "66123"},
+		{"country prefix with space", "de 66123", "66123"},
+		{"country prefix without separator", "DE66123", "66123"},
+		{"country prefix and plus-4 suffix", " DE-66123-4567 ", "66123"},
+		{"inner separators", "66 123", "66123"},
+		{"other country prefix kept", "AT-6612", "AT6612"},
+		{"alphanumeric zip uppercased", "sw1a 1aa", "SW1A1AA"},
+		{"empty string", "", ""},
+	}
+	
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := normalizer.NormalizeZip(tc.input)
+			if result != tc.expected {
+				t.Errorf("NormalizeZip(%q) = %q, expected %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+// Test normalized zip codes pass the zip code rule of the validator
+func TestNormalizeZipFields_PassesValidation(t *testing.T) {
+	validator := NewValidator()
+	
+	for _, zip := range []string{"66123-4567", "DE-66123", "  66123  "} {
+		payload := models.JSONB{
+			"zipcode": zip,
+			"house":   map[string]interface{}{"is_owner": true},
+		}
+		
+		if result := validator.ValidateLead(payload); result.Valid {
+			t.Errorf("Expected %q to fail validation without zip normalization", zip)
+		}
+		if result := validator.ValidateLead(NewNormalizer().NormalizeZipFields(payload)); result.Valid {
+			t.Errorf("Expected %q to stay unchanged with zip normalization disabled", zip)
+		}
+		
+		normalizer := NewNormalizerFromConfig(config.NormalizerConfig{NormalizeZip: true, ZipCountryPrefix: "DE"})
+		normalized := normalizer.NormalizeZipFields(payload)
+		if normalized["zipcode"] != "66123" {
+			t.Errorf("Expected %q to normalize to 66123, got %v", zip, normalized["zipcode"])
+		}
+		if result := validator.ValidateLead(normalized); !result.Valid {
+			t.Errorf("Expected normalized %q to pass validation, got %v", zip, result.Errors)
+		}
+		
+		// The stored normalized payload carries the same zip code
+		if stored := normalizer.NormalizeLeadWithFieldMapping(payload); stored["zipcode"] != "66123" {
+			t.Errorf("Expected the normalized lead to carry 66123, got %v", stored["zipcode"])
+		}
+	}
+}
+
 // Test whitespace handling
 // Requirement: 3.3
 func TestTrimString(t *testing.T) {
@@ -272,6 +384,66 @@ func TestNormalizeLeadWithFieldMapping(t *testing.T) {
 	}
 }
 
+// Test configured value aliases are applied after trimming
+func TestNormalizeLeadWithFieldMapping_ValueAliases(t *testing.T) {
+	normalizer := NewNormalizerWithValueAliases(map[string]map[string]interface{}{
+		"house.is_owner": {"yes": true, "own": true, "rent": false},
+		"roof_type":      {"Satteldach": "pitched"},
+	})
+	
+	input := models.JSONB{
+		"house": map[string]interface{}{
+			"is_owner": "  own ",
+		},
+		"roof_type": "Satteldach",
+		"is_owner":  "yes", // aliases apply to the configured path only
+	}
+	
+	result := normalizer.NormalizeLeadWithFieldMapping(input)
+	
+	house, ok := result["house"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected house to be an object, got %T", result["house"])
+	}
+	if house["is_owner"] != true {
+		t.Errorf("Expected house.is_owner alias true, got %#v", house["is_owner"])
+	}
+	if result["roof_type"] != "pitched" {
+		t.Errorf("Expected roof_type alias 'pitched', got %#v", result["roof_type"])
+	}
+	if result["is_owner"] != "yes" {
+		t.Errorf("Expected top-level is_owner to be left alone, got %#v", result["is_owner"])
+	}
+}
+
+// Test an aliased is_owner value passes homeowner validation
+func TestApplyValueAliases_AllowsAliasedHomeowner(t *testing.T) {
+	validator := NewValidator()
+	raw := models.JSONB{
+		"zipcode": "66123",
+		"house": map[string]interface{}{
+			"is_owner": "yes",
+		},
+	}
+	
+	if result := validator.ValidateLead(NewNormalizer().ApplyValueAliases(raw)); result.Valid {
+		t.Fatal("Expected non-boolean is_owner to be rejected without aliases")
+	}
+	
+	normalizer := NewNormalizerWithValueAliases(map[string]map[string]interface{}{
+		"house.is_owner": {"yes": true},
+	})
+	result := validator.ValidateLead(normalizer.ApplyValueAliases(raw))
+	if !result.Valid {
+		t.Errorf("Expected aliased is_owner to pass validation, got %v", result.Errors)
+	}
+	
+	// The raw payload itself is left untouched
+	if raw["house"].(map[string]interface{})["is_owner"] != "yes" {
+		t.Error("Expected ApplyValueAliases not to modify its input")
+	}
+}
+
 // Test normalizing strings with multiple spaces
 // Requirement: 3.3
 func TestNormalizeString_MultipleSpaces(t *testing.T) {
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/policy"
+)
+
+// maxRejectionReasonBytes is the size of the inbound_lead.rejection_reason column
+const maxRejectionReasonBytes = 100
+
+// PolicyValidationStage rejects leads for which a configured policy evaluates to false
+type PolicyValidationStage struct {
+	programs []*policy.Program
+}
+
+// NewPolicyValidationStage creates a new PolicyValidationStage, compiling the policies once.
+// It fails on a policy that does not compile, so that the POLICY_VIOLATION index of every
+// policy stays its index in the configuration.
+func NewPolicyValidationStage(policies []string) (*PolicyValidationStage, error) {
+	s := &PolicyValidationStage{}
+	for i, expr := range policies {
+		program, err := policy.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy at index %d: %w", i, err)
+		}
+		s.programs = append(s.programs, program)
+	}
+	return s, nil
+}
+
+// Validate evaluates the policies in order and rejects the payload on the first one that
+// is false. A policy that cannot be evaluated, e.g. because it selects a field the payload
+// lacks, does not apply to the lead and is skipped.
+func (s *PolicyValidationStage) Validate(payload models.JSONB) *ValidationResult {
+	result := &ValidationResult{
+		Valid:  true,
+		Errors: []string{},
+	}
+
+	for i, program := range s.programs {
+		passed, err := program.Eval(payload)
+		if err != nil {
+			log.Printf("[VALIDATION] Policy %d could not be evaluated, skipping: %v", i, err)
+			continue
+		}
+		if passed {
+			continue
+		}
+
+		log.Printf("[VALIDATION] Policy %d failed: %s", i, program)
+		result.Valid = false
+		reason := policyRejectionReason(i, program.String())
+		result.RejectionReason = &reason
+		result.Errors = append(result.Errors, fmt.Sprintf("policy %d failed: %s", i, program))
+		result.Context = map[string]string{
+			"policy_index": strconv.Itoa(i),
+			"policy":       program.String(),
+		}
+		return result // Return immediately on first failure
+	}
+
+	return result
+}
+
+// policyRejectionReason names the failed policy by index and text, e.g.
+// "POLICY_VIOLATION[0]: age >= 18", cut to fit the rejection_reason column
+func policyRejectionReason(index int, expr string) models.RejectionReason {
+	reason := fmt.Sprintf("%s[%d]: %s", models.RejectionReasonPolicyViolation, index, expr)
+	if len(reason) > maxRejectionReasonBytes {
+		reason = reason[:maxRejectionReasonBytes]
+		for !utf8.ValidString(reason) {
+			reason = reason[:len(reason)-1]
+		}
+	}
+	return models.RejectionReason(reason)
+}
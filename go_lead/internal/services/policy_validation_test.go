@@ -0,0 +1,113 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+var testPolicies = []string{
+	`!has(age) || age >= 18`,
+	`!has(house.roof_area) || house.roof_area >= 20`,
+}
+
+// newTestPolicyStage compiles policies, failing the test if one is invalid
+func newTestPolicyStage(t *testing.T, policies []string) *PolicyValidationStage {
+	t.Helper()
+	stage, err := NewPolicyValidationStage(policies)
+	if err != nil {
+		t.Fatalf("Failed to compile policies: %v", err)
+	}
+	return stage
+}
+
+func TestPolicyValidation_Pass(t *testing.T) {
+	stage := newTestPolicyStage(t, testPolicies)
+	payloads := []models.JSONB{
+		{"age": 42.0, "house": map[string]interface{}{"roof_area": 85.5}},
+		{"age": 18.0},
+		{},
+	}
+	for _, payload := range payloads {
+		if result := stage.Validate(payload); !result.Valid {
+			t.Errorf("Expected %v to pass, got %v", payload, result.Errors)
+		}
+	}
+}
+
+func TestPolicyValidation_Fail(t *testing.T) {
+	stage := newTestPolicyStage(t, testPolicies)
+	result := stage.Validate(models.JSONB{"age": 42.0, "house": map[string]interface{}{"roof_area": 12.0}})
+	if result.Valid {
+		t.Fatal("Expected the lead to be rejected")
+	}
+
+	expected := models.RejectionReason("POLICY_VIOLATION[1]: !has(house.roof_area) || house.roof_area >= 20")
+	if result.RejectionReason == nil || *result.RejectionReason != expected {
+		t.Errorf("Expected rejection reason %s, got %v", expected, result.RejectionReason)
+	}
+	if result.Context["policy_index"] != "1" || result.Context["policy"] != testPolicies[1] {
+		t.Errorf("Expected the policy in the context, got %v", result.Context)
+	}
+}
+
+func TestPolicyValidation_MissingFields(t *testing.T) {
+	// Policies selecting fields the payload lacks don't apply and must not panic
+	stage := newTestPolicyStage(t, []string{
+		`email.endsWith("@example.com")`,
+		`house.roof_area >= 20`,
+		`contact.address.city == "Saarbrücken"`,
+	})
+	payloads := []models.JSONB{
+		{},
+		{"house": "not an object"},
+		{"contact": map[string]interface{}{"address": nil}},
+	}
+	for _, payload := range payloads {
+		if result := stage.Validate(payload); !result.Valid {
+			t.Errorf("Expected %v to be skipped, got %v", payload, result.Errors)
+		}
+	}
+}
+
+func TestNewPolicyValidationStage_InvalidPolicy(t *testing.T) {
+	// An invalid policy must not be skipped, which would shift the index of the next one
+	_, err := NewPolicyValidationStage([]string{`age >= 18`, `age >=`, `has(email)`})
+	if err == nil || !strings.Contains(err.Error(), "invalid policy at index 1") {
+		t.Errorf("Expected an error for the policy at index 1, got %v", err)
+	}
+	if _, err := NewValidatorFromConfig(config.ValidationConfig{Policies: []string{`size(`}}); err == nil {
+		t.Error("Expected the validator to fail on an invalid policy")
+	}
+}
+
+func TestPolicyRejectionReason_Truncated(t *testing.T) {
+	reason := policyRejectionReason(3, "zipcode.startsWith('66') && "+strings.Repeat("ä", 60))
+	if len(reason) > maxRejectionReasonBytes {
+		t.Errorf("Expected at most %d bytes, got %d", maxRejectionReasonBytes, len(reason))
+	}
+	if !strings.HasPrefix(string(reason), "POLICY_VIOLATION[3]: zipcode") || strings.ContainsRune(string(reason), '�') {
+		t.Errorf("Unexpected truncated reason %q", reason)
+	}
+}
+
+func TestValidateLead_Policies(t *testing.T) {
+	validator := newTestValidator(t, config.ValidationConfig{Policies: testPolicies})
+	payload := models.JSONB{
+		"zipcode": "66123",
+		"house":   map[string]interface{}{"is_owner": true},
+		"age":     16.0,
+	}
+
+	result := validator.ValidateLead(payload)
+	if result.Valid || result.RejectionReason == nil || !strings.HasPrefix(string(*result.RejectionReason), "POLICY_VIOLATION[0]") {
+		t.Errorf("Expected policy 0 to reject the lead, got %+v", result)
+	}
+
+	payload["age"] = 30.0
+	if result := validator.ValidateLead(payload); !result.Valid {
+		t.Errorf("Expected the lead to pass, got %v", result.Errors)
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// SpamFilterStage rejects obvious bot and test submissions matching a configured
+// deny pattern for the email, phone or name of a lead
+type SpamFilterStage struct {
+	emailPatterns []*regexp.Regexp
+	phoneValues   map[string]bool
+	namePatterns  []*regexp.Regexp
+}
+
+// NewSpamFilterStage creates a new SpamFilterStage, compiling the deny patterns once.
+// Patterns match case-insensitively; phone values are compared by their digits only.
+func NewSpamFilterStage(cfg config.ValidationConfig) *SpamFilterStage {
+	s := &SpamFilterStage{
+		emailPatterns: compileDenyPatterns(cfg.DenyEmailPatterns),
+		phoneValues:   make(map[string]bool, len(cfg.DenyPhoneValues)),
+		namePatterns:  compileDenyPatterns(cfg.DenyNamePatterns),
+	}
+	for _, phone := range cfg.DenyPhoneValues {
+		if digits := phoneDigits(phone); digits != "" {
+			s.phoneValues[digits] = true
+		}
+	}
+	return s
+}
+
+// compileDenyPatterns compiles case-insensitive deny patterns
+func compileDenyPatterns(patterns []string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		// Patterns are validated when the config is loaded
+		if re, err := regexp.Compile("(?i)" + pattern); err == nil {
+			compiled = append(compiled, re)
+		}
+	}
+	return compiled
+}
+
+// Validate rejects the payload if its email, phone or name matches a deny pattern
+func (s *SpamFilterStage) Validate(payload models.JSONB) *ValidationResult {
+	result := &ValidationResult{
+		Valid:  true,
+		Errors: []string{},
+	}
+
+	field, ok := s.match(payload)
+	if !ok {
+		return result
+	}
+
+	log.Printf("[VALIDATION] Field '%s' matches a deny pattern", field)
+	result.Valid = false
+	reason := models.RejectionReasonSpamFilter
+	result.RejectionReason = &reason
+	result.Errors = append(result.Errors, fmt.Sprintf("%s matches a deny pattern", field))
+	result.Context = map[string]string{"field": field}
+	return result
+}
+
+// match returns the first field matching a deny pattern
+func (s *SpamFilterStage) match(payload models.JSONB) (string, bool) {
+	if email, ok := payload["email"].(string); ok && email != "" {
+		for _, re := range s.emailPatterns {
+			if re.MatchString(strings.TrimSpace(email)) {
+				return "email", true
+			}
+		}
+	}
+
+	if phone, ok := payload["phone"].(string); ok && s.phoneValues[phoneDigits(phone)] {
+		return "phone", true
+	}
+
+	if name := leadName(payload); name != "" {
+		for _, re := range s.namePatterns {
+			if re.MatchString(name) {
+				return "name", true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// leadName returns the name field of the payload, or first_name and last_name
+// joined by a space, with surrounding whitespace removed
+func leadName(payload models.JSONB) string {
+	if name, ok := payload["name"].(string); ok && strings.TrimSpace(name) != "" {
+		return strings.TrimSpace(name)
+	}
+	first, _ := payload["first_name"].(string)
+	last, _ := payload["last_name"].(string)
+	return strings.TrimSpace(strings.TrimSpace(first) + " " + strings.TrimSpace(last))
+}
+
+// phoneDigits returns the digits of a phone number
+func phoneDigits(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
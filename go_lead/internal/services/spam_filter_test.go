@@ -0,0 +1,109 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+var denyConfig = config.ValidationConfig{
+	DenyEmailPatterns: []string{`@test\.com$`, `^bot\d*@`},
+	DenyPhoneValues:   []string{"0000000000", "+49 123 456"},
+	DenyNamePatterns:  []string{`^test test$`, `^asdf`},
+}
+
+func validLeadPayload() models.JSONB {
+	return models.JSONB{
+		"zipcode": "66123",
+		"house":   map[string]interface{}{"is_owner": true},
+	}
+}
+
+func TestSpamFilter_DeniedSamples(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		key   string
+		value string
+	}{
+		{"email domain", "email", "email", "someone@test.com"},
+		{"email domain uppercase", "email", "email", "Someone@TEST.com"},
+		{"email prefix", "email", "email", "bot42@example.org"},
+		{"phone exact", "phone", "phone", "0000000000"},
+		{"phone formatted", "phone", "phone", "000-000-0000"},
+		{"phone with country code", "phone", "phone", "+49123456"},
+		{"name exact", "name", "name", "Test Test"},
+		{"name prefix", "name", "name", "asdfgh"},
+	}
+
+	validator := newTestValidator(t, denyConfig)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := validLeadPayload()
+			payload[tt.key] = tt.value
+
+			result := validator.ValidateLead(payload)
+			if result.Valid {
+				t.Fatalf("Expected %q to be denied", tt.value)
+			}
+			if result.RejectionReason == nil || *result.RejectionReason != models.RejectionReasonSpamFilter {
+				t.Errorf("Expected rejection reason %s, got %v", models.RejectionReasonSpamFilter, result.RejectionReason)
+			}
+			if result.Context["field"] != tt.field {
+				t.Errorf("Expected field %s in context, got %v", tt.field, result.Context)
+			}
+		})
+	}
+}
+
+func TestSpamFilter_AllowedSamples(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{"email other domain", "email", "someone@example.com"},
+		{"email containing test", "email", "test@contest.com.au"},
+		{"email bot later", "email", "robot@example.org"},
+		{"phone", "phone", "01701234567"},
+		{"phone containing zeros", "phone", "00000000001"},
+		{"name", "name", "Max Mustermann"},
+		{"name containing test", "name", "Testa Testarossa"},
+	}
+
+	validator := newTestValidator(t, denyConfig)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := validLeadPayload()
+			payload[tt.key] = tt.value
+
+			if result := validator.ValidateLead(payload); !result.Valid {
+				t.Errorf("Expected %q to be allowed, got errors %v", tt.value, result.Errors)
+			}
+		})
+	}
+}
+
+func TestSpamFilter_FirstAndLastName(t *testing.T) {
+	stage := NewSpamFilterStage(denyConfig)
+
+	payload := models.JSONB{"first_name": " test", "last_name": "TEST "}
+	if result := stage.Validate(payload); result.Valid {
+		t.Error("Expected first_name and last_name 'test test' to be denied")
+	}
+
+	payload = models.JSONB{"first_name": "test", "last_name": "Müller"}
+	if result := stage.Validate(payload); !result.Valid {
+		t.Errorf("Expected 'test Müller' to be allowed, got errors %v", result.Errors)
+	}
+}
+
+func TestSpamFilter_NoPatterns(t *testing.T) {
+	stage := NewSpamFilterStage(config.ValidationConfig{})
+
+	payload := models.JSONB{"email": "someone@test.com", "phone": "0000000000", "name": "test test"}
+	if result := stage.Validate(payload); !result.Valid {
+		t.Errorf("Expected no deny patterns to allow every lead, got errors %v", result.Errors)
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// SuppressionStage permanently rejects leads whose field values match a suppression rule,
+// e.g. known test email addresses or blocked names
+type SuppressionStage struct {
+	rules []compiledSuppressionRule
+}
+
+// compiledSuppressionRule is a suppression rule with its pattern compiled
+type compiledSuppressionRule struct {
+	field   string
+	pattern *regexp.Regexp
+	reason  models.RejectionReason
+}
+
+// NewSuppressionStage creates a new SuppressionStage, compiling the rule patterns once
+func NewSuppressionStage(rules []config.SuppressionRule) *SuppressionStage {
+	s := &SuppressionStage{}
+	for _, rule := range rules {
+		// Patterns are validated when the rules are loaded
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			continue
+		}
+		reason := rule.Reason
+		if reason == "" {
+			reason = models.RejectionReasonSuppressed
+		}
+		s.rules = append(s.rules, compiledSuppressionRule{field: rule.Field, pattern: pattern, reason: reason})
+	}
+	return s
+}
+
+// Validate rejects the payload with the reason of the first matching rule
+func (s *SuppressionStage) Validate(payload models.JSONB) *ValidationResult {
+	result := &ValidationResult{
+		Valid:  true,
+		Errors: []string{},
+	}
+
+	for _, rule := range s.rules {
+		value, ok := scalarString(fieldValue(payload, rule.field))
+		if !ok || !rule.pattern.MatchString(value) {
+			continue
+		}
+
+		log.Printf("[VALIDATION] Field '%s' matches suppression pattern '%s'", rule.field, rule.pattern)
+		result.Valid = false
+		reason := rule.reason
+		result.RejectionReason = &reason
+		result.Errors = append(result.Errors, fmt.Sprintf("%s matches suppression rule", rule.field))
+		result.Context = map[string]string{"field": rule.field}
+		return result // Return immediately on first match
+	}
+
+	return result
+}
+
+// fieldValue returns the value at the dot-separated path, or nil if it is absent
+func fieldValue(payload models.JSONB, path string) interface{} {
+	var current interface{} = map[string]interface{}(payload)
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if current, ok = object[key]; !ok {
+			return nil
+		}
+	}
+	return current
+}
+
+// scalarString returns a string, number or boolean payload value as a string
+func scalarString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
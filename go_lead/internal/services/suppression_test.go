@@ -0,0 +1,87 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+var suppressionRules = []config.SuppressionRule{
+	{Field: "email", Pattern: `.*@test\.com$`, Reason: "TEST_LEAD"},
+	{Field: "phone", Pattern: `^\+?49\s?151\s?0000`},
+	{Field: "contact.last_name", Pattern: `(?i)^mustermann$`, Reason: "BLOCKED_NAME"},
+}
+
+func TestSuppression_Match(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  models.JSONB
+		field    string
+		expected models.RejectionReason
+	}{
+		{"test email", models.JSONB{"email": "someone@test.com"}, "email", "TEST_LEAD"},
+		{"phone without reason", models.JSONB{"phone": "+49 151 00001234"}, "phone", models.RejectionReasonSuppressed},
+		{"phone as number", models.JSONB{"phone": json.Number("4915100001234")}, "phone", models.RejectionReasonSuppressed},
+		{"nested name", models.JSONB{"contact": map[string]interface{}{"last_name": "MUSTERMANN"}}, "contact.last_name", "BLOCKED_NAME"},
+	}
+
+	stage := NewSuppressionStage(suppressionRules)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := stage.Validate(tt.payload)
+			if result.Valid {
+				t.Fatal("Expected the lead to be suppressed")
+			}
+			if result.RejectionReason == nil || *result.RejectionReason != tt.expected {
+				t.Errorf("Expected rejection reason %s, got %v", tt.expected, result.RejectionReason)
+			}
+			if result.Context["field"] != tt.field {
+				t.Errorf("Expected field %s in context, got %v", tt.field, result.Context)
+			}
+		})
+	}
+}
+
+func TestSuppression_NoMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload models.JSONB
+	}{
+		{"other email", models.JSONB{"email": "someone@test.com.example.org"}},
+		{"other phone", models.JSONB{"phone": "+49 151 12345678"}},
+		{"other name", models.JSONB{"contact": map[string]interface{}{"last_name": "Musterfrau"}}},
+		{"name not nested", models.JSONB{"last_name": "Mustermann"}},
+		{"object value", models.JSONB{"email": map[string]interface{}{"address": "someone@test.com"}}},
+		{"empty payload", models.JSONB{}},
+	}
+
+	stage := NewSuppressionStage(suppressionRules)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := stage.Validate(tt.payload); !result.Valid {
+				t.Errorf("Expected the lead not to be suppressed, got errors %v", result.Errors)
+			}
+		})
+	}
+}
+
+func TestSuppression_RejectsRegardlessOfSeverity(t *testing.T) {
+	validator := newTestValidator(t, config.ValidationConfig{
+		RuleSeverities:   map[string]string{config.ValidationRuleZipcode: config.ValidationSeverityWarn},
+		SuppressionRules: suppressionRules,
+	})
+
+	result := validator.ValidateLead(models.JSONB{
+		"email":   "someone@test.com",
+		"zipcode": "66123",
+		"house":   map[string]interface{}{"is_owner": true},
+	})
+	if result.Valid {
+		t.Fatal("Expected an otherwise valid lead to be suppressed")
+	}
+	if *result.RejectionReason != "TEST_LEAD" {
+		t.Errorf("Expected rejection reason TEST_LEAD, got %s", *result.RejectionReason)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"regexp"
 
+	"github.com/checkfox/go_lead/internal/config"
 	"github.com/checkfox/go_lead/internal/models"
 )
 
@@ -13,23 +14,66 @@ type ValidationResult struct {
 	Valid           bool
 	RejectionReason *models.RejectionReason
 	Errors          []string
+	Context         map[string]string // extra detail about the failure, e.g. the offending field
+	Flags           []string          // failed warn-level rules of a valid lead, e.g. "soft_zip_mismatch"
 }
 
+// Flags recorded for failed rules whose severity is warn
+const (
+	FlagSoftZipMismatch           = "soft_zip_mismatch"
+	FlagSoftNotHomeowner          = "soft_not_homeowner"
+	FlagSoftMissingDependentField = "soft_missing_dependent_field"
+)
+
+// FlagSourceQuotaExceeded marks a lead accepted beyond its source's daily quota
+const FlagSourceQuotaExceeded = "source_quota_exceeded"
+
 // Validator provides lead validation functionality
 type Validator struct {
-	zipcodePattern *regexp.Regexp
+	zipcodePattern  *regexp.Regexp
+	dependencyStage *DependencyValidationStage
+	spamFilter      *SpamFilterStage
+	suppression     *SuppressionStage
+	policies        *PolicyValidationStage
+	severities      map[string]string
 }
 
 // NewValidator creates a new Validator instance
 func NewValidator() *Validator {
+	return NewValidatorWithDependencyRules(nil)
+}
+
+// NewValidatorWithDependencyRules creates a new Validator that also enforces cross-field dependency rules
+func NewValidatorWithDependencyRules(rules []config.FieldDependencyRule) *Validator {
 	// Compile the zipcode pattern: ^66\d{3}$
 	pattern := regexp.MustCompile(`^66\d{3}$`)
 	
 	return &Validator{
-		zipcodePattern: pattern,
+		zipcodePattern:  pattern,
+		dependencyStage: NewDependencyValidationStage(rules),
 	}
 }
 
+// NewValidatorFromConfig creates a new Validator with the configured dependency
+// rules, rule severities, spam deny patterns, suppression rules and policies
+func NewValidatorFromConfig(cfg config.ValidationConfig) (*Validator, error) {
+	policies, err := NewPolicyValidationStage(cfg.Policies)
+	if err != nil {
+		return nil, err
+	}
+	v := NewValidatorWithDependencyRules(cfg.DependencyRules)
+	v.severities = cfg.RuleSeverities
+	v.spamFilter = NewSpamFilterStage(cfg)
+	v.suppression = NewSuppressionStage(cfg.SuppressionRules)
+	v.policies = policies
+	return v, nil
+}
+
+// warns reports whether a failure of the given rule flags the lead instead of rejecting it
+func (v *Validator) warns(rule string) bool {
+	return v.severities[rule] == config.ValidationSeverityWarn
+}
+
 // ValidateLead validates a lead against all business rules
 // Requirements: 2.1, 2.2, 2.3, 2.4, 2.6
 func (v *Validator) ValidateLead(rawPayload models.JSONB) *ValidationResult {
@@ -38,27 +82,79 @@ func (v *Validator) ValidateLead(rawPayload models.JSONB) *ValidationResult {
 		Errors: []string{},
 	}
 	
+	// Drop bot and test submissions before any business rule
+	if v.spamFilter != nil {
+		if spamResult := v.spamFilter.Validate(rawPayload); !spamResult.Valid {
+			log.Printf("[VALIDATION] Spam filter matched payload")
+			return spamResult
+		}
+	}
+	
+	// Suppressed leads are rejected permanently, regardless of rule severities
+	if v.suppression != nil {
+		if suppressionResult := v.suppression.Validate(rawPayload); !suppressionResult.Valid {
+			log.Printf("[VALIDATION] Suppression rule matched payload")
+			return suppressionResult
+		}
+	}
+	
 	// Rule 1: Validate zipcode (Requirement 2.1)
 	if !v.validateZipcode(rawPayload) {
-		log.Printf("[VALIDATION] Zipcode validation failed for payload")
-		result.Valid = false
-		reason := models.RejectionReasonZipNotValid
-		result.RejectionReason = &reason
-		result.Errors = append(result.Errors, "zipcode must match pattern ^66\\d{3}$")
-		return result // Return immediately on first failure
+		if v.warns(config.ValidationRuleZipcode) {
+			log.Printf("[VALIDATION] Zipcode validation failed, flagging lead as %s", FlagSoftZipMismatch)
+			result.Flags = append(result.Flags, FlagSoftZipMismatch)
+		} else {
+			log.Printf("[VALIDATION] Zipcode validation failed for payload")
+			result.Valid = false
+			reason := models.RejectionReasonZipNotValid
+			result.RejectionReason = &reason
+			result.Errors = append(result.Errors, "zipcode must match pattern ^66\\d{3}$")
+			return result // Return immediately on first failure
+		}
+	} else {
+		log.Printf("[VALIDATION] Zipcode validation passed")
 	}
-	log.Printf("[VALIDATION] Zipcode validation passed")
 	
 	// Rule 2: Validate homeowner status (Requirement 2.2)
 	if !v.validateHomeowner(rawPayload) {
-		log.Printf("[VALIDATION] Homeowner validation failed for payload")
-		result.Valid = false
-		reason := models.RejectionReasonNotHomeowner
-		result.RejectionReason = &reason
-		result.Errors = append(result.Errors, "house.is_owner must be exactly true")
-		return result // Return immediately on first failure
+		if v.warns(config.ValidationRuleHomeowner) {
+			log.Printf("[VALIDATION] Homeowner validation failed, flagging lead as %s", FlagSoftNotHomeowner)
+			result.Flags = append(result.Flags, FlagSoftNotHomeowner)
+		} else {
+			log.Printf("[VALIDATION] Homeowner validation failed for payload")
+			result.Valid = false
+			reason := models.RejectionReasonNotHomeowner
+			result.RejectionReason = &reason
+			result.Errors = append(result.Errors, "house.is_owner must be exactly true")
+			return result // Return immediately on first failure
+		}
+	} else {
+		log.Printf("[VALIDATION] Homeowner validation passed")
+	}
+	
+	// Rule 3: Validate cross-field dependencies
+	if dependencyResult := v.dependencyStage.Validate(rawPayload); !dependencyResult.Valid {
+		if v.warns(config.ValidationRuleDependency) {
+			log.Printf("[VALIDATION] Dependency validation failed, flagging lead as %s", FlagSoftMissingDependentField)
+			result.Flags = append(result.Flags, FlagSoftMissingDependentField)
+		} else {
+			log.Printf("[VALIDATION] Dependency validation failed for payload")
+			return dependencyResult
+		}
+	}
+	
+	// Rule 4: Validate configured policies
+	if v.policies != nil {
+		if policyResult := v.policies.Validate(rawPayload); !policyResult.Valid {
+			log.Printf("[VALIDATION] Policy validation failed for payload")
+			return policyResult
+		}
+	}
+	
+	if len(result.Flags) > 0 {
+		log.Printf("[VALIDATION] Validation passed with flags: %v", result.Flags)
+		return result
 	}
-	log.Printf("[VALIDATION] Homeowner validation passed")
 	
 	log.Printf("[VALIDATION] All validation rules passed")
 	return result
@@ -91,37 +187,27 @@ func (v *Validator) validateZipcode(payload models.JSONB) bool {
 // validateHomeowner checks if house.is_owner is exactly true
 // Requirement 2.2
 func (v *Validator) validateHomeowner(payload models.JSONB) bool {
-	// Extract house object
-	house, ok := payload["house"]
-	if !ok {
-		log.Printf("[VALIDATION] House field missing from payload")
-		return false
-	}
-	
-	// Convert to map
-	houseMap, ok := house.(map[string]interface{})
-	if !ok {
-		log.Printf("[VALIDATION] House field is not an object: %T", house)
-		return false
-	}
-	
-	// Extract is_owner field
-	isOwner, ok := houseMap["is_owner"]
-	if !ok {
-		log.Printf("[VALIDATION] house.is_owner field missing from payload")
-		return false
-	}
-	
-	// Check if it's exactly true (boolean true)
-	isOwnerBool, ok := isOwner.(bool)
+	isOwner, ok := getNestedBool(payload, "house.is_owner")
 	if !ok {
-		log.Printf("[VALIDATION] house.is_owner is not a boolean: %T", isOwner)
+		if value := fieldValue(payload, "house.is_owner"); value == nil {
+			log.Printf("[VALIDATION] house.is_owner field missing from payload")
+		} else {
+			log.Printf("[VALIDATION] house.is_owner is not a boolean: %T", value)
+		}
 		return false
 	}
 	
-	log.Printf("[VALIDATION] house.is_owner value: %v", isOwnerBool)
+	log.Printf("[VALIDATION] house.is_owner value: %v", isOwner)
 	
-	return isOwnerBool == true
+	return isOwner
+}
+
+// getNestedBool returns the boolean at the dot-separated path of the payload
+// ok is false if the path is missing, passes through a value that is not an object,
+// or ends at a value that is not a boolean (e.g. the string "true" before coercion)
+func getNestedBool(payload models.JSONB, path string) (value bool, ok bool) {
+	value, ok = fieldValue(payload, path).(bool)
+	return value, ok
 }
 
 // ValidateAndGetReason is a convenience method that validates and returns the rejection reason
@@ -1,8 +1,10 @@
 package services
 
 import (
+	"reflect"
 	"testing"
 
+	"github.com/checkfox/go_lead/internal/config"
 	"github.com/checkfox/go_lead/internal/models"
 )
 
@@ -185,6 +187,45 @@ func TestValidateLead_IsOwnerInteger(t *testing.T) {
 	}
 }
 
+func TestGetNestedBool(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   models.JSONB
+		wantValue bool
+		wantOK    bool
+	}{
+		{"present true", models.JSONB{"house": map[string]interface{}{"is_owner": true}}, true, true},
+		{"present false", models.JSONB{"house": map[string]interface{}{"is_owner": false}}, false, true},
+		{"present non-bool", models.JSONB{"house": map[string]interface{}{"is_owner": "true"}}, false, false},
+		{"missing nested key", models.JSONB{"house": map[string]interface{}{}}, false, false},
+		{"missing parent", models.JSONB{}, false, false},
+		{"parent not an object", models.JSONB{"house": true}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := getNestedBool(tt.payload, "house.is_owner")
+			if value != tt.wantValue || ok != tt.wantOK {
+				t.Errorf("getNestedBool() = (%v, %v), want (%v, %v)", value, ok, tt.wantValue, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestValidateLead_CoercedIsOwner(t *testing.T) {
+	normalizer := NewNormalizerWithValueAliases(map[string]map[string]interface{}{
+		"house.is_owner": {"yes": true},
+	})
+	payload := normalizer.ApplyValueAliases(models.JSONB{
+		"zipcode": "66123",
+		"house":   map[string]interface{}{"is_owner": " yes "},
+	})
+
+	if result := NewValidator().ValidateLead(payload); !result.Valid {
+		t.Errorf("Expected a coerced is_owner to pass validation, got %v", result.Errors)
+	}
+}
+
 // Test boundary conditions for zipcode pattern
 func TestValidateLead_ZipcodeBoundaries(t *testing.T) {
 	validator := NewValidator()
@@ -298,3 +339,107 @@ func TestValidateAndGetReason_Failure(t *testing.T) {
 		t.Error("Expected error to be returned")
 	}
 }
+
+// Test warn-level rules flag the lead instead of rejecting it
+// newTestValidator creates a Validator from cfg, failing the test on invalid policies
+func newTestValidator(t *testing.T, cfg config.ValidationConfig) *Validator {
+	t.Helper()
+	validator, err := NewValidatorFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	return validator
+}
+
+func TestValidateLead_WarnSeverityFlagsLead(t *testing.T) {
+	tests := []struct {
+		name       string
+		severities map[string]string
+		payload    models.JSONB
+		wantValid  bool
+		wantFlags  []string
+	}{
+		{
+			name:       "zip mismatch rejected by default",
+			severities: nil,
+			payload:    models.JSONB{"zipcode": "10115", "house": map[string]interface{}{"is_owner": true}},
+			wantValid:  false,
+		},
+		{
+			name:       "zip mismatch flagged",
+			severities: map[string]string{config.ValidationRuleZipcode: config.ValidationSeverityWarn},
+			payload:    models.JSONB{"zipcode": "10115", "house": map[string]interface{}{"is_owner": true}},
+			wantValid:  true,
+			wantFlags:  []string{FlagSoftZipMismatch},
+		},
+		{
+			name:       "zip flagged but homeowner still rejects",
+			severities: map[string]string{config.ValidationRuleZipcode: config.ValidationSeverityWarn},
+			payload:    models.JSONB{"zipcode": "10115", "house": map[string]interface{}{"is_owner": false}},
+			wantValid:  false,
+		},
+		{
+			name: "zip and homeowner flagged",
+			severities: map[string]string{
+				config.ValidationRuleZipcode:   config.ValidationSeverityWarn,
+				config.ValidationRuleHomeowner: config.ValidationSeverityWarn,
+			},
+			payload:   models.JSONB{"zipcode": "10115", "house": map[string]interface{}{"is_owner": false}},
+			wantValid: true,
+			wantFlags: []string{FlagSoftZipMismatch, FlagSoftNotHomeowner},
+		},
+		{
+			name:       "explicit reject severity rejects",
+			severities: map[string]string{config.ValidationRuleZipcode: config.ValidationSeverityReject},
+			payload:    models.JSONB{"zipcode": "10115", "house": map[string]interface{}{"is_owner": true}},
+			wantValid:  false,
+		},
+		{
+			name:       "passing lead has no flags",
+			severities: map[string]string{config.ValidationRuleZipcode: config.ValidationSeverityWarn},
+			payload:    models.JSONB{"zipcode": "66123", "house": map[string]interface{}{"is_owner": true}},
+			wantValid:  true,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := newTestValidator(t, config.ValidationConfig{RuleSeverities: tt.severities})
+			result := validator.ValidateLead(tt.payload)
+			
+			if result.Valid != tt.wantValid {
+				t.Fatalf("Expected valid=%v, got %v (errors: %v)", tt.wantValid, result.Valid, result.Errors)
+			}
+			if tt.wantValid && (len(result.Flags) != 0 || len(tt.wantFlags) != 0) {
+				if !reflect.DeepEqual(result.Flags, tt.wantFlags) {
+					t.Errorf("Expected flags %v, got %v", tt.wantFlags, result.Flags)
+				}
+			}
+		})
+	}
+}
+
+// Test a warn-level dependency rule flags the lead
+func TestValidateLead_WarnSeverityDependency(t *testing.T) {
+	validator := newTestValidator(t, config.ValidationConfig{
+		DependencyRules: []config.FieldDependencyRule{
+			{IfPresent: "house.solar_panel_type", ThenRequired: []string{"house.roof_area"}},
+		},
+		RuleSeverities: map[string]string{config.ValidationRuleDependency: config.ValidationSeverityWarn},
+	})
+	
+	result := validator.ValidateLead(models.JSONB{
+		"zipcode": "66123",
+		"house": map[string]interface{}{
+			"is_owner":         true,
+			"solar_panel_type": "mono",
+		},
+	})
+	
+	if !result.Valid {
+		t.Fatalf("Expected lead to pass with a flag, got errors %v", result.Errors)
+	}
+	if !reflect.DeepEqual(result.Flags, []string{FlagSoftMissingDependentField}) {
+		t.Errorf("Expected flags [%s], got %v", FlagSoftMissingDependentField, result.Flags)
+	}
+}
@@ -0,0 +1,232 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+)
+
+// otlpFlushInterval is how often buffered spans are sent to the collector
+const otlpFlushInterval = 5 * time.Second
+
+// OTLPExporter batches spans and sends them to an OpenTelemetry collector
+// using the OTLP/HTTP JSON encoding
+type OTLPExporter struct {
+	url         string
+	serviceName string
+	httpClient  *http.Client
+
+	mu      sync.Mutex
+	pending []*Span
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewOTLPExporter creates a new OTLPExporter sending to endpoint + "/v1/traces"
+// and starts its background flush loop
+func NewOTLPExporter(endpoint, serviceName string) *OTLPExporter {
+	e := &OTLPExporter{
+		url:         strings.TrimRight(endpoint, "/") + "/v1/traces",
+		serviceName: serviceName,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// Init installs a tracer exporting to the configured OTLP endpoint.
+// Tracing stays disabled when no endpoint is configured.
+// The returned function flushes pending spans and must be called on shutdown.
+func Init(cfg config.TracingConfig, serviceName string) func(ctx context.Context) error {
+	if cfg.OTLPEndpoint == "" {
+		return func(ctx context.Context) error { return nil }
+	}
+
+	if cfg.ServiceName != "" {
+		serviceName = cfg.ServiceName
+	}
+	exporter := NewOTLPExporter(cfg.OTLPEndpoint, serviceName)
+	SetTracer(NewTracer(exporter))
+	return func(ctx context.Context) error {
+		SetTracer(nil)
+		return exporter.Shutdown(ctx)
+	}
+}
+
+// ExportSpan buffers the span until the next flush
+func (e *OTLPExporter) ExportSpan(span *Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pending = append(e.pending, span)
+}
+
+// Shutdown stops the flush loop and sends any buffered spans
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	close(e.stop)
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return e.flush(ctx)
+}
+
+// run flushes buffered spans periodically until stopped
+func (e *OTLPExporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(otlpFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+			if err := e.flush(context.Background()); err != nil {
+				log.Printf("[TRACING] Failed to export spans: %v", err)
+			}
+		}
+	}
+}
+
+// flush sends all buffered spans in a single request
+func (e *OTLPExporter) flush(ctx context.Context) error {
+	e.mu.Lock()
+	spans := e.pending
+	e.pending = nil
+	e.mu.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(e.encode(spans))
+	if err != nil {
+		return fmt.Errorf("failed to marshal spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("export request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// OTLP/HTTP JSON request shapes, see opentelemetry-proto trace/v1
+type otlpKeyValue struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+// encode converts spans into an OTLP export request
+func (e *OTLPExporter) encode(spans []*Span) *otlpRequest {
+	scopeSpans := otlpScopeSpans{Scope: otlpScope{Name: "github.com/checkfox/go_lead"}}
+	for _, span := range spans {
+		scopeSpans.Spans = append(scopeSpans.Spans, encodeSpan(span))
+	}
+
+	return &otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource:   otlpResource{Attributes: []otlpKeyValue{newOTLPKeyValue("service.name", e.serviceName)}},
+			ScopeSpans: []otlpScopeSpans{scopeSpans},
+		}},
+	}
+}
+
+// encodeSpan converts a span into its OTLP representation
+func encodeSpan(span *Span) otlpSpan {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+
+	encoded := otlpSpan{
+		TraceID:           hex.EncodeToString(span.Context.TraceID[:]),
+		SpanID:            hex.EncodeToString(span.Context.SpanID[:]),
+		Name:              span.Name,
+		Kind:              1, // SPAN_KIND_INTERNAL
+		StartTimeUnixNano: strconv.FormatInt(span.StartTime.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.EndTime.UnixNano(), 10),
+		Attributes:        make([]otlpKeyValue, 0, len(span.Attributes)),
+	}
+	if span.ParentSpanID != [8]byte{} {
+		encoded.ParentSpanID = hex.EncodeToString(span.ParentSpanID[:])
+	}
+	for key, value := range span.Attributes {
+		encoded.Attributes = append(encoded.Attributes, newOTLPKeyValue(key, value))
+	}
+	if span.Err != "" {
+		encoded.Status = otlpStatus{Code: 2, Message: span.Err} // STATUS_CODE_ERROR
+	}
+	return encoded
+}
+
+func newOTLPKeyValue(key, value string) otlpKeyValue {
+	kv := otlpKeyValue{Key: key}
+	kv.Value.StringValue = value
+	return kv
+}
@@ -0,0 +1,250 @@
+// Package tracing provides lightweight distributed tracing for the lead pipeline.
+// Spans follow the OpenTelemetry data model, are propagated between services via
+// the W3C traceparent header, and are exported to an OTLP/HTTP collector.
+// Tracing is a no-op until a tracer is installed with SetTracer or Init.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentHeader is the W3C Trace Context header carrying the trace and parent span IDs
+const TraceparentHeader = "traceparent"
+
+// Attribute keys shared by all pipeline spans
+const (
+	AttributeLeadID        = "lead.id"
+	AttributeCorrelationID = "correlation.id"
+)
+
+// SpanContext identifies a span within a trace
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+// IsValid reports whether the span context carries a trace
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{}
+}
+
+// Traceparent formats the span context as a W3C traceparent header value
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]))
+}
+
+// ParseTraceparent parses a W3C traceparent header value
+func ParseTraceparent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return sc, false
+	}
+	traceID, err := hex.DecodeString(parts[1])
+	if err != nil || len(traceID) != 16 {
+		return sc, false
+	}
+	spanID, err := hex.DecodeString(parts[2])
+	if err != nil || len(spanID) != 8 {
+		return sc, false
+	}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	return sc, sc.IsValid()
+}
+
+// Span is a single timed operation within a trace.
+// All methods are safe to call on a nil *Span, which is what Start returns when tracing is disabled.
+type Span struct {
+	Name         string
+	Context      SpanContext
+	ParentSpanID [8]byte
+	StartTime    time.Time
+	EndTime      time.Time
+	Attributes   map[string]string
+	Err          string
+
+	mu     sync.Mutex
+	tracer *Tracer
+	ended  bool
+}
+
+// SetAttribute records a key/value attribute on the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Attributes[key] = fmt.Sprint(value)
+}
+
+// RecordError marks the span as failed
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Err = err.Error()
+}
+
+// End completes the span and hands it to the exporter. Subsequent calls are ignored.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+
+	s.tracer.exporter.ExportSpan(s)
+}
+
+// Exporter receives completed spans
+type Exporter interface {
+	ExportSpan(span *Span)
+}
+
+// Tracer creates spans and sends them to an exporter once they end
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer creates a new Tracer exporting to the given exporter
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+var (
+	globalMu     sync.RWMutex
+	globalTracer *Tracer
+)
+
+// SetTracer installs the tracer used by Start. A nil tracer disables tracing.
+func SetTracer(tracer *Tracer) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	globalTracer = tracer
+}
+
+type contextKey string
+
+const (
+	spanKey         contextKey = "span"
+	remoteParentKey contextKey = "remote_parent"
+)
+
+// Start starts a span as a child of the span in ctx, or of a remote parent
+// extracted into ctx, and returns a context carrying the new span.
+// Returns ctx unchanged and a nil span when tracing is disabled.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	globalMu.RLock()
+	tracer := globalTracer
+	globalMu.RUnlock()
+	if tracer == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		Name:       name,
+		StartTime:  time.Now(),
+		Attributes: make(map[string]string),
+		tracer:     tracer,
+	}
+
+	if parent := SpanContextFromContext(ctx); parent.IsValid() {
+		span.Context.TraceID = parent.TraceID
+		span.ParentSpanID = parent.SpanID
+	} else {
+		rand.Read(span.Context.TraceID[:])
+	}
+	rand.Read(span.Context.SpanID[:])
+
+	return context.WithValue(ctx, spanKey, span), span
+}
+
+// SpanContextFromContext returns the context of the current span, or of the
+// remote parent if no local span has been started yet
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span, ok := ctx.Value(spanKey).(*Span); ok && span != nil {
+		return span.Context
+	}
+	if sc, ok := ctx.Value(remoteParentKey).(SpanContext); ok {
+		return sc
+	}
+	return SpanContext{}
+}
+
+// TraceID returns the hex-encoded ID of the trace the context belongs to, or "" outside a trace
+func TraceID(ctx context.Context) string {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		return hex.EncodeToString(sc.TraceID[:])
+	}
+	return ""
+}
+
+// Inject writes the current span context to the traceparent header
+func Inject(ctx context.Context, header http.Header) {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		header.Set(TraceparentHeader, sc.Traceparent())
+	}
+}
+
+// Extract returns a context whose spans continue the trace in the traceparent header, if any
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return ContextWithTraceparent(ctx, header.Get(TraceparentHeader))
+}
+
+// ContextWithTraceparent returns a context whose spans continue the given traceparent, if valid
+func ContextWithTraceparent(ctx context.Context, traceparent string) context.Context {
+	if sc, ok := ParseTraceparent(traceparent); ok {
+		return context.WithValue(ctx, remoteParentKey, sc)
+	}
+	return ctx
+}
+
+// Traceparent returns the traceparent value for the current span, or "" without one
+func Traceparent(ctx context.Context) string {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		return sc.Traceparent()
+	}
+	return ""
+}
+
+// InMemoryExporter collects spans in memory, for tests
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// NewInMemoryExporter creates a new InMemoryExporter
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// ExportSpan stores the span
+func (e *InMemoryExporter) ExportSpan(span *Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the exported spans in the order they ended
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*Span(nil), e.spans...)
+}
@@ -0,0 +1,126 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStart_DisabledReturnsNilSpan(t *testing.T) {
+	SetTracer(nil)
+
+	ctx, span := Start(context.Background(), "noop")
+	span.SetAttribute("key", "value")
+	span.RecordError(errors.New("ignored"))
+	span.End()
+
+	if span != nil {
+		t.Error("Expected nil span when tracing is disabled")
+	}
+	if Traceparent(ctx) != "" {
+		t.Error("Expected no traceparent when tracing is disabled")
+	}
+}
+
+func TestStart_ChildSpansShareTrace(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	SetTracer(NewTracer(exporter))
+	t.Cleanup(func() { SetTracer(nil) })
+
+	ctx, parent := Start(context.Background(), "parent")
+	_, child := Start(ctx, "child")
+	child.End()
+	parent.End()
+
+	spans := exporter.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].Context.TraceID != spans[1].Context.TraceID {
+		t.Error("Expected child to share the parent's trace ID")
+	}
+	if spans[0].ParentSpanID != spans[1].Context.SpanID {
+		t.Error("Expected child's parent span ID to be the parent's span ID")
+	}
+}
+
+func TestInjectExtract_RoundTrip(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	SetTracer(NewTracer(exporter))
+	t.Cleanup(func() { SetTracer(nil) })
+
+	ctx, span := Start(context.Background(), "client")
+	header := http.Header{}
+	Inject(ctx, header)
+	span.End()
+
+	remote, _ := Start(Extract(context.Background(), header), "server")
+	if got := SpanContextFromContext(remote).TraceID; got != span.Context.TraceID {
+		t.Error("Expected extracted context to continue the injected trace")
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-4bf92f35-00f067aa0ba902b7-01", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		sc, ok := ParseTraceparent(tt.value)
+		if ok != tt.valid {
+			t.Errorf("ParseTraceparent(%q) valid = %v, expected %v", tt.value, ok, tt.valid)
+		}
+		if ok && sc.Traceparent() != tt.value {
+			t.Errorf("Expected round trip of %q, got %q", tt.value, sc.Traceparent())
+		}
+	}
+}
+
+func TestOTLPExporter_SendsSpansOnShutdown(t *testing.T) {
+	var received otlpRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("Expected path /v1/traces, got %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(server.URL, "test-service")
+	SetTracer(NewTracer(exporter))
+	t.Cleanup(func() { SetTracer(nil) })
+
+	_, span := Start(context.Background(), "operation")
+	span.SetAttribute(AttributeLeadID, 42)
+	span.RecordError(errors.New("boom"))
+	span.End()
+
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	if len(received.ResourceSpans) != 1 || len(received.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("Unexpected export request: %+v", received)
+	}
+	spans := received.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 || spans[0].Name != "operation" {
+		t.Fatalf("Expected 1 exported span named operation, got %+v", spans)
+	}
+	if spans[0].Status.Code != 2 || spans[0].Status.Message != "boom" {
+		t.Errorf("Expected error status, got %+v", spans[0].Status)
+	}
+	if len(spans[0].Attributes) != 1 || spans[0].Attributes[0].Value.StringValue != "42" {
+		t.Errorf("Expected lead.id attribute, got %+v", spans[0].Attributes)
+	}
+}
@@ -0,0 +1,398 @@
+// Package transform reshapes decoded JSON documents with a subset of the jq language.
+//
+// Supported syntax:
+//   - identity and paths: ., .data, .data.lead, ."odd key", .["odd key"]
+//   - array indexes: .[0], .items[-1] (out-of-range indexes yield null)
+//   - pipes: .data | .lead
+//   - object construction: {phone: .tel, name: .contact.name, zipcode}
+//   - parentheses: {lead: (.data | .lead)}
+//
+// As in jq, looking up a missing key yields null, while indexing a value of
+// the wrong type (e.g. .name on an array) is an error.
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expression is a compiled transformation
+type Expression struct {
+	source string
+	root   node
+}
+
+// Compile parses a jq expression
+func Compile(expr string) (*Expression, error) {
+	p := &parser{input: expr}
+	root, err := p.parsePipeline()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.done() {
+		return nil, p.errorf("unexpected %q", p.input[p.pos])
+	}
+	return &Expression{source: expr, root: root}, nil
+}
+
+// Apply evaluates the expression against a decoded JSON value
+func (e *Expression) Apply(input interface{}) (interface{}, error) {
+	return e.root.eval(input)
+}
+
+// String returns the expression source
+func (e *Expression) String() string {
+	return e.source
+}
+
+// node is an evaluable part of an expression
+type node interface {
+	eval(input interface{}) (interface{}, error)
+}
+
+// pipeNode feeds the output of each stage into the next
+type pipeNode []node
+
+func (n pipeNode) eval(input interface{}) (interface{}, error) {
+	value := input
+	for _, stage := range n {
+		var err error
+		if value, err = stage.eval(value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// pathStep is a single object key or array index lookup
+type pathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// pathNode resolves a sequence of lookups; an empty path is the identity
+type pathNode []pathStep
+
+func (n pathNode) eval(input interface{}) (interface{}, error) {
+	value := input
+	for _, step := range n {
+		if value == nil {
+			return nil, nil
+		}
+		if step.isIndex {
+			array, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot index %s with number", typeName(value))
+			}
+			index := step.index
+			if index < 0 {
+				index += len(array)
+			}
+			if index < 0 || index >= len(array) {
+				return nil, nil
+			}
+			value = array[index]
+			continue
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot index %s with %q", typeName(value), step.key)
+		}
+		value = object[step.key]
+	}
+	return value, nil
+}
+
+// objectEntry is one key of an object construction
+type objectEntry struct {
+	key   string
+	value node
+}
+
+// objectNode builds a new object from the input
+type objectNode []objectEntry
+
+func (n objectNode) eval(input interface{}) (interface{}, error) {
+	result := make(map[string]interface{}, len(n))
+	for _, entry := range n {
+		value, err := entry.value.eval(input)
+		if err != nil {
+			return nil, err
+		}
+		result[entry.key] = value
+	}
+	return result, nil
+}
+
+// typeName names a decoded JSON value the way jq does in error messages
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64, json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// parser is a recursive descent parser over the expression source
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) parsePipeline() (node, error) {
+	first, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	stages := pipeNode{first}
+	for {
+		p.skipSpace()
+		if p.peek() != '|' {
+			break
+		}
+		p.pos++
+		stage, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, stage)
+	}
+	if len(stages) == 1 {
+		return first, nil
+	}
+	return stages, nil
+}
+
+func (p *parser) parseTerm() (node, error) {
+	p.skipSpace()
+	switch p.peek() {
+	case '.':
+		return p.parsePath()
+	case '{':
+		return p.parseObject()
+	case '(':
+		p.pos++
+		inner, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	case 0:
+		return nil, p.errorf("unexpected end of expression")
+	default:
+		return nil, p.errorf("unexpected %q", p.input[p.pos])
+	}
+}
+
+func (p *parser) parsePath() (node, error) {
+	p.pos++ // leading '.'
+	var steps pathNode
+
+	// The first step may follow the dot directly: .key, ."key", .[0]
+	switch c := p.peek(); {
+	case isIdentStart(c):
+		steps = append(steps, pathStep{key: p.parseIdent()})
+	case c == '"':
+		key, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, pathStep{key: key})
+	case c == '.':
+		return nil, p.errorf("recursive descent (..) is not supported")
+	}
+
+	for {
+		switch p.peek() {
+		case '.':
+			p.pos++
+			switch c := p.peek(); {
+			case isIdentStart(c):
+				steps = append(steps, pathStep{key: p.parseIdent()})
+			case c == '"':
+				key, err := p.parseString()
+				if err != nil {
+					return nil, err
+				}
+				steps = append(steps, pathStep{key: key})
+			case c == '[':
+				// .a.[0] is accepted like jq 1.7
+			default:
+				return nil, p.errorf("expected key after '.'")
+			}
+		case '[':
+			step, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+		default:
+			return steps, nil
+		}
+	}
+}
+
+func (p *parser) parseBracket() (pathStep, error) {
+	p.pos++ // '['
+	p.skipSpace()
+	var step pathStep
+	if p.peek() == '"' {
+		key, err := p.parseString()
+		if err != nil {
+			return step, err
+		}
+		step = pathStep{key: key}
+	} else {
+		start := p.pos
+		if p.peek() == '-' {
+			p.pos++
+		}
+		for isDigit(p.peek()) {
+			p.pos++
+		}
+		index, err := strconv.Atoi(p.input[start:p.pos])
+		if err != nil {
+			if p.peek() == ']' && start == p.pos {
+				return step, p.errorf("iteration (.[]) is not supported")
+			}
+			return step, p.errorf("expected array index or string key")
+		}
+		step = pathStep{index: index, isIndex: true}
+	}
+	p.skipSpace()
+	return step, p.expect(']')
+}
+
+func (p *parser) parseObject() (node, error) {
+	p.pos++ // '{'
+	var entries objectNode
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+		return entries, nil
+	}
+	for {
+		p.skipSpace()
+		var key string
+		switch c := p.peek(); {
+		case isIdentStart(c):
+			key = p.parseIdent()
+		case c == '"':
+			var err error
+			if key, err = p.parseString(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, p.errorf("expected object key")
+		}
+
+		p.skipSpace()
+		var value node = pathNode{{key: key}} // {key} is shorthand for {key: .key}
+		if p.peek() == ':' {
+			p.pos++
+			var err error
+			if value, err = p.parseTerm(); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, objectEntry{key: key, value: value})
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return entries, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in object")
+		}
+	}
+}
+
+func (p *parser) parseIdent() string {
+	start := p.pos
+	for isIdentStart(p.peek()) || isDigit(p.peek()) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+// parseString parses a double-quoted string with JSON escapes
+func (p *parser) parseString() (string, error) {
+	start := p.pos
+	p.pos++ // opening quote
+	for !p.done() {
+		switch p.input[p.pos] {
+		case '\\':
+			p.pos += 2
+			continue
+		case '"':
+			p.pos++
+			var s string
+			if err := json.Unmarshal([]byte(p.input[start:p.pos]), &s); err != nil {
+				return "", p.errorf("invalid string %s", p.input[start:p.pos])
+			}
+			return s, nil
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *parser) expect(c byte) error {
+	p.skipSpace()
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) skipSpace() {
+	for !p.done() && strings.IndexByte(" \t\r\n", p.input[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// peek returns the current byte, or 0 at the end of the input
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid transform expression at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,114 @@
+package transform
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid test JSON %s: %v", s, err)
+	}
+	return v
+}
+
+func TestExpression_Apply(t *testing.T) {
+	tests := []struct {
+		name  string
+		expr  string
+		input string
+		want  string
+	}{
+		{"identity", ".", `{"phone": "0151"}`, `{"phone": "0151"}`},
+		{"unwrap", ".data", `{"data": {"phone": "0151"}, "meta": 1}`, `{"phone": "0151"}`},
+		{"nested unwrap", ".data.lead", `{"data": {"lead": {"phone": "0151"}}}`, `{"phone": "0151"}`},
+		{"first array element", ".leads[0]", `{"leads": [{"phone": "1"}, {"phone": "2"}]}`, `{"phone": "1"}`},
+		{"root array element", ".[0]", `[{"phone": "1"}, {"phone": "2"}]`, `{"phone": "1"}`},
+		{"last array element", ".leads[-1]", `{"leads": [{"phone": "1"}, {"phone": "2"}]}`, `{"phone": "2"}`},
+		{"out of range index", ".leads[5]", `{"leads": []}`, `null`},
+		{"missing key", ".data", `{"lead": {}}`, `null`},
+		{"quoted keys", `."lead data"["zip code"]`, `{"lead data": {"zip code": "66123"}}`, `"66123"`},
+		{"pipe", ".data | .lead", `{"data": {"lead": {"phone": "0151"}}}`, `{"phone": "0151"}`},
+		{
+			"rename fields",
+			`{phone: .tel, zipcode: .address.zip, email}`,
+			`{"tel": "0151", "address": {"zip": "66123"}, "email": "a@b.de", "extra": true}`,
+			`{"phone": "0151", "zipcode": "66123", "email": "a@b.de"}`,
+		},
+		{
+			"unwrap then rename",
+			`.data | {phone: .tel, "house": (.object | {is_owner: .owner})}`,
+			`{"data": {"tel": "0151", "object": {"owner": true}}}`,
+			`{"phone": "0151", "house": {"is_owner": true}}`,
+		},
+		{"empty object", "{}", `{"a": 1}`, `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("Compile(%q) error: %v", tt.expr, err)
+			}
+
+			got, err := expr.Apply(decode(t, tt.input))
+			if err != nil {
+				t.Fatalf("Apply() error: %v", err)
+			}
+			if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("Apply() = %#v, want %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestExpression_ApplyTypeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		expr  string
+		input string
+	}{
+		{"key on array", ".data", `[1, 2]`},
+		{"key on string", ".data.lead", `{"data": "text"}`},
+		{"index on object", ".[0]", `{"a": 1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("Compile(%q) error: %v", tt.expr, err)
+			}
+			if _, err := expr.Apply(decode(t, tt.input)); err == nil {
+				t.Errorf("Apply() expected an error")
+			}
+		})
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"data",
+		".data.",
+		".data[",
+		".data[x]",
+		".[]",
+		"..",
+		`."unterminated`,
+		"{phone: }",
+		"{phone: .tel",
+		"(.data",
+		".data | ",
+		".data .lead",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := Compile(expr); err == nil {
+				t.Errorf("Compile(%q) expected an error", expr)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/client"
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/queue"
+	"github.com/checkfox/go_lead/internal/repository"
+)
+
+// confirmationLeadRepo serves a lead loaded with one status while its stored status may
+// already have changed, as when the confirmation callback settles it concurrently
+type confirmationLeadRepo struct {
+	notifierLeadRepo
+	stored models.LeadStatus
+}
+
+func (m *confirmationLeadRepo) UpdateLeadStatusFrom(ctx context.Context, id int64, from, to models.LeadStatus) error {
+	if m.stored != from {
+		return fmt.Errorf("lead %d has status %s instead of %s: %w", id, m.stored, from, models.ErrInvalidStatusTransition)
+	}
+	m.stored = to
+	return nil
+}
+
+// countingAttemptRepo reports a fixed number of delivery attempts per lead
+type countingAttemptRepo struct {
+	repository.DeliveryAttemptRepository
+	count int
+}
+
+func (m *countingAttemptRepo) CountDeliveryAttempts(ctx context.Context, leadID int64) (int, error) {
+	return m.count, nil
+}
+
+// newConfirmationTestProcessor creates a processor allowing 3 delivery attempts, of which the
+// lead has made attemptCount
+func newConfirmationTestProcessor(jobQueue queue.Queue, leadRepo *confirmationLeadRepo, attemptCount int) *Processor {
+	return NewProcessor(ProcessorConfig{
+		Queue:                    jobQueue,
+		LeadRepo:                 leadRepo,
+		DeliveryAttemptRepo:      &countingAttemptRepo{count: attemptCount},
+		MaxDeliveryAttempts:      3,
+		ExponentialBackoffDelays: []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute},
+		PriorityPenalty:          10,
+	})
+}
+
+func newConfirmationTimeoutJob(leadID int64) *queue.Job {
+	return &queue.Job{ID: 1, Type: queue.JobTypeConfirmationTimeout, Payload: queue.NewJobPayload(leadID)}
+}
+
+func TestProcessConfirmationTimeout_FailsPendingLead(t *testing.T) {
+	lead := &models.InboundLead{ID: 5, Status: models.LeadStatusPendingConfirmation}
+	leadRepo := &confirmationLeadRepo{notifierLeadRepo: notifierLeadRepo{lead: lead}, stored: lead.Status}
+	jobQueue := &recordingQueue{}
+	processor := newConfirmationTestProcessor(jobQueue, leadRepo, 2)
+
+	if err := processor.processConfirmationTimeout(context.Background(), newConfirmationTimeoutJob(5)); err != nil {
+		t.Fatalf("Failed to process confirmation timeout: %v", err)
+	}
+	if leadRepo.stored != models.LeadStatusFailed {
+		t.Errorf("Expected the unconfirmed lead to be FAILED, got %s", leadRepo.stored)
+	}
+
+	// The lead is retried after the backoff of its second attempt
+	if len(jobQueue.jobs) != 1 {
+		t.Fatalf("Expected 1 retry job, got %+v", jobQueue.jobs)
+	}
+	job := jobQueue.jobs[0]
+	if job.jobType != queue.JobTypeProcessLead || job.delay != 2*time.Minute || job.priority != 20 {
+		t.Errorf("Expected a process_lead job after 2m with priority 20, got %+v", job)
+	}
+	if leadID, _ := queue.GetLeadID(job.payload); leadID != 5 {
+		t.Errorf("Expected the retry job for lead 5, got %d", leadID)
+	}
+}
+
+func TestProcessConfirmationTimeout_PermanentlyFailsExhaustedLead(t *testing.T) {
+	lead := &models.InboundLead{
+		ID:            5,
+		Status:        models.LeadStatusPendingConfirmation,
+		SourceHeaders: models.JSONB{"X-Callback-Url": "https://sender.example.com/outcome"},
+	}
+	leadRepo := &confirmationLeadRepo{notifierLeadRepo: notifierLeadRepo{lead: lead}, stored: lead.Status}
+	jobQueue := &recordingQueue{}
+	processor := newConfirmationTestProcessor(jobQueue, leadRepo, 3)
+	processor.notifier = newTestNotificationWorker(t, NotificationWorkerConfig{Queue: jobQueue, LeadRepo: leadRepo})
+
+	if err := processor.processConfirmationTimeout(context.Background(), newConfirmationTimeoutJob(5)); err != nil {
+		t.Fatalf("Failed to process confirmation timeout: %v", err)
+	}
+	if leadRepo.stored != models.LeadStatusPermanentlyFailed {
+		t.Errorf("Expected the exhausted lead to be PERMANENTLY_FAILED, got %s", leadRepo.stored)
+	}
+
+	// The sender is notified instead of the lead being retried
+	if len(jobQueue.jobs) != 1 || jobQueue.jobs[0].jobType != queue.JobTypeNotifySender {
+		t.Errorf("Expected only a sender notification job, got %+v", jobQueue.jobs)
+	}
+}
+
+func TestProcessConfirmationTimeout_IgnoresConcurrentConfirmation(t *testing.T) {
+	// The lead is loaded as pending, but the callback confirms it before the update
+	lead := &models.InboundLead{ID: 5, Status: models.LeadStatusPendingConfirmation}
+	leadRepo := &confirmationLeadRepo{notifierLeadRepo: notifierLeadRepo{lead: lead}, stored: models.LeadStatusDelivered}
+	jobQueue := &recordingQueue{}
+	processor := newConfirmationTestProcessor(jobQueue, leadRepo, 1)
+
+	if err := processor.processConfirmationTimeout(context.Background(), newConfirmationTimeoutJob(5)); err != nil {
+		t.Fatalf("Expected the confirmed lead to be ignored, got %v", err)
+	}
+	if leadRepo.stored != models.LeadStatusDelivered {
+		t.Errorf("Expected the confirmed lead to stay DELIVERED, got %s", leadRepo.stored)
+	}
+	if len(jobQueue.jobs) != 0 {
+		t.Errorf("Expected the confirmed lead not to be retried, got %+v", jobQueue.jobs)
+	}
+}
+
+func TestProcessConfirmationTimeout_IgnoresSettledLead(t *testing.T) {
+	for _, status := range []models.LeadStatus{models.LeadStatusDelivered, models.LeadStatusFailed, models.LeadStatusReady} {
+		lead := &models.InboundLead{ID: 5, Status: status}
+		leadRepo := &confirmationLeadRepo{notifierLeadRepo: notifierLeadRepo{lead: lead}, stored: status}
+		processor := NewProcessor(ProcessorConfig{Queue: &recordingQueue{}, LeadRepo: leadRepo})
+
+		if err := processor.processConfirmationTimeout(context.Background(), newConfirmationTimeoutJob(5)); err != nil {
+			t.Fatalf("Expected the %s lead to be ignored, got %v", status, err)
+		}
+		if leadRepo.stored != status {
+			t.Errorf("Expected the %s lead to keep its status, got %s", status, leadRepo.stored)
+		}
+	}
+
+	// A deleted lead is ignored as well
+	processor := NewProcessor(ProcessorConfig{Queue: &recordingQueue{}, LeadRepo: &confirmationLeadRepo{}})
+	if err := processor.processConfirmationTimeout(context.Background(), newConfirmationTimeoutJob(5)); err != nil {
+		t.Errorf("Expected a missing lead to be ignored, got %v", err)
+	}
+}
+
+func TestExecuteDeliveryStage_AsyncModeAwaitsConfirmation(t *testing.T) {
+	processor, cleanup := setupTestProcessor(t)
+	if processor == nil {
+		return // Test was skipped
+	}
+	defer cleanup()
+
+	jobQueue := &recordingQueue{}
+	processor.queue = jobQueue
+	processor.asyncDeliveryMode = true
+	processor.confirmationTimeout = 15 * time.Minute
+	processor.customerAPIClient = &recordingSender{response: &client.DeliveryResponse{StatusCode: http.StatusAccepted, Body: "queued", Success: true}}
+
+	ctx := context.Background()
+	lead := &models.InboundLead{
+		RawPayload:      models.JSONB{"phone": "1234567890", "zipcode": "66123"},
+		Status:          models.LeadStatusReady,
+		CustomerPayload: models.JSONB{"phone": "1234567890"},
+	}
+	if err := processor.leadRepo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	if err := processor.executeDeliveryStage(ctx, lead); err != nil {
+		t.Fatalf("Delivery stage failed: %v", err)
+	}
+
+	stored, err := processor.leadRepo.GetLeadByID(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to load lead: %v", err)
+	}
+	if stored.Status != models.LeadStatusPendingConfirmation {
+		t.Errorf("Expected status PENDING_CONFIRMATION, got %s", stored.Status)
+	}
+	if len(jobQueue.jobs) != 1 || jobQueue.jobs[0].jobType != queue.JobTypeConfirmationTimeout || jobQueue.jobs[0].delay != 15*time.Minute {
+		t.Fatalf("Expected a confirmation timeout job after 15m, got %+v", jobQueue.jobs)
+	}
+
+	// The timeout fails the lead while it is unconfirmed
+	if err := processor.processConfirmationTimeout(ctx, newConfirmationTimeoutJob(lead.ID)); err != nil {
+		t.Fatalf("Failed to process confirmation timeout: %v", err)
+	}
+	if stored, err = processor.leadRepo.GetLeadByID(ctx, lead.ID); err != nil || stored.Status != models.LeadStatusFailed {
+		t.Errorf("Expected the unconfirmed lead to be FAILED, got %v (%v)", stored, err)
+	}
+}
+
+func TestExecuteDeliveryStage_SyncModeTreatsAcceptedAsDelivered(t *testing.T) {
+	processor, cleanup := setupTestProcessor(t)
+	if processor == nil {
+		return // Test was skipped
+	}
+	defer cleanup()
+
+	jobQueue := &recordingQueue{}
+	processor.queue = jobQueue
+	processor.asyncDeliveryMode = false
+	processor.customerAPIClient = &recordingSender{response: &client.DeliveryResponse{StatusCode: http.StatusAccepted, Body: "queued", Success: true}}
+
+	ctx := context.Background()
+	lead := &models.InboundLead{
+		RawPayload:      models.JSONB{"phone": "1234567890", "zipcode": "66123"},
+		Status:          models.LeadStatusReady,
+		CustomerPayload: models.JSONB{"phone": "1234567890"},
+	}
+	if err := processor.leadRepo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	if err := processor.executeDeliveryStage(ctx, lead); err != nil {
+		t.Fatalf("Delivery stage failed: %v", err)
+	}
+	if lead.Status != models.LeadStatusDelivered {
+		t.Errorf("Expected status DELIVERED, got %s", lead.Status)
+	}
+	if len(jobQueue.jobs) != 0 {
+		t.Errorf("Expected no confirmation timeout job, got %+v", jobQueue.jobs)
+	}
+}
@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+func TestDeliverySender_SwitchesAfterThreshold(t *testing.T) {
+	primary, fallback := acceptingSender(), acceptingSender()
+	processor := NewProcessor(ProcessorConfig{
+		Queue:             &recordingQueue{},
+		CustomerAPIClient: primary,
+		FallbackClient:    fallback,
+	})
+
+	for failedAttempts, want := range []string{"primary", "primary", "primary", "fallback", "fallback"} {
+		sender, endpoint := processor.deliverySender(failedAttempts)
+		if endpoint != want {
+			t.Errorf("Expected %s endpoint after %d failed attempts (default threshold 3), got %s", want, failedAttempts, endpoint)
+		}
+		if (endpoint == models.DeliveryEndpointFallback) != (sender == LeadSender(fallback)) {
+			t.Errorf("Expected the %s client after %d failed attempts", endpoint, failedAttempts)
+		}
+	}
+
+	// Without a fallback endpoint the primary is used for every attempt
+	processor = NewProcessor(ProcessorConfig{Queue: &recordingQueue{}, CustomerAPIClient: primary})
+	if _, endpoint := processor.deliverySender(10); endpoint != models.DeliveryEndpointPrimary {
+		t.Errorf("Expected the primary endpoint without fallback, got %s", endpoint)
+	}
+}
+
+func TestExecuteDeliveryStage_FallbackAfterThreshold(t *testing.T) {
+	processor, cleanup := setupTestProcessor(t)
+	if processor == nil {
+		return // Test was skipped
+	}
+	defer cleanup()
+
+	primary, fallback := failingSender(http.StatusServiceUnavailable), acceptingSender()
+	processor.customerAPIClient = primary
+	processor.fallbackClient = fallback
+	processor.fallbackAfterAttempts = 2
+	processor.maxDeliveryAttempts = 5
+	processor.exponentialBackoffDelays = nil
+	processor.queue = &recordingQueue{}
+
+	ctx := context.Background()
+	lead := &models.InboundLead{
+		RawPayload:      models.JSONB{"phone": "1234567890", "zipcode": "66123"},
+		Status:          models.LeadStatusReady,
+		CustomerPayload: models.JSONB{"phone": "1234567890"},
+	}
+	if err := processor.leadRepo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := processor.executeDeliveryStage(ctx, lead); err != nil {
+			t.Fatalf("Delivery stage failed: %v", err)
+		}
+	}
+
+	if primary.calls != 2 || fallback.calls != 1 {
+		t.Errorf("Expected 2 primary and 1 fallback deliveries, got %d and %d", primary.calls, fallback.calls)
+	}
+	if lead.Status != models.LeadStatusDelivered {
+		t.Errorf("Expected the fallback to deliver the lead, got %s", lead.Status)
+	}
+
+	attempts, err := processor.deliveryAttemptRepo.GetDeliveryAttemptsByLeadID(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to load delivery attempts: %v", err)
+	}
+	want := []string{models.DeliveryEndpointPrimary, models.DeliveryEndpointPrimary, models.DeliveryEndpointFallback}
+	if len(attempts) != len(want) {
+		t.Fatalf("Expected %d delivery attempts, got %d", len(want), len(attempts))
+	}
+	for i, attempt := range attempts {
+		if attempt.EndpointUsed != want[i] {
+			t.Errorf("Expected attempt %d to use the %s endpoint, got %s", attempt.AttemptNo, want[i], attempt.EndpointUsed)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/client"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// recordingSender returns a fixed outcome and counts its calls
+type recordingSender struct {
+	response *client.DeliveryResponse
+	err      error
+	calls    int
+	headers  map[string]string // headers of the last call
+}
+
+func (s *recordingSender) SendLead(ctx context.Context, payload map[string]interface{}, headers map[string]string) (*client.DeliveryResponse, error) {
+	s.calls++
+	s.headers = headers
+	return s.response, s.err
+}
+
+func acceptingSender() *recordingSender {
+	return &recordingSender{response: &client.DeliveryResponse{StatusCode: http.StatusOK, Body: "ok", Success: true}}
+}
+
+func failingSender(statusCode int) *recordingSender {
+	return &recordingSender{err: models.NewDeliveryError(statusCode, "rejected", true, nil)}
+}
+
+// memoryChainAttemptRepo keeps forwarding chain attempts in memory
+type memoryChainAttemptRepo struct {
+	attempts []*models.DeliveryChainAttempt
+	checkErr error
+}
+
+func (r *memoryChainAttemptRepo) CreateChainAttempt(ctx context.Context, attempt *models.DeliveryChainAttempt) error {
+	attempt.ID = int64(len(r.attempts) + 1)
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+func (r *memoryChainAttemptRepo) GetChainAttemptsByLeadID(ctx context.Context, leadID int64) ([]*models.DeliveryChainAttempt, error) {
+	var result []*models.DeliveryChainAttempt
+	for _, attempt := range r.attempts {
+		if attempt.LeadID == leadID {
+			result = append(result, attempt)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryChainAttemptRepo) HasSuccessfulChainAttempt(ctx context.Context, leadID int64, chainIndex int) (bool, error) {
+	if r.checkErr != nil {
+		return false, r.checkErr
+	}
+	for _, attempt := range r.attempts {
+		if attempt.LeadID == leadID && attempt.ChainIndex == chainIndex && attempt.Success {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newChainTestLead() *models.InboundLead {
+	return &models.InboundLead{
+		ID:              42,
+		Status:          models.LeadStatusDelivered,
+		CustomerPayload: models.JSONB{"phone": "1234567890"},
+	}
+}
+
+func TestForwardToChain_AllSucceed(t *testing.T) {
+	first, second := acceptingSender(), acceptingSender()
+	repo := &memoryChainAttemptRepo{}
+	processor := &Processor{forwardingChain: []LeadSender{first, second}, chainAttemptRepo: repo}
+	lead := newChainTestLead()
+
+	processor.forwardToChain(context.Background(), lead, 1)
+
+	if first.calls != 1 || second.calls != 1 {
+		t.Fatalf("Expected each endpoint to be called once, got %d and %d", first.calls, second.calls)
+	}
+	if len(repo.attempts) != 2 {
+		t.Fatalf("Expected 2 chain attempts, got %d", len(repo.attempts))
+	}
+	for i, attempt := range repo.attempts {
+		if attempt.ChainIndex != i+1 || attempt.AttemptNo != 1 || !attempt.Success {
+			t.Errorf("Unexpected chain attempt %d: %+v", i, attempt)
+		}
+	}
+	if lead.Status != models.LeadStatusDelivered {
+		t.Errorf("Expected lead status to be untouched, got %s", lead.Status)
+	}
+}
+
+func TestForwardToChain_FailureContinuesWithNextEndpoint(t *testing.T) {
+	first, second := failingSender(http.StatusServiceUnavailable), acceptingSender()
+	repo := &memoryChainAttemptRepo{}
+	processor := &Processor{forwardingChain: []LeadSender{first, second}, chainAttemptRepo: repo}
+	lead := newChainTestLead()
+
+	processor.forwardToChain(context.Background(), lead, 1)
+
+	if second.calls != 1 {
+		t.Fatalf("Expected the next endpoint to be called after a failure, got %d calls", second.calls)
+	}
+	if len(repo.attempts) != 2 {
+		t.Fatalf("Expected 2 chain attempts, got %d", len(repo.attempts))
+	}
+	failed := repo.attempts[0]
+	if failed.Success || failed.ResponseStatus == nil || *failed.ResponseStatus != http.StatusServiceUnavailable {
+		t.Errorf("Expected failed attempt with status 503, got %+v", failed)
+	}
+	if failed.ErrorMessage == nil {
+		t.Error("Expected failed attempt to record an error message")
+	}
+	if !repo.attempts[1].Success {
+		t.Errorf("Expected second endpoint attempt to succeed, got %+v", repo.attempts[1])
+	}
+	if lead.Status != models.LeadStatusDelivered {
+		t.Errorf("Expected lead status to be untouched, got %s", lead.Status)
+	}
+}
+
+func TestForwardToChain_SkipsEndpointsThatAcceptedTheLead(t *testing.T) {
+	first, second := acceptingSender(), failingSender(http.StatusBadGateway)
+	repo := &memoryChainAttemptRepo{}
+	processor := &Processor{forwardingChain: []LeadSender{first, second}, chainAttemptRepo: repo}
+	lead := newChainTestLead()
+
+	// A primary retry forwards the lead again
+	processor.forwardToChain(context.Background(), lead, 1)
+	processor.forwardToChain(context.Background(), lead, 2)
+
+	if first.calls != 1 {
+		t.Errorf("Expected accepting endpoint to receive the lead once, got %d", first.calls)
+	}
+	if second.calls != 2 {
+		t.Errorf("Expected failing endpoint to receive the lead on every attempt, got %d", second.calls)
+	}
+	if len(repo.attempts) != 3 {
+		t.Errorf("Expected 3 chain attempts, got %d", len(repo.attempts))
+	}
+}
+
+func TestForwardToChain_CheckErrorStillForwards(t *testing.T) {
+	sender := acceptingSender()
+	repo := &memoryChainAttemptRepo{checkErr: errors.New("connection refused")}
+	processor := &Processor{forwardingChain: []LeadSender{sender}, chainAttemptRepo: repo}
+
+	processor.forwardToChain(context.Background(), newChainTestLead(), 1)
+
+	if sender.calls != 1 || len(repo.attempts) != 1 {
+		t.Errorf("Expected the lead to be forwarded and recorded, got %d calls and %d attempts", sender.calls, len(repo.attempts))
+	}
+}
+
+func TestForwardToChain_ForwardsConfiguredHeaders(t *testing.T) {
+	sender := acceptingSender()
+	processor := &Processor{
+		forwardingChain:  []LeadSender{sender},
+		chainAttemptRepo: &memoryChainAttemptRepo{},
+		forwardHeaders:   []string{"x-lead-source", "X-Client-ID"},
+	}
+	lead := newChainTestLead()
+	lead.SourceHeaders = models.JSONB{"X-Lead-Source": "partner-a", "User-Agent": "curl/8.0"}
+
+	processor.forwardToChain(context.Background(), lead, 1)
+
+	if len(sender.headers) != 1 || sender.headers["X-Lead-Source"] != "partner-a" {
+		t.Errorf("Expected only X-Lead-Source to be forwarded, got %v", sender.headers)
+	}
+}
@@ -0,0 +1,56 @@
+package worker
+
+import "sync"
+
+// leadLockShardCount is the number of independently locked shards of a leadLocks set
+const leadLockShardCount = 32
+
+// leadLocks tracks the lead IDs currently being processed by this worker so that two
+// goroutines never process the same lead at once. Lead IDs are spread over shards to
+// keep goroutines working on different leads from contending on a single mutex.
+// Entries are removed on unlock, so the set never holds more IDs than jobs in flight.
+type leadLocks struct {
+	shards [leadLockShardCount]leadLockShard
+}
+
+// leadLockShard holds the locked lead IDs that hash to one shard
+type leadLockShard struct {
+	mu   sync.Mutex
+	held map[int64]struct{}
+}
+
+// newLeadLocks creates an empty lead lock set
+func newLeadLocks() *leadLocks {
+	locks := &leadLocks{}
+	for i := range locks.shards {
+		locks.shards[i].held = make(map[int64]struct{})
+	}
+	return locks
+}
+
+// TryLock locks leadID and reports whether it was free. It never blocks.
+func (l *leadLocks) TryLock(leadID int64) bool {
+	shard := l.shard(leadID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if _, held := shard.held[leadID]; held {
+		return false
+	}
+	shard.held[leadID] = struct{}{}
+	return true
+}
+
+// Unlock releases leadID
+func (l *leadLocks) Unlock(leadID int64) {
+	shard := l.shard(leadID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	delete(shard.held, leadID)
+}
+
+// shard returns the shard responsible for leadID
+func (l *leadLocks) shard(leadID int64) *leadLockShard {
+	return &l.shards[uint64(leadID)%leadLockShardCount]
+}
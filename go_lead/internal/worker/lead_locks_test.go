@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/checkfox/go_lead/internal/client"
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/queue"
+	"github.com/checkfox/go_lead/internal/services"
+)
+
+// blockingLeadRepo holds GetLeadByID until released and counts the loads
+type blockingLeadRepo struct {
+	notifierLeadRepo
+	loads   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingLeadRepo) GetLeadByID(ctx context.Context, id int64) (*models.InboundLead, error) {
+	r.loads.Add(1)
+	r.started <- struct{}{}
+	<-r.release
+	return r.notifierLeadRepo.GetLeadByID(ctx, id)
+}
+
+// blockingSender accepts every lead once released and counts its calls
+type blockingSender struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSender) SendLead(ctx context.Context, payload map[string]interface{}, headers map[string]string) (*client.DeliveryResponse, error) {
+	s.calls.Add(1)
+	s.started <- struct{}{}
+	<-s.release
+	return &client.DeliveryResponse{StatusCode: http.StatusOK, Body: "ok", Success: true}, nil
+}
+
+func TestLeadLocks_TryLock(t *testing.T) {
+	locks := newLeadLocks()
+
+	if !locks.TryLock(42) {
+		t.Fatal("Expected a free lead to be locked")
+	}
+	if locks.TryLock(42) {
+		t.Error("Expected a locked lead to be refused")
+	}
+	if !locks.TryLock(42 + leadLockShardCount) {
+		t.Error("Expected another lead on the same shard to be locked")
+	}
+
+	locks.Unlock(42)
+	if !locks.TryLock(42) {
+		t.Error("Expected an unlocked lead to be locked again")
+	}
+}
+
+func TestProcessLead_SkipsLeadAlreadyInProgress(t *testing.T) {
+	leadRepo := &blockingLeadRepo{
+		// A lead without a zipcode is rejected by validation, so processing needs no database
+		notifierLeadRepo: notifierLeadRepo{lead: &models.InboundLead{ID: 42, Status: models.LeadStatusReceived, RawPayload: models.JSONB{}}},
+		started:          make(chan struct{}, 2),
+		release:          make(chan struct{}),
+	}
+	processor := NewProcessor(ProcessorConfig{Queue: &recordingQueue{}, LeadRepo: leadRepo, Validator: services.NewValidator()})
+	ctx := context.Background()
+
+	// The first job holds the lead while loading it
+	firstErr := make(chan error, 1)
+	go func() {
+		firstErr <- processor.processLead(ctx, &queue.Job{ID: 1, Type: queue.JobTypeProcessLead, Payload: queue.NewJobPayload(42)})
+	}()
+	<-leadRepo.started
+
+	// A second job for the same lead is skipped without touching the lead
+	if err := processor.processLead(ctx, &queue.Job{ID: 2, Type: queue.JobTypeProcessLead, Payload: queue.NewJobPayload(42)}); err != nil {
+		t.Errorf("Expected the second job to complete as a no-op, got %v", err)
+	}
+	if loads := leadRepo.loads.Load(); loads != 1 {
+		t.Errorf("Expected the lead to be loaded once while in progress, got %d", loads)
+	}
+
+	close(leadRepo.release)
+	if err := <-firstErr; err != nil {
+		t.Errorf("Expected the first job to succeed, got %v", err)
+	}
+	if !processor.leadLocks.TryLock(42) {
+		t.Error("Expected the lead lock to be released after processing")
+	}
+}
+
+func TestProcessLead_ConcurrentJobsDeliverOnce(t *testing.T) {
+	processor, cleanup := setupTestProcessor(t)
+	if processor == nil {
+		return // Test was skipped
+	}
+	defer cleanup()
+
+	sender := &blockingSender{started: make(chan struct{}, 2), release: make(chan struct{})}
+	processor.customerAPIClient = sender
+
+	ctx := context.Background()
+
+	lead := &models.InboundLead{
+		RawPayload: models.JSONB{
+			"phone":   "1234567890",
+			"zipcode": "66123",
+		},
+		Status: models.LeadStatusReady,
+		CustomerPayload: models.JSONB{
+			"phone": "1234567890",
+			"product": map[string]interface{}{
+				"name": "test-product",
+			},
+		},
+	}
+	if err := processor.leadRepo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	// The first job waits for the Customer API while the second one arrives
+	firstErr := make(chan error, 1)
+	go func() {
+		firstErr <- processor.processLead(ctx, &queue.Job{ID: 1, Type: queue.JobTypeProcessLead, Payload: queue.NewJobPayload(lead.ID)})
+	}()
+	<-sender.started
+
+	secondErr := make(chan error, 1)
+	go func() {
+		secondErr <- processor.processLead(ctx, &queue.Job{ID: 2, Type: queue.JobTypeProcessLead, Payload: queue.NewJobPayload(lead.ID)})
+	}()
+	if err := <-secondErr; err != nil {
+		t.Errorf("Expected the second job to complete as a no-op, got %v", err)
+	}
+
+	close(sender.release)
+	if err := <-firstErr; err != nil {
+		t.Errorf("Expected the first job to succeed, got %v", err)
+	}
+
+	if calls := sender.calls.Load(); calls != 1 {
+		t.Errorf("Expected a single delivery, got %d", calls)
+	}
+	attempts, err := processor.deliveryAttemptRepo.GetDeliveryAttemptsByLeadID(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to load delivery attempts: %v", err)
+	}
+	if len(attempts) != 1 {
+		t.Errorf("Expected exactly one delivery attempt, got %d", len(attempts))
+	}
+}
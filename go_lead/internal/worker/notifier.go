@@ -0,0 +1,309 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/queue"
+	"github.com/checkfox/go_lead/internal/repository"
+)
+
+// SignatureHeader carries the hex-encoded HMAC-SHA256 of the callback body, prefixed with "sha256="
+const SignatureHeader = "X-Signature"
+
+// errPrivateCallbackAddress is returned when a sender-supplied callback URL resolves to an
+// address that is not publicly routable
+var errPrivateCallbackAddress = errors.New("callback address is not publicly routable")
+
+// NotificationWorker notifies webhook senders of the final outcome of their leads by posting
+// to the X-Callback-URL they supplied with the lead, or to the configured callback URL of
+// their source. Notifications are best-effort and never change the lead.
+//
+// X-Callback-URL is chosen by the submitter, so those URLs are only used if their host is
+// allowed, are only dialed on public addresses, and redirects are never followed.
+type NotificationWorker struct {
+	queue               queue.Queue
+	leadRepo            repository.LeadRepository
+	callbackAttemptRepo repository.CallbackAttemptRepository
+	httpClient          *http.Client // configured callback URLs
+	senderHTTPClient    *http.Client // sender-supplied callback URLs
+	sharedSecret        string
+	maxAttempts         int
+	backoff             time.Duration
+	defaultCallbackURL  string
+	sourceCallbackURLs  map[string]string
+	allowedHosts        map[string]bool
+}
+
+// NotificationWorkerConfig holds configuration for the notification worker
+type NotificationWorkerConfig struct {
+	Queue               queue.Queue
+	LeadRepo            repository.LeadRepository
+	CallbackAttemptRepo repository.CallbackAttemptRepository
+	SharedSecret        string            // key used to sign callback bodies, required
+	MaxAttempts         int               // total attempts per notification
+	Backoff             time.Duration     // delay between attempts
+	Timeout             time.Duration     // HTTP timeout per attempt
+	DefaultCallbackURL  string            // notified for leads without a callback URL of their own or of their source
+	SourceCallbackURLs  map[string]string // callback URL per source ID
+	AllowedHosts        []string          // hosts accepted in X-Callback-URL (empty = any host with a public address)
+}
+
+// CallbackNotification is the body posted to the sender's callback URL
+type CallbackNotification struct {
+	LeadID          int64      `json:"lead_id"`
+	ProviderRef     *string    `json:"provider_ref"`
+	FinalStatus     string     `json:"final_status"`
+	RejectionReason *string    `json:"rejection_reason"`
+	DeliveredAt     *time.Time `json:"delivered_at"`
+}
+
+// NewNotificationWorker creates a new notification worker. Returns an error without a
+// shared secret, since unsigned callbacks cannot be verified by the sender.
+func NewNotificationWorker(config NotificationWorkerConfig) (*NotificationWorker, error) {
+	if config.SharedSecret == "" {
+		return nil, fmt.Errorf("a shared secret is required to sign callbacks")
+	}
+
+	// Set default max attempts if not provided
+	if config.MaxAttempts == 0 {
+		config.MaxAttempts = 3
+	}
+
+	// Set default backoff if not provided
+	if config.Backoff == 0 {
+		config.Backoff = 5 * time.Second
+	}
+
+	// Set default timeout if not provided
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	allowedHosts := make(map[string]bool, len(config.AllowedHosts))
+	for _, host := range config.AllowedHosts {
+		allowedHosts[strings.ToLower(host)] = true
+	}
+
+	return &NotificationWorker{
+		queue:               config.Queue,
+		leadRepo:            config.LeadRepo,
+		callbackAttemptRepo: config.CallbackAttemptRepo,
+		httpClient:          newCallbackHTTPClient(config.Timeout, nil),
+		senderHTTPClient:    newCallbackHTTPClient(config.Timeout, rejectPrivateAddress),
+		sharedSecret:        config.SharedSecret,
+		maxAttempts:         config.MaxAttempts,
+		backoff:             config.Backoff,
+		defaultCallbackURL:  config.DefaultCallbackURL,
+		sourceCallbackURLs:  config.SourceCallbackURLs,
+		allowedHosts:        allowedHosts,
+	}, nil
+}
+
+// newCallbackHTTPClient returns a client that never follows redirects. control, if set,
+// vets every address the client dials after DNS resolution.
+func newCallbackHTTPClient(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if control != nil {
+		// A proxy would dial the callback host itself, bypassing the address check
+		transport.Proxy = nil
+		transport.DialContext = (&net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: 30 * time.Second,
+			Control:   control,
+		}).DialContext
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// rejectPrivateAddress refuses to dial loopback, private, link-local, multicast and
+// unspecified addresses
+func rejectPrivateAddress(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", errPrivateCallbackAddress, host)
+	}
+	return nil
+}
+
+// senderCallbackURL returns the X-Callback-URL sent with the lead if its host is allowed
+func (n *NotificationWorker) senderCallbackURL(lead *models.InboundLead) string {
+	callbackURL := lead.CallbackURL()
+	if callbackURL == "" || len(n.allowedHosts) == 0 {
+		return callbackURL
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil || !n.allowedHosts[strings.ToLower(u.Hostname())] {
+		return ""
+	}
+	return callbackURL
+}
+
+// callbackURL returns the URL notified of the lead's outcome: the allowed URL sent with the
+// lead, else the URL of its source, else the default URL. Empty if none is configured.
+func (n *NotificationWorker) callbackURL(lead *models.InboundLead) string {
+	if callbackURL := n.senderCallbackURL(lead); callbackURL != "" {
+		return callbackURL
+	}
+	if lead.SourceID != nil {
+		if callbackURL, ok := n.sourceCallbackURLs[*lead.SourceID]; ok {
+			return callbackURL
+		}
+	}
+	return n.defaultCallbackURL
+}
+
+// Schedule enqueues the first notification attempt if a callback URL applies to the lead
+func (n *NotificationWorker) Schedule(ctx context.Context, lead *models.InboundLead) error {
+	if lead.CallbackURL() != "" && n.senderCallbackURL(lead) == "" {
+		logger.Warn(ctx, "Ignoring callback URL with a host that is not allowed")
+	}
+	if n.callbackURL(lead) == "" {
+		return nil
+	}
+
+	logger.Info(ctx, "Scheduling sender notification", "status", lead.Status)
+	return n.queue.Enqueue(ctx, queue.JobTypeNotifySender, newNotificationPayload(lead.ID, 1))
+}
+
+// HandleJob sends one notification attempt and schedules the next one on failure.
+// Returns an error only once all attempts are exhausted so the job is recorded as failed.
+func (n *NotificationWorker) HandleJob(ctx context.Context, job *queue.Job) error {
+	leadID, ok := queue.GetLeadID(job.Payload)
+	if !ok {
+		return fmt.Errorf("invalid job payload: missing lead_id")
+	}
+	attemptNo, ok := queue.GetInt64(job.Payload, "attempt_no")
+	if !ok {
+		attemptNo = 1
+	}
+
+	ctx = context.WithValue(ctx, logger.LeadIDKey, leadID)
+
+	lead, err := n.leadRepo.GetLeadByID(ctx, leadID)
+	if err != nil {
+		return fmt.Errorf("failed to load lead %d: %w", leadID, err)
+	}
+
+	callbackURL := n.callbackURL(lead)
+	if callbackURL == "" {
+		logger.Warn(ctx, "Lead has no callback URL, skipping notification")
+		return nil
+	}
+
+	attempt := models.NewCallbackAttempt(lead.ID, int(attemptNo), callbackURL)
+	sendErr := n.send(ctx, callbackURL, lead, attempt)
+
+	if err := n.callbackAttemptRepo.CreateCallbackAttempt(ctx, attempt); err != nil {
+		logger.LogError(ctx, "Failed to record callback attempt", err)
+	}
+
+	if sendErr == nil {
+		logger.Info(ctx, "Sender notified", "attempt_no", attemptNo)
+		return nil
+	}
+
+	if int(attemptNo) >= n.maxAttempts {
+		return fmt.Errorf("sender notification failed after %d attempts: %w", attemptNo, sendErr)
+	}
+
+	logger.Warn(ctx, "Sender notification failed, scheduling retry",
+		"attempt_no", attemptNo,
+		"error", sendErr.Error(),
+		"retry_delay", n.backoff)
+	if err := n.queue.EnqueueWithDelay(ctx, queue.JobTypeNotifySender, newNotificationPayload(lead.ID, attemptNo+1), n.backoff); err != nil {
+		return fmt.Errorf("failed to schedule notification retry: %w", err)
+	}
+	return nil
+}
+
+// send posts the signed notification and records the outcome on the attempt
+func (n *NotificationWorker) send(ctx context.Context, callbackURL string, lead *models.InboundLead, attempt *models.CallbackAttempt) error {
+	notification := CallbackNotification{
+		LeadID:          lead.ID,
+		FinalStatus:     string(lead.Status),
+		RejectionReason: lead.RejectionReason,
+	}
+	if providerRef := lead.ProviderRef(); providerRef != "" {
+		notification.ProviderRef = &providerRef
+	}
+	if lead.Status == models.LeadStatusDelivered {
+		deliveredAt := lead.UpdatedAt
+		notification.DeliveredAt = &deliveredAt
+	}
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		attempt.MarkFailure(nil, "failed to marshal notification")
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		attempt.MarkFailure(nil, "failed to create request")
+		return fmt.Errorf("failed to create callback request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, "sha256="+SignPayload(n.sharedSecret, body))
+
+	client := n.httpClient
+	if callbackURL == n.senderCallbackURL(lead) {
+		client = n.senderHTTPClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		attempt.MarkFailure(nil, err.Error())
+		return fmt.Errorf("callback request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		statusCode := resp.StatusCode
+		attempt.MarkFailure(&statusCode, fmt.Sprintf("callback returned HTTP %d", statusCode))
+		return fmt.Errorf("callback returned HTTP %d", statusCode)
+	}
+
+	attempt.MarkSuccess(resp.StatusCode)
+	return nil
+}
+
+// SignPayload returns the hex-encoded HMAC-SHA256 of body keyed with secret
+func SignPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newNotificationPayload builds the job payload for a notification attempt
+func newNotificationPayload(leadID int64, attemptNo int64) map[string]interface{} {
+	payload := queue.NewJobPayload(leadID)
+	payload["attempt_no"] = attemptNo
+	return payload
+}
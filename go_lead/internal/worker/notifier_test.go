@@ -0,0 +1,543 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/queue"
+	"github.com/checkfox/go_lead/internal/repository"
+	"github.com/checkfox/go_lead/internal/services"
+)
+
+func init() {
+	// Initialize logger for tests
+	logger.Init()
+}
+
+// notifierLeadRepo serves a single lead for notification tests
+type notifierLeadRepo struct {
+	lead *models.InboundLead
+}
+
+func (m *notifierLeadRepo) CreateLead(ctx context.Context, lead *models.InboundLead) error {
+	return nil
+}
+
+func (m *notifierLeadRepo) GetLeadByID(ctx context.Context, id int64) (*models.InboundLead, error) {
+	if m.lead == nil || m.lead.ID != id {
+		return nil, fmt.Errorf("%w: %d", repository.ErrLeadNotFound, id)
+	}
+	return m.lead, nil
+}
+
+func (m *notifierLeadRepo) UpdateLeadStatus(ctx context.Context, id int64, status models.LeadStatus) error {
+	return nil
+}
+
+func (m *notifierLeadRepo) UpdateLeadStatusFrom(ctx context.Context, id int64, from, to models.LeadStatus) error {
+	return nil
+}
+
+func (m *notifierLeadRepo) UpdateLeadWithPayloads(ctx context.Context, id int64, normalizedPayload, customerPayload models.JSONB) error {
+	return nil
+}
+
+func (m *notifierLeadRepo) UpdateLeadAttachments(ctx context.Context, id int64, metadata models.JSONB, attachmentKeys []string) error {
+	return nil
+}
+
+func (m *notifierLeadRepo) UpdateLeadRejection(ctx context.Context, id int64, reason models.RejectionReason) error {
+	return nil
+}
+
+func (m *notifierLeadRepo) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return nil, nil
+}
+
+func (m *notifierLeadRepo) UpdateLeadStatusTx(ctx context.Context, tx *sql.Tx, id int64, status models.LeadStatus) error {
+	return nil
+}
+
+func (m *notifierLeadRepo) UpdateLeadStatusFromTx(ctx context.Context, tx *sql.Tx, id int64, from, to models.LeadStatus) error {
+	return nil
+}
+
+func (m *notifierLeadRepo) GetLeadCountsByStatus(ctx context.Context) (map[string]int, error) {
+	return map[string]int{}, nil
+}
+
+func (m *notifierLeadRepo) GetLeadCountsByStatusForSource(ctx context.Context, sourceID string) (map[string]int, error) {
+	return map[string]int{}, nil
+}
+
+func (m *notifierLeadRepo) GetLeadCountsByStatusPerSource(ctx context.Context) (map[string]map[string]int, error) {
+	return map[string]map[string]int{}, nil
+}
+
+func (m *notifierLeadRepo) CountLeadsForSourceSince(ctx context.Context, sourceID string, since time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *notifierLeadRepo) GetRecentLeads(ctx context.Context, limit int) ([]*models.InboundLead, error) {
+	return nil, nil
+}
+
+func (m *notifierLeadRepo) FindLeadsByContact(ctx context.Context, email, phone string, limit int) ([]*models.InboundLead, error) {
+	return nil, nil
+}
+
+func (m *notifierLeadRepo) FindLeadByPayloadHash(ctx context.Context, sourceID *string, payloadHash string, since time.Time) (*models.InboundLead, error) {
+	return nil, nil
+}
+
+func (m *notifierLeadRepo) FindInSuppressionList(ctx context.Context, phone, email string) (*models.SuppressionEntry, error) {
+	return nil, nil
+}
+
+// recordingCallbackAttemptRepo records every callback attempt
+type recordingCallbackAttemptRepo struct {
+	attempts []*models.CallbackAttempt
+}
+
+func (m *recordingCallbackAttemptRepo) CreateCallbackAttempt(ctx context.Context, attempt *models.CallbackAttempt) error {
+	m.attempts = append(m.attempts, attempt)
+	return nil
+}
+
+// enqueuedJob is a job captured by recordingQueue
+type enqueuedJob struct {
+	jobType  string
+	payload  map[string]interface{}
+	delay    time.Duration
+	priority int
+}
+
+// recordingQueue records enqueued jobs instead of storing them
+type recordingQueue struct {
+	mu   sync.Mutex
+	jobs []enqueuedJob
+}
+
+func (q *recordingQueue) Enqueue(ctx context.Context, jobType string, payload map[string]interface{}) error {
+	return q.EnqueueWithDelay(ctx, jobType, payload, 0)
+}
+
+func (q *recordingQueue) EnqueueReturningID(ctx context.Context, jobType string, payload map[string]interface{}) (int64, error) {
+	if err := q.Enqueue(ctx, jobType, payload); err != nil {
+		return 0, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int64(len(q.jobs)), nil
+}
+
+func (q *recordingQueue) EnqueueWithDelay(ctx context.Context, jobType string, payload map[string]interface{}, delay time.Duration) error {
+	return q.EnqueueWithPriority(ctx, jobType, payload, delay, 0)
+}
+
+func (q *recordingQueue) EnqueueWithPriority(ctx context.Context, jobType string, payload map[string]interface{}, delay time.Duration, priority int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.jobs = append(q.jobs, enqueuedJob{jobType: jobType, payload: payload, delay: delay, priority: priority})
+	return nil
+}
+
+func (q *recordingQueue) Dequeue(ctx context.Context) (*queue.Job, error) { return nil, nil }
+
+func (q *recordingQueue) Complete(ctx context.Context, jobID int64) error { return nil }
+
+func (q *recordingQueue) Retry(ctx context.Context, jobID int64, delay time.Duration) error {
+	return nil
+}
+
+func (q *recordingQueue) RetryWithPriority(ctx context.Context, jobID int64, delay time.Duration, priority int) error {
+	return nil
+}
+
+func (q *recordingQueue) Fail(ctx context.Context, jobID int64, errorMsg string) error { return nil }
+
+func (q *recordingQueue) HealthCheck(ctx context.Context) error { return nil }
+
+func (q *recordingQueue) Close() error { return nil }
+
+// newTestNotificationWorker creates a notification worker signing with "secret" unless the
+// config names another secret, whose sender callbacks may reach local test servers
+func newTestNotificationWorker(t *testing.T, config NotificationWorkerConfig) *NotificationWorker {
+	t.Helper()
+	if config.SharedSecret == "" {
+		config.SharedSecret = "secret"
+	}
+	notifier, err := NewNotificationWorker(config)
+	if err != nil {
+		t.Fatalf("Failed to create notification worker: %v", err)
+	}
+	notifier.senderHTTPClient = newCallbackHTTPClient(time.Second, nil)
+	return notifier
+}
+
+func newNotifierTestLead(callbackURL string) *models.InboundLead {
+	return &models.InboundLead{
+		ID:        42,
+		Status:    models.LeadStatusDelivered,
+		UpdatedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		SourceHeaders: models.JSONB{
+			"X-Callback-Url": callbackURL,
+			"X-Provider-Ref": "ref-7",
+		},
+	}
+}
+
+func TestNotificationWorker_SendsSignedNotification(t *testing.T) {
+	var receivedBody []byte
+	var receivedSignature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedBody, _ = io.ReadAll(r.Body)
+		receivedSignature = r.Header.Get(SignatureHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	attemptRepo := &recordingCallbackAttemptRepo{}
+	jobQueue := &recordingQueue{}
+	notifier := newTestNotificationWorker(t, NotificationWorkerConfig{
+		Queue:               jobQueue,
+		LeadRepo:            &notifierLeadRepo{lead: newNotifierTestLead(server.URL)},
+		CallbackAttemptRepo: attemptRepo,
+		SharedSecret:        "secret",
+	})
+
+	job := &queue.Job{Type: queue.JobTypeNotifySender, Payload: newNotificationPayload(42, 1)}
+	if err := notifier.HandleJob(context.Background(), job); err != nil {
+		t.Fatalf("Expected notification to succeed, got %v", err)
+	}
+
+	var notification CallbackNotification
+	if err := json.Unmarshal(receivedBody, &notification); err != nil {
+		t.Fatalf("Failed to decode notification: %v", err)
+	}
+	if notification.LeadID != 42 || notification.FinalStatus != string(models.LeadStatusDelivered) {
+		t.Errorf("Unexpected notification: %+v", notification)
+	}
+	if notification.ProviderRef == nil || *notification.ProviderRef != "ref-7" {
+		t.Errorf("Expected provider_ref ref-7, got %v", notification.ProviderRef)
+	}
+	if notification.RejectionReason != nil {
+		t.Errorf("Expected no rejection_reason for a delivered lead, got %q", *notification.RejectionReason)
+	}
+	if notification.DeliveredAt == nil {
+		t.Error("Expected delivered_at to be set for a delivered lead")
+	}
+
+	if expected := "sha256=" + SignPayload("secret", receivedBody); receivedSignature != expected {
+		t.Errorf("Expected signature %s, got %s", expected, receivedSignature)
+	}
+
+	if len(attemptRepo.attempts) != 1 || !attemptRepo.attempts[0].Success {
+		t.Errorf("Expected 1 successful callback attempt, got %+v", attemptRepo.attempts)
+	}
+	if len(jobQueue.jobs) != 0 {
+		t.Errorf("Expected no retry to be scheduled, got %d jobs", len(jobQueue.jobs))
+	}
+}
+
+func TestNotificationWorker_SchedulesRetryOnFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	attemptRepo := &recordingCallbackAttemptRepo{}
+	jobQueue := &recordingQueue{}
+	notifier := newTestNotificationWorker(t, NotificationWorkerConfig{
+		Queue:               jobQueue,
+		LeadRepo:            &notifierLeadRepo{lead: newNotifierTestLead(server.URL)},
+		CallbackAttemptRepo: attemptRepo,
+	})
+
+	job := &queue.Job{Type: queue.JobTypeNotifySender, Payload: newNotificationPayload(42, 1)}
+	if err := notifier.HandleJob(context.Background(), job); err != nil {
+		t.Fatalf("Expected failed attempt to be retried, got %v", err)
+	}
+
+	if len(attemptRepo.attempts) != 1 || attemptRepo.attempts[0].Success {
+		t.Fatalf("Expected 1 failed callback attempt, got %+v", attemptRepo.attempts)
+	}
+	if status := attemptRepo.attempts[0].ResponseStatus; status == nil || *status != http.StatusServiceUnavailable {
+		t.Errorf("Expected recorded status 503, got %v", status)
+	}
+
+	if len(jobQueue.jobs) != 1 {
+		t.Fatalf("Expected 1 retry job, got %d", len(jobQueue.jobs))
+	}
+	retry := jobQueue.jobs[0]
+	if retry.delay != 5*time.Second {
+		t.Errorf("Expected 5s retry backoff, got %v", retry.delay)
+	}
+	if attemptNo, _ := queue.GetInt64(retry.payload, "attempt_no"); attemptNo != 2 {
+		t.Errorf("Expected retry attempt_no 2, got %d", attemptNo)
+	}
+}
+
+func TestNotificationWorker_GivesUpAfterMaxAttempts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	jobQueue := &recordingQueue{}
+	notifier := newTestNotificationWorker(t, NotificationWorkerConfig{
+		Queue:               jobQueue,
+		LeadRepo:            &notifierLeadRepo{lead: newNotifierTestLead(server.URL)},
+		CallbackAttemptRepo: &recordingCallbackAttemptRepo{},
+	})
+
+	job := &queue.Job{Type: queue.JobTypeNotifySender, Payload: newNotificationPayload(42, 3)}
+	if err := notifier.HandleJob(context.Background(), job); err == nil {
+		t.Fatal("Expected error once all attempts are exhausted")
+	}
+
+	if len(jobQueue.jobs) != 0 {
+		t.Errorf("Expected no further retries, got %d", len(jobQueue.jobs))
+	}
+}
+
+func TestNotificationWorker_ScheduleRequiresCallbackURL(t *testing.T) {
+	jobQueue := &recordingQueue{}
+	notifier := newTestNotificationWorker(t, NotificationWorkerConfig{Queue: jobQueue})
+
+	if err := notifier.Schedule(context.Background(), &models.InboundLead{ID: 1}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(jobQueue.jobs) != 0 {
+		t.Fatalf("Expected no job for a lead without callback URL, got %d", len(jobQueue.jobs))
+	}
+
+	if err := notifier.Schedule(context.Background(), newNotifierTestLead("https://sender.example.com")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(jobQueue.jobs) != 1 || jobQueue.jobs[0].jobType != queue.JobTypeNotifySender {
+		t.Errorf("Expected one notify_sender job, got %+v", jobQueue.jobs)
+	}
+}
+
+func TestNotificationWorker_CallbackURLPrecedence(t *testing.T) {
+	notifier := newTestNotificationWorker(t, NotificationWorkerConfig{
+		DefaultCallbackURL: "https://default.example.com",
+		SourceCallbackURLs: map[string]string{"partner-a": "https://partner-a.example.com"},
+	})
+	partnerA, partnerB := "partner-a", "partner-b"
+
+	tests := []struct {
+		name     string
+		lead     *models.InboundLead
+		expected string
+	}{
+		{"lead callback URL wins", &models.InboundLead{SourceID: &partnerA, SourceHeaders: models.JSONB{"X-Callback-Url": "https://lead.example.com"}}, "https://lead.example.com"},
+		{"source callback URL", &models.InboundLead{SourceID: &partnerA}, "https://partner-a.example.com"},
+		{"unknown source falls back to default", &models.InboundLead{SourceID: &partnerB}, "https://default.example.com"},
+		{"no source falls back to default", &models.InboundLead{}, "https://default.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notifier.callbackURL(tt.lead); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewNotificationWorker_RequiresSharedSecret(t *testing.T) {
+	if _, err := NewNotificationWorker(NotificationWorkerConfig{}); err == nil {
+		t.Fatal("Expected error without a shared secret")
+	}
+}
+
+func TestNotificationWorker_RejectsPrivateSenderCallback(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	attemptRepo := &recordingCallbackAttemptRepo{}
+	notifier, err := NewNotificationWorker(NotificationWorkerConfig{
+		Queue:               &recordingQueue{},
+		LeadRepo:            &notifierLeadRepo{lead: newNotifierTestLead(server.URL)},
+		CallbackAttemptRepo: attemptRepo,
+		SharedSecret:        "secret",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create notification worker: %v", err)
+	}
+
+	job := &queue.Job{Type: queue.JobTypeNotifySender, Payload: newNotificationPayload(42, 1)}
+	if err := notifier.HandleJob(context.Background(), job); err != nil {
+		t.Fatalf("Expected failed attempt to be retried, got %v", err)
+	}
+
+	if requests != 0 {
+		t.Errorf("Expected no request to the loopback callback URL, got %d", requests)
+	}
+	if len(attemptRepo.attempts) != 1 || attemptRepo.attempts[0].Success {
+		t.Fatalf("Expected 1 failed callback attempt, got %+v", attemptRepo.attempts)
+	}
+}
+
+func TestNotificationWorker_DoesNotFollowRedirects(t *testing.T) {
+	redirected := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	attemptRepo := &recordingCallbackAttemptRepo{}
+	notifier := newTestNotificationWorker(t, NotificationWorkerConfig{
+		Queue:               &recordingQueue{},
+		LeadRepo:            &notifierLeadRepo{lead: newNotifierTestLead(server.URL)},
+		CallbackAttemptRepo: attemptRepo,
+	})
+
+	job := &queue.Job{Type: queue.JobTypeNotifySender, Payload: newNotificationPayload(42, 1)}
+	if err := notifier.HandleJob(context.Background(), job); err != nil {
+		t.Fatalf("Expected failed attempt to be retried, got %v", err)
+	}
+
+	if redirected {
+		t.Error("Expected the redirect not to be followed")
+	}
+	if status := attemptRepo.attempts[0].ResponseStatus; status == nil || *status != http.StatusTemporaryRedirect {
+		t.Errorf("Expected recorded status 307, got %v", status)
+	}
+}
+
+func TestNotificationWorker_AllowedHosts(t *testing.T) {
+	notifier := newTestNotificationWorker(t, NotificationWorkerConfig{
+		DefaultCallbackURL: "https://default.example.com",
+		AllowedHosts:       []string{"Sender.example.com"},
+	})
+
+	tests := []struct {
+		callbackURL string
+		expected    string
+	}{
+		{"https://sender.example.com/callback", "https://sender.example.com/callback"},
+		{"https://sender.example.com:8443/callback", "https://sender.example.com:8443/callback"},
+		{"http://169.254.169.254/latest/meta-data", "https://default.example.com"},
+		{"https://other.example.com/callback", "https://default.example.com"},
+	}
+
+	for _, tt := range tests {
+		lead := &models.InboundLead{SourceHeaders: models.JSONB{"X-Callback-Url": tt.callbackURL}}
+		if got := notifier.callbackURL(lead); got != tt.expected {
+			t.Errorf("callbackURL with %s = %s, expected %s", tt.callbackURL, got, tt.expected)
+		}
+	}
+}
+
+func TestRejectPrivateAddress(t *testing.T) {
+	for _, tt := range []struct {
+		address  string
+		rejected bool
+	}{
+		{"127.0.0.1:80", true},
+		{"[::1]:443", true},
+		{"10.0.0.5:80", true},
+		{"192.168.1.1:80", true},
+		{"169.254.169.254:80", true},
+		{"0.0.0.0:80", true},
+		{"93.184.216.34:443", false},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", false},
+	} {
+		err := rejectPrivateAddress("tcp", tt.address, nil)
+		if rejected := errors.Is(err, errPrivateCallbackAddress); rejected != tt.rejected {
+			t.Errorf("rejectPrivateAddress(%s) = %v, expected rejected %v", tt.address, err, tt.rejected)
+		}
+	}
+}
+
+// statusRecordingLeadRepo records status changes of the served lead
+type statusRecordingLeadRepo struct {
+	notifierLeadRepo
+	statusUpdates []models.LeadStatus
+}
+
+func (m *statusRecordingLeadRepo) UpdateLeadStatus(ctx context.Context, id int64, status models.LeadStatus) error {
+	m.statusUpdates = append(m.statusUpdates, status)
+	return nil
+}
+
+func (m *statusRecordingLeadRepo) UpdateLeadRejection(ctx context.Context, id int64, reason models.RejectionReason) error {
+	m.statusUpdates = append(m.statusUpdates, models.LeadStatusRejected)
+	return nil
+}
+
+func TestProcessLead_NotifiesRejectedLead(t *testing.T) {
+	var received CallbackNotification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	// A lead without a zipcode is rejected by validation
+	lead := &models.InboundLead{ID: 42, Status: models.LeadStatusReceived, RawPayload: models.JSONB{}}
+	leadRepo := &statusRecordingLeadRepo{notifierLeadRepo: notifierLeadRepo{lead: lead}}
+	jobQueue := &recordingQueue{}
+	notifier := newTestNotificationWorker(t, NotificationWorkerConfig{
+		Queue:               jobQueue,
+		LeadRepo:            leadRepo,
+		CallbackAttemptRepo: &recordingCallbackAttemptRepo{},
+		DefaultCallbackURL:  server.URL,
+	})
+	processor := NewProcessor(ProcessorConfig{
+		Queue:     jobQueue,
+		LeadRepo:  leadRepo,
+		Validator: services.NewValidator(),
+		Notifier:  notifier,
+	})
+	ctx := context.Background()
+
+	if err := processor.processLead(ctx, &queue.Job{ID: 1, Type: queue.JobTypeProcessLead, Payload: queue.NewJobPayload(42)}); err != nil {
+		t.Fatalf("Failed to process lead: %v", err)
+	}
+	if len(jobQueue.jobs) != 1 || jobQueue.jobs[0].jobType != queue.JobTypeNotifySender {
+		t.Fatalf("Expected one notify_sender job for the rejected lead, got %+v", jobQueue.jobs)
+	}
+
+	// The callback fails; the notification is retried but the lead stays REJECTED
+	job := &queue.Job{Type: queue.JobTypeNotifySender, Payload: jobQueue.jobs[0].payload}
+	if err := notifier.HandleJob(ctx, job); err != nil {
+		t.Fatalf("Expected the failed callback to be retried, got %v", err)
+	}
+
+	if received.LeadID != 42 || received.FinalStatus != string(models.LeadStatusRejected) {
+		t.Errorf("Unexpected notification: %+v", received)
+	}
+	if received.RejectionReason == nil || lead.RejectionReason == nil || *received.RejectionReason != *lead.RejectionReason {
+		t.Errorf("Expected the lead's rejection_reason, got %v", received.RejectionReason)
+	}
+	if len(leadRepo.statusUpdates) != 1 || leadRepo.statusUpdates[0] != models.LeadStatusRejected || lead.Status != models.LeadStatusRejected {
+		t.Errorf("Expected the callback failure not to change the lead, got updates %v and status %s", leadRepo.statusUpdates, lead.Status)
+	}
+	if len(jobQueue.jobs) != 2 {
+		t.Errorf("Expected a notification retry to be scheduled, got %d jobs", len(jobQueue.jobs))
+	}
+}
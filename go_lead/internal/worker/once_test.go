@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/queue"
+)
+
+// sliceQueue hands out a fixed list of jobs and records their outcome
+type sliceQueue struct {
+	recordingQueue
+	pending    []*queue.Job
+	completed  []int64
+	failed     []int64
+	retried    []int64
+	delays     []time.Duration // delays of the retried jobs
+	priorities []int           // priorities of the jobs retried with a new priority
+}
+
+func (q *sliceQueue) Dequeue(ctx context.Context) (*queue.Job, error) {
+	if len(q.pending) == 0 {
+		return nil, nil
+	}
+	job := q.pending[0]
+	q.pending = q.pending[1:]
+	return job, nil
+}
+
+func (q *sliceQueue) Complete(ctx context.Context, jobID int64) error {
+	q.completed = append(q.completed, jobID)
+	return nil
+}
+
+func (q *sliceQueue) Retry(ctx context.Context, jobID int64, delay time.Duration) error {
+	q.retried = append(q.retried, jobID)
+	q.delays = append(q.delays, delay)
+	return nil
+}
+
+func (q *sliceQueue) RetryWithPriority(ctx context.Context, jobID int64, delay time.Duration, priority int) error {
+	q.priorities = append(q.priorities, priority)
+	return q.Retry(ctx, jobID, delay)
+}
+
+func (q *sliceQueue) Fail(ctx context.Context, jobID int64, errorMsg string) error {
+	q.failed = append(q.failed, jobID)
+	return nil
+}
+
+// newOnceTestProcessor builds a processor whose notify_sender jobs succeed without network calls
+func newOnceTestProcessor(t *testing.T, jobQueue *sliceQueue) *Processor {
+	notifier := newTestNotificationWorker(t, NotificationWorkerConfig{
+		Queue:    jobQueue,
+		LeadRepo: &notifierLeadRepo{lead: &models.InboundLead{ID: 42}}, // no callback URL, nothing to send
+	})
+	return NewProcessor(ProcessorConfig{Queue: jobQueue, Notifier: notifier})
+}
+
+func newNotifyJobs(ids ...int64) []*queue.Job {
+	jobs := make([]*queue.Job, 0, len(ids))
+	for _, id := range ids {
+		jobs = append(jobs, &queue.Job{ID: id, Type: queue.JobTypeNotifySender, Payload: newNotificationPayload(42, 1)})
+	}
+	return jobs
+}
+
+func TestRunOnce_DrainsQueue(t *testing.T) {
+	jobQueue := &sliceQueue{pending: newNotifyJobs(1, 2, 3)}
+	processor := newOnceTestProcessor(t, jobQueue)
+
+	summary, err := processor.RunOnce(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if summary.Processed != 3 || summary.Succeeded != 3 || summary.Failed != 0 {
+		t.Errorf("Expected 3 processed and succeeded jobs, got %+v", summary)
+	}
+	if len(jobQueue.completed) != 3 || len(jobQueue.pending) != 0 {
+		t.Errorf("Expected all jobs completed, got completed=%v pending=%d", jobQueue.completed, len(jobQueue.pending))
+	}
+}
+
+func TestRunOnce_StopsAtMaxJobs(t *testing.T) {
+	jobQueue := &sliceQueue{pending: newNotifyJobs(1, 2, 3)}
+	processor := newOnceTestProcessor(t, jobQueue)
+
+	summary, err := processor.RunOnce(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if summary.Processed != 2 {
+		t.Errorf("Expected 2 processed jobs, got %+v", summary)
+	}
+	if len(jobQueue.pending) != 1 {
+		t.Errorf("Expected 1 job left in the queue, got %d", len(jobQueue.pending))
+	}
+}
+
+func TestRunOnce_CountsFailedJobs(t *testing.T) {
+	jobs := newNotifyJobs(1)
+	jobs = append(jobs, &queue.Job{ID: 2, Type: "unknown"})
+	jobQueue := &sliceQueue{pending: jobs}
+	processor := newOnceTestProcessor(t, jobQueue)
+
+	summary, err := processor.RunOnce(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if summary.Processed != 2 || summary.Succeeded != 1 || summary.Failed != 1 {
+		t.Errorf("Expected 1 succeeded and 1 failed job, got %+v", summary)
+	}
+	if len(jobQueue.failed) != 1 || jobQueue.failed[0] != 2 {
+		t.Errorf("Expected job 2 to be failed, got %v", jobQueue.failed)
+	}
+}
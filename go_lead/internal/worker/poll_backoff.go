@@ -0,0 +1,50 @@
+package worker
+
+import "time"
+
+// emptyPollsBeforeBackoff is the number of consecutive empty polls after which the poll interval doubles
+const emptyPollsBeforeBackoff = 3
+
+// pollBackoff adapts the worker's poll interval to the queue: the interval doubles after
+// every emptyPollsBeforeBackoff consecutive empty polls, up to max, and drops back to min
+// as soon as a job is found
+type pollBackoff struct {
+	min        time.Duration
+	max        time.Duration
+	interval   time.Duration
+	emptyPolls int
+}
+
+// newPollBackoff creates a pollBackoff starting at min. A max below min disables the backoff.
+func newPollBackoff(min, max time.Duration) *pollBackoff {
+	if max < min {
+		max = min
+	}
+	return &pollBackoff{min: min, max: max, interval: min}
+}
+
+// Interval returns the current poll interval
+func (b *pollBackoff) Interval() time.Duration {
+	return b.interval
+}
+
+// Record updates the interval after a poll and reports whether it changed
+func (b *pollBackoff) Record(found bool) bool {
+	previous := b.interval
+
+	if found {
+		b.emptyPolls = 0
+		b.interval = b.min
+		return b.interval != previous
+	}
+
+	b.emptyPolls++
+	if b.emptyPolls >= emptyPollsBeforeBackoff {
+		b.emptyPolls = 0
+		b.interval *= 2
+		if b.interval > b.max {
+			b.interval = b.max
+		}
+	}
+	return b.interval != previous
+}
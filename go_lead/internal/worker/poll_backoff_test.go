@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPollBackoff_DoublesAfterEmptyPollsAndResets(t *testing.T) {
+	backoff := newPollBackoff(time.Second, 5*time.Second)
+
+	// Polls 1-3 are empty, then the interval doubles; it is capped at the maximum
+	expected := []time.Duration{
+		time.Second, time.Second, 2 * time.Second,
+		2 * time.Second, 2 * time.Second, 4 * time.Second,
+		4 * time.Second, 4 * time.Second, 5 * time.Second,
+		5 * time.Second, 5 * time.Second, 5 * time.Second,
+	}
+	for i, want := range expected {
+		changed := backoff.Record(false)
+		if got := backoff.Interval(); got != want {
+			t.Fatalf("After empty poll %d: expected interval %v, got %v", i+1, want, got)
+		}
+		if wantChanged := (i+1)%3 == 0 && i+1 < 12; changed != wantChanged {
+			t.Errorf("After empty poll %d: expected changed=%v, got %v", i+1, wantChanged, changed)
+		}
+	}
+
+	if !backoff.Record(true) || backoff.Interval() != time.Second {
+		t.Errorf("Expected a found job to reset the interval to 1s, got %v", backoff.Interval())
+	}
+	if backoff.Record(true) {
+		t.Error("Expected no change while jobs keep arriving at the minimum interval")
+	}
+
+	// The empty poll count starts over after a job was found
+	backoff.Record(false)
+	backoff.Record(false)
+	if backoff.Interval() != time.Second {
+		t.Errorf("Expected the interval to stay at 1s after 2 empty polls, got %v", backoff.Interval())
+	}
+}
+
+func TestPollBackoff_MaxBelowMinDisablesBackoff(t *testing.T) {
+	backoff := newPollBackoff(10*time.Second, time.Second)
+
+	for i := 0; i < 6; i++ {
+		if backoff.Record(false) {
+			t.Fatalf("Expected a constant interval, changed to %v", backoff.Interval())
+		}
+	}
+	if backoff.Interval() != 10*time.Second {
+		t.Errorf("Expected interval 10s, got %v", backoff.Interval())
+	}
+}
+
+func TestStart_BacksOffWhileQueueIsEmpty(t *testing.T) {
+	jobQueue := &sliceQueue{}
+	processor := newOnceTestProcessor(t, jobQueue)
+	processor.pollInterval = time.Second
+	processor.maxPollInterval = 5 * time.Second
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Record the interval of every poll instead of waiting; a job arrives before poll 10
+	var intervals []time.Duration
+	processor.after = func(d time.Duration) <-chan time.Time {
+		intervals = append(intervals, d)
+		switch len(intervals) {
+		case 10:
+			jobQueue.pending = newNotifyJobs(1)
+		case 11:
+			cancel()
+			return nil
+		}
+		fired := make(chan time.Time, 1)
+		fired <- time.Time{}
+		return fired
+	}
+
+	if err := processor.Start(ctx); err != context.Canceled {
+		t.Fatalf("Expected Start to stop with context.Canceled, got %v", err)
+	}
+
+	expected := []time.Duration{
+		time.Second, time.Second, time.Second,
+		2 * time.Second, 2 * time.Second, 2 * time.Second,
+		4 * time.Second, 4 * time.Second, 4 * time.Second,
+		5 * time.Second,
+		time.Second, // reset after the job was found
+	}
+	if len(intervals) != len(expected) {
+		t.Fatalf("Expected intervals %v, got %v", expected, intervals)
+	}
+	for i := range expected {
+		if intervals[i] != expected[i] {
+			t.Fatalf("Expected intervals %v, got %v", expected, intervals)
+		}
+	}
+	if len(jobQueue.completed) != 1 {
+		t.Errorf("Expected the arriving job to be completed, got %v", jobQueue.completed)
+	}
+}
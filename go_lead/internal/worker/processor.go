@@ -2,20 +2,30 @@ package worker
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/checkfox/go_lead/internal/alerting"
 	"github.com/checkfox/go_lead/internal/client"
+	"github.com/checkfox/go_lead/internal/config"
 	"github.com/checkfox/go_lead/internal/logger"
 	"github.com/checkfox/go_lead/internal/models"
 	"github.com/checkfox/go_lead/internal/queue"
 	"github.com/checkfox/go_lead/internal/repository"
+	"github.com/checkfox/go_lead/internal/schedule"
 	"github.com/checkfox/go_lead/internal/services"
+	"github.com/checkfox/go_lead/internal/tracing"
 )
 
+// customerPayloadFlagsField is the customer payload field listing soft validation failures
+const customerPayloadFlagsField = "_flags"
+
 // Processor handles background job processing for leads
 type Processor struct {
 	queue                     queue.Queue
@@ -23,12 +33,43 @@ type Processor struct {
 	deliveryAttemptRepo       repository.DeliveryAttemptRepository
 	validator                 *services.Validator
 	normalizer                *services.Normalizer
+	normalizationAuditRepo    repository.NormalizationAuditRepository
 	mapper                    *services.Mapper
-	customerAPIClient         *client.CustomerAPIClient
+	keyCase                   *services.CustomerKeyCase
+	enricher                  services.Enricher
+	logObfuscator             *logger.LogObfuscator
+	lockRepo                  repository.ProcessingLockRepository
+	workerID                  string
+	lockTimeout               time.Duration
+	customerAPIClient         LeadSender
+	deliveryPool              *client.DeliveryConnectionPool
+	unexpectedResponseOutcome string
+	forwardingChain           []LeadSender
+	chainAttemptRepo          repository.DeliveryChainAttemptRepository
+	fallbackClient            LeadSender
+	fallbackAfterAttempts     int
 	pollInterval              time.Duration
+	maxPollInterval           time.Duration
 	shutdownChan              chan struct{}
 	maxDeliveryAttempts       int
 	exponentialBackoffDelays  []time.Duration
+	priorityPenalty           int
+	attemptRetention          int
+	forwardHeaders            []string
+	suppressionListEnabled    bool
+	asyncDeliveryMode         bool
+	confirmationTimeout       time.Duration
+	notifier                  *NotificationWorker
+	rejectionNotifier         alerting.RejectionNotifier
+	deliveryWindow            *schedule.Window
+	leadLocks                 *leadLocks
+	now                       func() time.Time
+	after                     func(time.Duration) <-chan time.Time
+}
+
+// LeadSender delivers customer payloads, implemented by client.CustomerAPIClient
+type LeadSender interface {
+	SendLead(ctx context.Context, payload map[string]interface{}, headers map[string]string) (*client.DeliveryResponse, error)
 }
 
 // ProcessorConfig holds configuration for the worker processor
@@ -38,11 +79,45 @@ type ProcessorConfig struct {
 	DeliveryAttemptRepo      repository.DeliveryAttemptRepository
 	Validator                *services.Validator
 	Normalizer               *services.Normalizer
+	NormalizationAuditRepo   repository.NormalizationAuditRepository // optional, records the fields normalization changed
 	Mapper                   *services.Mapper
-	CustomerAPIClient        *client.CustomerAPIClient
+	KeyCase                  *services.CustomerKeyCase           // optional, converts the customer payload keys before storing
+	Enricher                 services.Enricher                   // optional, derives fields between normalization and mapping
+	LogObfuscator            *logger.LogObfuscator               // optional, redacts PII in transformation logs
+	ProcessingLockRepo       repository.ProcessingLockRepository // optional, enables crash recovery
+	WorkerID                 string                              // identifies this worker in processing locks
+	LockTimeout              time.Duration                       // age after which a processing lock is considered abandoned
+	CustomerAPIClient        LeadSender
+	DeliveryPool             *client.DeliveryConnectionPool // optional, bounds simultaneous SendLead calls
+	ForwardHeaders           []string                       // webhook request headers sent along with each delivery
+	SuppressionListEnabled   bool                           // reject leads whose phone or email is in the suppression list
 	PollInterval             time.Duration
+	MaxPollInterval          time.Duration // poll interval limit while the queue stays empty
 	MaxDeliveryAttempts      int
-	ExponentialBackoffDelays []time.Duration
+	ExponentialBackoffDelays []time.Duration            // delay before retrying after the i-th failed attempt, waited out in the queue
+	PriorityPenalty          int                        // queue priority added per failed delivery attempt
+	AttemptRetention         int                        // delivery attempt rows kept per lead (0 = unlimited)
+	AsyncDeliveryMode        bool                       // treat 202 Accepted as PENDING_CONFIRMATION
+	ConfirmationTimeout      time.Duration              // delay before unconfirmed leads are marked FAILED
+	Notifier                 *NotificationWorker        // optional, notifies senders of the final lead outcome
+	RejectionNotifier        alerting.RejectionNotifier // optional, informs operators of rejected leads
+	DeliverySchedule         config.DeliverySchedule
+	Clock                    func() time.Time // defaults to time.Now, replaced in tests
+
+	// UnexpectedResponseOutcome classifies responses that report neither success nor an
+	// error as config.StatusOutcomeRetriableFailure (default) or StatusOutcomePermanentFailure
+	UnexpectedResponseOutcome string
+
+	// ForwardingChain holds secondary endpoints that receive each lead after the primary
+	// delivery attempt; ChainAttemptRepo records their attempts. Secondary failures never
+	// change the lead status or trigger retries.
+	ForwardingChain  []LeadSender
+	ChainAttemptRepo repository.DeliveryChainAttemptRepository
+
+	// FallbackClient, if set, receives the deliveries of a lead once FallbackAfterAttempts
+	// attempts at the primary endpoint failed (default 3)
+	FallbackClient        LeadSender
+	FallbackAfterAttempts int
 }
 
 // NewProcessor creates a new worker processor
@@ -52,6 +127,11 @@ func NewProcessor(config ProcessorConfig) *Processor {
 		config.PollInterval = 5 * time.Second
 	}
 
+	// Set default max poll interval if not provided
+	if config.MaxPollInterval == 0 {
+		config.MaxPollInterval = 60 * time.Second
+	}
+
 	// Set default max delivery attempts if not provided
 	if config.MaxDeliveryAttempts == 0 {
 		config.MaxDeliveryAttempts = 5
@@ -68,18 +148,81 @@ func NewProcessor(config ProcessorConfig) *Processor {
 		}
 	}
 
+	// Set default priority penalty if not provided
+	if config.PriorityPenalty == 0 {
+		config.PriorityPenalty = 1
+	}
+
+	// Set default enricher if not provided
+	if config.Enricher == nil {
+		config.Enricher = services.NoopEnricher{}
+	}
+
+	// Set default worker ID if not provided
+	if config.WorkerID == "" {
+		hostname, _ := os.Hostname()
+		config.WorkerID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+
+	// Set default lock timeout if not provided
+	if config.LockTimeout == 0 {
+		config.LockTimeout = 10 * time.Minute
+	}
+
+	// Set default clock if not provided
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
+
+	// Set default confirmation timeout if not provided
+	if config.ConfirmationTimeout == 0 {
+		config.ConfirmationTimeout = time.Hour
+	}
+
+	// Set default fallback threshold if not provided
+	if config.FallbackAfterAttempts == 0 {
+		config.FallbackAfterAttempts = 3
+	}
+
 	return &Processor{
 		queue:                    config.Queue,
 		leadRepo:                 config.LeadRepo,
 		deliveryAttemptRepo:      config.DeliveryAttemptRepo,
 		validator:                config.Validator,
 		normalizer:               config.Normalizer,
+		normalizationAuditRepo:   config.NormalizationAuditRepo,
 		mapper:                   config.Mapper,
+		keyCase:                  config.KeyCase,
+		enricher:                 config.Enricher,
+		logObfuscator:            config.LogObfuscator,
+		lockRepo:                 config.ProcessingLockRepo,
+		workerID:                 config.WorkerID,
+		lockTimeout:              config.LockTimeout,
 		customerAPIClient:        config.CustomerAPIClient,
+		deliveryPool:             config.DeliveryPool,
 		pollInterval:             config.PollInterval,
+		maxPollInterval:          config.MaxPollInterval,
 		shutdownChan:             make(chan struct{}),
 		maxDeliveryAttempts:      config.MaxDeliveryAttempts,
+		attemptRetention:         config.AttemptRetention,
+		forwardHeaders:           config.ForwardHeaders,
+		suppressionListEnabled:   config.SuppressionListEnabled,
 		exponentialBackoffDelays: config.ExponentialBackoffDelays,
+		priorityPenalty:          config.PriorityPenalty,
+		asyncDeliveryMode:        config.AsyncDeliveryMode,
+		confirmationTimeout:      config.ConfirmationTimeout,
+		notifier:                 config.Notifier,
+		rejectionNotifier:        config.RejectionNotifier,
+		deliveryWindow:           schedule.NewWindow(config.DeliverySchedule),
+		leadLocks:                newLeadLocks(),
+		now:                      config.Clock,
+		after:                    time.After,
+
+		unexpectedResponseOutcome: config.UnexpectedResponseOutcome,
+		forwardingChain:           config.ForwardingChain,
+		chainAttemptRepo:          config.ChainAttemptRepo,
+		fallbackClient:            config.FallbackClient,
+		fallbackAfterAttempts:     config.FallbackAfterAttempts,
 	}
 }
 
@@ -91,10 +234,19 @@ func (p *Processor) Start(ctx context.Context) error {
 	// Set up signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan) // processors are replaced on restart
+
+	// Recover jobs left behind by crashed workers before taking new ones
+	if _, err := p.RecoverStuckJobs(ctx); err != nil {
+		logger.LogError(ctx, "Failed to recover stuck jobs", err)
+	}
+
+	// Poll at the configured interval, backing off while the queue stays empty
+	backoff := newPollBackoff(p.pollInterval, p.maxPollInterval)
 
-	// Create a ticker for polling
-	ticker := time.NewTicker(p.pollInterval)
-	defer ticker.Stop()
+	// Check for abandoned locks once per lock timeout
+	recoveryTicker := time.NewTicker(p.lockTimeout)
+	defer recoveryTicker.Stop()
 
 	// Start the polling loop
 	for {
@@ -111,12 +263,22 @@ func (p *Processor) Start(ctx context.Context) error {
 			logger.Info(ctx, "Shutdown requested, shutting down gracefully")
 			return nil
 
-		case <-ticker.C:
+		case <-recoveryTicker.C:
+			if _, err := p.RecoverStuckJobs(ctx); err != nil {
+				logger.LogError(ctx, "Failed to recover stuck jobs", err)
+			}
+
+		case <-p.after(backoff.Interval()):
 			// Poll for jobs
-			if err := p.pollAndProcess(ctx); err != nil {
+			processed, err := p.pollAndProcess(ctx)
+			if err != nil {
 				logger.LogError(ctx, "Error polling and processing jobs", err)
 				// Continue polling even if there's an error
 			}
+
+			if backoff.Record(processed) {
+				logger.Info(ctx, "Poll interval changed", "poll_interval", backoff.Interval())
+			}
 		}
 	}
 }
@@ -126,30 +288,173 @@ func (p *Processor) Shutdown() {
 	close(p.shutdownChan)
 }
 
-// pollAndProcess polls for a job and processes it
-func (p *Processor) pollAndProcess(ctx context.Context) error {
+// OmissionStats returns the attribute omission counts of the processor's mapper
+func (p *Processor) OmissionStats() map[string]int64 {
+	return p.mapper.OmissionStats()
+}
+
+// UnknownOmissions returns the omissions of unmapped attributes by the processor's mapper
+func (p *Processor) UnknownOmissions() int64 {
+	return p.mapper.UnknownOmissions()
+}
+
+// pollAndProcess polls for a job and processes it.
+// Returns false if the queue was empty.
+func (p *Processor) pollAndProcess(ctx context.Context) (bool, error) {
+	return p.processNextJob(ctx)
+}
+
+// RunSummary reports the outcome of a one-shot run
+type RunSummary struct {
+	Processed int
+	Succeeded int
+	Failed    int
+}
+
+// RunOnce processes up to maxJobs available jobs and returns once the limit is
+// reached or the queue is empty. A maxJobs of 0 drains the queue.
+// Used for cron-style deployments instead of Start.
+func (p *Processor) RunOnce(ctx context.Context, maxJobs int) (*RunSummary, error) {
+	logger.Info(ctx, "Running worker processor once", "max_jobs", maxJobs)
+
+	if _, err := p.RecoverStuckJobs(ctx); err != nil {
+		logger.LogError(ctx, "Failed to recover stuck jobs", err)
+	}
+
+	summary := &RunSummary{}
+	for maxJobs == 0 || summary.Processed < maxJobs {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		processed, err := p.processNextJob(ctx)
+		if !processed {
+			if err != nil {
+				return summary, err
+			}
+			break
+		}
+
+		summary.Processed++
+		if err != nil {
+			summary.Failed++
+		} else {
+			summary.Succeeded++
+		}
+	}
+
+	logger.Info(ctx, "Worker run completed",
+		"processed", summary.Processed,
+		"succeeded", summary.Succeeded,
+		"failed", summary.Failed)
+	return summary, nil
+}
+
+// RecoverStuckJobs recovers jobs whose processing lock is older than the lock timeout,
+// which means the worker holding it crashed between Dequeue and Complete. Delivery
+// attempts recorded under the lock without a response are marked as errored, the
+// lock is released, and the job is put back on the queue.
+// Returns the number of recovered jobs.
+func (p *Processor) RecoverStuckJobs(ctx context.Context) (int, error) {
+	if p.lockRepo == nil {
+		return 0, nil
+	}
+
+	locks, err := p.lockRepo.GetStaleLocks(ctx, p.now().Add(-p.lockTimeout))
+	if err != nil {
+		return 0, err
+	}
+
+	recovered := 0
+	for _, lock := range locks {
+		marked, err := p.lockRepo.RecoverLock(ctx, lock, "worker stopped before the delivery response was recorded")
+		if err != nil {
+			logger.LogError(ctx, "Failed to recover processing lock", err, "job_id", lock.JobID)
+			continue
+		}
+
+		if err := p.queue.Retry(ctx, lock.JobID, 0); err != nil {
+			logger.LogError(ctx, "Failed to requeue recovered job", err, "job_id", lock.JobID)
+			continue
+		}
+
+		logger.Warn(ctx, "Recovered job from crashed worker",
+			"job_id", lock.JobID,
+			"lead_id", lock.LeadID,
+			"worker_id", lock.WorkerID,
+			"locked_at", lock.LockedAt,
+			"attempts_marked", marked)
+		recovered++
+	}
+
+	return recovered, nil
+}
+
+// processNextJob dequeues and processes a single job.
+// Returns false if no job was dequeued.
+func (p *Processor) processNextJob(ctx context.Context) (bool, error) {
 	// Dequeue the next job
 	job, err := p.queue.Dequeue(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to dequeue job: %w", err)
+		return false, fmt.Errorf("failed to dequeue job: %w", err)
 	}
 
 	// No jobs available
 	if job == nil {
-		return nil
+		return false, nil
 	}
 
 	logger.Info(ctx, "Processing job", "job_id", job.ID, "job_type", job.Type)
 
+	// Hold a processing lock for the duration of the job so a crash can be recovered
+	if p.lockRepo != nil {
+		if leadID, ok := queue.GetLeadID(job.Payload); ok {
+			lock := &models.ProcessingLock{JobID: job.ID, LeadID: leadID, WorkerID: p.workerID, LockedAt: p.now()}
+			if err := p.lockRepo.AcquireLock(ctx, lock); err != nil {
+				logger.LogError(ctx, "Failed to acquire processing lock", err, "job_id", job.ID)
+			} else {
+				defer func() {
+					if err := p.lockRepo.ReleaseLock(ctx, job.ID); err != nil {
+						logger.LogError(ctx, "Failed to release processing lock", err, "job_id", job.ID)
+					}
+				}()
+			}
+		}
+	}
+
+	// A process_lead job holds the queue slot of its lead, so a retry reschedules the job itself
+	retry := &jobRetry{}
+	ctx = context.WithValue(ctx, jobRetryKey{}, retry)
+
 	// Process the job based on its type
 	var processErr error
 	switch job.Type {
-	case "process_lead":
+	case queue.JobTypeProcessLead:
 		processErr = p.processLead(ctx, job)
+	case queue.JobTypeConfirmationTimeout:
+		processErr = p.processConfirmationTimeout(ctx, job)
+	case queue.JobTypeNotifySender:
+		if p.notifier == nil {
+			processErr = fmt.Errorf("sender notifications are not configured")
+		} else {
+			processErr = p.notifier.HandleJob(ctx, job)
+		}
 	default:
 		processErr = fmt.Errorf("unknown job type: %s", job.Type)
 	}
 
+	// Reschedule the job if delivery is not allowed yet
+	var deliveryErr *models.DeliveryError
+	if errors.As(processErr, &deliveryErr) && deliveryErr.NextAttemptAt != nil {
+		delay := deliveryErr.NextAttemptAt.Sub(p.now())
+		logger.Info(ctx, "Rescheduling job", "job_id", job.ID, "next_attempt_at", *deliveryErr.NextAttemptAt)
+		if err := p.queue.Retry(ctx, job.ID, delay); err != nil {
+			logger.LogError(ctx, "Failed to reschedule job", err, "job_id", job.ID)
+			return true, err
+		}
+		return true, nil
+	}
+
 	// Handle job completion or failure
 	if processErr != nil {
 		logger.LogError(ctx, "Job failed", processErr, "job_id", job.ID)
@@ -157,17 +462,27 @@ func (p *Processor) pollAndProcess(ctx context.Context) error {
 		if err := p.queue.Fail(ctx, job.ID, processErr.Error()); err != nil {
 			logger.LogError(ctx, "Failed to mark job as failed", err, "job_id", job.ID)
 		}
-		return processErr
+		return true, processErr
+	}
+
+	// Put the job back behind fresh ones if its lead is to be retried
+	if retry.requested {
+		if err := p.queue.RetryWithPriority(ctx, job.ID, retry.delay, retry.priority); err != nil {
+			logger.LogError(ctx, "Failed to reschedule job", err, "job_id", job.ID)
+			return true, err
+		}
+		logger.Info(ctx, "Job rescheduled for retry", "job_id", job.ID, "delay", retry.delay, "priority", retry.priority)
+		return true, nil
 	}
 
 	// Mark job as completed
 	if err := p.queue.Complete(ctx, job.ID); err != nil {
 		logger.LogError(ctx, "Failed to mark job as completed", err, "job_id", job.ID)
-		return err
+		return true, err
 	}
 
 	logger.Info(ctx, "Job completed successfully", "job_id", job.ID)
-	return nil
+	return true, nil
 }
 
 // processLead processes a single lead through the validation, transformation, and delivery pipeline
@@ -183,68 +498,160 @@ func (p *Processor) processLead(ctx context.Context, job *queue.Job) error {
 
 	// Add lead_id to context for logging
 	ctx = context.WithValue(ctx, logger.LeadIDKey, leadID)
+
+	// Another goroutine is already processing this lead (e.g. a reclaimed stuck job), so
+	// this job is completed as a no-op; the running one records the delivery outcome
+	if !p.leadLocks.TryLock(leadID) {
+		logger.Warn(ctx, "Lead is already being processed by this worker, skipping job", "job_id", job.ID)
+		return nil
+	}
+	defer p.leadLocks.Unlock(leadID)
+
+	// Continue the trace and correlation ID of the webhook request that enqueued the job
+	if correlationID, ok := job.Payload["correlation_id"].(string); ok && correlationID != "" {
+		ctx = context.WithValue(ctx, logger.CorrelationIDKey, correlationID)
+	}
+	if traceparent, ok := job.Payload["traceparent"].(string); ok {
+		ctx = tracing.ContextWithTraceparent(ctx, traceparent)
+	}
+	ctx, span := startLeadSpan(ctx, "processLead", leadID)
+	defer span.End()
 	
 	logger.Info(ctx, "Processing lead")
 
 	// Load lead from database
 	lead, err := p.leadRepo.GetLeadByID(ctx, leadID)
+	if errors.Is(err, repository.ErrLeadNotFound) {
+		// Retrying cannot help once the lead is gone, e.g. after it was deleted
+		logger.Warn(ctx, "Lead no longer exists, dropping job")
+		return nil
+	}
 	if err != nil {
 		logger.LogError(ctx, "Failed to load lead", err)
 		return fmt.Errorf("failed to load lead %d: %w", leadID, err)
 	}
 
+	// Add source_id and product to context for logging
+	if lead.SourceID != nil {
+		ctx = context.WithValue(ctx, logger.SourceIDKey, *lead.SourceID)
+	}
+	if product := lead.RawPayload.ProductName(); product != "" {
+		ctx = context.WithValue(ctx, logger.ProductKey, product)
+	}
+
 	logger.Info(ctx, "Loaded lead", "status", lead.Status)
 
-	// Execute validation stage
-	if err := p.executeValidationStage(ctx, lead); err != nil {
-		logger.LogError(ctx, "Validation stage failed", err)
-		return err
-	}
+	// A READY lead with a customer payload has already been validated and transformed,
+	// e.g. when delivery is re-triggered through the admin API, so it goes straight to delivery
+	if lead.Status != models.LeadStatusReady || len(lead.CustomerPayload) == 0 {
+		// Execute validation stage
+		if err := p.traceStage(ctx, "validation", leadID, func(ctx context.Context) error {
+			return p.executeValidationStage(ctx, lead)
+		}); err != nil {
+			logger.LogError(ctx, "Validation stage failed", err)
+			return err
+		}
 
-	// If lead was rejected, stop processing
-	if lead.Status == models.LeadStatusRejected {
-		logger.Info(ctx, "Lead was rejected, stopping processing")
-		logger.LogSlowOperation(ctx, "process_lead", time.Since(startTime))
-		return nil
-	}
+		// If lead was rejected, stop processing
+		if lead.Status == models.LeadStatusRejected {
+			logger.Info(ctx, "Lead was rejected, stopping processing")
+			p.notifyOutcome(ctx, lead)
+			logger.LogSlowOperation(ctx, "process_lead", time.Since(startTime))
+			return nil
+		}
 
-	// Execute transformation stage
-	if err := p.executeTransformationStage(ctx, lead); err != nil {
-		logger.LogError(ctx, "Transformation stage failed", err)
-		return err
-	}
+		// Execute transformation stage
+		if err := p.traceStage(ctx, "transformation", leadID, func(ctx context.Context) error {
+			return p.executeTransformationStage(ctx, lead)
+		}); err != nil {
+			logger.LogError(ctx, "Transformation stage failed", err)
+			return err
+		}
 
-	// If transformation failed (missing core fields), stop processing
-	if lead.Status == models.LeadStatusFailed || lead.Status == models.LeadStatusPermanentlyFailed {
-		logger.Info(ctx, "Lead transformation failed, stopping processing")
-		logger.LogSlowOperation(ctx, "process_lead", time.Since(startTime))
-		return nil
+		// If transformation failed (missing core fields), stop processing
+		if lead.Status == models.LeadStatusFailed || lead.Status == models.LeadStatusPermanentlyFailed {
+			logger.Info(ctx, "Lead transformation failed, stopping processing")
+			p.notifyOutcome(ctx, lead)
+			logger.LogSlowOperation(ctx, "process_lead", time.Since(startTime))
+			return nil
+		}
 	}
 
 	// Execute delivery stage
-	if err := p.executeDeliveryStage(ctx, lead); err != nil {
+	if err := p.traceStage(ctx, "delivery", leadID, func(ctx context.Context) error {
+		return p.executeDeliveryStage(ctx, lead)
+	}); err != nil {
 		logger.LogError(ctx, "Delivery stage failed", err)
 		return err
 	}
 
+	p.notifyOutcome(ctx, lead)
+
 	logger.Info(ctx, "Lead processed successfully", "final_status", lead.Status)
 	logger.LogSlowOperation(ctx, "process_lead", time.Since(startTime))
 	return nil
 }
 
+// notifyOutcome schedules the sender notification once the lead has reached a final outcome
+func (p *Processor) notifyOutcome(ctx context.Context, lead *models.InboundLead) {
+	if p.notifier == nil || !lead.Status.IsTerminal() {
+		return
+	}
+	if err := p.notifier.Schedule(ctx, lead); err != nil {
+		// The lead itself was processed, so a notification failure must not fail the job
+		logger.LogError(ctx, "Failed to schedule sender notification", err)
+	}
+}
+
+// traceStage runs a pipeline stage within its own span
+func (p *Processor) traceStage(ctx context.Context, name string, leadID int64, stage func(ctx context.Context) error) error {
+	ctx, span := startLeadSpan(ctx, name, leadID)
+	defer span.End()
+
+	err := stage(ctx)
+	span.RecordError(err)
+	return err
+}
+
+// startLeadSpan starts a span carrying the lead ID and the correlation ID from ctx
+func startLeadSpan(ctx context.Context, name string, leadID int64) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(ctx, name)
+	span.SetAttribute(tracing.AttributeLeadID, leadID)
+	if correlationID, ok := ctx.Value(logger.CorrelationIDKey).(string); ok {
+		span.SetAttribute(tracing.AttributeCorrelationID, correlationID)
+	}
+	return ctx, span
+}
+
 // executeValidationStage executes the validation stage for a lead
 // Requirements: 2.3, 2.4, 2.5, 6.2
 func (p *Processor) executeValidationStage(ctx context.Context, lead *models.InboundLead) error {
 	logger.Info(ctx, "Executing validation stage")
 
-	// Call validation service
-	result := p.validator.ValidateLead(lead.RawPayload)
+	// Call validation service on the raw payload with keys normalized, zip codes canonicalized
+	// and value aliases applied, so that e.g. "isOwner" or an aliased "yes" satisfies the
+	// homeowner rule and "DE-66123" the zip code rule
+	payload := lead.RawPayload
+	if p.normalizer != nil {
+		payload = p.normalizer.NormalizeKeys(payload)
+		payload = p.normalizer.ApplyValueAliases(p.normalizer.NormalizeZipFields(payload))
+	}
+	result := p.validator.ValidateLead(payload)
+	if result.Valid && p.suppressionListEnabled {
+		suppressed, err := p.checkSuppressionList(ctx, payload)
+		if err != nil {
+			return err
+		}
+		if suppressed != nil {
+			result = suppressed
+		}
+	}
 
 	if !result.Valid {
 		// Mark lead as REJECTED on validation failure
 		// Store rejection reason
 		if result.RejectionReason != nil {
-			logger.Info(ctx, "Lead validation failed", "rejection_reason", *result.RejectionReason)
+			logger.Info(ctx, "Lead validation failed", "rejection_reason", *result.RejectionReason, "context", result.Context)
 			if err := p.leadRepo.UpdateLeadRejection(ctx, lead.ID, *result.RejectionReason); err != nil {
 				return fmt.Errorf("failed to update lead rejection: %w", err)
 			}
@@ -253,12 +660,27 @@ func (p *Processor) executeValidationStage(ctx context.Context, lead *models.Inb
 			reasonStr := result.RejectionReason.String()
 			lead.RejectionReason = &reasonStr
 			logger.LogStatusTransition(ctx, lead.ID, string(oldStatus), string(lead.Status))
+			if p.rejectionNotifier != nil {
+				// The lead is rejected either way, so a notification failure must not fail the job
+				if err := p.rejectionNotifier.Notify(ctx, lead, *result.RejectionReason); err != nil {
+					logger.LogError(ctx, "Failed to send rejection notification", err)
+				}
+			}
 		} else {
 			return fmt.Errorf("validation failed but no rejection reason provided")
 		}
 		return nil
 	}
 
+	// Warn-level rule failures don't reject the lead; they are delivered as flags
+	lead.ValidationFlags = result.Flags
+	if lead.SourceQuotaExceeded() {
+		lead.ValidationFlags = append(lead.ValidationFlags, services.FlagSourceQuotaExceeded)
+	}
+	if len(result.Flags) > 0 {
+		logger.Warn(ctx, "Lead passed validation with flags", "flags", result.Flags)
+	}
+
 	// Mark lead as READY on validation success
 	logger.Info(ctx, "Lead validation passed, marking as READY")
 	if err := p.leadRepo.UpdateLeadStatus(ctx, lead.ID, models.LeadStatusReady); err != nil {
@@ -271,21 +693,71 @@ func (p *Processor) executeValidationStage(ctx context.Context, lead *models.Inb
 	return nil
 }
 
+// checkSuppressionList returns a rejecting validation result if the normalized phone or
+// email of the payload is in the suppression list, or nil otherwise
+func (p *Processor) checkSuppressionList(ctx context.Context, payload models.JSONB) (*services.ValidationResult, error) {
+	phone, _ := payload["phone"].(string)
+	email, _ := payload["email"].(string)
+	if p.normalizer != nil {
+		phone = p.normalizer.NormalizePhone(phone)
+		email = p.normalizer.NormalizeEmail(email)
+	}
+
+	entry, err := p.leadRepo.FindInSuppressionList(ctx, phone, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check suppression list: %w", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	reason := models.RejectionReasonSuppressed
+	if entry.Reason != nil && *entry.Reason != "" {
+		reason = models.RejectionReason(*entry.Reason)
+	}
+	return &services.ValidationResult{
+		Valid:           false,
+		RejectionReason: &reason,
+		Errors:          []string{"contact is in the suppression list"},
+		Context:         map[string]string{"suppression_id": fmt.Sprint(entry.ID)},
+	}, nil
+}
+
 // executeTransformationStage executes the transformation stage for a lead
 // Requirements: 3.4, 3.5, 3.6, 3.7, 6.3, 9.3, 9.4
 func (p *Processor) executeTransformationStage(ctx context.Context, lead *models.InboundLead) error {
-	logger.Info(ctx, "Executing transformation stage")
+	p.logObfuscator.Info(ctx, "Executing transformation stage")
+
+	// Call normalization service, collecting the changed fields if auditing is enabled
+	var normalizedPayload models.JSONB
+	var audits []models.NormalizationAudit
+	if p.normalizationAuditRepo != nil {
+		normalizedPayload, audits = p.normalizer.NormalizeLeadWithAudit(lead.RawPayload)
+	} else {
+		normalizedPayload = p.normalizer.NormalizeLeadWithFieldMapping(lead.RawPayload)
+	}
+	p.logObfuscator.Info(ctx, "Lead normalized successfully")
 
-	// Call normalization service
-	normalizedPayload := p.normalizer.NormalizeLeadWithFieldMapping(lead.RawPayload)
-	logger.Info(ctx, "Lead normalized successfully")
+	// Derive additional fields before mapping
+	normalizedPayload, err := p.enricher.Enrich(ctx, normalizedPayload)
+	if err != nil {
+		return fmt.Errorf("failed to enrich lead: %w", err)
+	}
 
-	// Call mapping service
-	mappingResult := p.mapper.MapToCustomerFormat(normalizedPayload)
+	// Call mapping service with the attribute rules of the lead's source or product
+	sourceID := ""
+	if lead.SourceID != nil {
+		sourceID = *lead.SourceID
+	}
+	profile := p.mapper.ProfileFor(sourceID, normalizedPayload)
+	if profile != "" {
+		p.logObfuscator.Info(ctx, "Selected mapping profile", "profile", profile)
+	}
+	mappingResult := p.mapper.MapToCustomerFormatWithProfile(normalizedPayload, profile)
 
 	if !mappingResult.Success {
 		// Mark lead as FAILED if core fields missing
-		logger.Info(ctx, "Lead mapping failed", "errors", mappingResult.Errors)
+		p.logObfuscator.Info(ctx, "Lead mapping failed", "errors", mappingResult.Errors)
 		if err := p.leadRepo.UpdateLeadStatus(ctx, lead.ID, models.LeadStatusPermanentlyFailed); err != nil {
 			return fmt.Errorf("failed to update lead status to PERMANENTLY_FAILED: %w", err)
 		}
@@ -295,9 +767,33 @@ func (p *Processor) executeTransformationStage(ctx context.Context, lead *models
 		return nil
 	}
 
+	// The webhook handler stores only the metadata of attachments. Attachments still in the
+	// raw payload, e.g. of leads received before, are summarized here and removed from it.
+	// Like every field, the metadata reaches the Customer API only if it is whitelisted.
+	for key, metadata := range lead.AttachmentsMetadata {
+		if _, ok := mappingResult.Attachments[key]; !ok {
+			mappingResult.Attachments[key] = metadata
+			if p.mapper.AllowsField(key) {
+				mappingResult.CustomerPayload[key] = metadata
+			}
+		}
+	}
+	// Only the attachment metadata is stored, never the attachment bytes
+	for _, key := range mappingResult.AttachmentKeys {
+		delete(normalizedPayload, key)
+	}
+
+	// Annotate the customer payload with soft validation failures
+	if len(lead.ValidationFlags) > 0 && p.mapper.AllowsField(customerPayloadFlagsField) {
+		mappingResult.CustomerPayload[customerPayloadFlagsField] = lead.ValidationFlags
+	}
+
+	// Convert the keys last, so the stored payload is exactly what is delivered
+	mappingResult.CustomerPayload = p.keyCase.Apply(mappingResult.CustomerPayload)
+
 	// Continue if optional attributes invalid (permissive)
 	if len(mappingResult.OmittedAttributes) > 0 {
-		logger.Info(ctx, "Invalid optional attributes omitted",
+		p.logObfuscator.Info(ctx, "Invalid optional attributes omitted",
 			"count", len(mappingResult.OmittedAttributes),
 			"attributes", mappingResult.OmittedAttributes)
 	}
@@ -309,7 +805,25 @@ func (p *Processor) executeTransformationStage(ctx context.Context, lead *models
 	lead.NormalizedPayload = normalizedPayload
 	lead.CustomerPayload = mappingResult.CustomerPayload
 
-	logger.Info(ctx, "Lead transformation completed successfully")
+	// Audit the normalization once its payload is stored; the audit of an earlier run
+	// of the stage is replaced
+	if p.normalizationAuditRepo != nil {
+		if err := p.normalizationAuditRepo.CreateNormalizationAudits(ctx, lead.ID, audits); err != nil {
+			return fmt.Errorf("failed to record normalization audit: %w", err)
+		}
+	}
+
+	if len(mappingResult.AttachmentKeys) > 0 {
+		if err := p.leadRepo.UpdateLeadAttachments(ctx, lead.ID, mappingResult.Attachments, mappingResult.AttachmentKeys); err != nil {
+			return fmt.Errorf("failed to update lead attachments: %w", err)
+		}
+		lead.AttachmentsMetadata = mappingResult.Attachments
+		for _, key := range mappingResult.AttachmentKeys {
+			delete(lead.RawPayload, key)
+		}
+	}
+
+	p.logObfuscator.Info(ctx, "Lead transformation completed successfully")
 	return nil
 }
 
@@ -318,6 +832,17 @@ func (p *Processor) executeTransformationStage(ctx context.Context, lead *models
 func (p *Processor) executeDeliveryStage(ctx context.Context, lead *models.InboundLead) error {
 	logger.Info(ctx, "Executing delivery stage")
 
+	// Hold the lead until the next delivery window if the schedule does not allow delivery now
+	if now := p.now(); !p.deliveryWindow.Allows(now) {
+		if nextAttemptAt, ok := p.deliveryWindow.Next(now); ok {
+			logger.Info(ctx, "Outside delivery schedule, deferring delivery", "next_attempt_at", nextAttemptAt)
+			deliveryErr := models.NewDeliveryError(0, "outside delivery schedule", true, nil)
+			deliveryErr.NextAttemptAt = &nextAttemptAt
+			return deliveryErr
+		}
+		logger.Warn(ctx, "Delivery schedule allows no delivery window, ignoring schedule")
+	}
+
 	// Get the current attempt count
 	attemptCount, err := p.deliveryAttemptRepo.CountDeliveryAttempts(ctx, lead.ID)
 	if err != nil {
@@ -341,73 +866,100 @@ func (p *Processor) executeDeliveryStage(ctx context.Context, lead *models.Inbou
 	// Calculate the next attempt number (1-indexed)
 	nextAttemptNo := attemptCount + 1
 
-	// If this is a retry (not the first attempt), apply exponential backoff
-	if attemptCount > 0 {
-		// Get the delay for this retry (attemptCount is 0-indexed for delays)
-		delayIndex := attemptCount - 1
-		if delayIndex < len(p.exponentialBackoffDelays) {
-			delay := p.exponentialBackoffDelays[delayIndex]
-			logger.Info(ctx, "Applying exponential backoff delay",
-				"attempt_no", nextAttemptNo,
-				"delay", delay)
-			
-			select {
-			case <-time.After(delay):
-				// Delay completed
-			case <-ctx.Done():
-				return ctx.Err()
-			}
-		}
-	}
-
 	logger.Info(ctx, "Attempting delivery",
 		"attempt_no", nextAttemptNo,
 		"max_attempts", p.maxDeliveryAttempts)
 
+	// Switch to the fallback endpoint once the primary failed often enough
+	sender, endpoint := p.deliverySender(attemptCount)
+	if endpoint == models.DeliveryEndpointFallback {
+		logger.Warn(ctx, "Primary Customer API unavailable, delivering to fallback endpoint",
+			"failed_attempts", attemptCount)
+	}
+
+	// Wait for a free Customer API connection, holding it only for the request itself
+	if err := p.deliveryPool.Acquire(ctx); err != nil {
+		return err
+	}
+
 	// Attempt delivery to Customer API
-	response, deliveryErr := p.customerAPIClient.SendLead(ctx, lead.CustomerPayload)
+	response, deliveryErr := sender.SendLead(ctx, lead.CustomerPayload, lead.ForwardedHeaders(p.forwardHeaders))
+	p.deliveryPool.Release()
 
 	// Create delivery attempt record
 	attempt := models.NewDeliveryAttempt(lead.ID, nextAttemptNo)
-
-	// Start a transaction to atomically update lead status and create delivery attempt
-	tx, err := p.leadRepo.BeginTx(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+	attempt.EndpointUsed = endpoint
+	if response != nil && response.BodyHash != "" {
+		attempt.ResponseHash = &response.BodyHash
+	}
+	if response != nil && response.Truncated {
+		attempt.Truncated = true
+		attempt.TruncatedAtBytes = &response.TruncatedAt
 	}
-	defer tx.Rollback()
 
-	// Handle the delivery response
-	if deliveryErr != nil {
-		// Check if the error is a DeliveryError with retriability information
-		if delErr, ok := deliveryErr.(*models.DeliveryError); ok {
-			logger.Info(ctx, "Delivery attempt failed",
-				"attempt_no", nextAttemptNo,
-				"error", delErr.Message,
-				"retriable", delErr.Retriable,
-				"status_code", delErr.StatusCode)
-
-			// Record the failure in the delivery attempt
-			statusCodePtr := delErr.StatusCode
-			if statusCodePtr == 0 {
-				attempt.MarkFailure(nil, delErr.Message)
-			} else {
-				attempt.MarkFailure(&statusCodePtr, delErr.Message)
-			}
+	// Set when the Customer API accepted the lead for asynchronous confirmation
+	awaitingConfirmation := false
 
-			if !delErr.Retriable {
-				// Non-retriable error (4xx except 429) - mark as PERMANENTLY_FAILED
-				logger.Info(ctx, "Non-retriable error encountered, marking as PERMANENTLY_FAILED")
-				if err := p.leadRepo.UpdateLeadStatusTx(ctx, tx, lead.ID, models.LeadStatusPermanentlyFailed); err != nil {
-					return fmt.Errorf("failed to update lead status to PERMANENTLY_FAILED: %w", err)
+	// Atomically update the lead status and create the delivery attempt
+	err = repository.WithTx(ctx, p.leadRepo, func(tx *sql.Tx) error {
+		// Handle the delivery response
+		if deliveryErr != nil {
+			// Check if the error is a DeliveryError with retriability information
+			if delErr, ok := deliveryErr.(*models.DeliveryError); ok {
+				logger.Info(ctx, "Delivery attempt failed",
+					"attempt_no", nextAttemptNo,
+					"error", delErr.Message,
+					"retriable", delErr.Retriable,
+					"status_code", delErr.StatusCode,
+					"customer_error_code", delErr.CustomerErrorCode)
+
+				// Record the failure in the delivery attempt
+				statusCodePtr := delErr.StatusCode
+				if statusCodePtr == 0 {
+					attempt.MarkFailure(nil, delErr.Message)
+				} else {
+					attempt.MarkFailure(&statusCodePtr, delErr.Message)
+				}
+
+				if !delErr.Retriable {
+					// Non-retriable error (4xx except 429) - mark as PERMANENTLY_FAILED
+					logger.Info(ctx, "Non-retriable error encountered, marking as PERMANENTLY_FAILED")
+					if err := p.leadRepo.UpdateLeadStatusTx(ctx, tx, lead.ID, models.LeadStatusPermanentlyFailed); err != nil {
+						return fmt.Errorf("failed to update lead status to PERMANENTLY_FAILED: %w", err)
+					}
+					oldStatus := lead.Status
+					lead.Status = models.LeadStatusPermanentlyFailed
+					logger.LogStatusTransition(ctx, lead.ID, string(oldStatus), string(lead.Status))
+				} else {
+					// Retriable error (5xx, network error, 429)
+					if nextAttemptNo >= p.maxDeliveryAttempts {
+						// Max retries exhausted
+						logger.Info(ctx, "Max retries exhausted, marking as PERMANENTLY_FAILED")
+						if err := p.leadRepo.UpdateLeadStatusTx(ctx, tx, lead.ID, models.LeadStatusPermanentlyFailed); err != nil {
+							return fmt.Errorf("failed to update lead status to PERMANENTLY_FAILED: %w", err)
+						}
+						oldStatus := lead.Status
+						lead.Status = models.LeadStatusPermanentlyFailed
+						logger.LogStatusTransition(ctx, lead.ID, string(oldStatus), string(lead.Status))
+					} else {
+						// Mark as FAILED for retry
+						logger.Info(ctx, "Marking as FAILED for retry")
+						if err := p.leadRepo.UpdateLeadStatusTx(ctx, tx, lead.ID, models.LeadStatusFailed); err != nil {
+							return fmt.Errorf("failed to update lead status to FAILED: %w", err)
+						}
+						oldStatus := lead.Status
+						lead.Status = models.LeadStatusFailed
+						logger.LogStatusTransition(ctx, lead.ID, string(oldStatus), string(lead.Status))
+					}
 				}
-				oldStatus := lead.Status
-				lead.Status = models.LeadStatusPermanentlyFailed
-				logger.LogStatusTransition(ctx, lead.ID, string(oldStatus), string(lead.Status))
 			} else {
-				// Retriable error (5xx, network error, 429)
+				// Unknown error type - treat as retriable
+				logger.LogError(ctx, "Delivery attempt failed with unknown error", deliveryErr,
+					"attempt_no", nextAttemptNo)
+				errorMsg := deliveryErr.Error()
+				attempt.MarkFailure(nil, errorMsg)
+
 				if nextAttemptNo >= p.maxDeliveryAttempts {
-					// Max retries exhausted
 					logger.Info(ctx, "Max retries exhausted, marking as PERMANENTLY_FAILED")
 					if err := p.leadRepo.UpdateLeadStatusTx(ctx, tx, lead.ID, models.LeadStatusPermanentlyFailed); err != nil {
 						return fmt.Errorf("failed to update lead status to PERMANENTLY_FAILED: %w", err)
@@ -416,7 +968,6 @@ func (p *Processor) executeDeliveryStage(ctx context.Context, lead *models.Inbou
 					lead.Status = models.LeadStatusPermanentlyFailed
 					logger.LogStatusTransition(ctx, lead.ID, string(oldStatus), string(lead.Status))
 				} else {
-					// Mark as FAILED for retry
 					logger.Info(ctx, "Marking as FAILED for retry")
 					if err := p.leadRepo.UpdateLeadStatusTx(ctx, tx, lead.ID, models.LeadStatusFailed); err != nil {
 						return fmt.Errorf("failed to update lead status to FAILED: %w", err)
@@ -426,14 +977,65 @@ func (p *Processor) executeDeliveryStage(ctx context.Context, lead *models.Inbou
 					logger.LogStatusTransition(ctx, lead.ID, string(oldStatus), string(lead.Status))
 				}
 			}
+		} else if response != nil && response.Success && response.Duplicate {
+			// The customer already has this lead - terminal, but distinct from a fresh delivery
+			logger.Info(ctx, "Lead already known to Customer API, marking as DELIVERED_DUPLICATE",
+				"status_code", response.StatusCode)
+			attempt.MarkSuccess(response.StatusCode, response.Body)
+
+			if err := p.leadRepo.UpdateLeadStatusTx(ctx, tx, lead.ID, models.LeadStatusDeliveredDuplicate); err != nil {
+				return fmt.Errorf("failed to update lead status to DELIVERED_DUPLICATE: %w", err)
+			}
+			oldStatus := lead.Status
+			lead.Status = models.LeadStatusDeliveredDuplicate
+			logger.LogStatusTransition(ctx, lead.ID, string(oldStatus), string(lead.Status))
+		} else if response != nil && response.Success && p.asyncDeliveryMode && response.StatusCode == http.StatusAccepted {
+			// Accepted for asynchronous processing - delivery is confirmed later via callback
+			logger.Info(ctx, "Lead accepted by Customer API, awaiting confirmation",
+				"status_code", response.StatusCode,
+				"confirmation_timeout", p.confirmationTimeout)
+			attempt.MarkSuccess(response.StatusCode, response.Body)
+
+			// Mark lead as PENDING_CONFIRMATION
+			if err := p.leadRepo.UpdateLeadStatusTx(ctx, tx, lead.ID, models.LeadStatusPendingConfirmation); err != nil {
+				return fmt.Errorf("failed to update lead status to PENDING_CONFIRMATION: %w", err)
+			}
+			oldStatus := lead.Status
+			lead.Status = models.LeadStatusPendingConfirmation
+			logger.LogStatusTransition(ctx, lead.ID, string(oldStatus), string(lead.Status))
+			awaitingConfirmation = true
+		} else if response != nil && response.Success {
+			// Successful delivery (2xx response)
+			logger.Info(ctx, "Lead delivered successfully",
+				"status_code", response.StatusCode)
+			attempt.MarkSuccess(response.StatusCode, response.Body)
+
+			// Mark lead as DELIVERED
+			if err := p.leadRepo.UpdateLeadStatusTx(ctx, tx, lead.ID, models.LeadStatusDelivered); err != nil {
+				return fmt.Errorf("failed to update lead status to DELIVERED: %w", err)
+			}
+			oldStatus := lead.Status
+			lead.Status = models.LeadStatusDelivered
+			logger.LogStatusTransition(ctx, lead.ID, string(oldStatus), string(lead.Status))
 		} else {
-			// Unknown error type - treat as retriable
-			logger.LogError(ctx, "Delivery attempt failed with unknown error", deliveryErr,
-				"attempt_no", nextAttemptNo)
-			errorMsg := deliveryErr.Error()
-			attempt.MarkFailure(nil, errorMsg)
+			// Unexpected case - the response reports neither success nor an error
+			errorMsg, statusCode := describeUnexpectedResponse(response)
+			retriable := p.unexpectedResponseOutcome != config.StatusOutcomePermanentFailure
+			logger.Warn(ctx, "Delivery attempt returned unexpected response",
+				"attempt_no", nextAttemptNo,
+				"error", errorMsg,
+				"retriable", retriable)
+			attempt.MarkFailure(statusCode, errorMsg)
 
-			if nextAttemptNo >= p.maxDeliveryAttempts {
+			if !retriable {
+				logger.Info(ctx, "Unexpected response is configured as permanent, marking as PERMANENTLY_FAILED")
+				if err := p.leadRepo.UpdateLeadStatusTx(ctx, tx, lead.ID, models.LeadStatusPermanentlyFailed); err != nil {
+					return fmt.Errorf("failed to update lead status to PERMANENTLY_FAILED: %w", err)
+				}
+				oldStatus := lead.Status
+				lead.Status = models.LeadStatusPermanentlyFailed
+				logger.LogStatusTransition(ctx, lead.ID, string(oldStatus), string(lead.Status))
+			} else if nextAttemptNo >= p.maxDeliveryAttempts {
 				logger.Info(ctx, "Max retries exhausted, marking as PERMANENTLY_FAILED")
 				if err := p.leadRepo.UpdateLeadStatusTx(ctx, tx, lead.ID, models.LeadStatusPermanentlyFailed); err != nil {
 					return fmt.Errorf("failed to update lead status to PERMANENTLY_FAILED: %w", err)
@@ -451,56 +1053,235 @@ func (p *Processor) executeDeliveryStage(ctx context.Context, lead *models.Inbou
 				logger.LogStatusTransition(ctx, lead.ID, string(oldStatus), string(lead.Status))
 			}
 		}
-	} else if response != nil && response.Success {
-		// Successful delivery (2xx response)
-		logger.Info(ctx, "Lead delivered successfully",
-			"status_code", response.StatusCode)
-		attempt.MarkSuccess(response.StatusCode, response.Body)
 
-		// Mark lead as DELIVERED
-		if err := p.leadRepo.UpdateLeadStatusTx(ctx, tx, lead.ID, models.LeadStatusDelivered); err != nil {
-			return fmt.Errorf("failed to update lead status to DELIVERED: %w", err)
+		// Create the delivery attempt record within the transaction
+		if err := p.deliveryAttemptRepo.CreateDeliveryAttemptTx(ctx, tx, attempt); err != nil {
+			return fmt.Errorf("failed to create delivery attempt: %w", err)
 		}
-		oldStatus := lead.Status
-		lead.Status = models.LeadStatusDelivered
-		logger.LogStatusTransition(ctx, lead.ID, string(oldStatus), string(lead.Status))
-	} else {
-		// Unexpected case - response is not nil but not successful
-		logger.Warn(ctx, "Delivery attempt returned unexpected response",
-			"attempt_no", nextAttemptNo,
-			"response", response)
-		errorMsg := fmt.Sprintf("unexpected response: %v", response)
-		attempt.MarkFailure(nil, errorMsg)
-
-		if nextAttemptNo >= p.maxDeliveryAttempts {
-			logger.Info(ctx, "Max retries exhausted, marking as PERMANENTLY_FAILED")
-			if err := p.leadRepo.UpdateLeadStatusTx(ctx, tx, lead.ID, models.LeadStatusPermanentlyFailed); err != nil {
-				return fmt.Errorf("failed to update lead status to PERMANENTLY_FAILED: %w", err)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Drop the oldest attempt rows beyond the retention limit; attempt numbering continues
+	// from the highest attempt number, so pruning never affects retries
+	if p.attemptRetention > 0 && nextAttemptNo > p.attemptRetention {
+		if deleted, err := p.deliveryAttemptRepo.PruneDeliveryAttempts(ctx, lead.ID, p.attemptRetention); err != nil {
+			logger.Warn(ctx, "Failed to prune delivery attempts", "error", err.Error())
+		} else if deleted > 0 {
+			logger.Debug(ctx, "Pruned delivery attempts", "deleted", deleted, "retention", p.attemptRetention)
+		}
+	}
+
+	// Forward to the secondary endpoints; only the primary outcome above drives retries
+	p.forwardToChain(ctx, lead, nextAttemptNo)
+
+	// Re-enqueue failed leads behind fresh ones
+	if lead.Status == models.LeadStatusFailed {
+		if err := p.requeueFailedLead(ctx, lead.ID, nextAttemptNo); err != nil {
+			return err
+		}
+	}
+
+	// Schedule the confirmation timeout so unconfirmed leads do not stay pending forever
+	if awaitingConfirmation {
+		payload := queue.NewJobPayload(lead.ID)
+		if err := p.queue.EnqueueWithDelay(ctx, queue.JobTypeConfirmationTimeout, payload, p.confirmationTimeout); err != nil {
+			return fmt.Errorf("failed to schedule confirmation timeout: %w", err)
+		}
+	}
+
+	logger.Info(ctx, "Delivery stage completed", "final_status", lead.Status)
+	return nil
+}
+
+// forwardToChain sends the lead to each secondary endpoint of the forwarding chain in order.
+// Failures are recorded and logged but never affect the lead. An endpoint that already
+// accepted the lead is skipped, so primary retries do not forward it twice.
+func (p *Processor) forwardToChain(ctx context.Context, lead *models.InboundLead, attemptNo int) {
+	for i, sender := range p.forwardingChain {
+		chainIndex := i + 1 // index 0 is the primary endpoint
+
+		if p.chainAttemptRepo != nil {
+			delivered, err := p.chainAttemptRepo.HasSuccessfulChainAttempt(ctx, lead.ID, chainIndex)
+			if err != nil {
+				// Forward anyway; a duplicate is preferable to a lost lead
+				logger.LogError(ctx, "Failed to check forwarding chain attempts", err, "chain_index", chainIndex)
+			} else if delivered {
+				continue
 			}
-			oldStatus := lead.Status
-			lead.Status = models.LeadStatusPermanentlyFailed
-			logger.LogStatusTransition(ctx, lead.ID, string(oldStatus), string(lead.Status))
+		}
+
+		attempt := models.NewDeliveryChainAttempt(lead.ID, chainIndex, attemptNo)
+		response, err := sender.SendLead(ctx, lead.CustomerPayload, lead.ForwardedHeaders(p.forwardHeaders))
+		switch {
+		case err != nil:
+			var statusCode *int
+			if delErr, ok := err.(*models.DeliveryError); ok && delErr.StatusCode != 0 {
+				statusCode = &delErr.StatusCode
+			}
+			attempt.MarkFailure(statusCode, err.Error())
+		case response != nil && response.Success:
+			attempt.MarkSuccess(response.StatusCode, response.Body)
+		default:
+			errorMsg, statusCode := describeUnexpectedResponse(response)
+			attempt.MarkFailure(statusCode, errorMsg)
+		}
+
+		if attempt.Success {
+			logger.Info(ctx, "Lead forwarded to chain endpoint",
+				"chain_index", chainIndex,
+				"status_code", *attempt.ResponseStatus)
 		} else {
-			logger.Info(ctx, "Marking as FAILED for retry")
-			if err := p.leadRepo.UpdateLeadStatusTx(ctx, tx, lead.ID, models.LeadStatusFailed); err != nil {
-				return fmt.Errorf("failed to update lead status to FAILED: %w", err)
+			logger.Warn(ctx, "Forwarding to chain endpoint failed",
+				"chain_index", chainIndex,
+				"error", *attempt.ErrorMessage)
+		}
+
+		if p.chainAttemptRepo != nil {
+			if err := p.chainAttemptRepo.CreateChainAttempt(ctx, attempt); err != nil {
+				logger.LogError(ctx, "Failed to record forwarding chain attempt", err, "chain_index", chainIndex)
 			}
-			oldStatus := lead.Status
-			lead.Status = models.LeadStatusFailed
-			logger.LogStatusTransition(ctx, lead.ID, string(oldStatus), string(lead.Status))
 		}
 	}
+}
 
-	// Create the delivery attempt record within the transaction
-	if err := p.deliveryAttemptRepo.CreateDeliveryAttemptTx(ctx, tx, attempt); err != nil {
-		return fmt.Errorf("failed to create delivery attempt: %w", err)
+// describeUnexpectedResponse builds the failure message for a delivery that returned
+// neither a successful response nor an error, with the observed status code if any
+func describeUnexpectedResponse(response *client.DeliveryResponse) (string, *int) {
+	if response == nil {
+		return "unexpected response: no response and no error from Customer API", nil
+	}
+	if response.StatusCode == 0 {
+		return "unexpected response: unsuccessful response without status code or error", nil
 	}
+	statusCode := response.StatusCode
+	return fmt.Sprintf("unexpected response: HTTP %d reported as unsuccessful without an error", statusCode), &statusCode
+}
 
-	// Commit the transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+// deliverySender returns the endpoint for the next delivery attempt of a lead with
+// failedAttempts previous attempts. Those attempts all failed with retriable errors, since
+// a non-retriable error fails the lead permanently, so the primary endpoint is considered
+// unavailable once their number reaches the fallback threshold.
+func (p *Processor) deliverySender(failedAttempts int) (LeadSender, string) {
+	if p.fallbackClient != nil && failedAttempts >= p.fallbackAfterAttempts {
+		return p.fallbackClient, models.DeliveryEndpointFallback
 	}
+	return p.customerAPIClient, models.DeliveryEndpointPrimary
+}
 
-	logger.Info(ctx, "Delivery stage completed", "final_status", lead.Status)
+// jobRetryKey is the context key of the jobRetry of the job being processed
+type jobRetryKey struct{}
+
+// jobRetry asks processNextJob to reschedule the current job with a delay and a new
+// priority instead of completing it
+type jobRetry struct {
+	requested bool
+	delay     time.Duration
+	priority  int
+}
+
+// requeueFailedLead re-enqueues a lead after a failed delivery attempt.
+// The job becomes due after the backoff delay of the attempt, so no worker holds it while waiting.
+// Each failed attempt deprioritizes the lead by the configured penalty so fresh leads are processed first.
+// Within a job, the job itself is rescheduled, as the queue holds one job per lead.
+func (p *Processor) requeueFailedLead(ctx context.Context, leadID int64, attemptNo int) error {
+	delay := p.retryDelay(attemptNo)
+	priority := p.retryPriority(attemptNo)
+	logger.Info(ctx, "Re-enqueueing failed lead", "attempt_no", attemptNo, "delay", delay, "priority", priority)
+
+	if retry, ok := ctx.Value(jobRetryKey{}).(*jobRetry); ok {
+		retry.requested = true
+		retry.delay = delay
+		retry.priority = priority
+		return nil
+	}
+
+	return p.enqueueFailedLead(ctx, leadID, delay, priority)
+}
+
+// enqueueFailedLead enqueues a new job for the next delivery attempt of a failed lead
+func (p *Processor) enqueueFailedLead(ctx context.Context, leadID int64, delay time.Duration, priority int) error {
+	payload := queue.NewJobPayload(leadID)
+	if err := p.queue.EnqueueWithPriority(ctx, queue.JobTypeProcessLead, payload, delay, priority); err != nil {
+		return fmt.Errorf("failed to re-enqueue failed lead: %w", err)
+	}
 	return nil
 }
+
+// retryDelay returns the exponential backoff delay before the next delivery attempt of a lead
+// that has failed attemptNo delivery attempts, or 0 beyond the configured delays
+func (p *Processor) retryDelay(attemptNo int) time.Duration {
+	if attemptNo < 1 || attemptNo > len(p.exponentialBackoffDelays) {
+		return 0
+	}
+	return p.exponentialBackoffDelays[attemptNo-1]
+}
+
+// retryPriority returns the queue priority for a lead that has failed attemptNo delivery attempts
+func (p *Processor) retryPriority(attemptNo int) int {
+	return attemptNo * p.priorityPenalty
+}
+
+// processConfirmationTimeout fails a lead if its asynchronous delivery has not been confirmed
+// by the time the confirmation timeout job runs. The lead is retried like a failed delivery,
+// or marked as PERMANENTLY_FAILED once its delivery attempts are exhausted.
+func (p *Processor) processConfirmationTimeout(ctx context.Context, job *queue.Job) error {
+	leadID, ok := queue.GetLeadID(job.Payload)
+	if !ok {
+		return fmt.Errorf("invalid job payload: missing lead_id")
+	}
+
+	ctx = context.WithValue(ctx, logger.LeadIDKey, leadID)
+
+	lead, err := p.leadRepo.GetLeadByID(ctx, leadID)
+	if errors.Is(err, repository.ErrLeadNotFound) {
+		logger.Warn(ctx, "Lead no longer exists, ignoring confirmation timeout")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load lead %d: %w", leadID, err)
+	}
+
+	// Confirmation arrived in time - nothing to do
+	if lead.Status != models.LeadStatusPendingConfirmation {
+		logger.Info(ctx, "Lead no longer pending confirmation, ignoring timeout", "status", lead.Status)
+		return nil
+	}
+
+	attemptCount, err := p.deliveryAttemptRepo.CountDeliveryAttempts(ctx, lead.ID)
+	if err != nil {
+		return fmt.Errorf("failed to count delivery attempts: %w", err)
+	}
+
+	newStatus := models.LeadStatusFailed
+	if attemptCount >= p.maxDeliveryAttempts {
+		newStatus = models.LeadStatusPermanentlyFailed
+	}
+
+	logger.Warn(ctx, "Delivery confirmation timed out",
+		"confirmation_timeout", p.confirmationTimeout, "attempt_no", attemptCount, "status", newStatus)
+	// The confirmation callback may settle the lead between loading and updating it
+	err = p.leadRepo.UpdateLeadStatusFrom(ctx, lead.ID, models.LeadStatusPendingConfirmation, newStatus)
+	if errors.Is(err, models.ErrInvalidStatusTransition) {
+		logger.Info(ctx, "Lead confirmed concurrently, ignoring timeout")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update lead status to %s: %w", newStatus, err)
+	}
+	logger.LogStatusTransition(ctx, lead.ID, string(lead.Status), string(newStatus))
+	lead.Status = newStatus
+
+	if newStatus == models.LeadStatusPermanentlyFailed {
+		p.notifyOutcome(ctx, lead)
+		return nil
+	}
+
+	// A new job is enqueued since rescheduling would run this timeout job again
+	delay := p.retryDelay(attemptCount)
+	priority := p.retryPriority(attemptCount)
+	logger.Info(ctx, "Re-enqueueing failed lead", "attempt_no", attemptCount, "delay", delay, "priority", priority)
+	return p.enqueueFailedLead(ctx, lead.ID, delay, priority)
+}
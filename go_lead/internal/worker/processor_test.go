@@ -1,14 +1,22 @@
 package worker
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/checkfox/go_lead/internal/client"
 	"github.com/checkfox/go_lead/internal/config"
 	"github.com/checkfox/go_lead/internal/database"
+	"github.com/checkfox/go_lead/internal/encryption"
 	"github.com/checkfox/go_lead/internal/models"
 	"github.com/checkfox/go_lead/internal/queue"
 	"github.com/checkfox/go_lead/internal/repository"
@@ -50,6 +58,7 @@ func setupTestProcessor(t *testing.T) (*Processor, func()) {
 	// Initialize repositories
 	leadRepo := repository.NewLeadRepository(db)
 	deliveryAttemptRepo := repository.NewDeliveryAttemptRepository(db)
+	chainAttemptRepo := repository.NewDeliveryChainAttemptRepository(db)
 
 	// Initialize services
 	validator := services.NewValidator()
@@ -73,6 +82,7 @@ func setupTestProcessor(t *testing.T) (*Processor, func()) {
 		Mapper:              mapper,
 		CustomerAPIClient:   customerAPIClient,
 		PollInterval:        100 * time.Millisecond,
+		ChainAttemptRepo:    chainAttemptRepo,
 	})
 
 	cleanup := func() {
@@ -164,6 +174,60 @@ func TestProcessLead_SuccessfulProcessing(t *testing.T) {
 	}
 }
 
+// TestProcessLead_ReadyLeadSkipsToDelivery tests that a READY lead with a customer payload,
+// e.g. one re-triggered through the admin API, is delivered without being re-validated
+func TestProcessLead_ReadyLeadSkipsToDelivery(t *testing.T) {
+	processor, cleanup := setupTestProcessor(t)
+	if processor == nil {
+		return // Test was skipped
+	}
+	defer cleanup()
+
+	sender := acceptingSender()
+	processor.customerAPIClient = sender
+
+	ctx := context.Background()
+
+	// The raw payload would be rejected by validation, so delivery proves the stages were skipped
+	lead := &models.InboundLead{
+		RawPayload: models.JSONB{
+			"phone":   "1234567890",
+			"zipcode": "10115",
+		},
+		Status: models.LeadStatusReady,
+		CustomerPayload: models.JSONB{
+			"phone": "1234567890",
+			"product": map[string]interface{}{
+				"name": "test-product",
+			},
+		},
+	}
+
+	if err := processor.leadRepo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	job := &queue.Job{
+		ID:      1,
+		Type:    queue.JobTypeProcessLead,
+		Payload: queue.NewJobPayload(lead.ID),
+	}
+	if err := processor.processLead(ctx, job); err != nil {
+		t.Fatalf("Failed to process lead: %v", err)
+	}
+
+	updatedLead, err := processor.leadRepo.GetLeadByID(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to get updated lead: %v", err)
+	}
+	if updatedLead.Status != models.LeadStatusDelivered {
+		t.Errorf("Expected lead status to be DELIVERED, got %s", updatedLead.Status)
+	}
+	if sender.calls != 1 {
+		t.Errorf("Expected exactly one delivery, got %d", sender.calls)
+	}
+}
+
 // TestProcessLead_ValidationFailure tests the validation failure path
 // Requirements: 5.2, 5.5
 func TestProcessLead_ValidationFailure(t *testing.T) {
@@ -344,6 +408,85 @@ func TestProcessLead_StatusTransitions(t *testing.T) {
 	}
 }
 
+// failingLeadRepo fails every lead lookup with err
+type failingLeadRepo struct {
+	notifierLeadRepo
+	err error
+}
+
+func (r *failingLeadRepo) GetLeadByID(ctx context.Context, id int64) (*models.InboundLead, error) {
+	return nil, r.err
+}
+
+// TestProcessLead_MissingLead tests that a job for a lead that no longer exists is dropped,
+// while other load failures are returned so that the job is retried
+func TestProcessLead_MissingLead(t *testing.T) {
+	ctx := context.Background()
+	job := &queue.Job{ID: 1, Type: queue.JobTypeProcessLead, Payload: queue.NewJobPayload(42)}
+
+	processor := NewProcessor(ProcessorConfig{Queue: &recordingQueue{}, LeadRepo: &notifierLeadRepo{}})
+	if err := processor.processLead(ctx, job); err != nil {
+		t.Errorf("Expected the job of a missing lead to be dropped, got %v", err)
+	}
+
+	processor = NewProcessor(ProcessorConfig{Queue: &recordingQueue{}, LeadRepo: &failingLeadRepo{err: sql.ErrConnDone}})
+	if err := processor.processLead(ctx, job); !errors.Is(err, sql.ErrConnDone) {
+		t.Errorf("Expected the database error to be returned, got %v", err)
+	}
+}
+
+// recordingRejectionNotifier records the rejections the processor notifies
+type recordingRejectionNotifier struct {
+	leads   []*models.InboundLead
+	reasons []models.RejectionReason
+}
+
+func (n *recordingRejectionNotifier) Notify(ctx context.Context, lead *models.InboundLead, reason models.RejectionReason) error {
+	n.leads = append(n.leads, lead)
+	n.reasons = append(n.reasons, reason)
+	return errors.New("notification failed")
+}
+
+func TestExecuteValidationStage_RejectionNotification(t *testing.T) {
+	ctx := context.Background()
+	notifier := &recordingRejectionNotifier{}
+	processor := NewProcessor(ProcessorConfig{
+		Queue:             &recordingQueue{},
+		LeadRepo:          &notifierLeadRepo{},
+		Validator:         services.NewValidator(),
+		RejectionNotifier: notifier,
+	})
+
+	rejected := &models.InboundLead{
+		ID:         7,
+		RawPayload: models.JSONB{"zipcode": "10115", "house": map[string]interface{}{"is_owner": true}},
+		Status:     models.LeadStatusReceived,
+	}
+	// A failing notification does not fail the rejected lead's job
+	if err := processor.executeValidationStage(ctx, rejected); err != nil {
+		t.Fatalf("Validation stage failed: %v", err)
+	}
+
+	valid := &models.InboundLead{
+		ID:         8,
+		RawPayload: models.JSONB{"zipcode": "66123", "house": map[string]interface{}{"is_owner": true}},
+		Status:     models.LeadStatusReceived,
+	}
+	if err := processor.executeValidationStage(ctx, valid); err != nil {
+		t.Fatalf("Validation stage failed: %v", err)
+	}
+
+	if len(notifier.leads) != 1 {
+		t.Fatalf("Expected 1 rejection notification, got %d", len(notifier.leads))
+	}
+	if notifier.leads[0] != rejected || notifier.reasons[0] != models.RejectionReasonZipNotValid {
+		t.Errorf("Expected lead 7 rejected as ZIP_NOT_66XXX, got lead %d with %s", notifier.leads[0].ID, notifier.reasons[0])
+	}
+	if notifier.leads[0].Status != models.LeadStatusRejected {
+		t.Errorf("Expected the lead to be REJECTED when notified, got %s", notifier.leads[0].Status)
+	}
+}
+
 // TestExecuteValidationStage_ValidLead tests validation stage with valid lead
 func TestExecuteValidationStage_ValidLead(t *testing.T) {
 	processor, cleanup := setupTestProcessor(t)
@@ -494,6 +637,152 @@ func TestExecuteTransformationStage_Success(t *testing.T) {
 	}
 }
 
+// recordingAuditRepo keeps the latest normalization audits recorded per lead in memory
+type recordingAuditRepo struct {
+	audits map[int64][]models.NormalizationAudit
+}
+
+func (r *recordingAuditRepo) CreateNormalizationAudits(ctx context.Context, leadID int64, audits []models.NormalizationAudit) error {
+	r.audits[leadID] = append([]models.NormalizationAudit{}, audits...)
+	return nil
+}
+
+func (r *recordingAuditRepo) GetNormalizationAudit(ctx context.Context, leadID int64) ([]models.NormalizationAudit, error) {
+	return r.audits[leadID], nil
+}
+
+// TestExecuteTransformationStage_NormalizationAudit tests that each changed field is audited once
+func TestExecuteTransformationStage_NormalizationAudit(t *testing.T) {
+	processor, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	auditRepo := &recordingAuditRepo{audits: make(map[int64][]models.NormalizationAudit)}
+	processor.normalizationAuditRepo = auditRepo
+
+	ctx := context.Background()
+	lead := &models.InboundLead{
+		RawPayload: models.JSONB{
+			"email":   "TEST@EXAMPLE.COM",
+			"phone":   "123-456-7890",
+			"zipcode": "66123",
+		},
+		Status: models.LeadStatusReady,
+	}
+	if err := processor.leadRepo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	if err := processor.executeTransformationStage(ctx, lead); err != nil {
+		t.Fatalf("Transformation stage failed: %v", err)
+	}
+
+	audits := auditRepo.audits[lead.ID]
+	if len(audits) != 2 {
+		t.Fatalf("Expected one audit record per changed field, got %+v", audits)
+	}
+	if audits[0].Field != "email" || audits[0].NormalizedValue != "test@example.com" {
+		t.Errorf("Unexpected email audit %+v", audits[0])
+	}
+	if audits[1].Field != "phone" || audits[1].OriginalValue != "123-456-7890" || audits[1].NormalizedValue != "1234567890" {
+		t.Errorf("Unexpected phone audit %+v", audits[1])
+	}
+
+	// A retried transformation replaces the audit instead of adding to it
+	if err := processor.executeTransformationStage(ctx, lead); err != nil {
+		t.Fatalf("Repeated transformation stage failed: %v", err)
+	}
+	if audits := auditRepo.audits[lead.ID]; len(audits) != 2 {
+		t.Errorf("Expected the repeated transformation to keep 2 audit records, got %+v", audits)
+	}
+}
+
+// TestExecuteTransformationStage_NormalizationAuditSkippedOnMappingFailure tests that a lead
+// whose payloads are not stored has no normalization audit
+func TestExecuteTransformationStage_NormalizationAuditSkippedOnMappingFailure(t *testing.T) {
+	processor, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	auditRepo := &recordingAuditRepo{audits: make(map[int64][]models.NormalizationAudit)}
+	processor.normalizationAuditRepo = auditRepo
+
+	ctx := context.Background()
+	lead := &models.InboundLead{
+		RawPayload: models.JSONB{"email": "TEST@EXAMPLE.COM"},
+		Status:     models.LeadStatusReady,
+	}
+	if err := processor.leadRepo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	if err := processor.executeTransformationStage(ctx, lead); err != nil {
+		t.Fatalf("Transformation stage failed: %v", err)
+	}
+	if lead.Status != models.LeadStatusPermanentlyFailed {
+		t.Fatalf("Expected status to be PERMANENTLY_FAILED, got %s", lead.Status)
+	}
+	if audits := auditRepo.audits[lead.ID]; len(audits) != 0 {
+		t.Errorf("Expected no audit for a lead without stored payloads, got %+v", audits)
+	}
+}
+
+// payloadLeadRepo records the customer payload stored by the transformation stage
+type payloadLeadRepo struct {
+	notifierLeadRepo
+	customerPayload models.JSONB
+}
+
+func (m *payloadLeadRepo) UpdateLeadWithPayloads(ctx context.Context, id int64, normalizedPayload, customerPayload models.JSONB) error {
+	m.customerPayload = customerPayload
+	return nil
+}
+
+// TestExecuteTransformationStage_AllowedFieldsCoverFlagsAndAttachments tests that soft
+// validation flags and stored attachment metadata are stripped like any field outside
+// CUSTOMER_API_ALLOWED_FIELDS
+func TestExecuteTransformationStage_AllowedFieldsCoverFlagsAndAttachments(t *testing.T) {
+	lead := &models.InboundLead{
+		ID:                  7,
+		RawPayload:          models.JSONB{"phone": "1234567890", "zipcode": "66123"},
+		Status:              models.LeadStatusReady,
+		ValidationFlags:     []string{"soft_zip_mismatch"},
+		AttachmentsMetadata: models.JSONB{"roof_photo": map[string]interface{}{"size_bytes": 5, "mime_type": "image/png"}},
+	}
+
+	for _, tt := range []struct {
+		name    string
+		allowed []string
+		want    []string
+	}{
+		{"no whitelist", nil, []string{"_flags", "roof_photo"}},
+		{"flags and attachment not whitelisted", []string{"phone", "zipcode"}, nil},
+		{"flags and attachment whitelisted", []string{"phone", "_flags", "roof_photo"}, []string{"_flags", "roof_photo"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			leadRepo := &payloadLeadRepo{notifierLeadRepo: notifierLeadRepo{lead: lead}}
+			processor := NewProcessor(ProcessorConfig{
+				Queue:      &recordingQueue{},
+				LeadRepo:   leadRepo,
+				Normalizer: services.NewNormalizer(),
+				Mapper: services.NewMapper(&config.Config{
+					CustomerAPI: config.CustomerAPIConfig{ProductName: "solar", AllowedPayloadFields: tt.allowed},
+				}),
+			})
+
+			if err := processor.executeTransformationStage(context.Background(), lead); err != nil {
+				t.Fatalf("Transformation stage failed: %v", err)
+			}
+			for _, key := range []string{"_flags", "roof_photo"} {
+				_, delivered := leadRepo.customerPayload[key]
+				wanted := false
+				for _, want := range tt.want {
+					wanted = wanted || want == key
+				}
+				if delivered != wanted {
+					t.Errorf("Expected %s in the customer payload: %v, got %v", key, wanted, leadRepo.customerPayload)
+				}
+			}
+		})
+	}
+}
+
 // TestExecuteTransformationStage_MissingCoreField tests transformation with missing core field
 func TestExecuteTransformationStage_MissingCoreField(t *testing.T) {
 	processor, cleanup := setupTestProcessor(t)
@@ -564,6 +853,442 @@ func TestExecuteDeliveryStage_SuccessfulDelivery(t *testing.T) {
 	t.Skip("Skipping delivery test - requires mock Customer API server")
 }
 
+// TestExecuteDeliveryStage_DuplicateOn409 tests that a 409 from the Customer API
+// marks the lead as DELIVERED_DUPLICATE without scheduling a retry
+func TestExecuteDeliveryStage_DuplicateOn409(t *testing.T) {
+	processor, cleanup := setupTestProcessor(t)
+	if processor == nil {
+		return // Test was skipped
+	}
+	defer cleanup()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"message": "lead already exists"}`))
+	}))
+	defer server.Close()
+
+	processor.customerAPIClient = client.NewCustomerAPIClientFromConfig(config.CustomerAPIConfig{
+		URL:                  server.URL,
+		Token:                "token",
+		Timeout:              5 * time.Second,
+		DuplicateStatusCodes: []int{http.StatusConflict},
+	})
+
+	ctx := context.Background()
+
+	lead := &models.InboundLead{
+		RawPayload: models.JSONB{
+			"phone":   "1234567890",
+			"zipcode": "66123",
+		},
+		Status: models.LeadStatusReady,
+		CustomerPayload: models.JSONB{
+			"phone": "1234567890",
+			"product": map[string]interface{}{
+				"name": "test-product",
+			},
+		},
+	}
+
+	if err := processor.leadRepo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	if err := processor.executeDeliveryStage(ctx, lead); err != nil {
+		t.Fatalf("Delivery stage failed: %v", err)
+	}
+
+	stored, err := processor.leadRepo.GetLeadByID(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to load lead: %v", err)
+	}
+	if stored.Status != models.LeadStatusDeliveredDuplicate {
+		t.Errorf("Expected status DELIVERED_DUPLICATE, got %s", stored.Status)
+	}
+	if !stored.Status.IsTerminal() {
+		t.Error("Expected DELIVERED_DUPLICATE to be terminal")
+	}
+
+	attempts, err := processor.deliveryAttemptRepo.GetDeliveryAttemptsByLeadID(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to load delivery attempts: %v", err)
+	}
+	if len(attempts) != 1 || !attempts[0].Success {
+		t.Errorf("Expected exactly one successful delivery attempt, got %+v", attempts)
+	}
+}
+
+// unexpectedResponseSender returns an unsuccessful response without an error
+type unexpectedResponseSender struct {
+	statusCode int
+}
+
+func (s *unexpectedResponseSender) SendLead(ctx context.Context, payload map[string]interface{}, headers map[string]string) (*client.DeliveryResponse, error) {
+	return &client.DeliveryResponse{StatusCode: s.statusCode, Success: false}, nil
+}
+
+// TestExecuteDeliveryStage_UnexpectedResponse tests that a response without success
+// or error follows the configured outcome and is recorded with its status code
+func TestExecuteDeliveryStage_UnexpectedResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		outcome     string
+		maxAttempts int
+		wantStatus  models.LeadStatus
+	}{
+		{"default is retriable", "", 5, models.LeadStatusFailed},
+		{"configured retriable", config.StatusOutcomeRetriableFailure, 5, models.LeadStatusFailed},
+		{"configured permanent", config.StatusOutcomePermanentFailure, 5, models.LeadStatusPermanentlyFailed},
+		{"retriable bounded by max attempts", config.StatusOutcomeRetriableFailure, 1, models.LeadStatusPermanentlyFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor, cleanup := setupTestProcessor(t)
+			if processor == nil {
+				return // Test was skipped
+			}
+			defer cleanup()
+
+			processor.customerAPIClient = &unexpectedResponseSender{statusCode: http.StatusOK}
+			processor.unexpectedResponseOutcome = tt.outcome
+			processor.maxDeliveryAttempts = tt.maxAttempts
+
+			ctx := context.Background()
+
+			lead := &models.InboundLead{
+				RawPayload: models.JSONB{
+					"phone":   "1234567890",
+					"zipcode": "66123",
+				},
+				Status: models.LeadStatusReady,
+				CustomerPayload: models.JSONB{
+					"phone": "1234567890",
+					"product": map[string]interface{}{
+						"name": "test-product",
+					},
+				},
+			}
+
+			if err := processor.leadRepo.CreateLead(ctx, lead); err != nil {
+				t.Fatalf("Failed to create lead: %v", err)
+			}
+
+			if err := processor.executeDeliveryStage(ctx, lead); err != nil {
+				t.Fatalf("Delivery stage failed: %v", err)
+			}
+
+			stored, err := processor.leadRepo.GetLeadByID(ctx, lead.ID)
+			if err != nil {
+				t.Fatalf("Failed to load lead: %v", err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("Expected status %s, got %s", tt.wantStatus, stored.Status)
+			}
+
+			attempts, err := processor.deliveryAttemptRepo.GetDeliveryAttemptsByLeadID(ctx, lead.ID)
+			if err != nil {
+				t.Fatalf("Failed to load delivery attempts: %v", err)
+			}
+			if len(attempts) != 1 || attempts[0].Success {
+				t.Fatalf("Expected exactly one failed delivery attempt, got %+v", attempts)
+			}
+			if attempts[0].ResponseStatus == nil || *attempts[0].ResponseStatus != http.StatusOK {
+				t.Errorf("Expected observed status 200 to be recorded, got %v", attempts[0].ResponseStatus)
+			}
+			if attempts[0].ErrorMessage == nil || !strings.Contains(*attempts[0].ErrorMessage, "HTTP 200") {
+				t.Errorf("Expected error message to include the observed status, got %v", attempts[0].ErrorMessage)
+			}
+		})
+	}
+}
+
+// TestDescribeUnexpectedResponse tests the failure message for unexpected responses
+func TestDescribeUnexpectedResponse(t *testing.T) {
+	msg, code := describeUnexpectedResponse(&client.DeliveryResponse{StatusCode: http.StatusNoContent})
+	if code == nil || *code != http.StatusNoContent {
+		t.Errorf("Expected status code 204, got %v", code)
+	}
+	if !strings.Contains(msg, "HTTP 204") {
+		t.Errorf("Expected message to include the status, got %q", msg)
+	}
+
+	msg, code = describeUnexpectedResponse(nil)
+	if code != nil {
+		t.Errorf("Expected no status code for a nil response, got %d", *code)
+	}
+	if !strings.Contains(msg, "no response") {
+		t.Errorf("Expected message to describe the missing response, got %q", msg)
+	}
+
+	if _, code = describeUnexpectedResponse(&client.DeliveryResponse{}); code != nil {
+		t.Errorf("Expected no status code for a zero status, got %d", *code)
+	}
+}
+
+// TestExecuteDeliveryStage_ForwardingChain tests that secondary endpoints receive the lead
+// after the primary attempt and that only the primary outcome decides the lead status
+func TestExecuteDeliveryStage_ForwardingChain(t *testing.T) {
+	tests := []struct {
+		name             string
+		primary          LeadSender
+		secondaryFails   bool
+		wantStatus       models.LeadStatus
+		wantChainSuccess []bool
+	}{
+		{"all endpoints succeed", acceptingSender(), false, models.LeadStatusDelivered, []bool{true, true}},
+		{"primary fails", failingSender(http.StatusServiceUnavailable), false, models.LeadStatusFailed, []bool{true, true}},
+		{"secondary fails", acceptingSender(), true, models.LeadStatusDelivered, []bool{false, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor, cleanup := setupTestProcessor(t)
+			if processor == nil {
+				return // Test was skipped
+			}
+			defer cleanup()
+
+			first := acceptingSender()
+			if tt.secondaryFails {
+				first = failingSender(http.StatusBadGateway)
+			}
+			processor.customerAPIClient = tt.primary
+			processor.forwardingChain = []LeadSender{first, acceptingSender()}
+			processor.maxDeliveryAttempts = 5
+
+			ctx := context.Background()
+
+			lead := &models.InboundLead{
+				RawPayload: models.JSONB{
+					"phone":   "1234567890",
+					"zipcode": "66123",
+				},
+				Status: models.LeadStatusReady,
+				CustomerPayload: models.JSONB{
+					"phone": "1234567890",
+				},
+			}
+
+			if err := processor.leadRepo.CreateLead(ctx, lead); err != nil {
+				t.Fatalf("Failed to create lead: %v", err)
+			}
+
+			if err := processor.executeDeliveryStage(ctx, lead); err != nil {
+				t.Fatalf("Delivery stage failed: %v", err)
+			}
+
+			stored, err := processor.leadRepo.GetLeadByID(ctx, lead.ID)
+			if err != nil {
+				t.Fatalf("Failed to load lead: %v", err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("Expected status %s, got %s", tt.wantStatus, stored.Status)
+			}
+
+			attempts, err := processor.chainAttemptRepo.GetChainAttemptsByLeadID(ctx, lead.ID)
+			if err != nil {
+				t.Fatalf("Failed to load chain attempts: %v", err)
+			}
+			if len(attempts) != len(tt.wantChainSuccess) {
+				t.Fatalf("Expected %d chain attempts, got %d", len(tt.wantChainSuccess), len(attempts))
+			}
+			for i, attempt := range attempts {
+				if attempt.ChainIndex != i+1 || attempt.AttemptNo != 1 {
+					t.Errorf("Unexpected chain attempt %d: %+v", i, attempt)
+				}
+				if attempt.Success != tt.wantChainSuccess[i] {
+					t.Errorf("Expected chain attempt %d success=%v, got %v", i, tt.wantChainSuccess[i], attempt.Success)
+				}
+			}
+		})
+	}
+}
+
+// TestProcessLead_WarnZipMismatchDeliversWithFlag tests that a zip mismatch with
+// warn severity delivers the lead with a flag instead of rejecting it
+func TestProcessLead_WarnZipMismatchDeliversWithFlag(t *testing.T) {
+	processor, cleanup := setupTestProcessor(t)
+	if processor == nil {
+		return // Test was skipped
+	}
+	defer cleanup()
+
+	var delivered map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&delivered)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	processor.customerAPIClient = client.NewCustomerAPIClient(server.URL, "token", 5*time.Second)
+	validator, err := services.NewValidatorFromConfig(config.ValidationConfig{
+		RuleSeverities: map[string]string{config.ValidationRuleZipcode: config.ValidationSeverityWarn},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	processor.validator = validator
+
+	ctx := context.Background()
+
+	lead := &models.InboundLead{
+		RawPayload: models.JSONB{
+			"phone":   "1234567890",
+			"zipcode": "10115", // outside 66xxx
+			"house": map[string]interface{}{
+				"is_owner": true,
+			},
+		},
+		SourceHeaders: models.JSONB{},
+		Status:        models.LeadStatusReceived,
+	}
+	if err := processor.leadRepo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	job := &queue.Job{
+		ID:      1,
+		Type:    "process_lead",
+		Payload: map[string]interface{}{"lead_id": float64(lead.ID)},
+	}
+	if err := processor.processLead(ctx, job); err != nil {
+		t.Fatalf("Failed to process lead: %v", err)
+	}
+
+	stored, err := processor.leadRepo.GetLeadByID(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to load lead: %v", err)
+	}
+	if stored.Status != models.LeadStatusDelivered {
+		t.Errorf("Expected status DELIVERED, got %s", stored.Status)
+	}
+
+	flags, ok := delivered["_flags"].([]interface{})
+	if !ok || len(flags) != 1 || flags[0] != services.FlagSoftZipMismatch {
+		t.Errorf("Expected delivered payload to carry _flags [%s], got %v", services.FlagSoftZipMismatch, delivered["_flags"])
+	}
+}
+
+// TestProcessLead_EncryptedPayloadsDeliveredInPlaintext tests that PII fields are stored
+// encrypted but delivered to the Customer API decrypted
+func TestProcessLead_EncryptedPayloadsDeliveredInPlaintext(t *testing.T) {
+	processor, cleanup := setupTestProcessor(t)
+	if processor == nil {
+		return // Test was skipped
+	}
+	defer cleanup()
+
+	var delivered map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&delivered)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	encryptor, err := encryption.NewFieldEncryptor(bytes.Repeat([]byte{5}, 32), []string{"email", "phone"})
+	if err != nil {
+		t.Fatalf("Failed to create encryptor: %v", err)
+	}
+	plainRepo := processor.leadRepo
+	processor.leadRepo = repository.NewEncryptedLeadRepository(plainRepo, encryptor)
+	processor.customerAPIClient = client.NewCustomerAPIClient(server.URL, "token", 5*time.Second)
+
+	ctx := context.Background()
+	lead := &models.InboundLead{
+		RawPayload: models.JSONB{
+			"phone":   "1234567890",
+			"zipcode": "66123",
+			"house": map[string]interface{}{
+				"is_owner": true,
+			},
+		},
+		SourceHeaders: models.JSONB{},
+		Status:        models.LeadStatusReceived,
+	}
+	if err := processor.leadRepo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	job := &queue.Job{
+		ID:      1,
+		Type:    "process_lead",
+		Payload: map[string]interface{}{"lead_id": float64(lead.ID)},
+	}
+	if err := processor.processLead(ctx, job); err != nil {
+		t.Fatalf("Failed to process lead: %v", err)
+	}
+
+	if delivered["phone"] != "1234567890" {
+		t.Errorf("Expected the decrypted phone to be delivered, got %v", delivered["phone"])
+	}
+
+	// Read without decryption, the stored payloads hold ciphertext
+	stored, err := plainRepo.GetLeadByID(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to load lead: %v", err)
+	}
+	if stored.Status != models.LeadStatusDelivered {
+		t.Errorf("Expected status DELIVERED, got %s", stored.Status)
+	}
+	for _, payload := range []models.JSONB{stored.RawPayload, stored.NormalizedPayload, stored.CustomerPayload} {
+		if !encryption.IsEncrypted(payload["phone"]) {
+			t.Errorf("Expected the stored phone to be ciphertext, got %v", payload["phone"])
+		}
+	}
+}
+
+// TestProcessLead_SourceQuotaExceededDeliversWithFlag tests that a lead accepted beyond
+// its source's quota is delivered with a flag
+func TestProcessLead_SourceQuotaExceededDeliversWithFlag(t *testing.T) {
+	processor, cleanup := setupTestProcessor(t)
+	if processor == nil {
+		return // Test was skipped
+	}
+	defer cleanup()
+
+	var delivered map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&delivered)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	processor.customerAPIClient = client.NewCustomerAPIClient(server.URL, "token", 5*time.Second)
+
+	ctx := context.Background()
+
+	lead := &models.InboundLead{
+		RawPayload: models.JSONB{
+			"phone":   "1234567890",
+			"zipcode": "66123",
+			"house": map[string]interface{}{
+				"is_owner": true,
+			},
+		},
+		SourceHeaders: models.JSONB{"X-Source-Quota-Exceeded": "true"},
+		Status:        models.LeadStatusReceived,
+	}
+	if err := processor.leadRepo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	job := &queue.Job{
+		ID:      1,
+		Type:    "process_lead",
+		Payload: map[string]interface{}{"lead_id": float64(lead.ID)},
+	}
+	if err := processor.processLead(ctx, job); err != nil {
+		t.Fatalf("Failed to process lead: %v", err)
+	}
+
+	flags, ok := delivered["_flags"].([]interface{})
+	if !ok || len(flags) != 1 || flags[0] != services.FlagSourceQuotaExceeded {
+		t.Errorf("Expected delivered payload to carry _flags [%s], got %v", services.FlagSourceQuotaExceeded, delivered["_flags"])
+	}
+}
+
 // TestExecuteDeliveryStage_RetryOn5xx tests retry behavior on 5xx errors
 // Requirements: 4.3, 4.4, 5.3
 func TestExecuteDeliveryStage_RetryOn5xx(t *testing.T) {
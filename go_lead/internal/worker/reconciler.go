@@ -0,0 +1,174 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/logger"
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/repository"
+)
+
+// LeadLookup asks the Customer API whether it has a lead, see client.CustomerAPIClient.LookupLead
+type LeadLookup interface {
+	LookupLead(ctx context.Context, readURL string) (bool, error)
+}
+
+// Reconciler periodically confirms that recently DELIVERED leads exist at the Customer API
+// by calling its read endpoint, and records the outcome as the lead's reconciliation status.
+// Leads the endpoint does not know are logged as discrepancies and checked again in later
+// runs until they leave the window. It does nothing when no read endpoint is configured.
+type Reconciler struct {
+	repo            repository.ReconciliationRepository
+	lookup          LeadLookup
+	readURL         string
+	customerIDField string
+	interval        time.Duration
+	window          time.Duration
+	batchSize       int
+	now             func() time.Time
+}
+
+// ReconcileSummary counts the outcomes of a reconciliation run
+type ReconcileSummary struct {
+	Found   int
+	Missing int
+	Skipped int // leads that could not be checked, e.g. because the read endpoint failed
+}
+
+// NewReconciler creates a new Reconciler from the reconciliation config
+func NewReconciler(repo repository.ReconciliationRepository, lookup LeadLookup, cfg config.ReconciliationConfig) *Reconciler {
+	return &Reconciler{
+		repo:            repo,
+		lookup:          lookup,
+		readURL:         cfg.ReadURL,
+		customerIDField: cfg.CustomerIDField,
+		interval:        cfg.Interval,
+		window:          cfg.Window,
+		batchSize:       cfg.BatchSize,
+		now:             time.Now,
+	}
+}
+
+// Run reconciles immediately and then every interval until ctx is cancelled
+func (r *Reconciler) Run(ctx context.Context) {
+	if r.readURL == "" {
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		summary, err := r.Reconcile(ctx)
+		if err != nil {
+			logger.LogError(ctx, "Failed to reconcile delivered leads", err)
+		} else if summary != (ReconcileSummary{}) {
+			logger.Info(ctx, "Reconciled delivered leads",
+				"found", summary.Found, "missing", summary.Missing, "skipped", summary.Skipped)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile checks one batch of recently delivered leads against the read endpoint
+func (r *Reconciler) Reconcile(ctx context.Context) (ReconcileSummary, error) {
+	var summary ReconcileSummary
+	if r.readURL == "" {
+		return summary, nil
+	}
+
+	leads, err := r.repo.FindLeadsToReconcile(ctx, r.now().Add(-r.window), r.batchSize)
+	if err != nil {
+		return summary, err
+	}
+
+	for _, lead := range leads {
+		readURL, err := r.readURLFor(lead)
+		if err != nil {
+			logger.Warn(ctx, "Cannot reconcile delivered lead", "lead_id", lead.LeadID, "error", err.Error())
+			summary.Skipped++
+			continue
+		}
+
+		found, err := r.lookup.LookupLead(ctx, readURL)
+		if err != nil {
+			logger.Warn(ctx, "Customer API read endpoint failed, retrying in the next run",
+				"lead_id", lead.LeadID, "error", err.Error())
+			summary.Skipped++
+			continue
+		}
+
+		status := models.ReconciliationStatusFound
+		if !found {
+			status = models.ReconciliationStatusMissing
+			logger.Warn(ctx, "Delivered lead not found at Customer API",
+				"lead_id", lead.LeadID, "delivered_at", lead.DeliveredAt, "read_url", readURL)
+		}
+		if err := r.repo.UpdateReconciliationStatus(ctx, lead.LeadID, status); err != nil {
+			return summary, err
+		}
+		if found {
+			summary.Found++
+		} else {
+			summary.Missing++
+		}
+	}
+	return summary, nil
+}
+
+// readURLFor fills in the read URL placeholders for a lead. The customer ID is taken
+// from the body of the successful delivery response.
+func (r *Reconciler) readURLFor(lead models.ReconciliationCandidate) (string, error) {
+	readURL := strings.ReplaceAll(r.readURL, config.ReadURLLeadID, strconv.FormatInt(lead.LeadID, 10))
+	if !strings.Contains(readURL, config.ReadURLCustomerID) {
+		return readURL, nil
+	}
+
+	if lead.ResponseBody == nil {
+		return "", fmt.Errorf("no successful delivery response recorded")
+	}
+	customerID := customerIDFrom(*lead.ResponseBody, r.customerIDField)
+	if customerID == "" {
+		return "", fmt.Errorf("delivery response has no %s", r.customerIDField)
+	}
+	return strings.ReplaceAll(readURL, config.ReadURLCustomerID, url.PathEscape(customerID)), nil
+}
+
+// customerIDFrom returns the string or number at the dot-separated path of a JSON
+// response body, or an empty string if there is none
+func customerIDFrom(body, path string) string {
+	var current interface{}
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&current); err != nil {
+		return ""
+	}
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		current = object[key]
+	}
+
+	switch v := current.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		return ""
+	}
+}
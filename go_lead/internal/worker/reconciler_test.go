@@ -0,0 +1,147 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/client"
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+)
+
+// fakeReconciliationRepo serves fixed candidates and records the statuses set
+type fakeReconciliationRepo struct {
+	leads          []models.ReconciliationCandidate
+	deliveredAfter time.Time
+	statuses       map[int64]models.ReconciliationStatus
+}
+
+func (r *fakeReconciliationRepo) FindLeadsToReconcile(ctx context.Context, deliveredAfter time.Time, limit int) ([]models.ReconciliationCandidate, error) {
+	r.deliveredAfter = deliveredAfter
+	if len(r.leads) > limit {
+		return r.leads[:limit], nil
+	}
+	return r.leads, nil
+}
+
+func (r *fakeReconciliationRepo) UpdateReconciliationStatus(ctx context.Context, leadID int64, status models.ReconciliationStatus) error {
+	if r.statuses == nil {
+		r.statuses = make(map[int64]models.ReconciliationStatus)
+	}
+	r.statuses[leadID] = status
+	return nil
+}
+
+// newReadEndpoint serves the customer leads with the given IDs and 404 for all others
+func newReadEndpoint(t *testing.T, ids ...string) *httptest.Server {
+	known := make(map[string]bool)
+	for _, id := range ids {
+		known["/leads/"+id] = true
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/leads/broken" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if !known[r.URL.Path] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"status": "open"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestReconciler_FlagsMissingLeads(t *testing.T) {
+	server := newReadEndpoint(t, "cust-1")
+	body := func(s string) *string { return &s }
+	repo := &fakeReconciliationRepo{leads: []models.ReconciliationCandidate{
+		{LeadID: 1, ResponseBody: body(`{"id": "cust-1"}`)},
+		{LeadID: 2, ResponseBody: body(`{"id": "cust-2"}`)},
+		{LeadID: 3, ResponseBody: body(`{"status": "accepted"}`)}, // no customer ID to look up
+	}}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	reconciler := NewReconciler(repo, client.NewCustomerAPIClient(server.URL, "token", time.Second), config.ReconciliationConfig{
+		ReadURL:         server.URL + "/leads/{customer_id}",
+		CustomerIDField: "id",
+		Window:          24 * time.Hour,
+		BatchSize:       10,
+	})
+	reconciler.now = func() time.Time { return now }
+
+	summary, err := reconciler.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if summary != (ReconcileSummary{Found: 1, Missing: 1, Skipped: 1}) {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+	if repo.statuses[1] != models.ReconciliationStatusFound {
+		t.Errorf("Expected lead 1 to be FOUND, got %q", repo.statuses[1])
+	}
+	if repo.statuses[2] != models.ReconciliationStatusMissing {
+		t.Errorf("Expected lead 2 to be MISSING, got %q", repo.statuses[2])
+	}
+	if _, ok := repo.statuses[3]; ok {
+		t.Errorf("Expected lead 3 to stay unchecked, got %q", repo.statuses[3])
+	}
+	if !repo.deliveredAfter.Equal(now.Add(-24 * time.Hour)) {
+		t.Errorf("Expected leads delivered within the window, got after %v", repo.deliveredAfter)
+	}
+}
+
+func TestReconciler_LeadIDAndEndpointErrors(t *testing.T) {
+	server := newReadEndpoint(t, "7")
+	repo := &fakeReconciliationRepo{leads: []models.ReconciliationCandidate{{LeadID: 7}, {LeadID: 8}}}
+	reconciler := NewReconciler(repo, client.NewCustomerAPIClient(server.URL, "token", time.Second), config.ReconciliationConfig{
+		ReadURL:   server.URL + "/leads/{lead_id}",
+		Window:    time.Hour,
+		BatchSize: 10,
+	})
+
+	if _, err := reconciler.Reconcile(context.Background()); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if repo.statuses[7] != models.ReconciliationStatusFound || repo.statuses[8] != models.ReconciliationStatusMissing {
+		t.Errorf("Expected lead 7 FOUND and lead 8 MISSING, got %v", repo.statuses)
+	}
+
+	// A failing read endpoint says nothing about the lead, so its status is left alone
+	failing := &fakeReconciliationRepo{leads: []models.ReconciliationCandidate{{LeadID: 9}}}
+	reconciler = NewReconciler(failing, client.NewCustomerAPIClient(server.URL, "token", time.Second), config.ReconciliationConfig{
+		ReadURL:   server.URL + "/leads/broken?lead={lead_id}",
+		Window:    time.Hour,
+		BatchSize: 10,
+	})
+	summary, err := reconciler.Reconcile(context.Background())
+	if err != nil || summary.Skipped != 1 || len(failing.statuses) != 0 {
+		t.Errorf("Expected the lead to be skipped, got %+v, %v, %v", summary, failing.statuses, err)
+	}
+}
+
+func TestReconciler_NoReadURL(t *testing.T) {
+	repo := &fakeReconciliationRepo{leads: []models.ReconciliationCandidate{{LeadID: 1}}}
+	reconciler := NewReconciler(repo, client.NewCustomerAPIClient("http://unused", "token", time.Second), config.ReconciliationConfig{})
+
+	summary, err := reconciler.Reconcile(context.Background())
+	if err != nil || summary != (ReconcileSummary{}) || len(repo.statuses) != 0 {
+		t.Errorf("Expected no reconciliation without a read URL, got %+v, %v", summary, err)
+	}
+
+	// Run returns at once instead of ticking with a zero interval
+	done := make(chan struct{})
+	go func() {
+		reconciler.Run(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Run to return without a read URL")
+	}
+}
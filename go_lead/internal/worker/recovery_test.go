@@ -0,0 +1,166 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/queue"
+)
+
+// memoryLockRepo keeps processing locks in memory and records recoveries
+type memoryLockRepo struct {
+	locks      map[int64]*models.ProcessingLock
+	acquired   []int64
+	recovered  []int64
+	recoverMsg string
+}
+
+func newMemoryLockRepo() *memoryLockRepo {
+	return &memoryLockRepo{locks: make(map[int64]*models.ProcessingLock)}
+}
+
+func (r *memoryLockRepo) AcquireLock(ctx context.Context, lock *models.ProcessingLock) error {
+	r.locks[lock.JobID] = lock
+	r.acquired = append(r.acquired, lock.JobID)
+	return nil
+}
+
+func (r *memoryLockRepo) ReleaseLock(ctx context.Context, jobID int64) error {
+	delete(r.locks, jobID)
+	return nil
+}
+
+func (r *memoryLockRepo) GetStaleLocks(ctx context.Context, lockedBefore time.Time) ([]*models.ProcessingLock, error) {
+	var stale []*models.ProcessingLock
+	for _, lock := range r.locks {
+		if lock.LockedAt.Before(lockedBefore) {
+			stale = append(stale, lock)
+		}
+	}
+	return stale, nil
+}
+
+func (r *memoryLockRepo) RecoverLock(ctx context.Context, lock *models.ProcessingLock, errorMessage string) (int64, error) {
+	delete(r.locks, lock.JobID)
+	r.recovered = append(r.recovered, lock.JobID)
+	r.recoverMsg = errorMessage
+	return 1, nil
+}
+
+func TestProcessor_HoldsLockDuringJob(t *testing.T) {
+	lockRepo := newMemoryLockRepo()
+	jobQueue := &sliceQueue{pending: newNotifyJobs(7)}
+	processor := newOnceTestProcessor(t, jobQueue)
+	processor.lockRepo = lockRepo
+
+	if _, err := processor.processNextJob(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(lockRepo.acquired) != 1 || lockRepo.acquired[0] != 7 {
+		t.Errorf("Expected lock to be acquired for job 7, got %v", lockRepo.acquired)
+	}
+	if len(lockRepo.locks) != 0 {
+		t.Errorf("Expected lock to be released after the job, got %v", lockRepo.locks)
+	}
+}
+
+func TestRecoverStuckJobs_RecoversCrashedJob(t *testing.T) {
+	now := time.Date(2025, 1, 7, 12, 0, 0, 0, time.UTC)
+	lockRepo := newMemoryLockRepo()
+	jobQueue := &sliceQueue{}
+
+	// Simulate a worker that crashed after acquiring the lock: the lock was never released
+	crashed := NewProcessor(ProcessorConfig{
+		Queue:              jobQueue,
+		ProcessingLockRepo: lockRepo,
+		WorkerID:           "worker-a",
+		Clock:              func() time.Time { return now.Add(-time.Hour) },
+	})
+	lockRepo.AcquireLock(context.Background(), &models.ProcessingLock{
+		JobID: 7, LeadID: 42, WorkerID: crashed.workerID, LockedAt: crashed.now(),
+	})
+
+	recovering := NewProcessor(ProcessorConfig{
+		Queue:              jobQueue,
+		ProcessingLockRepo: lockRepo,
+		WorkerID:           "worker-b",
+		LockTimeout:        10 * time.Minute,
+		Clock:              func() time.Time { return now },
+	})
+
+	recovered, err := recovering.RecoverStuckJobs(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if recovered != 1 {
+		t.Errorf("Expected 1 recovered job, got %d", recovered)
+	}
+	if len(lockRepo.recovered) != 1 || lockRepo.recovered[0] != 7 {
+		t.Errorf("Expected lock of job 7 to be recovered, got %v", lockRepo.recovered)
+	}
+	if lockRepo.recoverMsg == "" {
+		t.Error("Expected unanswered delivery attempts to be marked with an error message")
+	}
+	if len(jobQueue.retried) != 1 || jobQueue.retried[0] != 7 {
+		t.Errorf("Expected job 7 to be requeued, got %v", jobQueue.retried)
+	}
+}
+
+func TestRecoverStuckJobs_LeavesActiveLocks(t *testing.T) {
+	now := time.Date(2025, 1, 7, 12, 0, 0, 0, time.UTC)
+	lockRepo := newMemoryLockRepo()
+	lockRepo.AcquireLock(context.Background(), &models.ProcessingLock{
+		JobID: 7, LeadID: 42, WorkerID: "worker-a", LockedAt: now.Add(-time.Minute),
+	})
+	jobQueue := &sliceQueue{}
+
+	processor := NewProcessor(ProcessorConfig{
+		Queue:              jobQueue,
+		ProcessingLockRepo: lockRepo,
+		LockTimeout:        10 * time.Minute,
+		Clock:              func() time.Time { return now },
+	})
+
+	recovered, err := processor.RecoverStuckJobs(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if recovered != 0 || len(jobQueue.retried) != 0 {
+		t.Errorf("Expected an active lock to be left alone, got %d recovered", recovered)
+	}
+	if _, ok := lockRepo.locks[7]; !ok {
+		t.Error("Expected the active lock to be kept")
+	}
+}
+
+func TestRecoverStuckJobs_ThenProcessesJobAgain(t *testing.T) {
+	now := time.Date(2025, 1, 7, 12, 0, 0, 0, time.UTC)
+	lockRepo := newMemoryLockRepo()
+	lockRepo.AcquireLock(context.Background(), &models.ProcessingLock{
+		JobID: 7, LeadID: 42, WorkerID: "worker-a", LockedAt: now.Add(-time.Hour),
+	})
+
+	// The recovered job becomes available again once it is requeued
+	jobQueue := &sliceQueue{}
+	processor := newOnceTestProcessor(t, jobQueue)
+	processor.lockRepo = lockRepo
+	processor.now = func() time.Time { return now }
+
+	if _, err := processor.RecoverStuckJobs(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	jobQueue.pending = append(jobQueue.pending, &queue.Job{ID: 7, Type: queue.JobTypeNotifySender, Payload: newNotificationPayload(42, 1)})
+
+	summary, err := processor.RunOnce(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if summary.Succeeded != 1 || len(lockRepo.locks) != 0 {
+		t.Errorf("Expected the recovered job to complete and release its lock, got %+v, locks %v", summary, lockRepo.locks)
+	}
+}
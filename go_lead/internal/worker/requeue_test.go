@@ -0,0 +1,129 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/queue"
+)
+
+func TestRequeueFailedLead_DeprioritizesRepeatedFailures(t *testing.T) {
+	jobQueue := &recordingQueue{}
+	processor := NewProcessor(ProcessorConfig{Queue: jobQueue})
+	ctx := context.Background()
+
+	// A fresh lead enters the queue at the default priority
+	if err := jobQueue.Enqueue(ctx, queue.JobTypeProcessLead, queue.NewJobPayload(1)); err != nil {
+		t.Fatalf("Failed to enqueue fresh lead: %v", err)
+	}
+
+	// Another lead fails delivery three times
+	for attemptNo := 1; attemptNo <= 3; attemptNo++ {
+		if err := processor.requeueFailedLead(ctx, 2, attemptNo); err != nil {
+			t.Fatalf("Failed to re-enqueue lead: %v", err)
+		}
+	}
+
+	if len(jobQueue.jobs) != 4 {
+		t.Fatalf("Expected 4 enqueued jobs, got %d", len(jobQueue.jobs))
+	}
+
+	fresh := jobQueue.jobs[0]
+	failedThrice := jobQueue.jobs[3]
+	if failedThrice.jobType != queue.JobTypeProcessLead {
+		t.Errorf("Expected process_lead job, got %s", failedThrice.jobType)
+	}
+	if diff := failedThrice.priority - fresh.priority; diff != 3 {
+		t.Errorf("Expected lead failing 3 times to be 3 priority levels behind a fresh lead, got %d", diff)
+	}
+	if failedThrice.delay != 120*time.Second {
+		t.Errorf("Expected the third default backoff delay of 2m, got %v", failedThrice.delay)
+	}
+}
+
+func TestRetryPriority_UsesConfiguredPenalty(t *testing.T) {
+	processor := NewProcessor(ProcessorConfig{Queue: &recordingQueue{}, PriorityPenalty: 5})
+
+	if got := processor.retryPriority(3); got != 15 {
+		t.Errorf("Expected priority 15 after 3 failures with penalty 5, got %d", got)
+	}
+}
+
+func TestRetryDelay_FollowsBackoffSchedule(t *testing.T) {
+	processor := NewProcessor(ProcessorConfig{
+		Queue:                    &recordingQueue{},
+		ExponentialBackoffDelays: []time.Duration{time.Second, 2 * time.Second},
+	})
+
+	for attemptNo, want := range map[int]time.Duration{0: 0, 1: time.Second, 2: 2 * time.Second, 3: 0} {
+		if got := processor.retryDelay(attemptNo); got != want {
+			t.Errorf("Expected delay %v after %d failed attempts, got %v", want, attemptNo, got)
+		}
+	}
+}
+
+func TestRequeueFailedLead_ReschedulesCurrentJob(t *testing.T) {
+	jobQueue := &recordingQueue{}
+	processor := NewProcessor(ProcessorConfig{Queue: jobQueue, PriorityPenalty: 2})
+	retry := &jobRetry{}
+	ctx := context.WithValue(context.Background(), jobRetryKey{}, retry)
+
+	if err := processor.requeueFailedLead(ctx, 2, 3); err != nil {
+		t.Fatalf("Failed to re-enqueue lead: %v", err)
+	}
+
+	// Inside a running job the lead keeps its queue slot instead of getting a new job
+	if len(jobQueue.jobs) != 0 {
+		t.Errorf("Expected no new job while the lead's job is processing, got %d", len(jobQueue.jobs))
+	}
+	if !retry.requested {
+		t.Fatal("Expected the current job to be rescheduled")
+	}
+	if retry.priority != 6 {
+		t.Errorf("Expected rescheduled priority 6, got %d", retry.priority)
+	}
+	if retry.delay != 120*time.Second {
+		t.Errorf("Expected the job to be rescheduled after the backoff delay of 2m, got %v", retry.delay)
+	}
+}
+
+// Test a backoff delay longer than the processing lock timeout does not hold the job
+func TestExecuteDeliveryStage_BackoffBeyondLockTimeout(t *testing.T) {
+	processor, cleanup := setupTestProcessor(t)
+	if processor == nil {
+		return // Test was skipped
+	}
+	defer cleanup()
+
+	processor.customerAPIClient = failingSender(http.StatusServiceUnavailable)
+	processor.maxDeliveryAttempts = 5
+	processor.exponentialBackoffDelays = []time.Duration{24 * time.Hour, 24 * time.Hour}
+	processor.queue = &recordingQueue{}
+
+	ctx := context.Background()
+	lead := &models.InboundLead{
+		RawPayload:      models.JSONB{"phone": "1234567890", "zipcode": "66123"},
+		Status:          models.LeadStatusReady,
+		CustomerPayload: models.JSONB{"phone": "1234567890"},
+	}
+	if err := processor.leadRepo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+
+	for attemptNo := 1; attemptNo <= 2; attemptNo++ {
+		retry := &jobRetry{}
+		start := time.Now()
+		if err := processor.executeDeliveryStage(context.WithValue(ctx, jobRetryKey{}, retry), lead); err != nil {
+			t.Fatalf("Delivery stage failed: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed >= processor.lockTimeout || elapsed > 5*time.Second {
+			t.Fatalf("Expected attempt %d not to wait for the backoff, took %v", attemptNo, elapsed)
+		}
+		if !retry.requested || retry.delay != 24*time.Hour {
+			t.Errorf("Expected attempt %d to reschedule the job after 24h, got %+v", attemptNo, retry)
+		}
+	}
+}
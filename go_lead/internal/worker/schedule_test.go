@@ -0,0 +1,128 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/queue"
+	"github.com/checkfox/go_lead/internal/schedule"
+)
+
+func TestExecuteDeliveryStage_DefersOutsideSchedule(t *testing.T) {
+	now := time.Date(2025, 1, 7, 12, 0, 0, 0, time.UTC)
+	processor := NewProcessor(ProcessorConfig{
+		Queue:            &recordingQueue{},
+		DeliverySchedule: config.DeliverySchedule{AllowedHours: []int{22}, Timezone: "UTC"},
+		Clock:            func() time.Time { return now },
+	})
+
+	err := processor.executeDeliveryStage(context.Background(), &models.InboundLead{ID: 1})
+
+	var deliveryErr *models.DeliveryError
+	if !errors.As(err, &deliveryErr) {
+		t.Fatalf("Expected DeliveryError, got %v", err)
+	}
+	if !deliveryErr.IsRetriable() {
+		t.Error("Expected deferred delivery to be retriable")
+	}
+	expected := time.Date(2025, 1, 7, 22, 0, 0, 0, time.UTC)
+	if deliveryErr.NextAttemptAt == nil || !deliveryErr.NextAttemptAt.Equal(expected) {
+		t.Errorf("Expected next attempt at %v, got %v", expected, deliveryErr.NextAttemptAt)
+	}
+}
+
+// newScheduleTestLead returns a READY lead whose customer payload awaits delivery
+func newScheduleTestLead() *models.InboundLead {
+	return &models.InboundLead{
+		ID:              42,
+		Status:          models.LeadStatusReady,
+		RawPayload:      models.JSONB{"phone": "1234567890", "zipcode": "66123"},
+		CustomerPayload: models.JSONB{"phone": "1234567890", "product": map[string]interface{}{"name": "test-product"}},
+	}
+}
+
+func TestProcessNextJob_HoldsLeadOutsideSchedule(t *testing.T) {
+	// Tuesday 18:30 in Berlin; the schedule opens again Wednesday at 08:00
+	location, _ := time.LoadLocation("Europe/Berlin")
+	now := time.Date(2025, 1, 7, 18, 30, 0, 0, location)
+	lead := newScheduleTestLead()
+	leadRepo := &statusRecordingLeadRepo{notifierLeadRepo: notifierLeadRepo{lead: lead}}
+	sender := acceptingSender()
+	jobQueue := &sliceQueue{pending: []*queue.Job{{ID: 7, Type: queue.JobTypeProcessLead, Payload: queue.NewJobPayload(lead.ID)}}}
+	processor := NewProcessor(ProcessorConfig{
+		Queue:             jobQueue,
+		LeadRepo:          leadRepo,
+		CustomerAPIClient: sender,
+		DeliverySchedule: config.DeliverySchedule{
+			AllowedHours:    []int{8, 9, 10, 11, 12, 13, 14, 15, 16, 17},
+			AllowedWeekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			Timezone:        "Europe/Berlin",
+		},
+		Clock: func() time.Time { return now },
+	})
+
+	if _, err := processor.processNextJob(context.Background()); err != nil {
+		t.Fatalf("Expected the job to be rescheduled, got %v", err)
+	}
+
+	if sender.calls != 0 {
+		t.Errorf("Expected no delivery outside the schedule, got %d calls", sender.calls)
+	}
+	if len(jobQueue.retried) != 1 || jobQueue.retried[0] != 7 {
+		t.Fatalf("Expected job 7 to be rescheduled, got %v", jobQueue.retried)
+	}
+	nextOpen := time.Date(2025, 1, 8, 8, 0, 0, 0, location)
+	if jobQueue.delays[0] != nextOpen.Sub(now) {
+		t.Errorf("Expected the job to be deferred by %v until %v, got %v", nextOpen.Sub(now), nextOpen, jobQueue.delays[0])
+	}
+	if len(jobQueue.completed) != 0 || len(jobQueue.failed) != 0 {
+		t.Errorf("Expected the job to stay pending, got completed %v and failed %v", jobQueue.completed, jobQueue.failed)
+	}
+	if lead.Status != models.LeadStatusReady || len(leadRepo.statusUpdates) != 0 {
+		t.Errorf("Expected the lead to stay READY, got %s with updates %v", lead.Status, leadRepo.statusUpdates)
+	}
+}
+
+func TestProcessNextJob_DeliversLeadInsideSchedule(t *testing.T) {
+	processor, cleanup := setupTestProcessor(t)
+	if processor == nil {
+		return // Test was skipped
+	}
+	defer cleanup()
+
+	now := time.Date(2025, 1, 7, 22, 15, 0, 0, time.UTC)
+	processor.deliveryWindow = schedule.NewWindow(config.DeliverySchedule{AllowedHours: []int{22}, Timezone: "UTC"})
+	processor.now = func() time.Time { return now }
+	sender := acceptingSender()
+	processor.customerAPIClient = sender
+
+	ctx := context.Background()
+	lead := newScheduleTestLead()
+	if err := processor.leadRepo.CreateLead(ctx, lead); err != nil {
+		t.Fatalf("Failed to create lead: %v", err)
+	}
+	jobQueue := &sliceQueue{pending: []*queue.Job{{ID: 7, Type: queue.JobTypeProcessLead, Payload: queue.NewJobPayload(lead.ID)}}}
+	processor.queue = jobQueue
+
+	if _, err := processor.processNextJob(ctx); err != nil {
+		t.Fatalf("Failed to process job: %v", err)
+	}
+
+	if sender.calls != 1 {
+		t.Errorf("Expected the lead to be delivered immediately, got %d calls", sender.calls)
+	}
+	if len(jobQueue.completed) != 1 || len(jobQueue.retried) != 0 {
+		t.Errorf("Expected the job to complete without rescheduling, got completed %v and retried %v", jobQueue.completed, jobQueue.retried)
+	}
+	delivered, err := processor.leadRepo.GetLeadByID(ctx, lead.ID)
+	if err != nil {
+		t.Fatalf("Failed to load lead: %v", err)
+	}
+	if delivered.Status != models.LeadStatusDelivered {
+		t.Errorf("Expected the lead to be DELIVERED, got %s", delivered.Status)
+	}
+}
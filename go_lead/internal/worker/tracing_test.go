@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/checkfox/go_lead/internal/config"
+	"github.com/checkfox/go_lead/internal/models"
+	"github.com/checkfox/go_lead/internal/queue"
+	"github.com/checkfox/go_lead/internal/services"
+	"github.com/checkfox/go_lead/internal/tracing"
+)
+
+func TestProcessLead_CreatesStageSpans(t *testing.T) {
+	exporter := tracing.NewInMemoryExporter()
+	tracing.SetTracer(tracing.NewTracer(exporter))
+	t.Cleanup(func() { tracing.SetTracer(nil) })
+
+	lead := &models.InboundLead{
+		ID:     42,
+		Status: models.LeadStatusReceived,
+		RawPayload: models.JSONB{
+			"phone":   "1234567890",
+			"zipcode": "66123",
+			"house":   map[string]interface{}{"is_owner": true},
+		},
+	}
+
+	// Delivery is outside the schedule so the lead stops before the Customer API is called
+	now := time.Date(2025, 1, 7, 12, 0, 0, 0, time.UTC)
+	processor := NewProcessor(ProcessorConfig{
+		Queue:            &recordingQueue{},
+		LeadRepo:         &notifierLeadRepo{lead: lead},
+		Validator:        services.NewValidator(),
+		Normalizer:       services.NewNormalizer(),
+		Mapper:           services.NewMapper(&config.Config{CustomerAPI: config.CustomerAPIConfig{ProductName: "test_product"}}),
+		DeliverySchedule: config.DeliverySchedule{AllowedHours: []int{22}, Timezone: "UTC"},
+		Clock:            func() time.Time { return now },
+	})
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	payload := queue.NewJobPayload(lead.ID)
+	payload["traceparent"] = traceparent
+	payload["correlation_id"] = "corr-123"
+
+	err := processor.processLead(context.Background(), &queue.Job{ID: 1, Type: queue.JobTypeProcessLead, Payload: payload})
+	var deliveryErr *models.DeliveryError
+	if !errors.As(err, &deliveryErr) {
+		t.Fatalf("Expected deferred delivery, got %v", err)
+	}
+
+	spans := exporter.Spans()
+	names := make([]string, 0, len(spans))
+	byName := make(map[string]*tracing.Span, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name)
+		byName[span.Name] = span
+	}
+	expected := []string{"validation", "transformation", "delivery", "processLead"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected spans %v, got %v", expected, names)
+	}
+	for i, name := range expected {
+		if names[i] != name {
+			t.Fatalf("Expected spans %v, got %v", expected, names)
+		}
+	}
+
+	remote, _ := tracing.ParseTraceparent(traceparent)
+	root := byName["processLead"]
+	if root.ParentSpanID != remote.SpanID {
+		t.Error("Expected processLead to continue the webhook span from the job payload")
+	}
+	for _, span := range spans {
+		if span.Context.TraceID != remote.TraceID {
+			t.Errorf("Expected span %s to belong to the webhook trace", span.Name)
+		}
+		if span.Attributes[tracing.AttributeLeadID] != strconv.FormatInt(lead.ID, 10) {
+			t.Errorf("Expected span %s to carry lead.id, got %v", span.Name, span.Attributes)
+		}
+		if span.Attributes[tracing.AttributeCorrelationID] != "corr-123" {
+			t.Errorf("Expected span %s to carry correlation.id, got %v", span.Name, span.Attributes)
+		}
+		if span.Name != "processLead" && span.ParentSpanID != root.Context.SpanID {
+			t.Errorf("Expected span %s to be a child of processLead", span.Name)
+		}
+	}
+	if byName["delivery"].Err == "" {
+		t.Error("Expected the deferred delivery to be recorded on the delivery span")
+	}
+}
@@ -0,0 +1,8 @@
+-- Migration: Add source_id to inbound_lead
+-- Stores the resolved sender identity (X-Source-ID) for multi-tenant deployments
+
+ALTER TABLE inbound_lead ADD COLUMN IF NOT EXISTS source_id VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS idx_inbound_lead_source_id ON inbound_lead(source_id);
+
+COMMENT ON COLUMN inbound_lead.source_id IS 'Resolved source identifier of the webhook sender (from X-Source-ID header)';
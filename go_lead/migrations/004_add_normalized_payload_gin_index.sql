@@ -0,0 +1,5 @@
+-- Migration: Add GIN index on inbound_lead.normalized_payload
+-- Supports containment lookups (e.g. search by email or phone) without a full table scan
+
+CREATE INDEX IF NOT EXISTS idx_inbound_lead_normalized_payload
+    ON inbound_lead USING GIN (normalized_payload jsonb_path_ops);
@@ -0,0 +1,8 @@
+-- Migration: Add tenant_id to inbound_lead
+-- Allows multi-tenant deployments to scope every lead query to a single tenant
+
+ALTER TABLE inbound_lead ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS idx_inbound_lead_tenant_id ON inbound_lead(tenant_id);
+
+COMMENT ON COLUMN inbound_lead.tenant_id IS 'Owning tenant in multi-tenant deployments (NULL in single-tenant mode)';
@@ -0,0 +1,9 @@
+-- Migration: Allow PENDING_CONFIRMATION lead status
+-- Used when the Customer API accepts a lead asynchronously (202) and confirms delivery via callback
+
+ALTER TABLE inbound_lead DROP CONSTRAINT IF EXISTS check_status;
+
+ALTER TABLE inbound_lead ADD CONSTRAINT check_status
+    CHECK (status IN ('RECEIVED', 'REJECTED', 'READY', 'PENDING_CONFIRMATION', 'DELIVERED', 'FAILED', 'PERMANENTLY_FAILED'));
+
+COMMENT ON COLUMN inbound_lead.status IS 'Current processing status: RECEIVED, REJECTED, READY, PENDING_CONFIRMATION, DELIVERED, FAILED, PERMANENTLY_FAILED';
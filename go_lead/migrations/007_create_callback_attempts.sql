@@ -0,0 +1,28 @@
+-- Migration: Create callback_attempts table
+-- This table stores all attempts to notify webhook senders of the final lead outcome
+
+CREATE TABLE IF NOT EXISTS callback_attempts (
+    id SERIAL PRIMARY KEY,
+    lead_id INTEGER NOT NULL REFERENCES inbound_lead(id) ON DELETE CASCADE,
+    attempt_no INTEGER NOT NULL,
+    callback_url TEXT NOT NULL,
+    requested_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    response_status INTEGER,
+    error_message TEXT,
+    success BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Add constraint to ensure attempt_no is positive
+ALTER TABLE callback_attempts ADD CONSTRAINT check_callback_attempt_no
+    CHECK (attempt_no > 0);
+
+-- Create indexes for common query patterns
+CREATE INDEX idx_callback_attempts_lead_id ON callback_attempts(lead_id);
+
+-- Add unique constraint to prevent duplicate attempt numbers for the same lead
+CREATE UNIQUE INDEX idx_callback_attempts_lead_attempt ON callback_attempts(lead_id, attempt_no);
+
+-- Add comment for documentation
+COMMENT ON TABLE callback_attempts IS 'Audit trail of outcome notifications sent to webhook senders via X-Callback-URL';
+COMMENT ON COLUMN callback_attempts.response_status IS 'HTTP status code returned by the callback endpoint (null if network error)';
@@ -0,0 +1,9 @@
+-- Migration: Allow DELIVERED_DUPLICATE lead status
+-- Used when the Customer API reports it already has the lead (e.g. 409 Conflict)
+
+ALTER TABLE inbound_lead DROP CONSTRAINT IF EXISTS check_status;
+
+ALTER TABLE inbound_lead ADD CONSTRAINT check_status
+    CHECK (status IN ('RECEIVED', 'REJECTED', 'READY', 'PENDING_CONFIRMATION', 'DELIVERED', 'DELIVERED_DUPLICATE', 'FAILED', 'PERMANENTLY_FAILED'));
+
+COMMENT ON COLUMN inbound_lead.status IS 'Current processing status: RECEIVED, REJECTED, READY, PENDING_CONFIRMATION, DELIVERED, DELIVERED_DUPLICATE, FAILED, PERMANENTLY_FAILED';
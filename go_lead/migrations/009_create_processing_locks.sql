@@ -0,0 +1,17 @@
+-- Migration: Create processing_locks table
+-- A worker holds a lock while it processes a job; locks left behind by a crashed
+-- worker are used to recover the job and close out its unanswered delivery attempts
+
+CREATE TABLE IF NOT EXISTS processing_locks (
+    job_id INTEGER PRIMARY KEY,
+    lead_id INTEGER NOT NULL REFERENCES inbound_lead(id) ON DELETE CASCADE,
+    worker_id VARCHAR(255) NOT NULL,
+    locked_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create index for finding stale locks
+CREATE INDEX idx_processing_locks_locked_at ON processing_locks(locked_at);
+
+-- Add comment for documentation
+COMMENT ON TABLE processing_locks IS 'Jobs currently being processed, used to recover jobs interrupted by a worker crash';
+COMMENT ON COLUMN processing_locks.worker_id IS 'Identifier of the worker holding the lock (hostname and process ID)';
@@ -0,0 +1,4 @@
+-- Migration: Index inbound_lead by source and status
+-- Serves per-source lead counts (GET /stats/leads/counts?source_id=) without scanning other sources
+
+CREATE INDEX IF NOT EXISTS idx_inbound_lead_source_id_status ON inbound_lead(source_id, status);
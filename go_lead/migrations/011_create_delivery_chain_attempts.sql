@@ -0,0 +1,34 @@
+-- Migration: Create delivery_chain_attempts table
+-- This table stores deliveries to the secondary endpoints of the forwarding chain.
+-- The primary Customer API endpoint (chain index 0) is tracked in delivery_attempt.
+
+CREATE TABLE IF NOT EXISTS delivery_chain_attempts (
+    id SERIAL PRIMARY KEY,
+    lead_id INTEGER NOT NULL REFERENCES inbound_lead(id) ON DELETE CASCADE,
+    chain_index INTEGER NOT NULL,
+    attempt_no INTEGER NOT NULL,
+    requested_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    response_status INTEGER,
+    response_body TEXT,
+    error_message TEXT,
+    success BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Secondary endpoints start at chain index 1; attempt numbers follow the primary delivery attempt
+ALTER TABLE delivery_chain_attempts ADD CONSTRAINT check_chain_index
+    CHECK (chain_index > 0);
+ALTER TABLE delivery_chain_attempts ADD CONSTRAINT check_chain_attempt_no
+    CHECK (attempt_no > 0);
+
+-- Create indexes for common query patterns
+CREATE INDEX idx_delivery_chain_attempts_lead_id ON delivery_chain_attempts(lead_id);
+
+-- Add unique constraint to prevent duplicate attempts for the same lead, endpoint and primary attempt
+CREATE UNIQUE INDEX idx_delivery_chain_attempts_lead_chain_attempt
+    ON delivery_chain_attempts(lead_id, chain_index, attempt_no);
+
+-- Add comment for documentation
+COMMENT ON TABLE delivery_chain_attempts IS 'Audit trail of lead deliveries to secondary forwarding chain endpoints';
+COMMENT ON COLUMN delivery_chain_attempts.chain_index IS 'Position in CUSTOMER_API_FORWARDING_CHAIN_FILE, starting at 1 (0 is the primary endpoint)';
+COMMENT ON COLUMN delivery_chain_attempts.attempt_no IS 'Primary delivery attempt during which the lead was forwarded';
@@ -0,0 +1,12 @@
+-- Migration: Create submission_throttle table
+-- Remembers when a sender last submitted a lead with a given contact, so that
+-- re-submissions of the same lead within the deduplication window can be rejected
+
+CREATE TABLE IF NOT EXISTS submission_throttle (
+    throttle_key VARCHAR(512) PRIMARY KEY,
+    last_submitted_at TIMESTAMP NOT NULL
+);
+
+-- Add comment for documentation
+COMMENT ON TABLE submission_throttle IS 'Last accepted submission per sender IP and normalized contact, used to throttle duplicate leads';
+COMMENT ON COLUMN submission_throttle.throttle_key IS 'Sender IP and normalized phone or email, e.g. 203.0.113.7:phone:4915112345678';
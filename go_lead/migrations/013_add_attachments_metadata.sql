@@ -0,0 +1,6 @@
+-- Migration: Add attachments_metadata to inbound_lead
+-- Base64 attachment fields are replaced by a metadata summary; the decoded bytes are never stored
+
+ALTER TABLE inbound_lead ADD COLUMN IF NOT EXISTS attachments_metadata JSONB;
+
+COMMENT ON COLUMN inbound_lead.attachments_metadata IS 'Size and detected MIME type per base64 attachment attribute, e.g. {"utility_bill": {"size_bytes": 48213, "mime_type": "application/pdf"}}';
@@ -0,0 +1,11 @@
+-- Migration: Allow repeated payload hashes for the deduplication grace period
+-- A payload received again after the grace period creates a new lead, so the hash is no
+-- longer unique; lookups filter by hash and creation time
+
+ALTER TABLE inbound_lead DROP CONSTRAINT IF EXISTS inbound_lead_payload_hash_key;
+
+DROP INDEX IF EXISTS idx_inbound_lead_payload_hash;
+
+CREATE INDEX IF NOT EXISTS idx_inbound_lead_payload_hash_created_at ON inbound_lead(payload_hash, created_at);
+
+COMMENT ON COLUMN inbound_lead.payload_hash IS 'SHA-256 hash of the raw payload; a repeated payload within DEDUPLICATION_GRACE_PERIOD_HOURS returns the existing lead';
@@ -0,0 +1,22 @@
+-- Migration: Create suppression_list table
+-- Phone numbers and email addresses whose leads are permanently rejected, e.g. from a
+-- do-not-call list; checked by the worker when VALIDATION_SUPPRESSION_LIST_ENABLED is set
+
+CREATE TABLE IF NOT EXISTS suppression_list (
+    id SERIAL PRIMARY KEY,
+    phone VARCHAR(50),
+    email VARCHAR(255),
+    reason VARCHAR(100),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT check_suppression_contact CHECK (phone IS NOT NULL OR email IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_suppression_list_phone ON suppression_list(phone);
+CREATE INDEX IF NOT EXISTS idx_suppression_list_email ON suppression_list(email);
+
+-- Add comment for documentation
+COMMENT ON TABLE suppression_list IS 'Contacts whose leads are rejected as SUPPRESSED (or with the entry reason)';
+COMMENT ON COLUMN suppression_list.phone IS 'Normalized phone number, as produced by the worker normalization';
+COMMENT ON COLUMN suppression_list.email IS 'Normalized (lowercased, trimmed) email address';
+COMMENT ON COLUMN suppression_list.reason IS 'Rejection reason stored on matching leads (default SUPPRESSED)';
@@ -0,0 +1,12 @@
+-- Migration: Add reconciliation_status to inbound_lead
+-- The worker periodically confirms that recently DELIVERED leads exist at the Customer API
+-- when CUSTOMER_API_READ_URL is set, and records the outcome here
+
+ALTER TABLE inbound_lead ADD COLUMN IF NOT EXISTS reconciliation_status VARCHAR(20);
+ALTER TABLE inbound_lead ADD COLUMN IF NOT EXISTS reconciled_at TIMESTAMP;
+
+ALTER TABLE inbound_lead ADD CONSTRAINT check_reconciliation_status
+    CHECK (reconciliation_status IS NULL OR reconciliation_status IN ('FOUND', 'MISSING'));
+
+COMMENT ON COLUMN inbound_lead.reconciliation_status IS 'Whether the Customer API read endpoint found the delivered lead (FOUND) or not (MISSING); null if not checked';
+COMMENT ON COLUMN inbound_lead.reconciled_at IS 'Time of the latest reconciliation check';
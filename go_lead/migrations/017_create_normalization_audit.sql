@@ -0,0 +1,21 @@
+-- Migration: Create normalization_audit table
+-- When PRIVACY_NORMALIZATION_AUDIT_ENABLED is set, the worker records every field value
+-- normalization changed, as a before/after snapshot for compliance
+
+CREATE TABLE IF NOT EXISTS normalization_audit (
+    id SERIAL PRIMARY KEY,
+    lead_id INTEGER NOT NULL REFERENCES inbound_lead(id) ON DELETE CASCADE,
+    field TEXT NOT NULL,
+    original_value JSONB,
+    normalized_value JSONB,
+    transformer_applied VARCHAR(100) NOT NULL,
+    normalized_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes for common query patterns
+CREATE INDEX idx_normalization_audit_lead_id ON normalization_audit(lead_id);
+
+-- Add comment for documentation
+COMMENT ON TABLE normalization_audit IS 'Audit trail of the field values changed by lead normalization';
+COMMENT ON COLUMN normalization_audit.field IS 'Dot-separated path of the field in the normalized payload';
+COMMENT ON COLUMN normalization_audit.transformer_applied IS 'Comma-separated normalization steps that changed the value, e.g. whitespace,value_alias';
@@ -0,0 +1,10 @@
+-- Migration: Add endpoint_used to delivery_attempt
+-- With CUSTOMER_API_FALLBACK_URL set, the worker delivers a lead to the fallback endpoint
+-- once enough attempts at the primary endpoint failed; each attempt records its endpoint
+
+ALTER TABLE delivery_attempt ADD COLUMN IF NOT EXISTS endpoint_used VARCHAR(20) NOT NULL DEFAULT 'primary';
+
+ALTER TABLE delivery_attempt ADD CONSTRAINT check_endpoint_used
+    CHECK (endpoint_used IN ('primary', 'fallback'));
+
+COMMENT ON COLUMN delivery_attempt.endpoint_used IS 'Customer API endpoint the attempt was sent to: primary or fallback';
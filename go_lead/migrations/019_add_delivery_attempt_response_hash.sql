@@ -0,0 +1,11 @@
+-- Migration: Add response_hash to delivery_attempt
+-- Stores the SHA-256 hash of the Customer API response body for auditing; the same hash
+-- for attempts of different leads can reveal responses replayed or cached by the API
+
+ALTER TABLE delivery_attempt ADD COLUMN IF NOT EXISTS response_hash VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_attempt_response_hash
+    ON delivery_attempt(response_hash)
+    WHERE response_hash IS NOT NULL;
+
+COMMENT ON COLUMN delivery_attempt.response_hash IS 'Hex-encoded SHA-256 hash of the response body; NULL if no response was received or hashing is disabled';
@@ -0,0 +1,9 @@
+-- Migration: Add response truncation to delivery_attempt
+-- Response bodies longer than MAX_ATTEMPT_RESPONSE_BYTES are stored truncated; the
+-- limit in effect is kept with the attempt so the audit trail shows what was cut
+
+ALTER TABLE delivery_attempt ADD COLUMN IF NOT EXISTS truncated BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE delivery_attempt ADD COLUMN IF NOT EXISTS truncated_at_bytes INT;
+
+COMMENT ON COLUMN delivery_attempt.truncated IS 'Whether response_body was cut to truncated_at_bytes';
+COMMENT ON COLUMN delivery_attempt.truncated_at_bytes IS 'Byte limit response_body was truncated to; NULL if the body was stored completely';